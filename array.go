@@ -358,6 +358,7 @@ func (a *arrayObject) expand(idx uint32) bool {
 				}
 				tl := int(targetLen)
 				newValues := make([]Value, tl, growCap(tl, len(a.values), cap(a.values)))
+				a.val.runtime.accountAlloc(int64(cap(newValues)) * usageValueSize)
 				copy(newValues, a.values)
 				a.values = newValues
 			}
@@ -527,9 +528,9 @@ func (a *arrayObject) exportToArrayOrSlice(dst reflect.Value, typ reflect.Type,
 			if p, ok := val.(*valueProperty); ok {
 				val = p.get(a.val)
 			}
-			err := r.toReflectValue(val, dst.Index(i), ctx)
+			err := r.toReflectValueAt(val, dst.Index(i), ctx, valueInt(i))
 			if err != nil {
-				return fmt.Errorf("could not convert array element %v to %v at %d: %w", val, typ, i, err)
+				return err
 			}
 		}
 		return nil
@@ -139,7 +139,11 @@ func (a *sparseArrayObject) getOwnPropIdx(idx valueInt) Value {
 	return a.baseObject.getOwnPropStr(idx.string())
 }
 
+// add inserts a new element, it's used right after a dense array has been converted.
 func (a *sparseArrayObject) add(idx uint32, val Value) {
+	if r := a.val.runtime; r.propertyLimits != nil {
+		r.checkNewElement(a)
+	}
 	i := a.findIdx(idx)
 	a.items = append(a.items, sparseArrayItem{})
 	copy(a.items[i+1:], a.items[i:])
@@ -169,6 +173,9 @@ func (a *sparseArrayObject) _setOwnIdx(idx uint32, val Value, throw bool) bool {
 			a.val.runtime.typeErrorResult(throw, "Cannot add property %d, object is not extensible", idx)
 			return false
 		}
+		if r := a.val.runtime; r.propertyLimits != nil {
+			r.checkNewElement(a)
+		}
 
 		if idx >= a.length {
 			if !a.setLengthInt(idx+1, throw) {
@@ -329,6 +336,10 @@ func (a *sparseArrayObject) _defineIdxProperty(idx uint32, desc PropertyDescript
 	i := a.findIdx(idx)
 	if i < len(a.items) && a.items[i].idx == idx {
 		existing = a.items[i].value
+	} else if a.extensible {
+		if r := a.val.runtime; r.propertyLimits != nil {
+			r.checkNewElement(a)
+		}
 	}
 	prop, ok := a.baseObject._defineOwnProperty(unistring.String(strconv.FormatUint(uint64(idx), 10)), existing, desc, throw)
 	if ok {
@@ -477,9 +488,9 @@ func (a *sparseArrayObject) exportToArrayOrSlice(dst reflect.Value, typ reflect.
 			if idx >= l {
 				break
 			}
-			err := r.toReflectValue(val, dst.Index(idx), ctx)
+			err := r.toReflectValueAt(val, dst.Index(idx), ctx, valueInt(idx))
 			if err != nil {
-				return fmt.Errorf("could not convert array element %v to %v at %d: %w", item.value, typ, idx, err)
+				return err
 			}
 		}
 		return nil
@@ -41,6 +41,71 @@ func TestArrayCanonicalIndex(t *testing.T) {
 	testScript(SCRIPT, _undefined, t)
 }
 
+func TestArrayNonWritableLength(t *testing.T) {
+	const SCRIPT = `
+	function nonWritable(a) {
+		Object.defineProperty(a, "length", {writable: false});
+		return a;
+	}
+
+	var a = nonWritable([1, 2, 3]);
+	assert.throws(TypeError, function() { a.shift(); }, "shift");
+	assert.sameValue(a.length, 3, "shift: length");
+	assert(compareArray(a, [2, 3, undefined]), "shift: elements");
+	assert(!a.hasOwnProperty(2), "shift: last element deleted");
+
+	a = nonWritable([1, 2, 3]);
+	assert.throws(TypeError, function() { a.unshift(0); }, "unshift");
+	assert(compareArray(a, [1, 2, 3]), "unshift: unchanged");
+
+	a = nonWritable([1, 2, 3]);
+	assert.throws(TypeError, function() { a.splice(0, 0, 9); }, "splice insert");
+	assert(compareArray(a, [1, 2, 3]), "splice insert: unchanged");
+	assert(!a.hasOwnProperty(3), "splice insert: no element past length");
+
+	a = nonWritable([1, 2, 3]);
+	assert.throws(TypeError, function() { a.splice(0, 2, 9); }, "splice delete");
+	assert.sameValue(a.length, 3, "splice delete: length");
+	assert(compareArray(a, [9, 3, undefined]), "splice delete: elements");
+
+	assert.throws(TypeError, function() { nonWritable([]).shift(); }, "shift empty");
+	assert.throws(TypeError, function() { nonWritable([1]).push(2); }, "push");
+	assert.throws(TypeError, function() { nonWritable([1]).pop(); }, "pop");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestArrayMaxLength(t *testing.T) {
+	const SCRIPT = `
+	var a = [];
+	a[4294967294] = 1;
+	assert.sameValue(a.length, 4294967295, "max index");
+	a[4294967295] = 2;
+	assert.sameValue(a.length, 4294967295, "not an index");
+	assert.throws(RangeError, function() { a.length = 4294967296; });
+
+	a = [];
+	a.length = 4294967295;
+	assert.throws(RangeError, function() { a.push(1); }, "push");
+	assert.sameValue(a[4294967295], 1, "push sets the property before failing");
+	assert.sameValue(a.length, 4294967295, "push: length");
+
+	a = [];
+	a.length = 4294967295;
+	assert.throws(RangeError, function() { a.splice(4294967294, 0, 1, 2); }, "splice");
+	assert.sameValue(a.length, 4294967295, "splice: length");
+
+	a = [0, 1, 2, 3, 4, 5];
+	Object.defineProperty(a, 3, {value: 3, configurable: false});
+	a.length = 1;
+	assert.sameValue(a.length, 4, "truncation stops at a non-configurable element");
+	assert(compareArray(a, [0, 1, 2, 3]), "truncation");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func BenchmarkArrayGetStr(b *testing.B) {
 	b.StopTimer()
 	r := New()
@@ -300,6 +300,16 @@ type (
 		Meta, Property *Identifier
 		Idx            file.Idx
 	}
+
+	// CustomInstructionExpression is never produced by the parser. It can be inserted into the AST by
+	// an embedder to evaluate the arguments and pass the results to a custom VM instruction. The Instruction
+	// is opaque to this package, see goja.CustomInstruction for the details.
+	CustomInstructionExpression struct {
+		Instruction  interface{}
+		ArgumentList []Expression
+		From         file.Idx
+		To           file.Idx
+	}
 )
 
 // _expressionNode
@@ -339,6 +349,8 @@ func (*Binding) _expressionNode()               {}
 func (*PropertyShort) _expressionNode() {}
 func (*PropertyKeyed) _expressionNode() {}
 
+func (*CustomInstructionExpression) _expressionNode() {}
+
 // ========= //
 // Statement //
 // ========= //
@@ -461,6 +473,8 @@ type (
 		List []*Binding
 	}
 
+	// LexicalDeclaration is a let, const, using or await using declaration. For the latter two Token is
+	// token.USING or token.AWAIT_USING and Idx points to "using" or "await" respectively.
 	LexicalDeclaration struct {
 		Idx   file.Idx
 		Token token.Token
@@ -586,6 +600,7 @@ type (
 	ForDeclaration struct {
 		Idx     file.Idx
 		IsConst bool
+		Using   token.Token // token.USING or token.AWAIT_USING for a using declaration (IsConst is also set)
 		Target  BindingTarget
 	}
 
@@ -764,6 +779,9 @@ func (self *MetaProperty) Idx1() file.Idx {
 	return self.Property.Idx1()
 }
 
+func (self *CustomInstructionExpression) Idx0() file.Idx { return self.From }
+func (self *CustomInstructionExpression) Idx1() file.Idx { return self.To }
+
 func (self *BadStatement) Idx1() file.Idx        { return self.To }
 func (self *BlockStatement) Idx1() file.Idx      { return self.RightBrace + 1 }
 func (self *BranchStatement) Idx1() file.Idx     { return self.Idx }
@@ -0,0 +1,404 @@
+package goja
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// CBOR is a BinaryCodec implementing the Concise Binary Object Representation (RFC 8949).
+//
+// The encoder uses the preferred serialisation: the shortest form of integers, lengths and floating point numbers
+// that preserves the value. BigInts are always encoded as bignums (tags 2 and 3) and Dates as epoch-based date/time
+// (tag 1). The decoder accepts indefinite-length items, both standard date/time tags and ignores all other tags.
+var CBOR BinaryCodec = cborCodec{}
+
+type cborCodec struct{}
+
+type cborEncoder struct {
+	buf []byte
+}
+
+func (cborCodec) Marshal(v interface{}) ([]byte, error) {
+	e := &cborEncoder{}
+	if err := e.encode(v, 0); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (e *cborEncoder) head(major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		e.buf = append(e.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, major|25)
+		e.buf = appendUint16(e.buf, uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = append(e.buf, major|26)
+		e.buf = appendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, major|27)
+		e.buf = appendUint64(e.buf, n)
+	}
+}
+
+func (e *cborEncoder) int(n int64) {
+	if n >= 0 {
+		e.head(0, uint64(n))
+	} else {
+		e.head(1, uint64(-1-n))
+	}
+}
+
+// float16Bits returns the IEEE 754 half-precision representation of f if it's exact.
+func float16Bits(f float32) (uint16, bool) {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+	switch {
+	case exp == 0xff:
+		if mant != 0 {
+			return 0x7e00, true
+		}
+		return sign | 0x7c00, true
+	case exp == 0 && mant == 0:
+		return sign, true
+	}
+	e := exp - 127
+	switch {
+	case e > 15:
+		return 0, false
+	case e >= -14:
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(e+15)<<10 | uint16(mant>>13), true
+	case e >= -24:
+		s := mant | 0x800000
+		shift := uint(-(e + 1))
+		if s&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(s>>shift), true
+	}
+	return 0, false
+}
+
+func (e *cborEncoder) float(f float64) {
+	if f32 := float32(f); float64(f32) == f || math.IsNaN(f) {
+		if h, ok := float16Bits(f32); ok {
+			e.buf = append(e.buf, 0xf9)
+			e.buf = appendUint16(e.buf, h)
+			return
+		}
+		e.buf = append(e.buf, 0xfa)
+		e.buf = appendUint32(e.buf, math.Float32bits(f32))
+		return
+	}
+	e.buf = append(e.buf, 0xfb)
+	e.buf = appendUint64(e.buf, math.Float64bits(f))
+}
+
+func (e *cborEncoder) encode(v interface{}, depth int) error {
+	if depth > maxBinaryDepth {
+		return errors.New("cbor: maximum nesting depth exceeded")
+	}
+	switch v := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xf6)
+	case bool:
+		if v {
+			e.buf = append(e.buf, 0xf5)
+		} else {
+			e.buf = append(e.buf, 0xf4)
+		}
+	case int:
+		e.int(int64(v))
+	case int64:
+		e.int(v)
+	case uint64:
+		e.head(0, v)
+	case float64:
+		e.float(v)
+	case *big.Int:
+		if v.Sign() >= 0 {
+			e.head(6, 2)
+			e.bytes(2, v.Bytes())
+		} else {
+			e.head(6, 3)
+			e.bytes(2, new(big.Int).Sub(new(big.Int).Neg(v), big.NewInt(1)).Bytes())
+		}
+	case string:
+		e.head(3, uint64(len(v)))
+		e.buf = append(e.buf, v...)
+	case []byte:
+		e.bytes(2, v)
+	case time.Time:
+		e.head(6, 1)
+		if ms := v.UnixNano() / 1e6; ms%1000 == 0 {
+			e.int(ms / 1000)
+		} else {
+			e.float(float64(ms) / 1000)
+		}
+	case []interface{}:
+		e.head(4, uint64(len(v)))
+		for _, item := range v {
+			if err := e.encode(item, depth+1); err != nil {
+				return err
+			}
+		}
+	case []BinaryMapEntry:
+		e.head(5, uint64(len(v)))
+		for _, entry := range v {
+			if err := e.encode(entry.Key, depth+1); err != nil {
+				return err
+			}
+			if err := e.encode(entry.Value, depth+1); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		e.head(5, uint64(len(v)))
+		for _, key := range keys {
+			e.head(3, uint64(len(key)))
+			e.buf = append(e.buf, key...)
+			if err := e.encode(v[key], depth+1); err != nil {
+				return err
+			}
+		}
+	case Value:
+		if v != _undefined {
+			return fmt.Errorf("cbor: unsupported value %s", v.String())
+		}
+		e.buf = append(e.buf, 0xf7)
+	default:
+		return fmt.Errorf("cbor: unsupported type %T", v)
+	}
+	return nil
+}
+
+func (e *cborEncoder) bytes(major byte, b []byte) {
+	e.head(major, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) errorf(format string, args ...interface{}) {
+	panic(&binaryDecodeError{msg: fmt.Sprintf("cbor: "+format+" at offset %d", append(args, d.pos)...)})
+}
+
+func (cborCodec) Unmarshal(data []byte) (v interface{}, err error) {
+	d := &cborDecoder{data: data}
+	defer func() {
+		if x := recover(); x != nil {
+			if e, ok := x.(*binaryDecodeError); ok {
+				v, err = nil, e
+				return
+			}
+			panic(x)
+		}
+	}()
+	v = d.value(0)
+	if d.pos < len(d.data) {
+		d.errorf("unexpected data after the top-level item")
+	}
+	return
+}
+
+func (d *cborDecoder) read(n uint64) []byte {
+	if n > uint64(len(d.data)-d.pos) {
+		d.errorf("unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b
+}
+
+// head reads the initial byte and the argument of an item. For indefinite-length items indefinite is true.
+func (d *cborDecoder) head() (major, info byte, arg uint64, indefinite bool) {
+	b := d.read(1)[0]
+	major, info = b>>5, b&0x1f
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info == 24:
+		arg = uint64(d.read(1)[0])
+	case info == 25:
+		arg = uint64(binary.BigEndian.Uint16(d.read(2)))
+	case info == 26:
+		arg = uint64(binary.BigEndian.Uint32(d.read(4)))
+	case info == 27:
+		arg = binary.BigEndian.Uint64(d.read(8))
+	case info == 31 && major >= 2 && major <= 5:
+		indefinite = true
+	default:
+		d.pos--
+		d.errorf("invalid initial byte 0x%02x", b)
+	}
+	return
+}
+
+func (d *cborDecoder) isBreak() bool {
+	if d.pos >= len(d.data) {
+		d.errorf("unexpected end of data")
+	}
+	if d.data[d.pos] == 0xff {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func (d *cborDecoder) bytes(major byte, arg uint64, indefinite bool) []byte {
+	if !indefinite {
+		return d.read(arg)
+	}
+	var res []byte
+	for !d.isBreak() {
+		m, _, n, ind := d.head()
+		if m != major || ind {
+			d.errorf("invalid chunk of an indefinite-length string")
+		}
+		res = append(res, d.read(n)...)
+	}
+	return res
+}
+
+func (d *cborDecoder) value(depth int) interface{} {
+	if depth > maxBinaryDepth {
+		d.errorf("maximum nesting depth exceeded")
+	}
+	major, info, arg, indefinite := d.head()
+	switch major {
+	case 0:
+		if arg <= math.MaxInt64 {
+			return int64(arg)
+		}
+		return arg
+	case 1:
+		if arg <= math.MaxInt64 {
+			return -1 - int64(arg)
+		}
+		n := new(big.Int).SetUint64(arg)
+		return n.Sub(n.Neg(n), big.NewInt(1))
+	case 2:
+		return append([]byte(nil), d.bytes(2, arg, indefinite)...)
+	case 3:
+		return string(d.bytes(3, arg, indefinite))
+	case 4:
+		var res []interface{}
+		if indefinite {
+			for !d.isBreak() {
+				res = append(res, d.value(depth+1))
+			}
+			if res == nil {
+				res = []interface{}{}
+			}
+			return res
+		}
+		if arg > uint64(len(d.data)-d.pos) {
+			d.errorf("array is too long")
+		}
+		res = make([]interface{}, arg)
+		for i := range res {
+			res[i] = d.value(depth + 1)
+		}
+		return res
+	case 5:
+		var res []BinaryMapEntry
+		if indefinite {
+			res = []BinaryMapEntry{}
+			for !d.isBreak() {
+				key := d.value(depth + 1)
+				res = append(res, BinaryMapEntry{Key: key, Value: d.value(depth + 1)})
+			}
+			return res
+		}
+		if arg > uint64(len(d.data)-d.pos)/2 {
+			d.errorf("map is too long")
+		}
+		res = make([]BinaryMapEntry, arg)
+		for i := range res {
+			res[i].Key = d.value(depth + 1)
+			res[i].Value = d.value(depth + 1)
+		}
+		return res
+	case 6:
+		pos := d.pos
+		content := d.value(depth + 1)
+		switch arg {
+		case 0:
+			if s, ok := content.(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					return t
+				}
+			}
+			d.pos = pos
+			d.errorf("invalid date/time string")
+		case 1:
+			var ms float64
+			switch n := content.(type) {
+			case int64:
+				ms = float64(n) * 1000
+			case float64:
+				ms = math.Round(n * 1000)
+			default:
+				d.pos = pos
+				d.errorf("invalid epoch-based date/time")
+			}
+			if math.IsNaN(ms) || math.Abs(ms) > maxTime {
+				d.pos = pos
+				d.errorf("invalid epoch-based date/time")
+			}
+			return timeFromMsec(int64(ms))
+		case 2, 3:
+			b, ok := content.([]byte)
+			if !ok {
+				d.pos = pos
+				d.errorf("invalid bignum")
+			}
+			n := new(big.Int).SetBytes(b)
+			if arg == 3 {
+				n.Sub(n.Neg(n), big.NewInt(1))
+			}
+			return n
+		}
+		return content
+	}
+	switch info {
+	case 20:
+		return false
+	case 21:
+		return true
+	case 22:
+		return nil
+	case 23:
+		return _undefined
+	case 25:
+		return float16ToFloat64(uint16(arg))
+	case 26:
+		return float64(math.Float32frombits(uint32(arg)))
+	case 27:
+		return math.Float64frombits(arg)
+	}
+	d.errorf("unsupported simple value %d", arg)
+	return nil
+}
@@ -0,0 +1,332 @@
+package goja
+
+import (
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/dop251/goja/unistring"
+)
+
+// BinaryCodec is a binary serialisation format for JavaScript values, such as CBOR or MessagePack. It's used by
+// Runtime.MarshalBinary(), Runtime.UnmarshalBinary() and Runtime.NewBinaryCodecObject().
+//
+// Codecs operate on a tree of the following Go values:
+//
+//   - nil (null) and Undefined() (undefined)
+//   - bool
+//   - int64 for the numbers that are integers and float64 for the other ones. Unmarshal may also return uint64.
+//   - *big.Int (BigInt)
+//   - string
+//   - []byte (Uint8Array, ArrayBuffer and DataView, decoded as Uint8Array)
+//   - time.Time (Date)
+//   - []interface{} (Array and Set, as well as typed arrays other than Uint8Array)
+//   - []BinaryMapEntry (Map and other objects, decoded as an Object if all keys are strings and as a Map
+//     otherwise). Unmarshal may also return map[string]interface{} or map[interface{}]interface{}, which is useful
+//     for adapting third-party implementations.
+//
+// The slices passed to Marshal() may refer to the memory of ArrayBuffers, so they must not be retained.
+type BinaryCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// BinaryMapEntry is a key-value pair of a map, see BinaryCodec.
+type BinaryMapEntry struct {
+	Key, Value interface{}
+}
+
+const maxBinaryDepth = 1000
+
+type binaryEncodeCtx struct {
+	r     *Runtime
+	stack objectStack
+}
+
+func (ctx *binaryEncodeCtx) enter(o *Object) {
+	if ctx.stack.contains(o) {
+		panic(ctx.r.NewTypeError("Converting circular structure"))
+	}
+	if ctx.stack.len() >= maxBinaryDepth {
+		panic(ctx.r.newError(ctx.r.global.RangeError, "Maximum nesting depth exceeded"))
+	}
+	ctx.stack.push(o)
+}
+
+func (ctx *binaryEncodeCtx) leave() {
+	ctx.stack.pop()
+}
+
+func numberToBinary(f float64) interface{} {
+	if f == math.Trunc(f) && math.Abs(f) <= maxInt && !(f == 0 && math.Signbit(f)) {
+		return int64(f)
+	}
+	return f
+}
+
+func (ctx *binaryEncodeCtx) toBinary(v Value) interface{} {
+	r := ctx.r
+	switch v := v.(type) {
+	case valueUndefined:
+		return _undefined
+	case valueNull:
+		return nil
+	case valueBool:
+		return bool(v)
+	case valueInt:
+		return int64(v)
+	case valueFloat:
+		return numberToBinary(float64(v))
+	case valueString:
+		return v.String()
+	case *valueBigInt:
+		return new(big.Int).Set((*big.Int)(v))
+	case *Object:
+		return ctx.objectToBinary(v)
+	}
+	panic(r.NewTypeError("Cannot serialise %s", v.String()))
+}
+
+func (ctx *binaryEncodeCtx) objectToBinary(o *Object) interface{} {
+	r := ctx.r
+	switch obj := o.self.(type) {
+	case *primitiveValueObject:
+		return ctx.toBinary(obj.pValue)
+	case *stringObject:
+		return o.toString().String()
+	case *dateObject:
+		if !obj.isSet() {
+			panic(r.newError(r.global.RangeError, "Invalid time value"))
+		}
+		return obj.time()
+	case *arrayBufferObject, *dataViewObject:
+		return r.bufferSourceBytes(o)
+	case *typedArrayObject:
+		switch obj.typedArray.(type) {
+		case *uint8Array, *uint8ClampedArray:
+			return r.bufferSourceBytes(o)
+		}
+		obj.viewedArrayBuf.ensureNotDetached(true)
+		res := make([]interface{}, obj.length)
+		for i := range res {
+			res[i] = ctx.toBinary(obj.typedArray.get(obj.offset + i))
+		}
+		return res
+	case *mapObject:
+		ctx.enter(o)
+		defer ctx.leave()
+		res := make([]BinaryMapEntry, 0, obj.m.size)
+		iter := obj.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			res = append(res, BinaryMapEntry{Key: ctx.toBinary(entry.key), Value: ctx.toBinary(entry.value)})
+		}
+		return res
+	case *setObject:
+		ctx.enter(o)
+		defer ctx.leave()
+		res := make([]interface{}, 0, obj.m.size)
+		iter := obj.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			res = append(res, ctx.toBinary(entry.key))
+		}
+		return res
+	}
+	if _, ok := o.self.assertCallable(); ok {
+		panic(r.NewTypeError("Cannot serialise a function"))
+	}
+	ctx.enter(o)
+	defer ctx.leave()
+	if isArray(o) {
+		l := toLength(o.self.getStr("length", nil))
+		res := make([]interface{}, l)
+		for i := range res {
+			res[i] = ctx.toBinary(nilSafe(o.self.getIdx(valueInt(int64(i)), nil)))
+		}
+		return res
+	}
+	keys := o.self.stringKeys(false, nil)
+	res := make([]BinaryMapEntry, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, BinaryMapEntry{Key: key.String(), Value: ctx.toBinary(nilSafe(o.get(key, nil)))})
+	}
+	return res
+}
+
+func (r *Runtime) fromBinary(v interface{}, depth int) Value {
+	if depth > maxBinaryDepth {
+		panic(r.newError(r.global.RangeError, "Maximum nesting depth exceeded"))
+	}
+	switch v := v.(type) {
+	case nil:
+		return _null
+	case Value:
+		if v == _undefined {
+			return v
+		}
+	case bool:
+		return r.toBoolean(v)
+	case int64:
+		if v >= -maxInt && v <= maxInt {
+			return intToValue(v)
+		}
+		return (*valueBigInt)(big.NewInt(v))
+	case uint64:
+		if v <= maxInt {
+			return intToValue(int64(v))
+		}
+		return (*valueBigInt)(new(big.Int).SetUint64(v))
+	case float64:
+		return floatToValue(v)
+	case *big.Int:
+		return (*valueBigInt)(new(big.Int).Set(v))
+	case string:
+		return newStringValue(v)
+	case []byte:
+		return r.newUint8ArrayFromBytes(append([]byte(nil), v...))
+	case time.Time:
+		return r.newDateObject(v, true, r.global.DatePrototype)
+	case []interface{}:
+		values := make([]Value, len(v))
+		for i, item := range v {
+			values[i] = r.fromBinary(item, depth+1)
+		}
+		return r.newArrayValues(values)
+	case []BinaryMapEntry:
+		for _, entry := range v {
+			if _, ok := entry.Key.(string); !ok {
+				m := r.toConstructor(r.global.Map)(nil, r.global.Map)
+				mo := m.self.(*mapObject)
+				for _, entry := range v {
+					mo.m.set(r.fromBinary(entry.Key, depth+1), r.fromBinary(entry.Value, depth+1))
+				}
+				return m
+			}
+		}
+		o := r.NewObject()
+		for _, entry := range v {
+			createDataPropertyOrThrow(o, newStringValue(entry.Key.(string)), r.fromBinary(entry.Value, depth+1))
+		}
+		return o
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		o := r.NewObject()
+		for _, key := range keys {
+			o.self._putProp(unistring.NewFromString(key), r.fromBinary(v[key], depth+1), true, true, true)
+		}
+		return o
+	case map[interface{}]interface{}:
+		entries := make([]BinaryMapEntry, 0, len(v))
+		for key, value := range v {
+			entries = append(entries, BinaryMapEntry{Key: key, Value: value})
+		}
+		return r.fromBinary(entries, depth)
+	}
+	return r.ToValue(v)
+}
+
+type binaryDecodeError struct {
+	msg string
+}
+
+func (e *binaryDecodeError) Error() string {
+	return e.msg
+}
+
+func appendUint16(b []byte, n uint16) []byte {
+	return append(b, byte(n>>8), byte(n))
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	return append(b, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	return append(b, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32), byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func (r *Runtime) newUint8ArrayFromBytes(data []byte) *Object {
+	buf := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
+	buf.data = data
+	return r.toConstructor(r.global.Uint8Array)([]Value{buf.val}, r.global.Uint8Array)
+}
+
+// bufferSourceBytes returns the bytes of an ArrayBuffer or the bytes viewed by a typed array or a DataView.
+func (r *Runtime) bufferSourceBytes(v Value) []byte {
+	if o, ok := v.(*Object); ok {
+		switch obj := o.self.(type) {
+		case *arrayBufferObject:
+			obj.ensureNotDetached(true)
+			return obj.data
+		case *dataViewObject:
+			obj.viewedArrayBuf.ensureNotDetached(true)
+			return obj.viewedArrayBuf.data[obj.byteOffset : obj.byteOffset+obj.byteLen]
+		case *typedArrayObject:
+			obj.viewedArrayBuf.ensureNotDetached(true)
+			return obj.viewedArrayBuf.data[obj.offset*obj.elemSize : (obj.offset+obj.length)*obj.elemSize]
+		}
+	}
+	panic(r.NewTypeError("The argument must be an ArrayBuffer, a typed array or a DataView"))
+}
+
+// MarshalBinary serialises v using the codec. See BinaryCodec for the supported types. Other values (e.g.
+// functions and symbols) as well as circular structures cause a TypeError, which is returned as *Exception
+// (together with any exception thrown by a getter); errors returned by the codec are returned as is.
+func (r *Runtime) MarshalBinary(codec BinaryCodec, v Value) (data []byte, err error) {
+	var model interface{}
+	err = r.runWrapped(func() {
+		ctx := &binaryEncodeCtx{r: r}
+		model = ctx.toBinary(nilSafe(v))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codec.Marshal(model)
+}
+
+// UnmarshalBinary deserialises data using the codec. See BinaryCodec for the resulting types. Numbers which are
+// integers outside the safe integer range are converted into BigInts, so that their values are not lost.
+func (r *Runtime) UnmarshalBinary(codec BinaryCodec, data []byte) (Value, error) {
+	model, err := codec.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var res Value
+	err = r.runWrapped(func() {
+		res = r.fromBinary(model, 0)
+	})
+	return res, err
+}
+
+// NewBinaryCodecObject creates an object with two methods which use the codec: encode(value) which returns a
+// Uint8Array and decode(data) which accepts an ArrayBuffer, a typed array or a DataView:
+//
+//	vm.Set("CBOR", vm.NewBinaryCodecObject(goja.CBOR))
+//
+//	const bytes = CBOR.encode({id: 42n, tags: new Set(["a"]), created: new Date()});
+//	const copy = CBOR.decode(bytes);
+//
+// Codec errors are thrown as TypeErrors.
+func (r *Runtime) NewBinaryCodecObject(codec BinaryCodec) *Object {
+	o := r.NewObject()
+	r.putMethod(o, "encode", func(call FunctionCall) Value {
+		ctx := &binaryEncodeCtx{r: r}
+		data, err := codec.Marshal(ctx.toBinary(call.Argument(0)))
+		if err != nil {
+			panic(r.NewTypeError(err.Error()))
+		}
+		return r.newUint8ArrayFromBytes(data)
+	}, 1)
+	r.putMethod(o, "decode", func(call FunctionCall) Value {
+		model, err := codec.Unmarshal(r.bufferSourceBytes(call.Argument(0)))
+		if err != nil {
+			panic(r.NewTypeError(err.Error()))
+		}
+		return r.fromBinary(model, 0)
+	}, 1)
+	return o
+}
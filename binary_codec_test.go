@@ -0,0 +1,213 @@
+package goja
+
+import (
+	"bytes"
+	hexenc "encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestCBORVectors(t *testing.T) {
+	two64, _ := new(big.Int).SetString("18446744073709551616", 10)
+	tests := []struct {
+		v   interface{}
+		hex string
+	}{
+		{int64(0), "00"},
+		{int64(23), "17"},
+		{int64(24), "1818"},
+		{int64(100000), "1a000186a0"},
+		{int64(-1), "20"},
+		{int64(-1000), "3903e7"},
+		{1.5, "f93e00"},
+		{100000.5, "fa47c35040"},
+		{1.1, "fb3ff199999999999a"},
+		{false, "f4"},
+		{nil, "f6"},
+		{_undefined, "f7"},
+		{"IETF", "6449455446"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{two64, "c249010000000000000000"},
+		{[]interface{}{int64(1), []interface{}{int64(2), int64(3)}}, "8201820203"},
+		{[]BinaryMapEntry{{Key: "a", Value: int64(1)}, {Key: "b", Value: []interface{}{int64(2), int64(3)}}}, "a26161016162820203"},
+		{time.Unix(1363896240, 0), "c11a514b67b0"},
+	}
+	for _, tc := range tests {
+		data, err := CBOR.Marshal(tc.v)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.v, err)
+		}
+		if h := hexenc.EncodeToString(data); h != tc.hex {
+			t.Fatalf("%v: expected %s, got %s", tc.v, tc.hex, h)
+		}
+	}
+
+	for _, h := range []string{"5f42010243030405ff", "9f018202039f0405ffff", "bf61610161629f0203ffff"} {
+		data, _ := hexenc.DecodeString(h)
+		if _, err := CBOR.Unmarshal(data); err != nil {
+			t.Fatalf("%s: %v", h, err)
+		}
+	}
+
+	for _, h := range []string{"", "18", "1a0001", "62616", "9b00000000ffffffff", "0000", "f8", "ff"} {
+		data, _ := hexenc.DecodeString(h)
+		if _, err := CBOR.Unmarshal(data); err == nil {
+			t.Fatalf("%q: expected an error", h)
+		}
+	}
+}
+
+func TestMessagePackVectors(t *testing.T) {
+	tests := []struct {
+		v   interface{}
+		hex string
+	}{
+		{int64(0), "00"},
+		{int64(127), "7f"},
+		{int64(128), "cc80"},
+		{int64(-32), "e0"},
+		{int64(-33), "d0df"},
+		{int64(65536), "ce00010000"},
+		{1.5, "cb3ff8000000000000"},
+		{true, "c3"},
+		{nil, "c0"},
+		{"abc", "a3616263"},
+		{[]byte{1, 2}, "c4020102"},
+		{[]interface{}{int64(1), "a"}, "9201a161"},
+		{[]BinaryMapEntry{{Key: "a", Value: int64(1)}}, "81a16101"},
+		{time.Unix(1, 0), "d6ff00000001"},
+		{time.Unix(1, 5), "d7ff0000001400000001"},
+	}
+	for _, tc := range tests {
+		data, err := MessagePack.Marshal(tc.v)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.v, err)
+		}
+		if h := hexenc.EncodeToString(data); h != tc.hex {
+			t.Fatalf("%v: expected %s, got %s", tc.v, tc.hex, h)
+		}
+	}
+
+	if _, err := MessagePack.Marshal(new(big.Int).Lsh(big.NewInt(1), 64)); err == nil {
+		t.Fatal("expected an error for a BigInt out of range")
+	}
+
+	for _, h := range []string{"", "cc", "a3616", "dd0000ffff", "c1", "d40101", "0000"} {
+		data, _ := hexenc.DecodeString(h)
+		if _, err := MessagePack.Unmarshal(data); err == nil {
+			t.Fatalf("%q: expected an error", h)
+		}
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	const SCRIPT = `
+	({
+		n: 42, neg: -7, f: 0.5, big: 12345678901234567890n, s: "héllo 😀", t: true, nul: null,
+		arr: [1, [2, "x"], {}], date: new Date(1600000000123), bytes: new Uint8Array([1, 2, 3]),
+		nested: {a: {b: {c: []}}}
+	})
+	`
+	for _, codec := range []BinaryCodec{CBOR, MessagePack} {
+		vm := New()
+		v, err := vm.RunString(SCRIPT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := vm.MarshalBinary(codec, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := vm.UnmarshalBinary(codec, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm.Set("orig", v)
+		vm.Set("res", res)
+		_, err = vm.RunString(TESTLIB + `
+		assert.sameValue(res.n, 42);
+		assert.sameValue(res.neg, -7);
+		assert.sameValue(res.f, 0.5);
+		assert.sameValue(res.big, 12345678901234567890n);
+		assert.sameValue(res.s, orig.s);
+		assert.sameValue(res.t, true);
+		assert.sameValue(res.nul, null);
+		assert(compareArray(Object.keys(res), Object.keys(orig)), "keys");
+		assert.sameValue(JSON.stringify(res.arr), JSON.stringify(orig.arr));
+		assert(res.date instanceof Date, "date");
+		assert.sameValue(res.date.getTime(), 1600000000123);
+		assert(res.bytes instanceof Uint8Array, "bytes");
+		assert(compareArray(Array.from(res.bytes), [1, 2, 3]), "bytes content");
+		assert.sameValue(JSON.stringify(res.nested), '{"a":{"b":{"c":[]}}}');
+		`)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBinaryCodecObject(t *testing.T) {
+	const SCRIPT = `
+	const m = new Map([[1, "one"], ["k", [true]]]);
+	let d = CBOR.decode(CBOR.encode(m));
+	assert(d instanceof Map, "map");
+	assert.sameValue(d.get(1), "one");
+	assert(compareArray(d.get("k"), [true]), "map value");
+
+	d = CBOR.decode(CBOR.encode(new Set([1, "a"])));
+	assert(compareArray(d, [1, "a"]), "set");
+
+	assert.sameValue(CBOR.decode(CBOR.encode(undefined)), undefined);
+	assert.sameValue(MsgPack.decode(MsgPack.encode(undefined)), null);
+	assert.sameValue(CBOR.decode(CBOR.encode(new String("s"))), "s");
+	assert.sameValue(CBOR.decode(CBOR.encode(-0)), -0);
+	assert.sameValue(CBOR.decode(CBOR.encode(NaN)), NaN);
+	assert.sameValue(CBOR.decode(CBOR.encode(2n ** 100n)), 2n ** 100n);
+	assert.sameValue(CBOR.decode(CBOR.encode(-(2n ** 100n))), -(2n ** 100n));
+	assert.sameValue(CBOR.decode(CBOR.encode(2 ** 60)), 2 ** 60);
+	assert.sameValue(CBOR.decode(new Uint8Array([0x1b, 0, 0x40, 0, 0, 0, 0, 0, 1])), 2n ** 54n + 1n);
+
+	const bytes = CBOR.encode([1, 2]);
+	assert(bytes instanceof Uint8Array, "encode result");
+	assert(compareArray(Array.from(bytes), [0x82, 1, 2]), "encoded bytes");
+	assert(compareArray(CBOR.decode(bytes.buffer), [1, 2]), "decode ArrayBuffer");
+	assert(compareArray(CBOR.decode(new DataView(bytes.buffer)), [1, 2]), "decode DataView");
+	assert(compareArray(CBOR.decode(CBOR.encode(new Int16Array([-1, 300]))), [-1, 300]), "typed array");
+
+	assert.throws(TypeError, () => CBOR.encode(function() {}));
+	assert.throws(TypeError, () => CBOR.encode(Symbol()));
+	assert.throws(TypeError, () => CBOR.encode({a: {toJSON() {}}, f: () => 1}));
+	const circular = {};
+	circular.self = circular;
+	assert.throws(TypeError, () => CBOR.encode(circular));
+	assert.throws(TypeError, () => MsgPack.encode(2n ** 64n));
+	assert.throws(TypeError, () => CBOR.decode(new Uint8Array([0x18])));
+	assert.throws(TypeError, () => CBOR.decode("abc"));
+	assert.throws(RangeError, () => CBOR.encode(new Date(NaN)));
+
+	const shared = [1];
+	assert.sameValue(JSON.stringify(MsgPack.decode(MsgPack.encode([shared, shared]))), "[[1],[1]]");
+	`
+	r := New()
+	r.Set("CBOR", r.NewBinaryCodecObject(CBOR))
+	r.Set("MsgPack", r.NewBinaryCodecObject(MessagePack))
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestMarshalBinaryErrors(t *testing.T) {
+	vm := New()
+	v, _ := vm.RunString("({f() {}})")
+	if _, err := vm.MarshalBinary(CBOR, v); err == nil {
+		t.Fatal("expected an error")
+	} else if _, ok := err.(*Exception); !ok {
+		t.Fatalf("unexpected error type %T", err)
+	}
+	if _, err := vm.UnmarshalBinary(MessagePack, []byte{0xc1}); err == nil {
+		t.Fatal("expected an error")
+	}
+	data, err := vm.MarshalBinary(CBOR, vm.ToValue("x"))
+	if err != nil || !bytes.Equal(data, []byte{0x61, 'x'}) {
+		t.Fatalf("unexpected result %x, %v", data, err)
+	}
+}
@@ -0,0 +1,347 @@
+package goja
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// MessagePack is a BinaryCodec implementing the MessagePack format (https://msgpack.org).
+//
+// Integers are encoded in the shortest form, other numbers as 64-bit floats, and Dates using the timestamp
+// extension type (-1). As MessagePack has no undefined value undefined is encoded as nil (and decoded as null),
+// BigInts are encoded as integers and must fit into 64 bits. Extension types other than timestamps are not
+// supported by the decoder.
+var MessagePack BinaryCodec = msgpackCodec{}
+
+type msgpackCodec struct{}
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	e := &msgpackEncoder{}
+	if err := e.encode(v, 0); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (e *msgpackEncoder) int(n int64) {
+	switch {
+	case n >= 0:
+		e.uint(uint64(n))
+	case n >= -32:
+		e.buf = append(e.buf, byte(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.buf = appendUint16(append(e.buf, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		e.buf = appendUint32(append(e.buf, 0xd2), uint32(n))
+	default:
+		e.buf = appendUint64(append(e.buf, 0xd3), uint64(n))
+	}
+}
+
+func (e *msgpackEncoder) uint(n uint64) {
+	switch {
+	case n < 0x80:
+		e.buf = append(e.buf, byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = appendUint16(append(e.buf, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = appendUint32(append(e.buf, 0xce), uint32(n))
+	default:
+		e.buf = appendUint64(append(e.buf, 0xcf), n)
+	}
+}
+
+// length writes the header of a string, binary, array or map. The fix parameter is the type byte of the fix
+// variant (0 if there is none) and first is the type byte of the 8-bit variant (the 16 and 32-bit variants follow
+// it, for arrays and maps the 8-bit variant doesn't exist).
+func (e *msgpackEncoder) length(n int, fix byte, fixMax int, first byte) error {
+	switch {
+	case fix != 0 && n <= fixMax:
+		e.buf = append(e.buf, fix|byte(n))
+	case first != 0 && n <= math.MaxUint8:
+		e.buf = append(e.buf, first, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = appendUint16(append(e.buf, first+1), uint16(n))
+	case uint64(n) <= math.MaxUint32:
+		e.buf = appendUint32(append(e.buf, first+2), uint32(n))
+	default:
+		return errors.New("msgpack: value is too long")
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) str(s string) error {
+	if err := e.length(len(s), 0xa0, 31, 0xd9); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, s...)
+	return nil
+}
+
+func (e *msgpackEncoder) encode(v interface{}, depth int) error {
+	if depth > maxBinaryDepth {
+		return errors.New("msgpack: maximum nesting depth exceeded")
+	}
+	switch v := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xc0)
+	case bool:
+		if v {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case int:
+		e.int(int64(v))
+	case int64:
+		e.int(v)
+	case uint64:
+		e.uint(v)
+	case float64:
+		e.buf = appendUint64(append(e.buf, 0xcb), math.Float64bits(v))
+	case *big.Int:
+		switch {
+		case v.IsInt64():
+			e.int(v.Int64())
+		case v.IsUint64():
+			e.uint(v.Uint64())
+		default:
+			return errors.New("msgpack: BigInt value is out of the 64-bit range")
+		}
+	case string:
+		return e.str(v)
+	case []byte:
+		if len(v) <= math.MaxUint8 {
+			e.buf = append(e.buf, 0xc4, byte(len(v)))
+		} else if err := e.length(len(v), 0, 0, 0xc4); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, v...)
+	case time.Time:
+		sec, nsec := v.Unix(), uint64(v.Nanosecond())
+		switch {
+		case sec >= 0 && sec <= math.MaxUint32 && nsec == 0:
+			e.buf = appendUint32(append(e.buf, 0xd6, 0xff), uint32(sec))
+		case sec >= 0 && sec < 1<<34:
+			e.buf = appendUint64(append(e.buf, 0xd7, 0xff), nsec<<34|uint64(sec))
+		default:
+			e.buf = appendUint64(appendUint32(append(e.buf, 0xc7, 12, 0xff), uint32(nsec)), uint64(sec))
+		}
+	case []interface{}:
+		if err := e.length(len(v), 0x90, 15, 0xdb); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := e.encode(item, depth+1); err != nil {
+				return err
+			}
+		}
+	case []BinaryMapEntry:
+		if err := e.length(len(v), 0x80, 15, 0xdd); err != nil {
+			return err
+		}
+		for _, entry := range v {
+			if err := e.encode(entry.Key, depth+1); err != nil {
+				return err
+			}
+			if err := e.encode(entry.Value, depth+1); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if err := e.length(len(v), 0x80, 15, 0xdd); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := e.str(key); err != nil {
+				return err
+			}
+			if err := e.encode(v[key], depth+1); err != nil {
+				return err
+			}
+		}
+	case Value:
+		if v != _undefined {
+			return fmt.Errorf("msgpack: unsupported value %s", v.String())
+		}
+		e.buf = append(e.buf, 0xc0)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+	return nil
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) errorf(format string, args ...interface{}) {
+	panic(&binaryDecodeError{msg: fmt.Sprintf("msgpack: "+format+" at offset %d", append(args, d.pos)...)})
+}
+
+func (msgpackCodec) Unmarshal(data []byte) (v interface{}, err error) {
+	d := &msgpackDecoder{data: data}
+	defer func() {
+		if x := recover(); x != nil {
+			if e, ok := x.(*binaryDecodeError); ok {
+				v, err = nil, e
+				return
+			}
+			panic(x)
+		}
+	}()
+	v = d.value(0)
+	if d.pos < len(d.data) {
+		d.errorf("unexpected data after the top-level object")
+	}
+	return
+}
+
+func (d *msgpackDecoder) read(n uint64) []byte {
+	if n > uint64(len(d.data)-d.pos) {
+		d.errorf("unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b
+}
+
+func (d *msgpackDecoder) uint(size int) uint64 {
+	b := d.read(uint64(size))
+	switch size {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b))
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b))
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (d *msgpackDecoder) array(n uint64, depth int) []interface{} {
+	if n > uint64(len(d.data)-d.pos) {
+		d.errorf("array is too long")
+	}
+	res := make([]interface{}, n)
+	for i := range res {
+		res[i] = d.value(depth + 1)
+	}
+	return res
+}
+
+func (d *msgpackDecoder) mapEntries(n uint64, depth int) []BinaryMapEntry {
+	if n > uint64(len(d.data)-d.pos)/2 {
+		d.errorf("map is too long")
+	}
+	res := make([]BinaryMapEntry, n)
+	for i := range res {
+		res[i].Key = d.value(depth + 1)
+		res[i].Value = d.value(depth + 1)
+	}
+	return res
+}
+
+func (d *msgpackDecoder) ext(n uint64) interface{} {
+	typ := int8(d.read(1)[0])
+	data := d.read(n)
+	if typ != -1 {
+		d.errorf("unsupported extension type %d", typ)
+	}
+	var sec int64
+	var nsec uint32
+	switch n {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(data))
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		nsec, sec = uint32(v>>34), int64(v&(1<<34-1))
+	case 12:
+		nsec, sec = binary.BigEndian.Uint32(data), int64(binary.BigEndian.Uint64(data[4:]))
+	default:
+		d.errorf("invalid timestamp length %d", n)
+	}
+	if nsec >= 1e9 {
+		d.errorf("invalid timestamp")
+	}
+	return time.Unix(sec, int64(nsec))
+}
+
+func (d *msgpackDecoder) value(depth int) interface{} {
+	if depth > maxBinaryDepth {
+		d.errorf("maximum nesting depth exceeded")
+	}
+	b := d.read(1)[0]
+	switch {
+	case b < 0x80:
+		return int64(b)
+	case b >= 0xe0:
+		return int64(int8(b))
+	case b < 0x90:
+		return d.mapEntries(uint64(b&0x0f), depth)
+	case b < 0xa0:
+		return d.array(uint64(b&0x0f), depth)
+	case b < 0xc0:
+		return string(d.read(uint64(b & 0x1f)))
+	}
+	switch b {
+	case 0xc0:
+		return nil
+	case 0xc2:
+		return false
+	case 0xc3:
+		return true
+	case 0xc4, 0xc5, 0xc6:
+		return append([]byte(nil), d.read(d.uint(1<<(b-0xc4)))...)
+	case 0xc7, 0xc8, 0xc9:
+		return d.ext(d.uint(1 << (b - 0xc7)))
+	case 0xca:
+		return float64(math.Float32frombits(uint32(d.uint(4))))
+	case 0xcb:
+		return math.Float64frombits(d.uint(8))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n := d.uint(1 << (b - 0xcc))
+		if n <= math.MaxInt64 {
+			return int64(n)
+		}
+		return n
+	case 0xd0:
+		return int64(int8(d.uint(1)))
+	case 0xd1:
+		return int64(int16(d.uint(2)))
+	case 0xd2:
+		return int64(int32(d.uint(4)))
+	case 0xd3:
+		return int64(d.uint(8))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		return string(d.read(d.uint(1 << (b - 0xd9))))
+	case 0xdc, 0xdd:
+		return d.array(d.uint(2<<(b-0xdc)), depth)
+	case 0xde, 0xdf:
+		return d.mapEntries(d.uint(2<<(b-0xde)), depth)
+	}
+	d.pos--
+	d.errorf("invalid type byte 0x%02x", b)
+	return nil
+}
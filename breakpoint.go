@@ -0,0 +1,193 @@
+package goja
+
+import (
+	"strings"
+)
+
+// Breakpoint is a location in the source code at which the host is notified when the execution reaches it, see
+// Runtime.AddBreakpoint().
+type Breakpoint struct {
+	// Filename and Line (1-based) identify the location, in the same form as reported by StackFrame.Position().
+	Filename string
+	Line     int
+	// Condition is an optional expression evaluated in the scope of the frame (see Frame.Eval()) in the read-only
+	// mode each time the line is reached. If the result is falsy the breakpoint is skipped.
+	Condition string
+	// LogMessage turns the breakpoint into a logpoint: instead of pausing, the message is passed to the log handler
+	// with each {expression} replaced by the string value of the expression evaluated in the scope of the frame.
+	LogMessage string
+}
+
+// BreakpointHit describes a breakpoint reached by the execution, see Runtime.SetBreakpointHandler().
+type BreakpointHit struct {
+	Breakpoint *Breakpoint
+	// Frame is the frame which has reached the breakpoint. It is only valid while the handler is running.
+	Frame *Frame
+	// Message is the interpolated LogMessage of a logpoint.
+	Message string
+	// Err is the exception thrown while evaluating the Condition or the expressions of the LogMessage, if any.
+	// A breakpoint whose condition throws is not skipped, so that the error does not go unnoticed.
+	Err error
+}
+
+type debugger struct {
+	breakpoints []*Breakpoint
+	onPause     func(hit *BreakpointHit)
+	onLog       func(hit *BreakpointHit)
+	// the pcs at which the breakpoints are located, per Program. Reset when the breakpoints change.
+	locations map[*Program]map[int][]*Breakpoint
+	// set while the handlers (and the breakpoint conditions) are running, so that they do not hit breakpoints
+	handling bool
+}
+
+func (d *debugger) enabled() bool {
+	return len(d.breakpoints) > 0 && !d.handling
+}
+
+// breakpointsAt returns the breakpoints located at the pc. A breakpoint is located at the first instruction of each
+// sequence of instructions compiled from its line, so it is hit each time the execution enters the line.
+func (d *debugger) breakpointsAt(prg *Program, pc int) []*Breakpoint {
+	locs, exists := d.locations[prg]
+	if !exists {
+		if prg.src != nil {
+			name := prg.src.Name()
+			prevLine := 0
+			for _, item := range prg.srcMap {
+				pos := prg.src.Position(item.srcPos)
+				if pos.Line != prevLine {
+					for _, bp := range d.breakpoints {
+						if bp.Line == pos.Line && (bp.Filename == pos.Filename || bp.Filename == name) {
+							if locs == nil {
+								locs = make(map[int][]*Breakpoint)
+							}
+							locs[item.pc] = append(locs[item.pc], bp)
+						}
+					}
+					prevLine = pos.Line
+				}
+			}
+		}
+		if d.locations == nil {
+			d.locations = make(map[*Program]map[int][]*Breakpoint)
+		}
+		d.locations[prg] = locs
+	}
+	return locs[pc]
+}
+
+// AddBreakpoint adds a breakpoint (or a logpoint). The code running in the Runtime is checked for breakpoints before
+// each instruction while there are any, which makes the execution noticeably slower.
+// The conditions and the log messages can only refer to the local variables of the functions compiled with
+// the scope information, see SetDebugScopes().
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) AddBreakpoint(bp *Breakpoint) {
+	d := r.getDebugger()
+	d.breakpoints = append(d.breakpoints, bp)
+	d.locations = nil
+}
+
+// RemoveBreakpoint removes a breakpoint added with AddBreakpoint().
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) RemoveBreakpoint(bp *Breakpoint) {
+	d := r.debugger
+	if d == nil {
+		return
+	}
+	for i, b := range d.breakpoints {
+		if b == bp {
+			copy(d.breakpoints[i:], d.breakpoints[i+1:])
+			d.breakpoints[len(d.breakpoints)-1] = nil
+			d.breakpoints = d.breakpoints[:len(d.breakpoints)-1]
+			d.locations = nil
+			break
+		}
+	}
+}
+
+// SetBreakpointHandler sets the functions called when a breakpoint is hit.
+//
+// onPause is called for the breakpoints. The execution is paused while it is running, it resumes once it returns.
+// It can inspect the frames using hit.Frame or Runtime.Frame() and evaluate expressions in them with Frame.Eval().
+// Calling Interrupt() from onPause stops the execution as soon as it resumes.
+//
+// onLog is called for the logpoints with the interpolated message, the execution is not meant to be paused.
+//
+// Either function may be nil, in which case the respective breakpoints are ignored. The breakpoints are not hit
+// while the handlers are running (including the code evaluated with Frame.Eval()).
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetBreakpointHandler(onPause, onLog func(hit *BreakpointHit)) {
+	d := r.getDebugger()
+	d.onPause, d.onLog = onPause, onLog
+}
+
+func (r *Runtime) getDebugger() *debugger {
+	if r.debugger == nil {
+		r.debugger = &debugger{}
+	}
+	return r.debugger
+}
+
+func (r *Runtime) hitBreakpoints(d *debugger, bps []*Breakpoint) {
+	d.handling = true
+	defer func() {
+		d.handling = false
+	}()
+	f := r.Frame(0)
+	for _, bp := range bps {
+		handler := d.onPause
+		if bp.LogMessage != "" {
+			handler = d.onLog
+		}
+		if handler == nil {
+			continue
+		}
+		hit := &BreakpointHit{Breakpoint: bp, Frame: f}
+		if bp.Condition != "" {
+			v, err := f.Eval(bp.Condition, true)
+			if err != nil {
+				hit.Err = err
+			} else if !v.ToBoolean() {
+				continue
+			}
+		}
+		if bp.LogMessage != "" && hit.Err == nil {
+			hit.Message, hit.Err = f.interpolate(bp.LogMessage)
+		}
+		handler(hit)
+	}
+}
+
+// interpolate replaces each {expression} in the message with the string value of the expression evaluated in
+// the read-only mode.
+func (f *Frame) interpolate(msg string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(msg, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(msg[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(msg[:start])
+		v, err := f.Eval(msg[start+1:end], true)
+		if err != nil {
+			return "", err
+		}
+		var s string
+		if ex := f.r.vm.try(func() {
+			s = v.String()
+		}); ex != nil {
+			return "", ex
+		}
+		b.WriteString(s)
+		msg = msg[end+1:]
+	}
+	b.WriteString(msg)
+	return b.String(), nil
+}
@@ -0,0 +1,113 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestBreakpoints(t *testing.T) {
+	const SCRIPT = `
+	function f(i) {
+		let sq = i * i;
+		return sq;
+	}
+	var sum = 0;
+	for (var i = 0; i < 4; i++) {
+		sum += f(i);
+	}
+	sum;
+	`
+	r := New()
+	r.SetDebugScopes(true)
+	var paused []Value
+	var logged []string
+	r.SetBreakpointHandler(func(hit *BreakpointHit) {
+		if hit.Err != nil {
+			t.Fatal(hit.Err)
+		}
+		if hit.Frame.FuncName() != "f" || hit.Frame.Position().Line != 4 {
+			t.Fatalf("Unexpected frame: %v", hit.Frame)
+		}
+		v, err := hit.Frame.Eval("sq", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paused = append(paused, v)
+		if _, err := hit.Frame.Eval("sq = 100", false); err != nil {
+			t.Fatal(err)
+		}
+	}, func(hit *BreakpointHit) {
+		if hit.Err != nil {
+			t.Fatal(hit.Err)
+		}
+		logged = append(logged, hit.Message)
+	})
+	r.AddBreakpoint(&Breakpoint{Filename: "test.js", Line: 4, Condition: "i === 2"})
+	logpoint := &Breakpoint{Filename: "test.js", Line: 8, LogMessage: "i={i}, sum={sum}"}
+	r.AddBreakpoint(logpoint)
+
+	v, err := r.RunScript("test.js", SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paused) != 1 || !paused[0].SameAs(intToValue(4)) {
+		t.Fatalf("Unexpected pauses: %v", paused)
+	}
+	if len(logged) != 4 || logged[0] != "i=0, sum=0" || logged[3] != "i=3, sum=101" {
+		t.Fatalf("Unexpected log: %q", logged)
+	}
+	if !v.SameAs(intToValue(110)) {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+
+	r.RemoveBreakpoint(logpoint)
+	logged = nil
+	paused = nil
+	if _, err := r.RunScript("test.js", SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 0 || len(paused) != 1 {
+		t.Fatalf("Unexpected hits: %v, %q", paused, logged)
+	}
+}
+
+func TestBreakpointConditionError(t *testing.T) {
+	r := New()
+	var hits []*BreakpointHit
+	r.SetBreakpointHandler(func(hit *BreakpointHit) {
+		hits = append(hits, hit)
+	}, func(hit *BreakpointHit) {
+		hits = append(hits, hit)
+	})
+	r.AddBreakpoint(&Breakpoint{Filename: "test.js", Line: 2, Condition: "undeclared.x"})
+	r.AddBreakpoint(&Breakpoint{Filename: "test.js", Line: 3, LogMessage: "x={x.y.z}"})
+	_, err := r.RunScript("test.js", `
+	var x = {};
+	x.y = 1;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("Unexpected hits: %d", len(hits))
+	}
+	for _, hit := range hits {
+		if _, ok := hit.Err.(*Exception); !ok {
+			t.Fatalf("Unexpected error: %v", hit.Err)
+		}
+	}
+}
+
+func TestBreakpointInterrupt(t *testing.T) {
+	r := New()
+	r.SetBreakpointHandler(func(hit *BreakpointHit) {
+		r.Interrupt("stop")
+	}, nil)
+	r.AddBreakpoint(&Breakpoint{Filename: "test.js", Line: 3})
+	_, err := r.RunScript("test.js", `
+	var i = 0;
+	while (true) { i++; }
+	`)
+	if _, ok := err.(*InterruptedError); !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
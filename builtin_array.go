@@ -23,6 +23,7 @@ func (r *Runtime) newArrayObject() *arrayObject {
 }
 
 func setArrayValues(a *arrayObject, values []Value) *arrayObject {
+	a.val.runtime.accountAlloc(int64(cap(values)) * usageValueSize)
 	a.values = values
 	a.length = uint32(len(values))
 	a.objCount = len(values)
@@ -188,6 +189,12 @@ func (r *Runtime) arrayproto_join(call FunctionCall) Value {
 		return stringEmpty
 	}
 
+	if arr := r.checkStdArrayObj(o); arr != nil && len(arr.values) == l {
+		if s := joinStrings(arr.values, sep); s != nil {
+			return s
+		}
+	}
+
 	var buf valueStringBuilder
 
 	element0 := o.self.getIdx(valueInt(0), nil)
@@ -196,6 +203,7 @@ func (r *Runtime) arrayproto_join(call FunctionCall) Value {
 	}
 
 	for i := 1; i < l; i++ {
+		r.vm.checkInterrupt()
 		buf.WriteString(sep)
 		element := o.self.getIdx(valueInt(int64(i)), nil)
 		if element != nil && element != _undefined && element != _null {
@@ -363,6 +371,7 @@ func (r *Runtime) arrayproto_sort(call FunctionCall) Value {
 		ctx := arraySortCtx{
 			obj:     s,
 			compare: compareFn,
+			vm:      r.vm,
 		}
 
 		sort.Stable(&ctx)
@@ -370,6 +379,7 @@ func (r *Runtime) arrayproto_sort(call FunctionCall) Value {
 		length := toLength(o.self.getStr("length", nil))
 		a := make([]Value, 0, length)
 		for i := int64(0); i < length; i++ {
+			r.vm.checkInterrupt()
 			idx := valueInt(i)
 			if o.self.hasPropertyIdx(idx) {
 				a = append(a, nilSafe(o.self.getIdx(idx, nil)))
@@ -379,6 +389,7 @@ func (r *Runtime) arrayproto_sort(call FunctionCall) Value {
 		ctx := arraySortCtx{
 			obj:     ar.self,
 			compare: compareFn,
+			vm:      r.vm,
 		}
 
 		sort.Stable(&ctx)
@@ -409,18 +420,15 @@ func (r *Runtime) arrayproto_splice(call FunctionCall) Value {
 	if newLength >= maxInt {
 		panic(r.NewTypeError("Invalid array length"))
 	}
+	_, goArray := o.self.(*objectGoArrayReflect)
+	if goArray && newLength != length {
+		panic(r.NewTypeError("Cannot change the length of a Go array"))
+	}
 	a := arraySpeciesCreate(o, actualDeleteCount)
-	if src := r.checkStdArrayObj(o); src != nil {
-		if dst := r.checkStdArrayObjWithProto(a); dst != nil {
-			values := make([]Value, actualDeleteCount)
-			copy(values, src.values[actualStart:])
-			setArrayValues(dst, values)
-		} else {
-			for k := int64(0); k < actualDeleteCount; k++ {
-				createDataPropertyOrThrow(a, intToValue(k), src.values[k+actualStart])
-			}
-			a.self.setOwnStr("length", intToValue(actualDeleteCount), true)
-		}
+	if src := r.checkStdArrayObj(o); src != nil && src.lengthProp.writable {
+		deleted := make([]Value, actualDeleteCount)
+		copy(deleted, src.values[actualStart:])
+		r.setSpliceDeleted(a, deleted)
 		var values []Value
 		if itemCount < actualDeleteCount {
 			values = src.values
@@ -447,6 +455,27 @@ func (r *Runtime) arrayproto_splice(call FunctionCall) Value {
 		}
 		src.values = values
 		src.objCount = len(values)
+	} else if src, ok := o.self.(*objectGoSlice); ok && int64(len(*src.data)) == length {
+		deleted := make([]Value, actualDeleteCount)
+		for k := range deleted {
+			deleted[k] = src._getIdx(int(actualStart) + k)
+		}
+		r.setSpliceDeleted(a, deleted)
+		if itemCount < actualDeleteCount {
+			data := *src.data
+			copy(data[actualStart+itemCount:], data[actualStart+actualDeleteCount:])
+			src.shrink(int(newLength))
+		} else if itemCount > actualDeleteCount {
+			src.grow(int(newLength))
+			data := *src.data
+			copy(data[actualStart+itemCount:], data[actualStart+actualDeleteCount:length])
+		}
+		if itemCount > 0 {
+			data := *src.data
+			for i, item := range call.Arguments[2:] {
+				data[actualStart+int64(i)] = item.Export()
+			}
+		}
 	} else {
 		for k := int64(0); k < actualDeleteCount; k++ {
 			from := valueInt(k + actualStart)
@@ -488,11 +517,25 @@ func (r *Runtime) arrayproto_splice(call FunctionCall) Value {
 		}
 	}
 
-	o.self.setOwnStr("length", intToValue(newLength), true)
+	if !goArray {
+		o.self.setOwnStr("length", intToValue(newLength), true)
+	}
 
 	return a
 }
 
+// setSpliceDeleted populates the array returned by splice() with the deleted values.
+func (r *Runtime) setSpliceDeleted(a *Object, values []Value) {
+	if dst := r.checkStdArrayObjWithProto(a); dst != nil {
+		setArrayValues(dst, values)
+	} else {
+		for k, v := range values {
+			createDataPropertyOrThrow(a, intToValue(int64(k)), v)
+		}
+		a.self.setOwnStr("length", intToValue(int64(len(values))), true)
+	}
+}
+
 func (r *Runtime) arrayproto_unshift(call FunctionCall) Value {
 	o := call.This.ToObject(r)
 	length := toLength(o.self.getStr("length", nil))
@@ -503,7 +546,7 @@ func (r *Runtime) arrayproto_unshift(call FunctionCall) Value {
 		if newSize >= maxInt {
 			panic(r.NewTypeError("Invalid array length"))
 		}
-		if arr := r.checkStdArrayObjWithProto(o); arr != nil && newSize < math.MaxUint32 {
+		if arr := r.checkStdArrayObjWithProto(o); arr != nil && arr.lengthProp.writable && newSize < math.MaxUint32 {
 			if int64(cap(arr.values)) >= newSize {
 				arr.values = arr.values[:newSize]
 				copy(arr.values[argCount:], arr.values[:length])
@@ -580,6 +623,7 @@ func (r *Runtime) arrayproto_indexOf(call FunctionCall) Value {
 	}
 
 	for ; n < length; n++ {
+		r.vm.checkInterrupt()
 		idx := valueInt(n)
 		if o.self.hasPropertyIdx(idx) {
 			if val := o.self.getIdx(idx, nil); val != nil {
@@ -624,6 +668,7 @@ func (r *Runtime) arrayproto_includes(call FunctionCall) Value {
 	}
 
 	for ; n < length; n++ {
+		r.vm.checkInterrupt()
 		idx := valueInt(n)
 		val := nilSafe(o.self.getIdx(idx, nil))
 		if searchElement.SameAs(val) {
@@ -667,6 +712,7 @@ func (r *Runtime) arrayproto_lastIndexOf(call FunctionCall) Value {
 	}
 
 	for k := fromIndex; k >= 0; k-- {
+		r.vm.checkInterrupt()
 		idx := valueInt(k)
 		if o.self.hasPropertyIdx(idx) {
 			if val := o.self.getIdx(idx, nil); val != nil {
@@ -779,8 +825,7 @@ func (r *Runtime) arrayproto_map(call FunctionCall) Value {
 func (r *Runtime) arrayproto_filter(call FunctionCall) Value {
 	o := call.This.ToObject(r)
 	length := toLength(o.self.getStr("length", nil))
-	callbackFn := call.Argument(0).ToObject(r)
-	if callbackFn, ok := callbackFn.self.assertCallable(); ok {
+	if callbackFn, ok := assertCallable(call.Argument(0)); ok {
 		a := arraySpeciesCreate(o, 0)
 		fc := FunctionCall{
 			This:      call.Argument(1),
@@ -818,7 +863,7 @@ func (r *Runtime) arrayproto_filter(call FunctionCall) Value {
 		}
 		return a
 	} else {
-		r.typeErrorResult(true, "%s is not a function", call.Argument(0))
+		r.typeErrorResult(true, "%s is not a function", describeValue(call.Argument(0)))
 	}
 	panic("unreachable")
 }
@@ -826,8 +871,7 @@ func (r *Runtime) arrayproto_filter(call FunctionCall) Value {
 func (r *Runtime) arrayproto_reduce(call FunctionCall) Value {
 	o := call.This.ToObject(r)
 	length := toLength(o.self.getStr("length", nil))
-	callbackFn := call.Argument(0).ToObject(r)
-	if callbackFn, ok := callbackFn.self.assertCallable(); ok {
+	if callbackFn, ok := assertCallable(call.Argument(0)); ok {
 		fc := FunctionCall{
 			This:      _undefined,
 			Arguments: []Value{nil, nil, nil, o},
@@ -846,7 +890,7 @@ func (r *Runtime) arrayproto_reduce(call FunctionCall) Value {
 				}
 			}
 			if fc.Arguments[0] == nil {
-				r.typeErrorResult(true, "No initial value")
+				r.typeErrorResult(true, "Reduce of empty array with no initial value")
 				panic("unreachable")
 			}
 			k++
@@ -862,7 +906,7 @@ func (r *Runtime) arrayproto_reduce(call FunctionCall) Value {
 		}
 		return fc.Arguments[0]
 	} else {
-		r.typeErrorResult(true, "%s is not a function", call.Argument(0))
+		r.typeErrorResult(true, "%s is not a function", describeValue(call.Argument(0)))
 	}
 	panic("unreachable")
 }
@@ -870,8 +914,7 @@ func (r *Runtime) arrayproto_reduce(call FunctionCall) Value {
 func (r *Runtime) arrayproto_reduceRight(call FunctionCall) Value {
 	o := call.This.ToObject(r)
 	length := toLength(o.self.getStr("length", nil))
-	callbackFn := call.Argument(0).ToObject(r)
-	if callbackFn, ok := callbackFn.self.assertCallable(); ok {
+	if callbackFn, ok := assertCallable(call.Argument(0)); ok {
 		fc := FunctionCall{
 			This:      _undefined,
 			Arguments: []Value{nil, nil, nil, o},
@@ -890,7 +933,7 @@ func (r *Runtime) arrayproto_reduceRight(call FunctionCall) Value {
 				}
 			}
 			if fc.Arguments[0] == nil {
-				r.typeErrorResult(true, "No initial value")
+				r.typeErrorResult(true, "Reduce of empty array with no initial value")
 				panic("unreachable")
 			}
 			k--
@@ -906,7 +949,7 @@ func (r *Runtime) arrayproto_reduceRight(call FunctionCall) Value {
 		}
 		return fc.Arguments[0]
 	} else {
-		r.typeErrorResult(true, "%s is not a function", call.Argument(0))
+		r.typeErrorResult(true, "%s is not a function", describeValue(call.Argument(0)))
 	}
 	panic("unreachable")
 }
@@ -939,6 +982,7 @@ func (r *Runtime) arrayproto_reverse_generic(o *Object, start int64) {
 	l := toLength(o.self.getStr("length", nil))
 	middle := l / 2
 	for lower := start; lower != middle; lower++ {
+		r.vm.checkInterrupt()
 		arrayproto_reverse_generic_step(o, lower, l-lower-1)
 	}
 }
@@ -961,11 +1005,8 @@ func (r *Runtime) arrayproto_reverse(call FunctionCall) Value {
 
 func (r *Runtime) arrayproto_shift(call FunctionCall) Value {
 	o := call.This.ToObject(r)
-	if a := r.checkStdArrayObjWithProto(o); a != nil {
+	if a := r.checkStdArrayObjWithProto(o); a != nil && a.lengthProp.writable {
 		if len(a.values) == 0 {
-			if !a.lengthProp.writable {
-				a.setLength(0, true) // will throw
-			}
 			return _undefined
 		}
 		first := a.values[0]
@@ -982,6 +1023,7 @@ func (r *Runtime) arrayproto_shift(call FunctionCall) Value {
 	}
 	first := o.self.getIdx(valueInt(0), nil)
 	for i := int64(1); i < length; i++ {
+		r.vm.checkInterrupt()
 		idxFrom := valueInt(i)
 		idxTo := valueInt(i - 1)
 		if o.self.hasPropertyIdx(idxFrom) {
@@ -1025,6 +1067,13 @@ func (r *Runtime) arrayproto_copyWithin(call FunctionCall) Value {
 		}
 		return o
 	}
+	if s, ok := o.self.(*objectGoSlice); ok && int64(len(*s.data)) == l {
+		if count > 0 {
+			data := *s.data
+			copy(data[to:to+count], data[from:from+count])
+		}
+		return o
+	}
 	if from < to && to < from+count {
 		dir = -1
 		from = from + count - 1
@@ -1033,6 +1082,7 @@ func (r *Runtime) arrayproto_copyWithin(call FunctionCall) Value {
 		dir = 1
 	}
 	for count > 0 {
+		r.vm.checkInterrupt()
 		if o.self.hasPropertyIdx(valueInt(from)) {
 			o.self.setOwnIdx(valueInt(to), nilSafe(o.self.getIdx(valueInt(from), nil)), true)
 		} else {
@@ -1066,8 +1116,15 @@ func (r *Runtime) arrayproto_fill(call FunctionCall) Value {
 		for ; k < final; k++ {
 			arr.values[k] = value
 		}
+	} else if s, ok := o.self.(*objectGoSlice); ok && int64(len(*s.data)) == l {
+		data := *s.data
+		v := value.Export()
+		for ; k < final; k++ {
+			data[k] = v
+		}
 	} else {
 		for ; k < final; k++ {
+			r.vm.checkInterrupt()
 			o.self.setOwnIdx(valueInt(k), value, true)
 		}
 	}
@@ -1266,7 +1323,7 @@ func (r *Runtime) array_from(call FunctionCall) Value {
 			}
 		}
 		if mapFn == nil {
-			panic(r.NewTypeError("%s is not a function", mapFnArg))
+			panic(r.NewTypeError("%s is not a function", describeValue(mapFnArg)))
 		}
 	}
 	t := call.Argument(2)
@@ -1506,6 +1563,7 @@ type sortable interface {
 type arraySortCtx struct {
 	obj     sortable
 	compare func(FunctionCall) Value
+	vm      *vm
 }
 
 func (a *arraySortCtx) sortCompare(x, y Value) int {
@@ -1559,6 +1617,7 @@ func (a *arraySortCtx) Len() int {
 }
 
 func (a *arraySortCtx) Less(j, k int) bool {
+	a.vm.checkInterrupt()
 	return a.sortCompare(a.obj.sortGet(j), a.obj.sortGet(k)) < 0
 }
 
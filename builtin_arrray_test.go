@@ -323,3 +323,46 @@ func TestArrayFlatMap(t *testing.T) {
 	`
 	testScriptWithTestLibX(SCRIPT, _undefined, t)
 }
+
+func TestArrayJoinStrings(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(["a", "b", "c"].join(), "a,b,c", "ascii");
+	assert.sameValue(["a", "б", "c"].join("-"), "a-б-c", "unicode element");
+	assert.sameValue(["a", "b"].join("→"), "a→b", "unicode separator");
+	assert.sameValue(["a"].join("→"), "a", "unicode separator, single element");
+	assert.sameValue(["a"].join("→").length, 1, "single element length");
+	assert.sameValue(["", "", ""].join(""), "", "empty");
+	assert.sameValue(["a", 1, "b"].join(), "a,1,b", "mixed");
+	assert.sameValue(["a", , "b"].join(), "a,,b", "hole");
+	assert.sameValue(["a", null, "b"].join(), "a,,b", "null");
+
+	var arr = ["a", "b"];
+	var sep = {
+		toString: function() {
+			arr.push("c");
+			return "+";
+		}
+	};
+	assert.sameValue(arr.join(sep), "a+b", "length is read before the separator is converted");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func BenchmarkArrayJoinStrings(b *testing.B) {
+	vm := New()
+	if _, err := vm.RunString(`
+	var parts = [];
+	for (var i = 0; i < 1000; i++) {
+		parts.push("item" + i);
+	}
+	`); err != nil {
+		b.Fatal(err)
+	}
+	prg := MustCompile("test.js", `parts.join(",")`, false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := vm.RunProgram(prg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package goja
+
+import (
+	"math"
+	"math/big"
+)
+
+// maxBigIntBits limits the size of BigInt values produced by operations which can make them grow
+// very fast (exponentiation and left shifts).
+const maxBigIntBits = 1 << 30
+
+var errBigIntTooBig = rangeError("Maximum BigInt size exceeded")
+
+// stringToBigInt implements StringToBigInt (https://tc39.es/ecma262/#sec-stringtobigint).
+func stringToBigInt(s valueString) (*big.Int, bool) {
+	str := s.toTrimmedUTF8()
+	if str == "" {
+		return new(big.Int), true
+	}
+	base := 10
+	if len(str) > 2 && str[0] == '0' {
+		switch str[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			str = str[2:]
+			if str[0] == '+' || str[0] == '-' {
+				return nil, false
+			}
+		}
+	}
+	return new(big.Int).SetString(str, base)
+}
+
+// toNumeric implements ToNumeric (https://tc39.es/ecma262/#sec-tonumeric). The result is either
+// a Number (valueInt or valueFloat) or a BigInt.
+func toNumeric(v Value) Value {
+	switch v := v.(type) {
+	case valueInt, valueFloat, *valueBigInt:
+		return v
+	case *Object:
+		prim := v.toPrimitiveNumber()
+		if b, ok := prim.(*valueBigInt); ok {
+			return b
+		}
+		return prim.ToNumber()
+	}
+	return v.ToNumber()
+}
+
+// bigIntOperands returns both operands as *big.Int if they are BigInts or nils if neither of them is.
+// Mixing a BigInt with a Number results in a TypeError. The operands must be results of toNumeric().
+func bigIntOperands(left, right Value) (*big.Int, *big.Int) {
+	l, lok := left.(*valueBigInt)
+	r, rok := right.(*valueBigInt)
+	if lok != rok {
+		panic(errMixBigIntType)
+	}
+	if lok {
+		return (*big.Int)(l), (*big.Int)(r)
+	}
+	return nil, nil
+}
+
+func bigIntToNumber(b *big.Int) Value {
+	if b.IsInt64() {
+		if i := b.Int64(); i >= -maxInt && i <= maxInt {
+			return intToValue(i)
+		}
+	}
+	f, _ := new(big.Float).SetInt(b).Float64()
+	return floatToValue(f)
+}
+
+func bigIntExp(base, exponent *big.Int) *valueBigInt {
+	if exponent.Sign() < 0 {
+		panic(rangeError("Exponent must be non-negative"))
+	}
+	if base.CmpAbs(big.NewInt(1)) > 0 {
+		if !exponent.IsInt64() || exponent.Int64() > maxBigIntBits/int64(base.BitLen()-1) {
+			panic(errBigIntTooBig)
+		}
+	}
+	return (*valueBigInt)(new(big.Int).Exp(base, exponent, nil))
+}
+
+func bigIntShiftLeft(b *big.Int, shift *big.Int) *valueBigInt {
+	if shift.Sign() < 0 {
+		if !shift.IsInt64() || shift.Int64() < -maxBigIntBits {
+			if b.Sign() < 0 {
+				return (*valueBigInt)(big.NewInt(-1))
+			}
+			return (*valueBigInt)(new(big.Int))
+		}
+		return (*valueBigInt)(new(big.Int).Rsh(b, uint(-shift.Int64())))
+	}
+	if b.Sign() == 0 {
+		return (*valueBigInt)(new(big.Int))
+	}
+	if !shift.IsInt64() || shift.Int64()+int64(b.BitLen()) > maxBigIntBits {
+		panic(errBigIntTooBig)
+	}
+	return (*valueBigInt)(new(big.Int).Lsh(b, uint(shift.Int64())))
+}
+
+func (r *Runtime) numberToBigInt(v Value) *valueBigInt {
+	switch v := v.(type) {
+	case valueInt:
+		return (*valueBigInt)(big.NewInt(int64(v)))
+	case valueFloat:
+		f := float64(v)
+		if !math.IsNaN(f) && !math.IsInf(f, 0) && math.Trunc(f) == f {
+			i, _ := big.NewFloat(f).Int(nil)
+			return (*valueBigInt)(i)
+		}
+	}
+	panic(r.newError(r.global.RangeError, "The number %s cannot be converted to a BigInt because it is not an integer", v.String()))
+}
+
+// toBigInt implements ToBigInt (https://tc39.es/ecma262/#sec-tobigint).
+func (r *Runtime) toBigInt(v Value) *valueBigInt {
+	prim := toPrimitiveNumber(v)
+	switch prim := prim.(type) {
+	case *valueBigInt:
+		return prim
+	case valueBool:
+		if prim {
+			return (*valueBigInt)(big.NewInt(1))
+		}
+		return (*valueBigInt)(new(big.Int))
+	case valueString:
+		if b, ok := stringToBigInt(prim); ok {
+			return (*valueBigInt)(b)
+		}
+		panic(r.newError(r.global.SyntaxError, "Cannot convert %s to a BigInt", prim.String()))
+	case *Symbol:
+		panic(r.NewTypeError("Cannot convert a Symbol value to a BigInt"))
+	}
+	panic(r.NewTypeError("Cannot convert %s to a BigInt", prim.String()))
+}
+
+func (r *Runtime) thisBigIntValue(v Value, method string) *big.Int {
+	switch t := v.(type) {
+	case *valueBigInt:
+		return (*big.Int)(t)
+	case *Object:
+		if pVal, ok := t.self.(*primitiveValueObject); ok {
+			if b, ok := pVal.pValue.(*valueBigInt); ok {
+				return (*big.Int)(b)
+			}
+		}
+	}
+	panic(r.NewTypeError("BigInt.prototype.%s requires that 'this' be a BigInt", method))
+}
+
+func (r *Runtime) builtin_BigInt(call FunctionCall) Value {
+	prim := toPrimitiveNumber(call.Argument(0))
+	switch prim.(type) {
+	case valueInt, valueFloat:
+		return r.numberToBigInt(prim)
+	}
+	return r.toBigInt(prim)
+}
+
+func (r *Runtime) bigint_asIntN(call FunctionCall) Value {
+	bits := r.toIndex(call.Argument(0))
+	b := (*big.Int)(r.toBigInt(call.Argument(1)))
+	if bits == 0 {
+		return (*valueBigInt)(new(big.Int))
+	}
+	if b.BitLen() < bits {
+		return (*valueBigInt)(b)
+	}
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	res := new(big.Int).Mod(b, mod)
+	if res.Bit(bits-1) == 1 {
+		res.Sub(res, mod)
+	}
+	return (*valueBigInt)(res)
+}
+
+func (r *Runtime) bigint_asUintN(call FunctionCall) Value {
+	bits := r.toIndex(call.Argument(0))
+	b := (*big.Int)(r.toBigInt(call.Argument(1)))
+	if b.Sign() >= 0 && b.BitLen() <= bits {
+		return (*valueBigInt)(b)
+	}
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	return (*valueBigInt)(new(big.Int).Mod(b, mod))
+}
+
+func (r *Runtime) bigintproto_toString(call FunctionCall) Value {
+	b := r.thisBigIntValue(call.This, "toString")
+	radix := 10
+	if arg := call.Argument(0); arg != _undefined {
+		radix = int(arg.ToInteger())
+		if radix < 2 || radix > 36 {
+			panic(r.newError(r.global.RangeError, "toString() radix argument must be between 2 and 36"))
+		}
+	}
+	return asciiString(b.Text(radix))
+}
+
+func (r *Runtime) bigintproto_toLocaleString(call FunctionCall) Value {
+	return asciiString(r.thisBigIntValue(call.This, "toLocaleString").String())
+}
+
+func (r *Runtime) bigintproto_valueOf(call FunctionCall) Value {
+	if b, ok := call.This.(*valueBigInt); ok {
+		return b
+	}
+	return (*valueBigInt)(r.thisBigIntValue(call.This, "valueOf"))
+}
+
+func (r *Runtime) createBigIntProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.BigInt, true, false, true)
+	o._putProp("toLocaleString", r.newNativeFunc(r.bigintproto_toLocaleString, nil, "toLocaleString", nil, 0), true, false, true)
+	o._putProp("toString", r.newNativeFunc(r.bigintproto_toString, nil, "toString", nil, 0), true, false, true)
+	o._putProp("valueOf", r.newNativeFunc(r.bigintproto_valueOf, nil, "valueOf", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classBigInt), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createBigInt(val *Object) objectImpl {
+	o := r.newNativeFuncObj(val, r.builtin_BigInt, func(args []Value, proto *Object) *Object {
+		panic(r.NewTypeError("BigInt is not a constructor"))
+	}, "BigInt", r.global.BigIntPrototype, intToValue(1))
+
+	o._putProp("asIntN", r.newNativeFunc(r.bigint_asIntN, nil, "asIntN", nil, 2), true, false, true)
+	o._putProp("asUintN", r.newNativeFunc(r.bigint_asUintN, nil, "asUintN", nil, 2), true, false, true)
+
+	return o
+}
+
+func (r *Runtime) initBigInt() {
+	r.global.BigIntPrototype = r.newLazyObject(r.createBigIntProto)
+
+	r.global.BigInt = r.newLazyObject(r.createBigInt)
+	r.addToGlobal("BigInt", r.global.BigInt)
+}
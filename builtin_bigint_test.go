@@ -0,0 +1,210 @@
+package goja
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigIntLiterals(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(typeof 1n, "bigint");
+	assert.sameValue(0n, BigInt(0));
+	assert.sameValue(0xFFn, 255n);
+	assert.sameValue(0o17n, 15n);
+	assert.sameValue(0b101n, 5n);
+	assert.sameValue(1_000n, 1000n);
+	assert.sameValue(String(123456789012345678901234567890n), "123456789012345678901234567890");
+	assert.sameValue({1n: true}["1"], true);
+	assert.sameValue([1, 2, 3][1n], 2);
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestBigIntLiteralErrors(t *testing.T) {
+	for _, src := range []string{"1.5n", "1e3n", "01n", "1n2", "0x1.5n", ".5n"} {
+		if _, err := Compile("", src, false); err == nil {
+			t.Fatalf("%q: expected a syntax error", src)
+		}
+	}
+}
+
+func TestBigIntArithmetic(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(1n + 2n, 3n);
+	assert.sameValue(2n - 5n, -3n);
+	assert.sameValue(4n * 5n, 20n);
+	assert.sameValue(7n / 2n, 3n);
+	assert.sameValue(-7n / 2n, -3n);
+	assert.sameValue(7n % 3n, 1n);
+	assert.sameValue(-7n % 3n, -1n);
+	assert.sameValue(2n ** 64n, 18446744073709551616n);
+	assert.sameValue(-(1n), -1n);
+	assert.sameValue(~5n, -6n);
+	assert.sameValue(6n & 3n, 2n);
+	assert.sameValue(6n | 3n, 7n);
+	assert.sameValue(6n ^ 3n, 5n);
+	assert.sameValue(-6n & 3n, 2n);
+	assert.sameValue(1n << 100n, 1267650600228229401496703205376n);
+	assert.sameValue(1n << -1n, 0n);
+	assert.sameValue(-9n >> 1n, -5n);
+	assert.sameValue(-1n >> 1000n, -1n);
+	assert.sameValue("x" + 1n, "x1");
+	assert.sameValue(` + "`${10n}`" + `, "10");
+
+	var x = 1n;
+	assert.sameValue(x++, 1n);
+	assert.sameValue(x, 2n);
+	assert.sameValue(++x, 3n);
+	assert.sameValue(x--, 3n);
+	assert.sameValue(--x, 1n);
+	x += 2n;
+	assert.sameValue(x, 3n);
+	x **= 2n;
+	assert.sameValue(x, 9n);
+
+	assert.sameValue(Object(2n) * 3n, 6n);
+
+	assert.throws(TypeError, function() { 1n + 1; });
+	assert.throws(TypeError, function() { 1 * 1n; });
+	assert.throws(TypeError, function() { 1n >>> 0n; });
+	assert.throws(TypeError, function() { +1n; });
+	assert.throws(TypeError, function() { 1n | 0; });
+	assert.throws(TypeError, function() { Math.abs(1n); });
+	assert.throws(RangeError, function() { 1n / 0n; });
+	assert.throws(RangeError, function() { 1n % 0n; });
+	assert.throws(RangeError, function() { 2n ** -1n; });
+	assert.throws(RangeError, function() { 2n ** 10000000000n; });
+	assert.throws(RangeError, function() { 1n << 10000000000n; });
+	assert.sameValue(1n ** 10000000000n, 1n);
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestBigIntComparison(t *testing.T) {
+	const SCRIPT = `
+	assert(1n < 2n, "1n < 2n");
+	assert(1n < 1.5, "1n < 1.5");
+	assert(2 > 1n, "2 > 1n");
+	assert(!(1n < NaN), "1n < NaN");
+	assert(!(1n >= NaN), "1n >= NaN");
+	assert(1n < Infinity, "1n < Infinity");
+	assert(-Infinity < -100000000000000000000000n, "-Infinity < big");
+	assert(1n < "2", '1n < "2"');
+	assert("10" > 9n, '"10" > 9n');
+	assert(!(1n < "x"), '1n < "x"');
+	assert(!(1n >= "x"), '1n >= "x"');
+	assert(9007199254740993n > 9007199254740992, "precision");
+
+	assert(1n == 1, "1n == 1");
+	assert(1n == 1.0, "1n == 1.0");
+	assert(1n != 1.5, "1n != 1.5");
+	assert(1n == "1", '1n == "1"');
+	assert("0x10" == 16n, '"0x10" == 16n');
+	assert(1n == true, "1n == true");
+	assert(0n == false, "0n == false");
+	assert(1n == Object(1n), "1n == Object(1n)");
+	assert(Object(1n) == 1n, "Object(1n) == 1n");
+	assert(9007199254740993n != 9007199254740992, "precision ==");
+	assert(1n !== 1, "1n !== 1");
+	assert(10n === 10n, "10n === 10n");
+	assert(Object.is(0n, -0n), "Object.is(0n, -0n)");
+
+	assert(!0n, "!0n");
+	assert(!!1n, "!!1n");
+
+	switch (2n) {
+	case 2:
+		throw new Error("matched a Number");
+	case 2n:
+		break;
+	default:
+		throw new Error("no match");
+	}
+
+	var m = new Map([[1n, "a"], [1, "b"]]);
+	assert.sameValue(m.get(1n), "a");
+	assert.sameValue(m.get(1), "b");
+	assert(new Set([123456789012345678901234567890n]).has(123456789012345678901234567890n), "Set.has");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestBigIntBuiltin(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(BigInt(10), 10n);
+	assert.sameValue(BigInt(1e21), 1000000000000000000000n);
+	assert.sameValue(BigInt(true), 1n);
+	assert.sameValue(BigInt(" 0x1f "), 31n);
+	assert.sameValue(BigInt("-12"), -12n);
+	assert.sameValue(BigInt(""), 0n);
+	assert.sameValue(BigInt(Object(5n)), 5n);
+	assert.throws(RangeError, function() { BigInt(1.5); });
+	assert.throws(RangeError, function() { BigInt(NaN); });
+	assert.throws(SyntaxError, function() { BigInt("1.5"); });
+	assert.throws(SyntaxError, function() { BigInt("-0x1"); });
+	assert.throws(SyntaxError, function() { BigInt("1_000"); });
+	assert.throws(SyntaxError, function() { BigInt("010n"); });
+	assert.throws(TypeError, function() { BigInt(undefined); });
+	assert.throws(TypeError, function() { BigInt(Symbol()); });
+	assert.throws(TypeError, function() { new BigInt(1); });
+
+	assert.sameValue(Number(2n ** 53n + 1n), 9007199254740992);
+	assert.sameValue(Number(-5n), -5);
+	assert.sameValue(new Number(3n).valueOf(), 3);
+
+	assert.sameValue(BigInt.asIntN(8, 255n), -1n);
+	assert.sameValue(BigInt.asIntN(8, 127n), 127n);
+	assert.sameValue(BigInt.asIntN(8, 128n), -128n);
+	assert.sameValue(BigInt.asIntN(0, 5n), 0n);
+	assert.sameValue(BigInt.asIntN(64, -1n), -1n);
+	assert.sameValue(BigInt.asUintN(8, -1n), 255n);
+	assert.sameValue(BigInt.asUintN(64, -1n), 18446744073709551615n);
+	assert.sameValue(BigInt.asUintN(0, 5n), 0n);
+
+	assert.sameValue((255n).toString(16), "ff");
+	assert.sameValue((-255n).toString(2), "-11111111");
+	assert.sameValue((10n).toLocaleString(), "10");
+	assert.sameValue(Object(10n).valueOf(), 10n);
+	assert.throws(RangeError, function() { (1n).toString(37); });
+	assert.throws(TypeError, function() { BigInt.prototype.valueOf.call(1); });
+	assert.sameValue(Object.prototype.toString.call(1n), "[object BigInt]");
+	assert.sameValue(typeof Object(1n), "object");
+
+	assert.throws(TypeError, function() { JSON.stringify({a: 1n}); });
+	BigInt.prototype.toJSON = function() { return this.toString(); };
+	assert.sameValue(JSON.stringify({a: 1n}), '{"a":"1"}');
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestBigIntExportImport(t *testing.T) {
+	vm := New()
+	v, err := vm.RunString("12345678901234567890123n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := new(big.Int).SetString("12345678901234567890123", 10)
+	if exp, ok := v.Export().(*big.Int); !ok || exp.Cmp(expected) != 0 {
+		t.Fatalf("Unexpected export: %#v", v.Export())
+	}
+
+	var b big.Int
+	if err := vm.ExportTo(v, &b); err != nil {
+		t.Fatal(err)
+	}
+	if b.Cmp(expected) != 0 {
+		t.Fatal(b.String())
+	}
+
+	vm.Set("b", expected)
+	res, err := vm.RunString(`typeof b === "bigint" && b + 1n === 12345678901234567890124n`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != valueTrue {
+		t.Fatal(res)
+	}
+	if expected.String() != "12345678901234567890123" {
+		t.Fatal("the original value has been modified")
+	}
+}
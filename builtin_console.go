@@ -0,0 +1,530 @@
+package goja
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dop251/goja/console"
+	"github.com/dop251/goja/unistring"
+)
+
+const (
+	consoleMaxDepth      = 2
+	consoleMaxArrayItems = 100
+	consoleDefaultLabel  = "default"
+)
+
+var defaultConsolePrinter = console.NewWriterPrinter(os.Stdout, os.Stderr)
+
+// consoleState is the per-Runtime state of the console object.
+type consoleState struct {
+	printer console.Printer // see SetConsolePrinter()
+	indent  int
+	timers  map[string]time.Time
+	counts  map[string]int
+}
+
+func (r *Runtime) consolePrint(level console.Level, msg string) {
+	if r.console.indent > 0 {
+		prefix := strings.Repeat(" ", r.console.indent)
+		msg = prefix + strings.ReplaceAll(msg, "\n", "\n"+prefix)
+	}
+	p := r.console.printer
+	if p == nil {
+		p = defaultConsolePrinter
+	}
+	p.Print(level, msg)
+}
+
+// consoleFormat formats the arguments of the logging methods: if the first one is a string, its format specifiers
+// (%s, %d, %i, %f, %o, %O, %c and %%) are replaced with the following arguments, the remaining arguments are
+// appended separated by spaces.
+func (r *Runtime) consoleFormat(args []Value) string {
+	var sb strings.Builder
+	if len(args) > 0 {
+		if s, ok := args[0].(valueString); ok {
+			args = r.consoleFormatString(&sb, s.String(), args[1:])
+		} else {
+			sb.WriteString(r.consoleInspect(args[0], false, 0, nil))
+			args = args[1:]
+		}
+	}
+	for _, arg := range args {
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(r.consoleInspect(arg, false, 0, nil))
+	}
+	return sb.String()
+}
+
+func (r *Runtime) consoleFormatString(sb *strings.Builder, format string, args []Value) []Value {
+	for {
+		idx := strings.IndexByte(format, '%')
+		if idx == -1 || idx == len(format)-1 {
+			sb.WriteString(format)
+			return args
+		}
+		sb.WriteString(format[:idx])
+		c := format[idx+1]
+		format = format[idx+2:]
+		if c == '%' {
+			sb.WriteByte('%')
+			continue
+		}
+		if len(args) == 0 || strings.IndexByte("sdifoOc", c) == -1 {
+			sb.WriteByte('%')
+			sb.WriteByte(c)
+			continue
+		}
+		arg := args[0]
+		args = args[1:]
+		switch c {
+		case 's':
+			sb.WriteString(r.consoleInspect(arg, false, consoleMaxDepth, nil))
+		case 'd', 'i':
+			// ToInteger, except that NaN is kept
+			switch arg.(type) {
+			case *valueBigInt:
+				sb.WriteString(arg.String() + "n")
+			case *Object, *Symbol:
+				sb.WriteString("NaN")
+			default:
+				sb.WriteString(floatToValue(math.Trunc(arg.ToFloat())).String())
+			}
+		case 'f':
+			if _, ok := arg.(*Symbol); ok {
+				sb.WriteString("NaN")
+			} else {
+				sb.WriteString(parseFloat(arg.toString()).String())
+			}
+		case 'o', 'O':
+			sb.WriteString(r.consoleInspect(arg, true, 0, nil))
+		}
+		// %c (CSS) is consumed and ignored
+	}
+}
+
+// consoleInspect returns the representation of the value. Strings are quoted if nested is true. The properties of
+// the objects nested deeper than consoleMaxDepth are not shown.
+func (r *Runtime) consoleInspect(v Value, nested bool, depth int, seen []*Object) string {
+	switch v := v.(type) {
+	case valueString:
+		if nested {
+			return consoleQuote(v.String())
+		}
+		return v.String()
+	case *valueBigInt:
+		return v.String() + "n"
+	case *Symbol:
+		return v.descriptiveString().String()
+	case valueFloat:
+		if v == _negativeZero {
+			return "-0"
+		}
+		return v.String()
+	case *Object:
+		return r.consoleInspectObject(v, depth, seen)
+	}
+	return v.String()
+}
+
+func consoleQuote(s string) string {
+	q := strconv.Quote(s)
+	return "'" + strings.ReplaceAll(q[1:len(q)-1], `\"`, `"`) + "'"
+}
+
+func consoleKey(key string) string {
+	for i, c := range key {
+		if !(c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return consoleQuote(key)
+		}
+	}
+	if key == "" {
+		return "''"
+	}
+	return key
+}
+
+func (r *Runtime) consoleInspectObject(o *Object, depth int, seen []*Object) string {
+	for _, s := range seen {
+		if s == o {
+			return "[Circular]"
+		}
+	}
+	if _, ok := AssertFunction(o); ok {
+		name := nilSafe(o.self.getStr("name", nil)).String()
+		if name == "" {
+			return "[Function (anonymous)]"
+		}
+		return "[Function: " + name + "]"
+	}
+	switch obj := o.self.(type) {
+	case *errorObject:
+		if stack, ok := o.self.getStr("stack", nil).(valueString); ok {
+			return stack.String()
+		}
+		return o.toString().String()
+	case *dateObject:
+		if !obj.isSet() {
+			return "Invalid Date"
+		}
+		return obj.timeUTC().Format("2006-01-02T15:04:05.000Z")
+	case *regexpObject:
+		return o.toString().String()
+	}
+
+	var prefix string
+	if proto := o.self.proto(); proto == nil {
+		prefix = "[Object: null prototype] "
+	} else if ctor, ok := proto.self.getStr("constructor", nil).(*Object); ok {
+		if name := nilSafe(ctor.self.getStr("name", nil)).String(); name != "" && name != classObject && name != classArray {
+			prefix = name + " "
+		}
+	}
+	isArray := o.self.className() == classArray
+	if depth > consoleMaxDepth {
+		if isArray {
+			return "[Array]"
+		}
+		if prefix != "" {
+			return "[" + strings.TrimSuffix(prefix, " ") + "]"
+		}
+		return "[Object]"
+	}
+	seen = append(seen, o)
+	var items []string
+	switch obj := o.self.(type) {
+	case *mapObject:
+		prefix = fmt.Sprintf("Map(%d) ", obj.m.size)
+		iter := obj.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			items = append(items, r.consoleInspect(entry.key, true, depth+1, seen)+" => "+r.consoleInspect(entry.value, true, depth+1, seen))
+		}
+	case *setObject:
+		prefix = fmt.Sprintf("Set(%d) ", obj.m.size)
+		iter := obj.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			items = append(items, r.consoleInspect(entry.key, true, depth+1, seen))
+		}
+	case *Promise:
+		switch obj.state {
+		case PromiseStatePending:
+			items = append(items, "<pending>")
+		case PromiseStateRejected:
+			items = append(items, "<rejected> "+r.consoleInspect(obj.result, true, depth+1, seen))
+		default:
+			items = append(items, r.consoleInspect(obj.result, true, depth+1, seen))
+		}
+	}
+	var length int64 = -1
+	if isArray {
+		length = toLength(o.self.getStr("length", nil))
+		for i := int64(0); i < length && i < consoleMaxArrayItems; i++ {
+			items = append(items, r.consoleInspect(nilSafe(o.self.getIdx(valueInt(i), nil)), true, depth+1, seen))
+		}
+		if length > consoleMaxArrayItems {
+			items = append(items, fmt.Sprintf("... %d more items", length-consoleMaxArrayItems))
+		}
+	}
+	for _, key := range o.self.stringKeys(false, nil) {
+		name := key.string()
+		if isArray {
+			if idx, err := strconv.ParseInt(string(name), 10, 64); err == nil && idx >= 0 && idx < length {
+				continue
+			}
+		}
+		var value string
+		if prop, ok := o.self.getOwnPropStr(name).(*valueProperty); ok && prop.accessor {
+			switch {
+			case prop.getterFunc != nil && prop.setterFunc != nil:
+				value = "[Getter/Setter]"
+			case prop.getterFunc != nil:
+				value = "[Getter]"
+			default:
+				value = "[Setter]"
+			}
+		} else {
+			value = r.consoleInspect(nilSafe(o.self.getStr(name, nil)), true, depth+1, seen)
+		}
+		items = append(items, consoleKey(name.String())+": "+value)
+	}
+	if isArray {
+		if len(items) == 0 {
+			return prefix + "[]"
+		}
+		return prefix + "[ " + strings.Join(items, ", ") + " ]"
+	}
+	if len(items) == 0 {
+		return prefix + "{}"
+	}
+	return prefix + "{ " + strings.Join(items, ", ") + " }"
+}
+
+func (r *Runtime) consoleLabel(v Value) string {
+	if v == nil || v == _undefined {
+		return consoleDefaultLabel
+	}
+	return v.String()
+}
+
+func (r *Runtime) consoleLogger(level console.Level) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		r.consolePrint(level, r.consoleFormat(call.Arguments))
+		return _undefined
+	}
+}
+
+func (r *Runtime) console_dir(call FunctionCall) Value {
+	r.consolePrint(console.Log, r.consoleInspect(call.Argument(0), true, 0, nil))
+	return _undefined
+}
+
+func (r *Runtime) console_assert(call FunctionCall) Value {
+	if call.Argument(0).ToBoolean() {
+		return _undefined
+	}
+	msg := "Assertion failed"
+	if len(call.Arguments) > 1 {
+		msg += ": " + r.consoleFormat(call.Arguments[1:])
+	}
+	r.consolePrint(console.Error, msg)
+	return _undefined
+}
+
+func (r *Runtime) console_group(call FunctionCall) Value {
+	if len(call.Arguments) > 0 {
+		r.consolePrint(console.Log, r.consoleFormat(call.Arguments))
+	}
+	r.console.indent += 2
+	return _undefined
+}
+
+func (r *Runtime) console_groupEnd(FunctionCall) Value {
+	if r.console.indent > 0 {
+		r.console.indent -= 2
+	}
+	return _undefined
+}
+
+func (r *Runtime) console_count(call FunctionCall) Value {
+	label := r.consoleLabel(call.Argument(0))
+	if r.console.counts == nil {
+		r.console.counts = make(map[string]int)
+	}
+	r.console.counts[label]++
+	r.consolePrint(console.Log, label+": "+strconv.Itoa(r.console.counts[label]))
+	return _undefined
+}
+
+func (r *Runtime) console_countReset(call FunctionCall) Value {
+	label := r.consoleLabel(call.Argument(0))
+	if _, exists := r.console.counts[label]; !exists {
+		r.consolePrint(console.Warn, "Count for '"+label+"' does not exist")
+		return _undefined
+	}
+	r.console.counts[label] = 0
+	return _undefined
+}
+
+func (r *Runtime) console_time(call FunctionCall) Value {
+	label := r.consoleLabel(call.Argument(0))
+	if _, exists := r.console.timers[label]; exists {
+		r.consolePrint(console.Warn, "Timer '"+label+"' already exists")
+		return _undefined
+	}
+	if r.console.timers == nil {
+		r.console.timers = make(map[string]time.Time)
+	}
+	r.console.timers[label] = r.now()
+	return _undefined
+}
+
+// consoleElapsed prints the time elapsed since the timer has been started, followed by the data.
+func (r *Runtime) consoleElapsed(label string, data []Value) bool {
+	start, exists := r.console.timers[label]
+	if !exists {
+		r.consolePrint(console.Warn, "Timer '"+label+"' does not exist")
+		return false
+	}
+	var elapsed string
+	if d := r.now().Sub(start); d >= time.Second {
+		elapsed = strconv.FormatFloat(d.Seconds(), 'f', 3, 64) + "s"
+	} else {
+		elapsed = strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64) + "ms"
+	}
+	msg := label + ": " + elapsed
+	if len(data) > 0 {
+		msg += " " + r.consoleFormat(data)
+	}
+	r.consolePrint(console.Log, msg)
+	return true
+}
+
+func (r *Runtime) console_timeLog(call FunctionCall) Value {
+	var data []Value
+	if len(call.Arguments) > 1 {
+		data = call.Arguments[1:]
+	}
+	r.consoleElapsed(r.consoleLabel(call.Argument(0)), data)
+	return _undefined
+}
+
+func (r *Runtime) console_timeEnd(call FunctionCall) Value {
+	label := r.consoleLabel(call.Argument(0))
+	if r.consoleElapsed(label, nil) {
+		delete(r.console.timers, label)
+	}
+	return _undefined
+}
+
+// console_table prints the properties of the object (or the elements of the array) as the rows of a table, the
+// columns being the properties of the rows which are objects (restricted to the ones listed in the second
+// argument, if any) and the values of the other rows.
+func (r *Runtime) console_table(call FunctionCall) Value {
+	data, ok := call.Argument(0).(*Object)
+	if !ok {
+		r.consolePrint(console.Log, r.consoleFormat(call.Arguments))
+		return _undefined
+	}
+	var filter []string
+	if columns, ok := call.Argument(1).(*Object); ok {
+		for _, v := range r.iterableToList(columns, nil) {
+			filter = append(filter, v.String())
+		}
+	}
+
+	header := []string{"(index)"}
+	columnIdx := make(map[string]int)
+	for _, name := range filter {
+		columnIdx[name] = len(header)
+		header = append(header, name)
+	}
+	type tableRow struct {
+		cells    map[int]string
+		value    string
+		hasValue bool
+	}
+	var index []string
+	var tableRows []tableRow
+	for _, key := range data.self.stringKeys(false, nil) {
+		name := key.string()
+		index = append(index, name.String())
+		row := tableRow{cells: make(map[int]string)}
+		value := nilSafe(data.self.getStr(name, nil))
+		obj, isObj := value.(*Object)
+		if _, isFunc := AssertFunction(value); isObj && !isFunc {
+			for _, k := range obj.self.stringKeys(false, nil) {
+				col := k.String()
+				idx, exists := columnIdx[col]
+				if !exists {
+					if filter != nil {
+						continue
+					}
+					idx = len(header)
+					columnIdx[col] = idx
+					header = append(header, col)
+				}
+				row.cells[idx] = r.consoleInspect(nilSafe(obj.self.getStr(k.string(), nil)), true, consoleMaxDepth, nil)
+			}
+		} else {
+			row.value, row.hasValue = r.consoleInspect(value, true, consoleMaxDepth, nil), true
+		}
+		tableRows = append(tableRows, row)
+	}
+	valuesIdx := -1
+	for _, row := range tableRows {
+		if row.hasValue {
+			// the values of the non-object rows go to the last column
+			valuesIdx = len(header)
+			header = append(header, "Values")
+			break
+		}
+	}
+	rows := make([][]string, len(tableRows))
+	for n, row := range tableRows {
+		cells := make([]string, len(header))
+		cells[0] = index[n]
+		for i, cell := range row.cells {
+			cells[i] = cell
+		}
+		if row.hasValue {
+			cells[valuesIdx] = row.value
+		}
+		rows[n] = cells
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if w := utf8.RuneCountInString(cell) + 2; w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	var sb strings.Builder
+	line := func(left, mid, right string) {
+		sb.WriteString(left)
+		for i, w := range widths {
+			if i > 0 {
+				sb.WriteString(mid)
+			}
+			sb.WriteString(strings.Repeat("─", w))
+		}
+		sb.WriteString(right)
+	}
+	cells := func(row []string) {
+		sb.WriteString("│")
+		for i, cell := range row {
+			if i > 0 {
+				sb.WriteString("│")
+			}
+			sb.WriteByte(' ')
+			sb.WriteString(cell)
+			sb.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)-1))
+		}
+		sb.WriteString("│\n")
+	}
+	line("┌", "┬", "┐\n")
+	cells(header)
+	line("├", "┼", "┤\n")
+	for _, row := range rows {
+		cells(row)
+	}
+	line("└", "┴", "┘")
+	r.consolePrint(console.Log, sb.String())
+	return _undefined
+}
+
+func (r *Runtime) createConsole(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	for _, level := range []console.Level{console.Log, console.Info, console.Debug, console.Warn, console.Error} {
+		name := unistring.String(level)
+		o._putProp(name, r.newNativeFunc(r.consoleLogger(level), nil, name, nil, 0), true, false, true)
+	}
+	o._putProp("assert", r.newNativeFunc(r.console_assert, nil, "assert", nil, 0), true, false, true)
+	o._putProp("count", r.newNativeFunc(r.console_count, nil, "count", nil, 0), true, false, true)
+	o._putProp("countReset", r.newNativeFunc(r.console_countReset, nil, "countReset", nil, 0), true, false, true)
+	o._putProp("dir", r.newNativeFunc(r.console_dir, nil, "dir", nil, 0), true, false, true)
+	o._putProp("group", r.newNativeFunc(r.console_group, nil, "group", nil, 0), true, false, true)
+	o._putProp("groupCollapsed", r.newNativeFunc(r.console_group, nil, "groupCollapsed", nil, 0), true, false, true)
+	o._putProp("groupEnd", r.newNativeFunc(r.console_groupEnd, nil, "groupEnd", nil, 0), true, false, true)
+	o._putProp("table", r.newNativeFunc(r.console_table, nil, "table", nil, 1), true, false, true)
+	o._putProp("time", r.newNativeFunc(r.console_time, nil, "time", nil, 0), true, false, true)
+	o._putProp("timeEnd", r.newNativeFunc(r.console_timeEnd, nil, "timeEnd", nil, 0), true, false, true)
+	o._putProp("timeLog", r.newNativeFunc(r.console_timeLog, nil, "timeLog", nil, 0), true, false, true)
+
+	o._putSym(SymToStringTag, valueProp(asciiString("console"), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) initConsole() {
+	r.addToGlobal("console", r.newLazyObject(r.createConsole))
+}
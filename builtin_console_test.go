@@ -0,0 +1,148 @@
+package goja
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja/console"
+)
+
+func TestConsole(t *testing.T) {
+	const SCRIPT = `
+	console.log("a", 1, -0, 2n, true, null, undefined, Symbol("s"));
+	console.info("%s is %d years and %i days, %f%%%c", "Bob", 42.5, 3.7, 1.5, "color: red", "extra");
+	console.debug({a: 1, "b-c": "x", nested: {deep: {deeper: {deepest: 1}}}}, [1, "2", [3]]);
+	console.warn(new Map([["k", {v: 1}]]), new Set([1]), Object.create(null), function f() {}, () => {});
+	const cyclic = {name: "c"};
+	cyclic.self = cyclic;
+	console.error(cyclic, {get g() { return 1; }}, new Date(0), /re/g, Promise.resolve(1));
+	class Point { constructor() { this.x = 1; } }
+	console.dir(new Point());
+	console.group("Group");
+	console.log("inside\nmultiline");
+	console.groupCollapsed();
+	console.log("nested");
+	console.groupEnd();
+	console.groupEnd();
+	console.groupEnd();
+	console.log("outside");
+	console.assert(true, "not printed");
+	console.assert(false, "printed", 1);
+	console.count();
+	console.count("x");
+	console.count();
+	console.countReset();
+	console.count();
+	console.countReset("y");
+	console.time();
+	advance(1500);
+	console.timeLog(undefined, "data");
+	console.timeEnd();
+	console.timeEnd();
+	console.time("t");
+	console.time("t");
+	advance(0.25);
+	console.timeEnd("t");
+	console.table([{a: 1, b: "x"}, {a: 2, c: true}, 3]);
+	console.table({r1: {a: 1, b: 2}, r2: {a: 3}}, ["a"]);
+	console.table("not tabular");
+	`
+	var out []string
+	vm := New()
+	now := time.Unix(1e9, 0)
+	vm.SetTimeSource(func() time.Time {
+		return now
+	})
+	vm.SetConsolePrinter(console.PrinterFunc(func(level console.Level, msg string) {
+		out = append(out, string(level)+": "+msg)
+	}))
+	_ = vm.Set("advance", func(ms float64) {
+		now = now.Add(time.Duration(ms * float64(time.Millisecond)))
+	})
+	if _, err := vm.RunString(SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"log: a 1 -0 2n true null undefined Symbol(s)",
+		"info: Bob is 42 years and 3 days, 1.5% extra",
+		"debug: { a: 1, 'b-c': 'x', nested: { deep: { deeper: [Object] } } } [ 1, '2', [ 3 ] ]",
+		"warn: Map(1) { 'k' => { v: 1 } } Set(1) { 1 } [Object: null prototype] {} [Function: f] [Function (anonymous)]",
+		"error: { name: 'c', self: [Circular] } { g: [Getter] } 1970-01-01T00:00:00.000Z /re/g Promise { 1 }",
+		"log: Point { x: 1 }",
+		"log: Group",
+		"log:   inside\n  multiline",
+		"log:     nested",
+		"log: outside",
+		"error: Assertion failed: printed 1",
+		"log: default: 1",
+		"log: x: 1",
+		"log: default: 2",
+		"log: default: 1",
+		"warn: Count for 'y' does not exist",
+		"log: default: 1.500s data",
+		"log: default: 1.500s",
+		"warn: Timer 'default' does not exist",
+		"warn: Timer 't' already exists",
+		"log: t: 0.250ms",
+		"log: ┌─────────┬───┬─────┬──────┬────────┐\n" +
+			"│ (index) │ a │ b   │ c    │ Values │\n" +
+			"├─────────┼───┼─────┼──────┼────────┤\n" +
+			"│ 0       │ 1 │ 'x' │      │        │\n" +
+			"│ 1       │ 2 │     │ true │        │\n" +
+			"│ 2       │   │     │      │ 3      │\n" +
+			"└─────────┴───┴─────┴──────┴────────┘",
+		"log: ┌─────────┬───┐\n" +
+			"│ (index) │ a │\n" +
+			"├─────────┼───┤\n" +
+			"│ r1      │ 1 │\n" +
+			"│ r2      │ 3 │\n" +
+			"└─────────┴───┘",
+		"log: not tabular",
+	}
+	if len(out) != len(expected) {
+		t.Fatalf("Unexpected output:\n%s", strings.Join(out, "\n"))
+	}
+	for i, line := range out {
+		if line != expected[i] {
+			t.Errorf("Line %d: %q, expected %q", i, line, expected[i])
+		}
+	}
+}
+
+func TestConsoleFormatNumbers(t *testing.T) {
+	vm := New()
+	var out []string
+	vm.SetConsolePrinter(console.PrinterFunc(func(level console.Level, msg string) {
+		out = append(out, msg)
+	}))
+	if _, err := vm.RunString(`
+	console.log("%d %i %d %d %d %d %d", 5.5, -3.7, "42", "42px", 2n, Symbol(), {});
+	console.log("%f %f %f %f %f", "1.5px", "  3e2abc", "-Infinityx", {}, Symbol());
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || out[0] != "5 -3 42 NaN 2n NaN NaN" || out[1] != "1.5 300 -Infinity NaN NaN" {
+		t.Fatalf("Unexpected output: %q", out)
+	}
+}
+
+func TestConsoleWriterPrinter(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	vm := New()
+	vm.SetConsolePrinter(console.NewWriterPrinter(&stdout, &stderr))
+	if _, err := vm.RunString(`console.log("out"); console.error("err"); console.debug("debug")`); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "out\ndebug\n" || stderr.String() != "err\n" {
+		t.Fatalf("Unexpected output: %q, %q", stdout.String(), stderr.String())
+	}
+	vm.SetConsolePrinter(console.Discard)
+	if _, err := vm.RunString(`console.log("discarded")`); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.Len() != len("out\ndebug\n") {
+		t.Fatal("Output has not been discarded")
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"math"
 	"time"
+
+	"golang.org/x/text/language"
 )
 
 func (r *Runtime) makeDate(args []Value, utc bool) (t time.Time, valid bool) {
 	switch {
 	case len(args) >= 2:
-		t = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.Local)
+		t = time.Date(1970, time.January, 1, 0, 0, 0, 0, r.location())
 		t, valid = _dateSetYear(t, FunctionCall{Arguments: args}, 0, utc)
 	case len(args) == 0:
 		t = r.now()
@@ -24,7 +26,7 @@ func (r *Runtime) makeDate(args []Value, utc bool) (t time.Time, valid bool) {
 		if !valid {
 			pv := toPrimitive(args[0])
 			if val, ok := pv.(valueString); ok {
-				return dateParse(val.String())
+				return r.dateParse(val.String())
 			}
 			pv = pv.ToNumber()
 			var n int64
@@ -68,11 +70,11 @@ func (r *Runtime) builtin_newDate(args []Value, proto *Object) *Object {
 }
 
 func (r *Runtime) builtin_date(FunctionCall) Value {
-	return asciiString(dateFormat(r.now()))
+	return asciiString(r.now().In(r.location()).Format(dateTimeLayout))
 }
 
 func (r *Runtime) date_parse(call FunctionCall) Value {
-	t, set := dateParse(call.Argument(0).toString().String())
+	t, set := r.dateParse(call.Argument(0).toString().String())
 	if set {
 		return intToValue(timeToMsec(t))
 	}
@@ -197,40 +199,34 @@ func (r *Runtime) dateproto_toTimeString(call FunctionCall) Value {
 	panic(r.NewTypeError("Method Date.prototype.toTimeString is called on incompatible receiver"))
 }
 
-func (r *Runtime) dateproto_toLocaleString(call FunctionCall) Value {
+// dateToLocaleString implements the locale-sensitive methods using Intl.DateTimeFormat. required and defaults are
+// the parameters of ToDateTimeOptions.
+func (r *Runtime) dateToLocaleString(call FunctionCall, method, required, defaults string) Value {
 	obj := r.toObject(call.This)
 	if d, ok := obj.self.(*dateObject); ok {
-		if d.isSet() {
-			return asciiString(d.time().Format(datetimeLayout_en_GB))
-		} else {
+		if !d.isSet() {
 			return stringInvalidDate
 		}
+		def := r.intlLocale
+		if def == language.Und {
+			def = language.AmericanEnglish
+		}
+		f := r.newDateTimeFormat(call.Argument(0), call.Argument(1), required, defaults, def)
+		return newStringValue(f.format(d.msec))
 	}
-	panic(r.NewTypeError("Method Date.prototype.toLocaleString is called on incompatible receiver"))
+	panic(r.NewTypeError("Method Date.prototype.%s is called on incompatible receiver", method))
+}
+
+func (r *Runtime) dateproto_toLocaleString(call FunctionCall) Value {
+	return r.dateToLocaleString(call, "toLocaleString", "any", "all")
 }
 
 func (r *Runtime) dateproto_toLocaleDateString(call FunctionCall) Value {
-	obj := r.toObject(call.This)
-	if d, ok := obj.self.(*dateObject); ok {
-		if d.isSet() {
-			return asciiString(d.time().Format(dateLayout_en_GB))
-		} else {
-			return stringInvalidDate
-		}
-	}
-	panic(r.NewTypeError("Method Date.prototype.toLocaleDateString is called on incompatible receiver"))
+	return r.dateToLocaleString(call, "toLocaleDateString", "date", "date")
 }
 
 func (r *Runtime) dateproto_toLocaleTimeString(call FunctionCall) Value {
-	obj := r.toObject(call.This)
-	if d, ok := obj.self.(*dateObject); ok {
-		if d.isSet() {
-			return asciiString(d.time().Format(timeLayout_en_GB))
-		} else {
-			return stringInvalidDate
-		}
-	}
-	panic(r.NewTypeError("Method Date.prototype.toLocaleTimeString is called on incompatible receiver"))
+	return r.dateToLocaleString(call, "toLocaleTimeString", "time", "time")
 }
 
 func (r *Runtime) dateproto_valueOf(call FunctionCall) Value {
@@ -664,20 +660,12 @@ func _dateSetMilliseconds(year, mon, day, hours, min, sec int64, t time.Time, ca
 		return time.Time{}, false
 	}
 
-	var loc *time.Location
+	// t is in the local time zone of the Runtime unless utc is true
+	loc := t.Location()
 	if utc {
 		loc = time.UTC
-	} else {
-		loc = time.Local
-	}
-	r, ok := mkTime(year, mon, day, hours, min, sec, msec*1e6, loc)
-	if !ok {
-		return time.Time{}, false
-	}
-	if utc {
-		return r.In(time.Local), true
 	}
-	return r, true
+	return mkTime(year, mon, day, hours, min, sec, msec*1e6, loc)
 }
 
 func (r *Runtime) dateproto_setMilliseconds(call FunctionCall) Value {
@@ -907,7 +895,7 @@ func (r *Runtime) dateproto_setFullYear(call FunctionCall) Value {
 		if d.isSet() {
 			t = d.time()
 		} else {
-			t = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.Local)
+			t = time.Date(1970, time.January, 1, 0, 0, 0, 0, r.location())
 		}
 		t, ok := _dateSetFullYear(t, limitCallArgs(call, 3), 0, false)
 		if !ok {
@@ -0,0 +1,388 @@
+package goja
+
+import "github.com/dop251/goja/unistring"
+
+const (
+	classDisposableStack      = "DisposableStack"
+	classAsyncDisposableStack = "AsyncDisposableStack"
+)
+
+type disposableResource struct {
+	value  Value
+	method func(FunctionCall) Value
+	// the result of the method is awaited
+	async bool
+}
+
+// disposableStackObject is the backing object of DisposableStack and AsyncDisposableStack instances. Without a
+// prototype it is also used to hold the resources of a block containing using declarations.
+type disposableStackObject struct {
+	baseObject
+	resources []disposableResource
+	async     bool
+	disposed  bool
+
+	// the exception thrown by the block containing using declarations, nil if there is none
+	err Value
+}
+
+func (r *Runtime) newDisposableStack(proto *Object, async bool) *disposableStackObject {
+	o := &Object{runtime: r}
+	s := &disposableStackObject{
+		async: async,
+	}
+	s.class = classObject
+	s.val = o
+	s.extensible = true
+	s.prototype = proto
+	o.self = s
+	s.init()
+	return s
+}
+
+// getDisposeMethod returns the [Symbol.asyncDispose] method of v if async is true and v has one, or its
+// [Symbol.dispose] method. The second return value is true if the returned method is [Symbol.asyncDispose].
+func (r *Runtime) getDisposeMethod(v Value, async bool) (func(FunctionCall) Value, bool) {
+	if async {
+		if method := toMethod(r.getV(v, SymAsyncDispose)); method != nil {
+			return method, true
+		}
+	}
+	return toMethod(r.getV(v, SymDispose)), false
+}
+
+func (s *disposableStackObject) use(v Value, async bool) {
+	r := s.val.runtime
+	if v == _null || v == _undefined {
+		if async {
+			// the disposal must still be awaited
+			s.resources = append(s.resources, disposableResource{value: _undefined, async: true})
+		}
+		return
+	}
+	if _, ok := v.(*Object); !ok {
+		panic(r.NewTypeError("Disposable resource must be an object, null or undefined"))
+	}
+	method, isAsync := r.getDisposeMethod(v, async)
+	if method == nil {
+		panic(r.NewTypeError("Object is not disposable"))
+	}
+	if async && !isAsync {
+		m := method
+		method = func(call FunctionCall) Value {
+			m(call)
+			return _undefined
+		}
+	}
+	s.resources = append(s.resources, disposableResource{value: v, method: method, async: async})
+}
+
+func (s *disposableStackObject) add(method func(FunctionCall) Value) {
+	s.resources = append(s.resources, disposableResource{value: _undefined, method: method, async: s.async})
+}
+
+func (s *disposableStackObject) takeResources() []disposableResource {
+	resources := s.resources
+	s.resources = nil
+	s.disposed = true
+	return resources
+}
+
+func (r *Runtime) newSuppressedError(err, suppressed Value) *Object {
+	o := r.newErrorObject(r.global.SuppressedErrorPrototype, classError)
+	o._putProp("error", err, true, false, true)
+	o._putProp("suppressed", suppressed, true, false, true)
+	return o.val
+}
+
+// suppressError returns the exception to throw if err is thrown while suppressed is being thrown (nil means there
+// is no such exception).
+func (r *Runtime) suppressError(err, suppressed Value) Value {
+	if suppressed == nil {
+		return err
+	}
+	return r.newSuppressedError(err, suppressed)
+}
+
+// disposeResources disposes the resources in the reverse order. err is the exception which has been thrown
+// before the disposal (or nil), the result is the exception to throw afterwards (or nil).
+func (r *Runtime) disposeResources(resources []disposableResource, err Value) Value {
+	for i := len(resources) - 1; i >= 0; i-- {
+		res := resources[i]
+		if res.method == nil {
+			continue
+		}
+		if ex := r.vm.try(func() {
+			res.method(FunctionCall{This: res.value})
+		}); ex != nil {
+			err = r.suppressError(ex.val, err)
+		}
+	}
+	return err
+}
+
+// disposeResourcesAsync is like disposeResources, but it awaits the results of the async resources. It returns
+// a promise which is rejected with the resulting exception or fulfilled with undefined if there is none.
+func (r *Runtime) disposeResourcesAsync(resources []disposableResource, err Value) *Object {
+	pcap := r.newPromiseCapability(r.global.Promise)
+	var step func(i int)
+	step = func(i int) {
+		for ; i >= 0; i-- {
+			res := resources[i]
+			var result Value = _undefined
+			var promise *Promise
+			if ex := r.vm.try(func() {
+				if res.method != nil {
+					result = res.method(FunctionCall{This: res.value})
+				}
+				if res.async {
+					p := r.newPromiseCapability(r.global.Promise)
+					p.resolve(result)
+					promise = p.promise.self.(*Promise)
+				}
+			}); ex != nil {
+				err = r.suppressError(ex.val, err)
+				continue
+			}
+			if promise != nil {
+				next := i - 1
+				r.performPromiseThen(promise, r.newNativeFunc(func(FunctionCall) Value {
+					step(next)
+					return _undefined
+				}, nil, "", nil, 1), r.newNativeFunc(func(call FunctionCall) Value {
+					err = r.suppressError(call.Argument(0), err)
+					step(next)
+					return _undefined
+				}, nil, "", nil, 1), nil)
+				return
+			}
+		}
+		if err != nil {
+			pcap.reject(err)
+		} else {
+			pcap.resolve(_undefined)
+		}
+	}
+	step(len(resources) - 1)
+	return pcap.promise
+}
+
+// usingIntrinsics implement using declarations, see compiler.desugarUsing(). Their names cannot appear in the
+// source code.
+var usingIntrinsics = map[unistring.String]Intrinsic{
+	"%disposeScope": func(r *Runtime, args []Value) Value {
+		return r.newDisposableStack(nil, false).val
+	},
+	"%using": func(r *Runtime, args []Value) Value {
+		args[0].(*Object).self.(*disposableStackObject).use(args[1], false)
+		return args[1]
+	},
+	"%awaitUsing": func(r *Runtime, args []Value) Value {
+		args[0].(*Object).self.(*disposableStackObject).use(args[1], true)
+		return args[1]
+	},
+	"%disposeScopeError": func(r *Runtime, args []Value) Value {
+		args[0].(*Object).self.(*disposableStackObject).err = args[1]
+		return _undefined
+	},
+	"%dispose": func(r *Runtime, args []Value) Value {
+		s := args[0].(*Object).self.(*disposableStackObject)
+		if err := r.disposeResources(s.takeResources(), s.err); err != nil {
+			panic(err)
+		}
+		return _undefined
+	},
+	"%disposeAsync": func(r *Runtime, args []Value) Value {
+		s := args[0].(*Object).self.(*disposableStackObject)
+		return r.disposeResourcesAsync(s.takeResources(), s.err)
+	},
+}
+
+func (r *Runtime) iterProto_dispose(call FunctionCall) Value {
+	if ret := toMethod(r.getVStr(call.This, "return")); ret != nil {
+		ret(FunctionCall{This: call.This})
+	}
+	return _undefined
+}
+
+func (r *Runtime) toDisposableStack(v Value, async bool, method string) *disposableStackObject {
+	if obj, ok := v.(*Object); ok {
+		if s, ok := obj.self.(*disposableStackObject); ok && s.async == async {
+			return s
+		}
+	}
+	name := classDisposableStack
+	if async {
+		name = classAsyncDisposableStack
+	}
+	panic(r.NewTypeError("Method %s.prototype.%s called on incompatible receiver %s", name, method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) checkNotDisposed(s *disposableStackObject) {
+	if s.disposed {
+		panic(r.newError(r.global.ReferenceError, "Cannot use a disposed stack"))
+	}
+}
+
+func (r *Runtime) builtin_newDisposableStack(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew(classDisposableStack))
+	}
+	proto := r.getPrototypeFromCtor(newTarget, r.global.DisposableStack, r.global.DisposableStackPrototype)
+	return r.newDisposableStack(proto, false).val
+}
+
+func (r *Runtime) builtin_newAsyncDisposableStack(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew(classAsyncDisposableStack))
+	}
+	proto := r.getPrototypeFromCtor(newTarget, r.global.AsyncDisposableStack, r.global.AsyncDisposableStackPrototype)
+	return r.newDisposableStack(proto, true).val
+}
+
+func (r *Runtime) disposableStackProto_adopt(async bool) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		s := r.toDisposableStack(call.This, async, "adopt")
+		r.checkNotDisposed(s)
+		value := call.Argument(0)
+		onDispose, ok := assertCallable(call.Argument(1))
+		if !ok {
+			panic(r.NewTypeError("onDispose is not a function"))
+		}
+		s.add(func(FunctionCall) Value {
+			return onDispose(FunctionCall{This: _undefined, Arguments: []Value{value}})
+		})
+		return value
+	}
+}
+
+func (r *Runtime) disposableStackProto_defer(async bool) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		s := r.toDisposableStack(call.This, async, "defer")
+		r.checkNotDisposed(s)
+		onDispose, ok := assertCallable(call.Argument(0))
+		if !ok {
+			panic(r.NewTypeError("onDispose is not a function"))
+		}
+		s.add(onDispose)
+		return _undefined
+	}
+}
+
+func (r *Runtime) disposableStackProto_getDisposed(async bool) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		if r.toDisposableStack(call.This, async, "disposed").disposed {
+			return valueTrue
+		}
+		return valueFalse
+	}
+}
+
+func (r *Runtime) disposableStackProto_move(async bool) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		s := r.toDisposableStack(call.This, async, "move")
+		r.checkNotDisposed(s)
+		proto := r.global.DisposableStackPrototype
+		if async {
+			proto = r.global.AsyncDisposableStackPrototype
+		}
+		res := r.newDisposableStack(proto, async)
+		res.resources = s.takeResources()
+		return res.val
+	}
+}
+
+func (r *Runtime) disposableStackProto_use(async bool) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		s := r.toDisposableStack(call.This, async, "use")
+		r.checkNotDisposed(s)
+		value := call.Argument(0)
+		s.use(value, async)
+		return value
+	}
+}
+
+func (r *Runtime) disposableStackProto_dispose(call FunctionCall) Value {
+	s := r.toDisposableStack(call.This, false, "dispose")
+	if !s.disposed {
+		if err := r.disposeResources(s.takeResources(), nil); err != nil {
+			panic(err)
+		}
+	}
+	return _undefined
+}
+
+func (r *Runtime) asyncDisposableStackProto_disposeAsync(call FunctionCall) Value {
+	var resources []disposableResource
+	pcap := r.newPromiseCapability(r.global.Promise)
+	if !pcap.try(func() {
+		if s := r.toDisposableStack(call.This, true, "disposeAsync"); !s.disposed {
+			resources = s.takeResources()
+		}
+	}) {
+		return pcap.promise
+	}
+	return r.disposeResourcesAsync(resources, nil)
+}
+
+func (r *Runtime) createDisposableStackProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	o._putProp("constructor", r.global.DisposableStack, true, false, true)
+	o._putProp("adopt", r.newNativeFunc(r.disposableStackProto_adopt(false), nil, "adopt", nil, 2), true, false, true)
+	o._putProp("defer", r.newNativeFunc(r.disposableStackProto_defer(false), nil, "defer", nil, 1), true, false, true)
+	dispose := r.newNativeFunc(r.disposableStackProto_dispose, nil, "dispose", nil, 0)
+	o._putProp("dispose", dispose, true, false, true)
+	o.setOwnStr("disposed", &valueProperty{
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.disposableStackProto_getDisposed(false), nil, "get disposed", nil, 0),
+		accessor:     true,
+	}, false)
+	o._putProp("move", r.newNativeFunc(r.disposableStackProto_move(false), nil, "move", nil, 0), true, false, true)
+	o._putProp("use", r.newNativeFunc(r.disposableStackProto_use(false), nil, "use", nil, 1), true, false, true)
+
+	o._putSym(SymDispose, valueProp(dispose, true, false, true))
+	o._putSym(SymToStringTag, valueProp(asciiString(classDisposableStack), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createDisposableStack(val *Object) objectImpl {
+	return r.newNativeConstructOnly(val, r.builtin_newDisposableStack, r.global.DisposableStackPrototype, classDisposableStack, 0)
+}
+
+func (r *Runtime) createAsyncDisposableStackProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	o._putProp("constructor", r.global.AsyncDisposableStack, true, false, true)
+	o._putProp("adopt", r.newNativeFunc(r.disposableStackProto_adopt(true), nil, "adopt", nil, 2), true, false, true)
+	o._putProp("defer", r.newNativeFunc(r.disposableStackProto_defer(true), nil, "defer", nil, 1), true, false, true)
+	disposeAsync := r.newNativeFunc(r.asyncDisposableStackProto_disposeAsync, nil, "disposeAsync", nil, 0)
+	o._putProp("disposeAsync", disposeAsync, true, false, true)
+	o.setOwnStr("disposed", &valueProperty{
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.disposableStackProto_getDisposed(true), nil, "get disposed", nil, 0),
+		accessor:     true,
+	}, false)
+	o._putProp("move", r.newNativeFunc(r.disposableStackProto_move(true), nil, "move", nil, 0), true, false, true)
+	o._putProp("use", r.newNativeFunc(r.disposableStackProto_use(true), nil, "use", nil, 1), true, false, true)
+
+	o._putSym(SymAsyncDispose, valueProp(disposeAsync, true, false, true))
+	o._putSym(SymToStringTag, valueProp(asciiString(classAsyncDisposableStack), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createAsyncDisposableStack(val *Object) objectImpl {
+	return r.newNativeConstructOnly(val, r.builtin_newAsyncDisposableStack, r.global.AsyncDisposableStackPrototype, classAsyncDisposableStack, 0)
+}
+
+func (r *Runtime) initDisposableStack() {
+	r.global.DisposableStackPrototype = r.newLazyObject(r.createDisposableStackProto)
+	r.global.DisposableStack = r.newLazyObject(r.createDisposableStack)
+	r.addToGlobal(classDisposableStack, r.global.DisposableStack)
+
+	r.global.AsyncDisposableStackPrototype = r.newLazyObject(r.createAsyncDisposableStackProto)
+	r.global.AsyncDisposableStack = r.newLazyObject(r.createAsyncDisposableStack)
+	r.addToGlobal(classAsyncDisposableStack, r.global.AsyncDisposableStack)
+}
@@ -0,0 +1,228 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestUsingDeclaration(t *testing.T) {
+	const SCRIPT = `
+	const log = [];
+	function res(name) {
+		return {
+			[Symbol.dispose]() {
+				log.push(name);
+			}
+		};
+	}
+
+	function f() {
+		using a = res("a"), b = res("b");
+		{
+			using c = res("c"), d = null, e = undefined;
+			log.push("block");
+		}
+		log.push("body");
+		return "result";
+	}
+	assert.sameValue(f(), "result");
+	assert.sameValue(log.join(), "block,c,body,b,a");
+
+	log.length = 0;
+	for (using x of [res("x1"), res("x2")]) {
+		log.push("iteration");
+		if (log.length > 2) {
+			break;
+		}
+	}
+	assert.sameValue(log.join(), "iteration,x1,iteration,x2", "for-of");
+
+	log.length = 0;
+	for (using x = res("x"), i = res("i"); log.length === 0;) {
+		log.push("iteration");
+	}
+	assert.sameValue(log.join(), "iteration,i,x", "for");
+
+	log.length = 0;
+	switch (1) {
+	case 1:
+		using s = res("s");
+		log.push("case");
+	}
+	assert.sameValue(log.join(), "case,s", "switch");
+
+	log.length = 0;
+	function* gen() {
+		using g = res("g");
+		yield 1;
+		yield 2;
+	}
+	for (const v of gen()) {
+		break;
+	}
+	assert.sameValue(log.join(), "g", "generator");
+
+	log.length = 0;
+	class C {
+		static {
+			using r = res("static");
+			log.push("init");
+		}
+	}
+	assert.sameValue(log.join(), "init,static", "static block");
+
+	assert.throws(TypeError, () => {
+		using x = 1;
+	});
+	assert.throws(TypeError, () => {
+		using x = {};
+	});
+
+	function throwing(msg) {
+		return {
+			[Symbol.dispose]() {
+				throw new Error(msg);
+			}
+		};
+	}
+	try {
+		using a = throwing("a"), b = throwing("b");
+		throw new Error("body");
+	} catch (e) {
+		assert(e instanceof SuppressedError, "outer");
+		assert.sameValue(e.error.message, "a");
+		assert(e.suppressed instanceof SuppressedError, "inner");
+		assert.sameValue(e.suppressed.error.message, "b");
+		assert.sameValue(e.suppressed.suppressed.message, "body");
+	}
+
+	const err = new Error("dispose");
+	try {
+		using a = {[Symbol.dispose]() { throw err; }};
+	} catch (e) {
+		assert.sameValue(e, err, "single error is not wrapped");
+	}
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestUsingDeclarationTopLevel(t *testing.T) {
+	_, err := Compile("", "using x = null;", false)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if _, err := Compile("", "{ using x = null; }", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Compile("", "var using = 1; using\nx = 1;", false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAwaitUsingDeclaration(t *testing.T) {
+	const SCRIPT = `
+	const log = [];
+	{
+		await using a = {
+			async [Symbol.asyncDispose]() {
+				await null;
+				log.push("async");
+			}
+		};
+		await using b = {
+			[Symbol.dispose]() {
+				log.push("sync");
+			}
+		};
+		await using c = null;
+		log.push("body");
+	}
+	assert.sameValue(log.join(), "body,sync,async");
+
+	log.length = 0;
+	try {
+		await using a = {
+			[Symbol.asyncDispose]() {
+				return Promise.reject(new Error("rejected"));
+			}
+		};
+		throw new Error("body");
+	} catch (e) {
+		assert(e instanceof SuppressedError);
+		assert.sameValue(e.error.message, "rejected");
+		assert.sameValue(e.suppressed.message, "body");
+	}
+	`
+	testAsyncFuncWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestDisposableStack(t *testing.T) {
+	const SCRIPT = `
+	const log = [];
+	const stack = new DisposableStack();
+	const res = {[Symbol.dispose]() { log.push("use"); }};
+	assert.sameValue(stack.use(res), res);
+	assert.sameValue(stack.adopt(42, v => log.push("adopt " + v)), 42);
+	assert.sameValue(stack.defer(() => log.push("defer")), undefined);
+
+	const moved = stack.move();
+	assert(stack.disposed, "disposed after move");
+	assert.throws(ReferenceError, () => stack.use(res));
+	assert.sameValue(Object.getPrototypeOf(moved), DisposableStack.prototype);
+
+	{
+		using s = moved;
+	}
+	assert.sameValue(log.join(), "defer,adopt 42,use");
+	assert(moved.disposed);
+	moved.dispose();
+	assert.sameValue(log.length, 3, "dispose is idempotent");
+
+	assert.sameValue(DisposableStack.prototype[Symbol.dispose], DisposableStack.prototype.dispose);
+	assert.sameValue(Object.prototype.toString.call(stack), "[object DisposableStack]");
+	assert.throws(TypeError, () => DisposableStack());
+	assert.throws(TypeError, () => DisposableStack.prototype.dispose.call({}));
+
+	const e = new SuppressedError(1, 2, "msg");
+	assert.sameValue(e.error, 1);
+	assert.sameValue(e.suppressed, 2);
+	assert.sameValue(e.message, "msg");
+	assert.sameValue(e.name, "SuppressedError");
+	assert(e instanceof Error);
+
+	function* g() {
+		try {
+			yield 1;
+		} finally {
+			log.push("return");
+		}
+	}
+	const it = g();
+	it.next();
+	it[Symbol.dispose]();
+	assert.sameValue(log[log.length-1], "return", "Iterator.prototype[Symbol.dispose]");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestAsyncDisposableStack(t *testing.T) {
+	const SCRIPT = `
+	const log = [];
+	const stack = new AsyncDisposableStack();
+	stack.use({async [Symbol.asyncDispose]() { log.push("async"); }});
+	stack.use({[Symbol.dispose]() { log.push("sync"); }});
+	stack.defer(async () => {
+		await null;
+		log.push("defer");
+	});
+	assert.sameValue(await stack.disposeAsync(), undefined);
+	assert.sameValue(log.join(), "defer,sync,async");
+	assert(stack.disposed);
+
+	let rejected = false;
+	await AsyncDisposableStack.prototype.disposeAsync.call({}).catch(e => {
+		rejected = e instanceof TypeError;
+	});
+	assert(rejected, "incompatible receiver");
+	`
+	testAsyncFuncWithTestLib(SCRIPT, _undefined, t)
+}
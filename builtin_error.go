@@ -1,6 +1,10 @@
 package goja
 
-import "github.com/dop251/goja/unistring"
+import (
+	"math"
+
+	"github.com/dop251/goja/unistring"
+)
 
 const propNameStack = "stack"
 
@@ -100,8 +104,36 @@ func (e *errorObject) iterateStringKeys() iterNextFunc {
 
 func (e *errorObject) init() {
 	e.baseObject.init()
-	vm := e.val.runtime.vm
-	e.stack = vm.captureStack(make([]StackFrame, 0, len(vm.callStack)+1), 0)
+	r := e.val.runtime
+	e.stack = r.vm.captureStackTrace(r.errorStackTraceLimit())
+}
+
+// errorStackTraceLimit returns the maximum number of frames to capture for a new Error. It follows
+// Error.stackTraceLimit if it is set to a number and the Runtime default (see Runtime.SetStackTraceLimit)
+// otherwise. Like in V8, a value other than a number disables the capture.
+func (r *Runtime) errorStackTraceLimit() int {
+	switch v := r.global.Error.self.getOwnPropStr("stackTraceLimit").(type) {
+	case nil:
+		return r.vm.stackTraceLimit
+	case valueInt:
+		if v < 0 {
+			return 0
+		}
+		if v > math.MaxInt32 {
+			return math.MaxInt32
+		}
+		return int(v)
+	case valueFloat:
+		f := float64(v)
+		if !(f > 0) {
+			return 0
+		}
+		if f > math.MaxInt32 {
+			return math.MaxInt32
+		}
+		return int(f)
+	}
+	return 0
 }
 
 func (r *Runtime) newErrorObject(proto *Object, class string) *errorObject {
@@ -141,6 +173,24 @@ func (r *Runtime) builtin_AggregateError(args []Value, proto *Object) *Object {
 	return obj.val
 }
 
+func (r *Runtime) builtin_SuppressedError(args []Value, proto *Object) *Object {
+	obj := r.newErrorObject(proto, classError)
+	if len(args) > 2 && args[2] != nil && args[2] != _undefined {
+		obj._putProp("message", args[2].toString(), true, false, true)
+	}
+	var err, suppressed Value = _undefined, _undefined
+	if len(args) > 0 {
+		err = args[0]
+	}
+	if len(args) > 1 {
+		suppressed = args[1]
+	}
+	obj._putProp("error", err, true, false, true)
+	obj._putProp("suppressed", suppressed, true, false, true)
+
+	return obj.val
+}
+
 func writeErrorString(sb *valueStringBuilder, obj *Object) valueString {
 	var nameStr, msgStr valueString
 	name := obj.self.getStr("name", nil)
@@ -197,6 +247,10 @@ func (r *Runtime) initErrors() {
 	r.global.AggregateError = r.newNativeFuncConstructProto(r.builtin_AggregateError, "AggregateError", r.global.AggregateErrorPrototype, r.global.Error, 2)
 	r.addToGlobal("AggregateError", r.global.AggregateError)
 
+	r.global.SuppressedErrorPrototype = r.createErrorPrototype(stringSuppressedError)
+	r.global.SuppressedError = r.newNativeFuncConstructProto(r.builtin_SuppressedError, "SuppressedError", r.global.SuppressedErrorPrototype, r.global.Error, 3)
+	r.addToGlobal("SuppressedError", r.global.SuppressedError)
+
 	r.global.TypeErrorPrototype = r.createErrorPrototype(stringTypeError)
 
 	r.global.TypeError = r.newNativeFuncConstructProto(r.builtin_Error, "TypeError", r.global.TypeErrorPrototype, r.global.Error, 1)
@@ -130,7 +130,10 @@ func (r *Runtime) boundCallable(target func(FunctionCall) Value, boundArgs []Val
 		this = _undefined
 	}
 	return func(call FunctionCall) Value {
-		a := append(args, call.Arguments...)
+		a := call.Arguments
+		if len(args) > 0 {
+			a = append(args, call.Arguments...)
+		}
 		return target(FunctionCall{
 			This:      this,
 			Arguments: a,
@@ -148,7 +151,10 @@ func (r *Runtime) boundConstruct(f *Object, target func([]Value, *Object) *Objec
 		copy(args, boundArgs[1:])
 	}
 	return func(fargs []Value, newTarget *Object) *Object {
-		a := append(args, fargs...)
+		a := fargs
+		if len(args) > 0 {
+			a = append(args, fargs...)
+		}
 		if newTarget == f {
 			newTarget = nil
 		}
@@ -157,9 +163,13 @@ func (r *Runtime) boundConstruct(f *Object, target func([]Value, *Object) *Objec
 }
 
 func (r *Runtime) functionproto_bind(call FunctionCall) Value {
-	obj := r.toObject(call.This)
+	return r.bindFunction(r.toObject(call.This), call.Arguments)
+}
 
-	fcall := r.toCallable(call.This)
+// bindFunction creates a bound function exotic object. boundArgs[0], if present, is the bound 'this' value,
+// the rest are the bound arguments.
+func (r *Runtime) bindFunction(obj *Object, boundArgs []Value) *Object {
+	fcall := r.toCallable(obj)
 	construct := obj.self.assertConstructor()
 
 	var l = _positiveZero
@@ -179,12 +189,12 @@ func (r *Runtime) functionproto_bind(call FunctionCall) Value {
 				// no-op, li == 0
 			default:
 				if !math.IsNaN(float64(lenProp)) {
-					li = int64(math.Abs(float64(lenProp)))
+					li = int64(math.Trunc(float64(lenProp)))
 				} // else li = 0
 			}
 		}
-		if len(call.Arguments) > 1 {
-			li -= int64(len(call.Arguments)) - 1
+		if len(boundArgs) > 1 {
+			li -= int64(len(boundArgs)) - 1
 		}
 		if li < 0 {
 			li = 0
@@ -199,11 +209,20 @@ lenNotInt:
 	}
 
 	v := &Object{runtime: r}
-	ff := r.newNativeFuncAndConstruct(v, r.boundCallable(fcall, call.Arguments), r.boundConstruct(v, construct, call.Arguments), nil, nameStr.string(), l)
+	ff := r.newNativeFuncAndConstruct(v, r.boundCallable(fcall, boundArgs), r.boundConstruct(v, construct, boundArgs), nil, nameStr.string(), l)
 	bf := &boundFuncObject{
 		nativeFuncObject: *ff,
 		wrapped:          obj,
 	}
+	if len(boundArgs) > 0 {
+		bf.boundThis = boundArgs[0]
+		if len(boundArgs) > 1 {
+			bf.boundArgs = make([]Value, len(boundArgs)-1)
+			copy(bf.boundArgs, boundArgs[1:])
+		}
+	} else {
+		bf.boundThis = _undefined
+	}
 	bf.prototype = obj.self.proto()
 	v.self = bf
 
@@ -12,3 +12,108 @@ func TestHashbangInFunctionConstructor(t *testing.T) {
 	`
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
+
+func TestHashbangInScript(t *testing.T) {
+	r := New()
+	v, err := r.RunScript("test.js", "#!/usr/bin/env node\nnew Error().stack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "Error\n\tat test.js:2:1(1)\n" {
+		t.Fatalf("Unexpected stack: %q", s)
+	}
+
+	r.testScriptWithTestLib(`
+	assert.sameValue(eval("#!/usr/bin/env node\n42"), 42);
+	assert.throws(SyntaxError, function() {
+		eval(" #!/usr/bin/env node\n42");
+	});
+	assert.throws(SyntaxError, function() {
+		eval("42\n#!/usr/bin/env node");
+	});
+	`, _undefined, t)
+}
+
+func TestBoundFunction(t *testing.T) {
+	const SCRIPT = `
+	function f(a, b, c) {
+		return [this, a, b, c];
+	}
+	var thisArg = {};
+	var b = f.bind(thisArg, 1);
+	assert.sameValue(b.length, 2, "length");
+	assert.sameValue(b.name, "bound f", "name");
+	assert(compareArray(b(2, 3), [thisArg, 1, 2, 3]), "call");
+	assert(compareArray(b.call(null, 2), [thisArg, 1, 2, undefined]), "call()");
+	assert(compareArray(b.apply(null, [2, 3, 4]), [thisArg, 1, 2, 3]), "apply()");
+	assert.sameValue(f.bind(null, 1, 2, 3, 4).length, 0, "length with excess arguments");
+	assert.sameValue(f.bind().bind(null, 1).name, "bound bound f", "name of a bound bound function");
+	assert(compareArray(f.bind(thisArg, 1).bind(null, 2)(3), [thisArg, 1, 2, 3]), "bound bound call");
+
+	function g() {}
+	Object.defineProperty(g, "length", {value: -5.5});
+	assert.sameValue(g.bind().length, 0, "negative length");
+	Object.defineProperty(g, "length", {value: 3.7});
+	assert.sameValue(g.bind(null, 1).length, 2, "fractional length");
+	Object.defineProperty(g, "length", {value: Infinity});
+	assert.sameValue(g.bind(null, 1).length, Infinity, "infinite length");
+	Object.defineProperty(g, "name", {value: 42});
+	assert.sameValue(g.bind().name, "bound ", "non-string name");
+
+	function C(x, y) {
+		this.x = x;
+		this.y = y;
+	}
+	var BC = C.bind({}, 1);
+	var o = new BC(2);
+	assert.sameValue(o.x, 1, "x");
+	assert.sameValue(o.y, 2, "y");
+	assert(o instanceof C, "instanceof C");
+	assert(o instanceof BC, "instanceof BC");
+	assert.sameValue(Object.getPrototypeOf(o), C.prototype, "prototype");
+	assert(!BC.hasOwnProperty("prototype"), "bound functions have no prototype");
+
+	var newTarget;
+	function E() {
+		newTarget = new.target;
+	}
+	new (E.bind())();
+	assert.sameValue(newTarget, E, "new.target");
+	Reflect.construct(E.bind(), [], C);
+	assert.sameValue(newTarget, C, "explicit new.target");
+
+	assert.throws(TypeError, function() {
+		new (() => {}).bind()();
+	}, "arrow functions are not constructors");
+
+	var max = Math.max.bind(null, 10);
+	assert.sameValue(max(1, 20), 20, "native target");
+	assert.sameValue(max(), 10, "native target without arguments");
+
+	var sum = 0;
+	var add = function(a, b) { sum += a * b; }.bind(null, 2);
+	[1, 2, 3].forEach(add);
+	assert.sameValue(sum, 12, "bound callback");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func BenchmarkBoundFunctionCall(b *testing.B) {
+	vm := New()
+	_, err := vm.RunString(`
+	function f(a, b, c) {
+		return a + b + c;
+	}
+	var bound = f.bind(null, 1);
+	`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	prg := MustCompile("test.js", "for (var i = 0; i < 1000; i++) bound(2, 3);", false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := vm.RunProgram(prg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/dop251/goja/unistring"
 	"io"
 	"math"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
@@ -33,7 +34,11 @@ func (r *Runtime) builtin_parseInt(call FunctionCall) Value {
 }
 
 func (r *Runtime) builtin_parseFloat(call FunctionCall) Value {
-	m := parseFloatRegexp.FindStringSubmatch(call.Argument(0).toString().toTrimmedUTF8())
+	return parseFloat(call.Argument(0).toString())
+}
+
+func parseFloat(str valueString) Value {
+	m := parseFloatRegexp.FindStringSubmatch(str.toTrimmedUTF8())
 	if len(m) == 2 {
 		if s := m[1]; s != "" && s != "+" && s != "-" {
 			switch s {
@@ -344,6 +349,8 @@ func (r *Runtime) initGlobalObject() {
 	o._putProp("encodeURIComponent", r.newNativeFunc(r.builtin_encodeURIComponent, nil, "encodeURIComponent", nil, 1), true, false, true)
 	o._putProp("escape", r.newNativeFunc(r.builtin_escape, nil, "escape", nil, 1), true, false, true)
 	o._putProp("unescape", r.newNativeFunc(r.builtin_unescape, nil, "unescape", nil, 1), true, false, true)
+	o._putProp("queueMicrotask", r.newNativeFunc(r.builtin_queueMicrotask, nil, "queueMicrotask", nil, 1), true, false, true)
+	o._putProp("structuredClone", r.newNativeFunc(r.builtin_structuredClone, nil, "structuredClone", nil, 1), true, false, true)
 
 	o._putSym(SymToStringTag, valueProp(asciiString(classGlobal), false, false, true))
 
@@ -442,7 +449,7 @@ func parseInt(s string, base int) (Value, error) {
 	for ; i < len(s); i++ {
 		if n >= cutoff {
 			// n*base overflows
-			return parseLargeInt(float64(n), s[i:], base, sign)
+			return parseLargeInt(s, base, sign), nil
 		}
 		v := digitVal(s[i])
 		if v >= base {
@@ -453,7 +460,7 @@ func parseInt(s string, base int) (Value, error) {
 		n1 := n + int64(v)
 		if n1 < n || n1 > maxVal {
 			// n+v overflows
-			return parseLargeInt(float64(n)+float64(v), s[i+1:], base, sign)
+			return parseLargeInt(s, base, sign), nil
 		}
 		n = n1
 	}
@@ -464,6 +471,9 @@ func parseInt(s string, base int) (Value, error) {
 	}
 
 	if sign {
+		if n == 0 {
+			return _negativeZero, nil
+		}
 		n = -n
 	}
 	return intToValue(n), nil
@@ -472,21 +482,33 @@ Error:
 	return _NaN, err
 }
 
-func parseLargeInt(n float64, s string, base int, sign bool) (Value, error) {
+// parseLargeInt parses the leading digits of s which are known not to fit into int64. The result is
+// correctly rounded.
+func parseLargeInt(s string, base int, sign bool) Value {
 	i := 0
-	b := float64(base)
-	for ; i < len(s); i++ {
-		v := digitVal(s[i])
-		if v >= base {
-			break
+	for i < len(s) && digitVal(s[i]) < base {
+		i++
+	}
+	s = s[:i]
+	var n float64
+	if base == 10 {
+		// ParseFloat works in linear time even for a very long input and returns +Inf if it's out of range.
+		n, _ = strconv.ParseFloat(s, 64)
+	} else {
+		s = strings.TrimLeft(s, "0")
+		// Avoid converting huge numbers which are going to be out of range anyway.
+		if float64(len(s)-1)*math.Log2(float64(base)) > 1024 {
+			n = math.Inf(1)
+		} else {
+			x, _ := new(big.Int).SetString(s, base)
+			n, _ = new(big.Float).SetInt(x).Float64()
 		}
-		n = n*b + float64(v)
 	}
 	if sign {
 		n = -n
 	}
 	// We know it can't be represented as int, so use valueFloat instead of floatToValue
-	return valueFloat(n), nil
+	return valueFloat(n)
 }
 
 var (
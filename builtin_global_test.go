@@ -19,3 +19,33 @@ func TestDecodeURI(t *testing.T) {
 
 	testScript(SCRIPT, newStringValue("http://ru.wikipedia.org/wiki/Юникод"), t)
 }
+
+func TestParseIntFloat(t *testing.T) {
+	const SCRIPT = `
+	var ws = "\u0009\u000B\u000C\u0020\u00A0\uFEFF\u1680\u2000\u200A\u202F\u205F\u3000\u2028\u2029\n\r";
+	assert.sameValue(parseInt(ws + "42"), 42, "parseInt whitespace");
+	assert.sameValue(parseFloat(ws + "4.5"), 4.5, "parseFloat whitespace");
+	assert.sameValue(parseInt("\u180E1"), NaN, "U+180E is not whitespace");
+
+	assert.sameValue(parseInt("-0"), -0, "parseInt -0");
+	assert.sameValue(parseInt("-0x0"), -0, "parseInt -0x0");
+	assert.sameValue(parseFloat("-0"), -0, "parseFloat -0");
+	assert.sameValue(parseInt("  -0x1f"), -31, "hex");
+	assert.sameValue(parseInt("0x11", 10), 0, "hex prefix with radix 10");
+	assert.sameValue(parseInt("11", 37), NaN, "radix 37");
+
+	// correct rounding
+	assert.sameValue(parseInt("9007199254740993"), 9007199254740992, "2**53 + 1");
+	assert.sameValue(parseInt("123456789012345678901234567890"), 1.2345678901234568e+29, "long decimal");
+	assert.sameValue(parseInt("1" + "0".repeat(53) + "11", 2), 2**55 + 4, "long binary");
+	assert.sameValue(parseInt("0x" + "f".repeat(20)), 1.2089258196146292e+24, "long hex");
+
+	// huge inputs
+	assert.sameValue(parseInt("1".repeat(100000)), Infinity, "decimal overflow");
+	assert.sameValue(parseInt("-" + "z".repeat(100000), 36), -Infinity, "radix 36 overflow");
+	assert.sameValue(parseInt("0".repeat(100000) + "12", 3), 5, "leading zeros");
+	assert.sameValue(parseFloat("9".repeat(100000) + "e-99999"), 10, "parseFloat long mantissa");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
@@ -0,0 +1,595 @@
+package goja
+
+import (
+	"math"
+)
+
+const (
+	classIterator = "Iterator"
+)
+
+// iteratorHelperObject implements the objects returned by Iterator.prototype.map() and the other lazy helpers.
+// The spec defines them as generators, here the state is kept explicitly: step produces the next value (or
+// reports that the iteration is finished) and closeInner closes the inner iterator of flatMap().
+type iteratorHelperObject struct {
+	baseObject
+	underlying *iteratorRecord
+	step       func() (Value, bool)
+	closeInner func()
+	running    bool
+	done       bool
+}
+
+// wrapForValidIteratorObject is returned by Iterator.from() for the iterators that do not inherit from
+// Iterator.prototype.
+type wrapForValidIteratorObject struct {
+	baseObject
+	iterated *iteratorRecord
+}
+
+// getIteratorDirect implements GetIteratorDirect(): unlike getIterator() it does not call [Symbol.iterator] as
+// the object is the iterator itself.
+func (r *Runtime) getIteratorDirect(obj *Object) *iteratorRecord {
+	var next func(FunctionCall) Value
+	if nextFn, ok := obj.self.getStr("next", nil).(*Object); ok {
+		if call, ok := nextFn.self.assertCallable(); ok {
+			next = call
+		}
+	}
+	return &iteratorRecord{
+		iterator: obj,
+		next:     next,
+	}
+}
+
+// getIteratorFlattenable implements GetIteratorFlattenable(). Strings are iterated if iterateStrings is set, other
+// primitives are rejected.
+func (r *Runtime) getIteratorFlattenable(v Value, iterateStrings bool) *iteratorRecord {
+	obj, ok := v.(*Object)
+	if !ok {
+		if _, isStr := v.(valueString); !isStr || !iterateStrings {
+			panic(r.NewTypeError("%s is not an object", v))
+		}
+	}
+	method := toMethod(r.getV(v, SymIterator))
+	var iter Value
+	if method == nil {
+		iter = obj
+	} else {
+		iter = method(FunctionCall{This: v})
+	}
+	iterObj, ok := iter.(*Object)
+	if !ok {
+		panic(r.NewTypeError("Result of the Symbol.iterator method is not an object"))
+	}
+	return r.getIteratorDirect(iterObj)
+}
+
+// nextValue calls the next() method of the iterator and returns the value or false if the iteration is finished.
+// Unlike step() it does not catch exceptions.
+func (ir *iteratorRecord) nextValue() (Value, bool) {
+	r := ir.iterator.runtime
+	if ir.next == nil {
+		panic(r.NewTypeError("iterator.next is missing or not a function"))
+	}
+	res, ok := ir.next(FunctionCall{This: ir.iterator}).(*Object)
+	if !ok {
+		panic(r.NewTypeError("Iterator result is not an object"))
+	}
+	if iteratorComplete(res) {
+		ir.close()
+		return nil, false
+	}
+	return iteratorValue(res), true
+}
+
+// closeOnPanic closes the iterator if f panics, ignoring any errors thrown by return() as IteratorClose() does for
+// throw completions, and re-panics.
+func (ir *iteratorRecord) closeOnPanic(f func()) {
+	if ex := tryFunc(f); ex != nil {
+		_ = tryFunc(ir.returnIter)
+		panic(ex)
+	}
+}
+
+func (r *Runtime) toIteratorThis(v Value) *Object {
+	if obj, ok := v.(*Object); ok {
+		return obj
+	}
+	panic(r.NewTypeError("Iterator.prototype method called on a non-object %s", v))
+}
+
+// toIteratorCallback validates a callback argument of an Iterator.prototype method, closing the iterator if it's
+// not callable.
+func (r *Runtime) toIteratorCallback(obj *Object, v Value) func(FunctionCall) Value {
+	if fn, ok := v.(*Object); ok {
+		if call, ok := fn.self.assertCallable(); ok {
+			return call
+		}
+	}
+	(&iteratorRecord{iterator: obj}).closeOnPanic(func() {
+		panic(r.NewTypeError("%s is not a function", describeValue(v)))
+	})
+	return nil
+}
+
+// toIteratorLimit validates the argument of take() and drop(), closing the iterator if it's invalid.
+func (r *Runtime) toIteratorLimit(obj *Object, v Value) (limit float64) {
+	(&iteratorRecord{iterator: obj}).closeOnPanic(func() {
+		num := v.ToNumber()
+		if IsNaN(num) {
+			panic(r.newError(r.global.RangeError, "%s must be positive", v))
+		}
+		limit = num.ToFloat()
+		if !math.IsInf(limit, 0) {
+			limit = math.Trunc(limit)
+		}
+		if limit < 0 {
+			panic(r.newError(r.global.RangeError, "%s must be positive", v))
+		}
+	})
+	return
+}
+
+func (r *Runtime) newIteratorHelper(underlying *iteratorRecord, step func() (Value, bool)) *Object {
+	o := &Object{runtime: r}
+	h := &iteratorHelperObject{
+		underlying: underlying,
+		step:       step,
+	}
+	h.class = classObject
+	h.val = o
+	h.extensible = true
+	o.self = h
+	h.prototype = r.getIteratorHelperPrototype()
+	h.init()
+	return o
+}
+
+func (h *iteratorHelperObject) next() Value {
+	r := h.val.runtime
+	if h.running {
+		panic(r.NewTypeError("Iterator helper is already running"))
+	}
+	if h.done {
+		return r.createIterResultObject(_undefined, true)
+	}
+	h.running = true
+	var value Value
+	var ok bool
+	ex := tryFunc(func() {
+		value, ok = h.step()
+	})
+	h.running = false
+	if ex != nil {
+		h.done = true
+		panic(ex)
+	}
+	if !ok {
+		h.done = true
+		return r.createIterResultObject(_undefined, true)
+	}
+	return r.createIterResultObject(value, false)
+}
+
+func (h *iteratorHelperObject) doReturn() Value {
+	r := h.val.runtime
+	if h.running {
+		panic(r.NewTypeError("Iterator helper is already running"))
+	}
+	if !h.done {
+		h.done = true
+		if h.closeInner != nil {
+			h.underlying.closeOnPanic(h.closeInner)
+		}
+		h.underlying.returnIter()
+	}
+	return r.createIterResultObject(_undefined, true)
+}
+
+func (r *Runtime) toIteratorHelper(v Value, method string) *iteratorHelperObject {
+	if obj, ok := v.(*Object); ok {
+		if h, ok := obj.self.(*iteratorHelperObject); ok {
+			return h
+		}
+	}
+	panic(r.NewTypeError("Method Iterator Helper.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) iteratorHelperProto_next(call FunctionCall) Value {
+	return r.toIteratorHelper(call.This, "next").next()
+}
+
+func (r *Runtime) iteratorHelperProto_return(call FunctionCall) Value {
+	return r.toIteratorHelper(call.This, "return").doReturn()
+}
+
+func (r *Runtime) iteratorProto_map(call FunctionCall) Value {
+	obj := r.toIteratorThis(call.This)
+	mapper := r.toIteratorCallback(obj, call.Argument(0))
+	iterated := r.getIteratorDirect(obj)
+	var counter int64
+	return r.newIteratorHelper(iterated, func() (res Value, ok bool) {
+		var value Value
+		if value, ok = iterated.nextValue(); !ok {
+			return
+		}
+		iterated.closeOnPanic(func() {
+			res = mapper(FunctionCall{Arguments: []Value{value, valueInt(counter)}})
+		})
+		counter++
+		return
+	})
+}
+
+func (r *Runtime) iteratorProto_filter(call FunctionCall) Value {
+	obj := r.toIteratorThis(call.This)
+	predicate := r.toIteratorCallback(obj, call.Argument(0))
+	iterated := r.getIteratorDirect(obj)
+	var counter int64
+	return r.newIteratorHelper(iterated, func() (Value, bool) {
+		for {
+			value, ok := iterated.nextValue()
+			if !ok {
+				return nil, false
+			}
+			var selected bool
+			iterated.closeOnPanic(func() {
+				selected = predicate(FunctionCall{Arguments: []Value{value, valueInt(counter)}}).ToBoolean()
+			})
+			counter++
+			if selected {
+				return value, true
+			}
+		}
+	})
+}
+
+func (r *Runtime) iteratorProto_take(call FunctionCall) Value {
+	obj := r.toIteratorThis(call.This)
+	remaining := r.toIteratorLimit(obj, call.Argument(0))
+	iterated := r.getIteratorDirect(obj)
+	return r.newIteratorHelper(iterated, func() (Value, bool) {
+		if remaining == 0 {
+			iterated.returnIter()
+			return nil, false
+		}
+		if !math.IsInf(remaining, 1) {
+			remaining--
+		}
+		return iterated.nextValue()
+	})
+}
+
+func (r *Runtime) iteratorProto_drop(call FunctionCall) Value {
+	obj := r.toIteratorThis(call.This)
+	remaining := r.toIteratorLimit(obj, call.Argument(0))
+	iterated := r.getIteratorDirect(obj)
+	return r.newIteratorHelper(iterated, func() (Value, bool) {
+		for remaining > 0 {
+			if !math.IsInf(remaining, 1) {
+				remaining--
+			}
+			if _, ok := iterated.nextValue(); !ok {
+				return nil, false
+			}
+		}
+		return iterated.nextValue()
+	})
+}
+
+func (r *Runtime) iteratorProto_flatMap(call FunctionCall) Value {
+	obj := r.toIteratorThis(call.This)
+	mapper := r.toIteratorCallback(obj, call.Argument(0))
+	iterated := r.getIteratorDirect(obj)
+	var counter int64
+	var inner *iteratorRecord
+	helper := r.newIteratorHelper(iterated, func() (res Value, ok bool) {
+		for {
+			if inner == nil {
+				var value Value
+				if value, ok = iterated.nextValue(); !ok {
+					return
+				}
+				iterated.closeOnPanic(func() {
+					mapped := mapper(FunctionCall{Arguments: []Value{value, valueInt(counter)}})
+					inner = r.getIteratorFlattenable(mapped, false)
+				})
+				counter++
+			}
+			iterated.closeOnPanic(func() {
+				res, ok = inner.nextValue()
+			})
+			if ok {
+				return
+			}
+			inner = nil
+		}
+	})
+	helper.self.(*iteratorHelperObject).closeInner = func() {
+		if inner != nil {
+			inner.returnIter()
+		}
+	}
+	return helper
+}
+
+func (r *Runtime) iteratorProto_reduce(call FunctionCall) Value {
+	obj := r.toIteratorThis(call.This)
+	reducer := r.toIteratorCallback(obj, call.Argument(0))
+	iterated := r.getIteratorDirect(obj)
+	var accumulator Value
+	var counter int64
+	if len(call.Arguments) < 2 {
+		value, ok := iterated.nextValue()
+		if !ok {
+			panic(r.NewTypeError("Reduce of empty iterator with no initial value"))
+		}
+		accumulator = value
+		counter = 1
+	} else {
+		accumulator = call.Argument(1)
+	}
+	for {
+		value, ok := iterated.nextValue()
+		if !ok {
+			return accumulator
+		}
+		iterated.closeOnPanic(func() {
+			accumulator = reducer(FunctionCall{Arguments: []Value{accumulator, value, valueInt(counter)}})
+		})
+		counter++
+	}
+}
+
+func (r *Runtime) iteratorProto_toArray(call FunctionCall) Value {
+	obj := r.toIteratorThis(call.This)
+	iterated := r.getIteratorDirect(obj)
+	var values []Value
+	for {
+		value, ok := iterated.nextValue()
+		if !ok {
+			return r.newArrayValues(values)
+		}
+		values = append(values, value)
+	}
+}
+
+// iterateWithCallback implements the common part of forEach(), some(), every() and find(): fn is called for each
+// value and the iteration stops (closing the iterator) as soon as it returns false.
+func (r *Runtime) iterateWithCallback(call FunctionCall, fn func(value, result Value) bool) {
+	obj := r.toIteratorThis(call.This)
+	callback := r.toIteratorCallback(obj, call.Argument(0))
+	iterated := r.getIteratorDirect(obj)
+	var counter int64
+	for {
+		value, ok := iterated.nextValue()
+		if !ok {
+			return
+		}
+		var result Value
+		iterated.closeOnPanic(func() {
+			result = callback(FunctionCall{Arguments: []Value{value, valueInt(counter)}})
+		})
+		counter++
+		if !fn(value, result) {
+			iterated.returnIter()
+			return
+		}
+	}
+}
+
+func (r *Runtime) iteratorProto_forEach(call FunctionCall) Value {
+	r.iterateWithCallback(call, func(Value, Value) bool {
+		return true
+	})
+	return _undefined
+}
+
+func (r *Runtime) iteratorProto_some(call FunctionCall) Value {
+	res := valueFalse
+	r.iterateWithCallback(call, func(_, result Value) bool {
+		if result.ToBoolean() {
+			res = valueTrue
+			return false
+		}
+		return true
+	})
+	return res
+}
+
+func (r *Runtime) iteratorProto_every(call FunctionCall) Value {
+	res := valueTrue
+	r.iterateWithCallback(call, func(_, result Value) bool {
+		if !result.ToBoolean() {
+			res = valueFalse
+			return false
+		}
+		return true
+	})
+	return res
+}
+
+func (r *Runtime) iteratorProto_find(call FunctionCall) Value {
+	var res Value = _undefined
+	r.iterateWithCallback(call, func(value, result Value) bool {
+		if result.ToBoolean() {
+			res = value
+			return false
+		}
+		return true
+	})
+	return res
+}
+
+// iteratorProtoSetter implements SetterThatIgnoresPrototypeProperties() for Iterator.prototype.constructor and
+// Iterator.prototype[Symbol.toStringTag].
+func (r *Runtime) iteratorProtoSetter(name Value) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		obj, ok := call.This.(*Object)
+		if !ok {
+			panic(r.NewTypeError("Cannot set %s on a non-object", name))
+		}
+		if obj == r.getIteratorPrototype() {
+			panic(r.NewTypeError("Cannot set %s on Iterator.prototype", name))
+		}
+		value := call.Argument(0)
+		if obj.getOwnProp(name) == nil {
+			createDataPropertyOrThrow(obj, name, value)
+		} else {
+			obj.set(name, value, obj, true)
+		}
+		return _undefined
+	}
+}
+
+func (r *Runtime) builtin_newIterator(args []Value, newTarget *Object) *Object {
+	if newTarget == nil || newTarget == r.global.Iterator {
+		panic(r.NewTypeError("Abstract class Iterator not directly constructable"))
+	}
+	proto := r.getPrototypeFromCtor(newTarget, r.global.Iterator, r.getIteratorPrototype())
+	return r.newBaseObject(proto, classObject).val
+}
+
+func (r *Runtime) iterator_from(call FunctionCall) Value {
+	iterated := r.getIteratorFlattenable(call.Argument(0), true)
+	iterProto := r.getIteratorPrototype()
+	for proto := iterated.iterator.self.proto(); proto != nil; proto = proto.self.proto() {
+		if proto == iterProto {
+			return iterated.iterator
+		}
+	}
+	o := &Object{runtime: r}
+	w := &wrapForValidIteratorObject{
+		iterated: iterated,
+	}
+	w.class = classObject
+	w.val = o
+	w.extensible = true
+	o.self = w
+	w.prototype = r.getWrapForValidIteratorPrototype()
+	w.init()
+	return o
+}
+
+func (r *Runtime) toWrapForValidIterator(v Value, method string) *wrapForValidIteratorObject {
+	if obj, ok := v.(*Object); ok {
+		if w, ok := obj.self.(*wrapForValidIteratorObject); ok {
+			return w
+		}
+	}
+	panic(r.NewTypeError("Method %s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) wrapForValidIteratorProto_next(call FunctionCall) Value {
+	iterated := r.toWrapForValidIterator(call.This, "next").iterated
+	if iterated.next == nil {
+		panic(r.NewTypeError("iterator.next is missing or not a function"))
+	}
+	return iterated.next(FunctionCall{This: iterated.iterator})
+}
+
+func (r *Runtime) wrapForValidIteratorProto_return(call FunctionCall) Value {
+	iterator := r.toWrapForValidIterator(call.This, "return").iterated.iterator
+	ret := toMethod(iterator.self.getStr("return", nil))
+	if ret == nil {
+		return r.createIterResultObject(_undefined, true)
+	}
+	return ret(FunctionCall{This: iterator})
+}
+
+func (r *Runtime) createIterProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	o.setOwnStr("constructor", &valueProperty{
+		configurable: true,
+		getterFunc: r.newNativeFunc(func(FunctionCall) Value {
+			return r.global.Iterator
+		}, nil, "get constructor", nil, 0),
+		setterFunc: r.newNativeFunc(r.iteratorProtoSetter(asciiString("constructor")), nil, "set constructor", nil, 1),
+		accessor:   true,
+	}, false)
+	o._putProp("drop", r.newNativeFunc(r.iteratorProto_drop, nil, "drop", nil, 1), true, false, true)
+	o._putProp("every", r.newNativeFunc(r.iteratorProto_every, nil, "every", nil, 1), true, false, true)
+	o._putProp("filter", r.newNativeFunc(r.iteratorProto_filter, nil, "filter", nil, 1), true, false, true)
+	o._putProp("find", r.newNativeFunc(r.iteratorProto_find, nil, "find", nil, 1), true, false, true)
+	o._putProp("flatMap", r.newNativeFunc(r.iteratorProto_flatMap, nil, "flatMap", nil, 1), true, false, true)
+	o._putProp("forEach", r.newNativeFunc(r.iteratorProto_forEach, nil, "forEach", nil, 1), true, false, true)
+	o._putProp("map", r.newNativeFunc(r.iteratorProto_map, nil, "map", nil, 1), true, false, true)
+	o._putProp("reduce", r.newNativeFunc(r.iteratorProto_reduce, nil, "reduce", nil, 1), true, false, true)
+	o._putProp("some", r.newNativeFunc(r.iteratorProto_some, nil, "some", nil, 1), true, false, true)
+	o._putProp("take", r.newNativeFunc(r.iteratorProto_take, nil, "take", nil, 1), true, false, true)
+	o._putProp("toArray", r.newNativeFunc(r.iteratorProto_toArray, nil, "toArray", nil, 0), true, false, true)
+
+	o._putSym(SymIterator, valueProp(r.newNativeFunc(r.returnThis, nil, "[Symbol.iterator]", nil, 0), true, false, true))
+	o._putSym(SymDispose, valueProp(r.newNativeFunc(r.iterProto_dispose, nil, "[Symbol.dispose]", nil, 0), true, false, true))
+	o._putSym(SymToStringTag, &valueProperty{
+		configurable: true,
+		getterFunc: r.newNativeFunc(func(FunctionCall) Value {
+			return asciiString(classIterator)
+		}, nil, "get [Symbol.toStringTag]", nil, 0),
+		setterFunc: r.newNativeFunc(r.iteratorProtoSetter(SymToStringTag), nil, "set [Symbol.toStringTag]", nil, 1),
+		accessor:   true,
+	})
+	return o
+}
+
+func (r *Runtime) getIteratorPrototype() *Object {
+	var o *Object
+	if o = r.global.IteratorPrototype; o == nil {
+		o = &Object{runtime: r}
+		r.global.IteratorPrototype = o
+		o.self = r.createIterProto(o)
+	}
+	return o
+}
+
+func (r *Runtime) createIteratorHelperProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.getIteratorPrototype(), classObject)
+
+	o._putProp("next", r.newNativeFunc(r.iteratorHelperProto_next, nil, "next", nil, 0), true, false, true)
+	o._putProp("return", r.newNativeFunc(r.iteratorHelperProto_return, nil, "return", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString("Iterator Helper"), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) getIteratorHelperPrototype() *Object {
+	var o *Object
+	if o = r.global.IteratorHelperPrototype; o == nil {
+		o = &Object{runtime: r}
+		r.global.IteratorHelperPrototype = o
+		o.self = r.createIteratorHelperProto(o)
+	}
+	return o
+}
+
+func (r *Runtime) createWrapForValidIteratorProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.getIteratorPrototype(), classObject)
+
+	o._putProp("next", r.newNativeFunc(r.wrapForValidIteratorProto_next, nil, "next", nil, 0), true, false, true)
+	o._putProp("return", r.newNativeFunc(r.wrapForValidIteratorProto_return, nil, "return", nil, 0), true, false, true)
+
+	return o
+}
+
+func (r *Runtime) getWrapForValidIteratorPrototype() *Object {
+	var o *Object
+	if o = r.global.WrapForValidIteratorPrototype; o == nil {
+		o = &Object{runtime: r}
+		r.global.WrapForValidIteratorPrototype = o
+		o.self = r.createWrapForValidIteratorProto(o)
+	}
+	return o
+}
+
+func (r *Runtime) createIterator(val *Object) objectImpl {
+	o := r.newNativeConstructOnly(val, r.builtin_newIterator, r.getIteratorPrototype(), classIterator, 0)
+	o._putProp("from", r.newNativeFunc(r.iterator_from, nil, "from", nil, 1), true, false, true)
+	return o
+}
+
+func (r *Runtime) initIterator() {
+	r.global.Iterator = r.newLazyObject(r.createIterator)
+	r.addToGlobal(classIterator, r.global.Iterator)
+}
@@ -0,0 +1,81 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestIteratorHelpers(t *testing.T) {
+	const SCRIPT = `
+	function* gen(n) {
+		for (let i = 0; i < n; i++) {
+			yield i;
+		}
+	}
+
+	assert(compareArray(gen(10).filter(x => x % 2).map((x, i) => x * 10 + i).drop(1).take(2).toArray(), [31, 52]), "chain");
+	assert(compareArray(gen(3).flatMap(x => [x, x]).toArray(), [0, 0, 1, 1, 2, 2]), "flatMap");
+	assert.throws(TypeError, () => [1].values().flatMap(x => "ab").next(), "flatMap rejects strings");
+	assert.sameValue(gen(5).reduce((a, b) => a + b), 10, "reduce");
+	assert.sameValue(gen(5).reduce((a, b) => a + b, 100), 110, "reduce with initial value");
+	assert.throws(TypeError, () => gen(0).reduce((a, b) => a + b), "reduce empty");
+	assert(gen(5).some(x => x === 4), "some");
+	assert(!gen(5).every(x => x < 4), "every");
+	assert.sameValue(gen(5).find(x => x > 2), 3, "find");
+	var seen = [];
+	assert.sameValue(gen(3).forEach((x, i) => seen.push(x + ":" + i)), undefined);
+	assert(compareArray(seen, ["0:0", "1:1", "2:2"]), "forEach");
+	assert(compareArray(new Set([1, 2, 3]).values().map(x => x * 2).toArray(), [2, 4, 6]), "set iterator");
+
+	assert.throws(RangeError, () => gen(1).take(-1));
+	assert.throws(RangeError, () => gen(1).drop(NaN));
+	assert(compareArray(gen(3).take(Infinity).toArray(), [0, 1, 2]), "take(Infinity)");
+
+	// closing
+	var closed = 0;
+	var it = {
+		i: 0,
+		next() { return {value: this.i++, done: false}; },
+		return() { closed++; return {}; },
+		__proto__: Iterator.prototype,
+	};
+	assert(compareArray(it.take(2).toArray(), [0, 1]), "take closes");
+	assert.sameValue(closed, 1);
+	assert.sameValue(it.find(x => x > 5), 6);
+	assert.sameValue(closed, 2);
+	assert.throws(TypeError, () => it.map(1));
+	assert.sameValue(closed, 3, "closed on invalid callback");
+	var helper = it.map(x => x);
+	helper.next();
+	assert.sameValue(helper.return().done, true);
+	assert.sameValue(closed, 4, "return() closes the underlying iterator");
+	assert.sameValue(helper.next().done, true);
+	assert.throws(Error, () => it.map(() => { throw new Error("x"); }).next());
+	assert.sameValue(closed, 5, "closed when the mapper throws");
+
+	// Iterator.from
+	var plain = {i: 0, next() { return {value: this.i, done: this.i++ > 1}; }};
+	var wrapped = Iterator.from(plain);
+	assert.sameValue(Object.getPrototypeOf(Object.getPrototypeOf(wrapped)), Iterator.prototype);
+	assert(compareArray(wrapped.toArray(), [0, 1]), "from");
+	var g = gen(1);
+	assert.sameValue(Iterator.from(g), g, "from returns iterators as is");
+	assert(compareArray(Iterator.from("ab").toArray(), ["a", "b"]), "from string");
+	assert.throws(TypeError, () => Iterator.from(1));
+
+	// the constructor
+	assert.throws(TypeError, () => new Iterator());
+	assert.throws(TypeError, () => Iterator());
+	class MyIter extends Iterator {
+		next() { return {done: true}; }
+	}
+	assert(new MyIter() instanceof Iterator);
+	assert.sameValue(Iterator.prototype.constructor, Iterator);
+	assert.sameValue(Iterator.prototype[Symbol.toStringTag], "Iterator");
+	assert.sameValue(Object.prototype.toString.call(gen(1).map(x => x)), "[object Iterator Helper]");
+	var o = Object.create(Iterator.prototype);
+	o[Symbol.toStringTag] = "Mine";
+	assert.sameValue(Object.getOwnPropertyDescriptor(o, Symbol.toStringTag).value, "Mine");
+	assert.throws(TypeError, () => { Iterator.prototype.constructor = 1; });
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
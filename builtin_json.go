@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode/utf16"
@@ -90,6 +91,9 @@ func (r *Runtime) builtinJSON_decodeObject(d *json.Decoder) (*Object, error) {
 			return nil, err
 		}
 
+		if r.propertyLimits != nil {
+			r.checkNewProperty(object.self.(*baseObject))
+		}
 		object.self._putProp(unistring.NewFromString(key), value, true, true, true)
 	}
 	return object, nil
@@ -171,9 +175,11 @@ type _builtinJSON_stringifyContext struct {
 	replacerFunction func(FunctionCall) Value
 	gap              string
 	indent           string
-	stack            []*Object
+	stack            objectStack
 	propertyList     []Value
 	allAscii         bool
+	// produce the RFC 8785 canonical form, see Runtime.MarshalCanonicalJSON()
+	canonical bool
 }
 
 func (r *Runtime) builtinJSON_stringify(call FunctionCall) Value {
@@ -278,8 +284,9 @@ func (ctx *_builtinJSON_stringifyContext) do(v Value) bool {
 func (ctx *_builtinJSON_stringifyContext) str(key Value, holder *Object) bool {
 	value := nilSafe(holder.get(key, nil))
 
-	if object, ok := value.(*Object); ok {
-		if toJSON, ok := object.self.getStr("toJSON", nil).(*Object); ok {
+	switch value.(type) {
+	case *Object, *valueBigInt:
+		if toJSON, ok := ctx.r.getVStr(value, "toJSON").(*Object); ok {
 			if c, ok := toJSON.self.assertCallable(); ok {
 				value = c(FunctionCall{
 					This:      value,
@@ -315,9 +322,15 @@ func (ctx *_builtinJSON_stringifyContext) str(key Value, holder *Object) bool {
 				if err != nil {
 					panic(err)
 				}
-				ctx.buf.Write(b)
-				ctx.allAscii = false
-				return true
+				if !ctx.canonical {
+					ctx.buf.Write(b)
+					ctx.allAscii = false
+					return true
+				}
+				value, err = ctx.r.builtinJSON_decodeValue(json.NewDecoder(bytes.NewReader(b)))
+				if err != nil {
+					panic(err)
+				}
 			} else {
 				switch o1.className() {
 				case classNumber:
@@ -349,19 +362,21 @@ func (ctx *_builtinJSON_stringifyContext) str(key Value, holder *Object) bool {
 	case valueFloat:
 		if !math.IsNaN(float64(value1)) && !math.IsInf(float64(value1), 0) {
 			ctx.buf.WriteString(value.String())
+		} else if ctx.canonical {
+			ctx.r.typeErrorResult(true, "Cannot serialize %s in canonical JSON", value1.String())
 		} else {
 			ctx.buf.WriteString("null")
 		}
 	case valueNull:
 		ctx.buf.WriteString("null")
+	case *valueBigInt:
+		ctx.r.typeErrorResult(true, "Do not know how to serialize a BigInt")
 	case *Object:
-		for _, object := range ctx.stack {
-			if value1 == object {
-				ctx.r.typeErrorResult(true, "Converting circular structure to JSON")
-			}
+		if ctx.stack.contains(value1) {
+			ctx.r.typeErrorResult(true, "Converting circular structure to JSON")
 		}
-		ctx.stack = append(ctx.stack, value1)
-		defer func() { ctx.stack = ctx.stack[:len(ctx.stack)-1] }()
+		ctx.stack.push(value1)
+		defer ctx.stack.pop()
 		if _, ok := value1.self.assertCallable(); !ok {
 			if isArray(value1) {
 				ctx.ja(value1)
@@ -439,6 +454,11 @@ func (ctx *_builtinJSON_stringifyContext) jo(object *Object) {
 	} else {
 		props = ctx.propertyList
 	}
+	if ctx.canonical {
+		sort.Slice(props, func(i, j int) bool {
+			return compareUTF16(props[i].toString(), props[j].toString()) < 0
+		})
+	}
 
 	empty := true
 	for _, name := range props {
@@ -519,6 +539,50 @@ func (ctx *_builtinJSON_stringifyContext) quote(str valueString) {
 	ctx.buf.WriteByte('"')
 }
 
+// compareUTF16 compares the strings as sequences of UTF-16 code units.
+func compareUTF16(a, b valueString) int {
+	la, lb := a.length(), b.length()
+	for i := 0; i < la && i < lb; i++ {
+		if ca, cb := a.charAt(i), b.charAt(i); ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return la - lb
+}
+
+// MarshalCanonicalJSON serialises v the same way as JSON.stringify(v) (including calling toJSON() methods), but
+// produces the canonical form defined by RFC 8785 (JSON Canonicalization Scheme): object properties are sorted by
+// their names compared as UTF-16 code unit sequences. The number formatting and string escaping of
+// JSON.stringify() already match the RFC. As the result is deterministic it can be hashed or signed:
+//
+//	vm.Set("canonicalJSON", func(v goja.Value) (string, error) {
+//		b, err := vm.MarshalCanonicalJSON(v)
+//		return string(b), err
+//	})
+//
+// Unlike JSON.stringify(), NaN and Infinity cause a TypeError rather than being serialised as null, and so do the
+// values that cannot be serialised at all (undefined, functions and symbols). Any exception (including the ones
+// thrown by toJSON() methods) is returned as *Exception.
+func (r *Runtime) MarshalCanonicalJSON(v Value) (b []byte, err error) {
+	err = r.runWrapped(func() {
+		ctx := _builtinJSON_stringifyContext{
+			r:         r,
+			canonical: true,
+		}
+		if !ctx.do(nilSafe(v)) {
+			panic(r.NewTypeError("Value %s cannot be serialised as JSON", nilSafe(v).String()))
+		}
+		b = ctx.buf.Bytes()
+	})
+	if err != nil {
+		b = nil
+	}
+	return
+}
+
 func (r *Runtime) initJSON() {
 	JSON := r.newBaseObject(r.global.ObjectPrototype, classObject)
 	JSON._putProp("parse", r.newNativeFunc(r.builtinJSON_parse, nil, "parse", nil, 2), true, false, true)
@@ -65,6 +65,61 @@ func TestQuoteMalformedSurrogatePair(t *testing.T) {
 	testScript(`JSON.stringify("\uD800")`, asciiString(`"\ud800"`), t)
 }
 
+type testJSONMarshaler string
+
+func (m testJSONMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(m), nil
+}
+
+func TestMarshalCanonicalJSON(t *testing.T) {
+	vm := New()
+	v, err := vm.RunString(`({
+		"\u20ac": "Euro Sign",
+		"\r": "Carriage Return",
+		"\ufb33": "Hebrew Letter Dalet With Dagesh",
+		"1": "One",
+		"\ud83d\ude00": "Emoji: Grinning Face",
+		"\u0080": "Control",
+		"\u00f6": "Latin Small Letter O With Diaeresis",
+		nested: {b: [1e30, 4.50, 2e-3, 0.000001, 1e-7, -0], a: {toJSON() { return {z: 1, y: undefined, x: null}; }}},
+		skipped: undefined,
+	})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := vm.MarshalCanonicalJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `{"\r":"Carriage Return","1":"One","nested":{"a":{"x":null,"z":1},"b":[1e+30,4.5,0.002,0.000001,1e-7,0]},` +
+		"\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\"," +
+		"\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+	if string(b) != expected {
+		t.Fatalf("Unexpected value: %s", b)
+	}
+
+	vm.Set("m", map[string]interface{}{"b": testJSONMarshaler(`{"d": 1, "c": [true]}`), "a": time.Unix(0, 0).UTC()})
+	b, err = vm.MarshalCanonicalJSON(vm.Get("m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"a":"1970-01-01T00:00:00Z","b":{"c":[true],"d":1}}` {
+		t.Fatalf("Unexpected value: %s", b)
+	}
+
+	for _, src := range []string{"NaN", "({a: Infinity})", "undefined", "(function() {})", "({get a() { throw new Error('boom'); }})"} {
+		v, err := vm.RunString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := vm.MarshalCanonicalJSON(v); err == nil {
+			t.Fatalf("%s: expected an error", src)
+		} else if _, ok := err.(*Exception); !ok {
+			t.Fatalf("%s: unexpected error type: %T", src, err)
+		}
+	}
+}
+
 func BenchmarkJSONStringify(b *testing.B) {
 	b.StopTimer()
 	vm := New()
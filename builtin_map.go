@@ -80,12 +80,12 @@ func (mo *mapObject) exportToMap(dst reflect.Value, typ reflect.Type, ctx *objec
 			break
 		}
 		keyVal := reflect.New(keyTyp).Elem()
-		err := r.toReflectValue(entry.key, keyVal, ctx)
+		err := r.toReflectValueAt(entry.key, keyVal, ctx, entry.key)
 		if err != nil {
 			return err
 		}
 		elemVal := reflect.New(elemTyp).Elem()
-		err = r.toReflectValue(entry.value, elemVal, ctx)
+		err = r.toReflectValueAt(entry.value, elemVal, ctx, entry.key)
 		if err != nil {
 			return err
 		}
@@ -144,7 +144,7 @@ func (r *Runtime) mapProto_set(call FunctionCall) Value {
 	if !ok {
 		panic(r.NewTypeError("Method Map.prototype.set called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
 	}
-	mo.m.set(call.Argument(0), call.Argument(1))
+	r.setMapEntry(&mo.baseObject, mo.m, call.Argument(0), call.Argument(1))
 	return call.This
 }
 
@@ -221,7 +221,7 @@ func (r *Runtime) builtin_newMap(args []Value, newTarget *Object) *Object {
 					itemObj := r.toObject(item)
 					k := nilSafe(itemObj.self.getIdx(i0, nil))
 					v := nilSafe(itemObj.self.getIdx(i1, nil))
-					mo.m.set(k, v)
+					r.setMapEntry(&mo.baseObject, mo.m, k, v)
 				})
 			} else {
 				iter.iterate(func(item Value) {
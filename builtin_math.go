@@ -3,6 +3,8 @@ package goja
 import (
 	"math"
 	"math/bits"
+
+	"github.com/dop251/goja/fdlibm"
 )
 
 func (r *Runtime) math_abs(call FunctionCall) Value {
@@ -10,38 +12,38 @@ func (r *Runtime) math_abs(call FunctionCall) Value {
 }
 
 func (r *Runtime) math_acos(call FunctionCall) Value {
-	return floatToValue(math.Acos(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Acos(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_acosh(call FunctionCall) Value {
-	return floatToValue(math.Acosh(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Acosh(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_asin(call FunctionCall) Value {
-	return floatToValue(math.Asin(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Asin(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_asinh(call FunctionCall) Value {
-	return floatToValue(math.Asinh(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Asinh(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_atan(call FunctionCall) Value {
-	return floatToValue(math.Atan(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Atan(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_atanh(call FunctionCall) Value {
-	return floatToValue(math.Atanh(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Atanh(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_atan2(call FunctionCall) Value {
 	y := call.Argument(0).ToFloat()
 	x := call.Argument(1).ToFloat()
 
-	return floatToValue(math.Atan2(y, x))
+	return floatToValue(fdlibm.Atan2(y, x))
 }
 
 func (r *Runtime) math_cbrt(call FunctionCall) Value {
-	return floatToValue(math.Cbrt(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Cbrt(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_ceil(call FunctionCall) Value {
@@ -53,25 +55,29 @@ func (r *Runtime) math_clz32(call FunctionCall) Value {
 }
 
 func (r *Runtime) math_cos(call FunctionCall) Value {
-	return floatToValue(math.Cos(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Cos(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_cosh(call FunctionCall) Value {
-	return floatToValue(math.Cosh(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Cosh(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_exp(call FunctionCall) Value {
-	return floatToValue(math.Exp(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Exp(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_expm1(call FunctionCall) Value {
-	return floatToValue(math.Expm1(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Expm1(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_floor(call FunctionCall) Value {
 	return floatToValue(math.Floor(call.Argument(0).ToFloat()))
 }
 
+func (r *Runtime) math_f16round(call FunctionCall) Value {
+	return floatToValue(float16ToFloat64(toFloat16(call.Argument(0))))
+}
+
 func (r *Runtime) math_fround(call FunctionCall) Value {
 	return floatToValue(float64(float32(call.Argument(0).ToFloat())))
 }
@@ -122,19 +128,19 @@ func (r *Runtime) math_imul(call FunctionCall) Value {
 }
 
 func (r *Runtime) math_log(call FunctionCall) Value {
-	return floatToValue(math.Log(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Log(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_log1p(call FunctionCall) Value {
-	return floatToValue(math.Log1p(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Log1p(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_log10(call FunctionCall) Value {
-	return floatToValue(math.Log10(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Log10(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_log2(call FunctionCall) Value {
-	return floatToValue(math.Log2(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Log2(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_max(call FunctionCall) Value {
@@ -190,21 +196,18 @@ func pow(x, y Value) Value {
 			if x == 0 {
 				return intToValue(0)
 			}
-			ip := ipow(int64(x), int64(y))
-			if ip != 0 {
-				return intToValue(ip)
+			ux := uint64(x)
+			if x < 0 {
+				ux = -ux
+			}
+			// |x| < 2^bits.Len64(|x|), so the result is exact if it is guaranteed to fit into 53 bits,
+			// otherwise ipow() could overflow
+			if bits.Len64(ux)*int(y) <= 53 {
+				return intToValue(ipow(int64(x), int64(y)))
 			}
 		}
 	}
-	xf := x.ToFloat()
-	yf := y.ToFloat()
-	if math.Abs(xf) == 1 && math.IsInf(yf, 0) {
-		return _NaN
-	}
-	if xf == 1 && math.IsNaN(yf) {
-		return _NaN
-	}
-	return floatToValue(math.Pow(xf, yf))
+	return floatToValue(fdlibm.Pow(x.ToFloat(), y.ToFloat()))
 }
 
 func (r *Runtime) math_pow(call FunctionCall) Value {
@@ -253,23 +256,194 @@ func (r *Runtime) math_sign(call FunctionCall) Value {
 }
 
 func (r *Runtime) math_sin(call FunctionCall) Value {
-	return floatToValue(math.Sin(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Sin(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_sinh(call FunctionCall) Value {
-	return floatToValue(math.Sinh(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Sinh(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_sqrt(call FunctionCall) Value {
 	return floatToValue(math.Sqrt(call.Argument(0).ToFloat()))
 }
 
+// preciseSum accumulates the exact sum of finite float64 values as a list of non-overlapping partials
+// (Shewchuk's algorithm, as in Python's math.fsum), with an additional counter of 2**1024 overflows
+// so that intermediate sums exceeding the float64 range do not lose the result.
+type preciseSum struct {
+	partials []float64
+	overflow int
+}
+
+func twoSum(x, y float64) (hi, lo float64) {
+	hi = x + y
+	lo = y - (hi - x)
+	return
+}
+
+func (s *preciseSum) add(x float64) {
+	used := 0
+	for _, y := range s.partials {
+		if math.Abs(x) < math.Abs(y) {
+			x, y = y, x
+		}
+		hi, lo := twoSum(x, y)
+		if math.IsInf(hi, 0) {
+			sign := 1.0
+			if hi < 0 {
+				sign = -1
+			}
+			s.overflow += int(sign)
+			x = (x - sign*0x1p1023) - sign*0x1p1023
+			if math.Abs(x) < math.Abs(y) {
+				x, y = y, x
+			}
+			hi, lo = twoSum(x, y)
+		}
+		if lo != 0 {
+			s.partials[used] = lo
+			used++
+		}
+		x = hi
+	}
+	s.partials = s.partials[:used]
+	if x != 0 {
+		s.partials = append(s.partials, x)
+	}
+}
+
+// result returns the sum rounded to the nearest float64 (ties to even).
+func (s *preciseSum) result() float64 {
+	const maxULP = 0x1p971 // math.MaxFloat64 - math.Nextafter(math.MaxFloat64, 0)
+	partials := s.partials
+	n := len(partials) - 1
+	var hi, lo float64
+
+	if s.overflow != 0 {
+		next := 0.0
+		if n >= 0 {
+			next = partials[n]
+		}
+		n--
+		if s.overflow > 1 || s.overflow < -1 || (s.overflow > 0 && next > 0) || (s.overflow < 0 && next < 0) {
+			return math.Inf(s.overflow)
+		}
+		// drop a factor of 2 to do the arithmetic without overflowing
+		hi, lo = twoSum(float64(s.overflow)*0x1p1023, next/2)
+		lo *= 2
+		if math.IsInf(2*hi, 0) {
+			// Exactly half an ulp below 2**1024 rounds to infinity, unless the rest of the sum has the opposite sign.
+			if hi > 0 {
+				if hi == 0x1p1023 && lo == -maxULP/2 && n >= 0 && partials[n] < 0 {
+					return math.MaxFloat64
+				}
+				return math.Inf(1)
+			}
+			if hi == -0x1p1023 && lo == maxULP/2 && n >= 0 && partials[n] > 0 {
+				return -math.MaxFloat64
+			}
+			return math.Inf(-1)
+		}
+		if lo != 0 {
+			partials[n+1] = lo
+			n++
+			lo = 0
+		}
+		hi *= 2
+	}
+
+	for n >= 0 {
+		x, y := hi, partials[n]
+		n--
+		hi, lo = twoSum(x, y)
+		if lo != 0 {
+			break
+		}
+	}
+
+	// If the remainder is exactly half an ulp, the next partial decides the rounding direction.
+	if n >= 0 && ((lo < 0 && partials[n] < 0) || (lo > 0 && partials[n] > 0)) {
+		y := lo * 2
+		x := hi + y
+		if x-hi == y {
+			hi = x
+		}
+	}
+	return hi
+}
+
+func (r *Runtime) math_sumPrecise(call FunctionCall) Value {
+	items := call.Argument(0)
+	r.checkObjectCoercible(items)
+	iter := r.getIterator(items, nil)
+
+	const (
+		stateMinusZero = iota
+		stateFinite
+		statePlusInf
+		stateMinusInf
+		stateNaN
+	)
+	state := stateMinusZero
+	var sum preciseSum
+	var count int64
+	iter.iterate(func(v Value) {
+		if count >= 1<<53 {
+			panic(r.newError(r.global.RangeError, "Too many values"))
+		}
+		count++
+		if !isNumber(v) {
+			panic(r.NewTypeError("Value %s is not a number", v))
+		}
+		if state == stateNaN {
+			return
+		}
+		f := v.ToFloat()
+		switch {
+		case math.IsNaN(f):
+			state = stateNaN
+		case math.IsInf(f, 1):
+			if state == stateMinusInf {
+				state = stateNaN
+			} else {
+				state = statePlusInf
+			}
+		case math.IsInf(f, -1):
+			if state == statePlusInf {
+				state = stateNaN
+			} else {
+				state = stateMinusInf
+			}
+		case f == 0 && math.Signbit(f): // -0 does not change the sum
+		default:
+			if state == stateMinusZero {
+				state = stateFinite
+			}
+			if state == stateFinite {
+				sum.add(f)
+			}
+		}
+	})
+
+	switch state {
+	case stateNaN:
+		return _NaN
+	case statePlusInf:
+		return _positiveInf
+	case stateMinusInf:
+		return _negativeInf
+	case stateMinusZero:
+		return _negativeZero
+	}
+	return floatToValue(sum.result())
+}
+
 func (r *Runtime) math_tan(call FunctionCall) Value {
-	return floatToValue(math.Tan(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Tan(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_tanh(call FunctionCall) Value {
-	return floatToValue(math.Tanh(call.Argument(0).ToFloat()))
+	return floatToValue(fdlibm.Tanh(call.Argument(0).ToFloat()))
 }
 
 func (r *Runtime) math_trunc(call FunctionCall) Value {
@@ -315,6 +489,7 @@ func (r *Runtime) createMath(val *Object) objectImpl {
 	m._putProp("exp", r.newNativeFunc(r.math_exp, nil, "exp", nil, 1), true, false, true)
 	m._putProp("expm1", r.newNativeFunc(r.math_expm1, nil, "expm1", nil, 1), true, false, true)
 	m._putProp("floor", r.newNativeFunc(r.math_floor, nil, "floor", nil, 1), true, false, true)
+	m._putProp("f16round", r.newNativeFunc(r.math_f16round, nil, "f16round", nil, 1), true, false, true)
 	m._putProp("fround", r.newNativeFunc(r.math_fround, nil, "fround", nil, 1), true, false, true)
 	m._putProp("hypot", r.newNativeFunc(r.math_hypot, nil, "hypot", nil, 2), true, false, true)
 	m._putProp("imul", r.newNativeFunc(r.math_imul, nil, "imul", nil, 2), true, false, true)
@@ -331,6 +506,7 @@ func (r *Runtime) createMath(val *Object) objectImpl {
 	m._putProp("sin", r.newNativeFunc(r.math_sin, nil, "sin", nil, 1), true, false, true)
 	m._putProp("sinh", r.newNativeFunc(r.math_sinh, nil, "sinh", nil, 1), true, false, true)
 	m._putProp("sqrt", r.newNativeFunc(r.math_sqrt, nil, "sqrt", nil, 1), true, false, true)
+	m._putProp("sumPrecise", r.newNativeFunc(r.math_sumPrecise, nil, "sumPrecise", nil, 1), true, false, true)
 	m._putProp("tan", r.newNativeFunc(r.math_tan, nil, "tan", nil, 1), true, false, true)
 	m._putProp("tanh", r.newNativeFunc(r.math_tanh, nil, "tanh", nil, 1), true, false, true)
 	m._putProp("trunc", r.newNativeFunc(r.math_trunc, nil, "trunc", nil, 1), true, false, true)
@@ -0,0 +1,72 @@
+package goja
+
+import "testing"
+
+func TestMathSumPrecise(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(Math.sumPrecise.length, 1);
+	assert.sameValue(Math.sumPrecise([]), -0, "empty");
+	assert.sameValue(Math.sumPrecise([-0, -0]), -0);
+	assert.sameValue(Math.sumPrecise([-0, 0]), 0);
+	assert.sameValue(Math.sumPrecise([0.1, 0.2, 0.3]), 0.6);
+	assert.sameValue(Math.sumPrecise([1, 1e100, 1, -1e100]), 2);
+	assert.sameValue(Math.sumPrecise([2**53, 1]), 2**53, "ties to even");
+	assert.sameValue(Math.sumPrecise([2**53, 1, 1e-300]), 2**53 + 2, "tie broken by the remainder");
+	assert.sameValue(Math.sumPrecise([1e308, 1e308, -1e308]), 1e308, "intermediate overflow");
+	assert.sameValue(Math.sumPrecise([1e308, 1e308]), Infinity);
+	assert.sameValue(Math.sumPrecise([-1e308, -1e308]), -Infinity);
+	assert.sameValue(Math.sumPrecise([Number.MAX_VALUE, 9.979201547673598e+291]), Number.MAX_VALUE);
+	assert.sameValue(Math.sumPrecise([Number.MAX_VALUE, 9.979201547673599e+291]), Infinity);
+	assert.sameValue(Math.sumPrecise([Infinity, 1]), Infinity);
+	assert.sameValue(Math.sumPrecise([Infinity, -Infinity]), NaN);
+	assert.sameValue(Math.sumPrecise([NaN, 1]), NaN);
+	assert.sameValue(Math.sumPrecise(new Set([1, 2, 3])), 6, "iterable");
+
+	assert.throws(TypeError, () => Math.sumPrecise());
+	assert.throws(TypeError, () => Math.sumPrecise(1, 2));
+
+	let closed = false;
+	const iter = {
+		[Symbol.iterator]() {
+			return {
+				next() {
+					return { value: "1", done: false };
+				},
+				return() {
+					closed = true;
+					return {};
+				}
+			};
+		}
+	};
+	assert.throws(TypeError, () => Math.sumPrecise(iter), "non-number value");
+	assert(closed, "iterator is closed");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestMathPowIntOverflow(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(Math.pow(1e10, 2), 1e20);
+	assert.sameValue(10 ** 20, 1e20);
+	assert.sameValue(Math.pow(1e5, 4), 1e20);
+	assert.sameValue(3 ** 41, 36472996377170786403);
+	assert.sameValue(Math.pow(-2, 63), -9223372036854775808);
+	assert.sameValue(2 ** 64, 18446744073709551616);
+	assert.sameValue(Math.pow(-3, 3), -27);
+	assert.sameValue(2 ** 53, 9007199254740992);
+	assert.sameValue(7 ** 18, 1628413597910449);
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestMathPowMatchesV8(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(7 ** -0.5, 0.37796447300922725);
+	assert.sameValue(Math.pow(7, -0.5), 0.37796447300922725);
+	assert.sameValue(123.456 ** -1.5, 0.0007290069984559876);
+	assert.sameValue(3 ** -308, 1.113405971825609e-147);
+	assert.sameValue(Math.pow(2, 1.5406621675255194), 2.909280028297811);
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
@@ -264,8 +264,9 @@ func (r *Runtime) object_seal(call FunctionCall) Value {
 			Configurable: FLAG_FALSE,
 		}
 
+		_, guarded := obj.self.(*guardedGlobalObject)
 		for item, next := obj.self.iterateKeys()(); next != nil; item, next = next() {
-			if prop, ok := item.value.(*valueProperty); ok {
+			if prop, ok := item.value.(*valueProperty); ok && !guarded {
 				prop.configurable = false
 			} else {
 				obj.defineOwnProperty(item.name, descr, true)
@@ -282,8 +283,10 @@ func (r *Runtime) object_freeze(call FunctionCall) Value {
 	if obj, ok := arg.(*Object); ok {
 		obj.self.preventExtensions(true)
 
+		// the changes of the global object have to be reported, see SetGlobalMutationHook()
+		_, guarded := obj.self.(*guardedGlobalObject)
 		for item, next := obj.self.iterateKeys()(); next != nil; item, next = next() {
-			if prop, ok := item.value.(*valueProperty); ok {
+			if prop, ok := item.value.(*valueProperty); ok && !guarded {
 				prop.configurable = false
 				if !prop.accessor {
 					prop.writable = false
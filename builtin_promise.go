@@ -43,7 +43,9 @@ type promiseReaction struct {
 	capability  *promiseCapability
 	handler     *jobCallback
 	asyncRunner *asyncRunner
-	typ         promiseReactionType
+	// the stack at the time of the then() call, see Runtime.SetAsyncStackTraces()
+	creationStack []StackFrame
+	typ           promiseReactionType
 }
 
 var typePromise = reflect.TypeOf((*Promise)(nil))
@@ -191,6 +193,22 @@ func (r *Runtime) enqueuePromiseJob(job func()) {
 	r.jobQueue = append(r.jobQueue, job)
 }
 
+func (r *Runtime) builtin_queueMicrotask(call FunctionCall) Value {
+	callback := call.Argument(0)
+	fn, ok := assertCallable(callback)
+	if !ok {
+		panic(r.NewTypeError("%s is not a function", describeValue(callback)))
+	}
+	r.enqueuePromiseJob(func() {
+		if ex := r.vm.try(func() {
+			fn(FunctionCall{This: _undefined})
+		}); ex != nil && r.microtaskErr == nil {
+			r.microtaskErr = ex
+		}
+	})
+	return _undefined
+}
+
 func (r *Runtime) triggerPromiseReactions(reactions []*promiseReaction, argument Value) {
 	for _, reaction := range reactions {
 		r.enqueuePromiseJob(r.newPromiseReactionJob(reaction, argument))
@@ -210,10 +228,13 @@ func (r *Runtime) newPromiseReactionJob(reaction *promiseReaction, argument Valu
 			if tracker := r.asyncContextTracker; tracker != nil {
 				tracker.Resumed(reaction.asyncCtx)
 			}
+			parentStack := r.vm.asyncParentStack
+			r.vm.asyncParentStack = reaction.creationStack
 			ex := r.vm.try(func() {
 				handlerResult = r.callJobCallback(reaction.handler, _undefined, argument)
 				fulfill = true
 			})
+			r.vm.asyncParentStack = parentStack
 			if ex != nil {
 				handlerResult = ex.val
 			}
@@ -321,15 +342,18 @@ func (r *Runtime) performPromiseThen(p *Promise, onFulfilled, onRejected Value,
 	if f, ok := assertCallable(onRejected); ok {
 		onRejectedJobCallback = &jobCallback{callback: f}
 	}
+	creationStack := r.vm.captureCreationStack()
 	fulfillReaction := &promiseReaction{
-		capability: resultCapability,
-		typ:        promiseReactionFulfill,
-		handler:    onFulfilledJobCallback,
+		capability:    resultCapability,
+		typ:           promiseReactionFulfill,
+		handler:       onFulfilledJobCallback,
+		creationStack: creationStack,
 	}
 	rejectReaction := &promiseReaction{
-		capability: resultCapability,
-		typ:        promiseReactionReject,
-		handler:    onRejectedJobCallback,
+		capability:    resultCapability,
+		typ:           promiseReactionReject,
+		handler:       onRejectedJobCallback,
+		creationStack: creationStack,
 	}
 	p.addReactions(fulfillReaction, rejectReaction)
 	if resultCapability == nil {
@@ -550,6 +574,21 @@ func (r *Runtime) promise_resolve(call FunctionCall) Value {
 	return r.promiseResolve(r.toObject(call.This), call.Argument(0))
 }
 
+func (r *Runtime) promise_try(call FunctionCall) Value {
+	pcap := r.newPromiseCapability(r.toObject(call.This))
+	var args []Value
+	if len(call.Arguments) > 1 {
+		args = call.Arguments[1:]
+	}
+	var result Value
+	if pcap.try(func() {
+		result = r.toCallable(call.Argument(0))(FunctionCall{This: _undefined, Arguments: args})
+	}) {
+		pcap.resolve(result)
+	}
+	return pcap.promise
+}
+
 func (r *Runtime) createPromiseProto(val *Object) objectImpl {
 	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
 	o._putProp("constructor", r.global.Promise, true, false, true)
@@ -572,6 +611,7 @@ func (r *Runtime) createPromise(val *Object) objectImpl {
 	o._putProp("race", r.newNativeFunc(r.promise_race, nil, "race", nil, 1), true, false, true)
 	o._putProp("reject", r.newNativeFunc(r.promise_reject, nil, "reject", nil, 1), true, false, true)
 	o._putProp("resolve", r.newNativeFunc(r.promise_resolve, nil, "resolve", nil, 1), true, false, true)
+	o._putProp("try", r.newNativeFunc(r.promise_try, nil, "try", nil, 1), true, false, true)
 
 	r.putSpeciesReturnThis(o)
 
@@ -628,6 +668,34 @@ func (r *Runtime) SetPromiseRejectionTracker(tracker PromiseRejectionTracker) {
 	r.promiseRejectionTracker = tracker
 }
 
+// QueueMicrotask adds fn to the job queue which is also used for the Promise reaction jobs and by the queueMicrotask()
+// global function. The queued jobs run in order when the control is about to return from the Runtime (i.e. after
+// RunProgram or a call to a JavaScript function made from Go completes) or when RunMicrotasks is called.
+// fn may call back into the Runtime, but it must not panic with anything other than the values thrown by JavaScript.
+// This method (as Runtime in general) is not goroutine-safe.
+func (r *Runtime) QueueMicrotask(fn func()) {
+	r.enqueuePromiseJob(fn)
+}
+
+// RunMicrotasks runs the queued jobs (see QueueMicrotask) until the queue is empty, including the jobs that are
+// queued while running. This allows embedders without an event loop to process the jobs queued with QueueMicrotask
+// while the Runtime is idle at a deterministic point. Note that settling a Promise returned by NewPromise runs
+// the resulting jobs straight away, as the resolving functions call into the Runtime.
+//
+// If a callback passed to the queueMicrotask() global function throws, the remaining jobs still run and the first
+// such exception is returned as an *Exception. The same exception is returned by RunProgram (or by a call of
+// a JavaScript function made from Go) when the jobs are run as the control returns from the Runtime, unless
+// the program has failed by itself.
+//
+// If called while the Runtime is running (i.e. from a Go function called by a script) this method does nothing:
+// the jobs will run when the control returns from the Runtime.
+func (r *Runtime) RunMicrotasks() error {
+	if len(r.vm.callStack) > 0 {
+		return nil
+	}
+	return r.runWrapped(func() {})
+}
+
 // SetAsyncContextTracker registers a handler that allows to track async execution contexts. See AsyncContextTracker
 // documentation for more details. Setting it to nil disables the functionality.
 // This method (as Runtime in general) is not goroutine-safe.
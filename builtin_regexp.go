@@ -3,11 +3,13 @@ package goja
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"unicode/utf16"
 	"unicode/utf8"
 
 	"github.com/dop251/goja/parser"
+	"github.com/dop251/goja/unistring"
 )
 
 func (r *Runtime) newRegexpObject(proto *Object) *regexpObject {
@@ -184,7 +186,7 @@ func compileRegexpFromValueString(patternStr valueString, flags string) (*regexp
 }
 
 func compileRegexp(patternStr, flags string) (p *regexpPattern, err error) {
-	var global, ignoreCase, multiline, sticky, unicode bool
+	var global, ignoreCase, multiline, sticky, unicode, unicodeSets bool
 	var wrapper *regexpWrapper
 	var wrapper2 *regexp2Wrapper
 
@@ -219,10 +221,17 @@ func compileRegexp(patternStr, flags string) (p *regexpPattern, err error) {
 				}
 				sticky = true
 			case 'u':
-				if unicode {
+				if unicode || unicodeSets {
 					invalidFlags()
+					return
 				}
 				unicode = true
+			case 'v':
+				if unicode || unicodeSets {
+					invalidFlags()
+					return
+				}
+				unicodeSets = true
 			default:
 				invalidFlags()
 				return
@@ -230,7 +239,18 @@ func compileRegexp(patternStr, flags string) (p *regexpPattern, err error) {
 		}
 	}
 
-	if unicode {
+	if unicodeSets {
+		// unicodeSets mode is a superset of unicode mode, the extended class syntax is translated
+		// before the pattern goes through the usual unicode mode pipeline.
+		unicode = true
+		patternStr = convertRegexpToUnicode(patternStr)
+		converted, err1 := convertRegexpUnicodeSets(patternStr)
+		if err1 != nil {
+			err = fmt.Errorf("Invalid regular expression (v): %s (%v)", patternStr, err1)
+			return
+		}
+		patternStr = converted
+	} else if unicode {
 		patternStr = convertRegexpToUnicode(patternStr)
 	} else {
 		patternStr = convertRegexpToUtf16(patternStr)
@@ -276,6 +296,7 @@ func compileRegexp(patternStr, flags string) (p *regexpPattern, err error) {
 		multiline:      multiline,
 		sticky:         sticky,
 		unicode:        unicode,
+		unicodeSets:    unicodeSets,
 	}
 	return
 }
@@ -432,7 +453,9 @@ func (r *Runtime) regexpproto_toString(call FunctionCall) Value {
 		if this.pattern.multiline {
 			sb.WriteRune('m')
 		}
-		if this.pattern.unicode {
+		if this.pattern.unicodeSets {
+			sb.WriteRune('v')
+		} else if this.pattern.unicode {
 			sb.WriteRune('u')
 		}
 		if this.pattern.sticky {
@@ -555,7 +578,7 @@ func (r *Runtime) regexpproto_getIgnoreCase(call FunctionCall) Value {
 
 func (r *Runtime) regexpproto_getUnicode(call FunctionCall) Value {
 	if this, ok := r.toObject(call.This).self.(*regexpObject); ok {
-		if this.pattern.unicode {
+		if this.pattern.unicode && !this.pattern.unicodeSets {
 			return valueTrue
 		} else {
 			return valueFalse
@@ -567,6 +590,20 @@ func (r *Runtime) regexpproto_getUnicode(call FunctionCall) Value {
 	}
 }
 
+func (r *Runtime) regexpproto_getUnicodeSets(call FunctionCall) Value {
+	if this, ok := r.toObject(call.This).self.(*regexpObject); ok {
+		if this.pattern.unicodeSets {
+			return valueTrue
+		} else {
+			return valueFalse
+		}
+	} else if call.This == r.global.RegExpPrototype {
+		return _undefined
+	} else {
+		panic(r.NewTypeError("Method RegExp.prototype.unicodeSets getter called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: call.This})))
+	}
+}
+
 func (r *Runtime) regexpproto_getSticky(call FunctionCall) Value {
 	if this, ok := r.toObject(call.This).self.(*regexpObject); ok {
 		if this.pattern.sticky {
@@ -582,7 +619,7 @@ func (r *Runtime) regexpproto_getSticky(call FunctionCall) Value {
 }
 
 func (r *Runtime) regexpproto_getFlags(call FunctionCall) Value {
-	var global, ignoreCase, multiline, sticky, unicode bool
+	var global, ignoreCase, multiline, sticky, unicode, unicodeSets bool
 
 	thisObj := r.toObject(call.This)
 	size := 0
@@ -616,6 +653,12 @@ func (r *Runtime) regexpproto_getFlags(call FunctionCall) Value {
 			size++
 		}
 	}
+	if v := thisObj.self.getStr("unicodeSets", nil); v != nil {
+		unicodeSets = v.ToBoolean()
+		if unicodeSets {
+			size++
+		}
+	}
 
 	var sb strings.Builder
 	sb.Grow(size)
@@ -631,6 +674,9 @@ func (r *Runtime) regexpproto_getFlags(call FunctionCall) Value {
 	if unicode {
 		sb.WriteByte('u')
 	}
+	if unicodeSets {
+		sb.WriteByte('v')
+	}
 	if sticky {
 		sb.WriteByte('y')
 	}
@@ -654,7 +700,7 @@ func (r *Runtime) regExpExec(execFn func(FunctionCall) Value, rxObj *Object, arg
 }
 
 func (r *Runtime) getGlobalRegexpMatches(rxObj *Object, s valueString) []Value {
-	fullUnicode := nilSafe(rxObj.self.getStr("unicode", nil)).ToBoolean()
+	fullUnicode := nilSafe(rxObj.self.getStr("unicode", nil)).ToBoolean() || nilSafe(rxObj.self.getStr("unicodeSets", nil)).ToBoolean()
 	rxObj.self.setOwnStr("lastIndex", intToValue(0), true)
 	execFn, ok := r.toObject(rxObj.self.getStr("exec", nil)).self.assertCallable()
 	if !ok {
@@ -662,6 +708,7 @@ func (r *Runtime) getGlobalRegexpMatches(rxObj *Object, s valueString) []Value {
 	}
 	var a []Value
 	for {
+		r.vm.checkInterrupt()
 		res := r.regExpExec(execFn, rxObj, s)
 		if res == _null {
 			break
@@ -727,7 +774,7 @@ func (r *Runtime) regexpproto_stdMatcher(call FunctionCall) Value {
 		return r.regexpproto_stdMatcherGeneric(thisObj, s)
 	}
 	if rx.pattern.global {
-		res := rx.pattern.findAllSubmatchIndex(s, 0, -1, rx.pattern.sticky)
+		res := rx.pattern.findAllSubmatchIndex(s, 0, -1, rx.pattern.sticky, r.vm)
 		if len(res) == 0 {
 			rx.setOwnStr("lastIndex", intToValue(0), true)
 			return _null
@@ -737,6 +784,7 @@ func (r *Runtime) regexpproto_stdMatcher(call FunctionCall) Value {
 			a = append(a, s.substring(result[0], result[1]))
 		}
 		rx.setOwnStr("lastIndex", intToValue(int64(res[len(res)-1][1])), true)
+		r.updateLegacyRegExpStatics(s, res[len(res)-1])
 		return r.newArrayValues(a)
 	} else {
 		return rx.exec(s)
@@ -777,7 +825,7 @@ func (r *Runtime) regexpproto_stdMatcherAll(call FunctionCall) Value {
 	matcher.self.setOwnStr("lastIndex", valueInt(toLength(thisObj.self.getStr("lastIndex", nil))), true)
 	flagsStr := flags.String()
 	global := strings.Contains(flagsStr, "g")
-	fullUnicode := strings.Contains(flagsStr, "u")
+	fullUnicode := strings.ContainsAny(flagsStr, "uv")
 	return r.createRegExpStringIterator(matcher, s, global, fullUnicode)
 }
 
@@ -890,6 +938,7 @@ func (r *Runtime) regexpproto_stdSplitterGeneric(splitter *Object, s valueString
 
 	q := p
 	for q < size {
+		r.vm.checkInterrupt()
 		splitter.self.setOwnStr("lastIndex", intToValue(int64(q)), true)
 		z := r.regExpExec(execFn, splitter, s)
 		if z == _null {
@@ -978,7 +1027,7 @@ func (r *Runtime) regexpproto_stdSplitter(call FunctionCall) Value {
 		splitter = r.toConstructor(c)([]Value{rxObj, flags}, nil)
 		search = r.checkStdRegexp(splitter)
 		if search == nil {
-			return r.regexpproto_stdSplitterGeneric(splitter, s, limitValue, strings.Contains(flagsStr, "u"))
+			return r.regexpproto_stdSplitterGeneric(splitter, s, limitValue, strings.ContainsAny(flagsStr, "uv"))
 		}
 	}
 
@@ -997,7 +1046,10 @@ func (r *Runtime) regexpproto_stdSplitter(call FunctionCall) Value {
 	lastIndex := 0
 	found := 0
 
-	result := pattern.findAllSubmatchIndex(s, 0, -1, false)
+	result := pattern.findAllSubmatchIndex(s, 0, -1, false, r.vm)
+	if len(result) > 0 {
+		r.updateLegacyRegExpStatics(s, result[len(result)-1])
+	}
 	if targetLength == 0 {
 		if result == nil {
 			valueArray = append(valueArray, s)
@@ -1194,10 +1246,12 @@ func (r *Runtime) regexpproto_stdReplacer(call FunctionCall) Value {
 	} else {
 		index = rx.getLastIndex()
 	}
-	found := rx.pattern.findAllSubmatchIndex(s, toIntStrict(index), find, rx.pattern.sticky)
+	found := rx.pattern.findAllSubmatchIndex(s, toIntStrict(index), find, rx.pattern.sticky, r.vm)
 	if len(found) > 0 {
 		if !rx.updateLastIndex(index, found[0], found[len(found)-1]) {
 			found = nil
+		} else {
+			r.updateLegacyRegExpStatics(s, found[len(found)-1])
 		}
 	} else {
 		rx.updateLastIndex(index, nil, nil)
@@ -1233,6 +1287,121 @@ func (r *Runtime) getRegExpStringIteratorPrototype() *Object {
 	return o
 }
 
+// regexpLegacyStatics holds the state behind the legacy static properties of the RegExp constructor
+// (RegExp.$1-$9, RegExp.lastMatch, etc.).
+type regexpLegacyStatics struct {
+	// the value of RegExp.input, which may be modified by the scripts
+	input valueString
+	// the string the last match has been made against, result are the indices into it
+	subject valueString
+	result  []int
+}
+
+func (r *Runtime) updateLegacyRegExpStatics(input valueString, result []int) {
+	if s := r.regexpLegacyStatics; s != nil {
+		s.input = input
+		s.subject = input
+		s.result = append(s.result[:0], result...)
+	}
+}
+
+func (s *regexpLegacyStatics) group(n int) Value {
+	if n < len(s.result)>>1 {
+		if start := s.result[n*2]; start >= 0 {
+			return s.subject.substring(start, s.result[n*2+1])
+		}
+	}
+	return stringEmpty
+}
+
+func (r *Runtime) regexpLegacyStaticsGetter(name string, get func(s *regexpLegacyStatics) Value) *Object {
+	return r.newNativeFunc(func(call FunctionCall) Value {
+		if call.This != r.global.RegExp {
+			panic(r.NewTypeError("RegExp.%s getter called on incompatible receiver %s", name, r.objectproto_toString(FunctionCall{This: call.This})))
+		}
+		s := r.regexpLegacyStatics
+		if s.subject == nil {
+			return stringEmpty
+		}
+		return get(s)
+	}, nil, unistring.NewFromString("get "+name), nil, 0)
+}
+
+func (r *Runtime) defineLegacyRegExpStatics() {
+	rx := r.global.RegExp.self
+	define := func(name string, get func(s *regexpLegacyStatics) Value, aliases ...string) {
+		getter := r.regexpLegacyStaticsGetter(name, get)
+		for _, n := range append([]string{name}, aliases...) {
+			rx.setOwnStr(unistring.String(n), &valueProperty{
+				configurable: true,
+				getterFunc:   getter,
+				accessor:     true,
+			}, false)
+		}
+	}
+
+	inputGetter := r.newNativeFunc(func(call FunctionCall) Value {
+		if call.This != r.global.RegExp {
+			panic(r.NewTypeError("RegExp.input getter called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: call.This})))
+		}
+		if input := r.regexpLegacyStatics.input; input != nil {
+			return input
+		}
+		return stringEmpty
+	}, nil, "get input", nil, 0)
+	inputSetter := r.newNativeFunc(func(call FunctionCall) Value {
+		if call.This != r.global.RegExp {
+			panic(r.NewTypeError("RegExp.input setter called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: call.This})))
+		}
+		r.regexpLegacyStatics.input = call.Argument(0).toString()
+		return _undefined
+	}, nil, "set input", nil, 1)
+	for _, n := range []unistring.String{"input", "$_"} {
+		rx.setOwnStr(n, &valueProperty{
+			configurable: true,
+			getterFunc:   inputGetter,
+			setterFunc:   inputSetter,
+			accessor:     true,
+		}, false)
+	}
+
+	define("lastMatch", func(s *regexpLegacyStatics) Value {
+		return s.group(0)
+	}, "$&")
+	define("lastParen", func(s *regexpLegacyStatics) Value {
+		if n := len(s.result) >> 1; n > 1 {
+			return s.group(n - 1)
+		}
+		return stringEmpty
+	}, "$+")
+	define("leftContext", func(s *regexpLegacyStatics) Value {
+		if len(s.result) == 0 {
+			return stringEmpty
+		}
+		return s.subject.substring(0, s.result[0])
+	}, "$`")
+	define("rightContext", func(s *regexpLegacyStatics) Value {
+		if len(s.result) == 0 {
+			return stringEmpty
+		}
+		return s.subject.substring(s.result[1], s.subject.length())
+	}, "$'")
+	for i := 1; i <= 9; i++ {
+		n := i
+		define("$"+strconv.Itoa(n), func(s *regexpLegacyStatics) Value {
+			return s.group(n)
+		})
+	}
+}
+
+func (r *Runtime) deleteLegacyRegExpStatics() {
+	rx := r.global.RegExp.self
+	for _, n := range []unistring.String{"input", "$_", "lastMatch", "$&", "lastParen", "$+", "leftContext", "$`", "rightContext", "$'",
+		"$1", "$2", "$3", "$4", "$5", "$6", "$7", "$8", "$9"} {
+		rx.deleteStr(n, false)
+	}
+}
+
 func (r *Runtime) initRegExp() {
 	o := r.newGuardedObject(r.global.ObjectPrototype, classObject)
 	r.global.RegExpPrototype = o.val
@@ -1267,6 +1436,11 @@ func (r *Runtime) initRegExp() {
 		getterFunc:   r.newNativeFunc(r.regexpproto_getUnicode, nil, "get unicode", nil, 0),
 		accessor:     true,
 	}, false)
+	o.setOwnStr("unicodeSets", &valueProperty{
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.regexpproto_getUnicodeSets, nil, "get unicodeSets", nil, 0),
+		accessor:     true,
+	}, false)
 	o.setOwnStr("sticky", &valueProperty{
 		configurable: true,
 		getterFunc:   r.newNativeFunc(r.regexpproto_getSticky, nil, "get sticky", nil, 0),
@@ -2,6 +2,7 @@ package goja
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 )
 
@@ -80,7 +81,7 @@ func (so *setObject) exportToArrayOrSlice(dst reflect.Value, typ reflect.Type, c
 		if entry == nil {
 			break
 		}
-		err := r.toReflectValue(entry.key, dst.Index(i), ctx)
+		err := r.toReflectValueAt(entry.key, dst.Index(i), ctx, valueInt(i))
 		if err != nil {
 			return err
 		}
@@ -100,7 +101,7 @@ func (so *setObject) exportToMap(dst reflect.Value, typ reflect.Type, ctx *objec
 			break
 		}
 		keyVal := reflect.New(keyTyp).Elem()
-		err := r.toReflectValue(entry.key, keyVal, ctx)
+		err := r.toReflectValueAt(entry.key, keyVal, ctx, entry.key)
 		if err != nil {
 			return err
 		}
@@ -116,7 +117,7 @@ func (r *Runtime) setProto_add(call FunctionCall) Value {
 		panic(r.NewTypeError("Method Set.prototype.add called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
 	}
 
-	so.m.set(call.Argument(0), nil)
+	r.setMapEntry(&so.baseObject, so.m, call.Argument(0), nil)
 	return call.This
 }
 
@@ -192,6 +193,234 @@ func (r *Runtime) setProto_values(call FunctionCall) Value {
 	return r.createSetIterator(call.This, iterationKindValue)
 }
 
+// setRecord is the result of GetSetRecord() used by the Set methods which accept set-like objects.
+type setRecord struct {
+	set  *Object
+	size float64
+	has  func(FunctionCall) Value
+	keys func(FunctionCall) Value
+}
+
+func (r *Runtime) getSetRecord(v Value) *setRecord {
+	obj, ok := v.(*Object)
+	if !ok {
+		panic(r.NewTypeError("%s is not an object", v))
+	}
+	numSize := nilSafe(obj.self.getStr("size", nil)).ToNumber().ToFloat()
+	if math.IsNaN(numSize) {
+		panic(r.NewTypeError("The 'size' property must be a number"))
+	}
+	intSize := math.Trunc(numSize)
+	if intSize < 0 {
+		panic(r.newError(r.global.RangeError, "The 'size' property must not be negative"))
+	}
+	has := toMethod(obj.self.getStr("has", nil))
+	if has == nil {
+		panic(r.NewTypeError("The 'has' property must be a function"))
+	}
+	keys := toMethod(obj.self.getStr("keys", nil))
+	if keys == nil {
+		panic(r.NewTypeError("The 'keys' property must be a function"))
+	}
+	return &setRecord{
+		set:  obj,
+		size: intSize,
+		has:  has,
+		keys: keys,
+	}
+}
+
+func (rec *setRecord) hasValue(v Value) bool {
+	return rec.has(FunctionCall{This: rec.set, Arguments: []Value{v}}).ToBoolean()
+}
+
+// keysIterator implements GetIteratorFromMethod(set, keys).
+func (rec *setRecord) keysIterator() *iteratorRecord {
+	r := rec.set.runtime
+	iter, ok := rec.keys(FunctionCall{This: rec.set}).(*Object)
+	if !ok {
+		panic(r.NewTypeError("The result of the 'keys' method is not an object"))
+	}
+	return r.getIteratorDirect(iter)
+}
+
+func (r *Runtime) thisSetObject(call FunctionCall, method string) *setObject {
+	thisObj := r.toObject(call.This)
+	so, ok := thisObj.self.(*setObject)
+	if !ok {
+		panic(r.NewTypeError("Method Set.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return so
+}
+
+func (r *Runtime) newSetObject() *setObject {
+	o := &Object{runtime: r}
+	so := &setObject{}
+	so.class = classObject
+	so.val = o
+	so.extensible = true
+	o.self = so
+	so.prototype = r.global.SetPrototype
+	so.init()
+	return so
+}
+
+func (r *Runtime) copySetObject(src *setObject) *setObject {
+	res := r.newSetObject()
+	iter := src.m.newIter()
+	for entry := iter.next(); entry != nil; entry = iter.next() {
+		r.setMapEntry(&res.baseObject, res.m, entry.key, nil)
+	}
+	return res
+}
+
+func (r *Runtime) setProto_union(call FunctionCall) Value {
+	so := r.thisSetObject(call, "union")
+	otherRec := r.getSetRecord(call.Argument(0))
+	keysIter := otherRec.keysIterator()
+	res := r.copySetObject(so)
+	for {
+		next, ok := keysIter.nextValue()
+		if !ok {
+			break
+		}
+		r.setMapEntry(&res.baseObject, res.m, next, nil)
+	}
+	return res.val
+}
+
+func (r *Runtime) setProto_intersection(call FunctionCall) Value {
+	so := r.thisSetObject(call, "intersection")
+	otherRec := r.getSetRecord(call.Argument(0))
+	res := r.newSetObject()
+	if float64(so.m.size) <= otherRec.size {
+		iter := so.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			e := entry.key
+			if otherRec.hasValue(e) {
+				r.setMapEntry(&res.baseObject, res.m, e, nil)
+			}
+		}
+	} else {
+		keysIter := otherRec.keysIterator()
+		for {
+			next, ok := keysIter.nextValue()
+			if !ok {
+				break
+			}
+			if so.m.has(next) {
+				r.setMapEntry(&res.baseObject, res.m, next, nil)
+			}
+		}
+	}
+	return res.val
+}
+
+func (r *Runtime) setProto_difference(call FunctionCall) Value {
+	so := r.thisSetObject(call, "difference")
+	otherRec := r.getSetRecord(call.Argument(0))
+	res := r.copySetObject(so)
+	if float64(so.m.size) <= otherRec.size {
+		// iterating over the copy, so that only the elements that were in the set originally are checked
+		iter := res.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			if otherRec.hasValue(entry.key) {
+				res.m.remove(entry.key)
+			}
+		}
+	} else {
+		keysIter := otherRec.keysIterator()
+		for {
+			next, ok := keysIter.nextValue()
+			if !ok {
+				break
+			}
+			res.m.remove(next)
+		}
+	}
+	return res.val
+}
+
+func (r *Runtime) setProto_symmetricDifference(call FunctionCall) Value {
+	so := r.thisSetObject(call, "symmetricDifference")
+	otherRec := r.getSetRecord(call.Argument(0))
+	keysIter := otherRec.keysIterator()
+	res := r.copySetObject(so)
+	for {
+		next, ok := keysIter.nextValue()
+		if !ok {
+			break
+		}
+		if so.m.has(next) {
+			res.m.remove(next)
+		} else {
+			r.setMapEntry(&res.baseObject, res.m, next, nil)
+		}
+	}
+	return res.val
+}
+
+func (r *Runtime) setProto_isSubsetOf(call FunctionCall) Value {
+	so := r.thisSetObject(call, "isSubsetOf")
+	otherRec := r.getSetRecord(call.Argument(0))
+	if float64(so.m.size) > otherRec.size {
+		return valueFalse
+	}
+	iter := so.m.newIter()
+	for entry := iter.next(); entry != nil; entry = iter.next() {
+		if !otherRec.hasValue(entry.key) {
+			return valueFalse
+		}
+	}
+	return valueTrue
+}
+
+func (r *Runtime) setProto_isSupersetOf(call FunctionCall) Value {
+	so := r.thisSetObject(call, "isSupersetOf")
+	otherRec := r.getSetRecord(call.Argument(0))
+	if float64(so.m.size) < otherRec.size {
+		return valueFalse
+	}
+	keysIter := otherRec.keysIterator()
+	for {
+		next, ok := keysIter.nextValue()
+		if !ok {
+			break
+		}
+		if !so.m.has(next) {
+			keysIter.returnIter()
+			return valueFalse
+		}
+	}
+	return valueTrue
+}
+
+func (r *Runtime) setProto_isDisjointFrom(call FunctionCall) Value {
+	so := r.thisSetObject(call, "isDisjointFrom")
+	otherRec := r.getSetRecord(call.Argument(0))
+	if float64(so.m.size) <= otherRec.size {
+		iter := so.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			if otherRec.hasValue(entry.key) {
+				return valueFalse
+			}
+		}
+	} else {
+		keysIter := otherRec.keysIterator()
+		for {
+			next, ok := keysIter.nextValue()
+			if !ok {
+				break
+			}
+			if so.m.has(next) {
+				keysIter.returnIter()
+				return valueFalse
+			}
+		}
+	}
+	return valueTrue
+}
+
 func (r *Runtime) builtin_newSet(args []Value, newTarget *Object) *Object {
 	if newTarget == nil {
 		panic(r.needNew("Set"))
@@ -213,11 +442,11 @@ func (r *Runtime) builtin_newSet(args []Value, newTarget *Object) *Object {
 			if adder == r.global.setAdder {
 				if stdArr != nil {
 					for _, v := range stdArr.values {
-						so.m.set(v, nil)
+						r.setMapEntry(&so.baseObject, so.m, v, nil)
 					}
 				} else {
 					r.getIterator(arg, nil).iterate(func(item Value) {
-						so.m.set(item, nil)
+						r.setMapEntry(&so.baseObject, so.m, item, nil)
 					})
 				}
 			} else {
@@ -280,8 +509,15 @@ func (r *Runtime) createSetProto(val *Object) objectImpl {
 
 	o._putProp("clear", r.newNativeFunc(r.setProto_clear, nil, "clear", nil, 0), true, false, true)
 	o._putProp("delete", r.newNativeFunc(r.setProto_delete, nil, "delete", nil, 1), true, false, true)
+	o._putProp("difference", r.newNativeFunc(r.setProto_difference, nil, "difference", nil, 1), true, false, true)
 	o._putProp("forEach", r.newNativeFunc(r.setProto_forEach, nil, "forEach", nil, 1), true, false, true)
 	o._putProp("has", r.newNativeFunc(r.setProto_has, nil, "has", nil, 1), true, false, true)
+	o._putProp("intersection", r.newNativeFunc(r.setProto_intersection, nil, "intersection", nil, 1), true, false, true)
+	o._putProp("isDisjointFrom", r.newNativeFunc(r.setProto_isDisjointFrom, nil, "isDisjointFrom", nil, 1), true, false, true)
+	o._putProp("isSubsetOf", r.newNativeFunc(r.setProto_isSubsetOf, nil, "isSubsetOf", nil, 1), true, false, true)
+	o._putProp("isSupersetOf", r.newNativeFunc(r.setProto_isSupersetOf, nil, "isSupersetOf", nil, 1), true, false, true)
+	o._putProp("symmetricDifference", r.newNativeFunc(r.setProto_symmetricDifference, nil, "symmetricDifference", nil, 1), true, false, true)
+	o._putProp("union", r.newNativeFunc(r.setProto_union, nil, "union", nil, 1), true, false, true)
 	o.setOwnStr("size", &valueProperty{
 		getterFunc:   r.newNativeFunc(r.setProto_getSize, nil, "get size", nil, 0),
 		accessor:     true,
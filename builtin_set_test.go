@@ -26,6 +26,71 @@ func TestSetEvilIterator(t *testing.T) {
 	testScript(SCRIPT, _undefined, t)
 }
 
+func TestSetMethods(t *testing.T) {
+	const SCRIPT = `
+	function str(s) {
+		return [...s].join();
+	}
+	var a = new Set([1, 2, 3, 4]);
+	var b = new Set([3, 4, 5]);
+
+	assert.sameValue(str(a.union(b)), "1,2,3,4,5", "union");
+	assert.sameValue(str(a.intersection(b)), "3,4", "intersection (this is larger)");
+	assert.sameValue(str(b.intersection(a)), "3,4", "intersection (this is smaller)");
+	assert.sameValue(str(a.difference(b)), "1,2", "difference (this is larger)");
+	assert.sameValue(str(b.difference(a)), "5", "difference (this is smaller)");
+	assert.sameValue(str(a.symmetricDifference(b)), "1,2,5", "symmetricDifference");
+	assert.sameValue(new Set([3]).isSubsetOf(b), true, "isSubsetOf");
+	assert.sameValue(a.isSubsetOf(b), false, "isSubsetOf (larger)");
+	assert.sameValue(b.isSupersetOf(new Set([5, 3])), true, "isSupersetOf");
+	assert.sameValue(b.isSupersetOf(new Set([5, 6])), false, "isSupersetOf (missing)");
+	assert.sameValue(a.isDisjointFrom(new Set([7, 8])), true, "isDisjointFrom");
+	assert.sameValue(a.isDisjointFrom(b), false, "isDisjointFrom (common)");
+	assert.sameValue(new Set([1]).isDisjointFrom(new Set([2, 3, 1])), false, "isDisjointFrom (this is smaller)");
+
+	var u = a.union(b);
+	assert.sameValue(Object.getPrototypeOf(u), Set.prototype, "result prototype");
+	assert.sameValue(str(a), "1,2,3,4", "receiver unchanged");
+
+	// set-like objects
+	var closed = 0;
+	var setLike = {
+		size: 2,
+		has: function(v) {
+			return v === 1 || v === -0;
+		},
+		keys: function() {
+			var vals = [1, -0, 1];
+			var i = 0;
+			return {
+				next: function() {
+					return i < vals.length ? {value: vals[i++], done: false} : {done: true};
+				},
+				return: function() {
+					closed++;
+					return {};
+				}
+			};
+		}
+	};
+	assert.sameValue(str(new Set([2]).union(setLike)), "2,1,0", "union with a set-like object");
+	assert.sameValue(Object.is([...new Set().union(setLike)][1], 0), true, "-0 is canonicalized");
+	assert.sameValue(str(new Set([0, 1, 2, 3]).intersection(setLike)), "1,0", "intersection with a set-like object");
+	assert.sameValue(new Set([7, 8, 9]).isSupersetOf(setLike), false);
+	assert.sameValue(closed, 1, "iterator closed by isSupersetOf");
+	assert.sameValue(new Set([1, 7, 8]).isDisjointFrom(setLike), false);
+	assert.sameValue(closed, 2, "iterator closed by isDisjointFrom");
+
+	assert.throws(TypeError, function() { a.union([1, 2]); }, "array is not set-like");
+	assert.throws(TypeError, function() { a.union({size: 1, has: null, keys: function() {}}); }, "has");
+	assert.throws(TypeError, function() { a.union({size: undefined, has: function() {}, keys: function() {}}); }, "size");
+	assert.throws(RangeError, function() { a.union({size: -1, has: function() {}, keys: function() {}}); }, "negative size");
+	assert.throws(TypeError, function() { Set.prototype.union.call(new Map(), b); }, "receiver");
+	assert.sameValue(a.isSubsetOf({size: Infinity, has: function() { return true; }, keys: function() {}}), true, "infinite size");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func ExampleRuntime_ExportTo_setToMap() {
 	vm := New()
 	s, err := vm.RunString(`
@@ -8,6 +8,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/dop251/goja/parser"
+	"golang.org/x/text/cases"
 	"golang.org/x/text/collate"
 	"golang.org/x/text/language"
 	"golang.org/x/text/unicode/norm"
@@ -287,6 +288,12 @@ func (r *Runtime) stringproto_endsWith(call FunctionCall) Value {
 	if start < 0 {
 		return valueFalse
 	}
+	if a, u := devirtualizeString(s); u == nil {
+		if sa, u := devirtualizeString(searchStr); u == nil {
+			return r.toBoolean(strings.HasSuffix(string(a[:end]), string(sa)))
+		}
+		return valueFalse
+	}
 	for i := 0; i < searchLength; i++ {
 		if s.charAt(start+i) != searchStr.charAt(i) {
 			return valueFalse
@@ -316,6 +323,23 @@ func (r *Runtime) stringproto_includes(call FunctionCall) Value {
 	return valueFalse
 }
 
+func (r *Runtime) stringproto_isWellFormed(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	if _, u := devirtualizeString(call.This.toString()); u != nil {
+		return r.toBoolean(u.loneSurrogate(0) == -1)
+	}
+	return valueTrue
+}
+
+func (r *Runtime) stringproto_toWellFormed(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.toString()
+	if _, u := devirtualizeString(s); u != nil {
+		return u.toWellFormed()
+	}
+	return s
+}
+
 func (r *Runtime) stringproto_indexOf(call FunctionCall) Value {
 	r.checkObjectCoercible(call.This)
 	value := call.This.toString()
@@ -773,32 +797,81 @@ func (r *Runtime) stringproto_split(call FunctionCall) Value {
 		return r.newArrayValues([]Value{s})
 	}
 
-	separator := separatorValue.String()
+	separator := separatorValue.toString()
 
-	excess := false
-	str := s.String()
-	if limit > len(str) {
-		limit = len(str)
+	if limit < 0 {
+		limit = math.MaxInt32
 	}
-	splitLimit := limit
-	if limit > 0 {
-		splitLimit = limit + 1
-		excess = true
+
+	var valueArray []Value
+	if a, u := devirtualizeString(s); u == nil {
+		if sep, u := devirtualizeString(separator); u == nil {
+			valueArray = splitASCII(a, sep, limit)
+		} else {
+			valueArray = []Value{s}
+		}
+	} else {
+		valueArray = splitString(s, separator, limit)
 	}
 
-	// TODO handle invalid UTF-16
-	split := strings.SplitN(str, separator, splitLimit)
+	return r.newArrayValues(valueArray)
+}
 
-	if excess && len(split) > limit {
-		split = split[:limit]
+func splitASCII(s, sep asciiString, limit int) []Value {
+	if len(sep) == 0 {
+		n := len(s)
+		if n > limit {
+			n = limit
+		}
+		res := make([]Value, n)
+		for i := range res {
+			res[i] = s[i : i+1]
+		}
+		return res
+	}
+	n := strings.Count(string(s), string(sep)) + 1
+	if n > limit {
+		n = limit
+	}
+	res := make([]Value, 0, n)
+	str := string(s)
+	for len(res) < n {
+		p := strings.Index(str, string(sep))
+		if p == -1 {
+			res = append(res, asciiString(str))
+			break
+		}
+		res = append(res, asciiString(str[:p]))
+		str = str[p+len(sep):]
 	}
+	return res
+}
 
-	valueArray := make([]Value, len(split))
-	for index, value := range split {
-		valueArray[index] = newStringValue(value)
+func splitString(s, sep valueString, limit int) []Value {
+	l := s.length()
+	sepLen := sep.length()
+	var res []Value
+	if sepLen == 0 {
+		if l > limit {
+			l = limit
+		}
+		res = make([]Value, l)
+		for i := range res {
+			res[i] = s.substring(i, i+1)
+		}
+		return res
+	}
+	start := 0
+	for len(res) < limit {
+		p := s.index(sep, start)
+		if p == -1 {
+			res = append(res, s.substring(start, l))
+			break
+		}
+		res = append(res, s.substring(start, p))
+		start = p + sepLen
 	}
-
-	return r.newArrayValues(valueArray)
+	return res
 }
 
 func (r *Runtime) stringproto_startsWith(call FunctionCall) Value {
@@ -819,6 +892,12 @@ func (r *Runtime) stringproto_startsWith(call FunctionCall) Value {
 	if int64(searchLength+start) > l {
 		return valueFalse
 	}
+	if a, u := devirtualizeString(s); u == nil {
+		if sa, u := devirtualizeString(searchStr); u == nil {
+			return r.toBoolean(strings.HasPrefix(string(a[start:]), string(sa)))
+		}
+		return valueFalse
+	}
 	for i := 0; i < searchLength; i++ {
 		if s.charAt(start+i) != searchStr.charAt(i) {
 			return valueFalse
@@ -872,6 +951,44 @@ func (r *Runtime) stringproto_toUpperCase(call FunctionCall) Value {
 	return s.toUpper()
 }
 
+// localeCaser returns a case mapper for the requested locale if its case mapping rules differ from the
+// default ones (i.e. for Azeri, Greek, Lithuanian and Turkish).
+func (r *Runtime) localeCaser(locales Value, upper bool) (cases.Caser, bool) {
+	// unlike the Intl constructors only the first requested locale is considered
+	tag := r.intlLocale
+	if tags := r.localeList(locales); len(tags) > 0 {
+		tag = tags[0]
+	}
+	switch localeLanguage(tag) {
+	case "az", "el", "lt", "tr":
+		if upper {
+			return cases.Upper(tag), true
+		}
+		return cases.Lower(tag), true
+	}
+	return cases.Caser{}, false
+}
+
+func (r *Runtime) stringproto_toLocaleLowerCase(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.toString()
+	if c, ok := r.localeCaser(call.Argument(0), false); ok {
+		return newStringValue(c.String(s.String()))
+	}
+
+	return s.toLower()
+}
+
+func (r *Runtime) stringproto_toLocaleUpperCase(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.toString()
+	if c, ok := r.localeCaser(call.Argument(0), true); ok {
+		return newStringValue(c.String(s.String()))
+	}
+
+	return s.toUpper()
+}
+
 func (r *Runtime) stringproto_trim(call FunctionCall) Value {
 	r.checkObjectCoercible(call.This)
 	s := call.This.toString()
@@ -952,13 +1069,17 @@ func (r *Runtime) initString() {
 
 	o := r.global.StringPrototype.self
 	o._putProp("at", r.newNativeFunc(r.stringproto_at, nil, "at", nil, 1), true, false, true)
-	o._putProp("charAt", r.newNativeFunc(r.stringproto_charAt, nil, "charAt", nil, 1), true, false, true)
-	o._putProp("charCodeAt", r.newNativeFunc(r.stringproto_charCodeAt, nil, "charCodeAt", nil, 1), true, false, true)
-	o._putProp("codePointAt", r.newNativeFunc(r.stringproto_codePointAt, nil, "codePointAt", nil, 1), true, false, true)
+	r.global.stringCharAt = r.newNativeFunc(r.stringproto_charAt, nil, "charAt", nil, 1)
+	o._putProp("charAt", r.global.stringCharAt, true, false, true)
+	r.global.stringCharCodeAt = r.newNativeFunc(r.stringproto_charCodeAt, nil, "charCodeAt", nil, 1)
+	o._putProp("charCodeAt", r.global.stringCharCodeAt, true, false, true)
+	r.global.stringCodePointAt = r.newNativeFunc(r.stringproto_codePointAt, nil, "codePointAt", nil, 1)
+	o._putProp("codePointAt", r.global.stringCodePointAt, true, false, true)
 	o._putProp("concat", r.newNativeFunc(r.stringproto_concat, nil, "concat", nil, 1), true, false, true)
 	o._putProp("endsWith", r.newNativeFunc(r.stringproto_endsWith, nil, "endsWith", nil, 1), true, false, true)
 	o._putProp("includes", r.newNativeFunc(r.stringproto_includes, nil, "includes", nil, 1), true, false, true)
 	o._putProp("indexOf", r.newNativeFunc(r.stringproto_indexOf, nil, "indexOf", nil, 1), true, false, true)
+	o._putProp("isWellFormed", r.newNativeFunc(r.stringproto_isWellFormed, nil, "isWellFormed", nil, 0), true, false, true)
 	o._putProp("lastIndexOf", r.newNativeFunc(r.stringproto_lastIndexOf, nil, "lastIndexOf", nil, 1), true, false, true)
 	o._putProp("localeCompare", r.newNativeFunc(r.stringproto_localeCompare, nil, "localeCompare", nil, 1), true, false, true)
 	o._putProp("match", r.newNativeFunc(r.stringproto_match, nil, "match", nil, 1), true, false, true)
@@ -973,11 +1094,12 @@ func (r *Runtime) initString() {
 	o._putProp("split", r.newNativeFunc(r.stringproto_split, nil, "split", nil, 2), true, false, true)
 	o._putProp("startsWith", r.newNativeFunc(r.stringproto_startsWith, nil, "startsWith", nil, 1), true, false, true)
 	o._putProp("substring", r.newNativeFunc(r.stringproto_substring, nil, "substring", nil, 2), true, false, true)
-	o._putProp("toLocaleLowerCase", r.newNativeFunc(r.stringproto_toLowerCase, nil, "toLocaleLowerCase", nil, 0), true, false, true)
-	o._putProp("toLocaleUpperCase", r.newNativeFunc(r.stringproto_toUpperCase, nil, "toLocaleUpperCase", nil, 0), true, false, true)
+	o._putProp("toLocaleLowerCase", r.newNativeFunc(r.stringproto_toLocaleLowerCase, nil, "toLocaleLowerCase", nil, 0), true, false, true)
+	o._putProp("toLocaleUpperCase", r.newNativeFunc(r.stringproto_toLocaleUpperCase, nil, "toLocaleUpperCase", nil, 0), true, false, true)
 	o._putProp("toLowerCase", r.newNativeFunc(r.stringproto_toLowerCase, nil, "toLowerCase", nil, 0), true, false, true)
 	o._putProp("toString", r.newNativeFunc(r.stringproto_toString, nil, "toString", nil, 0), true, false, true)
 	o._putProp("toUpperCase", r.newNativeFunc(r.stringproto_toUpperCase, nil, "toUpperCase", nil, 0), true, false, true)
+	o._putProp("toWellFormed", r.newNativeFunc(r.stringproto_toWellFormed, nil, "toWellFormed", nil, 0), true, false, true)
 	o._putProp("trim", r.newNativeFunc(r.stringproto_trim, nil, "trim", nil, 0), true, false, true)
 	trimEnd := r.newNativeFunc(r.stringproto_trimEnd, nil, "trimEnd", nil, 0)
 	trimStart := r.newNativeFunc(r.stringproto_trimStart, nil, "trimStart", nil, 0)
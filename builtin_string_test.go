@@ -187,6 +187,148 @@ if (result.value !== pair) {
 	testScript(SCRIPT, _undefined, t)
 }
 
+func TestStringLocaleCase(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue("I".toLocaleLowerCase("tr"), "\u0131", "tr dotless i");
+	assert.sameValue("\u0130".toLocaleLowerCase("tr"), "i", "tr dotted I");
+	assert.sameValue("i".toLocaleUpperCase("tr"), "\u0130", "tr upper");
+	assert.sameValue("i".toLocaleUpperCase(["az", "en"]), "\u0130", "az upper");
+	assert.sameValue("i".toLocaleUpperCase("en"), "I", "en upper");
+	assert.sameValue("I".toLocaleLowerCase("en-US"), "i", "en lower");
+	assert.sameValue("I".toLocaleLowerCase(), "i", "default lower");
+	assert.sameValue("\u00CC".toLocaleLowerCase("lt"), "i\u0307\u0300", "lt lower");
+	assert.sameValue("i\u0307".toLocaleUpperCase("lt"), "I", "lt upper");
+	assert.sameValue("stra\u00DFe".toLocaleUpperCase("de"), "STRASSE", "de upper");
+	assert.sameValue("\u1F00".toLocaleUpperCase("el"), "\u0391", "el upper");
+	assert.throws(RangeError, function() { "a".toLocaleUpperCase("x-invalid-"); });
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestStringSplitSearch(t *testing.T) {
+	const SCRIPT = `
+	assert(compareArray("a,b,,c".split(","), ["a", "b", "", "c"]), "ascii");
+	assert(compareArray("a,b,c".split(",", 2), ["a", "b"]), "ascii limit");
+	assert(compareArray("abc".split("", 2), ["a", "b"]), "ascii empty separator");
+	assert(compareArray("".split(""), []), "empty string");
+	assert(compareArray("".split(","), [""]), "empty string with separator");
+	assert(compareArray("abc".split("\u0436"), ["abc"]), "unicode separator");
+	assert(compareArray("\u0430\u0436b\u0436c".split("\u0436", 2), ["\u0430", "b"]), "unicode limit");
+	assert(compareArray("\uD83D\uDE00".split(""), ["\uD83D", "\uDE00"]), "surrogate pair");
+	assert(compareArray("a\uD800,b".split(","), ["a\uD800", "b"]), "lone surrogate");
+
+	assert("hello".startsWith("lo", 3), "startsWith");
+	assert(!"hello".startsWith("\u0436"), "startsWith unicode");
+	assert("\u0436hello".startsWith("\u0436h"), "unicode startsWith");
+	assert("hello".endsWith("he", 2), "endsWith");
+	assert(!"hello".endsWith("\u0436"), "endsWith unicode");
+	assert("hello\u0436".endsWith("o\u0436"), "unicode endsWith");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestStringWellFormed(t *testing.T) {
+	const SCRIPT = `
+	assert("abc".isWellFormed(), "ascii");
+	assert("\u0436\uD83D\uDE00".isWellFormed(), "pair");
+	assert(!"a\uD800".isWellFormed(), "lone lead");
+	assert(!"\uDC00a".isWellFormed(), "lone trail");
+	assert(!"\uDE00\uD83D".isWellFormed(), "reversed pair");
+
+	assert.sameValue("abc".toWellFormed(), "abc", "ascii");
+	assert.sameValue("\uD83D\uDE00".toWellFormed(), "\uD83D\uDE00", "pair");
+	assert.sameValue("a\uD800b\uDC00".toWellFormed(), "a\uFFFDb\uFFFD", "lone surrogates");
+	assert.sameValue("\uD800\uD83D\uDE00\uDFFF".toWellFormed(), "\uFFFD\uD83D\uDE00\uFFFD", "mixed");
+	var s = "x\uD800";
+	s.toWellFormed();
+	assert.sameValue(s, "x\uD800", "original is unchanged");
+
+	assert.sameValue(String.prototype.isWellFormed.call(42), true, "number");
+	assert.throws(TypeError, function() { String.prototype.toWellFormed.call(null); });
+	assert.throws(TypeError, function() { String.prototype.isWellFormed.call(undefined); });
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestStringScanFastPath(t *testing.T) {
+	const SCRIPT = `
+	var s = "a\u0436\uD83D\uDE00";
+	var codes = [], chars = [], points = [];
+	for (var i = -1; i <= s.length; i++) {
+		codes.push(s.charCodeAt(i));
+		chars.push(s.charAt(i));
+		points.push(s.codePointAt(i));
+	}
+	assert.sameValue(codes.join(), "NaN,97,1078,55357,56832,NaN", "charCodeAt");
+	assert.sameValue(chars.join(), ",a,\u0436,\uD83D,\uDE00,", "charAt");
+	assert.sameValue(points.join(), ",97,1078,128512,56832,", "codePointAt");
+	assert.sameValue(s.charCodeAt(1.5), 1078, "non-integer index");
+	assert.sameValue(s.charCodeAt(), 97, "no index");
+	assert.sameValue(new String("xy").charCodeAt(1), 121, "String object");
+
+	var orig = String.prototype.charCodeAt;
+	String.prototype.charCodeAt = function(i) { return "patched" + i; };
+	try {
+		assert.sameValue("abc".charCodeAt(1), "patched1", "overridden method");
+	} finally {
+		String.prototype.charCodeAt = orig;
+	}
+	var f = "abc".charAt;
+	assert.sameValue(f.call("xyz", 2), "z", "call()");
+	assert.throws(TypeError, function() { f(0); }, "undefined this");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func BenchmarkStringScan(b *testing.B) {
+	vm := New()
+	vm.Set("line", "2024-01-01T00:00:00Z INFO request handled method=GET path=/api/v1/items status=200 duration=12ms")
+	prg := MustCompile("test.js", `
+	(function(s) {
+		var words = 0, digits = 0;
+		for (var i = 0; i < s.length; i++) {
+			var c = s.charCodeAt(i);
+			if (c >= 48 && c <= 57) {
+				digits++;
+			} else if (s.charAt(i) === " ") {
+				words++;
+			}
+		}
+		return words + digits;
+	})(line);
+	`, false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := vm.RunProgram(prg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStringSplit(b *testing.B) {
+	vm := New()
+	vm.Set("line", "2024-01-01T00:00:00Z INFO request handled method=GET path=/api/v1/items status=200 duration=12ms")
+	prg := MustCompile("test.js", `line.split(" ")`, false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := vm.RunProgram(prg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStringStartsWith(b *testing.B) {
+	vm := New()
+	vm.Set("line", "2024-01-01T00:00:00Z INFO request handled method=GET path=/api/v1/items status=200 duration=12ms")
+	prg := MustCompile("test.js", `line.startsWith("2024-01-01") && line.endsWith("ms") && line.includes("status=200")`, false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := vm.RunProgram(prg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestValueStringBuilder(t *testing.T) {
 	t.Run("substringASCII", func(t *testing.T) {
 		t.Parallel()
@@ -0,0 +1,83 @@
+package goja
+
+const classStringBuilder = "StringBuilder"
+
+// stringBuilderObject is the backing object of StringBuilder instances, see Runtime.SetStringBuilder.
+type stringBuilderObject struct {
+	baseObject
+	buf valueStringBuilder
+}
+
+func (r *Runtime) toStringBuilder(v Value, method string) *stringBuilderObject {
+	if obj, ok := v.(*Object); ok {
+		if b, ok := obj.self.(*stringBuilderObject); ok {
+			return b
+		}
+	}
+	panic(r.NewTypeError("Method StringBuilder.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) stringBuilderProto_append(call FunctionCall) Value {
+	b := r.toStringBuilder(call.This, "append")
+	for _, arg := range call.Arguments {
+		b.buf.WriteString(arg.toString())
+	}
+	return call.This
+}
+
+func (r *Runtime) stringBuilderProto_clear(call FunctionCall) Value {
+	b := r.toStringBuilder(call.This, "clear")
+	// Strings returned by toString() may share the storage, so it must not be reused.
+	b.buf = valueStringBuilder{}
+	return _undefined
+}
+
+func (r *Runtime) stringBuilderProto_getLength(call FunctionCall) Value {
+	return intToValue(int64(r.toStringBuilder(call.This, "length").buf.Len()))
+}
+
+func (r *Runtime) stringBuilderProto_toString(call FunctionCall) Value {
+	return r.toStringBuilder(call.This, "toString").buf.String()
+}
+
+func (r *Runtime) builtin_newStringBuilder(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew(classStringBuilder))
+	}
+	proto := r.getPrototypeFromCtor(newTarget, r.global.StringBuilder, r.global.StringBuilderPrototype)
+	o := &Object{runtime: r}
+
+	b := &stringBuilderObject{}
+	b.class = classObject
+	b.val = o
+	b.extensible = true
+	o.self = b
+	b.prototype = proto
+	b.init()
+	if len(args) > 0 && args[0] != _undefined {
+		b.buf.WriteString(args[0].toString())
+	}
+	return o
+}
+
+func (r *Runtime) createStringBuilderProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	o._putProp("constructor", r.global.StringBuilder, true, false, true)
+	o._putProp("append", r.newNativeFunc(r.stringBuilderProto_append, nil, "append", nil, 1), true, false, true)
+	o._putProp("clear", r.newNativeFunc(r.stringBuilderProto_clear, nil, "clear", nil, 0), true, false, true)
+	o.setOwnStr("length", &valueProperty{
+		getterFunc:   r.newNativeFunc(r.stringBuilderProto_getLength, nil, "get length", nil, 0),
+		accessor:     true,
+		configurable: true,
+	}, true)
+	o._putProp("toString", r.newNativeFunc(r.stringBuilderProto_toString, nil, "toString", nil, 0), true, false, true)
+
+	o._putSym(SymToStringTag, valueProp(asciiString(classStringBuilder), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createStringBuilder(val *Object) objectImpl {
+	return r.newNativeConstructOnly(val, r.builtin_newStringBuilder, r.global.StringBuilderPrototype, classStringBuilder, 0)
+}
@@ -0,0 +1,72 @@
+package goja
+
+import "testing"
+
+func TestStringBuilder(t *testing.T) {
+	const SCRIPT = `
+	var sb = new StringBuilder("<");
+	assert.sameValue(sb.append("a", 1, true), sb, "append returns the builder");
+	assert.sameValue(sb.length, 7);
+	var s = sb.toString();
+	assert.sameValue(s, "<a1true");
+	sb.append("→", "b");
+	assert.sameValue(s, "<a1true", "earlier result is not affected");
+	assert.sameValue(sb.toString(), "<a1true→b");
+	assert.sameValue(sb.length, 9);
+	assert.sameValue(String(sb), "<a1true→b");
+	sb.clear();
+	assert.sameValue(sb.toString(), "");
+	assert.sameValue(sb.length, 0);
+	assert.sameValue(Object.prototype.toString.call(sb), "[object StringBuilder]");
+	assert.throws(TypeError, function() {
+		StringBuilder();
+	});
+	assert.throws(TypeError, function() {
+		StringBuilder.prototype.append.call({}, "a");
+	});
+	`
+	vm := New()
+	vm.SetStringBuilder(true)
+	vm.testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	vm.SetStringBuilder(false)
+	if v, err := vm.RunString("typeof StringBuilder"); err != nil || v.String() != "undefined" {
+		t.Fatal(v, err)
+	}
+}
+
+func BenchmarkStringBuilder(b *testing.B) {
+	b.Run("append", func(b *testing.B) {
+		vm := New()
+		vm.SetStringBuilder(true)
+		prg := MustCompile("test.js", `
+		var sb = new StringBuilder();
+		for (var i = 0; i < 10000; i++) {
+			sb.append("line ", i, "\n");
+		}
+		sb.toString().length;
+		`, false)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := vm.RunProgram(prg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("concat", func(b *testing.B) {
+		vm := New()
+		prg := MustCompile("test.js", `
+		var s = "";
+		for (var i = 0; i < 10000; i++) {
+			s += "line " + i + "\n";
+		}
+		s.length;
+		`, false)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := vm.RunProgram(prg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
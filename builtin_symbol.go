@@ -3,6 +3,8 @@ package goja
 import "github.com/dop251/goja/unistring"
 
 var (
+	SymAsyncDispose       = newSymbol(asciiString("Symbol.asyncDispose"))
+	SymDispose            = newSymbol(asciiString("Symbol.dispose"))
 	SymHasInstance        = newSymbol(asciiString("Symbol.hasInstance"))
 	SymIsConcatSpreadable = newSymbol(asciiString("Symbol.isConcatSpreadable"))
 	SymIterator           = newSymbol(asciiString("Symbol.iterator"))
@@ -135,6 +137,8 @@ func (r *Runtime) createSymbol(val *Object) objectImpl {
 	o._putProp("keyFor", r.newNativeFunc(r.symbol_keyfor, nil, "keyFor", nil, 1), true, false, true)
 
 	for _, s := range []*Symbol{
+		SymAsyncDispose,
+		SymDispose,
 		SymHasInstance,
 		SymIsConcatSpreadable,
 		SymIterator,
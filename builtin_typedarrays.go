@@ -13,6 +13,93 @@ type typedArraySortCtx struct {
 	ta           *typedArrayObject
 	compare      func(FunctionCall) Value
 	needValidate bool
+	vm           *vm
+}
+
+// typedArraySortDefault sorts the elements of the typed array in the default (numeric) order directly in the
+// underlying slice, without going through the generic path. Returns false if the array type is not supported.
+func typedArraySortDefault(ta *typedArrayObject) bool {
+	start, end := ta.offset, ta.offset+ta.length
+	switch a := ta.typedArray.(type) {
+	case *uint8Array:
+		countingSortUint8((*a)[start:end])
+	case *uint8ClampedArray:
+		countingSortUint8((*a)[start:end])
+	case *int8Array:
+		s := (*a)[start:end]
+		var counts [256]int
+		for _, v := range s {
+			counts[uint8(v)^0x80]++
+		}
+		i := 0
+		for v, c := range counts {
+			for ; c > 0; c-- {
+				s[i] = int8(uint8(v) ^ 0x80)
+				i++
+			}
+		}
+	case *uint16Array:
+		s := (*a)[start:end]
+		sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	case *int16Array:
+		s := (*a)[start:end]
+		sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	case *uint32Array:
+		s := (*a)[start:end]
+		sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	case *int32Array:
+		s := (*a)[start:end]
+		sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	case *float32Array:
+		s := (*a)[start:end]
+		// NaNs may have different payloads, so they are moved to the end preserving their order. The rest of
+		// the elements which compare as equal are identical, so the sort doesn't need to be stable.
+		n := 0
+		var nans []float32
+		for _, v := range s {
+			if v != v {
+				nans = append(nans, v)
+			} else {
+				s[n] = v
+				n++
+			}
+		}
+		copy(s[n:], nans)
+		s = s[:n]
+		sort.Slice(s, func(i, j int) bool { return typedFloatLess(float64(s[i]), float64(s[j])) })
+	case *float64Array:
+		s := (*a)[start:end]
+		n := 0
+		var nans []float64
+		for _, v := range s {
+			if v != v {
+				nans = append(nans, v)
+			} else {
+				s[n] = v
+				n++
+			}
+		}
+		copy(s[n:], nans)
+		s = s[:n]
+		sort.Slice(s, func(i, j int) bool { return typedFloatLess(s[i], s[j]) })
+	default:
+		return false
+	}
+	return true
+}
+
+func countingSortUint8(s []uint8) {
+	var counts [256]int
+	for _, v := range s {
+		counts[v]++
+	}
+	i := 0
+	for v, c := range counts {
+		for ; c > 0; c-- {
+			s[i] = uint8(v)
+			i++
+		}
+	}
 }
 
 func (ctx *typedArraySortCtx) Len() int {
@@ -20,6 +107,7 @@ func (ctx *typedArraySortCtx) Len() int {
 }
 
 func (ctx *typedArraySortCtx) Less(i, j int) bool {
+	ctx.vm.checkInterrupt()
 	if ctx.needValidate {
 		ctx.ta.viewedArrayBuf.ensureNotDetached(true)
 		ctx.needValidate = false
@@ -80,11 +168,31 @@ func (r *Runtime) builtin_newArrayBuffer(args []Value, newTarget *Object) *Objec
 	}
 	b := r._newArrayBuffer(r.getPrototypeFromCtor(newTarget, r.global.ArrayBuffer, r.global.ArrayBufferPrototype), nil)
 	if len(args) > 0 {
-		b.data = allocByteSlice(r.toIndex(args[0]))
+		r.allocArrayBufferData(b, r.toIndex(args[0]))
 	}
 	return b.val
 }
 
+// allocArrayBufferData allocates zeroed storage of the specified size for the ArrayBuffer, using the
+// ArrayBufferPool if one is set.
+func (r *Runtime) allocArrayBufferData(b *arrayBufferObject, size int) {
+	r.accountAlloc(int64(size))
+	if pool := r.arrayBufferPool; pool != nil && size > 0 {
+		data := pool.Get(size)
+		if len(data) != size {
+			panic(r.NewTypeError("ArrayBufferPool returned a buffer of invalid length %d (expected %d)", len(data), size))
+		}
+		for i := range data {
+			data[i] = 0
+		}
+		b.data = data
+		b.pooled = true
+		return
+	}
+	b.data = allocByteSlice(size)
+	b.pooled = false
+}
+
 func (r *Runtime) arrayBufferProto_getByteLength(call FunctionCall) Value {
 	o := r.toObject(call.This)
 	if b, ok := o.self.(*arrayBufferObject); ok {
@@ -99,6 +207,7 @@ func (r *Runtime) arrayBufferProto_getByteLength(call FunctionCall) Value {
 func (r *Runtime) arrayBufferProto_slice(call FunctionCall) Value {
 	o := r.toObject(call.This)
 	if b, ok := o.self.(*arrayBufferObject); ok {
+		b.ensureNotDetached(true)
 		l := int64(len(b.data))
 		start := relToIdx(call.Argument(0).ToInteger(), l)
 		var stop int64
@@ -129,6 +238,49 @@ func (r *Runtime) arrayBufferProto_slice(call FunctionCall) Value {
 	panic(r.NewTypeError("Object is not ArrayBuffer: %s", o))
 }
 
+func (r *Runtime) arrayBufferProto_getDetached(call FunctionCall) Value {
+	o := r.toObject(call.This)
+	if b, ok := o.self.(*arrayBufferObject); ok {
+		return r.toBoolean(b.detached)
+	}
+	panic(r.NewTypeError("Object is not ArrayBuffer: %s", o))
+}
+
+func (r *Runtime) arrayBufferProto_transfer(call FunctionCall) Value {
+	o := r.toObject(call.This)
+	if b, ok := o.self.(*arrayBufferObject); ok {
+		arg := call.Argument(0)
+		var newLen int
+		if arg != _undefined {
+			newLen = r.toIndex(arg)
+		}
+		b.ensureNotDetached(true)
+		if arg == _undefined {
+			newLen = len(b.data)
+		}
+		ret := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
+		if newLen == 0 {
+			b.release()
+		} else if newLen <= len(b.data) || b.pooled && newLen <= cap(b.data) {
+			// the spare capacity of a slice supplied by the host is not ours to use.
+			// The storage is moved to the new buffer without copying.
+			ret.data = b.data[:newLen]
+			ret.pooled = b.pooled
+			ret.exposed = b.exposed
+			for i := len(b.data); i < newLen; i++ {
+				ret.data[i] = 0
+			}
+			b.detach()
+		} else {
+			r.allocArrayBufferData(ret, newLen)
+			copy(ret.data, b.data)
+			b.release()
+		}
+		return ret.val
+	}
+	panic(r.NewTypeError("Object is not ArrayBuffer: %s", o))
+}
+
 func (r *Runtime) arrayBuffer_isView(call FunctionCall) Value {
 	if o, ok := call.Argument(0).(*Object); ok {
 		if _, ok := o.self.(*dataViewObject); ok {
@@ -161,12 +313,11 @@ func (r *Runtime) newDataView(args []Value, newTarget *Object) *Object {
 	}
 	var byteOffset, byteLen int
 	if len(args) > 1 {
-		offsetArg := nilSafe(args[1])
-		byteOffset = r.toIndex(offsetArg)
-		buffer.ensureNotDetached(true)
-		if byteOffset > len(buffer.data) {
-			panic(r.newError(r.global.RangeError, "Start offset %s is outside the bounds of the buffer", offsetArg.String()))
-		}
+		byteOffset = r.toIndex(nilSafe(args[1]))
+	}
+	buffer.ensureNotDetached(true)
+	if byteOffset > len(buffer.data) {
+		panic(r.newError(r.global.RangeError, "Start offset %d is outside the bounds of the buffer", byteOffset))
 	}
 	if len(args) > 2 && args[2] != nil && args[2] != _undefined {
 		byteLen = r.toIndex(args[2])
@@ -216,6 +367,13 @@ func (r *Runtime) dataViewProto_getByteOffset(call FunctionCall) Value {
 	panic(r.NewTypeError("Method get DataView.prototype.byteOffset called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: call.This})))
 }
 
+func (r *Runtime) dataViewProto_getFloat16(call FunctionCall) Value {
+	if dv, ok := r.toObject(call.This).self.(*dataViewObject); ok {
+		return floatToValue(dv.viewedArrayBuf.getFloat16(dv.getIdxAndByteOrder(r.toIndex(call.Argument(0)), call.Argument(1), 2)))
+	}
+	panic(r.NewTypeError("Method DataView.prototype.getFloat16 called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: call.This})))
+}
+
 func (r *Runtime) dataViewProto_getFloat32(call FunctionCall) Value {
 	if dv, ok := r.toObject(call.This).self.(*dataViewObject); ok {
 		return floatToValue(float64(dv.viewedArrayBuf.getFloat32(dv.getIdxAndByteOrder(r.toIndex(call.Argument(0)), call.Argument(1), 4))))
@@ -274,6 +432,17 @@ func (r *Runtime) dataViewProto_getUint32(call FunctionCall) Value {
 	panic(r.NewTypeError("Method DataView.prototype.getUint32 called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: call.This})))
 }
 
+func (r *Runtime) dataViewProto_setFloat16(call FunctionCall) Value {
+	if dv, ok := r.toObject(call.This).self.(*dataViewObject); ok {
+		idxVal := r.toIndex(call.Argument(0))
+		val := toFloat16(call.Argument(1))
+		idx, bo := dv.getIdxAndByteOrder(idxVal, call.Argument(2), 2)
+		dv.viewedArrayBuf.setFloat16(idx, val, bo)
+		return _undefined
+	}
+	panic(r.NewTypeError("Method DataView.prototype.setFloat16 called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: call.This})))
+}
+
 func (r *Runtime) dataViewProto_setFloat32(call FunctionCall) Value {
 	if dv, ok := r.toObject(call.This).self.(*dataViewObject); ok {
 		idxVal := r.toIndex(call.Argument(0))
@@ -1004,11 +1173,25 @@ func (r *Runtime) typedArrayProto_set(call FunctionCall) Value {
 			if x := srcLen + targetOffset; x < 0 || x > targetLen {
 				panic(r.newError(r.global.RangeError, "Source is too large"))
 			}
-			for i := 0; i < srcLen; i++ {
+			i := 0
+			if arr := r.checkStdArrayObjWithProto(srcObj); arr != nil {
+				// Numbers can be stored without running any user code, so the values can be read directly.
+				// Bail out to the generic path at the first value of any other type.
+				dstOffset := ta.offset + targetOffset
+				for ; i < srcLen; i++ {
+					switch val := arr.values[i].(type) {
+					case valueInt, valueFloat:
+						ta.typedArray.set(dstOffset+i, val)
+						continue
+					}
+					break
+				}
+			}
+			for ; i < srcLen; i++ {
 				val := nilSafe(srcObj.self.getIdx(valueInt(i), nil))
 				ta.viewedArrayBuf.ensureNotDetached(true)
-				if ta.isValidIntegerIndex(i) {
-					ta.typedArray.set(targetOffset+i, val)
+				if ta.isValidIntegerIndex(targetOffset + i) {
+					ta.typedArray.set(ta.offset+targetOffset+i, val)
 				}
 			}
 		}
@@ -1086,9 +1269,17 @@ func (r *Runtime) typedArrayProto_sort(call FunctionCall) Value {
 			compareFn = r.toCallable(arg)
 		}
 
+		if compareFn == nil {
+			r.vm.checkInterrupt()
+			if typedArraySortDefault(ta) {
+				return call.This
+			}
+		}
+
 		ctx := typedArraySortCtx{
 			ta:      ta,
 			compare: compareFn,
+			vm:      r.vm,
 		}
 
 		sort.Stable(&ctx)
@@ -1217,7 +1408,7 @@ func (r *Runtime) allocateTypedArray(newTarget *Object, length int, taCtor typed
 	buf := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
 	ta := taCtor(buf, 0, length, r.getPrototypeFromCtor(newTarget, nil, proto))
 	if length > 0 {
-		buf.data = allocByteSlice(length * ta.elemSize)
+		r.allocArrayBufferData(buf, length*ta.elemSize)
 	}
 	return ta
 }
@@ -1328,7 +1519,7 @@ func (r *Runtime) _newTypedArrayFromTypedArray(src *typedArrayObject, newTarget
 	l := src.length
 
 	dst.viewedArrayBuf.prototype = r.getPrototypeFromCtor(r.speciesConstructorObj(src.viewedArrayBuf.val, r.global.ArrayBuffer), r.global.ArrayBuffer, r.global.ArrayBufferPrototype)
-	dst.viewedArrayBuf.data = allocByteSlice(toIntStrict(int64(l) * int64(dst.elemSize)))
+	r.allocArrayBufferData(dst.viewedArrayBuf, toIntStrict(int64(l)*int64(dst.elemSize)))
 	src.viewedArrayBuf.ensureNotDetached(true)
 	if src.defaultCtor == dst.defaultCtor {
 		copy(dst.viewedArrayBuf.data, src.viewedArrayBuf.data[src.offset*src.elemSize:])
@@ -1395,6 +1586,10 @@ func (r *Runtime) newInt32Array(args []Value, newTarget, proto *Object) *Object
 	return r._newTypedArray(args, newTarget, r.newInt32ArrayObject, proto)
 }
 
+func (r *Runtime) newFloat16Array(args []Value, newTarget, proto *Object) *Object {
+	return r._newTypedArray(args, newTarget, r.newFloat16ArrayObject, proto)
+}
+
 func (r *Runtime) newFloat32Array(args []Value, newTarget, proto *Object) *Object {
 	return r._newTypedArray(args, newTarget, r.newFloat32ArrayObject, proto)
 }
@@ -1413,6 +1608,13 @@ func (r *Runtime) createArrayBufferProto(val *Object) objectImpl {
 	b._put("byteLength", byteLengthProp)
 	b._putProp("constructor", r.global.ArrayBuffer, true, false, true)
 	b._putProp("slice", r.newNativeFunc(r.arrayBufferProto_slice, nil, "slice", nil, 2), true, false, true)
+	b._put("detached", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.arrayBufferProto_getDetached, nil, "get detached", nil, 0),
+	})
+	b._putProp("transfer", r.newNativeFunc(r.arrayBufferProto_transfer, nil, "transfer", nil, 0), true, false, true)
+	b._putProp("transferToFixedLength", r.newNativeFunc(r.arrayBufferProto_transfer, nil, "transferToFixedLength", nil, 0), true, false, true)
 	b._putSym(SymToStringTag, valueProp(asciiString("ArrayBuffer"), false, false, true))
 	return b
 }
@@ -1443,6 +1645,7 @@ func (r *Runtime) createDataViewProto(val *Object) objectImpl {
 		getterFunc:   r.newNativeFunc(r.dataViewProto_getByteOffset, nil, "get byteOffset", nil, 0),
 	})
 	b._putProp("constructor", r.global.DataView, true, false, true)
+	b._putProp("getFloat16", r.newNativeFunc(r.dataViewProto_getFloat16, nil, "getFloat16", nil, 1), true, false, true)
 	b._putProp("getFloat32", r.newNativeFunc(r.dataViewProto_getFloat32, nil, "getFloat32", nil, 1), true, false, true)
 	b._putProp("getFloat64", r.newNativeFunc(r.dataViewProto_getFloat64, nil, "getFloat64", nil, 1), true, false, true)
 	b._putProp("getInt8", r.newNativeFunc(r.dataViewProto_getInt8, nil, "getInt8", nil, 1), true, false, true)
@@ -1451,6 +1654,7 @@ func (r *Runtime) createDataViewProto(val *Object) objectImpl {
 	b._putProp("getUint8", r.newNativeFunc(r.dataViewProto_getUint8, nil, "getUint8", nil, 1), true, false, true)
 	b._putProp("getUint16", r.newNativeFunc(r.dataViewProto_getUint16, nil, "getUint16", nil, 1), true, false, true)
 	b._putProp("getUint32", r.newNativeFunc(r.dataViewProto_getUint32, nil, "getUint32", nil, 1), true, false, true)
+	b._putProp("setFloat16", r.newNativeFunc(r.dataViewProto_setFloat16, nil, "setFloat16", nil, 2), true, false, true)
 	b._putProp("setFloat32", r.newNativeFunc(r.dataViewProto_setFloat32, nil, "setFloat32", nil, 2), true, false, true)
 	b._putProp("setFloat64", r.newNativeFunc(r.dataViewProto_setFloat64, nil, "setFloat64", nil, 2), true, false, true)
 	b._putProp("setInt8", r.newNativeFunc(r.dataViewProto_setInt8, nil, "setInt8", nil, 2), true, false, true)
@@ -1570,7 +1774,7 @@ func (r *Runtime) initTypedArrays() {
 	r.global.TypedArrayPrototype = r.newLazyObject(r.createTypedArrayProto)
 	r.global.TypedArray = r.newLazyObject(r.createTypedArray)
 
-	r.global.Uint8Array = r.newLazyObject(r.typedArrayCreator(r.newUint8Array, "Uint8Array", 1))
+	r.global.Uint8Array = r.newLazyObject(r.createUint8Array)
 	r.addToGlobal("Uint8Array", r.global.Uint8Array)
 
 	r.global.Uint8ClampedArray = r.newLazyObject(r.typedArrayCreator(r.newUint8ClampedArray, "Uint8ClampedArray", 1))
@@ -1591,6 +1795,9 @@ func (r *Runtime) initTypedArrays() {
 	r.global.Int32Array = r.newLazyObject(r.typedArrayCreator(r.newInt32Array, "Int32Array", 4))
 	r.addToGlobal("Int32Array", r.global.Int32Array)
 
+	r.global.Float16Array = r.newLazyObject(r.typedArrayCreator(r.newFloat16Array, "Float16Array", 2))
+	r.addToGlobal("Float16Array", r.global.Float16Array)
+
 	r.global.Float32Array = r.newLazyObject(r.typedArrayCreator(r.newFloat32Array, "Float32Array", 4))
 	r.addToGlobal("Float32Array", r.global.Float32Array)
 
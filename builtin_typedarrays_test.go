@@ -297,6 +297,49 @@ func TestTypedArraySortComparatorReturnValueNegZero(t *testing.T) {
 	testScript(SCRIPT, _undefined, t)
 }
 
+func TestTypedArraySortDefault(t *testing.T) {
+	const SCRIPT = `
+	function check(ta, expected, msg) {
+		assert(compareArray(ta, expected), msg + ": " + ta);
+	}
+	var buf = new ArrayBuffer(16);
+	var u8 = new Uint8Array(buf);
+	u8.set([9, 8, 7, 6, 255, 0, 128, 3, 5, 4]);
+	new Uint8Array(buf, 4, 4).sort();
+	check(u8.subarray(0, 10), [9, 8, 7, 6, 0, 3, 128, 255, 5, 4], "Uint8Array view");
+
+	check(new Int8Array([3, -128, 127, -1, 0]).sort(), [-128, -1, 0, 3, 127], "Int8Array");
+	check(new Uint8ClampedArray([3, 255, 0, 3]).sort(), [0, 3, 3, 255], "Uint8ClampedArray");
+	check(new Int16Array([300, -300, 0]).sort(), [-300, 0, 300], "Int16Array");
+	check(new Uint16Array([65535, 1, 0]).sort(), [0, 1, 65535], "Uint16Array");
+	check(new Int32Array([2147483647, -2147483648, 0]).sort(), [-2147483648, 0, 2147483647], "Int32Array");
+	check(new Uint32Array([4294967295, 1, 0]).sort(), [0, 1, 4294967295], "Uint32Array");
+
+	var f = new Float64Array([3, NaN, -Infinity, 0, -0, -1.5, Infinity]).sort();
+	check(f.subarray(0, 6), [-Infinity, -1.5, -0, 0, 3, Infinity], "Float64Array");
+	assert.sameValue(1 / f[2], -Infinity, "Float64Array -0");
+	assert.sameValue(f[6], NaN, "Float64Array NaN");
+	f = new Float32Array([0, NaN, -0, 1]).sort();
+	assert.sameValue(1 / f[0], -Infinity, "Float32Array -0");
+	assert.sameValue(f[3], NaN, "Float32Array NaN");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestTypedArraySetFromArray(t *testing.T) {
+	const SCRIPT = `
+	var b = new Uint8Array(8);
+	var s = b.subarray(4);
+	s.set([1, 2.7, -1]);
+	assert(compareArray(b, [0, 0, 0, 0, 1, 2, 255, 0]), "numbers: " + b);
+
+	var a = [1, {valueOf: function() { a[2] = 42; return 2; }}, 3];
+	s.set(a, 1);
+	assert(compareArray(b, [0, 0, 0, 0, 1, 1, 2, 42]), "values changed by valueOf: " + b);
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestInt32ArrayNegativeIndex(t *testing.T) {
 	const SCRIPT = `
 	new Int32Array()[-1] === undefined;
@@ -324,3 +367,77 @@ func TestTypedArrayDeleteUnconfigurable(t *testing.T) {
 
 	testScript(SCRIPT, _undefined, t)
 }
+
+func TestFloat16Array(t *testing.T) {
+	const SCRIPT = `
+	var a = new Float16Array([1.337, 65504, 65520, -1e-8, 5.960464477539063e-8, NaN, Infinity]);
+	assert.sameValue(Float16Array.BYTES_PER_ELEMENT, 2);
+	assert.sameValue(a.byteLength, 14);
+	assert(compareArray(a.slice(0, 5), [1.3369140625, 65504, Infinity, -0, 5.960464477539063e-8]), a.join());
+	assert.sameValue(a[5], NaN);
+	assert.sameValue(a[6], Infinity);
+	assert.sameValue(1 / a[3], -Infinity, "negative zero");
+	assert(a.includes(65504), "includes");
+	assert(!a.includes(1.337), "includes inexact");
+	assert(a.includes(NaN), "includes NaN");
+	assert.sameValue(a.indexOf(1.3369140625), 0);
+
+	var u = new Uint16Array(a.buffer);
+	assert.sameValue(u[0], 0x3d59);
+	assert.sameValue(u[1], 0x7bff);
+	assert.sameValue(u[4], 1);
+
+	var sorted = new Float16Array([3, -1, NaN, 0.5, 0, -0]).sort();
+	assert(compareArray(sorted.subarray(0, 5), [-1, -0, 0, 0.5, 3]), "sort");
+	assert.sameValue(1 / sorted[1], -Infinity, "sort -0");
+	assert.sameValue(sorted[5], NaN, "sort NaN");
+
+	assert.sameValue(Math.f16round.length, 1);
+	assert.sameValue(Math.f16round(1.337), 1.3369140625);
+	assert.sameValue(Math.f16round(2.9802322387695312e-8), 0, "ties to even (zero)");
+	assert.sameValue(Math.f16round(8.940696716308594e-8), 1.1920928955078125e-7, "ties to even (subnormal)");
+	assert.sameValue(Math.f16round(65519.99), 65504);
+	assert.sameValue(Math.f16round(65520), Infinity);
+	assert.sameValue(Math.f16round(1 + Math.pow(2, -11) + Math.pow(2, -30)), 1.0009765625, "no double rounding");
+	assert.sameValue(Math.f16round(), NaN);
+
+	var dv = new DataView(new ArrayBuffer(4));
+	dv.setFloat16(0, 1.5);
+	dv.setFloat16(2, 1.5, true);
+	assert.sameValue(dv.getUint16(0), 0x3e00);
+	assert.sameValue(dv.getUint16(2, true), 0x3e00);
+	assert.sameValue(dv.getFloat16(0), 1.5);
+	assert.sameValue(dv.getFloat16(2, true), 1.5);
+	assert.throws(RangeError, function() { dv.getFloat16(3); });
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func BenchmarkTypedArraySort(b *testing.B) {
+	vm := New()
+	_, err := vm.RunString(`
+	var fsrc = new Float64Array(10000);
+	var usrc = new Uint8Array(10000);
+	for (var i = 0; i < fsrc.length; i++) {
+		fsrc[i] = Math.sin(i);
+		usrc[i] = i * 31;
+	}
+	var f = new Float64Array(fsrc.length);
+	var u = new Uint8Array(usrc.length);
+	`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	prg := MustCompile("test.js", `
+	f.set(fsrc);
+	f.sort();
+	u.set(usrc);
+	u.sort();
+	`, false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := vm.RunProgram(prg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
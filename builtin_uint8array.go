@@ -0,0 +1,339 @@
+package goja
+
+import (
+	"encoding/base64"
+	hexenc "encoding/hex"
+)
+
+// Base64 and hex conversion methods of Uint8Array, see https://tc39.es/proposal-arraybuffer-base64/
+
+const (
+	base64LastChunkLoose = iota
+	base64LastChunkStrict
+	base64LastChunkStopBeforePartial
+)
+
+func (r *Runtime) createUint8Array(val *Object) objectImpl {
+	o := r.typedArrayCreator(r.newUint8Array, "Uint8Array", 1)(val)
+	o._putProp("fromBase64", r.newNativeFunc(r.uint8Array_fromBase64, nil, "fromBase64", nil, 1), true, false, true)
+	o._putProp("fromHex", r.newNativeFunc(r.uint8Array_fromHex, nil, "fromHex", nil, 1), true, false, true)
+
+	p := o.getStr("prototype", nil).(*Object)
+	r.putMethod(p, "setFromBase64", r.uint8ArrayProto_setFromBase64, 1)
+	r.putMethod(p, "setFromHex", r.uint8ArrayProto_setFromHex, 1)
+	r.putMethod(p, "toBase64", r.uint8ArrayProto_toBase64, 0)
+	r.putMethod(p, "toHex", r.uint8ArrayProto_toHex, 0)
+	return o
+}
+
+func (r *Runtime) toUint8Array(v Value, method string) *typedArrayObject {
+	if o, ok := v.(*Object); ok {
+		if ta, ok := o.self.(*typedArrayObject); ok {
+			if _, ok := ta.typedArray.(*uint8Array); ok {
+				return ta
+			}
+		}
+	}
+	panic(r.NewTypeError("Method Uint8Array.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (ta *typedArrayObject) uint8Bytes() []byte {
+	ta.viewedArrayBuf.ensureNotDetached(true)
+	return ta.viewedArrayBuf.data[ta.offset : ta.offset+ta.length]
+}
+
+func (r *Runtime) base64StringArg(v Value) string {
+	if s, ok := v.(valueString); ok {
+		return s.String()
+	}
+	panic(r.NewTypeError("The argument must be a string"))
+}
+
+func (r *Runtime) getOptionsObject(v Value) *Object {
+	switch v := v.(type) {
+	case valueUndefined:
+		return nil
+	case *Object:
+		return v
+	}
+	panic(r.NewTypeError("Options must be an object"))
+}
+
+func (r *Runtime) getStringOption(opts *Object, name, def string, values ...string) string {
+	if opts == nil {
+		return def
+	}
+	v := opts.self.getStr(asciiString(name).string(), nil)
+	if v == nil || v == _undefined {
+		return def
+	}
+	if s, ok := v.(valueString); ok {
+		str := s.String()
+		for _, value := range values {
+			if str == value {
+				return str
+			}
+		}
+	}
+	panic(r.NewTypeError("Invalid value for option %s: %s", name, v.String()))
+}
+
+func (r *Runtime) getBase64Alphabet(opts *Object) bool {
+	return r.getStringOption(opts, "alphabet", "base64", "base64", "base64url") == "base64url"
+}
+
+func (r *Runtime) getBase64LastChunkHandling(opts *Object) int {
+	switch r.getStringOption(opts, "lastChunkHandling", "loose", "loose", "strict", "stop-before-partial") {
+	case "strict":
+		return base64LastChunkStrict
+	case "stop-before-partial":
+		return base64LastChunkStopBeforePartial
+	}
+	return base64LastChunkLoose
+}
+
+func isASCIIWhitespace(c byte) bool {
+	switch c {
+	case '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func skipASCIIWhitespace(s string, i int) int {
+	for i < len(s) && isASCIIWhitespace(s[i]) {
+		i++
+	}
+	return i
+}
+
+func base64Value(c byte) (uint32, bool) {
+	switch {
+	case c >= 'A' && c <= 'Z':
+		return uint32(c - 'A'), true
+	case c >= 'a' && c <= 'z':
+		return uint32(c-'a') + 26, true
+	case c >= '0' && c <= '9':
+		return uint32(c-'0') + 52, true
+	case c == '+':
+		return 62, true
+	case c == '/':
+		return 63, true
+	}
+	return 0, false
+}
+
+// appendBase64Chunk appends the bytes of an incomplete chunk of 2 or 3 characters. It returns false if
+// throwOnExtraBits is set and the padding bits are not zero.
+func appendBase64Chunk(b []byte, chunk uint32, chunkLen int, throwOnExtraBits bool) ([]byte, bool) {
+	if chunkLen == 2 {
+		if throwOnExtraBits && chunk&0xf != 0 {
+			return b, false
+		}
+		return append(b, byte(chunk>>4)), true
+	}
+	if throwOnExtraBits && chunk&0x3 != 0 {
+		return b, false
+	}
+	return append(b, byte(chunk>>10), byte(chunk>>2)), true
+}
+
+// fromBase64 implements FromBase64 from the proposal. It returns the number of characters read and the decoded
+// bytes, which are the ones decoded before the error if there is one. Well-formed input which does not need to be
+// truncated is decoded using encoding/base64.
+func fromBase64(s string, url bool, lastChunkHandling, maxLength int) (read int, b []byte, err string) {
+	if maxLength == 0 {
+		return 0, nil, ""
+	}
+
+	enc := base64.StdEncoding
+	if url {
+		enc = base64.URLEncoding
+	}
+	if lastChunkHandling == base64LastChunkStrict {
+		enc = enc.Strict()
+	}
+	// encoding/base64 only skips \r and \n and requires padding, so it accepts a subset of the valid input
+	if enc.DecodedLen(len(s)) < maxLength {
+		buf := make([]byte, enc.DecodedLen(len(s)))
+		if n, err := enc.Decode(buf, []byte(s)); err == nil {
+			return len(s), buf[:n], ""
+		}
+	}
+
+	var chunk uint32
+	chunkLen := 0
+	i := 0
+	for {
+		i = skipASCIIWhitespace(s, i)
+		if i == len(s) {
+			if chunkLen > 0 {
+				switch lastChunkHandling {
+				case base64LastChunkStopBeforePartial:
+					return read, b, ""
+				case base64LastChunkStrict:
+					return read, b, "Missing padding in base64 string"
+				}
+				if chunkLen == 1 {
+					return read, b, "Incomplete base64 string"
+				}
+				b, _ = appendBase64Chunk(b, chunk, chunkLen, false)
+			}
+			return len(s), b, ""
+		}
+		c := s[i]
+		i++
+		if c == '=' {
+			if chunkLen < 2 {
+				return read, b, "Unexpected padding in base64 string"
+			}
+			i = skipASCIIWhitespace(s, i)
+			if chunkLen == 2 {
+				if i == len(s) {
+					if lastChunkHandling == base64LastChunkStopBeforePartial {
+						return read, b, ""
+					}
+					return read, b, "Incomplete padding in base64 string"
+				}
+				if s[i] == '=' {
+					i = skipASCIIWhitespace(s, i+1)
+				}
+			}
+			if i < len(s) {
+				return read, b, "Unexpected data after padding in base64 string"
+			}
+			var ok bool
+			if b, ok = appendBase64Chunk(b, chunk, chunkLen, lastChunkHandling == base64LastChunkStrict); !ok {
+				return read, b, "Non-zero padding bits in base64 string"
+			}
+			return len(s), b, ""
+		}
+		if url {
+			switch c {
+			case '+', '/':
+				c = 0
+			case '-':
+				c = '+'
+			case '_':
+				c = '/'
+			}
+		}
+		v, ok := base64Value(c)
+		if !ok {
+			return read, b, "Invalid character in base64 string"
+		}
+		remaining := maxLength - len(b)
+		if remaining == 1 && chunkLen == 2 || remaining == 2 && chunkLen == 3 {
+			return read, b, ""
+		}
+		chunk = chunk<<6 | v
+		chunkLen++
+		if chunkLen == 4 {
+			b = append(b, byte(chunk>>16), byte(chunk>>8), byte(chunk))
+			chunk, chunkLen = 0, 0
+			read = i
+			if len(b) == maxLength {
+				return read, b, ""
+			}
+		}
+	}
+}
+
+// fromHex implements FromHex from the proposal, see fromBase64. length is the length of the string in UTF-16
+// code units.
+func fromHex(s string, length, maxLength int) (read int, b []byte, err string) {
+	if length%2 != 0 {
+		return 0, nil, "Hex string must have an even length"
+	}
+	n := len(s) / 2
+	if n > maxLength {
+		n = maxLength
+	}
+	b = make([]byte, n)
+	n, e := hexenc.Decode(b, []byte(s[:n*2]))
+	if e != nil {
+		return n * 2, b[:n], "Invalid character in hex string"
+	}
+	return n * 2, b, ""
+}
+
+func (r *Runtime) uint8Array_fromBase64(call FunctionCall) Value {
+	s := r.base64StringArg(call.Argument(0))
+	opts := r.getOptionsObject(call.Argument(1))
+	url := r.getBase64Alphabet(opts)
+	lastChunkHandling := r.getBase64LastChunkHandling(opts)
+	_, b, err := fromBase64(s, url, lastChunkHandling, maxInt)
+	if err != "" {
+		panic(r.newError(r.global.SyntaxError, err))
+	}
+	return r.newUint8ArrayFromBytes(b)
+}
+
+func (r *Runtime) uint8Array_fromHex(call FunctionCall) Value {
+	arg := call.Argument(0)
+	s := r.base64StringArg(arg)
+	_, b, err := fromHex(s, arg.(valueString).length(), maxInt)
+	if err != "" {
+		panic(r.newError(r.global.SyntaxError, err))
+	}
+	return r.newUint8ArrayFromBytes(b)
+}
+
+func (r *Runtime) newReadWrittenResult(read, written int) Value {
+	res := r.NewObject()
+	createDataPropertyOrThrow(res, asciiString("read"), intToValue(int64(read)))
+	createDataPropertyOrThrow(res, asciiString("written"), intToValue(int64(written)))
+	return res
+}
+
+func (r *Runtime) uint8ArrayProto_setFromBase64(call FunctionCall) Value {
+	ta := r.toUint8Array(call.This, "setFromBase64")
+	s := r.base64StringArg(call.Argument(0))
+	opts := r.getOptionsObject(call.Argument(1))
+	url := r.getBase64Alphabet(opts)
+	lastChunkHandling := r.getBase64LastChunkHandling(opts)
+	dst := ta.uint8Bytes()
+	read, b, err := fromBase64(s, url, lastChunkHandling, len(dst))
+	copy(dst, b)
+	if err != "" {
+		panic(r.newError(r.global.SyntaxError, err))
+	}
+	return r.newReadWrittenResult(read, len(b))
+}
+
+func (r *Runtime) uint8ArrayProto_setFromHex(call FunctionCall) Value {
+	ta := r.toUint8Array(call.This, "setFromHex")
+	arg := call.Argument(0)
+	s := r.base64StringArg(arg)
+	dst := ta.uint8Bytes()
+	read, b, err := fromHex(s, arg.(valueString).length(), len(dst))
+	copy(dst, b)
+	if err != "" {
+		panic(r.newError(r.global.SyntaxError, err))
+	}
+	return r.newReadWrittenResult(read, len(b))
+}
+
+func (r *Runtime) uint8ArrayProto_toBase64(call FunctionCall) Value {
+	ta := r.toUint8Array(call.This, "toBase64")
+	opts := r.getOptionsObject(call.Argument(0))
+	url := r.getBase64Alphabet(opts)
+	omitPadding := opts != nil && nilSafe(opts.self.getStr("omitPadding", nil)).ToBoolean()
+	var enc *base64.Encoding
+	switch {
+	case url && omitPadding:
+		enc = base64.RawURLEncoding
+	case url:
+		enc = base64.URLEncoding
+	case omitPadding:
+		enc = base64.RawStdEncoding
+	default:
+		enc = base64.StdEncoding
+	}
+	return asciiString(enc.EncodeToString(ta.uint8Bytes()))
+}
+
+func (r *Runtime) uint8ArrayProto_toHex(call FunctionCall) Value {
+	ta := r.toUint8Array(call.This, "toHex")
+	return asciiString(hexenc.EncodeToString(ta.uint8Bytes()))
+}
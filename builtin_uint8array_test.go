@@ -0,0 +1,125 @@
+package goja
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestUint8ArrayBase64(t *testing.T) {
+	const SCRIPT = `
+	var hello = new Uint8Array([72, 101, 108, 108, 111]);
+	assert.sameValue(hello.toBase64(), "SGVsbG8=");
+	assert.sameValue(hello.toBase64({omitPadding: true}), "SGVsbG8");
+	assert.sameValue(new Uint8Array([251, 255]).toBase64(), "+/8=");
+	assert.sameValue(new Uint8Array([251, 255]).toBase64({alphabet: "base64url"}), "-_8=");
+	assert.sameValue(new Uint8Array([251, 255]).toBase64({alphabet: "base64url", omitPadding: 1}), "-_8");
+	assert.sameValue(new Uint8Array(0).toBase64(), "");
+	assert.sameValue(hello.subarray(1, 3).toBase64(), "ZWw=");
+
+	assert(compareArray(Uint8Array.fromBase64("SGVsbG8="), hello), "padded");
+	assert(compareArray(Uint8Array.fromBase64(" SGVs\tbG8\n= "), hello), "whitespace");
+	assert(compareArray(Uint8Array.fromBase64("SGVsbG8"), hello), "unpadded");
+	assert(compareArray(Uint8Array.fromBase64("SGVsbG9="), hello), "extra bits");
+	assert(compareArray(Uint8Array.fromBase64("SGVsbG8", {lastChunkHandling: "stop-before-partial"}), [72, 101, 108]), "stop-before-partial");
+	assert(compareArray(Uint8Array.fromBase64("SGVsbG8=", {lastChunkHandling: "strict"}), hello), "strict");
+	assert(compareArray(Uint8Array.fromBase64("-_8", {alphabet: "base64url"}), [251, 255]), "base64url");
+	assert.sameValue(Uint8Array.fromBase64("").length, 0);
+
+	for (var s of ["SGVsbG8", "SGVsbG9="]) {
+		assert.throws(SyntaxError, function() { Uint8Array.fromBase64(s, {lastChunkHandling: "strict"}); }, s);
+	}
+	for (var s of ["S", "SGVsbG8==", "SG=", "SGVsbG8=A", "S===", "SGVsé", "+/8="]) {
+		assert.throws(SyntaxError, function() { Uint8Array.fromBase64(s, {alphabet: "base64url"}); }, s);
+	}
+	assert.throws(TypeError, function() { Uint8Array.fromBase64(new String("SGVsbG8=")); });
+	assert.throws(TypeError, function() { Uint8Array.fromBase64("", {alphabet: "other"}); });
+	assert.throws(TypeError, function() { Uint8Array.fromBase64("", {alphabet: new String("base64")}); });
+	assert.throws(TypeError, function() { Uint8Array.fromBase64("", {lastChunkHandling: "none"}); });
+	assert.throws(TypeError, function() { Uint8Array.fromBase64("", "strict"); });
+
+	var target = new Uint8Array(4);
+	var res = target.setFromBase64("SGVsbG8=");
+	assert.sameValue(res.read, 4);
+	assert.sameValue(res.written, 3);
+	assert(compareArray(target, [72, 101, 108, 0]), "partial set");
+
+	target = new Uint8Array(5);
+	res = target.setFromBase64("SGVsbG8=");
+	assert.sameValue(res.read, 8);
+	assert.sameValue(res.written, 5);
+	assert(compareArray(target, hello), "exact set");
+
+	target = new Uint8Array(3);
+	res = target.setFromBase64("SGVs  \n");
+	assert.sameValue(res.read, 4, "read stops after the last chunk");
+	assert.sameValue(res.written, 3);
+
+	target = new Uint8Array(8);
+	assert.throws(SyntaxError, function() { target.setFromBase64("SGVsbG8=!"); });
+	assert(compareArray(target, [72, 101, 108, 0, 0, 0, 0, 0]), "bytes before the error are written");
+
+	var detaching = new Uint8Array(4);
+	assert.throws(TypeError, function() {
+		detaching.toBase64({get alphabet() { detaching.buffer.transfer(); return "base64"; }});
+	});
+	assert.throws(TypeError, function() { Uint8Array.prototype.toBase64.call(new Uint8ClampedArray(1)); });
+	assert.sameValue(Uint8ClampedArray.fromBase64, undefined);
+	assert.sameValue(Uint8Array.fromBase64.length, 1);
+	assert.sameValue(Uint8Array.prototype.toBase64.length, 0);
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestUint8ArrayHex(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(new Uint8Array([0, 255, 16, 171]).toHex(), "00ff10ab");
+	assert.sameValue(new Uint8Array(0).toHex(), "");
+	assert(compareArray(Uint8Array.fromHex("00Ff10aB"), [0, 255, 16, 171]), "fromHex");
+	assert.throws(SyntaxError, function() { Uint8Array.fromHex("abc"); });
+	assert.throws(SyntaxError, function() { Uint8Array.fromHex("0g"); });
+	assert.throws(SyntaxError, function() { Uint8Array.fromHex("éé"); });
+	assert.throws(SyntaxError, function() { Uint8Array.fromHex("é"); });
+	assert.throws(TypeError, function() { Uint8Array.fromHex(12); });
+
+	var target = new Uint8Array(2);
+	var res = target.setFromHex("aabbcc");
+	assert.sameValue(res.read, 4);
+	assert.sameValue(res.written, 2);
+	assert(compareArray(target, [0xaa, 0xbb]), "setFromHex");
+
+	target = new Uint8Array(3);
+	assert.throws(SyntaxError, function() { target.setFromHex("0102zz04"); });
+	assert(compareArray(target, [1, 2, 0]), "bytes before the error are written");
+	assert.throws(TypeError, function() { Uint8Array.prototype.setFromHex.call(new Int8Array(2), "00"); });
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestFromBase64MatchesEncoding(t *testing.T) {
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	for n := 0; n <= len(data); n++ {
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding} {
+			s := enc.EncodeToString(data[:n])
+			for _, maxLength := range []int{maxInt, n, n + 1, n / 2} {
+				read, b, err := fromBase64(s, enc == base64.URLEncoding, base64LastChunkLoose, maxLength)
+				if err != "" {
+					t.Fatalf("%q: %s", s, err)
+				}
+				expected := n
+				if maxLength < n {
+					// only whole chunks fit
+					expected = maxLength / 3 * 3
+				}
+				if string(b) != string(data[:expected]) {
+					t.Fatalf("%q (max %d): unexpected result %v", s, maxLength, b)
+				}
+				if len(b) == n && read != len(s) {
+					t.Fatalf("%q (max %d): unexpected read %d", s, maxLength, read)
+				}
+			}
+		}
+	}
+}
@@ -1,19 +1,26 @@
 package goja
 
+// weakMap is the identifier of a WeakMap or a WeakSet. The entries are stored in the keys (see Object.weakRefs),
+// so that they become unreachable together with the keys.
 type weakMap uint64
 
 type weakMapObject struct {
 	baseObject
-	m weakMap
+	m     weakMap
+	token *weakMapToken
 }
 
 func (wmo *weakMapObject) init() {
 	wmo.baseObject.init()
-	wmo.m = weakMap(wmo.val.runtime.genId())
+	wmo.m, wmo.token = wmo.val.runtime.newWeakMap()
 }
 
 func (wm weakMap) set(key *Object, value Value) {
-	key.getWeakRefs()[wm] = value
+	refs := key.getWeakRefs()
+	if _, exists := refs[wm]; !exists {
+		wm.track(key)
+	}
+	refs[wm] = value
 }
 
 func (wm weakMap) get(key *Object) Value {
@@ -23,6 +30,7 @@ func (wm weakMap) get(key *Object) Value {
 func (wm weakMap) remove(key *Object) bool {
 	if _, exists := key.weakRefs[wm]; exists {
 		delete(key.weakRefs, wm)
+		wm.untrack(key)
 		return true
 	}
 	return false
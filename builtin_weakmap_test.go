@@ -1,7 +1,9 @@
 package goja
 
 import (
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestWeakMap(t *testing.T) {
@@ -74,3 +76,89 @@ func TestWeakMapGetAdderGetIteratorOrder(t *testing.T) {
 	`
 	testScript(SCRIPT, valueTrue, t)
 }
+
+func TestWeakMapCollectedEntries(t *testing.T) {
+	if !weakMapCleanupSupported {
+		t.Skip("weak pointers are not supported")
+	}
+	vm := New()
+	key := vm.NewObject()
+	vm.Set("key", key)
+	_, err := vm.RunString(`
+	function fill() {
+		const m = new WeakMap();
+		m.set(key, new Array(1000));
+		const s = new WeakSet();
+		s.add(key);
+	}
+	for (let i = 0; i < 100; i++) {
+		fill();
+	}
+	(function() {
+		// overwrite the stale stack slots
+		let a = 0, b = 0, c = 0, d = 0;
+	})();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := len(key.weakRefs); l != 200 {
+		t.Fatalf("unexpected number of entries: %d", l)
+	}
+	live, err := vm.RunString(`
+	const live = new WeakMap();
+	live.set(key, 1);
+	live;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100 && len(key.weakRefs) > 1; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+		// a new map triggers the cleanup
+		if _, err := vm.RunString("new WeakSet()"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if l := len(key.weakRefs); l != 1 {
+		t.Fatalf("entries of unreachable maps have not been removed: %d", l)
+	}
+	res, err := vm.RunString("live.get(key)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.SameAs(valueInt(1)) {
+		t.Fatalf("unexpected value: %v", res)
+	}
+	runtime.KeepAlive(live)
+}
+
+func TestWeakMapKeysPruned(t *testing.T) {
+	if !weakMapCleanupSupported {
+		t.Skip("weak pointers are not supported")
+	}
+	vm := New()
+	m, err := vm.RunString(`
+	const m = new WeakMap();
+	for (let i = 0; i < 1000; i++) {
+		m.set({}, i);
+	}
+	m;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime.GC()
+	_, err = vm.RunString(`
+	for (let i = 0; i < 1000; i++) {
+		m.set({}, i);
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := len(vm.weakMaps.maps[m.(*Object).self.(*weakMapObject).m].keys); l > 1500 {
+		t.Fatalf("collected keys have not been pruned: %d", l)
+	}
+}
@@ -2,12 +2,13 @@ package goja
 
 type weakSetObject struct {
 	baseObject
-	s weakMap
+	s     weakMap
+	token *weakMapToken
 }
 
 func (ws *weakSetObject) init() {
 	ws.baseObject.init()
-	ws.s = weakMap(ws.val.runtime.genId())
+	ws.s, ws.token = ws.val.runtime.newWeakMap()
 }
 
 func (r *Runtime) weakSetProto_add(call FunctionCall) Value {
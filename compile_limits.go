@@ -0,0 +1,95 @@
+package goja
+
+import (
+	gocontext "context"
+	"errors"
+	"time"
+
+	"github.com/dop251/goja/parser"
+)
+
+// CompileLimits restricts the resources that can be spent by CompileContext(). Zero values mean no limit.
+type CompileLimits struct {
+	// MaxSourceBytes is the maximum length of the source in bytes.
+	MaxSourceBytes int
+	// MaxFunctions is the maximum number of functions in the source, including arrow functions, methods,
+	// accessors and class static blocks.
+	MaxFunctions int
+	// MaxTime is the maximum duration of parsing and compiling combined.
+	MaxTime time.Duration
+}
+
+var (
+	// ErrSourceTooLarge means that the source is longer than CompileLimits.MaxSourceBytes.
+	ErrSourceTooLarge = errors.New("source is too large")
+	// ErrTooManyFunctions means that the source contains more than CompileLimits.MaxFunctions functions.
+	ErrTooManyFunctions = parser.ErrTooManyFunctions
+)
+
+// CompileLimitError is returned by CompileContext() (as well as by Compile() and CompileAST() with WithContext())
+// when the compilation has been aborted. Err is either ErrSourceTooLarge, ErrTooManyFunctions or the error of the
+// context (context.DeadlineExceeded if CompileLimits.MaxTime has elapsed), so it can be checked with errors.Is().
+type CompileLimitError struct {
+	Err error
+}
+
+func (e *CompileLimitError) Error() string {
+	return "compilation aborted: " + e.Err.Error()
+}
+
+func (e *CompileLimitError) Unwrap() error {
+	return e.Err
+}
+
+// CompileContext is like Compile but it aborts with a *CompileLimitError as soon as ctx is done or any of the
+// limits is exceeded. This makes it suitable for compiling untrusted code, e.g.:
+//
+//	prg, err := CompileContext(req.Context(), "upload.js", src, false, CompileLimits{
+//		MaxSourceBytes: 1 << 20,
+//		MaxFunctions:   10000,
+//		MaxTime:        time.Second,
+//	})
+//	var limitErr *CompileLimitError
+//	if errors.As(err, &limitErr) { /* ... */ }
+//
+// Syntax errors are returned in the same way as by Compile.
+func CompileContext(ctx gocontext.Context, name, src string, strict bool, limits CompileLimits, options ...CompilerOption) (*Program, error) {
+	if limits.MaxSourceBytes > 0 && len(src) > limits.MaxSourceBytes {
+		return nil, &CompileLimitError{Err: ErrSourceTooLarge}
+	}
+	if limits.MaxTime > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = gocontext.WithTimeout(ctx, limits.MaxTime)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, &CompileLimitError{Err: err}
+	}
+	prg, err := parser.ParseFile(nil, name, src, 0, parser.WithContext(ctx), parser.WithMaxFunctions(limits.MaxFunctions))
+	if err != nil {
+		if _, ok := err.(parser.ErrorList); ok {
+			return nil, &CompilerSyntaxError{
+				CompilerError: CompilerError{
+					Message: err.Error(),
+				},
+			}
+		}
+		return nil, &CompileLimitError{Err: err}
+	}
+	return compileAST(prg, strict, true, nil, append(options, WithContext(ctx))...)
+}
+
+// checkAbort is called for every compiled statement and expression. It panics with a *CompileLimitError
+// if the context passed to WithContext() is done. The context is only checked every so often because
+// it involves locking.
+func (c *compiler) checkAbort() {
+	if c.opts.ctx == nil {
+		return
+	}
+	c.abortCheckCount++
+	if c.abortCheckCount&1023 == 0 {
+		if err := c.opts.ctx.Err(); err != nil {
+			panic(&CompileLimitError{Err: err})
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package goja
+
+import (
+	gocontext "context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja/parser"
+)
+
+// countdownContext is a context which becomes done after Err() has been called a certain number of times.
+type countdownContext struct {
+	gocontext.Context
+	calls, failAfter int
+}
+
+func (c *countdownContext) Err() error {
+	c.calls++
+	if c.failAfter > 0 && c.calls > c.failAfter {
+		return gocontext.Canceled
+	}
+	return nil
+}
+
+func TestCompileContext(t *testing.T) {
+	const SRC = `
+	function f() { return [1, 2].map((x) => x * 2); }
+	var o = { get x() { return 1; }, m() {} };
+	class C { static {} }
+	f()[1] + o.x;
+	`
+	prg, err := CompileContext(gocontext.Background(), "test.js", SRC, false, CompileLimits{
+		MaxSourceBytes: len(SRC),
+		MaxFunctions:   5,
+		MaxTime:        time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := New().RunProgram(prg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 5 {
+		t.Fatal(v)
+	}
+
+	_, err = CompileContext(gocontext.Background(), "test.js", SRC, false, CompileLimits{MaxSourceBytes: len(SRC) - 1})
+	var limitErr *CompileLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrSourceTooLarge) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = CompileContext(gocontext.Background(), "test.js", SRC, false, CompileLimits{MaxFunctions: 4})
+	if !errors.Is(err, ErrTooManyFunctions) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The parser backtracks when it encounters arrow function parameters, this must not count twice.
+	_, err = CompileContext(gocontext.Background(), "test.js", "var f = (a, b = () => 1) => a + b();", false, CompileLimits{MaxFunctions: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = CompileContext(gocontext.Background(), "test.js", "var;", false, CompileLimits{})
+	if _, ok := err.(*CompilerSyntaxError); !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	_, err = CompileContext(ctx, "test.js", SRC, false, CompileLimits{})
+	if !errors.As(err, &limitErr) || !errors.Is(err, gocontext.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCompileContextAbort(t *testing.T) {
+	src := strings.Repeat("x = [a + 1, b * 2, c];\n", 10000)
+	parseCtx := &countdownContext{Context: gocontext.Background()}
+	if _, err := parser.ParseFile(nil, "test.js", src, 0, parser.WithContext(parseCtx)); err != nil {
+		t.Fatal(err)
+	}
+	if parseCtx.calls == 0 {
+		t.Fatal("the parser did not check the context")
+	}
+
+	for _, test := range []struct {
+		name      string
+		failAfter int
+	}{
+		{"parser", 2},
+		{"compiler", parseCtx.calls + 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := &countdownContext{Context: gocontext.Background(), failAfter: test.failAfter}
+			_, err := CompileContext(ctx, "test.js", src, false, CompileLimits{})
+			var limitErr *CompileLimitError
+			if !errors.As(err, &limitErr) || limitErr.Err != gocontext.Canceled {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ctx.calls != test.failAfter+1 {
+				t.Fatalf("compilation continued after the context was done (%d calls)", ctx.calls)
+			}
+		})
+	}
+
+	_, err := CompileContext(gocontext.Background(), "test.js", src, false, CompileLimits{MaxTime: time.Nanosecond})
+	if !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCompileWithContextLargeFunction(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("function generated(a, b) {\n")
+	for i := 0; i < 50000; i++ {
+		b.WriteString("a = (a * 31 + b) % 1000003;\n")
+	}
+	b.WriteString("return a;\n}")
+	prg, err := Parse("generated.js", b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &countdownContext{Context: gocontext.Background(), failAfter: 10}
+	_, err = CompileAST(prg, false, WithContext(ctx))
+	if !errors.Is(err, gocontext.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ctx.calls != 11 {
+		t.Fatalf("compilation continued after the context was done (%d calls)", ctx.calls)
+	}
+
+	if _, err := CompileAST(prg, false, WithContext(gocontext.Background())); err != nil {
+		t.Fatal(err)
+	}
+}
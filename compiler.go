@@ -1,6 +1,7 @@
 package goja
 
 import (
+	gocontext "context"
 	"fmt"
 	"sort"
 
@@ -64,6 +65,11 @@ type srcMapItem struct {
 	srcPos int
 }
 
+type operandNameItem struct {
+	pc   int
+	name string
+}
+
 type Program struct {
 	code   []instruction
 	values []Value
@@ -71,6 +77,8 @@ type Program struct {
 	funcName unistring.String
 	src      *file.File
 	srcMap   []srcMapItem
+
+	operandNames []operandNameItem
 }
 
 type compiler struct {
@@ -86,6 +94,70 @@ type compiler struct {
 	ctxVM  *vm // VM in which an eval() code is compiled
 
 	codeScratchpad []instruction
+
+	opts compilerOptions
+
+	// function declarations nested in blocks that also have a var binding in the enclosing
+	// function or script (see Annex B.3.3). A nil binding means a global var, or a var of the
+	// calling function if annexBFuncVars is set.
+	annexBFuncs map[*ast.FunctionDeclaration]*binding
+	// set when compiling direct eval() code inside a function, see createAnnexBBindings()
+	annexBFuncVars bool
+
+	abortCheckCount int
+}
+
+// CompilerOption is an option that can be passed to Compile() and CompileAST().
+type CompilerOption func(*compilerOptions)
+
+type compilerOptions struct {
+	strictBlockFunctions bool
+	debugScopes          bool
+	intrinsics           map[unistring.String]Intrinsic
+	ctx                  gocontext.Context
+}
+
+// WithStrictBlockFunctions turns off the web compatibility semantics for function declarations
+// inside blocks in non-strict code (ECMAScript Annex B.3.3). By default such a declaration, in
+// addition to the block-scoped binding, assigns the function to a var binding of the same name in
+// the enclosing function or script when the declaration is evaluated, unless that would conflict
+// with a lexical declaration. With this option the functions are only visible inside the block,
+// exactly as in strict mode code. The code compiled at run time by eval() and the Function
+// constructor follows the setting of the Runtime instead, see Runtime.SetStrictBlockFunctions().
+func WithStrictBlockFunctions(opts *compilerOptions) {
+	opts.strictBlockFunctions = true
+}
+
+// WithIntrinsic makes the compiler translate direct calls of the global function with the specified name
+// (i.e. name(...)) into a single VM instruction which calls f, see Intrinsic. Calls where the name refers to
+// a local binding or may refer to a property of a with statement object are compiled as usual. Note that
+// the intrinsic is used regardless of the actual value of the global property at run time, and that
+// redeclaring the name using a direct eval() call is not detected. To make the function available in
+// other contexts (e.g. 'typeof name' or passing it as a value) it has to be set as a global as well.
+func WithIntrinsic(name string, f Intrinsic) CompilerOption {
+	return func(opts *compilerOptions) {
+		if opts.intrinsics == nil {
+			opts.intrinsics = make(map[unistring.String]Intrinsic)
+		}
+		opts.intrinsics[unistring.NewFromString(name)] = f
+	}
+}
+
+// WithDebugScopes makes the compiler keep the variables of all functions and blocks in named scopes, as it does
+// for the ones containing a direct eval() call, so that code can be evaluated in the scope of any active frame
+// using Frame.Eval(). This makes the variable access slower, so it should only be used for debugging.
+func WithDebugScopes(opts *compilerOptions) {
+	opts.debugScopes = true
+}
+
+// WithContext makes the compiler check ctx periodically (every so many compiled statements and expressions) and
+// abort with a *CompileLimitError as soon as it's done. This allows cancelling the compilation of very large
+// functions, which otherwise blocks the goroutine until it's finished. Note that this only covers the
+// compilation itself; use CompileContext() to make the parsing abort as well.
+func WithContext(ctx gocontext.Context) CompilerOption {
+	return func(opts *compilerOptions) {
+		opts.ctx = ctx
+	}
 }
 
 type binding struct {
@@ -134,6 +206,7 @@ func (b *binding) emitGet() {
 	if b.isVar && !b.isArg {
 		b.scope.c.emit(loadStack(0))
 	} else {
+		b.scope.c.p.addOperandName(b.name.String())
 		b.scope.c.emit(loadStackLex(0))
 	}
 }
@@ -143,6 +216,7 @@ func (b *binding) emitGetAt(pos int) {
 	if b.isVar && !b.isArg {
 		b.scope.c.p.code[pos] = loadStack(0)
 	} else {
+		b.scope.c.p.setOperandName(pos, b.name.String())
 		b.scope.c.p.code[pos] = loadStackLex(0)
 	}
 }
@@ -153,6 +227,7 @@ func (b *binding) emitGetP() {
 	} else {
 		// make sure TDZ is checked
 		b.markAccessPoint()
+		b.scope.c.p.addOperandName(b.name.String())
 		b.scope.c.emit(loadStackLex(0), pop)
 	}
 }
@@ -168,6 +243,7 @@ func (b *binding) emitSet() {
 	if b.isVar && !b.isArg {
 		b.scope.c.emit(storeStack(0))
 	} else {
+		b.scope.c.p.addOperandName(b.name.String())
 		b.scope.c.emit(storeStackLex(0))
 	}
 }
@@ -183,6 +259,7 @@ func (b *binding) emitSetP() {
 	if b.isVar && !b.isArg {
 		b.scope.c.emit(storeStackP(0))
 	} else {
+		b.scope.c.p.addOperandName(b.name.String())
 		b.scope.c.emit(storeStackLexP(0))
 	}
 }
@@ -363,10 +440,11 @@ func (c *compiler) newScope() {
 		strict = c.scope.strict
 	}
 	c.scope = &scope{
-		c:      c,
-		prg:    c.p,
-		outer:  c.scope,
-		strict: strict,
+		c:         c,
+		prg:       c.p,
+		outer:     c.scope,
+		strict:    strict,
+		dynLookup: c.opts.debugScopes,
 	}
 }
 
@@ -382,11 +460,15 @@ func (c *compiler) popScope() {
 	c.scope = c.scope.outer
 }
 
-func newCompiler() *compiler {
+func newCompiler(options ...CompilerOption) *compiler {
 	c := &compiler{
 		p: &Program{},
 	}
 
+	for _, opt := range options {
+		opt(&c.opts)
+	}
+
 	c.enumGetExpr.init(c, file.Idx(0))
 
 	return c
@@ -460,6 +542,39 @@ func (p *Program) addSrcMap(srcPos int) {
 	p.srcMap = append(p.srcMap, srcMapItem{pc: len(p.code), srcPos: srcPos})
 }
 
+// setOperandName records the description of the operand of the instruction at pc, i.e. the callee of a call,
+// the name of a lexical binding or the source of a destructuring assignment. It's only used in the error messages.
+func (p *Program) setOperandName(pc int, name string) {
+	if name == "" {
+		return
+	}
+	i := sort.Search(len(p.operandNames), func(idx int) bool {
+		return p.operandNames[idx].pc >= pc
+	})
+	if i < len(p.operandNames) && p.operandNames[i].pc == pc {
+		p.operandNames[i].name = name
+		return
+	}
+	p.operandNames = append(p.operandNames, operandNameItem{})
+	copy(p.operandNames[i+1:], p.operandNames[i:])
+	p.operandNames[i] = operandNameItem{pc: pc, name: name}
+}
+
+// addOperandName records the description of the operand of the instruction that is about to be emitted.
+func (p *Program) addOperandName(name string) {
+	p.setOperandName(len(p.code), name)
+}
+
+func (p *Program) operandName(pc int) string {
+	i := sort.Search(len(p.operandNames), func(idx int) bool {
+		return p.operandNames[idx].pc >= pc
+	})
+	if i < len(p.operandNames) && p.operandNames[i].pc == pc {
+		return p.operandNames[i].name
+	}
+	return ""
+}
+
 func (s *scope) lookupName(name unistring.String) (binding *binding, noDynamics bool) {
 	noDynamics = true
 	toStash := false
@@ -485,6 +600,9 @@ func (s *scope) lookupName(name unistring.String) (binding *binding, noDynamics
 			}
 			curScope.argsNeeded = true
 			binding, _ = curScope.bindName(name)
+			if toStash && !binding.inStash {
+				binding.moveToStash()
+			}
 			return
 		}
 		if curScope.isFunction() {
@@ -829,6 +947,10 @@ func (s *scope) trimCode(delta int) {
 		for i := range srcMap {
 			srcMap[i].pc -= delta
 		}
+		operandNames := s.c.p.operandNames
+		for i := range operandNames {
+			operandNames[i].pc -= delta
+		}
 		s.adjustBase(-delta)
 	}
 }
@@ -915,6 +1037,7 @@ func (c *compiler) compile(in *ast.Program, strict, inGlobal bool, evalVm *vm) {
 			}
 		}
 	}
+	c.checkNoUsingDeclarations(in.Body)
 	funcs := c.extractFunctions(in.Body)
 	c.createFunctionBindings(funcs)
 	numFuncs := len(scope.bindings)
@@ -926,12 +1049,16 @@ func (c *compiler) compile(in *ast.Program, strict, inGlobal bool, evalVm *vm) {
 		}
 	}
 	c.compileDeclList(in.DeclarationList, false)
+	var annexBVars []unistring.String
+	if !ownVarScope {
+		annexBVars = c.createAnnexBBindings(in.Body, nil, inGlobal)
+	}
 	numVars := len(scope.bindings) - numFuncs
 	vars := make([]unistring.String, len(scope.bindings))
 	for i, b := range scope.bindings {
 		vars[i] = b.name
 	}
-	if len(vars) > 0 && !ownVarScope && ownLexScope {
+	if (len(vars) > 0 || len(annexBVars) > 0) && !ownVarScope && ownLexScope {
 		if inGlobal {
 			c.emit(&bindGlobal{
 				vars:      vars[numFuncs:],
@@ -939,7 +1066,7 @@ func (c *compiler) compile(in *ast.Program, strict, inGlobal bool, evalVm *vm) {
 				deletable: eval,
 			})
 		} else {
-			c.emit(&bindVars{names: vars, deletable: eval})
+			c.emit(&bindVars{names: vars, annexB: annexBVars, deletable: eval})
 		}
 	}
 	var enter *enterBlock
@@ -1047,6 +1174,217 @@ func (c *compiler) createFunctionBindings(funcs []*ast.FunctionDeclaration) {
 	}
 }
 
+// lookupIntrinsic returns the intrinsic registered for name if a reference to it from the current scope
+// resolves to the global binding. The intrinsics implementing using declarations are always returned as their
+// names can't be bound.
+func (c *compiler) lookupIntrinsic(name unistring.String) Intrinsic {
+	if f := usingIntrinsics[name]; f != nil {
+		return f
+	}
+	f := c.opts.intrinsics[name]
+	if f == nil {
+		return nil
+	}
+	for s := c.scope; s != nil; s = s.outer {
+		if _, exists := s.boundNames[name]; exists {
+			return nil
+		}
+		if s.outer == nil {
+			if s.eval {
+				return nil
+			}
+			break
+		}
+		if s.dynamic {
+			return nil
+		}
+	}
+	return f
+}
+
+// createAnnexBBindings creates var bindings in the current scope for the function declarations
+// nested in blocks of body which are subject to Annex B.3.3, i.e. those for which replacing the
+// declaration with a var statement would not cause an early error. Parameter names (which are
+// bound in paramScope) are skipped.
+// For direct eval() code inside a function (i.e. the top scope which is not global) the vars belong
+// to the calling function and whether they can be created depends on its lexical declarations
+// (Annex B.3.3.3), so no bindings are created and the names are returned to be bound at run time.
+func (c *compiler) createAnnexBBindings(body []ast.Statement, paramScope *scope, global bool) (funcVars []unistring.String) {
+	if c.scope.strict || c.opts.strictBlockFunctions {
+		return
+	}
+	inFuncEval := c.scope.outer == nil && !global
+	for _, decl := range c.collectAnnexBFunctions(body) {
+		name := decl.Function.Name.Name
+		if paramScope != nil {
+			if name == "arguments" {
+				continue
+			}
+			// the only const binding there can be is the name of a function expression
+			if b := paramScope.boundNames[name]; b != nil && !b.isConst {
+				continue
+			}
+		}
+		if c.annexBFuncs == nil {
+			c.annexBFuncs = make(map[*ast.FunctionDeclaration]*binding)
+		}
+		if inFuncEval {
+			c.annexBFuncs[decl] = nil
+			c.annexBFuncVars = true
+			funcVars = append(funcVars, name)
+			continue
+		}
+		b, _ := c.scope.bindName(name)
+		if global {
+			b = nil
+		}
+		c.annexBFuncs[decl] = b
+	}
+	return
+}
+
+func (c *compiler) collectAnnexBFunctions(body []ast.Statement) (funcs []*ast.FunctionDeclaration) {
+	var lexNames []unistring.String
+	declared := func(names []unistring.String, name unistring.String) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	addNames := func(target ast.Expression) {
+		c.createBindings(target, func(name unistring.String, offset int) {
+			lexNames = append(lexNames, name)
+		})
+	}
+	addLexicalNames := func(list []ast.Statement, withFunctions bool) {
+		for _, st := range list {
+			switch st := st.(type) {
+			case *ast.LexicalDeclaration:
+				for _, d := range st.List {
+					addNames(d.Target)
+				}
+			case *ast.ClassDeclaration:
+				lexNames = append(lexNames, st.Class.Name.Name)
+			}
+		}
+		if withFunctions {
+			for _, decl := range c.extractFunctions(list) {
+				lexNames = append(lexNames, decl.Function.Name.Name)
+			}
+		}
+	}
+	var walk func(st ast.Statement)
+	walkBlock := func(list []ast.Statement) {
+		mark := len(lexNames)
+		addLexicalNames(list, true)
+		for _, st := range list {
+			if decl, ok := st.(*ast.FunctionDeclaration); ok {
+				if !decl.Function.Async && !decl.Function.Generator && !declared(lexNames[:mark], decl.Function.Name.Name) {
+					funcs = append(funcs, decl)
+				}
+				continue
+			}
+			walk(st)
+		}
+		lexNames = lexNames[:mark]
+	}
+	walk = func(st ast.Statement) {
+		switch st := st.(type) {
+		case *ast.BlockStatement:
+			walkBlock(st.List)
+		case *ast.IfStatement:
+			for _, s := range []ast.Statement{st.Consequent, st.Alternate} {
+				if decl, ok := s.(*ast.FunctionDeclaration); ok {
+					walkBlock([]ast.Statement{decl})
+				} else if s != nil {
+					walk(s)
+				}
+			}
+		case *ast.ForStatement:
+			mark := len(lexNames)
+			if init, ok := st.Initializer.(*ast.ForLoopInitializerLexicalDecl); ok {
+				for _, d := range init.LexicalDeclaration.List {
+					addNames(d.Target)
+				}
+			}
+			walk(st.Body)
+			lexNames = lexNames[:mark]
+		case *ast.ForInStatement:
+			mark := len(lexNames)
+			if into, ok := st.Into.(*ast.ForDeclaration); ok {
+				addNames(into.Target)
+			}
+			walk(st.Body)
+			lexNames = lexNames[:mark]
+		case *ast.ForOfStatement:
+			mark := len(lexNames)
+			if into, ok := st.Into.(*ast.ForDeclaration); ok {
+				addNames(into.Target)
+			}
+			walk(st.Body)
+			lexNames = lexNames[:mark]
+		case *ast.WhileStatement:
+			walk(st.Body)
+		case *ast.DoWhileStatement:
+			walk(st.Body)
+		case *ast.LabelledStatement:
+			walk(st.Statement)
+		case *ast.WithStatement:
+			walk(st.Body)
+		case *ast.TryStatement:
+			walkBlock(st.Body.List)
+			if st.Catch != nil {
+				mark := len(lexNames)
+				// a simple catch parameter does not prevent the hoisting (see B.3.4)
+				if _, ok := st.Catch.Parameter.(*ast.Identifier); !ok && st.Catch.Parameter != nil {
+					addNames(st.Catch.Parameter)
+				}
+				walkBlock(st.Catch.Body.List)
+				lexNames = lexNames[:mark]
+			}
+			if st.Finally != nil {
+				walkBlock(st.Finally.List)
+			}
+		case *ast.SwitchStatement:
+			var list []ast.Statement
+			for _, s := range st.Body {
+				list = append(list, s.Consequent...)
+			}
+			walkBlock(list)
+		}
+	}
+	addLexicalNames(body, false)
+	for _, st := range body {
+		walk(st)
+	}
+	return
+}
+
+// emitAnnexBFunctionCopy assigns the value of the block-scoped binding of the function to the
+// corresponding var binding created by createAnnexBBindings(), if there is one.
+func (c *compiler) emitAnnexBFunctionCopy(decl *ast.FunctionDeclaration) {
+	vb, exists := c.annexBFuncs[decl]
+	if !exists {
+		return
+	}
+	name := decl.Function.Name.Name
+	b, _ := c.scope.lookupName(name)
+	if b == nil || b == vb {
+		return
+	}
+	b.emitGet()
+	switch {
+	case vb != nil:
+		vb.emitSetP()
+	case c.annexBFuncVars:
+		c.emit(setFuncVarP(name))
+	default:
+		c.emit(setGlobal(name), pop)
+	}
+}
+
 func (c *compiler) compileFunctions(list []*ast.FunctionDeclaration) {
 	for _, decl := range list {
 		c.compileFunction(decl)
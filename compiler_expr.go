@@ -1,6 +1,8 @@
 package goja
 
 import (
+	"math/big"
+
 	"github.com/dop251/goja/ast"
 	"github.com/dop251/goja/file"
 	"github.com/dop251/goja/token"
@@ -33,6 +35,12 @@ type compiledNewExpr struct {
 	compiledCallExpr
 }
 
+type compiledCustomInstructionExpr struct {
+	baseCompiledExpr
+	instr CustomInstruction
+	args  []compiledExpr
+}
+
 type compiledObjectLiteral struct {
 	expr *ast.ObjectLiteral
 	baseCompiledExpr
@@ -215,6 +223,14 @@ type compiledBinaryExpr struct {
 	operator token.Token
 }
 
+// compiledTypeofTest is a comparison of a typeof result with a string literal, e.g. typeof x === "string".
+type compiledTypeofTest struct {
+	operand compiledExpr
+	baseCompiledExpr
+	tag typeofTag
+	not bool
+}
+
 type compiledEnumGetExpr struct {
 	baseCompiledExpr
 }
@@ -247,6 +263,7 @@ func (e *defaultDeleteExpr) emitGetter(putOnStack bool) {
 }
 
 func (c *compiler) compileExpression(v ast.Expression) compiledExpr {
+	c.checkAbort()
 	// log.Printf("compileExpression: %T", v)
 	switch v := v.(type) {
 	case nil:
@@ -330,6 +347,8 @@ func (c *compiler) compileExpression(v ast.Expression) compiledExpr {
 		}
 		r.init(c, v.Await)
 		return r
+	case *ast.CustomInstructionExpression:
+		return c.compileCustomInstructionExpression(v)
 	case *ast.YieldExpression:
 		r := &compiledYieldExpression{
 			arg:      c.compileExpression(v.Argument),
@@ -1336,6 +1355,7 @@ func (e *compiledTemplateLiteral) emitGetter(putOnStack bool) {
 		for _, expr := range e.expressions {
 			expr.emitGetter(true)
 		}
+		e.c.p.addOperandName(describeCallee(e.tag))
 		e.c.emit(call(len(e.expressions) + 1))
 	}
 	if !putOnStack {
@@ -1478,7 +1498,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 	paramsCount := len(e.parameterList.List)
 
 	s.numArgs = paramsCount
-	body := e.body
+	body := desugarUsing(e.body)
 	funcs := e.c.extractFunctions(body)
 	var calleeBinding *binding
 
@@ -1505,7 +1525,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 				}, item.Initializer, item.Target.Idx0()).emitGetter(true)
 				e.c.emitPattern(pattern, func(target, init compiledExpr) {
 					e.c.emitPatternLexicalAssign(target, init)
-				}, false)
+				}, "", false)
 			} else if item.Initializer != nil {
 				markGet := len(e.c.p.code)
 				e.c.emit(nil)
@@ -1517,11 +1537,15 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 				}
 				if firstForwardRef == -1 {
 					s.bindings[i].emitGetAt(markGet)
+					s.bindings[i].emitInitP()
+					e.c.p.code[mark] = jdefP(len(e.c.p.code) - mark)
 				} else {
+					// The binding is in the stash and has not been initialised yet, so the argument
+					// value must be stored there too.
 					e.c.p.code[markGet] = loadStackLex(-i - 1)
+					e.c.p.code[mark] = jdef(len(e.c.p.code) - mark)
+					s.bindings[i].emitInitP()
 				}
-				s.bindings[i].emitInitP()
-				e.c.p.code[mark] = jdefP(len(e.c.p.code) - mark)
 			} else {
 				if firstForwardRef == -1 && s.bindings[i].useCount() > 0 {
 					firstForwardRef = i
@@ -1556,8 +1580,17 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 		enterFunc2Mark = len(e.c.p.code)
 		e.c.emit(nil)
 		e.c.compileDeclList(e.declarationList, false)
+		e.c.createAnnexBBindings(body, s, false)
 		e.c.createFunctionBindings(funcs)
 		e.c.compileLexicalDeclarationsFuncBody(body, calleeBinding)
+		if e.typ != funcArrow && e.typ != funcClsInit {
+			// With parameter expressions the arguments object is created even if the body declares a var
+			// named 'arguments', which is then initialised with it.
+			if b := varScope.boundNames["arguments"]; b != nil && b.isVar && s.boundNames["arguments"] == nil {
+				s.argsNeeded = true
+				s.bindName("arguments")
+			}
+		}
 		for _, b := range varScope.bindings {
 			if b.isVar {
 				if parentBinding := s.boundNames[b.name]; parentBinding != nil && parentBinding != calleeBinding {
@@ -1573,6 +1606,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 			b.isVar = true
 		}
 		e.c.compileDeclList(e.declarationList, true)
+		e.c.createAnnexBBindings(body, s, false)
 		e.c.createFunctionBindings(funcs)
 		e.c.compileLexicalDeclarations(body, true)
 		if e.isExpr && e.name != nil {
@@ -2368,6 +2402,7 @@ func (e *compiledNewExpr) emitGetter(putOnStack bool) {
 		expr.emitGetter(true)
 	}
 	e.addSrcMap()
+	e.c.p.addOperandName(describeCallee(e.callee))
 	if e.isVariadic {
 		e.c.emit(newVariadic, endVariadic)
 	} else {
@@ -2453,7 +2488,7 @@ func (c *compiler) emitThrow(v Value) {
 	if o, ok := v.(*Object); ok {
 		t := nilSafe(o.self.getStr("name", nil)).toString().String()
 		switch t {
-		case "TypeError":
+		case "TypeError", "RangeError":
 			c.emit(loadDynamic(t))
 			msg := o.self.getStr("message", nil)
 			if msg != nil {
@@ -2820,6 +2855,13 @@ func (c *compiler) compileBinaryExpression(v *ast.BinaryExpression) compiledExpr
 		return c.compilePrivateIn(id, v.Right, id.Idx)
 	}
 
+	switch v.Operator {
+	case token.EQUAL, token.NOT_EQUAL, token.STRICT_EQUAL, token.STRICT_NOT_EQUAL:
+		if r := c.compileTypeofTest(v); r != nil {
+			return r
+		}
+	}
+
 	r := &compiledBinaryExpr{
 		left:     c.compileExpression(v.Left),
 		right:    c.compileExpression(v.Right),
@@ -2829,6 +2871,47 @@ func (c *compiler) compileBinaryExpression(v *ast.BinaryExpression) compiledExpr
 	return r
 }
 
+// compileTypeofTest recognises the comparisons of a typeof result with a string literal (in either order) and
+// compiles them into a single type test which does not need to materialise and compare the strings.
+// Returns nil if the expression does not match. The loose and the strict equality are the same in this case.
+func (c *compiler) compileTypeofTest(v *ast.BinaryExpression) compiledExpr {
+	unary, ok := v.Left.(*ast.UnaryExpression)
+	lit, ok1 := v.Right.(*ast.StringLiteral)
+	if !ok || !ok1 {
+		unary, ok = v.Right.(*ast.UnaryExpression)
+		lit, ok1 = v.Left.(*ast.StringLiteral)
+		if !ok || !ok1 {
+			return nil
+		}
+	}
+	if unary.Operator != token.TYPEOF {
+		return nil
+	}
+	r := &compiledTypeofTest{
+		operand: c.compileExpression(unary.Operand),
+		tag:     typeofTagFromString(lit.Value),
+		not:     v.Operator == token.NOT_EQUAL || v.Operator == token.STRICT_NOT_EQUAL,
+	}
+	r.init(c, v.Idx0())
+	return r
+}
+
+func (e *compiledTypeofTest) constant() bool {
+	return e.operand.constant()
+}
+
+func (e *compiledTypeofTest) emitGetter(putOnStack bool) {
+	if o, ok := e.operand.(compiledExprOrRef); ok {
+		o.emitGetterOrRef()
+	} else {
+		e.operand.emitGetter(true)
+	}
+	e.c.emit(typeofIs{tag: e.tag, not: e.not})
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
 type compiledPrivateIn struct {
 	right compiledExpr
 	baseCompiledExpr
@@ -3049,6 +3132,58 @@ func (c *compiler) compileRegexpLiteral(v *ast.RegExpLiteral) compiledExpr {
 	return r
 }
 
+// describeCallee returns the description of a callee expression for the error messages, e.g. "o.f" or
+// "f(...)", in the same format as V8.
+func describeCallee(e compiledExpr) string {
+	switch e := e.(type) {
+	case *compiledIdentifierExpr:
+		return e.name.String()
+	case *compiledThisExpr:
+		return "this"
+	case *compiledLiteral:
+		if s, ok := e.val.(valueString); ok {
+			return "\"" + s.String() + "\""
+		}
+		return e.val.String()
+	case *compiledDotExpr:
+		return describeMemberBase(e.left) + "." + e.name.String()
+	case *compiledPrivateDotExpr:
+		return describeMemberBase(e.left) + ".#" + e.name.String()
+	case *compiledSuperDotExpr:
+		return "(intermediate value)." + e.name.String()
+	case *compiledBracketExpr:
+		if opt, ok := e.left.(*compiledOptional); ok {
+			return describeCallee(opt.expr) + "?.[" + describeCallee(e.member) + "]"
+		}
+		return describeCallee(e.left) + "[" + describeCallee(e.member) + "]"
+	case *compiledCallExpr:
+		return describeMemberBase(e.callee) + "(...)"
+	case *compiledOptionalChain:
+		return describeCallee(e.expr)
+	case *compiledOptional:
+		return describeCallee(e.expr)
+	}
+	return "(intermediate value)"
+}
+
+// describeSource returns the description of the source of a destructuring assignment for the error messages, or
+// "" if it's not a reference or a call and the value itself should be described instead.
+func describeSource(e compiledExpr) string {
+	switch e.(type) {
+	case *compiledIdentifierExpr, *compiledThisExpr, *compiledDotExpr, *compiledPrivateDotExpr, *compiledBracketExpr,
+		*compiledCallExpr, *compiledOptionalChain:
+		return describeCallee(e)
+	}
+	return ""
+}
+
+func describeMemberBase(e compiledExpr) string {
+	if opt, ok := e.(*compiledOptional); ok {
+		return describeCallee(opt.expr) + "?"
+	}
+	return describeCallee(e)
+}
+
 func (c *compiler) emitCallee(callee compiledExpr) (calleeName unistring.String) {
 	switch callee := callee.(type) {
 	case *compiledDotExpr:
@@ -3106,6 +3241,7 @@ func (e *compiledCallExpr) emitGetter(putOnStack bool) {
 	}
 
 	e.addSrcMap()
+	e.c.p.addOperandName(describeCallee(e.callee))
 	if _, ok := e.callee.(*compiledSuperExpr); ok {
 		b, eval := e.c.scope.lookupThis()
 		e.c.assert(eval || b != nil, e.offset, "super call, but no 'this' binding")
@@ -3202,6 +3338,17 @@ func (c *compiler) compileCallExpression(v *ast.CallExpression) compiledExpr {
 		}
 	}
 
+	if id, ok := v.Callee.(*ast.Identifier); ok && !isVariadic {
+		if f := c.lookupIntrinsic(id.Name); f != nil {
+			r := &compiledCustomInstructionExpr{
+				instr: f,
+				args:  args,
+			}
+			r.init(c, v.LeftParenthesis)
+			return r
+		}
+	}
+
 	r := &compiledCallExpr{
 		args:       args,
 		callee:     c.compileCallee(v.Callee),
@@ -3235,6 +3382,8 @@ func (c *compiler) compileNumberLiteral(v *ast.NumberLiteral) compiledExpr {
 		val = intToValue(num)
 	case float64:
 		val = floatToValue(num)
+	case *big.Int:
+		val = (*valueBigInt)(num)
 	default:
 		c.assert(false, int(v.Idx)-1, "Unsupported number literal type: %T", v.Value)
 		panic("unreachable")
@@ -3369,12 +3518,14 @@ func (e *compiledClassLiteral) emitNamed(name unistring.String) {
 	e.emitGetter(true)
 }
 
-func (c *compiler) emitPattern(pattern ast.Pattern, emitter func(target, init compiledExpr), putOnStack bool) {
+// emitPattern emits the destructuring of the value on the stack. srcName is the description of the expression
+// which has produced the value (see describeSource()), it's only used in the error messages.
+func (c *compiler) emitPattern(pattern ast.Pattern, emitter func(target, init compiledExpr), srcName string, putOnStack bool) {
 	switch pattern := pattern.(type) {
 	case *ast.ObjectPattern:
-		c.emitObjectPattern(pattern, emitter, putOnStack)
+		c.emitObjectPattern(pattern, emitter, srcName, putOnStack)
 	case *ast.ArrayPattern:
-		c.emitArrayPattern(pattern, emitter, putOnStack)
+		c.emitArrayPattern(pattern, emitter, srcName, putOnStack)
 	default:
 		c.assert(false, int(pattern.Idx0())-1, "unsupported Pattern: %T", pattern)
 		panic("unreachable")
@@ -3385,17 +3536,30 @@ func (c *compiler) emitAssign(target ast.Expression, init compiledExpr, emitAssi
 	pattern, isPattern := target.(ast.Pattern)
 	if isPattern {
 		init.emitGetter(true)
-		c.emitPattern(pattern, emitAssignSimple, false)
+		c.emitPattern(pattern, emitAssignSimple, "", false)
 	} else {
 		emitAssignSimple(c.compileExpression(target), init)
 	}
 }
 
-func (c *compiler) emitObjectPattern(pattern *ast.ObjectPattern, emitAssign func(target, init compiledExpr), putOnStack bool) {
+func (c *compiler) emitObjectPattern(pattern *ast.ObjectPattern, emitAssign func(target, init compiledExpr), srcName string, putOnStack bool) {
+	desc := destructSrcDesc{name: srcName}
+	if len(pattern.Properties) > 0 {
+		switch prop := pattern.Properties[0].(type) {
+		case *ast.PropertyShort:
+			desc.prop = prop.Name.Name.String()
+		case *ast.PropertyKeyed:
+			if !prop.Computed {
+				if key, ok := c.compileExpression(prop.Key).(*compiledLiteral); ok {
+					desc.prop = key.val.String()
+				}
+			}
+		}
+	}
 	if pattern.Rest != nil {
-		c.emit(createDestructSrc)
+		c.emit(&createDestructSrc{desc})
 	} else {
-		c.emit(checkObjectCoercible)
+		c.emit(&checkObjectCoercible{desc})
 	}
 	for _, prop := range pattern.Properties {
 		switch prop := prop.(type) {
@@ -3434,8 +3598,8 @@ func (c *compiler) emitObjectPattern(pattern *ast.ObjectPattern, emitAssign func
 	}
 }
 
-func (c *compiler) emitArrayPattern(pattern *ast.ArrayPattern, emitAssign func(target, init compiledExpr), putOnStack bool) {
-	c.emit(iterate)
+func (c *compiler) emitArrayPattern(pattern *ast.ArrayPattern, emitAssign func(target, init compiledExpr), srcName string, putOnStack bool) {
+	c.emit(iterate(srcName))
 	for _, elt := range pattern.Elements {
 		switch elt := elt.(type) {
 		case nil:
@@ -3465,12 +3629,12 @@ func (c *compiler) emitArrayPattern(pattern *ast.ArrayPattern, emitAssign func(t
 
 func (e *compiledObjectAssignmentPattern) emitSetter(valueExpr compiledExpr, putOnStack bool) {
 	valueExpr.emitGetter(true)
-	e.c.emitObjectPattern(e.expr, e.c.emitPatternAssign, putOnStack)
+	e.c.emitObjectPattern(e.expr, e.c.emitPatternAssign, describeSource(valueExpr), putOnStack)
 }
 
 func (e *compiledArrayAssignmentPattern) emitSetter(valueExpr compiledExpr, putOnStack bool) {
 	valueExpr.emitGetter(true)
-	e.c.emitArrayPattern(e.expr, e.c.emitPatternAssign, putOnStack)
+	e.c.emitArrayPattern(e.expr, e.c.emitPatternAssign, describeSource(valueExpr), putOnStack)
 }
 
 type compiledPatternInitExpr struct {
@@ -3614,3 +3778,34 @@ func (e *compiledYieldExpression) emitGetter(putOnStack bool) {
 		}
 	}
 }
+
+func (c *compiler) compileCustomInstructionExpression(v *ast.CustomInstructionExpression) compiledExpr {
+	instr, ok := v.Instruction.(CustomInstruction)
+	if !ok {
+		c.throwSyntaxError(int(v.Idx0())-1, "Invalid custom instruction: %T", v.Instruction)
+	}
+	args := make([]compiledExpr, len(v.ArgumentList))
+	for i, arg := range v.ArgumentList {
+		if _, ok := arg.(*ast.SpreadElement); ok {
+			c.throwSyntaxError(int(arg.Idx0())-1, "Spread is not allowed in custom instruction arguments")
+		}
+		args[i] = c.compileExpression(arg)
+	}
+	r := &compiledCustomInstructionExpr{
+		instr: instr,
+		args:  args,
+	}
+	r.init(c, v.Idx0())
+	return r
+}
+
+func (e *compiledCustomInstructionExpr) emitGetter(putOnStack bool) {
+	for _, expr := range e.args {
+		expr.emitGetter(true)
+	}
+	e.addSrcMap()
+	e.c.emit(&customInstr{instr: e.instr, nargs: len(e.args)})
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
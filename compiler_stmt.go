@@ -8,6 +8,7 @@ import (
 )
 
 func (c *compiler) compileStatement(v ast.Statement, needResult bool) {
+	c.checkAbort()
 
 	switch v := v.(type) {
 	case *ast.BlockStatement:
@@ -138,7 +139,7 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement, needResult bool) {
 				outer: c.block,
 			}
 			c.newBlockScope()
-			list := v.Catch.Body.List
+			list := desugarUsing(v.Catch.Body.List)
 			funcs := c.extractFunctions(list)
 			if _, ok := v.Catch.Parameter.(ast.Pattern); ok {
 				// add anonymous binding for the catch parameter, note it must be first
@@ -159,7 +160,7 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement, needResult bool) {
 				c.scope.bindings[0].emitGet()
 				c.emitPattern(pattern, func(target, init compiledExpr) {
 					c.emitPatternLexicalAssign(target, init)
-				}, false)
+				}, "", false)
 			}
 			for _, decl := range funcs {
 				c.scope.bindNameLexical(decl.Function.Name.Name, true, int(decl.Function.Name.Idx1())-1)
@@ -182,7 +183,12 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement, needResult bool) {
 			c.emit(pop)
 			c.compileBlockStatement(v.Catch.Body, bodyNeedResult)
 		}
-		c.p.code[lbl2] = jump(len(c.p.code) - lbl2)
+		if v.Finally == nil {
+			// leave the try block and jump over the catch block and the trailing leaveTry in one go
+			c.p.code[lbl2] = leaveTryJump(len(c.p.code) + 1 - lbl2)
+		} else {
+			c.p.code[lbl2] = jump(len(c.p.code) - lbl2)
+		}
 	}
 	var finallyOffset int
 	if v.Finally != nil {
@@ -250,6 +256,31 @@ func (c *compiler) compileForHeadLexDecl(decl *ast.LexicalDeclaration, needResul
 }
 
 func (c *compiler) compileLabeledForStatement(v *ast.ForStatement, needResult bool, label unistring.String) {
+	if init, ok := v.Initializer.(*ast.ForLoopInitializerLexicalDecl); ok {
+		if tok := init.LexicalDeclaration.Token; tok == token.USING || tok == token.AWAIT_USING {
+			// for (using x = ...; ...) is compiled as { using x = ...; for (; ...) }
+			var loop ast.Statement = &ast.ForStatement{
+				For:    v.For,
+				Test:   v.Test,
+				Update: v.Update,
+				Body:   v.Body,
+			}
+			if label != "" {
+				loop = &ast.LabelledStatement{
+					Label:     &ast.Identifier{Name: label, Idx: v.For},
+					Colon:     v.For,
+					Statement: loop,
+				}
+			}
+			decl := init.LexicalDeclaration
+			c.compileBlockStatement(&ast.BlockStatement{
+				LeftBrace:  v.For,
+				List:       []ast.Statement{&decl, loop},
+				RightBrace: v.For,
+			}, needResult)
+			return
+		}
+	}
 	loopBlock := &block{
 		typ:        blockLoop,
 		outer:      c.block,
@@ -367,7 +398,7 @@ func (c *compiler) compileForInto(into ast.ForInto, needResult bool) (enter *ent
 			c.compileIdentifierExpression(target).emitSetter(&c.enumGetExpr, false)
 		case ast.Pattern:
 			c.emit(enumGet)
-			c.emitPattern(target, c.emitPatternVarAssign, false)
+			c.emitPattern(target, c.emitPatternVarAssign, "", false)
 		default:
 			c.throwSyntaxError(int(target.Idx0()-1), "unsupported for-in var target: %T", target)
 		}
@@ -392,7 +423,7 @@ func (c *compiler) compileForInto(into ast.ForInto, needResult bool) (enter *ent
 			c.emit(enumGet)
 			c.emitPattern(target, func(target, init compiledExpr) {
 				c.emitPatternLexicalAssign(target, init)
-			}, false)
+			}, "", false)
 		default:
 			c.assert(false, int(into.Idx)-1, "Unsupported ForBinding: %T", into.Target)
 		}
@@ -411,6 +442,29 @@ func (c *compiler) compileLabeledForInOfStatement(into ast.ForInto, source ast.E
 		label:      label,
 		needResult: needResult,
 	}
+	if forDecl, ok := into.(*ast.ForDeclaration); ok && forDecl.Using != 0 {
+		// for (using x of ...) body is compiled as for (const %value of ...) { using x = %value; body }
+		into = &ast.ForDeclaration{
+			Idx:     forDecl.Idx,
+			IsConst: true,
+			Target:  &ast.Identifier{Name: "%value", Idx: forDecl.Idx},
+		}
+		body = &ast.BlockStatement{
+			LeftBrace: forDecl.Idx,
+			List: []ast.Statement{
+				&ast.LexicalDeclaration{
+					Idx:   forDecl.Idx,
+					Token: forDecl.Using,
+					List: []*ast.Binding{{
+						Target:      forDecl.Target,
+						Initializer: &ast.Identifier{Name: "%value", Idx: forDecl.Idx},
+					}},
+				},
+				body,
+			},
+			RightBrace: forDecl.Idx,
+		}
+	}
 	enterPos := -1
 	if forDecl, ok := into.(*ast.ForDeclaration); ok {
 		c.block = &block{
@@ -785,8 +839,9 @@ func (c *compiler) compileVarBinding(expr *ast.Binding) {
 	case *ast.Identifier:
 		c.emitVarAssign(target.Name, int(target.Idx)-1, c.compileExpression(expr.Initializer))
 	case ast.Pattern:
-		c.compileExpression(expr.Initializer).emitGetter(true)
-		c.emitPattern(target, c.emitPatternVarAssign, false)
+		init := c.compileExpression(expr.Initializer)
+		init.emitGetter(true)
+		c.emitPattern(target, c.emitPatternVarAssign, describeSource(init), false)
 	default:
 		c.throwSyntaxError(int(target.Idx0()-1), "unsupported variable binding target: %T", target)
 	}
@@ -840,10 +895,11 @@ func (c *compiler) compileLexicalBinding(expr *ast.Binding) {
 	case *ast.Identifier:
 		c.emitLexicalAssign(target.Name, int(target.Idx)-1, c.compileExpression(expr.Initializer))
 	case ast.Pattern:
-		c.compileExpression(expr.Initializer).emitGetter(true)
+		init := c.compileExpression(expr.Initializer)
+		init.emitGetter(true)
 		c.emitPattern(target, func(target, init compiledExpr) {
 			c.emitPatternLexicalAssign(target, init)
-		}, false)
+		}, describeSource(init), false)
 	default:
 		c.throwSyntaxError(int(target.Idx0()-1), "unsupported lexical binding target: %T", target)
 	}
@@ -901,7 +957,8 @@ func (c *compiler) scanStatements(list []ast.Statement) (lastProducingIdx int, b
 func (c *compiler) compileStatementsNeedResult(list []ast.Statement, lastProducingIdx int) {
 	if lastProducingIdx >= 0 {
 		for _, st := range list[:lastProducingIdx] {
-			if _, ok := st.(*ast.FunctionDeclaration); ok {
+			if decl, ok := st.(*ast.FunctionDeclaration); ok {
+				c.emitAnnexBFunctionCopy(decl)
 				continue
 			}
 			c.compileStatement(st, false)
@@ -915,7 +972,8 @@ func (c *compiler) compileStatementsNeedResult(list []ast.Statement, lastProduci
 		}
 	}()
 	for _, st := range list[lastProducingIdx+1:] {
-		if _, ok := st.(*ast.FunctionDeclaration); ok {
+		if decl, ok := st.(*ast.FunctionDeclaration); ok {
+			c.emitAnnexBFunctionCopy(decl)
 			continue
 		}
 		c.compileStatement(st, false)
@@ -937,7 +995,8 @@ func (c *compiler) compileStatements(list []ast.Statement, needResult bool) {
 		return
 	}
 	for _, st := range list {
-		if _, ok := st.(*ast.FunctionDeclaration); ok {
+		if decl, ok := st.(*ast.FunctionDeclaration); ok {
+			c.emitAnnexBFunctionCopy(decl)
 			continue
 		}
 		c.compileStatement(st, false)
@@ -956,14 +1015,15 @@ func (c *compiler) compileGenericLabeledStatement(v ast.Statement, needResult bo
 }
 
 func (c *compiler) compileBlockStatement(v *ast.BlockStatement, needResult bool) {
+	list := desugarUsing(v.List)
 	var scopeDeclared bool
-	funcs := c.extractFunctions(v.List)
+	funcs := c.extractFunctions(list)
 	if len(funcs) > 0 {
 		c.newBlockScope()
 		scopeDeclared = true
 	}
 	c.createFunctionBindings(funcs)
-	scopeDeclared = c.compileLexicalDeclarations(v.List, scopeDeclared)
+	scopeDeclared = c.compileLexicalDeclarations(list, scopeDeclared)
 
 	var enter *enterBlock
 	if scopeDeclared {
@@ -976,7 +1036,7 @@ func (c *compiler) compileBlockStatement(v *ast.BlockStatement, needResult bool)
 		c.emit(enter)
 	}
 	c.compileFunctions(funcs)
-	c.compileStatements(v.List, needResult)
+	c.compileStatements(list, needResult)
 	if scopeDeclared {
 		c.leaveScopeBlock(enter)
 		c.popScope()
@@ -1011,6 +1071,14 @@ func (c *compiler) compileWithStatement(v *ast.WithStatement, needResult bool) {
 }
 
 func (c *compiler) compileSwitchStatement(v *ast.SwitchStatement, needResult bool) {
+	if list := desugarUsingSwitch(v); list != nil {
+		c.compileBlockStatement(&ast.BlockStatement{
+			LeftBrace:  v.Switch,
+			List:       list,
+			RightBrace: v.Switch,
+		}, needResult)
+		return
+	}
 	c.block = &block{
 		typ:        blockSwitch,
 		outer:      c.block,
@@ -1125,3 +1193,176 @@ func (c *compiler) compileSwitchStatement(v *ast.SwitchStatement, needResult boo
 func (c *compiler) compileClassDeclaration(v *ast.ClassDeclaration) {
 	c.emitLexicalAssign(v.Class.Name.Name, int(v.Class.Class)-1, c.compileClassLiteral(v.Class, false))
 }
+
+// hasUsingDeclarations reports whether list contains using declarations and whether any of them is an await
+// using declaration.
+func hasUsingDeclarations(list []ast.Statement) (found, async bool) {
+	for _, st := range list {
+		if lex, ok := st.(*ast.LexicalDeclaration); ok {
+			switch lex.Token {
+			case token.USING:
+				found = true
+			case token.AWAIT_USING:
+				return true, true
+			}
+		}
+	}
+	return
+}
+
+// rewriteUsingDeclaration turns a using declaration into a const declaration which adds the resources to the
+// dispose scope.
+func rewriteUsingDeclaration(st ast.Statement, scope unistring.String) ast.Statement {
+	lex, ok := st.(*ast.LexicalDeclaration)
+	if !ok || lex.Token != token.USING && lex.Token != token.AWAIT_USING {
+		return st
+	}
+	var use unistring.String = "%using"
+	if lex.Token == token.AWAIT_USING {
+		use = "%awaitUsing"
+	}
+	list := make([]*ast.Binding, len(lex.List))
+	for i, b := range lex.List {
+		list[i] = &ast.Binding{
+			Target: b.Target,
+			Initializer: &ast.CallExpression{
+				Callee:           &ast.Identifier{Name: use, Idx: lex.Idx},
+				LeftParenthesis:  b.Initializer.Idx0(),
+				ArgumentList:     []ast.Expression{&ast.Identifier{Name: scope, Idx: lex.Idx}, b.Initializer},
+				RightParenthesis: b.Initializer.Idx1(),
+			},
+		}
+	}
+	return &ast.LexicalDeclaration{
+		Idx:   lex.Idx,
+		Token: token.CONST,
+		List:  list,
+	}
+}
+
+// wrapUsing wraps the statements of a block which contain using declarations (already rewritten with
+// rewriteUsingDeclaration()) as follows:
+//
+//	const %scope = %disposeScope();
+//	try {
+//		body
+//	} catch (%err) {
+//		%disposeScopeError(%scope, %err);
+//	} finally {
+//		%dispose(%scope); // or await %disposeAsync(%scope) if there are await using declarations
+//	}
+//
+// %dispose throws the exception caught by the catch block (if any), combined with the exceptions thrown
+// by the dispose methods.
+func wrapUsing(body []ast.Statement, async bool, idx file.Idx) []ast.Statement {
+	id := func(name unistring.String) *ast.Identifier {
+		return &ast.Identifier{Name: name, Idx: idx}
+	}
+	call := func(name unistring.String, args ...ast.Expression) *ast.CallExpression {
+		return &ast.CallExpression{
+			Callee:           id(name),
+			LeftParenthesis:  idx,
+			ArgumentList:     args,
+			RightParenthesis: idx,
+		}
+	}
+	var dispose ast.Expression
+	if async {
+		dispose = &ast.AwaitExpression{
+			Await:    idx,
+			Argument: call("%disposeAsync", id("%scope")),
+		}
+	} else {
+		dispose = call("%dispose", id("%scope"))
+	}
+	return []ast.Statement{
+		&ast.LexicalDeclaration{
+			Idx:   idx,
+			Token: token.CONST,
+			List: []*ast.Binding{{
+				Target:      id("%scope"),
+				Initializer: call("%disposeScope"),
+			}},
+		},
+		&ast.TryStatement{
+			Try: idx,
+			Body: &ast.BlockStatement{
+				LeftBrace:  idx,
+				List:       body,
+				RightBrace: idx,
+			},
+			Catch: &ast.CatchStatement{
+				Catch:     idx,
+				Parameter: id("%err"),
+				Body: &ast.BlockStatement{
+					LeftBrace: idx,
+					List: []ast.Statement{
+						&ast.ExpressionStatement{Expression: call("%disposeScopeError", id("%scope"), id("%err"))},
+					},
+					RightBrace: idx,
+				},
+			},
+			Finally: &ast.BlockStatement{
+				LeftBrace: idx,
+				List: []ast.Statement{
+					&ast.ExpressionStatement{Expression: dispose},
+				},
+				RightBrace: idx,
+			},
+		},
+	}
+}
+
+// desugarUsing returns list unchanged if it doesn't contain using declarations, otherwise it returns the
+// statements which implement them, see wrapUsing().
+func desugarUsing(list []ast.Statement) []ast.Statement {
+	found, async := hasUsingDeclarations(list)
+	if !found {
+		return list
+	}
+	body := make([]ast.Statement, len(list))
+	for i, st := range list {
+		body[i] = rewriteUsingDeclaration(st, "%scope")
+	}
+	return wrapUsing(body, async, list[0].Idx0())
+}
+
+// desugarUsingSwitch is like desugarUsing, but for a switch statement where using declarations appear in the
+// case clauses. It returns nil if there are no such declarations.
+func desugarUsingSwitch(v *ast.SwitchStatement) []ast.Statement {
+	var found, async bool
+	for _, s := range v.Body {
+		f, a := hasUsingDeclarations(s.Consequent)
+		found = found || f
+		async = async || a
+	}
+	if !found {
+		return nil
+	}
+	body := make([]*ast.CaseStatement, len(v.Body))
+	for i, s := range v.Body {
+		consequent := make([]ast.Statement, len(s.Consequent))
+		for j, st := range s.Consequent {
+			consequent[j] = rewriteUsingDeclaration(st, "%scope")
+		}
+		body[i] = &ast.CaseStatement{
+			Case:       s.Case,
+			Test:       s.Test,
+			Consequent: consequent,
+		}
+	}
+	return wrapUsing([]ast.Statement{&ast.SwitchStatement{
+		Switch:       v.Switch,
+		Discriminant: v.Discriminant,
+		Default:      v.Default,
+		Body:         body,
+	}}, async, v.Switch)
+}
+
+func (c *compiler) checkNoUsingDeclarations(list []ast.Statement) {
+	for _, st := range list {
+		if lex, ok := st.(*ast.LexicalDeclaration); ok && (lex.Token == token.USING || lex.Token == token.AWAIT_USING) {
+			c.throwSyntaxError(int(lex.Idx)-1, "Using declaration is not allowed at the top level of a script")
+		}
+	}
+}
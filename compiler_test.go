@@ -1922,6 +1922,111 @@ func TestArgumentsRedeclareInEval(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestArgumentsWithParameterExpressions(t *testing.T) {
+	const SCRIPT = `
+	// unmapped when there are defaults or a rest parameter
+	(function(a = 0) {
+		a = 2;
+		assert.sameValue(arguments[0], 1, "defaults");
+		assert.sameValue(arguments.length, 1, "defaults length");
+	})(1);
+	(function(a, ...rest) {
+		a = 2;
+		assert.sameValue(arguments[0], 1, "rest");
+		assert.sameValue(arguments.length, 3, "rest length");
+		assert.sameValue(rest.length, 2, "rest.length");
+	})(1, 2, 3);
+	(function(a) {
+		a = 2;
+		assert.sameValue(arguments[0], 2, "mapped");
+	})(1);
+
+	// arguments.length reflects the actual arguments, not the formal parameters
+	(function(a, b = 1, c) {
+		assert.sameValue(arguments.length, 1, "length with defaults");
+	})(1);
+	(function(a = arguments.length) {
+		assert.sameValue(a, 3, "length in a default");
+	})(undefined, 2, 3);
+
+	// arrow function in a parameter initialiser
+	(function(a = () => arguments) {
+		var args = a();
+		assert.sameValue(Object.prototype.toString.call(args), "[object Arguments]", "arrow");
+		assert.sameValue(args.length, 2, "arrow length");
+		assert.sameValue(args[1], 5, "arrow args[1]");
+	})(undefined, 5);
+	(function(b, a = () => arguments) {
+		assert.sameValue(a()[0], 7, "arrow after a simple parameter");
+	})(7);
+
+	// var arguments in the body is initialised with the arguments object
+	(function(a = 1) {
+		var arguments;
+		assert.sameValue(typeof arguments, "object", "var arguments");
+		assert.sameValue(arguments.length, 1, "var arguments length");
+	})(1);
+	(function(a, b = () => arguments) {
+		var arguments;
+		assert.sameValue(arguments, b(), "var arguments same object");
+		arguments = "local";
+		assert.sameValue(typeof b(), "object", "parameter scope binding unchanged");
+	})(1);
+	(function(a = 1) {
+		function arguments() {}
+		assert.sameValue(typeof arguments, "function", "function arguments");
+	})();
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestDirectEvalInParameters(t *testing.T) {
+	const SCRIPT = `
+	var x = "global";
+
+	// vars created by eval in the parameter list are visible to the following parameters
+	// and to the body, but the body's own vars shadow them
+	(function(a = eval("var x = 'param'"), b = () => x, c = x) {
+		assert.sameValue(c, "param", "c");
+		var x = "body";
+		assert.sameValue(x, "body", "x");
+		assert.sameValue(b(), "param", "b()");
+	})();
+	(function(a = eval("var x = 'param'")) {
+		assert.sameValue(x, "param", "no body var");
+		assert.sameValue(eval("x"), "param", "eval in body");
+	})();
+	(function(a = () => eval("x")) {
+		var x = "body";
+		assert.sameValue(a(), "global", "eval in an arrow sees the parameter scope");
+	})();
+	((a = eval("var x = 'arrow'"), b = () => x) => {
+		var x = "body";
+		assert.sameValue(b(), "arrow", "arrow function");
+	})();
+	assert.sameValue(x, "global", "global x unchanged");
+
+	// the parameter scope is separate from the scope eval declares vars in
+	assert.throws(SyntaxError, function(a = eval("var a = 42")) {});
+	assert.throws(SyntaxError, function(a, b = eval("var a = 42")) {});
+	assert.throws(SyntaxError, function(a = eval("var arguments")) {});
+
+	// the initialiser is not evaluated if the argument is passed
+	(function(a = eval("var a = 42")) {
+		assert.sameValue(a, 5, "a");
+	})(5);
+	(function(a = () => a, b = 1) {
+		assert.sameValue(a, 5, "closure a");
+		assert.sameValue(b, 6, "closure b");
+	})(5, 6);
+	(function(a = eval("1"), b = 2) {
+		assert.sameValue(a, 1, "eval a");
+		assert.sameValue(b, 6, "eval b");
+	})(undefined, 6);
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestArgumentsRedeclareArrow(t *testing.T) {
 	const SCRIPT = `
 	const oldArguments = globalThis.arguments;
@@ -4670,6 +4775,46 @@ func TestBadObjectKey(t *testing.T) {
 	}
 }
 
+func TestTypeofTest(t *testing.T) {
+	const SCRIPT = `
+	var values = [undefined, null, true, 1, 1.5, 1n, "s", Symbol(), {}, [], function() {}, class {}, new Proxy(function() {}, {}), new Proxy({}, {})];
+	var types = ["undefined", "object", "boolean", "number", "bigint", "string", "symbol", "function", "other"];
+	for (var i = 0; i < values.length; i++) {
+		var v = values[i];
+		var t = typeof v;
+		assert.sameValue(typeof v === "undefined", t === "undefined", i + " undefined");
+		assert.sameValue(typeof v === "object", t === "object", i + " object");
+		assert.sameValue(typeof v == "boolean", t === "boolean", i + " boolean");
+		assert.sameValue("number" === typeof v, t === "number", i + " number");
+		assert.sameValue(typeof v !== "bigint", t !== "bigint", i + " bigint");
+		assert.sameValue("string" != typeof v, t !== "string", i + " string");
+		assert.sameValue(typeof v === "symbol", t === "symbol", i + " symbol");
+		assert.sameValue(typeof v === "function", t === "function", i + " function");
+		assert.sameValue(typeof v === "Function", false, i + " not a type");
+		assert.sameValue(typeof v !== "", true, i + " empty");
+	}
+	assert(typeof notDefined === "undefined", "unresolved");
+	assert(typeof (0, "s") === "string", "constant");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	prg := MustCompile("test.js", `typeof x === "string"`, false)
+	found := false
+	for _, instr := range prg.code {
+		switch instr.(type) {
+		case typeofIs:
+			found = true
+		case _typeof, _op_strict_eq:
+			prg.dumpCode(t.Logf)
+			t.Fatalf("Unexpected instruction: %T", instr)
+		}
+	}
+	if !found {
+		prg.dumpCode(t.Logf)
+		t.Fatal("typeofIs is not emitted")
+	}
+}
+
 func TestConstantFolding(t *testing.T) {
 	testValues := func(prg *Program, result Value, t *testing.T) {
 		if len(prg.values) != 1 || !prg.values[0].SameAs(result) {
@@ -5803,6 +5948,171 @@ func TestGeneratorMethods(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestNumericSeparators(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(1_000_000, 1000000);
+	assert.sameValue(0xFF_FF, 65535);
+	assert.sameValue(0b1010_0101, 165);
+	assert.sameValue(0o7_7, 63);
+	assert.sameValue(1_0.2_5e1_0, 10.25e10);
+	assert.sameValue({1_0: true}[10], true);
+	assert.sameValue(Number("1_000"), NaN);
+	assert.sameValue(parseInt("1_000"), 1);
+	assert.sameValue(Number("0x1p3"), NaN);
+	assert.sameValue(Number("inf"), NaN);
+	assert.sameValue(Number(" Infinity"), Infinity);
+	assert.sameValue(Number("Infinity "), Infinity);
+	assert.sameValue(+"\t-Infinity ", -Infinity);
+	assert.sameValue(Number(" +Infinity\n"), Infinity);
+	assert.sameValue(Number(" Infinityx"), NaN);
+	assert.sameValue(" Infinity" == Infinity, true);
+	assert.sameValue("abc".substring(" Infinity"), "");
+	assert.sameValue("abc".slice("-Infinity "), "abc");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestAnnexBBlockFunctions(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(typeof g1, "undefined", "global before the block");
+	{
+		function g1() { return 1; }
+	}
+	assert.sameValue(g1(), 1, "global after the block");
+
+	(function() {
+		assert.sameValue(f, undefined, "before the block");
+		{
+			assert.sameValue(f(), 2, "inside the block");
+			f = 1;
+			function f() { return 2; }
+			f = 3;
+		}
+		assert.sameValue(f, 1, "value at the point of declaration");
+	})();
+
+	(function() {
+		if (true) function f() {}
+		with ({}) { function f1() {} }
+		switch (1) { case 1: function f2() {} }
+		try { function f3() {} } finally {}
+		assert.sameValue(typeof f, "function", "if");
+		assert.sameValue(typeof f1, "function", "with");
+		assert.sameValue(typeof f2, "function", "switch");
+		assert.sameValue(typeof f3, "function", "try");
+	})();
+
+	(function() {
+		let f = 1;
+		{
+			function f() {}
+		}
+		assert.sameValue(f, 1, "top-level let");
+	})();
+
+	(function() {
+		{
+			let f = 1;
+			{
+				function f() {}
+			}
+		}
+		for (let f1 of [1]) {
+			function f1() {}
+		}
+		try {
+			throw [];
+		} catch ([f2]) {
+			{
+				function f2() {}
+			}
+		}
+		assert.sameValue(typeof f, "undefined", "enclosing block");
+		assert.sameValue(typeof f1, "undefined", "for-of head");
+		assert.sameValue(typeof f2, "undefined", "catch parameter pattern");
+	})();
+
+	(function(f) {
+		{
+			function f() {}
+		}
+		assert.sameValue(f, 1, "parameter");
+	})(1);
+
+	(function() {
+		"use strict";
+		{
+			function f() {}
+		}
+		assert.sameValue(typeof f, "undefined", "strict mode");
+	})();
+
+	(function() {
+		{
+			async function f() {}
+			function* f1() {}
+		}
+		assert.sameValue(typeof f, "undefined", "async function");
+		assert.sameValue(typeof f1, "undefined", "generator");
+	})();
+
+	(function() {
+		var before = typeof f;
+		eval("{ function f() { return 1; } }");
+		assert.sameValue(before, "undefined", "before direct eval");
+		assert.sameValue(f(), 1, "direct eval in a function");
+		eval("if (true) function f1() {}");
+		assert.sameValue(typeof f1, "function", "direct eval, if statement");
+		assert.sameValue(delete f1, true, "direct eval bindings are deletable");
+		(() => { eval("{ function f2() {} }"); assert.sameValue(typeof f2, "function", "arrow function"); })();
+	})();
+
+	(function() {
+		let f = 1;
+		eval("{ function f() {} }");
+		assert.sameValue(f, 1, "direct eval, conflicting let");
+		eval("let f1 = 1; { function f1() {} }");
+		assert.sameValue(typeof f1, "undefined", "direct eval, let in eval code");
+		eval("'use strict'; { function f2() {} }");
+		assert.sameValue(typeof f2, "undefined", "direct eval, strict mode");
+	})();
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestStrictBlockFunctionsOption(t *testing.T) {
+	const SCRIPT = `
+	{
+		function f() {}
+	}
+	(function() {
+		{
+			function f1() {}
+		}
+		return typeof f1;
+	})() + "," + typeof f;
+	`
+	for _, tc := range []struct {
+		options  []CompilerOption
+		expected string
+	}{
+		{nil, "function,function"},
+		{[]CompilerOption{WithStrictBlockFunctions}, "undefined,undefined"},
+	} {
+		prg, err := Compile("test.js", SCRIPT, false, tc.options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := New().RunProgram(prg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := v.String(); s != tc.expected {
+			t.Fatalf("Expected %q, got %q", tc.expected, s)
+		}
+	}
+}
+
 /*
 func TestBabel(t *testing.T) {
 	src, err := os.ReadFile("babel7.js")
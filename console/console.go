@@ -0,0 +1,78 @@
+// Package console defines the interface between the console built-in of goja and the embedder, see
+// goja.Runtime.SetConsolePrinter.
+//
+// The console object is installed in every Runtime. Its methods format their arguments into a message and pass it
+// to the Printer of the Runtime along with the level, which is the name of the method for log, info, debug, warn
+// and error. The other methods use the level of the closest equivalent: dir, table, group, timeLog, timeEnd and
+// count print at the log level, assert prints at the error level, and the warnings about unknown timers are printed
+// at the warn level.
+//
+// By default the messages are written to os.Stdout (log, info and debug) and to os.Stderr (warn and error). For
+// example, to route them to a logger:
+//
+//	vm.SetConsolePrinter(console.PrinterFunc(func(level console.Level, msg string) {
+//	    logger.Printf("[%s] %s", level, msg)
+//	}))
+//
+// This package does not depend on goja so that it can be imported by it.
+package console
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a message.
+type Level string
+
+const (
+	Log   Level = "log"
+	Info  Level = "info"
+	Debug Level = "debug"
+	Warn  Level = "warn"
+	Error Level = "error"
+)
+
+// Printer receives the messages printed by the console methods. msg does not end with a newline, it may contain
+// several lines which are already indented according to the current group (see console.group()). Print is called
+// on the goroutine running the script.
+type Printer interface {
+	Print(level Level, msg string)
+}
+
+// PrinterFunc is an adapter allowing to use a function as a Printer.
+type PrinterFunc func(level Level, msg string)
+
+// Print implements Printer.
+func (f PrinterFunc) Print(level Level, msg string) {
+	f(level, msg)
+}
+
+// Discard is a Printer which ignores the messages.
+var Discard Printer = PrinterFunc(func(Level, string) {})
+
+type writerPrinter struct {
+	mu             sync.Mutex
+	stdout, stderr io.Writer
+}
+
+// NewWriterPrinter returns a Printer which writes the messages followed by a newline to stdout, or to stderr for
+// the warn and error levels. The writes are serialised, so the Printer can be shared by several Runtimes.
+func NewWriterPrinter(stdout, stderr io.Writer) Printer {
+	return &writerPrinter{stdout: stdout, stderr: stderr}
+}
+
+func (p *writerPrinter) Print(level Level, msg string) {
+	w := p.stdout
+	if level == Warn || level == Error {
+		w = p.stderr
+	}
+	var sb strings.Builder
+	sb.Grow(len(msg) + 1)
+	sb.WriteString(msg)
+	sb.WriteByte('\n')
+	p.mu.Lock()
+	_, _ = io.WriteString(w, sb.String())
+	p.mu.Unlock()
+}
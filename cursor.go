@@ -0,0 +1,128 @@
+package goja
+
+// Cursor is implemented by Go iterators over results that hold resources which have to be released when the
+// iteration is finished, such as *sql.Rows. See Runtime.NewCursorIterator().
+//
+// If the Cursor also has an Err() error method (as *sql.Rows does), it is called after Next() returns false
+// and a non-nil result is thrown as an exception.
+type Cursor interface {
+	// Next advances the cursor to the next item. It returns false when there are no more items.
+	Next() bool
+	// Close releases the resources held by the cursor.
+	Close() error
+}
+
+type cursorIterObject struct {
+	baseObject
+	cursor Cursor
+	scan   func() (interface{}, error)
+}
+
+// NewCursorIterator wraps a Cursor into a JavaScript iterator, so it can be used with for-of loops, the spread
+// syntax, destructuring, Array.from() and so on. For each item scan is called to obtain its value, which is then
+// converted using ToValue(). For example:
+//
+//	rows, err := db.Query("SELECT id, name FROM users")
+//	if err != nil { /* ... */ }
+//	vm.Set("users", vm.NewCursorIterator(rows, func() (interface{}, error) {
+//		var u User
+//		err := rows.Scan(&u.ID, &u.Name)
+//		return &u, err
+//	}))
+//
+//	for (const u of users) {
+//		if (u.Name === "admin") break; // the cursor is closed here
+//	}
+//
+// The cursor is closed exactly once: when it is exhausted, when scan or Err() returns an error, or when the
+// iteration is terminated early (e.g. by break, return or an exception thrown in the loop body), which calls
+// the return() method of the iterator. Errors are thrown as GoError (an *Exception is thrown as is).
+//
+// Note that the cursor is not closed if the script does not iterate it to completion and never calls return(),
+// e.g. when it calls next() manually or does not use the iterator at all. The host should close the cursor
+// in such cases, once the script has finished. Closing an already closed cursor must be safe for that reason.
+//
+// Async iteration (for await) is not supported.
+func (r *Runtime) NewCursorIterator(cursor Cursor, scan func() (interface{}, error)) *Object {
+	o := &Object{runtime: r}
+	ci := &cursorIterObject{
+		cursor: cursor,
+		scan:   scan,
+	}
+	ci.class = classObject
+	ci.val = o
+	ci.extensible = true
+	o.self = ci
+	ci.prototype = r.getCursorIteratorPrototype()
+	ci.init()
+	return o
+}
+
+func (o *cursorIterObject) next() Value {
+	r := o.val.runtime
+	if o.cursor == nil {
+		return r.createIterResultObject(_undefined, true)
+	}
+	if !o.cursor.Next() {
+		var err error
+		if e, ok := o.cursor.(interface{ Err() error }); ok {
+			err = e.Err()
+		}
+		o.close(err)
+		return r.createIterResultObject(_undefined, true)
+	}
+	v, err := o.scan()
+	if err != nil {
+		o.close(err)
+	}
+	return r.createIterResultObject(r.ToValue(v), false)
+}
+
+func (o *cursorIterObject) close(err error) {
+	if c := o.cursor; c != nil {
+		o.cursor = nil
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		o.val.runtime.throwGoFuncError(err)
+	}
+}
+
+func (r *Runtime) toCursorIter(v Value, method string) *cursorIterObject {
+	thisObj := r.toObject(v)
+	if iter, ok := thisObj.self.(*cursorIterObject); ok {
+		return iter
+	}
+	panic(r.NewTypeError("Method Cursor Iterator.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+}
+
+func (r *Runtime) cursorIterProto_next(call FunctionCall) Value {
+	return r.toCursorIter(call.This, "next").next()
+}
+
+func (r *Runtime) cursorIterProto_return(call FunctionCall) Value {
+	r.toCursorIter(call.This, "return").close(nil)
+	return r.createIterResultObject(call.Argument(0), true)
+}
+
+func (r *Runtime) createCursorIterProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.getIteratorPrototype(), classObject)
+
+	o._putProp("next", r.newNativeFunc(r.cursorIterProto_next, nil, "next", nil, 0), true, false, true)
+	o._putProp("return", r.newNativeFunc(r.cursorIterProto_return, nil, "return", nil, 1), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString("Cursor Iterator"), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) getCursorIteratorPrototype() *Object {
+	var o *Object
+	if o = r.global.CursorIteratorPrototype; o == nil {
+		o = &Object{runtime: r}
+		r.global.CursorIteratorPrototype = o
+		o.self = r.createCursorIterProto(o)
+	}
+	return o
+}
@@ -0,0 +1,115 @@
+package goja
+
+import (
+	"errors"
+	"testing"
+)
+
+type testCursor struct {
+	items   []int
+	pos     int
+	failAt  int
+	err     error
+	closed  int
+	scanErr error
+}
+
+func (c *testCursor) Next() bool {
+	if c.pos == c.failAt {
+		c.err = errors.New("cursor failed")
+		return false
+	}
+	if c.pos >= len(c.items) {
+		return false
+	}
+	c.pos++
+	return true
+}
+
+func (c *testCursor) Err() error {
+	return c.err
+}
+
+func (c *testCursor) Close() error {
+	c.closed++
+	return nil
+}
+
+func (c *testCursor) scan() (interface{}, error) {
+	if c.scanErr != nil {
+		return nil, c.scanErr
+	}
+	return c.items[c.pos-1], nil
+}
+
+func TestCursorIterator(t *testing.T) {
+	vm := New()
+	newCursor := func() *testCursor {
+		return &testCursor{items: []int{1, 2, 3}, failAt: -1}
+	}
+
+	run := func(c *testCursor, script string) Value {
+		vm.Set("cursor", vm.NewCursorIterator(c, c.scan))
+		v, err := vm.RunString(TESTLIB + script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.closed != 1 {
+			t.Fatalf("%s: Close() has been called %d times", script, c.closed)
+		}
+		return v
+	}
+
+	run(newCursor(), `
+	assert(compareArray([...cursor], [1, 2, 3]));
+	assert.sameValue(cursor.next().done, true);
+	assert.sameValue(Object.prototype.toString.call(cursor), "[object Cursor Iterator]");
+	assert.sameValue(cursor[Symbol.iterator](), cursor);
+	`)
+
+	c := newCursor()
+	run(c, `
+	for (var x of cursor) {
+		if (x === 2) {
+			break;
+		}
+	}
+	`)
+	if c.pos != 2 {
+		t.Fatal(c.pos)
+	}
+
+	run(newCursor(), `
+	assert.throws(Error, function() {
+		for (var x of cursor) {
+			throw new Error("test");
+		}
+	});
+	var [a] = cursor;
+	assert.sameValue(a, undefined, "already closed");
+	`)
+
+	c = newCursor()
+	c.failAt = 1
+	run(c, `
+	assert.throws(GoError, function() {
+		for (var x of cursor) {}
+	});
+	`)
+
+	c = newCursor()
+	c.scanErr = errors.New("scan failed")
+	run(c, `
+	try {
+		cursor.next();
+		throw new Error("should have thrown");
+	} catch (e) {
+		assert.sameValue(e.value.Error(), "scan failed");
+	}
+	`)
+
+	_, err := vm.RunString(`cursor.next.call({})`)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
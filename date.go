@@ -7,14 +7,11 @@ import (
 )
 
 const (
-	dateTimeLayout       = "Mon Jan 02 2006 15:04:05 GMT-0700 (MST)"
-	utcDateTimeLayout    = "Mon, 02 Jan 2006 15:04:05 GMT"
-	isoDateTimeLayout    = "2006-01-02T15:04:05.000Z"
-	dateLayout           = "Mon Jan 02 2006"
-	timeLayout           = "15:04:05 GMT-0700 (MST)"
-	datetimeLayout_en_GB = "01/02/2006, 15:04:05"
-	dateLayout_en_GB     = "01/02/2006"
-	timeLayout_en_GB     = "15:04:05"
+	dateTimeLayout    = "Mon Jan 02 2006 15:04:05 GMT-0700 (MST)"
+	utcDateTimeLayout = "Mon, 02 Jan 2006 15:04:05 GMT"
+	isoDateTimeLayout = "2006-01-02T15:04:05.000Z"
+	dateLayout        = "Mon Jan 02 2006"
+	timeLayout        = "15:04:05 GMT-0700 (MST)"
 
 	maxTime   = 8.64e15
 	timeUnset = math.MinInt64
@@ -68,7 +65,7 @@ var (
 	}
 )
 
-func dateParse(date string) (time.Time, bool) {
+func dateParse(date string, loc *time.Location) (time.Time, bool) {
 	var t time.Time
 	var err error
 	var layouts []dateLayoutDesc
@@ -87,7 +84,7 @@ func dateParse(date string) (time.Time, bool) {
 		if desc.dateOnly {
 			defLoc = time.UTC
 		} else {
-			defLoc = time.Local
+			defLoc = loc
 		}
 		t, err = parseDate(desc.layout, date, defLoc)
 		if err == nil {
@@ -101,6 +98,15 @@ func dateParse(date string) (time.Time, bool) {
 	return t, unix >= -maxTime && unix <= maxTime
 }
 
+func (r *Runtime) dateParse(date string) (time.Time, bool) {
+	loc := r.location()
+	t, ok := dateParse(date, loc)
+	if !ok && r.extendedDateParsing {
+		t, ok = dateParseLegacy(date, loc)
+	}
+	return t, ok
+}
+
 func (r *Runtime) newDateObject(t time.Time, isSet bool, proto *Object) *Object {
 	v := &Object{runtime: r}
 	d := &dateObject{}
@@ -118,10 +124,6 @@ func (r *Runtime) newDateObject(t time.Time, isSet bool, proto *Object) *Object
 	return v
 }
 
-func dateFormat(t time.Time) string {
-	return t.Local().Format(dateTimeLayout)
-}
-
 func timeFromMsec(msec int64) time.Time {
 	sec := msec / 1000
 	nsec := (msec % 1000) * 1e6
@@ -132,10 +134,6 @@ func timeToMsec(t time.Time) int64 {
 	return t.Unix()*1000 + int64(t.Nanosecond())/1e6
 }
 
-func (d *dateObject) toPrimitive() Value {
-	return d.toPrimitiveString()
-}
-
 func (d *dateObject) exportType() reflect.Type {
 	return typeTime
 }
@@ -166,7 +164,7 @@ func (d *dateObject) unset() {
 }
 
 func (d *dateObject) time() time.Time {
-	return timeFromMsec(d.msec)
+	return timeFromMsec(d.msec).In(d.val.runtime.location())
 }
 
 func (d *dateObject) timeUTC() time.Time {
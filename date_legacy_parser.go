@@ -0,0 +1,278 @@
+package goja
+
+import (
+	"strings"
+	"time"
+)
+
+// This is a lenient parser for the non-standard date formats accepted by the browsers (see
+// Runtime.SetExtendedDateParsing), loosely following the legacy date parser of V8. The string is split into
+// numbers, words and signs, with the following rules:
+// - Numbers followed by ':' start the time of day: hh:mm[:ss[.sss]], optionally followed by "am" or "pm".
+// - A sign followed by a number after the time of day or after "GMT", "UTC", "UT" or "Z" is the time zone offset,
+//   either as hh, hh:mm or hhmm.
+// - Month names (and their prefixes at least 3 letters long) set the month; weekday names are ignored.
+// - The common North American time zone abbreviations (EST, EDT, CST, CDT, MST, MDT, PST, PDT) set the offset.
+// - Text in parentheses is ignored, as are commas.
+// - The other numbers form the date: day and year if the month is given as a name (in any order, a number which
+//   is greater than 31 or has more than 2 digits is the year), otherwise either month/day/year or
+//   year/month/day if the first number is the year. The numbers may be separated by '/', '-' or '.', and a month
+//   name may be separated from them by '-' as in the RFC 850 format (e.g. "Sat, 01-Jan-2000 08:00:00 GMT").
+// - Days past the end of the month (up to 31) roll over into the next month, e.g. Feb 30 is Mar 1 or 2.
+// - Two-digit years 00-49 are 2000-2049, 50-99 are 1950-1999.
+// If there is no offset the date is in the local time zone.
+
+type legacyDateNum struct {
+	val, digits int
+}
+
+var legacyMonthNames = [...]string{"january", "february", "march", "april", "may", "june", "july", "august",
+	"september", "october", "november", "december"}
+
+var legacyWeekdayNames = [...]string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+var legacyZoneOffsets = map[string]int{
+	"ut": 0, "utc": 0, "gmt": 0, "z": 0,
+	"est": -5 * 60, "edt": -4 * 60,
+	"cst": -6 * 60, "cdt": -5 * 60,
+	"mst": -7 * 60, "mdt": -6 * 60,
+	"pst": -8 * 60, "pdt": -7 * 60,
+}
+
+func lookupLegacyName(names []string, word string) int {
+	if len(word) < 3 {
+		return -1
+	}
+	for i, name := range names {
+		if strings.HasPrefix(name, word) {
+			return i
+		}
+	}
+	return -1
+}
+
+func readLegacyNum(s string, i int) (legacyDateNum, int) {
+	var n legacyDateNum
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		if n.digits < 9 {
+			n.val = n.val*10 + int(s[i]-'0')
+		}
+		n.digits++
+	}
+	return n, i
+}
+
+func dateParseLegacy(date string, loc *time.Location) (time.Time, bool) {
+	s := strings.ToLower(date)
+	var (
+		nums                     []legacyDateNum
+		month                    = -1
+		hour, minute, second, ms int
+		hasTime, pm, am          bool
+		offset                   int
+		hasOffset                bool
+		// a sign at this point is the offset
+		expectOffset bool
+	)
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == ',':
+			i++
+		case c == '(':
+			depth := 0
+			for ; i < len(s); i++ {
+				if s[i] == '(' {
+					depth++
+				} else if s[i] == ')' {
+					depth--
+					if depth == 0 {
+						i++
+						break
+					}
+				}
+			}
+		case c >= '0' && c <= '9':
+			var n legacyDateNum
+			n, i = readLegacyNum(s, i)
+			if i < len(s) && s[i] == ':' {
+				if hasTime || n.digits > 2 {
+					return time.Time{}, false
+				}
+				hasTime = true
+				expectOffset = !hasOffset
+				hour = n.val
+				n, i = readLegacyNum(s, i+1)
+				if n.digits == 0 || n.digits > 2 {
+					return time.Time{}, false
+				}
+				minute = n.val
+				if i < len(s) && s[i] == ':' {
+					n, i = readLegacyNum(s, i+1)
+					if n.digits == 0 || n.digits > 2 {
+						return time.Time{}, false
+					}
+					second = n.val
+					if i < len(s) && s[i] == '.' {
+						n, i = readLegacyNum(s, i+1)
+						if n.digits == 0 {
+							return time.Time{}, false
+						}
+						ms = n.val
+						for d := n.digits; d < 3; d++ {
+							ms *= 10
+						}
+						for d := n.digits; d > 3 && d <= 9; d-- {
+							ms /= 10
+						}
+					}
+				}
+				continue
+			}
+			nums = append(nums, n)
+			if i+1 < len(s) && (s[i] == '/' || s[i] == '-' || s[i] == '.') &&
+				(s[i+1] >= '0' && s[i+1] <= '9' || s[i] == '-' && s[i+1] >= 'a' && s[i+1] <= 'z') {
+				i++
+			}
+		case c == '+' || c == '-':
+			if !expectOffset {
+				return time.Time{}, false
+			}
+			sign := 1
+			if c == '-' {
+				sign = -1
+			}
+			n, j := readLegacyNum(s, i+1)
+			var h, m int
+			switch {
+			case n.digits == 0 || n.digits == 3 || n.digits > 4:
+				return time.Time{}, false
+			case n.digits == 4:
+				h, m = n.val/100, n.val%100
+			default:
+				h = n.val
+				if j < len(s) && s[j] == ':' {
+					n, j = readLegacyNum(s, j+1)
+					if n.digits != 2 {
+						return time.Time{}, false
+					}
+					m = n.val
+				}
+			}
+			if h > 23 || m > 59 {
+				return time.Time{}, false
+			}
+			offset = sign * (h*60 + m)
+			hasOffset = true
+			expectOffset = false
+			i = j
+		case c >= 'a' && c <= 'z':
+			j := i
+			for j < len(s) && (s[j] >= 'a' && s[j] <= 'z' || s[j] == '.') {
+				j++
+			}
+			word := strings.TrimRight(s[i:j], ".")
+			i = j
+			if word == "t" && i < len(s) && s[i] >= '0' && s[i] <= '9' {
+				// date and time separator as in ISO format
+				continue
+			}
+			if word == "am" || word == "a.m" {
+				am = true
+				continue
+			}
+			if word == "pm" || word == "p.m" {
+				pm = true
+				continue
+			}
+			if off, ok := legacyZoneOffsets[word]; ok {
+				if hasOffset {
+					return time.Time{}, false
+				}
+				offset = off
+				hasOffset = true
+				// the offset may follow, e.g. "GMT+0100"
+				expectOffset = off == 0
+				continue
+			}
+			if m := lookupLegacyName(legacyMonthNames[:], word); m >= 0 {
+				if month >= 0 {
+					return time.Time{}, false
+				}
+				month = m + 1
+				if i+1 < len(s) && s[i] == '-' && s[i+1] >= '0' && s[i+1] <= '9' {
+					i++
+				}
+				continue
+			}
+			if lookupLegacyName(legacyWeekdayNames[:], word) >= 0 {
+				continue
+			}
+			return time.Time{}, false
+		default:
+			return time.Time{}, false
+		}
+	}
+
+	var year, day int
+	var yearDigits int
+	if month > 0 {
+		switch len(nums) {
+		case 1:
+			if nums[0].val <= 31 && nums[0].digits <= 2 {
+				return time.Time{}, false
+			}
+			year, yearDigits, day = nums[0].val, nums[0].digits, 1
+		case 2:
+			if nums[0].val > 31 || nums[0].digits > 2 {
+				year, yearDigits, day = nums[0].val, nums[0].digits, nums[1].val
+			} else {
+				day, year, yearDigits = nums[0].val, nums[1].val, nums[1].digits
+			}
+		default:
+			return time.Time{}, false
+		}
+	} else {
+		if len(nums) != 3 {
+			return time.Time{}, false
+		}
+		if nums[0].val > 31 || nums[0].digits > 2 {
+			year, yearDigits, month, day = nums[0].val, nums[0].digits, nums[1].val, nums[2].val
+		} else {
+			month, day, year, yearDigits = nums[0].val, nums[1].val, nums[2].val, nums[2].digits
+		}
+	}
+	if yearDigits <= 2 {
+		if year < 50 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+
+	if am || pm {
+		if !hasTime || am && pm || hour < 1 || hour > 12 {
+			return time.Time{}, false
+		}
+		if hour == 12 {
+			hour = 0
+		}
+		if pm {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, false
+	}
+
+	if hasOffset {
+		loc = time.FixedZone("", offset*60)
+	}
+	t := time.Date(year, time.Month(month), day, hour, minute, second, ms*1e6, loc)
+	unix := timeToMsec(t)
+	return t, unix >= -maxTime && unix <= maxTime
+}
@@ -261,6 +261,129 @@ func TestDateParse(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestDateParseExtended(t *testing.T) {
+	const SCRIPT = `
+	function testParse(str, expected) {
+		assert.sameValue(Date.parse(str), expected, str);
+	}
+
+	testParse("Jan 1 2020",							1577854800000);
+	testParse("1 January 2020",						1577854800000);
+	testParse("January 2, 2020 10:00 PM",			1578020400000);
+	testParse("1/2/2020",							1577941200000);
+	testParse("1.2.2020",							1577941200000);
+	testParse("12/25/20 3:45:10 pm",				1608929110000);
+	testParse("Sept 5, 99",							936504000000);
+	testParse("2020/01/02 10:00",					1577977200000);
+	testParse("2020-1-2 10:00:00.5",				1577977200500);
+	testParse("2020/1/2 12:00 am",					1577941200000);
+	testParse("12:30 Jan 2 2020",					1577986200000);
+	testParse("Jan 2020",							1577854800000);
+	testParse("Wed, 1 Jan 2020 10:00:00 EST",		1577890800000);
+	testParse("Wed, 01 Jan 2020 10:00:00 PDT",		1577898000000);
+	testParse("Thu Jan 02 2020 10:00:00 GMT+0100",	1577955600000);
+	testParse("Thu Jan 02 2020 10:00:00 GMT+01:00 (Central European Standard Time)", 1577955600000);
+	testParse("2 Jan 2020 10:00 UTC",				1577959200000);
+	testParse("Jan 2 2020 10:00Z",					1577959200000);
+	testParse("Jan 2 2020 10:00 +5",				1577941200000);
+	testParse("Sat, 01-Jan-2000 08:00:00 GMT",		946713600000);
+	testParse("Sunday, 06-Nov-94 08:49:37 GMT",		784111777000);
+	testParse("01-Jan-2000",						946702800000);
+	testParse("Jan-01-2000",						946702800000);
+	testParse("2000-Jan-01",						946702800000);
+	testParse("Feb 30 2020",						1583038800000);
+	testParse("Feb 31 2020 10:00",					1583161200000);
+	testParse("2/30/2020",							1583038800000);
+	testParse("Apr 31, 2021",						1619841600000);
+
+	testParse("Jan 32 2020",						NaN);
+	testParse("Feb 0 2020",							NaN);
+	testParse("13/1/2020",							NaN);
+	testParse("foo 2020",							NaN);
+	testParse("Jan 2 2020 13:00 pm",				NaN);
+	testParse("1/2",								NaN);
+	testParse("1/2/2020 10:00 +0100 +0200",			NaN);
+
+	// the standard formats are not affected
+	testParse("2006-01-02",							1136160000000);
+	testParse("Mon, 02 Jan 2006 15:04:05 MST",		1136239445000);
+	assert.sameValue(new Date("Jan 1 2020").getTime(), 1577854800000, "constructor");
+	`
+
+	l := time.Local
+	defer func() {
+		time.Local = l
+	}()
+	var err error
+	time.Local, err = time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm := New()
+	vm.SetExtendedDateParsing(true)
+	vm.testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	vm.SetExtendedDateParsing(false)
+	if v, err := vm.RunString(`Date.parse("Jan 1 2020")`); err != nil || !IsNaN(v) {
+		t.Fatalf("%v, %v", v, err)
+	}
+}
+
+func TestDateTimeZone(t *testing.T) {
+	const SCRIPT = `
+	var d = new Date(2020, 0, 1);
+	assert.sameValue(d.getTime(), 1577804400000, "constructor");
+	assert.sameValue(d.getTimezoneOffset(), -540, "getTimezoneOffset");
+	assert.sameValue(d.toString(), "Wed Jan 01 2020 00:00:00 GMT+0900 (JST)", "toString");
+	assert.sameValue(Date.parse("2020-01-01T00:00:00"), 1577804400000, "parse");
+	assert.sameValue(new Intl.DateTimeFormat().resolvedOptions().timeZone, "Asia/Tokyo", "Intl");
+	`
+
+	l := time.Local
+	defer func() {
+		time.Local = l
+	}()
+	var err error
+	time.Local, err = time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm := New()
+	vm.Set("Intl", vm.NewIntlNamespace("en"))
+	if err := vm.SetTimeZone("Asia/Tokyo"); err != nil {
+		t.Fatal(err)
+	}
+	vm.testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	if err := vm.SetTimeZone("Europe/Berlin"); err != nil {
+		t.Fatal(err)
+	}
+	vm.testScriptWithTestLib(`
+	var d = new Date(2020, 6, 1);
+	assert.sameValue(d.getTimezoneOffset(), -120, "summer time");
+	d.setHours(25);
+	assert.sameValue(d.getTime(), 1593644400000, "setHours");
+	assert.sameValue(String(new Date(2020, 2, 29, 2, 30)), "Sun Mar 29 2020 03:30:00 GMT+0200 (CEST)", "gap");
+	`, _undefined, t)
+
+	vm.SetLocation(time.FixedZone("", -(3*3600 + 30*60)))
+	vm.testScriptWithTestLib(`
+	assert.sameValue(new Date(0).getTimezoneOffset(), 210, "getTimezoneOffset");
+	assert.sameValue(new Intl.DateTimeFormat().resolvedOptions().timeZone, "-03:30", "Intl");
+	`, _undefined, t)
+
+	vm.SetLocation(nil)
+	if v, err := vm.RunString(`new Date(2020, 0, 1).getTimezoneOffset()`); err != nil || v.ToInteger() != 300 {
+		t.Fatalf("%v, %v", v, err)
+	}
+
+	if err := vm.SetTimeZone("No/Such_Zone"); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
 func TestDateMaxValues(t *testing.T) {
 	const SCRIPT = `
 	assert.sameValue((new Date(0)).setUTCMilliseconds(8.64e15), 8.64e15);
@@ -297,6 +420,24 @@ func TestDateToJSON(t *testing.T) {
 	testScript(SCRIPT, intToValue(1), t)
 }
 
+func TestDateToPrimitive(t *testing.T) {
+	const SCRIPT = `
+	var d = new Date(0);
+	assert.sameValue(typeof (d + 1), "string", "default hint");
+	assert.sameValue(d - 1, -1, "number hint");
+	assert.sameValue(d[Symbol.toPrimitive]("default"), d.toString());
+	assert.sameValue(d[Symbol.toPrimitive]("number"), 0);
+	assert.throws(TypeError, function() { d[Symbol.toPrimitive]("invalid"); });
+	assert.throws(TypeError, function() { Date.prototype[Symbol.toPrimitive].call(0, "number"); });
+
+	// without Symbol.toPrimitive the default hint is treated as "number"
+	delete Date.prototype[Symbol.toPrimitive];
+	assert.sameValue(d + 1, 1, "default hint (ordinary)");
+	assert.sameValue(String(d), d.toString(), "string hint (ordinary)");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestDateExportType(t *testing.T) {
 	vm := New()
 	v, err := vm.RunString(`new Date()`)
@@ -0,0 +1,324 @@
+package goja
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/dop251/goja/unistring"
+)
+
+// SerializedError is a representation of a script error which can be shipped across process boundaries, see
+// SerializeError() and DeserializeError(). It implements error, so it can be handled in Go without a Runtime, or it
+// can be turned back into a JavaScript value with Runtime.NewErrorFromSerialized().
+type SerializedError struct {
+	// Name is the name of the error, e.g. "TypeError". It is empty if the thrown value is not an error, in which case
+	// the value is in Value and its string representation is in Message.
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Stack is the stack trace in the format of the 'stack' property of the errors.
+	Stack string `json:"stack,omitempty"`
+	// Cause is the 'cause' property of the error. A cause which refers to one of the errors it is the cause of (i.e.
+	// a circular chain) is omitted.
+	Cause *SerializedError `json:"cause,omitempty"`
+	// Errors is the 'errors' property of an AggregateError.
+	Errors []*SerializedError `json:"errors,omitempty"`
+	// Err and Suppressed are the 'error' and the 'suppressed' properties of a SuppressedError.
+	Err        *SerializedError `json:"error,omitempty"`
+	Suppressed *SerializedError `json:"suppressed,omitempty"`
+	// Props are the other own enumerable properties of the error encoded as JSON. The properties whose values cannot
+	// be represented in JSON (such as functions) are omitted.
+	Props map[string]json.RawMessage `json:"props,omitempty"`
+	// Value is the thrown value encoded as JSON if it is not an error. It is nil if the value cannot be represented
+	// in JSON (e.g. a symbol), except for undefined which is marked by Undefined.
+	Value     json.RawMessage `json:"value,omitempty"`
+	Undefined bool            `json:"undefined,omitempty"`
+}
+
+// Error returns the name and the message of the error in the format of Error.prototype.toString(), or the message
+// if the thrown value is not an error.
+func (e *SerializedError) Error() string {
+	switch {
+	case e.Name == "":
+		return e.Message
+	case e.Message == "":
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// Unwrap returns the cause of the error, if any.
+func (e *SerializedError) Unwrap() error {
+	if e.Cause == nil {
+		return nil
+	}
+	return e.Cause
+}
+
+// SerializeError encodes the error as JSON (see SerializedError). If err is an *Exception the thrown value is
+// encoded along with the errors it refers to. Other errors are encoded as GoErrors (InterruptedError and
+// the compilation errors are encoded as InterruptedError, SyntaxError and ReferenceError respectively), with
+// the chain of the wrapped errors as the cause.
+//
+// The properties of the thrown value are read using the Runtime it belongs to, so SerializeError must be called
+// from the vm goroutine or when the vm is not running. Exceptions thrown by the getters are ignored.
+func SerializeError(err error) []byte {
+	data, _ := json.Marshal(newSerializedError(err))
+	return data
+}
+
+// DeserializeError decodes an error encoded by SerializeError().
+func DeserializeError(data []byte) (*SerializedError, error) {
+	var e SerializedError
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func newSerializedError(err error) *SerializedError {
+	switch err := err.(type) {
+	case nil:
+		return nil
+	case *SerializedError:
+		return err
+	case *Exception:
+		if err.val == nil {
+			return &SerializedError{Name: "Error", Message: err.Error()}
+		}
+		var e *SerializedError
+		if o, ok := err.val.(*Object); ok {
+			e = o.runtime.serializeValue(o, make(map[*Object]struct{}))
+		} else {
+			e = serializePrimitive(err.val)
+		}
+		if e.Stack == "" {
+			e.Stack = err.String()
+		}
+		return e
+	case *InterruptedError:
+		e := &SerializedError{
+			Name:  "InterruptedError",
+			Stack: err.String(),
+		}
+		if err.iface != nil {
+			e.Message = fmt.Sprint(err.iface)
+		}
+		if wrapped, ok := err.iface.(error); ok {
+			e.Cause = newSerializedError(wrapped)
+		}
+		return e
+	case *CompilerSyntaxError:
+		return &SerializedError{Name: "SyntaxError", Message: err.Error()}
+	case *CompilerReferenceError:
+		return &SerializedError{Name: "ReferenceError", Message: err.Error()}
+	}
+	return &SerializedError{
+		Name:    "GoError",
+		Message: err.Error(),
+		Cause:   newSerializedError(errors.Unwrap(err)),
+	}
+}
+
+func serializePrimitive(v Value) *SerializedError {
+	e := &SerializedError{Message: v.String()}
+	switch v := v.(type) {
+	case valueString:
+		e.Value, _ = json.Marshal(v.String())
+	case valueInt:
+		e.Value = strconv.AppendInt(nil, int64(v), 10)
+	case valueFloat:
+		if f := float64(v); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			e.Value = strconv.AppendFloat(nil, f, 'g', -1, 64)
+		}
+	case valueBool:
+		e.Value = strconv.AppendBool(nil, bool(v))
+	case valueNull:
+		e.Value = json.RawMessage("null")
+	case valueUndefined:
+		e.Undefined = true
+	}
+	return e
+}
+
+// serializeValue serialises a value referred to by an error. seen contains the errors being serialised, to break
+// circular chains.
+func (r *Runtime) serializeValue(v Value, seen map[*Object]struct{}) *SerializedError {
+	o, ok := v.(*Object)
+	if !ok {
+		return serializePrimitive(v)
+	}
+	if _, isError := o.self.(*errorObject); !isError {
+		e := &SerializedError{Value: r.toJSON(o)}
+		_ = r.vm.try(func() {
+			e.Message = o.String()
+		})
+		return e
+	}
+	if _, exists := seen[o]; exists {
+		return nil
+	}
+	seen[o] = struct{}{}
+	defer delete(seen, o)
+
+	e := &SerializedError{Name: "Error"}
+	handled := map[unistring.String]bool{"name": true, "message": true, propNameStack: true}
+	get := func(name unistring.String) (res Value) {
+		_ = r.vm.try(func() {
+			res = o.self.getStr(name, nil)
+		})
+		return
+	}
+	if name := get("name"); name != nil && name != _undefined {
+		e.Name = name.String()
+	}
+	if msg := get("message"); msg != nil && msg != _undefined {
+		e.Message = msg.String()
+	}
+	if stack, ok := get(propNameStack).(valueString); ok {
+		e.Stack = stack.String()
+	}
+	if o.self.hasOwnPropertyStr("cause") {
+		handled["cause"] = true
+		e.Cause = r.serializeValue(nilSafe(get("cause")), seen)
+	}
+	if errs, ok := get("errors").(*Object); ok && o.self.hasOwnPropertyStr("errors") && isArray(errs) {
+		handled["errors"] = true
+		_ = r.vm.try(func() {
+			for _, item := range r.iterableToList(errs, nil) {
+				if item := r.serializeValue(item, seen); item != nil {
+					e.Errors = append(e.Errors, item)
+				}
+			}
+		})
+	}
+	if o.self.hasOwnPropertyStr("error") && o.self.hasOwnPropertyStr("suppressed") {
+		handled["error"], handled["suppressed"] = true, true
+		e.Err = r.serializeValue(nilSafe(get("error")), seen)
+		e.Suppressed = r.serializeValue(nilSafe(get("suppressed")), seen)
+	}
+
+	for _, key := range o.self.stringKeys(false, nil) {
+		name := key.string()
+		if handled[name] {
+			continue
+		}
+		val := get(name)
+		if obj, ok := val.(*Object); ok {
+			if w, ok := obj.self.(*objectGoReflect); ok {
+				if _, isGoError := w.origValue.Interface().(error); isGoError {
+					// the 'value' of a GoError, it has no meaningful JSON representation
+					continue
+				}
+			}
+		}
+		if data := r.toJSON(nilSafe(val)); data != nil {
+			if e.Props == nil {
+				e.Props = make(map[string]json.RawMessage)
+			}
+			e.Props[name.String()] = data
+		}
+	}
+	return e
+}
+
+// toJSON returns the result of JSON.stringify(v) or nil if v cannot be represented in JSON (including when
+// an exception is thrown).
+func (r *Runtime) toJSON(v Value) (res json.RawMessage) {
+	ctx := _builtinJSON_stringifyContext{
+		r: r,
+	}
+	if ex := r.vm.try(func() {
+		if ctx.do(v) {
+			res = ctx.buf.Bytes()
+		}
+	}); ex != nil {
+		return nil
+	}
+	return
+}
+
+// NewErrorFromSerialized creates the JavaScript value described by the SerializedError. The errors are created
+// with the constructor matching their name if it is one of the standard ones (or GoError), otherwise they are
+// created as instances of Error with an own 'name' property. The stack trace is restored as the 'stack' property.
+// If e is nil, the result is undefined.
+func (r *Runtime) NewErrorFromSerialized(e *SerializedError) Value {
+	if e == nil {
+		return _undefined
+	}
+	if e.Name == "" {
+		if e.Undefined {
+			return _undefined
+		}
+		if e.Value != nil {
+			if v, err := r.builtinJSON_decodeValue(json.NewDecoder(bytes.NewReader(e.Value))); err == nil {
+				return v
+			}
+		}
+		return newStringValue(e.Message)
+	}
+
+	var proto *Object
+	switch e.Name {
+	case "EvalError":
+		proto = r.global.EvalErrorPrototype
+	case "RangeError":
+		proto = r.global.RangeErrorPrototype
+	case "ReferenceError":
+		proto = r.global.ReferenceErrorPrototype
+	case "SyntaxError":
+		proto = r.global.SyntaxErrorPrototype
+	case "TypeError":
+		proto = r.global.TypeErrorPrototype
+	case "URIError":
+		proto = r.global.URIErrorPrototype
+	case "AggregateError":
+		proto = r.global.AggregateErrorPrototype
+	case "SuppressedError":
+		proto = r.global.SuppressedErrorPrototype
+	case "GoError":
+		proto = r.global.GoErrorPrototype
+	default:
+		proto = r.global.ErrorPrototype
+	}
+	o := r.newErrorObject(proto, classError)
+	if proto == r.global.ErrorPrototype && e.Name != "Error" {
+		o._putProp("name", newStringValue(e.Name), true, false, true)
+	}
+	if e.Message != "" {
+		o._putProp("message", newStringValue(e.Message), true, false, true)
+	}
+	if e.Stack != "" {
+		o._putProp(propNameStack, newStringValue(e.Stack), true, false, true)
+		o.stackPropAdded = true
+	}
+	if e.Cause != nil {
+		o._putProp("cause", r.NewErrorFromSerialized(e.Cause), true, false, true)
+	}
+	if e.Errors != nil {
+		errs := make([]Value, len(e.Errors))
+		for i, item := range e.Errors {
+			errs[i] = r.NewErrorFromSerialized(item)
+		}
+		o._putProp("errors", r.newArrayValues(errs), true, false, true)
+	}
+	if e.Err != nil || e.Suppressed != nil {
+		o._putProp("error", r.NewErrorFromSerialized(e.Err), true, false, true)
+		o._putProp("suppressed", r.NewErrorFromSerialized(e.Suppressed), true, false, true)
+	}
+	names := make([]string, 0, len(e.Props))
+	for name := range e.Props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v, err := r.builtinJSON_decodeValue(json.NewDecoder(bytes.NewReader(e.Props[name]))); err == nil {
+			o._putProp(unistring.NewFromString(name), v, true, true, true)
+		}
+	}
+	return o.val
+}
@@ -0,0 +1,166 @@
+package goja
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSerializeError(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	class ValidationError extends Error {
+		constructor(message) {
+			super(message);
+			this.name = "ValidationError";
+			this.field = "email";
+			this.details = {min: 1, tags: ["a"]};
+			this.fn = function() {};
+		}
+	}
+	function validate() {
+		try {
+			JSON.parse("{");
+		} catch (e) {
+			const err = new ValidationError("invalid input");
+			err.cause = e;
+			throw err;
+		}
+	}
+	validate();
+	`)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	data := SerializeError(err)
+	e, err1 := DeserializeError(data)
+	if err1 != nil {
+		t.Fatal(err1)
+	}
+	if e.Name != "ValidationError" || e.Message != "invalid input" || !strings.Contains(e.Stack, "at validate") {
+		t.Fatalf("Unexpected error: %s", data)
+	}
+	if e.Cause == nil || e.Cause.Name != "SyntaxError" || e.Cause.Cause != nil {
+		t.Fatalf("Unexpected cause: %s", data)
+	}
+	if len(e.Props) != 2 || string(e.Props["field"]) != `"email"` || string(e.Props["details"]) != `{"min":1,"tags":["a"]}` {
+		t.Fatalf("Unexpected props: %s", data)
+	}
+	if e.Error() != "ValidationError: invalid input" {
+		t.Fatalf("Unexpected Error(): %q", e.Error())
+	}
+	var cause *SerializedError
+	if !errors.As(errors.Unwrap(e), &cause) || cause != e.Cause {
+		t.Fatal("The cause is not unwrapped")
+	}
+
+	// round-trip through another Runtime
+	vm1 := New()
+	_ = vm1.Set("e", vm1.NewErrorFromSerialized(e))
+	_, err = vm1.RunString(`
+	if (!(e instanceof Error) || e.name !== "ValidationError" || e.message !== "invalid input") {
+		throw new Error("unexpected error: " + e);
+	}
+	if (!e.stack.includes("at validate") || Object.keys(e).join() !== "details,field" || e.details.tags[0] !== "a") {
+		throw new Error("unexpected properties: " + e.stack + Object.keys(e));
+	}
+	if (!(e.cause instanceof SyntaxError)) {
+		throw new Error("unexpected cause: " + e.cause);
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSerializeErrorComposite(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	const a = new Error("a");
+	const b = new TypeError("b");
+	a.cause = b;
+	b.cause = a;
+	const agg = new AggregateError([a, "str", 42], "many");
+	throw new SuppressedError(agg, undefined, "suppressed");
+	`)
+	data := SerializeError(err)
+	e, err1 := DeserializeError(data)
+	if err1 != nil {
+		t.Fatal(err1)
+	}
+	if e.Name != "SuppressedError" || e.Err == nil || e.Err.Name != "AggregateError" || e.Suppressed == nil ||
+		!e.Suppressed.Undefined {
+		t.Fatalf("Unexpected error: %s", data)
+	}
+	errs := e.Err.Errors
+	if len(errs) != 3 || errs[0].Cause == nil || errs[0].Cause.Name != "TypeError" || errs[0].Cause.Cause != nil {
+		t.Fatalf("Unexpected errors: %s", data)
+	}
+	if errs[1].Name != "" || string(errs[1].Value) != `"str"` || string(errs[2].Value) != "42" {
+		t.Fatalf("Unexpected errors: %s", data)
+	}
+
+	vm1 := New()
+	_ = vm1.Set("e", vm1.NewErrorFromSerialized(e))
+	_, err = vm1.RunString(`
+	if (!(e instanceof SuppressedError) || !(e.error instanceof AggregateError) || e.suppressed !== undefined) {
+		throw new Error("unexpected error: " + e);
+	}
+	const [a, s, n] = e.error.errors;
+	if (a.message !== "a" || !(a.cause instanceof TypeError) || s !== "str" || n !== 42) {
+		throw new Error("unexpected errors: " + e.error.errors);
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSerializeErrorNonScript(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`throw "oops"`)
+	e, _ := DeserializeError(SerializeError(err))
+	if e.Name != "" || string(e.Value) != `"oops"` || !strings.Contains(e.Stack, "oops") {
+		t.Fatalf("Unexpected error: %+v", e)
+	}
+	if v := vm.NewErrorFromSerialized(e); v.Export() != "oops" {
+		t.Fatalf("Unexpected value: %v", v)
+	}
+
+	_ = vm.Set("fail", func() error {
+		return errors.New("host failure")
+	})
+	_, err = vm.RunString(`fail()`)
+	e, _ = DeserializeError(SerializeError(err))
+	if e.Name != "GoError" || e.Message != "host failure" || e.Props != nil {
+		t.Fatalf("Unexpected error: %+v", e)
+	}
+
+	_, err = vm.RunString(`throw undefined`)
+	e, _ = DeserializeError(SerializeError(err))
+	if !e.Undefined || vm.NewErrorFromSerialized(e) != _undefined {
+		t.Fatalf("Unexpected error: %+v", e)
+	}
+
+	_, err = vm.RunString(`
+	const e = new Error("getter");
+	e.x = {get y() { throw new Error("thrown by the getter"); }};
+	e.z = 1;
+	throw e;
+	`)
+	e, _ = DeserializeError(SerializeError(err))
+	if e.Message != "getter" || len(e.Props) != 1 || string(e.Props["z"]) != "1" {
+		t.Fatalf("Unexpected error: %+v", e)
+	}
+
+	wrapped := fmt.Errorf("wrapped: %w", errors.New("inner"))
+	e, _ = DeserializeError(SerializeError(wrapped))
+	if e.Name != "GoError" || e.Message != "wrapped: inner" || e.Cause == nil || e.Cause.Message != "inner" {
+		t.Fatalf("Unexpected error: %+v", e)
+	}
+
+	if _, err := DeserializeError([]byte("{")); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
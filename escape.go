@@ -0,0 +1,194 @@
+package goja
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// stringEscaper replaces characters of a string with escape sequences.
+type stringEscaper struct {
+	// replacements of ASCII characters, the empty string means the character is kept
+	ascii [utf8.RuneSelf]string
+	// returns the replacement for a non-ASCII UTF-16 code unit, nil means such characters are kept
+	other func(c uint16) string
+}
+
+func newStringEscaper(setup func(e *stringEscaper)) *stringEscaper {
+	e := &stringEscaper{}
+	setup(e)
+	return e
+}
+
+func (e *stringEscaper) replacement(c rune) string {
+	if c < utf8.RuneSelf {
+		return e.ascii[c]
+	}
+	if e.other != nil {
+		return e.other(uint16(c))
+	}
+	return ""
+}
+
+// escape returns s with the characters replaced. If nothing needs to be replaced s is returned unchanged.
+func (e *stringEscaper) escape(s valueString) valueString {
+	a, u := devirtualizeString(s)
+	if u == nil {
+		for i := 0; i < len(a); i++ {
+			if e.ascii[a[i]] != "" {
+				return e.escapeASCII(a, i)
+			}
+		}
+		return s
+	}
+	l := u.length()
+	for i := 0; i < l; i++ {
+		if e.replacement(u.charAt(i)) != "" {
+			var b valueStringBuilder
+			b.Grow(l + l/8)
+			b.WriteSubstring(u, 0, i)
+			start := i
+			for ; i < l; i++ {
+				if r := e.replacement(u.charAt(i)); r != "" {
+					b.WriteSubstring(u, start, i)
+					b.WriteASCII(r)
+					start = i + 1
+				}
+			}
+			b.WriteSubstring(u, start, l)
+			return b.String()
+		}
+	}
+	return s
+}
+
+func (e *stringEscaper) escapeASCII(s asciiString, i int) valueString {
+	var b strings.Builder
+	b.Grow(len(s) + len(s)/8)
+	b.WriteString(string(s[:i]))
+	start := i
+	for ; i < len(s); i++ {
+		if r := e.ascii[s[i]]; r != "" {
+			b.WriteString(string(s[start:i]))
+			b.WriteString(r)
+			start = i + 1
+		}
+	}
+	b.WriteString(string(s[start:]))
+	return asciiString(b.String())
+}
+
+var (
+	htmlEscaper = newStringEscaper(func(e *stringEscaper) {
+		e.ascii['&'] = "&amp;"
+		e.ascii['<'] = "&lt;"
+		e.ascii['>'] = "&gt;"
+		e.ascii['"'] = "&#34;"
+		e.ascii['\''] = "&#39;"
+	})
+
+	attrEscaper = newStringEscaper(func(e *stringEscaper) {
+		for c := 0; c < utf8.RuneSelf; c++ {
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+				e.ascii[c] = "&#x" + string(hexUpper[c>>4]) + string(hexUpper[c&15]) + ";"
+			}
+		}
+		e.ascii['&'] = "&amp;"
+		e.ascii['<'] = "&lt;"
+		e.ascii['>'] = "&gt;"
+	})
+
+	jsEscaper = newStringEscaper(func(e *stringEscaper) {
+		for c := 0; c < 0x20; c++ {
+			e.ascii[c] = `\x` + string(hexUpper[c>>4]) + string(hexUpper[c&15])
+		}
+		e.ascii['\b'] = `\b`
+		e.ascii['\t'] = `\t`
+		e.ascii['\n'] = `\n`
+		e.ascii['\v'] = `\v`
+		e.ascii['\f'] = `\f`
+		e.ascii['\r'] = `\r`
+		e.ascii['\\'] = `\\`
+		for _, c := range "\"'`$&<>=/\x7f" {
+			e.ascii[c] = `\x` + string(hexUpper[c>>4]) + string(hexUpper[c&15])
+		}
+		e.other = func(c uint16) string {
+			switch c {
+			case 0x2028:
+				return `\u2028`
+			case 0x2029:
+				return `\u2029`
+			}
+			return ""
+		}
+	})
+
+	// RFC 3986 unreserved characters
+	uriComponentUnreserved [256]bool
+)
+
+func init() {
+	for _, c := range "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.~" {
+		uriComponentUnreserved[c] = true
+	}
+}
+
+// NewEscapeNamespace creates an object with native functions escaping strings for inclusion in HTML, URLs and
+// JavaScript code. Templating scripts spend much of their time escaping the values they output, and hand-written
+// escape loops are both slow and easy to get wrong, so hosts can expose this object instead:
+//
+//	vm.Set("esc", vm.NewEscapeNamespace())
+//
+// All methods convert their argument to a string and return it unchanged (without copying) if there is nothing
+// to escape:
+//
+//   - html(s) replaces &, <, >, " and ' with character references. The result is safe in element content and
+//     in quoted attribute values. When used as a template tag (esc.html`<b>${name}</b>`) it applies the escaping
+//     to the substitutions only.
+//   - attr(s) replaces all ASCII characters except letters and digits with character references, so the result
+//     is also safe in unquoted attribute values.
+//   - url(s) percent-encodes s as a URI component. Unlike encodeURIComponent() it only leaves the unreserved
+//     characters of RFC 3986 unencoded (i.e. !, ', (, ) and * are encoded as well). Lone surrogates throw a
+//     URIError.
+//   - js(s) escapes s for inclusion in a JavaScript string or template literal, including inside an HTML <script>
+//     element or attribute: quotes, backticks, backslashes, $, &, <, >, =, /, control characters and line
+//     terminators are replaced with escape sequences.
+func (r *Runtime) NewEscapeNamespace() *Object {
+	o := r.NewObject()
+	o.self._putProp("html", r.newNativeFunc(func(call FunctionCall) Value {
+		if isTaggedTemplateCall(call.Arguments) {
+			return r.escapeHTMLTemplate(r.toTaggedTemplate(call.Arguments))
+		}
+		return htmlEscaper.escape(call.Argument(0).toString())
+	}, nil, "html", nil, 1), true, false, true)
+	o.self._putProp("attr", r.newNativeFunc(func(call FunctionCall) Value {
+		return attrEscaper.escape(call.Argument(0).toString())
+	}, nil, "attr", nil, 1), true, false, true)
+	o.self._putProp("url", r.newNativeFunc(func(call FunctionCall) Value {
+		return r._encode(call.Argument(0).toString(), &uriComponentUnreserved)
+	}, nil, "url", nil, 1), true, false, true)
+	o.self._putProp("js", r.newNativeFunc(func(call FunctionCall) Value {
+		return jsEscaper.escape(call.Argument(0).toString())
+	}, nil, "js", nil, 1), true, false, true)
+	return o
+}
+
+func (r *Runtime) escapeHTMLTemplate(t *TaggedTemplate) Value {
+	var b valueStringBuilder
+	for i, s := range t.Strings {
+		if i > 0 {
+			b.WriteString(htmlEscaper.escape(t.Values[i-1].toString()))
+		}
+		b.WriteString(newStringValue(s))
+	}
+	return b.String()
+}
+
+func isTaggedTemplateCall(args []Value) bool {
+	if len(args) > 0 {
+		if obj, ok := args[0].(*Object); ok {
+			_, ok = obj.self.(*taggedTemplateArray)
+			return ok
+		}
+	}
+	return false
+}
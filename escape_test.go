@@ -0,0 +1,39 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestEscapeNamespace(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(esc.html("plain text"), "plain text");
+	assert.sameValue(esc.html("<a href=\"x\" title='y'>&</a>"), "&lt;a href=&#34;x&#34; title=&#39;y&#39;&gt;&amp;&lt;/a&gt;");
+	assert.sameValue(esc.html("привет <b>"), "привет &lt;b&gt;");
+	assert.sameValue(esc.html(42), "42");
+
+	const name = "<script>";
+	assert.sameValue(esc.html` + "`<p title=\"${name}\">${name} & ${1 + 1}</p>`" + `, '<p title="&lt;script&gt;">&lt;script&gt; & 2</p>');
+
+	assert.sameValue(esc.attr("a b=c"), "a&#x20;b&#x3D;c");
+	assert.sameValue(esc.attr("x<y>&"), "x&lt;y&gt;&amp;");
+	assert.sameValue(esc.attr("ключ"), "ключ");
+
+	assert.sameValue(esc.url("a b&c=d/é!'()*~"), "a%20b%26c%3Dd%2F%C3%A9%21%27%28%29%2A~");
+	assert.throws(URIError, () => esc.url("\uD800"));
+
+	assert.sameValue(esc.js("it's \"quoted\"\n</script>\\"), "it\\x27s \\x22quoted\\x22\\n\\x3C\\x2Fscript\\x3E\\\\");
+	assert.sameValue(esc.js("\u2028\u2029ы\x60${x}"), "\\u2028\\u2029ы\\x60\\x24{x}");
+	const s = "any \u0000 'string' \u2028 \\ ы";
+	assert.sameValue(eval("'" + esc.js(s) + "'"), s);
+	`
+	r := New()
+	r.Set("esc", r.NewEscapeNamespace())
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func BenchmarkEscapeHTML(b *testing.B) {
+	s := asciiString("<div class=\"item\">Some text with an ampersand & and a quote ' in it</div>")
+	for i := 0; i < b.N; i++ {
+		htmlEscaper.escape(s)
+	}
+}
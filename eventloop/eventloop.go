@@ -0,0 +1,306 @@
+// Package eventloop runs the event loops of many goja Runtimes on a single goroutine.
+//
+// Each Runtime is attached to a Loop created by a Scheduler. The tasks of all the Loops of a Scheduler (the timer
+// callbacks and the functions posted with RunOnLoop, e.g. when an I/O operation started by the script completes) are
+// executed one at a time on the goroutine of the Scheduler. The Loops which have tasks queued take turns in a round
+// robin fashion: a Loop runs a single task (along with the Promise jobs it has triggered) and then yields to the next
+// one, so a Runtime which keeps posting tasks cannot starve the others. An idle Loop does not occupy a goroutine,
+// which makes it possible to keep tens of thousands of mostly idle scripts on a handful of goroutines:
+//
+//	s := eventloop.NewScheduler(eventloop.WithQueueCap(100))
+//	defer s.Close()
+//	for _, script := range scripts {
+//	    loop := s.NewLoop(goja.New())
+//	    script := script
+//	    loop.RunOnLoop(func(vm *goja.Runtime) {
+//	        if _, err := vm.RunString(script); err != nil {
+//	            log.Print(err)
+//	        }
+//	    })
+//	}
+//
+// NewLoop defines setTimeout, setInterval, clearTimeout and clearInterval in the Runtime. Once a Runtime is
+// attached to a Loop it must only be used from within the tasks.
+//
+// Tasks must not block: a task which does not return (e.g. because the script is stuck in an infinite loop) stalls
+// all the Loops of the Scheduler, use Runtime.Interrupt to guard against it. Deployments which need more than one
+// CPU can create a Scheduler per CPU and distribute the Runtimes among them.
+package eventloop
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+var (
+	// ErrQueueFull is returned by RunOnLoop when the Loop already has the maximum number of tasks queued, see
+	// WithQueueCap.
+	ErrQueueFull = errors.New("the task queue of the loop is full")
+	// ErrLoopClosed is returned by RunOnLoop when the Loop or its Scheduler has been closed.
+	ErrLoopClosed = errors.New("the loop has been closed")
+)
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithQueueCap limits the number of tasks posted with RunOnLoop which may be queued in a single Loop. The timer
+// callbacks are not subject to the limit. The default is 1024, zero or negative means no limit.
+func WithQueueCap(n int) Option {
+	return func(s *Scheduler) {
+		s.queueCap = n
+	}
+}
+
+// WithErrorHandler sets the function which is called when a timer callback throws or a task panics. It is called
+// on the goroutine of the Scheduler. By default the errors are logged to the standard logger.
+func WithErrorHandler(f func(l *Loop, err error)) Option {
+	return func(s *Scheduler) {
+		s.errorHandler = f
+	}
+}
+
+// Scheduler runs the tasks of its Loops on a single goroutine.
+type Scheduler struct {
+	queueCap     int
+	errorHandler func(l *Loop, err error)
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	ready  []*Loop // the loops which have tasks queued, in the order they are going to run
+	closed bool
+	done   chan struct{}
+}
+
+// Loop is the event loop of a single Runtime, see Scheduler.NewLoop.
+type Loop struct {
+	s  *Scheduler
+	vm *goja.Runtime
+
+	// protected by s.mu
+	queue  []func()
+	ready  bool // the loop is in s.ready
+	closed bool
+
+	// the fields below are only accessed on the goroutine of the scheduler
+	timers  map[int64]*timer
+	timerID int64
+}
+
+type timer struct {
+	id       int64
+	fn       goja.Callable
+	args     []goja.Value
+	delay    time.Duration
+	interval bool
+	canceled bool
+	t        *time.Timer
+}
+
+// NewScheduler creates a Scheduler and starts its goroutine.
+func NewScheduler(opts ...Option) *Scheduler {
+	s := &Scheduler{
+		queueCap: 1024,
+		errorHandler: func(l *Loop, err error) {
+			log.Printf("eventloop: %v", err)
+		},
+		done: make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	for _, opt := range opts {
+		opt(s)
+	}
+	go s.run()
+	return s
+}
+
+// Close stops the Scheduler once the currently running task (if any) returns and waits for its goroutine to exit.
+// The tasks which are still queued are dropped and RunOnLoop fails with ErrLoopClosed for all of its Loops. Close
+// must not be called from within a task.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	s.closed = true
+	for _, l := range s.ready {
+		l.queue = nil
+		l.ready = false
+	}
+	s.ready = nil
+	s.cond.Signal()
+	s.mu.Unlock()
+	<-s.done
+}
+
+// NewLoop attaches the Runtime to a new Loop and defines the timer functions in it. It must be called when the
+// Runtime is not running.
+func (s *Scheduler) NewLoop(vm *goja.Runtime) *Loop {
+	l := &Loop{
+		s:      s,
+		vm:     vm,
+		timers: make(map[int64]*timer),
+	}
+	clear := func(call goja.FunctionCall) goja.Value {
+		l.clearTimer(call.Argument(0).ToInteger())
+		return goja.Undefined()
+	}
+	_ = vm.Set("setTimeout", l.setTimer(false))
+	_ = vm.Set("setInterval", l.setTimer(true))
+	_ = vm.Set("clearTimeout", clear)
+	_ = vm.Set("clearInterval", clear)
+	return l
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		for len(s.ready) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+		l := s.ready[0]
+		s.ready[0] = nil
+		s.ready = s.ready[1:]
+		task := l.queue[0]
+		l.queue[0] = nil
+		l.queue = l.queue[1:]
+		if len(l.queue) > 0 {
+			s.ready = append(s.ready, l)
+		} else {
+			l.queue = nil
+			l.ready = false
+		}
+		s.mu.Unlock()
+		l.runTask(task)
+	}
+}
+
+// RunOnLoop posts a task which calls fn with the Runtime of the Loop. It is goroutine-safe and may be called from
+// within a task, in which case the new task runs after the other Loops have had their turn.
+func (l *Loop) RunOnLoop(fn func(vm *goja.Runtime)) error {
+	return l.enqueue(func() {
+		fn(l.vm)
+	}, true)
+}
+
+// Close detaches the Loop from its Scheduler: the queued tasks are dropped, the pending timers are cancelled and
+// RunOnLoop fails with ErrLoopClosed. It is goroutine-safe.
+func (l *Loop) Close() {
+	s := l.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l.closed || s.closed {
+		l.closed = true
+		return
+	}
+	l.closed = true
+	// the timers belong to the goroutine of the scheduler, so they are stopped by a task which replaces the queue
+	l.queue = append(l.queue[:0], l.stopTimers)
+	l.schedule()
+}
+
+func (l *Loop) enqueue(task func(), capped bool) error {
+	s := l.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l.closed || s.closed {
+		return ErrLoopClosed
+	}
+	if capped && s.queueCap > 0 && len(l.queue) >= s.queueCap {
+		return ErrQueueFull
+	}
+	l.queue = append(l.queue, task)
+	l.schedule()
+	return nil
+}
+
+// schedule puts the loop at the end of the ready list unless it is there already. It must be called with s.mu held.
+func (l *Loop) schedule() {
+	if !l.ready {
+		l.ready = true
+		l.s.ready = append(l.s.ready, l)
+		l.s.cond.Signal()
+	}
+}
+
+func (l *Loop) runTask(task func()) {
+	defer func() {
+		if x := recover(); x != nil {
+			err, ok := x.(error)
+			if !ok {
+				err = fmt.Errorf("%v", x)
+			}
+			l.s.errorHandler(l, fmt.Errorf("task panicked: %w", err))
+		}
+	}()
+	task()
+}
+
+func (l *Loop) setTimer(interval bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		fn, ok := goja.AssertFunction(call.Argument(0))
+		if !ok {
+			panic(l.vm.NewTypeError("The callback is not a function"))
+		}
+		d := call.Argument(1).ToFloat()
+		if math.IsNaN(d) || d < 0 {
+			d = 0
+		}
+		var args []goja.Value
+		if len(call.Arguments) > 2 {
+			args = append(args, call.Arguments[2:]...)
+		}
+		l.timerID++
+		t := &timer{
+			id:       l.timerID,
+			fn:       fn,
+			args:     args,
+			delay:    time.Duration(math.Min(d, float64(math.MaxInt64/time.Millisecond))) * time.Millisecond,
+			interval: interval,
+		}
+		l.timers[t.id] = t
+		t.t = time.AfterFunc(t.delay, func() {
+			_ = l.enqueue(func() {
+				l.fire(t)
+			}, false)
+		})
+		return l.vm.ToValue(t.id)
+	}
+}
+
+func (l *Loop) fire(t *timer) {
+	if t.canceled {
+		return
+	}
+	if t.interval {
+		t.t.Reset(t.delay)
+	} else {
+		delete(l.timers, t.id)
+	}
+	if _, err := t.fn(nil, t.args...); err != nil {
+		l.s.errorHandler(l, err)
+	}
+}
+
+func (l *Loop) clearTimer(id int64) {
+	if t, exists := l.timers[id]; exists {
+		t.canceled = true
+		t.t.Stop()
+		delete(l.timers, id)
+	}
+}
+
+func (l *Loop) stopTimers() {
+	for _, t := range l.timers {
+		t.canceled = true
+		t.t.Stop()
+	}
+	l.timers = make(map[int64]*timer)
+}
@@ -0,0 +1,230 @@
+package eventloop
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// block posts a task which blocks the scheduler until the returned function is called, so that the tasks posted
+// in the meantime are queued.
+func block(t *testing.T, s *Scheduler) func() {
+	t.Helper()
+	gate := make(chan struct{})
+	if err := s.NewLoop(goja.New()).RunOnLoop(func(*goja.Runtime) {
+		<-gate
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		close(gate)
+	}
+}
+
+// wait waits until the scheduler has run all the queued tasks.
+func wait(t *testing.T, s *Scheduler) {
+	t.Helper()
+	loop := s.NewLoop(goja.New())
+	for {
+		idle := make(chan bool)
+		if err := loop.RunOnLoop(func(*goja.Runtime) {
+			s.mu.Lock()
+			idle <- len(s.ready) == 0
+			s.mu.Unlock()
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if <-idle {
+			return
+		}
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	s := NewScheduler()
+	defer s.Close()
+
+	var order []string
+	release := block(t, s)
+	for _, l := range []struct {
+		name  string
+		tasks int
+	}{{"a", 5}, {"b", 2}, {"c", 1}} {
+		loop := s.NewLoop(goja.New())
+		for i := 1; i <= l.tasks; i++ {
+			name := fmt.Sprintf("%s%d", l.name, i)
+			if err := loop.RunOnLoop(func(*goja.Runtime) {
+				order = append(order, name)
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	release()
+	wait(t, s)
+
+	expected := []string{"a1", "b1", "c1", "a2", "b2", "a3", "a4", "a5"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("Unexpected order: %v", order)
+	}
+}
+
+func TestQueueCap(t *testing.T) {
+	s := NewScheduler(WithQueueCap(2))
+	defer s.Close()
+
+	var count int32
+	task := func(*goja.Runtime) {
+		atomic.AddInt32(&count, 1)
+	}
+	loop := s.NewLoop(goja.New())
+	other := s.NewLoop(goja.New())
+	release := block(t, s)
+	for i := 0; i < 2; i++ {
+		if err := loop.RunOnLoop(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := loop.RunOnLoop(task); err != ErrQueueFull {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := other.RunOnLoop(task); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	wait(t, s)
+	if c := atomic.LoadInt32(&count); c != 3 {
+		t.Fatalf("Unexpected count: %d", c)
+	}
+	if err := loop.RunOnLoop(task); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTimers(t *testing.T) {
+	const SCRIPT = `
+	let ticks = 0;
+	const id = setInterval(function() {
+		if (++ticks === 3) {
+			clearInterval(id);
+			setTimeout(function(a, b) {
+				done(a + b + ticks);
+			}, 1, "x", "y");
+		}
+	}, 1);
+	const cancelled = setTimeout(function() {
+		throw new Error("cancelled timer has fired");
+	}, 1);
+	clearTimeout(cancelled);
+	`
+	const loops = 1000
+	s := NewScheduler(WithErrorHandler(func(l *Loop, err error) {
+		t.Error(err)
+	}))
+	defer s.Close()
+
+	results := make(chan string, loops)
+	for i := 0; i < loops; i++ {
+		vm := goja.New()
+		_ = vm.Set("done", func(s string) {
+			results <- s
+		})
+		if err := s.NewLoop(vm).RunOnLoop(func(vm *goja.Runtime) {
+			if _, err := vm.RunString(SCRIPT); err != nil {
+				t.Error(err)
+			}
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < loops; i++ {
+		if res := <-results; res != "xy3" {
+			t.Fatalf("Unexpected result: %q", res)
+		}
+	}
+}
+
+func TestLoopClose(t *testing.T) {
+	s := NewScheduler()
+	defer s.Close()
+
+	var ticks int32
+	vm := goja.New()
+	_ = vm.Set("tick", func() {
+		atomic.AddInt32(&ticks, 1)
+	})
+	loop := s.NewLoop(vm)
+	if err := loop.RunOnLoop(func(vm *goja.Runtime) {
+		if _, err := vm.RunString(`setInterval(tick, 1)`); err != nil {
+			t.Error(err)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for atomic.LoadInt32(&ticks) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	loop.Close()
+	wait(t, s)
+	n := atomic.LoadInt32(&ticks)
+	time.Sleep(20 * time.Millisecond)
+	wait(t, s)
+	if c := atomic.LoadInt32(&ticks); c != n {
+		t.Fatalf("The interval has fired after Close: %d, %d", n, c)
+	}
+	if err := loop.RunOnLoop(func(*goja.Runtime) {}); err != ErrLoopClosed {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	s1 := NewScheduler()
+	loop = s1.NewLoop(goja.New())
+	s1.Close()
+	if err := loop.RunOnLoop(func(*goja.Runtime) {}); err != ErrLoopClosed {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var mu sync.Mutex
+	var errs []string
+	s := NewScheduler(WithErrorHandler(func(l *Loop, err error) {
+		mu.Lock()
+		errs = append(errs, err.Error())
+		mu.Unlock()
+	}))
+	defer s.Close()
+
+	done := make(chan struct{})
+	vm := goja.New()
+	_ = vm.Set("done", func() {
+		close(done)
+	})
+	if err := s.NewLoop(vm).RunOnLoop(func(vm *goja.Runtime) {
+		_, _ = vm.RunString(`
+		setTimeout(function() {
+			throw new Error("boom");
+		}, 0);
+		setTimeout(done, 5);
+		`)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.NewLoop(goja.New()).RunOnLoop(func(*goja.Runtime) {
+		panic("task failure")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 2 || errs[0] != "task panicked: task failure" || !strings.HasPrefix(errs[1], "Error: boom") {
+		t.Fatalf("Unexpected errors: %q", errs)
+	}
+}
@@ -0,0 +1,163 @@
+package goja
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatOption is an option for Exception.Format().
+type FormatOption func(*formatOptions)
+
+type formatOptions struct {
+	source       bool
+	contextLines int
+}
+
+// WithSource makes Exception.Format() print an excerpt of the source where the exception was thrown, with a caret
+// under the column. If the script has a source map, the excerpt is taken from the original source, provided that
+// the map includes it (i.e. it has "sourcesContent").
+func WithSource(enabled bool) FormatOption {
+	return func(opts *formatOptions) {
+		opts.source = enabled
+	}
+}
+
+// WithContextLines sets the number of lines printed before and after the failing one when WithSource is
+// enabled. The default is 1.
+func WithContextLines(n int) FormatOption {
+	return func(opts *formatOptions) {
+		if n < 0 {
+			n = 0
+		}
+		opts.contextLines = n
+	}
+}
+
+// Format writes a human-readable description of the exception to w: the exception value, optionally followed by
+// an excerpt of the source (see WithSource()), and the stack trace. For example:
+//
+//	TypeError: Cannot read properties of undefined (reading 'name')
+//	 --> greeting.js:3:26
+//	  2 | function greet(user) {
+//	  3 |     return "Hi, " + user.name;
+//	    |                          ^
+//	  4 | }
+//		at greet (greeting.js:3:26(3))
+//		at greeting.js:5:6(3)
+//
+// Without any options the output is the same as String().
+func (e *Exception) Format(w io.Writer, opts ...FormatOption) error {
+	o := formatOptions{
+		contextLines: 1,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var b bytes.Buffer
+	if e.val != nil {
+		b.WriteString(e.val.String())
+		b.WriteByte('\n')
+	}
+	if o.source {
+		e.writeSourceExcerpt(&b, o.contextLines)
+	}
+	e.writeFullStack(&b)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (e *Exception) writeSourceExcerpt(b *bytes.Buffer, contextLines int) {
+	var frame *StackFrame
+	for i := range e.stack {
+		if f := &e.stack[i]; f.prg != nil && f.prg.src != nil {
+			frame = f
+			break
+		}
+	}
+	if frame == nil {
+		return
+	}
+	pos, src := frame.prg.src.PositionSource(frame.prg.sourceOffset(frame.pc))
+	lines := splitSourceLines(src)
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return
+	}
+	b.WriteString(" --> ")
+	b.WriteString(pos.String())
+	b.WriteByte('\n')
+
+	first, last := pos.Line-contextLines, pos.Line+contextLines
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(strconv.Itoa(last))
+	gutter := strings.Repeat(" ", width)
+	for n := first; n <= last; n++ {
+		line := lines[n-1]
+		num := strconv.Itoa(n)
+		b.WriteString("  ")
+		b.WriteString(gutter[len(num):])
+		b.WriteString(num)
+		if line != "" {
+			b.WriteString(" | ")
+			b.WriteString(line)
+		} else {
+			b.WriteString(" |")
+		}
+		b.WriteByte('\n')
+		if n == pos.Line {
+			b.WriteString("  ")
+			b.WriteString(gutter)
+			b.WriteString(" | ")
+			writeCaretIndent(b, line, pos.Column-1)
+			b.WriteString("^\n")
+		}
+	}
+}
+
+// writeCaretIndent writes the whitespace that aligns a caret with the byte offset col in line. Tabs are kept so
+// that the alignment is preserved regardless of the tab width.
+func writeCaretIndent(b *bytes.Buffer, line string, col int) {
+	if col > len(line) {
+		col = len(line)
+	}
+	for i := 0; i < col; {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+		i += size
+	}
+}
+
+func splitSourceLines(src string) []string {
+	if src == "" {
+		return nil
+	}
+	var lines []string
+	start := 0
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch r {
+		case '\r':
+			lines = append(lines, src[start:i])
+			if i+1 < len(src) && src[i+1] == '\n' {
+				size++
+			}
+			start = i + size
+		case '\n', '\u2028', '\u2029':
+			lines = append(lines, src[start:i])
+			start = i + size
+		}
+		i += size
+	}
+	return append(lines, src[start:])
+}
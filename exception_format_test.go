@@ -0,0 +1,67 @@
+package goja
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestExceptionFormat(t *testing.T) {
+	const SCRIPT = `
+function greet(user) {
+	return "Hi, " + user.name;
+}
+greet();
+`
+	_, err := New().RunScript("greeting.js", SCRIPT)
+	ex, ok := err.(*Exception)
+	if !ok {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := ex.Format(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != ex.String() {
+		t.Fatalf("Unexpected output: %q", b.String())
+	}
+
+	b.Reset()
+	if err := ex.Format(&b, WithSource(true)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "TypeError: Cannot read properties of undefined (reading 'name')\n" +
+		" --> greeting.js:3:23\n" +
+		"  2 | function greet(user) {\n" +
+		"  3 | \treturn \"Hi, \" + user.name;\n" +
+		"    | \t                     ^\n" +
+		"  4 | }\n" +
+		"\tat greet (greeting.js:3:23(3))\n" +
+		"\tat greeting.js:5:6(3)\n"
+	if b.String() != expected {
+		t.Fatalf("Unexpected output:\n%s", b.String())
+	}
+
+	b.Reset()
+	_ = ex.Format(&b, WithSource(true), WithContextLines(0))
+	if !strings.Contains(b.String(), " --> greeting.js:3:23\n  3 | \treturn") || strings.Contains(b.String(), "  2 |") {
+		t.Fatalf("Unexpected output:\n%s", b.String())
+	}
+}
+
+func TestExceptionFormatSourceMap(t *testing.T) {
+	sm := `{"version":3,"file":"out.js","sources":["in.ts"],"sourcesContent":["// original\nnull.prop;\n"],"names":[],"mappings":"AACA,MAAM"}`
+	src := "null.prop;\n//# sourceMappingURL=data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(sm))
+	_, err := New().RunScript("out.js", src)
+	ex, ok := err.(*Exception)
+	if !ok {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	_ = ex.Format(&b, WithSource(true))
+	if !strings.Contains(b.String(), "  1 | // original\n  2 | null.prop;\n    |      ^\n") {
+		t.Fatalf("Unexpected output:\n%s", b.String())
+	}
+}
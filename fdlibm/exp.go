@@ -0,0 +1,213 @@
+package fdlibm
+
+import "math"
+
+const (
+	o_threshold float64 = 7.09782712893383973096e+02  // 0x40862E42, 0xFEFA39EF
+	u_threshold float64 = -7.45133219101941108420e+02 // 0xC0874910, 0xD52D3051
+	ln2_hi      float64 = 6.93147180369123816490e-01  // 0x3FE62E42, 0xFEE00000
+	ln2_lo      float64 = 1.90821492927058770002e-10  // 0x3DEA39EF, 0x35793C76
+	invln2      float64 = 1.44269504088896338700e+00  // 0x3FF71547, 0x652B82FE
+
+	expP1 = 1.66666666666666019037e-01  // 0x3FC55555, 0x5555553E
+	expP2 = -2.77777777770155933842e-03 // 0xBF66C16C, 0x16BEBD93
+	expP3 = 6.61375632143793436117e-05  // 0x3F11566A, 0xAF25DE2C
+	expP4 = -1.65339022054652515390e-06 // 0xBEBBBD41, 0xC5D26BF1
+	expP5 = 4.13813679705723846039e-08  // 0x3E663769, 0x72BEA4D0
+
+	twom1000 = 0x1p-1000 // 9.33263618503218878990e-302
+	two1023  = 0x1p1023  // 8.988465674311579539e307
+)
+
+// Exp returns e**x, the base-e exponential of x.
+func Exp(x float64) float64 {
+	const E = 2.718281828459045 // 0x4005BF0A, 0x8B145769
+
+	var hi, lo float64
+	var k int32
+	hx := uint32(highWord(x))
+	xsb := int32(hx>>31) & 1 // sign bit of x
+	hx &= 0x7FFFFFFF         // high word of |x|
+
+	// filter out non-finite argument
+	if hx >= 0x40862E42 { // if |x| >= 709.78...
+		if hx >= 0x7FF00000 {
+			if ((hx & 0xFFFFF) | lowWord(x)) != 0 {
+				return x + x // NaN
+			}
+			if xsb == 0 { // exp(+-inf) = {inf,0}
+				return x
+			}
+			return 0
+		}
+		if x > o_threshold { // overflow
+			return math.Inf(1)
+		}
+		if x < u_threshold { // underflow
+			return 0
+		}
+	}
+
+	// argument reduction
+	if hx > 0x3FD62E42 { // if |x| > 0.5 ln2
+		if hx < 0x3FF0A2B2 { // and |x| < 1.5 ln2
+			// V8 special-cases exp(1) to return the correctly rounded value of E, the computation below gets the
+			// last bit wrong.
+			if x == 1 {
+				return E
+			}
+			if xsb == 0 {
+				hi = x - ln2_hi
+				lo = ln2_lo
+			} else {
+				hi = x + ln2_hi
+				lo = -ln2_lo
+			}
+			k = 1 - xsb - xsb
+		} else {
+			if xsb == 0 {
+				k = int32(float64(invln2*x) + 0.5)
+			} else {
+				k = int32(float64(invln2*x) - 0.5)
+			}
+			t := float64(k)
+			hi = x - float64(t*ln2_hi) // t*ln2_hi is exact here
+			lo = t * ln2_lo
+		}
+		x = hi - lo
+	} else if hx < 0x3E300000 { // when |x| < 2**-28
+		return 1 + x
+	}
+
+	// x is now in primary range
+	t := x * x
+	var twopk float64
+	if k >= -1021 {
+		twopk = fromWords(uint32(0x3FF00000+k<<20), 0)
+	} else {
+		twopk = fromWords(uint32(0x3FF00000+(k+1000)<<20), 0)
+	}
+	c := x - float64(t*(expP1+float64(t*(expP2+float64(t*(expP3+float64(t*(expP4+float64(t*expP5)))))))))
+	if k == 0 {
+		return 1 - (float64(x*c)/(c-2.0) - x)
+	}
+	y := 1 - ((lo - float64(x*c)/(2.0-c)) - hi)
+	if k >= -1021 {
+		if k == 1024 {
+			return y * 2.0 * two1023
+		}
+		return y * twopk
+	}
+	return y * twopk * twom1000
+}
+
+// Expm1 returns e**x - 1, the base-e exponential of x minus 1. It is more accurate than Exp(x) - 1 when x is near
+// zero.
+func Expm1(x float64) float64 {
+	const (
+		// Scaled Q's: Qn_here = 2**n * Qn_above, for R(2*z) where z = hxs = x*x/2:
+		Q1 = -3.33333333333331316428e-02 // BFA11111 111110F4
+		Q2 = 1.58730158725481460165e-03  // 3F5A01A0 19FE5585
+		Q3 = -7.93650757867487942473e-05 // BF14CE19 9EAADBB7
+		Q4 = 4.00821782732936239552e-06  // 3ED0CFCA 86E65239
+		Q5 = -2.01099218183624371326e-07 // BE8AFDB7 6E09C32D
+	)
+
+	var hi, lo, c float64
+	var k int32
+	hx := uint32(highWord(x))
+	xsb := hx & 0x80000000 // sign bit of x
+	hx &= 0x7FFFFFFF       // high word of |x|
+
+	// filter out huge and non-finite argument
+	if hx >= 0x4043687A { // if |x| >= 56*ln2
+		if hx >= 0x40862E42 { // if |x| >= 709.78...
+			if hx >= 0x7FF00000 {
+				if ((hx & 0xFFFFF) | lowWord(x)) != 0 {
+					return x + x // NaN
+				}
+				if xsb == 0 { // exp(+-inf) = {inf,-1}
+					return x
+				}
+				return -1
+			}
+			if x > o_threshold { // overflow
+				return math.Inf(1)
+			}
+		}
+		if xsb != 0 { // x < -56*ln2, return -1.0 with inexact
+			return -1
+		}
+	}
+
+	// argument reduction
+	if hx > 0x3FD62E42 { // if |x| > 0.5 ln2
+		if hx < 0x3FF0A2B2 { // and |x| < 1.5 ln2
+			if xsb == 0 {
+				hi = x - ln2_hi
+				lo = ln2_lo
+				k = 1
+			} else {
+				hi = x + ln2_hi
+				lo = -ln2_lo
+				k = -1
+			}
+		} else {
+			if xsb == 0 {
+				k = int32(float64(invln2*x) + 0.5)
+			} else {
+				k = int32(float64(invln2*x) - 0.5)
+			}
+			t := float64(k)
+			hi = x - float64(t*ln2_hi) // t*ln2_hi is exact here
+			lo = t * ln2_lo
+		}
+		x = hi - lo
+		c = (hi - x) - lo
+	} else if hx < 0x3C900000 { // when |x| < 2**-54, return x
+		return x
+	}
+
+	// x is now in primary range
+	hfx := 0.5 * x
+	hxs := float64(x * hfx)
+	r1 := 1 + float64(hxs*(Q1+float64(hxs*(Q2+float64(hxs*(Q3+float64(hxs*(Q4+float64(hxs*Q5)))))))))
+	t := 3.0 - float64(r1*hfx)
+	e := float64(hxs * ((r1 - t) / (6.0 - float64(x*t))))
+	if k == 0 {
+		return x - (float64(x*e) - hxs) // c is 0
+	}
+	twopk := fromWords(uint32(0x3FF00000+k<<20), 0) // add k to y's exponent
+	e = float64(x*(e-c)) - c
+	e -= hxs
+	if k == -1 {
+		return float64(0.5*(x-e)) - 0.5
+	}
+	if k == 1 {
+		if x < -0.25 {
+			return -2.0 * (e - (x + 0.5))
+		}
+		return 1 + float64(2.0*(x-e))
+	}
+	if k <= -2 || k > 56 { // suffice to return exp(x)-1
+		y := 1 - (e - x)
+		if k == 1024 {
+			y = y * 2.0 * two1023
+		} else {
+			y = y * twopk
+		}
+		return y - 1
+	}
+	var y float64
+	if k < 20 {
+		t = fromWords(uint32(0x3FF00000-(0x200000>>k)), 0) // t=1-2^-k
+		y = t - (e - x)
+		y = y * twopk
+	} else {
+		t = fromWords(uint32((0x3FF-k)<<20), 0) // 2^-k
+		y = x - (e + t)
+		y += 1
+		y = y * twopk
+	}
+	return y
+}
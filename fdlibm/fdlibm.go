@@ -0,0 +1,36 @@
+// Package fdlibm is a port of the fdlibm implementations of the elementary functions, as used by V8 for the
+// JavaScript Math object. Unlike the math package, whose algorithms differ from fdlibm and which uses assembly
+// on some architectures, the results are the same on all platforms and match V8 bit for bit.
+//
+// The Go compiler is allowed to fuse a multiplication and an addition into a single FMA instruction on the
+// architectures that have one, which changes the rounding. To prevent that, every product that feeds an addition
+// or a subtraction is wrapped in an explicit float64 conversion.
+package fdlibm
+
+import "math"
+
+const (
+	two24  = 1.67772160000000000000e+07 // 0x41700000, 0x00000000
+	twon24 = 5.96046447753906250000e-08 // 0x3E700000, 0x00000000
+	two54  = 1.80143985094819840000e+16 // 0x43500000, 0x00000000
+)
+
+func highWord(x float64) int32 {
+	return int32(math.Float64bits(x) >> 32)
+}
+
+func lowWord(x float64) uint32 {
+	return uint32(math.Float64bits(x))
+}
+
+func fromWords(hi, lo uint32) float64 {
+	return math.Float64frombits(uint64(hi)<<32 | uint64(lo))
+}
+
+func withHighWord(x float64, hi uint32) float64 {
+	return fromWords(hi, lowWord(x))
+}
+
+func withLowWord(x float64, lo uint32) float64 {
+	return fromWords(uint32(highWord(x)), lo)
+}
@@ -0,0 +1,171 @@
+package fdlibm
+
+import (
+	"math"
+	"testing"
+)
+
+// The expected values were obtained from V8. Most of them are cases where the math package gives a different
+// result.
+var unaryTests = []struct {
+	name string
+	fn   func(float64) float64
+	x    uint64
+	want uint64
+}{
+	{"sin", Sin, 0x4000000000000000, 0x3fed18f6ead1b446},     // sin(2) = 0.9092974268256817
+	{"sin", Sin, 0x4024000000000000, 0xbfe1689ef5f34f52},     // sin(10) = -0.5440211108893698
+	{"sin", Sin, 0x7e37e43c8800759c, 0xbfea2c16b010e385},     // sin(1e+300) = -0.8178819121159085
+	{"sin", Sin, 0x400921fb54442d18, 0x3ca1a62633145c07},     // sin(3.141592653589793) = 1.2246467991473532e-16
+	{"cos", Cos, 0x3ff921fb54442d18, 0x3c91a62633145c07},     // cos(1.5707963267948966) = 6.123233995736766e-17
+	{"cos", Cos, 0x40862e3d70a3d70a, 0x3fef3aa8e02e8c65},     // cos(709.78) = 0.9759106043387759
+	{"cos", Cos, 0x3feccccccccccccd, 0x3fe3e43a9692e21c},     // cos(0.9) = 0.6216099682706644
+	{"cos", Cos, 0x4480f0cf064dd592, 0x3fe0be2cef01c8f4},     // cos(1e+22) = 0.523214785395139
+	{"tan", Tan, 0x3ff0000000000000, 0x3ff8eb245cbee3a6},     // tan(1) = 1.5574077246549023
+	{"tan", Tan, 0xbff0000000000000, 0xbff8eb245cbee3a6},     // tan(-1) = -1.5574077246549023
+	{"tan", Tan, 0x4024000000000000, 0x3fe4bf5f34be3782},     // tan(10) = 0.6483608274590866
+	{"tan", Tan, 0x4059000000000000, 0xbfe2ca74d62b5d38},     // tan(100) = -0.5872139151569291
+	{"asin", Asin, 0x3e45798ee2308c3a, 0x3e45798ee2308c3a},   // asin(1e-08) = 1e-08
+	{"asin", Asin, 0x3feffffffaa19c47, 0x3ff9216709c28b31},   // asin(0.99999999) = 1.5706549054381862
+	{"asin", Asin, 0x3fe0505ae75a5d04, 0x3fe11e6a2540a998},   // asin(0.5098089712727476) = 0.5349627235021215
+	{"asin", Asin, 0xbf6f6a3f0c66c400, 0xbf6f6a44183a15e3},   // asin(-0.003834841863146199) = -0.0038348512624135508
+	{"acos", Acos, 0x3fe0000000000000, 0x3ff0c152382d7366},   // acos(0.5) = 1.0471975511965979
+	{"acos", Acos, 0x3feffffffaa19c47, 0x3f228950343cef55},   // acos(0.99999999) = 0.00014142135671046477
+	{"acos", Acos, 0xbfc0c731be68ef44, 0x3ffb3c6e36f9477e},   // acos(-0.13107892796858256) = 1.7022535464679716
+	{"acos", Acos, 0xbfeed3172b17a844, 0x4006f52c30e2a2f5},   // acos(-0.9632678834580095) = 2.869713193813704
+	{"atan", Atan, 0x4024000000000000, 0x3ff789bd2c160054},   // atan(10) = 1.4711276743037347
+	{"atan", Atan, 0x4059000000000000, 0x3ff8f905eb2def22},   // atan(100) = 1.5607966601082315
+	{"atan", Atan, 0x4086300000000000, 0x3ff91c367668c617},   // atan(710) = 1.5693878770220004
+	{"atan", Atan, 0x3ff000001ad7f29b, 0x3fe921fb6f1c1f9d},   // atan(1.0000001) = 0.7853982133974459
+	{"exp", Exp, 0x40862e3d70a3d70a, 0x7fefe9ce5c4c52b4},     // exp(709.78) = 1.7928227943945155e+308
+	{"exp", Exp, 0x4036000000000000, 0x41eab5adb9c43600},     // exp(22) = 3.584912846131592e+09
+	{"exp", Exp, 0x4007c484c9caa0e2, 0x403382b4c07f931d},     // exp(2.9709563984834952) = 19.510570555826337
+	{"exp", Exp, 0x3fcc329124344ed0, 0x3ff3f16d48421002},     // exp(0.22029318111218865) = 1.2464421103077261
+	{"log", Log, 0x0000000000000001, 0xc0874385446d71c3},     // log(5e-324) = -744.4400719213812
+	{"log", Log, 0x3ff1ebf867d672b0, 0x3fbd098ab05dd858},     // log(1.1201099449309986) = 0.1134268456060864
+	{"log", Log, 0x3fb6007a21fc13e0, 0xc003a1e51dbf0309},     // log(0.08594477967933711) = -2.454050285712928
+	{"log", Log, 0x3fe5ffe0199917dc, 0xbfd7fb5708e9a347},     // log(0.6874847888909659) = -0.3747155749356818
+	{"log2", Log2, 0x3ff000001ad7f29b, 0x3e835d0fea5fccb7},   // log2(1.0000001) = 1.4426949695965583e-07
+	{"log2", Log2, 0x3fe86b1a4c0dcaf4, 0xbfd8f77afbc49226},   // log2(0.7630740628650003) = -0.39010500513748736
+	{"log2", Log2, 0x40002276eb5ab1da, 0x3ff0318387804ccb},   // log2(2.0168283831646763) = 1.0120883267416498
+	{"log2", Log2, 0x4002e75e867e4041, 0x3ff3d982d9bfc667},   // log2(2.362973261573672) = 1.2406033044648213
+	{"log10", Log10, 0x0000000000000001, 0xc07434e6420f4374}, // log10(5e-324) = -323.3062153431158
+	{"log10", Log10, 0x400921fb54442d18, 0x3fdfd14db31ba3ba}, // log10(3.141592653589793) = 0.4971498726941338
+	{"log10", Log10, 0x4086340000000000, 0x4006d000d45e28d5}, // log10(710.5) = 2.8515640822634887
+	{"log10", Log10, 0x4036000000000000, 0x3ff57a903478a3ee}, // log10(22) = 1.3424226808222062
+	{"sinh", Sinh, 0x40862e3d70a3d70a, 0x7fdfe9ce5c4c52b4},   // sinh(709.78) = 8.964113971972578e+307
+	{"sinh", Sinh, 0x4086300000000000, 0x7fe3e21a464507fa},   // sinh(710) = 1.1169973830808557e+308
+	{"sinh", Sinh, 0x4036000000000000, 0x41dab5adb9c43600},   // sinh(22) = 1.792456423065796e+09
+	{"sinh", Sinh, 0x3e45798ee2308c3a, 0x3e45798ee2308c3b},   // sinh(1e-08) = 1.0000000000000002e-08
+	{"cosh", Cosh, 0x40862e3d70a3d70a, 0x7fdfe9ce5c4c52b4},   // cosh(709.78) = 8.964113971972578e+307
+	{"cosh", Cosh, 0x4086300000000000, 0x7fe3e21a464507fa},   // cosh(710) = 1.1169973830808557e+308
+	{"cosh", Cosh, 0x4036000000000000, 0x41dab5adb9c43600},   // cosh(22) = 1.792456423065796e+09
+	{"cosh", Cosh, 0xc07a04dedb565d0e, 0x656841b09853b930},   // cosh(-416.3044083951544) = 3.145410718257277e+180
+	{"tanh", Tanh, 0x3feffffffaa19c47, 0x3fe85efab2d3be3f},   // tanh(0.99999999) = 0.7615941517560215
+	{"tanh", Tanh, 0x3febc46bbd50e5fc, 0x3fe6682e8ff9edd1},   // tanh(0.8677271554544741) = 0.7002175151860063
+	{"tanh", Tanh, 0xbfed1eb8dec0a80c, 0xbfe713843683907b},   // tanh(-0.9100002623210925) = -0.7211323799824777
+	{"tanh", Tanh, 0xbfcdc071d13b3240, 0xbfcd3a2ec6233afa},   // tanh(-0.2324354430806057) = -0.2283380954235879
+	{"asinh", Asinh, 0x40148dc74a4302de, 0x4002b6c190ba77d2}, // asinh(5.138455543842353) = 2.3392363840966732
+	{"asinh", Asinh, 0x400c13a8684d081a, 0x3fff7e60d3b214d5}, // asinh(3.5095985554226106) = 1.968354060112053
+	{"asinh", Asinh, 0xc0173bf9c5859fff, 0xc003adb1036a3785}, // asinh(-5.808569990425894) = -2.4598102824010675
+	{"asinh", Asinh, 0x407551a45cb28d22, 0x401a19f0d22a181b}, // asinh(341.10262746569254) = 6.525332721537803
+	{"acosh", Acosh, 0x40083f909fa04970, 0x3ffc60e671a74a77}, // acosh(3.0310375662604017) = 1.7736572684849798
+	{"acosh", Acosh, 0x4006c74ab9b05d4c, 0x3ffb507f00616d3c}, // acosh(2.8473104960391193) = 1.7071523680559286
+	{"acosh", Acosh, 0x4018488260361f53, 0x4003eb14c712bc2d}, // acosh(6.070809844306342) = 2.489785723933457
+	{"acosh", Acosh, 0x400627d0f5f464f9, 0x3ffad6f2a3023989}, // acosh(2.769441529770685) = 1.6774774901892633
+	{"cbrt", Cbrt, 0x3fe0000000000000, 0x3fe965fea53d6e3d},   // cbrt(0.5) = 0.7937005259840998
+	{"cbrt", Cbrt, 0xbfe0000000000000, 0xbfe965fea53d6e3d},   // cbrt(-0.5) = -0.7937005259840998
+	{"cbrt", Cbrt, 0x4059000000000000, 0x401290fca9c761f8},   // cbrt(100) = 4.641588833612779
+	{"cbrt", Cbrt, 0x3feccccccccccccd, 0x3feee549fe7085e8},   // cbrt(0.9) = 0.9654893846056298
+}
+
+var binaryTests = []struct {
+	name string
+	fn   func(float64, float64) float64
+	x, y uint64
+	want uint64
+}{
+	{"atan2", Atan2, 0x4059000000000000, 0x3ff96a26dd3f6ec0, 0x3ff8e0ecfb2912e9}, // atan2(100, 1.5884159700862455) = 1.5549135027842402
+	{"atan2", Atan2, 0x8000000000000001, 0xc007976dd2fce574, 0xc00921fb54442d18}, // atan2(-5e-324, -2.948939941733153) = -3.141592653589793
+	{"atan2", Atan2, 0xc08749999999999a, 0xc012aa4621218751, 0xbff93ba133c1df6b}, // atan2(-745.2, -4.666283147498533) = -1.577058031250966
+	{"atan2", Atan2, 0xc02369cfe549f750, 0xbfdf65cebc5a40a0, 0xbff9f0d2d1c0caf3}, // atan2(-9.706664243010579, -0.4905888404255254) = -1.6212948029976217
+	{"pow", Pow, 0x4024000000000000, 0x401e859d0706b682, 0x41845d0cc56f093f},     // pow(10, 7.630481824669575) = 4.2705304679216854e+07
+	{"pow", Pow, 0x4059000000000000, 0x4014b788d9012bb4, 0x421542985fd4fd51},     // pow(100, 5.179232969948249) = 2.282789886924738e+10
+	{"pow", Pow, 0x400921fb54442d18, 0xc01064b2c3802f1f, 0x3f82c94c1382122d},     // pow(3.141592653589793, -4.098338179301009) = 0.009173006387080818
+	{"pow", Pow, 0x3ff921fb54442d18, 0xc01a610ba0a04f70, 0x3faa0e487779974b},     // pow(1.5707963267948966, -6.594770917687001) = 0.05089022119936589
+	{"pow", Pow, 0x4024000000000000, 0xc014000000000000, 0x3ee4f8b588e368f0},     // pow(10, -5) = 0.000009999999999999999
+	{"pow", Pow, 0x401c000000000000, 0xbfe0000000000000, 0x3fd83091e6a7f7e7},     // pow(7, -0.5) = 0.37796447300922725
+	{"pow", Pow, 0x405edd2f1a9fbe77, 0xbff8000000000000, 0x3f47e35a9bc426f5},     // pow(123.456, -1.5) = 0.0007290069984559876
+	{"pow", Pow, 0x4008000000000000, 0xc073400000000000, 0x216c7939ad629f49},     // pow(3, -308) = 1.113405971825609e-147
+	{"pow", Pow, 0x4000000000000000, 0x3ff8a68d5f7b4e26, 0x400746349b838f78},     // pow(2, 1.5406621675255194) = 2.909280028297811
+	{"pow", Pow, 0x400ef6e501959ec0, 0x40154d341401810c, 0x40951562ca9930dd},     // pow(3.870553982142013, 5.325393974868586) = 1349.3464759765695
+	{"pow", Pow, 0x40554fffb305a84e, 0xc0356cb192555b60, 0x37581873eb886786},     // pow(85.24998164703831, -21.42458452781591) = 4.321921726845337e-42
+}
+
+func TestUnary(t *testing.T) {
+	for _, tc := range unaryTests {
+		x := math.Float64frombits(tc.x)
+		if got := tc.fn(x); math.Float64bits(got) != tc.want {
+			t.Errorf("%s(%v) = %v (%#016x), want %v (%#016x)", tc.name, x, got, math.Float64bits(got), math.Float64frombits(tc.want), tc.want)
+		}
+	}
+}
+
+func TestBinary(t *testing.T) {
+	for _, tc := range binaryTests {
+		x, y := math.Float64frombits(tc.x), math.Float64frombits(tc.y)
+		if got := tc.fn(x, y); math.Float64bits(got) != tc.want {
+			t.Errorf("%s(%v, %v) = %v (%#016x), want %v (%#016x)", tc.name, x, y, got, math.Float64bits(got), math.Float64frombits(tc.want), tc.want)
+		}
+	}
+}
+
+func TestSpecialCases(t *testing.T) {
+	nan, inf, negZero := math.NaN(), math.Inf(1), math.Copysign(0, -1)
+	same := func(a, b float64) bool {
+		return math.Float64bits(a) == math.Float64bits(b) || math.IsNaN(a) && math.IsNaN(b)
+	}
+	for _, tc := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"Sin(-0)", Sin(negZero), negZero},
+		{"Sin(Inf)", Sin(inf), nan},
+		{"Cos(-Inf)", Cos(-inf), nan},
+		{"Tan(-0)", Tan(negZero), negZero},
+		{"Asin(2)", Asin(2), nan},
+		{"Acos(1)", Acos(1), 0},
+		{"Atan(-Inf)", Atan(-inf), -math.Pi / 2},
+		{"Atan2(-0, -0)", Atan2(negZero, negZero), -math.Pi},
+		{"Atan2(Inf, -Inf)", Atan2(inf, -inf), 3 * math.Pi / 4},
+		{"Exp(-Inf)", Exp(-inf), 0},
+		{"Exp(1)", Exp(1), math.E},
+		{"Expm1(-0)", Expm1(negZero), negZero},
+		{"Log(-0)", Log(negZero), -inf},
+		{"Log(-1)", Log(-1), nan},
+		{"Log1p(-1)", Log1p(-1), -inf},
+		{"Log2(8)", Log2(8), 3},
+		{"Log10(1000)", Log10(1000), 3},
+		{"Sinh(-0)", Sinh(negZero), negZero},
+		{"Cosh(-Inf)", Cosh(-inf), inf},
+		{"Tanh(-Inf)", Tanh(-inf), -1},
+		{"Asinh(-0)", Asinh(negZero), negZero},
+		{"Acosh(0.5)", Acosh(0.5), nan},
+		{"Atanh(-1)", Atanh(-1), -inf},
+		{"Cbrt(-27)", Cbrt(-27), -3},
+		{"Cbrt(-0)", Cbrt(negZero), negZero},
+		{"Pow(NaN, 0)", Pow(nan, 0), 1},
+		{"Pow(1, NaN)", Pow(1, nan), nan},
+		{"Pow(-1, Inf)", Pow(-1, inf), nan},
+		{"Pow(-0, -3)", Pow(negZero, -3), -inf},
+		{"Pow(-0, 3)", Pow(negZero, 3), negZero},
+		{"Pow(-8, 1/3)", Pow(-8, 1.0/3), nan},
+		{"Pow(-2, 3)", Pow(-2, 3), -8},
+		{"Pow(2, -1074)", Pow(2, -1074), 5e-324},
+		{"Pow(2, 1024)", Pow(2, 1024), inf},
+	} {
+		if !same(tc.got, tc.want) {
+			t.Errorf("%s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package fdlibm
+
+import "math"
+
+const ln2 float64 = 6.93147180559945286227e-01 // 0x3FE62E42, 0xFEFA39EF
+
+// Sinh returns the hyperbolic sine of x.
+func Sinh(x float64) float64 {
+	const (
+		kSinhOverflow = 710.4758600739439
+		twoM28        = 3.725290298461914e-9 // 2^-28, empty lower half
+		logMaxD       = 709.7822265625       // 0x40862E42 00000000, empty lower half
+	)
+
+	h := 0.5
+	if x < 0 {
+		h = -0.5
+	}
+	// |x| in [0, 22]. return sign(x)*0.5*(E+E/(E+1))
+	ax := math.Abs(x)
+	if ax < 22 {
+		// For |x| < 2^-28, sinh(x) = x
+		if ax < twoM28 {
+			return x
+		}
+		t := Expm1(ax)
+		if ax < 1 {
+			return h * (float64(2.0*t) - float64(t*t)/(t+1.0))
+		}
+		return h * (t + t/(t+1.0))
+	}
+	// |x| in [22, log(maxdouble)], return 0.5 * exp(|x|)
+	if ax < logMaxD {
+		return h * Exp(ax)
+	}
+	// |x| in [log(maxdouble), overflowthreshold]
+	if ax <= kSinhOverflow {
+		w := Exp(0.5 * ax)
+		t := h * w
+		return t * w
+	}
+	// |x| > overflowthreshold or x = NaN, return inf
+	// NaN, return NaN
+	return x * 1.0e307
+}
+
+// Cosh returns the hyperbolic cosine of x.
+func Cosh(x float64) float64 {
+	const kCoshOverflow = 710.4758600739439
+
+	ix := highWord(x) & 0x7FFFFFFF
+
+	// |x| in [0,0.5*log2], return 1+expm1(|x|)^2/(2*exp(|x|))
+	if ix < 0x3FD62E43 {
+		t := Expm1(math.Abs(x))
+		w := 1 + t
+		// For |x| < 2^-55, cosh(x) = 1
+		if ix < 0x3C800000 {
+			return w
+		}
+		return 1 + float64(t*t)/(w+w)
+	}
+
+	// |x| in [0.5*log2, 22], return (exp(|x|)+1/exp(|x|)/2
+	if ix < 0x40360000 {
+		t := Exp(math.Abs(x))
+		return float64(0.5*t) + 0.5/t
+	}
+
+	// |x| in [22, log(maxdouble)], return half*exp(|x|)
+	if ix < 0x40862E42 {
+		return 0.5 * Exp(math.Abs(x))
+	}
+
+	// |x| in [log(maxdouble), overflowthreshold]
+	if math.Abs(x) <= kCoshOverflow {
+		w := Exp(0.5 * math.Abs(x))
+		t := 0.5 * w
+		return t * w
+	}
+
+	// x is INF or NaN
+	if ix >= 0x7FF00000 {
+		return x * x
+	}
+
+	// |x| > overflowthreshold.
+	return math.Inf(1)
+}
+
+// Tanh returns the hyperbolic tangent of x.
+func Tanh(x float64) float64 {
+	var z float64
+	jx := highWord(x)
+	ix := jx & 0x7FFFFFFF
+
+	// x is INF or NaN
+	if ix >= 0x7FF00000 {
+		if jx >= 0 { // tanh(+-inf)=+-1
+			return 1/x + 1
+		}
+		return 1/x - 1 // tanh(NaN) = NaN
+	}
+
+	if ix < 0x40360000 { // |x| < 22
+		if ix < 0x3E300000 { // |x| < 2**-28
+			return x // tanh(tiny) = tiny with inexact
+		}
+		if ix >= 0x3FF00000 { // |x| >= 1
+			t := Expm1(2 * math.Abs(x))
+			z = 1 - 2/(t+2)
+		} else {
+			t := Expm1(-2 * math.Abs(x))
+			z = -t / (t + 2)
+		}
+	} else { // |x| >= 22, return +-1
+		z = 1
+	}
+	if jx >= 0 {
+		return z
+	}
+	return -z
+}
+
+// Asinh returns the inverse hyperbolic sine of x.
+func Asinh(x float64) float64 {
+	var w float64
+	hx := highWord(x)
+	ix := hx & 0x7FFFFFFF
+	if ix >= 0x7FF00000 { // x is inf or NaN
+		return x + x
+	}
+	if ix < 0x3E300000 { // |x| < 2**-28
+		return x // return x inexact except 0
+	}
+	if ix > 0x41B00000 { // |x| > 2**28
+		w = Log(math.Abs(x)) + ln2
+	} else if ix > 0x40000000 { // 2**28 > |x| > 2.0
+		t := math.Abs(x)
+		w = Log(float64(2.0*t) + 1/(math.Sqrt(float64(x*x)+1)+t))
+	} else { // 2.0 > |x| > 2**-28
+		t := float64(x * x)
+		w = Log1p(math.Abs(x) + t/(1+math.Sqrt(1+t)))
+	}
+	if hx > 0 {
+		return w
+	}
+	return -w
+}
+
+// Acosh returns the inverse hyperbolic cosine of x.
+func Acosh(x float64) float64 {
+	hx := highWord(x)
+	if hx < 0x3FF00000 { // x < 1
+		return (x - x) / (x - x)
+	} else if hx >= 0x41B00000 { // x > 2**28
+		if hx >= 0x7FF00000 { // x is inf of NaN
+			return x + x
+		}
+		return Log(x) + ln2 // acosh(huge)=log(2x)
+	} else if (uint32(hx-0x3FF00000) | lowWord(x)) == 0 {
+		return 0 // acosh(1) = 0
+	} else if hx > 0x40000000 { // 2**28 > x > 2
+		t := float64(x * x)
+		return Log(float64(2.0*x) - 1/(x+math.Sqrt(t-1)))
+	}
+	// 1 < x < 2
+	t := x - 1
+	return Log1p(t + math.Sqrt(float64(2.0*t)+float64(t*t)))
+}
+
+// Atanh returns the inverse hyperbolic tangent of x.
+func Atanh(x float64) float64 {
+	var t float64
+	hx, lx := highWord(x), lowWord(x)
+	ix := hx & 0x7FFFFFFF
+	if (uint32(ix) | ((lx | -lx) >> 31)) > 0x3FF00000 { // |x| > 1
+		return (x - x) / (x - x)
+	}
+	if ix == 0x3FF00000 {
+		return math.Inf(int(hx>>31) | 1)
+	}
+	if ix < 0x3E300000 { // x < 2**-28
+		return x
+	}
+	x = withHighWord(x, uint32(ix))
+	if ix < 0x3FE00000 { // x < 0.5
+		t = x + x
+		t = 0.5 * Log1p(t+float64(t*x)/(1-x))
+	} else {
+		t = 0.5 * Log1p((x+x)/(1-x))
+	}
+	if hx >= 0 {
+		return t
+	}
+	return -t
+}
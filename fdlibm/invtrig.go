@@ -0,0 +1,286 @@
+package fdlibm
+
+import "math"
+
+const (
+	pi      float64 = 3.14159265358979311600e+00 // 0x400921FB, 0x54442D18
+	pio2_hi float64 = 1.57079632679489655800e+00 // 0x3FF921FB, 0x54442D18
+	pio2_lo float64 = 6.12323399573676603587e-17 // 0x3C91A626, 0x33145C07
+	pio4_hi float64 = 7.85398163397448278999e-01 // 0x3FE921FB, 0x54442D18
+
+	// coefficients for R(x^2)
+	pS0 = 1.66666666666666657415e-01  // 0x3FC55555, 0x55555555
+	pS1 = -3.25565818622400915405e-01 // 0xBFD4D612, 0x03EB6F7D
+	pS2 = 2.01212532134862925881e-01  // 0x3FC9C155, 0x0E884455
+	pS3 = -4.00555345006794114027e-02 // 0xBFA48228, 0xB5688F3B
+	pS4 = 7.91534994289814532176e-04  // 0x3F49EFE0, 0x7501B288
+	pS5 = 3.47933107596021167570e-05  // 0x3F023DE1, 0x0DFDF709
+	qS1 = -2.40339491173441421878e+00 // 0xC0033A27, 0x1C8A2D4B
+	qS2 = 2.02094576023350569471e+00  // 0x40002AE5, 0x9C598AC8
+	qS3 = -6.88283971605453293030e-01 // 0xBFE6066C, 0x1B8D0159
+	qS4 = 7.70381505559019352791e-02  // 0x3FB3B8C5, 0xB12E9282
+)
+
+var atanHi = [...]float64{
+	4.63647609000806093515e-01, // atan(0.5)hi 0x3FDDAC67, 0x0561BB4F
+	7.85398163397448278999e-01, // atan(1.0)hi 0x3FE921FB, 0x54442D18
+	9.82793723247329054082e-01, // atan(1.5)hi 0x3FEF730B, 0xD281F69B
+	1.57079632679489655800e+00, // atan(inf)hi 0x3FF921FB, 0x54442D18
+}
+
+var atanLo = [...]float64{
+	2.26987774529616870924e-17, // atan(0.5)lo 0x3C7A2B7F, 0x222F65E2
+	3.06161699786838301793e-17, // atan(1.0)lo 0x3C81A626, 0x33145C07
+	1.39033110312309984516e-17, // atan(1.5)lo 0x3C700788, 0x7AF0CBBD
+	6.12323399573676603587e-17, // atan(inf)lo 0x3C91A626, 0x33145C07
+}
+
+var aT = [...]float64{
+	3.33333333333329318027e-01,  // 0x3FD55555, 0x5555550D
+	-1.99999999998764832476e-01, // 0xBFC99999, 0x9998EBC4
+	1.42857142725034663711e-01,  // 0x3FC24924, 0x920083FF
+	-1.11111104054623557880e-01, // 0xBFBC71C6, 0xFE231671
+	9.09088713343650656196e-02,  // 0x3FB745CD, 0xC54C206E
+	-7.69187620504482999495e-02, // 0xBFB3B0F2, 0xAF749A6D
+	6.66107313738753120669e-02,  // 0x3FB10D66, 0xA0D03D51
+	-5.83357013379057348645e-02, // 0xBFADDE2D, 0x52DEFD9A
+	4.97687799461593236017e-02,  // 0x3FA97B4B, 0x24760DEB
+	-3.65315727442169155270e-02, // 0xBFA2B444, 0x2C6A6C2F
+	1.62858201153657823623e-02,  // 0x3F90AD3A, 0xE322DA11
+}
+
+// asinR computes the rational approximation R(t) = p(t)/q(t) used by Asin and Acos.
+func asinR(t float64) (p, q float64) {
+	p = t * (pS0 + float64(t*(pS1+float64(t*(pS2+float64(t*(pS3+float64(t*(pS4+float64(t*pS5))))))))))
+	q = 1 + float64(t*(qS1+float64(t*(qS2+float64(t*(qS3+float64(t*qS4)))))))
+	return
+}
+
+// Asin returns the arcsine of x.
+func Asin(x float64) float64 {
+	hx := highWord(x)
+	ix := hx & 0x7FFFFFFF
+	if ix >= 0x3FF00000 { // |x| >= 1
+		if (uint32(ix-0x3FF00000) | lowWord(x)) == 0 { // asin(1)=+-pi/2 with inexact
+			return float64(x*pio2_hi) + float64(x*pio2_lo)
+		}
+		return (x - x) / (x - x) // asin(|x|>1) is NaN
+	} else if ix < 0x3FE00000 { // |x| < 0.5
+		if ix < 0x3E400000 { // |x| < 2**-27
+			return x
+		}
+		t := x * x
+		p, q := asinR(t)
+		w := p / q
+		return x + float64(x*w)
+	}
+	// 1 > |x| >= 0.5
+	w := 1 - math.Abs(x)
+	t := float64(w * 0.5)
+	p, q := asinR(t)
+	s := math.Sqrt(t)
+	if ix >= 0x3FEF3333 { // |x| > 0.975
+		w = p / q
+		t = pio2_hi - (float64(2.0*(s+float64(s*w))) - pio2_lo)
+	} else {
+		w = withLowWord(s, 0)
+		c := (t - float64(w*w)) / (s + w)
+		r := p / q
+		p = float64(2.0*s*r) - (pio2_lo - float64(2.0*c))
+		q = pio4_hi - float64(2.0*w)
+		t = pio4_hi - (p - q)
+	}
+	if hx > 0 {
+		return t
+	}
+	return -t
+}
+
+// Acos returns the arccosine of x.
+func Acos(x float64) float64 {
+	hx := highWord(x)
+	ix := hx & 0x7FFFFFFF
+	if ix >= 0x3FF00000 { // |x| >= 1
+		if (uint32(ix-0x3FF00000) | lowWord(x)) == 0 { // |x| == 1
+			if hx > 0 { // acos(1) = 0
+				return 0.0
+			}
+			return pi + float64(2.0*pio2_lo) // acos(-1) = pi
+		}
+		return (x - x) / (x - x) // acos(|x|>1) is NaN
+	}
+	if ix < 0x3FE00000 { // |x| < 0.5
+		if ix <= 0x3C600000 { // |x| < 2**-57
+			return pio2_hi + pio2_lo
+		}
+		z := x * x
+		p, q := asinR(z)
+		r := p / q
+		return pio2_hi - (x - (pio2_lo - float64(x*r)))
+	} else if hx < 0 { // x < -0.5
+		z := float64((1 + x) * 0.5)
+		p, q := asinR(z)
+		s := math.Sqrt(z)
+		r := p / q
+		w := float64(r*s) - pio2_lo
+		return pi - float64(2.0*(s+w))
+	}
+	// x > 0.5
+	z := float64((1 - x) * 0.5)
+	s := math.Sqrt(z)
+	df := withLowWord(s, 0)
+	c := (z - float64(df*df)) / (s + df)
+	p, q := asinR(z)
+	r := p / q
+	w := float64(r*s) + c
+	return 2.0 * (df + w)
+}
+
+// Atan returns the arctangent of x.
+func Atan(x float64) float64 {
+	hx := highWord(x)
+	ix := hx & 0x7FFFFFFF
+	var id int
+	if ix >= 0x44100000 { // |x| >= 2^66
+		if ix > 0x7FF00000 || (ix == 0x7FF00000 && lowWord(x) != 0) {
+			return x + x // NaN
+		}
+		if hx > 0 {
+			return atanHi[3] + atanLo[3]
+		}
+		return -atanHi[3] - atanLo[3]
+	}
+	if ix < 0x3FDC0000 { // |x| < 0.4375
+		if ix < 0x3E400000 { // |x| < 2^-27
+			return x
+		}
+		id = -1
+	} else {
+		x = math.Abs(x)
+		if ix < 0x3FF30000 { // |x| < 1.1875
+			if ix < 0x3FE60000 { // 7/16 <= |x| < 11/16
+				id = 0
+				x = (float64(2.0*x) - 1) / (2.0 + x)
+			} else { // 11/16 <= |x| < 19/16
+				id = 1
+				x = (x - 1) / (x + 1)
+			}
+		} else {
+			if ix < 0x40038000 { // |x| < 2.4375
+				id = 2
+				x = (x - 1.5) / (1 + float64(1.5*x))
+			} else { // 2.4375 <= |x| < 2^66
+				id = 3
+				x = -1.0 / x
+			}
+		}
+	}
+	// end of argument reduction
+	z := x * x
+	w := z * z
+	// break sum from i=0 to 10 aT[i]z**(i+1) into odd and even poly
+	s1 := float64(z * (aT[0] + float64(w*(aT[2]+float64(w*(aT[4]+float64(w*(aT[6]+float64(w*(aT[8]+float64(w*aT[10])))))))))))
+	s2 := float64(w * (aT[1] + float64(w*(aT[3]+float64(w*(aT[5]+float64(w*(aT[7]+float64(w*aT[9])))))))))
+	if id < 0 {
+		return x - float64(x*(s1+s2))
+	}
+	z = atanHi[id] - ((float64(x*(s1+s2)) - atanLo[id]) - x)
+	if hx < 0 {
+		return -z
+	}
+	return z
+}
+
+// Atan2 returns the arctangent of y/x, using the signs of the two to determine the quadrant of the return value.
+func Atan2(y, x float64) float64 {
+	const (
+		pi_o_4 float64 = 7.8539816339744827900e-01 // 0x3FE921FB, 0x54442D18
+		pi_o_2 float64 = 1.5707963267948965580e+00 // 0x3FF921FB, 0x54442D18
+		pi_lo  float64 = 1.2246467991473531772e-16 // 0x3CA1A626, 0x33145C07
+	)
+
+	hx, lx := highWord(x), lowWord(x)
+	ix := hx & 0x7FFFFFFF
+	hy, ly := highWord(y), lowWord(y)
+	iy := hy & 0x7FFFFFFF
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return x + y
+	}
+	if (uint32(hx-0x3FF00000) | lx) == 0 { // x = 1.0
+		return Atan(y)
+	}
+	m := ((hy >> 31) & 1) | ((hx >> 30) & 2) // 2*sign(x)+sign(y)
+
+	// when y = 0
+	if (uint32(iy) | ly) == 0 {
+		switch m {
+		case 0, 1:
+			return y // atan(+-0,+anything)=+-0
+		case 2:
+			return pi // atan(+0,-anything) = pi
+		default:
+			return -pi // atan(-0,-anything) =-pi
+		}
+	}
+	// when x = 0
+	if (uint32(ix) | lx) == 0 {
+		if hy < 0 {
+			return -pi_o_2
+		}
+		return pi_o_2
+	}
+	// when x is INF
+	if ix == 0x7FF00000 {
+		if iy == 0x7FF00000 {
+			switch m {
+			case 0:
+				return pi_o_4 // atan(+INF,+INF)
+			case 1:
+				return -pi_o_4 // atan(-INF,+INF)
+			case 2:
+				return 3.0 * pi_o_4 // atan(+INF,-INF)
+			default:
+				return -3.0 * pi_o_4 // atan(-INF,-INF)
+			}
+		}
+		switch m {
+		case 0:
+			return 0 // atan(+...,+INF)
+		case 1:
+			return math.Copysign(0, -1) // atan(-...,+INF)
+		case 2:
+			return pi // atan(+...,-INF)
+		default:
+			return -pi // atan(-...,-INF)
+		}
+	}
+	// when y is INF
+	if iy == 0x7FF00000 {
+		if hy < 0 {
+			return -pi_o_2
+		}
+		return pi_o_2
+	}
+
+	// compute y/x
+	var z float64
+	k := (iy - ix) >> 20
+	if k > 60 { // |y/x| > 2**60
+		z = pi_o_2 + 0.5*pi_lo
+		m &= 1
+	} else if hx < 0 && k < -60 { // 0 > |y|/x > -2**-60
+		z = 0.0
+	} else { // safe to do y/x
+		z = Atan(math.Abs(y / x))
+	}
+	switch m {
+	case 0:
+		return z // atan(+,+)
+	case 1:
+		return -z // atan(-,+)
+	case 2:
+		return pi - (z - pi_lo) // atan(+,-)
+	default:
+		return (z - pi_lo) - pi // atan(-,-)
+	}
+}
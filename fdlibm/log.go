@@ -0,0 +1,278 @@
+package fdlibm
+
+import "math"
+
+const (
+	lg1 = 6.666666666666735130e-01 // 3FE55555 55555593
+	lg2 = 3.999999999940941908e-01 // 3FD99999 9997FA04
+	lg3 = 2.857142874366239149e-01 // 3FD24924 94229359
+	lg4 = 2.222219843214978396e-01 // 3FCC71C5 1D8E78AF
+	lg5 = 1.818357216161805012e-01 // 3FC74664 96CB03DE
+	lg6 = 1.531383769920937332e-01 // 3FC39A09 D078C69F
+	lg7 = 1.479819860511658591e-01 // 3FC2F112 DF3E5244
+)
+
+// kLog1p returns log(1+f) - f + f*f/2 for 1+f in [sqrt(2)/2, sqrt(2)].
+func kLog1p(f float64) float64 {
+	s := f / (2.0 + f)
+	z := s * s
+	w := z * z
+	t1 := float64(w * (lg2 + float64(w*(lg4+float64(w*lg6)))))
+	t2 := float64(z * (lg1 + float64(w*(lg3+float64(w*(lg5+float64(w*lg7)))))))
+	R := t2 + t1
+	hfsq := float64(0.5 * f * f)
+	return float64(s * (hfsq + R))
+}
+
+// Log returns the natural logarithm of x.
+func Log(x float64) float64 {
+	hx, lx := highWord(x), lowWord(x)
+
+	k := int32(0)
+	if hx < 0x00100000 { // x < 2**-1022
+		if (uint32(hx&0x7FFFFFFF) | lx) == 0 { // log(+-0)=-inf
+			return math.Inf(-1)
+		}
+		if hx < 0 { // log(-#) = NaN
+			return math.NaN()
+		}
+		k -= 54
+		x *= two54 // subnormal number, scale up x
+		hx = highWord(x)
+	}
+	if hx >= 0x7FF00000 {
+		return x + x
+	}
+	k += (hx >> 20) - 1023
+	hx &= 0x000FFFFF
+	i := (hx + 0x95F64) & 0x100000
+	x = withHighWord(x, uint32(hx|(i^0x3FF00000))) // normalize x or x/2
+	k += i >> 20
+	f := x - 1.0
+	if (0x000FFFFF & (2 + hx)) < 3 { // -2**-20 <= f < 2**-20
+		if f == 0 {
+			if k == 0 {
+				return 0
+			}
+			dk := float64(k)
+			return float64(dk*ln2_hi) + float64(dk*ln2_lo)
+		}
+		R := float64(f * f * (0.5 - float64(0.33333333333333333*f)))
+		if k == 0 {
+			return f - R
+		}
+		dk := float64(k)
+		return float64(dk*ln2_hi) - ((R - float64(dk*ln2_lo)) - f)
+	}
+	s := f / (2.0 + f)
+	dk := float64(k)
+	z := s * s
+	i = hx - 0x6147A
+	w := z * z
+	j := 0x6B851 - hx
+	t1 := float64(w * (lg2 + float64(w*(lg4+float64(w*lg6)))))
+	t2 := float64(z * (lg1 + float64(w*(lg3+float64(w*(lg5+float64(w*lg7)))))))
+	i |= j
+	R := t2 + t1
+	if i > 0 {
+		hfsq := float64(0.5 * f * f)
+		if k == 0 {
+			return f - (hfsq - float64(s*(hfsq+R)))
+		}
+		return float64(dk*ln2_hi) - ((hfsq - (float64(s*(hfsq+R)) + float64(dk*ln2_lo))) - f)
+	}
+	if k == 0 {
+		return f - float64(s*(f-R))
+	}
+	return float64(dk*ln2_hi) - ((float64(s*(f-R)) - float64(dk*ln2_lo)) - f)
+}
+
+// Log1p returns the natural logarithm of 1 plus its argument x. It is more accurate than Log(1 + x) when x is
+// near zero.
+func Log1p(x float64) float64 {
+	const (
+		Lp1 = 6.666666666666735130e-01 // 3FE55555 55555593
+		Lp2 = 3.999999999940941908e-01 // 3FD99999 9997FA04
+		Lp3 = 2.857142874366239149e-01 // 3FD24924 94229359
+		Lp4 = 2.222219843214978396e-01 // 3FCC71C5 1D8E78AF
+		Lp5 = 1.818357216161805012e-01 // 3FC74664 96CB03DE
+		Lp6 = 1.531383769920937332e-01 // 3FC39A09 D078C69F
+		Lp7 = 1.479819860511658591e-01 // 3FC2F112 DF3E5244
+	)
+
+	var f, c float64
+	var hu int32
+	hx := highWord(x)
+	ax := hx & 0x7FFFFFFF
+
+	k := int32(1)
+	if hx < 0x3FDA827A { // 1+x < sqrt(2)+
+		if ax >= 0x3FF00000 { // x <= -1.0
+			if x == -1.0 { // log1p(-1)=-inf
+				return math.Inf(-1)
+			}
+			return math.NaN() // log1p(x<-1)=NaN
+		}
+		if ax < 0x3E200000 { // |x| < 2**-29
+			if ax < 0x3C900000 { // |x| < 2**-54
+				return x
+			}
+			return x - float64(x*x*0.5)
+		}
+		if hx > 0 || hx <= int32(-1076707644) { // sqrt(2)/2- <= 1+x < sqrt(2)+, 0xBFD2BEC4
+			k = 0
+			f = x
+			hu = 1
+		}
+	}
+	if hx >= 0x7FF00000 {
+		return x + x
+	}
+	if k != 0 {
+		var u float64
+		if hx < 0x43400000 {
+			u = 1.0 + x
+			hu = highWord(u)
+			k = (hu >> 20) - 1023
+			if k > 0 { // correction term
+				c = 1.0 - (u - x)
+			} else {
+				c = x - (u - 1.0)
+			}
+			c /= u
+		} else {
+			u = x
+			hu = highWord(u)
+			k = (hu >> 20) - 1023
+			c = 0
+		}
+		hu &= 0x000FFFFF
+		// The approximation to sqrt(2) used in thresholds is not critical. However, the ones used above must
+		// give less strict bounds than the one here so that the k==0 case is never reached from here, since
+		// here we have committed to using the correction term but don't use it if k==0.
+		if hu < 0x6A09E { // u ~< sqrt(2)
+			u = withHighWord(u, uint32(hu|0x3FF00000)) // normalize u
+		} else {
+			k++
+			u = withHighWord(u, uint32(hu|0x3FE00000)) // normalize u/2
+			hu = (0x00100000 - hu) >> 2
+		}
+		f = u - 1.0
+	}
+	hfsq := float64(0.5 * f * f)
+	dk := float64(k)
+	if hu == 0 { // |f| < 2**-20
+		if f == 0 {
+			if k == 0 {
+				return 0
+			}
+			c += float64(dk * ln2_lo)
+			return float64(dk*ln2_hi) + c
+		}
+		R := float64(hfsq * (1.0 - float64(0.66666666666666666*f)))
+		if k == 0 {
+			return f - R
+		}
+		return float64(dk*ln2_hi) - ((R - (float64(dk*ln2_lo) + c)) - f)
+	}
+	s := f / (2.0 + f)
+	z := s * s
+	R := float64(z * (Lp1 + float64(z*(Lp2+float64(z*(Lp3+float64(z*(Lp4+float64(z*(Lp5+float64(z*(Lp6+float64(z*Lp7)))))))))))))
+	if k == 0 {
+		return f - (hfsq - float64(s*(hfsq+R)))
+	}
+	return float64(dk*ln2_hi) - ((hfsq - (float64(s*(hfsq+R)) + (float64(dk*ln2_lo) + c))) - f)
+}
+
+// Log2 returns the binary logarithm of x.
+func Log2(x float64) float64 {
+	const (
+		ivln2hi = 1.44269504072144627571e+00 // 0x3ff71547, 0x65200000
+		ivln2lo = 1.67517131648865118353e-10 // 0x3de705fc, 0x2eefa200
+	)
+
+	hx, lx := highWord(x), lowWord(x)
+
+	k := int32(0)
+	if hx < 0x00100000 { // x < 2**-1022
+		if (uint32(hx&0x7FFFFFFF) | lx) == 0 { // log(+-0)=-inf
+			return math.Inf(-1)
+		}
+		if hx < 0 { // log(-#) = NaN
+			return math.NaN()
+		}
+		k -= 54
+		x *= two54 // subnormal number, scale up x
+		hx = highWord(x)
+	}
+	if hx >= 0x7FF00000 {
+		return x + x
+	}
+	if hx == 0x3FF00000 && lx == 0 { // log(1) = +0
+		return 0
+	}
+	k += (hx >> 20) - 1023
+	hx &= 0x000FFFFF
+	i := (hx + 0x95F64) & 0x100000
+	x = withHighWord(x, uint32(hx|(i^0x3FF00000))) // normalize x or x/2
+	k += i >> 20
+	y := float64(k)
+	f := x - 1.0
+	hfsq := float64(0.5 * f * f)
+	r := kLog1p(f)
+
+	// f-hfsq must (for args near 1) be evaluated in extra precision to avoid a large cancellation when x is
+	// near sqrt(2) or 1/sqrt(2). This is fairly efficient since f-hfsq only depends on f, so can be evaluated
+	// in parallel with R. Not combining hfsq with R also keeps R small (though not as small as a true 'lo' term
+	// would be), so that extra precision is not needed for terms involving R.
+	hi := withLowWord(f-hfsq, 0)
+	lo := (f - hi) - hfsq + r
+	valHi := float64(hi * ivln2hi)
+	valLo := float64((lo+hi)*ivln2lo) + float64(lo*ivln2hi)
+
+	// spadd(valHi, valLo, y), except for not using double_t
+	w := y + valHi
+	valLo += (y - w) + valHi
+	valHi = w
+
+	return valLo + valHi
+}
+
+// Log10 returns the decimal logarithm of x.
+func Log10(x float64) float64 {
+	const (
+		ivln10    = 4.34294481903251816668e-01 // 0x3FDBCB7B, 0x1526E50E
+		log10_2hi = 3.01029995663611771306e-01 // 0x3FD34413, 0x509F6000
+		log10_2lo = 3.69423907715893078616e-13 // 0x3D59FEF3, 0x11F12B36
+	)
+
+	hx, lx := highWord(x), lowWord(x)
+
+	k := int32(0)
+	if hx < 0x00100000 { // x < 2**-1022
+		if (uint32(hx&0x7FFFFFFF) | lx) == 0 { // log(+-0)=-inf
+			return math.Inf(-1)
+		}
+		if hx < 0 { // log(-#) = NaN
+			return math.NaN()
+		}
+		k -= 54
+		x *= two54 // subnormal number, scale up x
+		hx, lx = highWord(x), lowWord(x)
+	}
+	if hx >= 0x7FF00000 {
+		return x + x
+	}
+	if hx == 0x3FF00000 && lx == 0 { // log(1) = +0
+		return 0
+	}
+	k += (hx >> 20) - 1023
+
+	i := int32(uint32(k) >> 31)
+	hx = (hx & 0x000FFFFF) | ((0x3FF - i) << 20)
+	y := float64(k + i)
+	x = fromWords(uint32(hx), lx)
+
+	z := float64(y*log10_2lo) + float64(ivln10*Log(x))
+	return z + float64(y*log10_2hi)
+}
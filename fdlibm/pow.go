@@ -0,0 +1,284 @@
+package fdlibm
+
+import "math"
+
+// Pow returns x**y, the base-x exponential of y. This is the variant of the fdlibm algorithm used by V8. The special
+// cases are those of fdlibm, in particular Pow(1, NaN) and Pow(+-1, +-Inf) are NaN, as required by ECMAScript.
+func Pow(x, y float64) float64 {
+	const (
+		two53 = 9007199254740992.0 // 0x43400000, 0x00000000
+
+		// poly coefs for (3/2)*(log(x)-2s-2/3*s**3
+		L1 = 5.99999999999994648725e-01 // 0x3FE33333, 0x33333303
+		L2 = 4.28571428578550184252e-01 // 0x3FDB6DB6, 0xDB6FABFF
+		L3 = 3.33333329818377432918e-01 // 0x3FD55555, 0x518F264D
+		L4 = 2.72728123808534006489e-01 // 0x3FD17460, 0xA91D4101
+		L5 = 2.30660745775561754067e-01 // 0x3FCD864A, 0x93C9DB65
+		L6 = 2.06975017800338417784e-01 // 0x3FCA7E28, 0x4A454EEF
+
+		lg2_h   = 6.93147182464599609375e-01  // 0x3FE62E43, 0x00000000
+		lg2_l   = -1.90465429995776804525e-09 // 0xBE205C61, 0x0CA86C39
+		ovt     = 8.0085662595372944372e-17   // -(1024-log2(ovfl+.5ulp))
+		cp      = 9.61796693925975554329e-01  // 0x3FEEC709, 0xDC3A03FD =2/(3ln2)
+		cp_h    = 9.61796700954437255859e-01  // 0x3FEEC709, 0xE0000000 =(float)cp
+		cp_l    = -7.02846165095275826516e-09 // 0xBE3E2FE0, 0x145B01F5 =tail of cp_h
+		ivln2_h = 1.44269502162933349609e+00  // 0x3FF71547, 0x60000000 =24b 1/ln2
+		ivln2_l = 1.92596299112661746887e-08  // 0x3E54AE0B, 0xF85DDF44 =1/ln2 tail
+	)
+	var (
+		bp   = [2]float64{1.0, 1.5}
+		dp_h = [2]float64{0.0, 5.84962487220764160156e-01} // 0x3FE2B803, 0x40000000
+		dp_l = [2]float64{0.0, 1.35003920212974897128e-08} // 0x3E4CFDEB, 0x43CFD006
+	)
+
+	var t1, t2 float64
+
+	hx, lx := highWord(x), lowWord(x)
+	hy, ly := highWord(y), lowWord(y)
+	ix := hx & 0x7FFFFFFF
+	iy := hy & 0x7FFFFFFF
+
+	// y==zero: x**0 = 1
+	if (uint32(iy) | ly) == 0 {
+		return 1
+	}
+
+	// +-NaN return x+y
+	if ix > 0x7FF00000 || ((ix == 0x7FF00000) && (lx != 0)) || iy > 0x7FF00000 || ((iy == 0x7FF00000) && (ly != 0)) {
+		return x + y
+	}
+
+	// determine if y is an odd int when x < 0
+	// yisint = 0 ... y is not an integer
+	// yisint = 1 ... y is an odd int
+	// yisint = 2 ... y is an even int
+	yisint := int32(0)
+	if hx < 0 {
+		if iy >= 0x43400000 {
+			yisint = 2 // even integer y
+		} else if iy >= 0x3FF00000 {
+			k := (iy >> 20) - 0x3FF // exponent
+			if k > 20 {
+				j := ly >> (52 - k)
+				if (j << (52 - k)) == ly {
+					yisint = 2 - int32(j&1)
+				}
+			} else if ly == 0 {
+				j := iy >> (20 - k)
+				if (j << (20 - k)) == iy {
+					yisint = 2 - (j & 1)
+				}
+			}
+		}
+	}
+
+	// special value of y
+	if ly == 0 {
+		if iy == 0x7FF00000 { // y is +-inf
+			if (uint32(ix-0x3FF00000) | lx) == 0 {
+				return y - y // inf**+-1 is NaN
+			} else if ix >= 0x3FF00000 { // (|x|>1)**+-inf = inf,0
+				if hy >= 0 {
+					return y
+				}
+				return 0
+			}
+			// (|x|<1)**-,+inf = inf,0
+			if hy < 0 {
+				return -y
+			}
+			return 0
+		}
+		if iy == 0x3FF00000 { // y is +-1
+			if hy < 0 {
+				return 1 / x
+			}
+			return x
+		}
+		if hy == 0x40000000 { // y is 2
+			return x * x
+		}
+		if hy == 0x3FE00000 { // y is 0.5
+			if hx >= 0 { // x >= +0
+				return math.Sqrt(x)
+			}
+		}
+	}
+
+	ax := math.Abs(x)
+	// special value of x
+	if lx == 0 {
+		if ix == 0x7FF00000 || ix == 0 || ix == 0x3FF00000 {
+			z := ax // x is +-0,+-inf,+-1
+			if hy < 0 {
+				z = 1 / z // z = (1/|x|)
+			}
+			if hx < 0 {
+				if ((ix - 0x3FF00000) | yisint) == 0 {
+					z = math.NaN() // (-1)**non-int is NaN
+				} else if yisint == 1 {
+					z = -z // (x<0)**odd = -(|x|**odd)
+				}
+			}
+			return z
+		}
+	}
+
+	n := (hx >> 31) + 1
+
+	// (x<0)**(non-int) is NaN
+	if (n | yisint) == 0 {
+		return math.NaN()
+	}
+
+	s := 1.0 // s (sign of result -ve**odd) = -1 else = 1
+	if (n | (yisint - 1)) == 0 {
+		s = -1 // (-ve)**(odd int)
+	}
+
+	// |y| is huge
+	if iy > 0x41E00000 { // if |y| > 2**31
+		if iy > 0x43F00000 { // if |y| > 2**64, must o/uflow
+			if ix <= 0x3FEFFFFF {
+				if hy < 0 {
+					return math.Inf(1)
+				}
+				return 0
+			}
+			if ix >= 0x3FF00000 {
+				if hy > 0 {
+					return math.Inf(1)
+				}
+				return 0
+			}
+		}
+		// over/underflow if x is not close to one
+		if ix < 0x3FEFFFFF {
+			if hy < 0 {
+				return s * math.Inf(1)
+			}
+			return s * 0
+		}
+		if ix > 0x3FF00000 {
+			if hy > 0 {
+				return s * math.Inf(1)
+			}
+			return s * 0
+		}
+		// now |1-x| is tiny <= 2**-20, suffice to compute log(x) by x-x^2/2+x^3/3-x^4/4
+		t := ax - 1 // t has 20 trailing zeros
+		w := float64((t * t) * (0.5 - float64(t*(0.3333333333333333333333-float64(t*0.25)))))
+		u := float64(ivln2_h * t) // ivln2_h has 21 sig. bits
+		v := float64(t*ivln2_l) - float64(w*invln2)
+		t1 = withLowWord(u+v, 0)
+		t2 = v - (t1 - u)
+	} else {
+		n = 0
+		// take care subnormal number
+		if ix < 0x00100000 {
+			ax *= two53
+			n -= 53
+			ix = highWord(ax)
+		}
+		n += (ix >> 20) - 0x3FF
+		j := ix & 0x000FFFFF
+		// determine interval
+		var k int32
+		ix = j | 0x3FF00000 // normalize ix
+		if j <= 0x3988E {   // |x|<sqrt(3/2)
+			k = 0
+		} else if j < 0xBB67A { // |x|<sqrt(3)
+			k = 1
+		} else {
+			k = 0
+			n++
+			ix -= 0x00100000
+		}
+		ax = withHighWord(ax, uint32(ix))
+
+		// compute ss = s_h+s_l = (x-1)/(x+1) or (x-1.5)/(x+1.5)
+		u := ax - bp[k] // bp[0]=1.0, bp[1]=1.5
+		v := 1 / (ax + bp[k])
+		ss := float64(u * v)
+		s_h := withLowWord(ss, 0)
+		// t_h=ax+bp[k] High
+		t_h := fromWords(uint32(((ix>>1)|0x20000000)+0x00080000+(k<<18)), 0)
+		t_l := ax - (t_h - bp[k])
+		s_l := v * ((u - float64(s_h*t_h)) - float64(s_h*t_l))
+		// compute log(ax)
+		s2 := ss * ss
+		r := float64(s2 * s2 * (L1 + float64(s2*(L2+float64(s2*(L3+float64(s2*(L4+float64(s2*(L5+float64(s2*L6)))))))))))
+		r += float64(s_l * (s_h + ss))
+		s2 = float64(s_h * s_h)
+		t_h = withLowWord(3.0+s2+r, 0)
+		t_l = r - ((t_h - 3.0) - s2)
+		// u+v = ss*(1+...)
+		u = float64(s_h * t_h)
+		v = float64(s_l*t_h) + float64(t_l*ss)
+		// 2/(3log2)*(ss+...)
+		p_h := withLowWord(u+v, 0)
+		p_l := v - (p_h - u)
+		z_h := float64(cp_h * p_h) // cp_h+cp_l = 2/(3*log2)
+		z_l := float64(cp_l*p_h) + float64(p_l*cp) + dp_l[k]
+		// log2(ax) = (ss+..)*2/(3*log2) = n + dp_h + z_h + z_l
+		t := float64(n)
+		t1 = withLowWord(((z_h+z_l)+dp_h[k])+t, 0)
+		t2 = z_l - (((t1 - t) - dp_h[k]) - z_h)
+	}
+
+	// split up y into y1+y2 and compute (y1+y2)*(t1+t2)
+	y1 := withLowWord(y, 0)
+	p_l := float64((y-y1)*t1) + float64(y*t2)
+	p_h := float64(y1 * t1)
+	z := p_l + p_h
+	j, i := highWord(z), lowWord(z)
+	if j >= 0x40900000 { // z >= 1024
+		if (uint32(j-0x40900000) | i) != 0 { // if z > 1024
+			return s * math.Inf(1) // overflow
+		}
+		if p_l+ovt > z-p_h {
+			return s * math.Inf(1) // overflow
+		}
+	} else if (j & 0x7FFFFFFF) >= 0x4090CC00 { // z <= -1075
+		if (uint32(j)-0xC090CC00)|i != 0 { // z < -1075
+			return s * 0 // underflow
+		}
+		if p_l <= z-p_h {
+			return s * 0 // underflow
+		}
+	}
+
+	// compute 2**(p_h+p_l)
+	ii := j & 0x7FFFFFFF
+	k := (ii >> 20) - 0x3FF
+	n = 0
+	if ii > 0x3FE00000 { // if |z| > 0.5, set n = [z+0.5]
+		n = j + (0x00100000 >> (k + 1))
+		k = ((n & 0x7FFFFFFF) >> 20) - 0x3FF // new k for n
+		t := fromWords(uint32(n&^(0x000FFFFF>>k)), 0)
+		n = ((n & 0x000FFFFF) | 0x00100000) >> (20 - k)
+		if j < 0 {
+			n = -n
+		}
+		p_h -= t
+	}
+	t := withLowWord(p_l+p_h, 0)
+	u := float64(t * lg2_h)
+	v := float64((p_l-(t-p_h))*ln2) + float64(t*lg2_l)
+	z = u + v
+	w := v - (z - u)
+	t = z * z
+	t1 = z - float64(t*(expP1+float64(t*(expP2+float64(t*(expP3+float64(t*(expP4+float64(t*expP5)))))))))
+	// V8 divides by (t1-2)-(w+z*w) instead of subtracting w+z*w from the quotient as fdlibm does, which changes
+	// the last bit of a few percent of the results. Do the same to match it.
+	r := float64(z*t1) / ((t1 - 2) - (w + float64(z*w)))
+	z = 1 - (r - z)
+	j = highWord(z)
+	j += n << 20
+	if (j >> 20) <= 0 {
+		z = math.Ldexp(z, int(n)) // subnormal output
+	} else {
+		z = withHighWord(z, uint32(j))
+	}
+	return s * z
+}
@@ -0,0 +1,316 @@
+package fdlibm
+
+import "math"
+
+// Table of constants for 2/pi, 396 hex digits (476 decimal) of 2/pi.
+var twoOverPi = [...]int32{
+	0xA2F983, 0x6E4E44, 0x1529FC, 0x2757D1, 0xF534DD, 0xC0DB62, 0x95993C,
+	0x439041, 0xFE5163, 0xABDEBB, 0xC561B7, 0x246E3A, 0x424DD2, 0xE00649,
+	0x2EEA09, 0xD1921C, 0xFE1DEB, 0x1CB129, 0xA73EE8, 0x8235F5, 0x2EBB44,
+	0x84E99C, 0x7026B4, 0x5F7E41, 0x3991D6, 0x398353, 0x39F49C, 0x845F8B,
+	0xBDF928, 0x3B1FF8, 0x97FFDE, 0x05980F, 0xEF2F11, 0x8B5A0A, 0x6D1F6D,
+	0x367ECF, 0x27CB09, 0xB74F46, 0x3F669E, 0x5FEA2D, 0x7527BA, 0xC7EBE5,
+	0xF17B3D, 0x0739F7, 0x8A5292, 0xEA6BFB, 0x5FB11F, 0x8D5D08, 0x560330,
+	0x46FC7B, 0x6BABF0, 0xCFBC20, 0x9AF436, 0x1DA9E3, 0x91615E, 0xE61B08,
+	0x659985, 0x5F14A0, 0x68408D, 0xFFD880, 0x4D7327, 0x310606, 0x1556CA,
+	0x73A8C9, 0x60E27B, 0xC08C6B,
+}
+
+// High words of n*pi/2 for n = 1..32.
+var npio2HighWords = [...]int32{
+	0x3FF921FB, 0x400921FB, 0x4012D97C, 0x401921FB, 0x401F6A7A, 0x4022D97C,
+	0x4025FDBB, 0x402921FB, 0x402C463A, 0x402F6A7A, 0x4031475C, 0x4032D97C,
+	0x40346B9C, 0x4035FDBB, 0x40378FDB, 0x403921FB, 0x403AB41B, 0x403C463A,
+	0x403DD85A, 0x403F6A7A, 0x40407E4C, 0x4041475C, 0x4042106C, 0x4042D97C,
+	0x4043A28C, 0x40446B9C, 0x404534AC, 0x4045FDBB, 0x4046C6CB, 0x40478FDB,
+	0x404858EB, 0x404921FB,
+}
+
+// pi/2 split into 24-bit chunks.
+var pio2Chunks = [...]float64{
+	1.57079625129699707031e+00, // 0x3FF921FB, 0x40000000
+	7.54978941586159635335e-08, // 0x3E74442D, 0x00000000
+	5.39030252995776476554e-15, // 0x3CF84698, 0x80000000
+	3.28200341580791294123e-22, // 0x3B78CC51, 0x60000000
+	1.27065575308067607349e-29, // 0x39F01B83, 0x80000000
+	1.22933308981111328932e-36, // 0x387A2520, 0x40000000
+	2.73370053816464559624e-44, // 0x36E38222, 0x80000000
+	2.16741683877804819444e-51, // 0x3569F31D, 0x00000000
+}
+
+const (
+	invpio2 = 6.36619772367581382433e-01 // 0x3FE45F30, 0x6DC9C883, 53 bits of 2/pi
+	pio2_1  = 1.57079632673412561417e+00 // 0x3FF921FB, 0x54400000, first 33 bits of pi/2
+	pio2_1t = 6.07710050650619224932e-11 // 0x3DD0B461, 0x1A626331, pi/2 - pio2_1
+	pio2_2  = 6.07710050630396597660e-11 // 0x3DD0B461, 0x1A600000, second 33 bits of pi/2
+	pio2_2t = 2.02226624879595063154e-21 // 0x3BA3198A, 0x2E037073, pi/2 - (pio2_1+pio2_2)
+	pio2_3  = 2.02226624871116645580e-21 // 0x3BA3198A, 0x2E000000, third 33 bits of pi/2
+	pio2_3t = 8.47842766036889956997e-32 // 0x397B839A, 0x252049C1, pi/2 - (pio2_1+pio2_2+pio2_3)
+)
+
+// remPio2 returns n and y0+y1 such that x = n*pi/2 + y0 + y1, |y0+y1| <= pi/4. For large arguments only
+// the lowest 3 bits of n are correct, which is enough for the trigonometric functions.
+func remPio2(x float64) (n int32, y0, y1 float64) {
+	hx := highWord(x)
+	ix := hx & 0x7FFFFFFF
+	if ix <= 0x3FE921FB { // |x| ~<= pi/4, no need for reduction
+		return 0, x, 0
+	}
+	if ix < 0x4002D97C { // |x| < 3pi/4, special case with n=+-1
+		if hx > 0 {
+			z := x - pio2_1
+			if ix != 0x3FF921FB { // 33+53 bit pi is good enough
+				y0 = z - pio2_1t
+				y1 = (z - y0) - pio2_1t
+			} else { // near pi/2, use 33+33+53 bit pi
+				z -= pio2_2
+				y0 = z - pio2_2t
+				y1 = (z - y0) - pio2_2t
+			}
+			return 1, y0, y1
+		}
+		z := x + pio2_1
+		if ix != 0x3FF921FB {
+			y0 = z + pio2_1t
+			y1 = (z - y0) + pio2_1t
+		} else {
+			z += pio2_2
+			y0 = z + pio2_2t
+			y1 = (z - y0) + pio2_2t
+		}
+		return -1, y0, y1
+	}
+	if ix <= 0x413921FB { // |x| ~<= 2^19*(pi/2), medium size
+		t := math.Abs(x)
+		n = int32(float64(t*invpio2) + 0.5)
+		fn := float64(n)
+		r := t - float64(fn*pio2_1)
+		w := float64(fn * pio2_1t) // 1st round good to 85 bit
+		if n < 32 && ix != npio2HighWords[n-1] {
+			y0 = r - w // quick check no cancellation
+		} else {
+			j := ix >> 20
+			y0 = r - w
+			i := j - ((highWord(y0) >> 20) & 0x7FF)
+			if i > 16 { // 2nd iteration needed, good to 118
+				t = r
+				w = float64(fn * pio2_2)
+				r = t - w
+				w = float64(fn*pio2_2t) - ((t - r) - w)
+				y0 = r - w
+				i = j - ((highWord(y0) >> 20) & 0x7FF)
+				if i > 49 { // 3rd iteration needed, 151 bits acc
+					t = r
+					w = float64(fn * pio2_3)
+					r = t - w
+					w = float64(fn*pio2_3t) - ((t - r) - w)
+					y0 = r - w
+				}
+			}
+		}
+		y1 = (r - y0) - w
+		if hx < 0 {
+			return -n, -y0, -y1
+		}
+		return n, y0, y1
+	}
+	// all other (large) arguments
+	if ix >= 0x7FF00000 { // x is inf or NaN
+		return 0, x - x, x - x
+	}
+	// set z = scalbn(|x|,ilogb(x)-23)
+	e0 := (ix >> 20) - 1046 // e0 = ilogb(z)-23
+	z := fromWords(uint32(ix-e0<<20), lowWord(x))
+	var tx [3]float64
+	for i := 0; i < 2; i++ {
+		tx[i] = float64(int32(z))
+		z = (z - tx[i]) * two24
+	}
+	tx[2] = z
+	nx := 3
+	for tx[nx-1] == 0 { // skip zero term
+		nx--
+	}
+	n, y0, y1 = kernelRemPio2(tx[:nx], e0)
+	if hx < 0 {
+		return -n, -y0, -y1
+	}
+	return n, y0, y1
+}
+
+// kernelRemPio2 is __kernel_rem_pio2 with prec = 2 (53-bit result returned as two doubles). x holds the input
+// split into 24-bit chunks, scaled by 2^-e0. The result is n mod 8 and y0+y1 = x - n*pi/2.
+func kernelRemPio2(x []float64, e0 int32) (int32, float64, float64) {
+	const (
+		jk = 4 // number of terms of ipio2[] used, init_jk[prec]
+		jp = jk
+	)
+	var (
+		iq        [20]int32
+		f, fq, q  [20]float64
+		z, fw     float64
+		n, ih     int32
+		carry     int32
+		jz, q0, i int32
+	)
+
+	// determine jx,jv,q0, note that 3>q0
+	jx := int32(len(x)) - 1
+	jv := (e0 - 3) / 24
+	if jv < 0 {
+		jv = 0
+	}
+	q0 = e0 - 24*(jv+1)
+
+	// set up f[0] to f[jx+jk] where f[jx+jk] = ipio2[jv+jk]
+	j := jv - jx
+	m := jx + jk
+	for i = 0; i <= m; i, j = i+1, j+1 {
+		if j >= 0 {
+			f[i] = float64(twoOverPi[j])
+		}
+	}
+
+	// compute q[0],q[1],...q[jk]
+	for i = 0; i <= jk; i++ {
+		fw = 0
+		for j = 0; j <= jx; j++ {
+			fw += float64(x[j] * f[jx+i-j])
+		}
+		q[i] = fw
+	}
+
+	jz = jk
+	for {
+		// distill q[] into iq[] reversingly
+		z = q[jz]
+		for i, j = 0, jz; j > 0; i, j = i+1, j-1 {
+			fw = float64(int32(twon24 * z))
+			iq[i] = int32(z - float64(two24*fw))
+			z = q[j-1] + fw
+		}
+
+		// compute n
+		z = math.Ldexp(z, int(q0))            // actual value of z
+		z -= float64(8 * math.Floor(z*0.125)) // trim off integer >= 8
+		n = int32(z)
+		z -= float64(n)
+		ih = 0
+		if q0 > 0 { // need iq[jz-1] to determine n
+			i = iq[jz-1] >> (24 - q0)
+			n += i
+			iq[jz-1] -= i << (24 - q0)
+			ih = iq[jz-1] >> (23 - q0)
+		} else if q0 == 0 {
+			ih = iq[jz-1] >> 23
+		} else if z >= 0.5 {
+			ih = 2
+		}
+
+		if ih > 0 { // q > 0.5
+			n++
+			carry = 0
+			for i = 0; i < jz; i++ { // compute 1-q
+				j = iq[i]
+				if carry == 0 {
+					if j != 0 {
+						carry = 1
+						iq[i] = 0x1000000 - j
+					}
+				} else {
+					iq[i] = 0xFFFFFF - j
+				}
+			}
+			switch q0 { // rare case: chance is 1 in 12
+			case 1:
+				iq[jz-1] &= 0x7FFFFF
+			case 2:
+				iq[jz-1] &= 0x3FFFFF
+			}
+			if ih == 2 {
+				z = 1 - z
+				if carry != 0 {
+					z -= math.Ldexp(1, int(q0))
+				}
+			}
+		}
+
+		// check if recomputation is needed
+		if z == 0 {
+			j = 0
+			for i = jz - 1; i >= jk; i-- {
+				j |= iq[i]
+			}
+			if j == 0 { // need recomputation
+				k := int32(1)
+				for k <= jk && iq[jk-k] == 0 { // k = no. of terms needed
+					k++
+				}
+				for i = jz + 1; i <= jz+k; i++ { // add q[jz+1] to q[jz+k]
+					f[jx+i] = float64(twoOverPi[jv+i])
+					fw = 0
+					for j = 0; j <= jx; j++ {
+						fw += float64(x[j] * f[jx+i-j])
+					}
+					q[i] = fw
+				}
+				jz += k
+				continue
+			}
+		}
+		break
+	}
+
+	// chop off zero terms
+	if z == 0 {
+		jz--
+		q0 -= 24
+		for iq[jz] == 0 {
+			jz--
+			q0 -= 24
+		}
+	} else { // break z into 24-bit if necessary
+		z = math.Ldexp(z, -int(q0))
+		if z >= two24 {
+			fw = float64(int32(twon24 * z))
+			iq[jz] = int32(z - float64(two24*fw))
+			jz++
+			q0 += 24
+			iq[jz] = int32(fw)
+		} else {
+			iq[jz] = int32(z)
+		}
+	}
+
+	// convert integer "bit" chunk to floating-point value
+	fw = math.Ldexp(1, int(q0))
+	for i = jz; i >= 0; i-- {
+		q[i] = fw * float64(iq[i])
+		fw *= twon24
+	}
+
+	// compute PIo2[0,...,jp]*q[jz,...,0]
+	for i = jz; i >= 0; i-- {
+		fw = 0
+		for k := int32(0); k <= jp && k <= jz-i; k++ {
+			fw += float64(pio2Chunks[k] * q[i+k])
+		}
+		fq[jz-i] = fw
+	}
+
+	// compress fq[] into y[]
+	fw = 0
+	for i = jz; i >= 0; i-- {
+		fw += fq[i]
+	}
+	y0 := fw
+	fw = fq[0] - fw
+	for i = 1; i <= jz; i++ {
+		fw += fq[i]
+	}
+	y1 := fw
+	if ih != 0 {
+		y0, y1 = -y0, -y1
+	}
+	return n & 7, y0, y1
+}
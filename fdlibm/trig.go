@@ -0,0 +1,192 @@
+package fdlibm
+
+import "math"
+
+const (
+	half = 5.00000000000000000000e-01
+
+	sinC1 = -1.66666666666666324348e-01 // 0xBFC55555, 0x55555549
+	sinC2 = 8.33333333332248946124e-03  // 0x3F811111, 0x1110F8A6
+	sinC3 = -1.98412698298579493134e-04 // 0xBF2A01A0, 0x19C161D5
+	sinC4 = 2.75573137070700676789e-06  // 0x3EC71DE3, 0x57B1FE7D
+	sinC5 = -2.50507602534068634195e-08 // 0xBE5AE5E6, 0x8A2B9CEB
+	sinC6 = 1.58969099521155010221e-10  // 0x3DE5D93A, 0x5ACFD57C
+
+	cosC1 = 4.16666666666666019037e-02  // 0x3FA55555, 0x5555554C
+	cosC2 = -1.38888888888741095749e-03 // 0xBF56C16C, 0x16C15177
+	cosC3 = 2.48015872894767294178e-05  // 0x3EFA01A0, 0x19CB1590
+	cosC4 = -2.75573143513906633035e-07 // 0xBE927E4F, 0x809C52AD
+	cosC5 = 2.08757232129817482790e-09  // 0x3E21EE9E, 0xBDB4B1C4
+	cosC6 = -1.13596475577881948265e-11 // 0xBDA8FAE9, 0xBE8838D4
+
+	pio4   = 7.85398163397448278999e-01 // 0x3FE921FB, 0x54442D18
+	pio4lo = 3.06161699786838301793e-17 // 0x3C81A626, 0x33145C07
+)
+
+var tanCoeffs = [...]float64{
+	3.33333333333334091986e-01,  // 0x3FD55555, 0x55555563
+	1.33333333333201242699e-01,  // 0x3FC11111, 0x1110FE7A
+	5.39682539762260521377e-02,  // 0x3FABA1BA, 0x1BB341FE
+	2.18694882948595424599e-02,  // 0x3F9664F4, 0x8406D637
+	8.86323982359930005737e-03,  // 0x3F8226E3, 0xE96E8493
+	3.59207910759131235356e-03,  // 0x3F6D6D22, 0xC9560328
+	1.45620945432529025516e-03,  // 0x3F57DBC8, 0xFEE08315
+	5.88041240820264096874e-04,  // 0x3F4344D8, 0xF2F26501
+	2.46463134818469906812e-04,  // 0x3F3026F7, 0x1A8D1068
+	7.81794442939557092300e-05,  // 0x3F147E88, 0xA03792A6
+	7.14072491382608190305e-05,  // 0x3F12B80F, 0x32F0A7E9
+	-1.85586374855275456654e-05, // 0xBEF375CB, 0xDB605373
+	2.59073051863633712884e-05,  // 0x3EFB2A70, 0x74BF7AD4
+}
+
+// kernelSin computes sin(x+y) for |x+y| <= pi/4, where y is the tail of x. iy indicates whether y is 0.
+func kernelSin(x, y float64, iy int) float64 {
+	ix := highWord(x) & 0x7FFFFFFF
+	if ix < 0x3E400000 { // |x| < 2**-27
+		return x
+	}
+	z := x * x
+	v := z * x
+	r := sinC2 + float64(z*(sinC3+float64(z*(sinC4+float64(z*(sinC5+float64(z*sinC6)))))))
+	if iy == 0 {
+		return x + float64(v*(sinC1+float64(z*r)))
+	}
+	return x - ((float64(z*(float64(half*y)-float64(v*r))) - y) - float64(v*sinC1))
+}
+
+// kernelCos computes cos(x+y) for |x+y| <= pi/4, where y is the tail of x.
+func kernelCos(x, y float64) float64 {
+	ix := highWord(x) & 0x7FFFFFFF
+	if ix < 0x3E400000 { // |x| < 2**-27
+		return 1
+	}
+	z := x * x
+	r := z * (cosC1 + float64(z*(cosC2+float64(z*(cosC3+float64(z*(cosC4+float64(z*(cosC5+float64(z*cosC6))))))))))
+	if ix < 0x3FD33333 { // |x| < 0.3
+		return 1 - (float64(0.5*z) - (float64(z*r) - float64(x*y)))
+	}
+	var qx float64
+	if ix > 0x3FE90000 { // |x| > 0.78125
+		qx = 0.28125
+	} else {
+		qx = fromWords(uint32(ix-0x00200000), 0) // x/4
+	}
+	hz := float64(0.5*z) - qx
+	a := 1 - qx
+	return a - (hz - (float64(z*r) - float64(x*y)))
+}
+
+// kernelTan computes tan(x+y) for |x+y| <= pi/4 if iy is 1 or -1/tan(x+y) if iy is -1.
+func kernelTan(x, y float64, iy int) float64 {
+	T := &tanCoeffs
+	hx := highWord(x)
+	ix := hx & 0x7FFFFFFF
+	if ix < 0x3E300000 { // |x| < 2**-28
+		if (uint32(ix) | lowWord(x) | uint32(iy+1)) == 0 {
+			return 1 / math.Abs(x)
+		}
+		if iy == 1 {
+			return x
+		}
+		// compute -1 / (x+y) carefully
+		w := x + y
+		z := withLowWord(w, 0)
+		v := y - (z - x)
+		a := -1 / w
+		t := withLowWord(a, 0)
+		s := 1 + float64(t*z)
+		return t + float64(a*(s+float64(t*v)))
+	}
+	if ix >= 0x3FE59428 { // |x| >= 0.6744
+		if hx < 0 {
+			x = -x
+			y = -y
+		}
+		z := pio4 - x
+		w := pio4lo - y
+		x = z + w
+		y = 0
+	}
+	z := x * x
+	w := z * z
+	// Break x^5*(T[1]+x^2*T[2]+...) into
+	// x^5(T[1]+x^4*T[3]+...+x^20*T[11]) +
+	// x^5(x^2*(T[2]+x^4*T[4]+...+x^22*[T12]))
+	r := T[1] + float64(w*(T[3]+float64(w*(T[5]+float64(w*(T[7]+float64(w*(T[9]+float64(w*T[11])))))))))
+	v := float64(z * (T[2] + float64(w*(T[4]+float64(w*(T[6]+float64(w*(T[8]+float64(w*(T[10]+float64(w*T[12])))))))))))
+	s := z * x
+	r = y + float64(z*(float64(s*(r+v))+y))
+	r += float64(T[0] * s)
+	w = x + r
+	if ix >= 0x3FE59428 {
+		v = float64(iy)
+		return float64(1-((hx>>30)&2)) * (v - float64(2.0*(x-(float64(w*w)/(w+v)-r))))
+	}
+	if iy == 1 {
+		return w
+	}
+	// compute -1.0 / (x+r) accurately
+	z = withLowWord(w, 0)
+	v = r - (z - x) // z+v = r+x
+	a := -1.0 / w   // a = -1.0/w
+	t := withLowWord(a, 0)
+	s = 1.0 + float64(t*z)
+	return t + float64(a*(s+float64(t*v)))
+}
+
+// Sin returns the sine of x.
+func Sin(x float64) float64 {
+	ix := highWord(x) & 0x7FFFFFFF
+	if ix <= 0x3FE921FB { // |x| ~< pi/4
+		return kernelSin(x, 0, 0)
+	}
+	if ix >= 0x7FF00000 { // sin(Inf or NaN) is NaN
+		return x - x
+	}
+	n, y0, y1 := remPio2(x)
+	switch n & 3 {
+	case 0:
+		return kernelSin(y0, y1, 1)
+	case 1:
+		return kernelCos(y0, y1)
+	case 2:
+		return -kernelSin(y0, y1, 1)
+	default:
+		return -kernelCos(y0, y1)
+	}
+}
+
+// Cos returns the cosine of x.
+func Cos(x float64) float64 {
+	ix := highWord(x) & 0x7FFFFFFF
+	if ix <= 0x3FE921FB { // |x| ~< pi/4
+		return kernelCos(x, 0)
+	}
+	if ix >= 0x7FF00000 { // cos(Inf or NaN) is NaN
+		return x - x
+	}
+	n, y0, y1 := remPio2(x)
+	switch n & 3 {
+	case 0:
+		return kernelCos(y0, y1)
+	case 1:
+		return -kernelSin(y0, y1, 1)
+	case 2:
+		return -kernelCos(y0, y1)
+	default:
+		return kernelSin(y0, y1, 1)
+	}
+}
+
+// Tan returns the tangent of x.
+func Tan(x float64) float64 {
+	ix := highWord(x) & 0x7FFFFFFF
+	if ix <= 0x3FE921FB { // |x| ~< pi/4
+		return kernelTan(x, 0, 1)
+	}
+	if ix >= 0x7FF00000 { // tan(Inf or NaN) is NaN
+		return x - x
+	}
+	n, y0, y1 := remPio2(x)
+	return kernelTan(y0, y1, 1-int((n&1)<<1)) // 1 -- n even, -1 -- n odd
+}
@@ -136,6 +136,18 @@ func (fl *File) SetSourceMap(m *sourcemap.Consumer) {
 }
 
 func (fl *File) Position(offset int) Position {
+	pos, _ := fl.position(offset, false)
+	return pos
+}
+
+// PositionSource is like Position but also returns the text of the source the position refers to, i.e. the
+// source of the file or, if the position is mapped using the source map, the original source included in the map
+// (an empty string if the map does not include it).
+func (fl *File) PositionSource(offset int) (Position, string) {
+	return fl.position(offset, true)
+}
+
+func (fl *File) position(offset int, withSource bool) (Position, string) {
 	var line int
 	var lineOffsets []int
 	fl.mu.Lock()
@@ -165,11 +177,16 @@ func (fl *File) Position(offset int) Position {
 				sourceUrlStr = sourceURL.String()
 			}
 
+			var src string
+			if withSource {
+				src = fl.sourceMap.SourceContent(source)
+			}
+
 			return Position{
 				Filename: sourceUrlStr,
 				Line:     row,
 				Column:   col,
-			}
+			}, src
 		}
 	}
 
@@ -177,7 +194,7 @@ func (fl *File) Position(offset int) Position {
 		Filename: fl.name,
 		Line:     row,
 		Column:   col,
-	}
+	}, fl.src
 }
 
 func ResolveSourcemapURL(basename, source string) *url.URL {
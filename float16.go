@@ -0,0 +1,66 @@
+package goja
+
+import "math"
+
+// float64ToFloat16 converts f into the IEEE 754 half-precision format, rounding to the nearest value with ties
+// to even. Rounding directly from float64 (rather than via float32) avoids double rounding errors.
+func float64ToFloat16(f float64) uint16 {
+	bits := math.Float64bits(f)
+	sign := uint16(bits>>48) & 0x8000
+	exp := int(bits>>52) & 0x7ff
+	mant := bits & (1<<52 - 1)
+	if exp == 0x7ff {
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	}
+	e := exp - 1023
+	var h, shift uint64
+	switch {
+	case e > 15:
+		return sign | 0x7c00
+	case e >= -14:
+		h = uint64(e+15)<<10 | mant>>42
+		shift = 42
+	case e >= -25:
+		// subnormal, the value is h * 2**-24
+		mant |= 1 << 52
+		shift = uint64(28 - e)
+		h = mant >> shift
+	default:
+		return sign
+	}
+	// A carry out of the mantissa correctly produces the next exponent (or infinity).
+	rem, half := mant&(1<<shift-1), uint64(1)<<(shift-1)
+	if rem > half || rem == half && h&1 != 0 {
+		h++
+	}
+	return sign | uint16(h)
+}
+
+func float16ToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+func toFloat16(v Value) uint16 {
+	return float64ToFloat16(v.ToFloat())
+}
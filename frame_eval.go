@@ -0,0 +1,84 @@
+package goja
+
+// Frame is an active JavaScript stack frame, see Runtime.Frame().
+type Frame struct {
+	StackFrame
+	r     *Runtime
+	stash *stash
+}
+
+// Frame returns the active JavaScript frame at the given depth, 0 being the innermost one (i.e. the function that
+// called the currently running Go function), or nil if there is no such frame. Native frames are skipped.
+//
+// The returned Frame is only valid while the Go function that obtained it is running. It is meant for debuggers and
+// REPLs, for example a host function that opens an interactive prompt:
+//
+//	vm.Set("debug", func(call goja.FunctionCall) goja.Value {
+//	    f := vm.Frame(0)
+//	    res, err := f.Eval(readLine(), false)
+//	    ...
+//	})
+func (r *Runtime) Frame(depth int) *Frame {
+	vm := r.vm
+	if vm.prg != nil {
+		if depth == 0 {
+			return &Frame{StackFrame: StackFrame{prg: vm.prg, pc: vm.pc, funcName: vm.prg.funcName}, r: r, stash: vm.stash}
+		}
+		depth--
+	}
+	for i := len(vm.callStack) - 1; i >= 0; i-- {
+		ctx := &vm.callStack[i]
+		if ctx.prg == nil {
+			continue
+		}
+		if depth == 0 {
+			return &Frame{StackFrame: StackFrame{prg: ctx.prg, pc: ctx.pc, funcName: ctx.prg.funcName}, r: r, stash: ctx.stash}
+		}
+		depth--
+	}
+	return nil
+}
+
+// Eval compiles and runs src as if it was a direct eval() call in the frame, i.e. with the frame's scope chain,
+// including the variables closed over by the function, and returns the completion value.
+//
+// In the read-write mode the code runs in non-strict mode and assignments to the variables of the frame are visible
+// to the function once it resumes. In the read-only mode the code runs in strict mode against a copy of the frame's
+// scope chain, so any modifications of local variables are discarded. Note that the read-only mode does not prevent
+// the code from modifying objects (including the global object) or global variables, nor from calling functions
+// that do so.
+//
+// The local variables of a function are only accessible by name if it contains a direct eval() call or if it was
+// compiled with WithDebugScopes (see also Runtime.SetDebugScopes()), otherwise the compiler may keep them
+// on the stack and a ReferenceError is thrown. The value of 'this' and the arguments object are not available.
+//
+// Exceptions thrown by the code are returned as *Exception.
+func (f *Frame) Eval(src string, readOnly bool) (ret Value, err error) {
+	r := f.r
+	st := f.stash
+	if readOnly {
+		st = copyStashChain(st, &r.global.stash)
+	}
+	err = r.runWrapped(func() {
+		vm := r.vm
+		savedStash, savedSb := vm.stash, vm.sb
+		vm.stash = st
+		vm.sb = 0
+		defer func() {
+			vm.stash, vm.sb = savedStash, savedSb
+		}()
+		ret = r.eval(newStringValue(src), true, readOnly)
+	})
+	return
+}
+
+// copyStashChain returns a copy of the chain of stashes up to (but not including) the stop one.
+func copyStashChain(s, stop *stash) *stash {
+	if s == nil || s == stop {
+		return s
+	}
+	c := *s
+	c.values = append([]Value(nil), s.values...)
+	c.outer = copyStashChain(s.outer, stop)
+	return &c
+}
@@ -0,0 +1,72 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestFrameEval(t *testing.T) {
+	r := New()
+	r.SetDebugScopes(true)
+	var results []Value
+	r.Set("inspect", func(call FunctionCall) Value {
+		f := r.Frame(0)
+		if f == nil || f.FuncName() != "inner" {
+			t.Fatalf("Unexpected frame: %v", f)
+		}
+		v, err := f.Eval("x + y", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, v)
+
+		v, err = f.Eval("y = 100; y", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, v)
+
+		if _, err := f.Eval("x = 10", false); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := f.Eval("undeclared = 1", true); err == nil {
+			t.Fatal("Expected an error")
+		} else if _, ok := err.(*Exception); !ok {
+			t.Fatal(err)
+		}
+
+		if outer := r.Frame(1); outer == nil || outer.FuncName() != "outer" {
+			t.Fatalf("Unexpected frame: %v", outer)
+		} else if v, err := outer.Eval("typeof y + ' ' + z", false); err != nil {
+			t.Fatal(err)
+		} else {
+			results = append(results, v)
+		}
+		if r.Frame(3) != nil {
+			t.Fatal("Expected no frame")
+		}
+		return nil
+	})
+
+	v, err := r.RunString(`
+	function outer() {
+		var z = "z";
+		function inner(x) {
+			let y = 2;
+			inspect();
+			return x + y;
+		}
+		return inner(1);
+	}
+	outer();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 12 {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+	if len(results) != 3 || results[0].ToInteger() != 3 || results[1].ToInteger() != 100 || results[2].String() != "undefined z" {
+		t.Fatalf("Unexpected results: %v", results)
+	}
+}
@@ -1,153 +1,112 @@
 package ftoa
 
 import (
-	"fmt"
 	"math"
-	"math/big"
 	"strconv"
-	"strings"
 )
 
 const (
 	digits = "0123456789abcdefghijklmnopqrstuvwxyz"
 )
 
+// FToBaseStr converts a finite number to a string in the given radix (2..36), as Number.prototype.toString() does.
+// The fractional part contains the shortest sequence of digits that uniquely identifies the number, the result is
+// the same as in V8.
 func FToBaseStr(num float64, radix int) string {
-	var negative bool
-	if num < 0 {
-		num = -num
-		negative = true
+	if num == math.Trunc(num) && math.Abs(num) <= 1<<53 {
+		return strconv.FormatInt(int64(num), radix)
 	}
 
-	dfloor := math.Floor(num)
-	ldfloor := int64(dfloor)
-	var intDigits string
-	if dfloor == float64(ldfloor) {
-		if negative {
-			ldfloor = -ldfloor
-		}
-		intDigits = strconv.FormatInt(ldfloor, radix)
-	} else {
-		floorBits := math.Float64bits(num)
-		exp := int(floorBits>>exp_shiftL) & exp_mask_shifted
-		var mantissa int64
-		if exp == 0 {
-			mantissa = int64((floorBits & frac_maskL) << 1)
-		} else {
-			mantissa = int64((floorBits & frac_maskL) | exp_msk1L)
-		}
+	// The buffer has enough space for 1024 integer digits and 1074 fractional digits (the worst case in
+	// radix 2), the decimal point and the sign. The integer part is written to the left from the middle,
+	// the fractional part to the right.
+	const bufferSize = 2200
+	var buffer [bufferSize]byte
+	integerCursor := bufferSize / 2
+	fractionCursor := integerCursor
 
-		if negative {
-			mantissa = -mantissa
-		}
-		exp -= 1075
-		x := big.NewInt(mantissa)
-		if exp > 0 {
-			x.Lsh(x, uint(exp))
-		} else if exp < 0 {
-			x.Rsh(x, uint(-exp))
-		}
-		intDigits = x.Text(radix)
+	negative := num < 0
+	if negative {
+		num = -num
 	}
 
-	if num == dfloor {
-		// No fraction part
-		return intDigits
-	} else {
-		/* We have a fraction. */
-		var buffer strings.Builder
-		buffer.WriteString(intDigits)
-		buffer.WriteByte('.')
-		df := num - dfloor
-
-		dBits := math.Float64bits(num)
-		word0 := uint32(dBits >> 32)
-		word1 := uint32(dBits)
-
-		dblBits := make([]byte, 0, 8)
-		e, _, dblBits := d2b(df, dblBits)
-		//            JS_ASSERT(e < 0);
-		/* At this point df = b * 2^e.  e must be less than zero because 0 < df < 1. */
-
-		s2 := -int((word0 >> exp_shift1) & (exp_mask >> exp_shift1))
-		if s2 == 0 {
-			s2 = -1
-		}
-		s2 += bias + p
-		/* 1/2^s2 = (nextDouble(d) - d)/2 */
-		//            JS_ASSERT(-s2 < e);
-		if -s2 >= e {
-			panic(fmt.Errorf("-s2 >= e: %d, %d", -s2, e))
-		}
-		mlo := big.NewInt(1)
-		mhi := mlo
-		if (word1 == 0) && ((word0 & bndry_mask) == 0) && ((word0 & (exp_mask & (exp_mask << 1))) != 0) {
-			/* The special case.  Here we want to be within a quarter of the last input
-			   significant digit instead of one half of it when the output string's value is less than d.  */
-			s2 += log2P
-			mhi = big.NewInt(1 << log2P)
-		}
-
-		b := new(big.Int).SetBytes(dblBits)
-		b.Lsh(b, uint(e+s2))
-		s := big.NewInt(1)
-		s.Lsh(s, uint(s2))
-		/* At this point we have the following:
-		 *   s = 2^s2;
-		 *   1 > df = b/2^s2 > 0;
-		 *   (d - prevDouble(d))/2 = mlo/2^s2;
-		 *   (nextDouble(d) - d)/2 = mhi/2^s2. */
-		bigBase := big.NewInt(int64(radix))
-
-		done := false
-		m := &big.Int{}
-		delta := &big.Int{}
-		for !done {
-			b.Mul(b, bigBase)
-			b.DivMod(b, s, m)
-			digit := byte(b.Int64())
-			b, m = m, b
-			mlo.Mul(mlo, bigBase)
-			if mlo != mhi {
-				mhi.Mul(mhi, bigBase)
-			}
-
-			/* Do we yet have the shortest string that will round to d? */
-			j := b.Cmp(mlo)
-			/* j is b/2^s2 compared with mlo/2^s2. */
-
-			delta.Sub(s, mhi)
-			var j1 int
-			if delta.Sign() <= 0 {
-				j1 = 1
-			} else {
-				j1 = b.Cmp(delta)
-			}
-			/* j1 is b/2^s2 compared with 1 - mhi/2^s2. */
-			if j1 == 0 && (word1&1) == 0 {
-				if j > 0 {
-					digit++
-				}
-				done = true
-			} else if j < 0 || (j == 0 && ((word1 & 1) == 0)) {
-				if j1 > 0 {
-					/* Either dig or dig+1 would work here as the least significant digit.
-					Use whichever would produce an output value closer to d. */
-					b.Lsh(b, 1)
-					j1 = b.Cmp(s)
-					if j1 > 0 { /* The even test (|| (j1 == 0 && (digit & 1))) is not here because it messes up odd base output such as 3.5 in base 3.  */
-						digit++
+	fRadix := float64(radix)
+	integer := math.Floor(num)
+	fraction := num - integer
+	// The fractional part must be precise up to half of the distance to the next number.
+	delta := 0.5 * (math.Nextafter(num, math.Inf(1)) - num)
+	delta = math.Max(math.Nextafter(0, 1), delta)
+	if fraction >= delta {
+		buffer[fractionCursor] = '.'
+		fractionCursor++
+		for {
+			fraction *= fRadix
+			delta *= fRadix
+			digit := int(fraction)
+			buffer[fractionCursor] = digits[digit]
+			fractionCursor++
+			fraction -= float64(digit)
+			// Round to even.
+			if fraction > 0.5 || (fraction == 0.5 && digit&1 != 0) {
+				if fraction+delta > 1 {
+					// Propagate the carry through the digits written so far.
+					for {
+						fractionCursor--
+						if fractionCursor == bufferSize/2 {
+							// The carry goes into the integer part, the decimal point is dropped.
+							integer += 1
+							break
+						}
+						c := buffer[fractionCursor]
+						var d int
+						if c > '9' {
+							d = int(c-'a') + 10
+						} else {
+							d = int(c - '0')
+						}
+						if d+1 < radix {
+							buffer[fractionCursor] = digits[d+1]
+							fractionCursor++
+							break
+						}
 					}
+					break
 				}
-				done = true
-			} else if j1 > 0 {
-				digit++
-				done = true
 			}
-			//                JS_ASSERT(digit < (uint32)base);
-			buffer.WriteByte(digits[digit])
+			if fraction < delta {
+				break
+			}
+		}
+	}
+
+	// The digits that are below the precision of the integer part are zeros.
+	for exponent(integer/fRadix) > 0 {
+		integer /= fRadix
+		integerCursor--
+		buffer[integerCursor] = '0'
+	}
+	for {
+		remainder := math.Mod(integer, fRadix)
+		integerCursor--
+		buffer[integerCursor] = digits[int(remainder)]
+		integer = (integer - remainder) / fRadix
+		if integer <= 0 {
+			break
 		}
+	}
+
+	if negative {
+		integerCursor--
+		buffer[integerCursor] = '-'
+	}
+	return string(buffer[integerCursor:fractionCursor])
+}
 
-		return buffer.String()
+// exponent returns the binary exponent of the number assuming its significand is a 53-bit integer.
+func exponent(d float64) int {
+	biased := int(math.Float64bits(d)>>exp_shiftL) & exp_mask_shifted
+	if biased == 0 {
+		return 1 - 1075
 	}
+	return biased - 1075
 }
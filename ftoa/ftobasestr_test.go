@@ -1,9 +1,46 @@
 package ftoa
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestFToBaseStr(t *testing.T) {
-	if s := FToBaseStr(0.8466400793967279, 36); s != "0.uh8u81s3fz" {
-		t.Fatal(s)
+	for _, test := range []struct {
+		num      float64
+		radix    int
+		expected string
+	}{
+		{0.8466400793967279, 36, "0.uh8u81s3fz"},
+		{0.5, 2, "0.1"},
+		{-0.5, 2, "-0.1"},
+		{-0.1, 16, "-0.1999999999999a"},
+		{0.1, 2, "0.0001100110011001100110011001100110011001100110011001101"},
+		{255.5, 16, "ff.8"},
+		{-255, 16, "-ff"},
+		{math.Pi, 16, "3.243f6a8885a3"},
+		{1 << 53, 2, "1" + zeros(53)},
+		{-(1 << 53), 36, "-2gosa7pa2gw"},
+		{1e21, 36, "5v1j4f4ds7c000"},
+		{0.9999999999999999, 2, "0.11111111111111111111111111111111111111111111111111111"},
+		{5e-324, 2, "0." + zeros(1073) + "1"},
+	} {
+		if s := FToBaseStr(test.num, test.radix); s != test.expected {
+			t.Errorf("%v in radix %d: %s, expected %s", test.num, test.radix, s, test.expected)
+		}
+	}
+}
+
+func zeros(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = '0'
+	}
+	return string(b)
+}
+
+func BenchmarkFToBaseStr(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		FToBaseStr(0.8466400793967279, 36)
 	}
 }
@@ -2,7 +2,9 @@ package ftoa
 
 import (
 	"math"
+	"math/rand"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -43,6 +45,80 @@ func TestDtostr(t *testing.T) {
 	testFToStr(4294967272.0, ModePrecision, 14, "4294967272.0000", t)
 }
 
+func TestFToStrRounding(t *testing.T) {
+	// Expected values are the exact decimal expansions rounded half away from zero,
+	// as required by Number.prototype.toFixed, toExponential and toPrecision.
+	tests := []struct {
+		num       float64
+		mode      FToStrMode
+		precision int
+		expected  string
+	}{
+		{1.005, ModeFixed, 2, "1.00"},
+		{1.45, ModeFixed, 1, "1.4"},
+		{2.345, ModeFixed, 2, "2.35"},
+		{8.345, ModeFixed, 2, "8.35"},
+		{10.235, ModeFixed, 2, "10.23"},
+		{0.5, ModeFixed, 0, "1"},
+		{2.5, ModeFixed, 0, "3"},
+		{1.23e+20, ModeFixed, 2, "123000000000000000000.00"},
+		{1e21, ModeFixed, 2, "1e+21"},
+		{0.1, ModeFixed, 20, "0.10000000000000000555"},
+		{1000000000000000128, ModeFixed, 0, "1000000000000000128"},
+		{123.456, ModeFixed, 30, "123.456000000000003069544618483633"},
+		{958.1371, ModeExponential, 1, "1e+3"},
+		{9.5, ModeExponential, 1, "1e+1"},
+		{1.45, ModeExponential, 2, "1.4e+0"},
+		{1.5e+300, ModeExponential, 1, "2e+300"},
+		{0.5, ModeExponential, 1, "5e-1"},
+		{999.118, ModePrecision, 1, "1e+3"},
+		{9.635922759610573e-14, ModePrecision, 1, "1e-13"},
+		{9611665688.235136, ModePrecision, 1, "1e+10"},
+		{1.255, ModePrecision, 3, "1.25"},
+		{99.99, ModePrecision, 3, "100"},
+		{35, ModePrecision, 1, "4e+1"},
+		{1.25, ModePrecision, 2, "1.3"},
+		{5e-324, ModePrecision, 3, "4.94e-324"},
+		{123456, ModePrecision, 2, "1.2e+5"},
+		{1e-05, ModePrecision, 1, "0.00001"},
+		{1e-07, ModePrecision, 1, "1e-7"},
+		{0.1, ModePrecision, 30, "0.100000000000000005551115123126"},
+		{1e20, ModeFixed, 2, "100000000000000000000.00"},
+		{math.MaxFloat64, ModeExponential, 21, "1.79769313486231570815e+308"},
+		{5e-324, ModeExponential, 21, "4.94065645841246544177e-324"},
+		{5e-324, ModeFixed, 100, "0." + strings.Repeat("0", 100)},
+		// subnormals
+		{1.8545916449125163e-308, ModeExponential, 31, "1.854591644912516338494330511167e-308"},
+		{2.0132154842086076e-308, ModePrecision, 40, "2.013215484208607621927185072688100314895e-308"},
+		{3.6228279044e-314, ModePrecision, 20, "3.6228279044239024183e-314"},
+	}
+	for _, tc := range tests {
+		testFToStr(tc.num, tc.mode, tc.precision, tc.expected, t)
+	}
+}
+
+func TestFToStrShortest(t *testing.T) {
+	// these are not handled by the fast path and were rounded up to a shorter, non round-tripping string
+	testFToStr(1.9676081334946639e+24, ModeStandard, 0, "1.9676081334946639e+24", t)
+	testFToStr(1.6291693311134379e-15, ModeStandard, 0, "1.6291693311134379e-15", t)
+
+	// the shortest digits must match strconv (which is always correct) for ModeStandardExponential
+	rnd := rand.New(rand.NewSource(1))
+	var buf []byte
+	for i := 0; i < 100000; i++ {
+		num := math.Float64frombits(rnd.Uint64())
+		if math.IsNaN(num) || math.IsInf(num, 0) {
+			continue
+		}
+		buf = FToStr(num, ModeStandardExponential, 0, buf[:0])
+		expected := strconv.FormatFloat(num, 'e', -1, 64)
+		expected = strings.Replace(strings.Replace(expected, "e+0", "e+", 1), "e-0", "e-", 1)
+		if string(buf) != expected {
+			t.Fatalf("%v: expected: '%s', actual: '%s'", math.Float64bits(num), expected, buf)
+		}
+	}
+}
+
 func BenchmarkDtostrSmall(b *testing.B) {
 	var buf [128]byte
 	b.ReportAllocs()
@@ -56,6 +56,11 @@ type funcObjectImpl interface {
 	source() valueString
 }
 
+// jsFuncObjectImpl is implemented by the functions defined in JavaScript.
+type jsFuncObjectImpl interface {
+	program() *Program
+}
+
 type baseFuncObject struct {
 	lenProp valueProperty
 	baseObject
@@ -83,6 +88,7 @@ type asyncFuncObject struct {
 }
 
 type classFuncObject struct {
+	name           unistring.String // the name of the class as it was defined, used in the error messages
 	initFields     *Program
 	privateEnvType *privateEnvType
 	computedKeys   []Value
@@ -117,6 +123,9 @@ type asyncArrowFuncObject struct {
 type nativeFuncObject struct {
 	f         func(FunctionCall) Value
 	construct func(args []Value, newTarget *Object) *Object
+	// hostPC is the entry point of the Go function provided by the host (see Runtime.ToValue()),
+	// it's 0 for the built-ins.
+	hostPC uintptr
 	baseFuncObject
 }
 
@@ -126,7 +135,9 @@ type wrappedFuncObject struct {
 }
 
 type boundFuncObject struct {
-	wrapped *Object
+	wrapped   *Object
+	boundThis Value
+	boundArgs []Value
 	nativeFuncObject
 }
 
@@ -251,6 +262,10 @@ func (f *baseJsFuncObject) source() valueString {
 	return newStringValue(f.src)
 }
 
+func (f *baseJsFuncObject) program() *Program {
+	return f.prg
+}
+
 func (f *baseJsFuncObject) construct(args []Value, newTarget *Object) *Object {
 	if newTarget == nil {
 		newTarget = f.val
@@ -276,7 +291,10 @@ func (f *baseJsFuncObject) construct(args []Value, newTarget *Object) *Object {
 }
 
 func (f *classFuncObject) Call(FunctionCall) Value {
-	panic(f.val.runtime.NewTypeError("Class constructor cannot be invoked without 'new'"))
+	if f.name != "" {
+		panic(f.val.runtime.NewTypeError("Class constructor %s cannot be invoked without 'new'", f.name))
+	}
+	panic(f.val.runtime.NewTypeError("Class constructors cannot be invoked without 'new'"))
 }
 
 func (f *classFuncObject) assertCallable() (func(FunctionCall) Value, bool) {
@@ -361,7 +379,7 @@ func (f *classFuncObject) construct(args []Value, newTarget *Object) *Object {
 			if v := r.vm.stack[r.vm.sp+1]; v != nil { // using residual 'this' value (a bit hacky)
 				instance = r.toObject(v)
 			} else {
-				panic(r.newError(r.global.ReferenceError, "Must call super constructor in derived class before returning from derived constructor"))
+				panic(r.newError(r.global.ReferenceError, "Must call super constructor in derived class before accessing 'this' or returning from derived constructor"))
 			}
 		}
 		return instance
@@ -547,6 +565,9 @@ func (f *nativeFuncObject) assertCallable() (func(FunctionCall) Value, bool) {
 
 func (f *nativeFuncObject) vmCall(vm *vm, n int) {
 	if f.f != nil {
+		if u := vm.r.usage; u != nil && f.hostPC != 0 {
+			u.hostCalls++
+		}
 		vm.pushCtx()
 		vm.prg = nil
 		vm.sb = vm.sp - n // so that [sb-1] points to the callee
@@ -574,6 +595,22 @@ func (f *boundFuncObject) hasInstance(v Value) bool {
 	return instanceOfOperator(v, f.wrapped)
 }
 
+// vmCall replaces the callee and 'this' on the stack with the target function and the bound 'this' and inserts
+// the bound arguments before the call arguments, so that the target is called directly without building
+// an intermediate arguments slice.
+func (f *boundFuncObject) vmCall(vm *vm, n int) {
+	if l := len(f.boundArgs); l > 0 {
+		vm.stack.expand(vm.sp + l - 1)
+		copy(vm.stack[vm.sp-n+l:], vm.stack[vm.sp-n:vm.sp])
+		copy(vm.stack[vm.sp-n:], f.boundArgs)
+		vm.sp += l
+		n += l
+	}
+	vm.stack[vm.sp-n-2] = f.boundThis
+	vm.stack[vm.sp-n-1] = f.wrapped
+	f.wrapped.self.vmCall(vm, n)
+}
+
 func (f *baseJsFuncObject) prepareForVmCall(call FunctionCall) {
 	vm := f.val.runtime.vm
 	args := call.Arguments
@@ -665,6 +702,8 @@ type asyncRunner struct {
 	f          *Object
 	vmCall     func(*vm, int)
 	gen        generator
+	// the stack at the time of the call, see Runtime.SetAsyncStackTraces()
+	creationStack []StackFrame
 }
 
 func (ar *asyncRunner) onFulfilled(call FunctionCall) Value {
@@ -717,6 +756,7 @@ func (ar *asyncRunner) start(nArgs int) {
 	r := ar.f.runtime
 	ar.gen.vm = r.vm
 	ar.promiseCap = r.newPromiseCapability(r.global.Promise)
+	ar.creationStack = r.vm.captureCreationStack()
 	sp := r.vm.sp
 	ar.gen.enter()
 	ar.vmCall(r.vm, nArgs)
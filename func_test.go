@@ -307,3 +307,43 @@ func TestAsyncContextTracker(t *testing.T) {
 		}
 	})
 }
+
+func TestFunctionPosition(t *testing.T) {
+	const SCRIPT = `
+function f() {
+}
+var arrow = () => 1;
+var obj = {
+  method() {}
+};
+`
+	r := New()
+	if _, err := r.RunScript("test.js", SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name     string
+		expected string
+	}{
+		{"f", "test.js:2:1"},
+		{"arrow", "test.js:4:13"},
+		{"obj.method", "test.js:6:3"},
+	} {
+		v, err := r.RunString(tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos, ok := FunctionPosition(v); !ok || pos.String() != tc.expected {
+			t.Fatalf("%s: %v, %v", tc.name, pos, ok)
+		}
+	}
+	for _, src := range []string{"Math.max", "f.bind(null)", "1"} {
+		v, err := r.RunString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := FunctionPosition(v); ok {
+			t.Fatalf("%s: expected no position", src)
+		}
+	}
+}
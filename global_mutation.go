@@ -0,0 +1,182 @@
+package goja
+
+import (
+	"github.com/dop251/goja/unistring"
+)
+
+// GlobalMutationKind is the kind of modification reported to the hook set by Runtime.SetGlobalMutationHook().
+type GlobalMutationKind int
+
+const (
+	// GlobalSet is an assignment, e.g. "Array = null" or "globalThis.foo = 1".
+	GlobalSet GlobalMutationKind = iota
+	// GlobalDefine is a property definition, e.g. Object.defineProperty(globalThis, ...) or a var or function
+	// declaration at the top level of a script.
+	GlobalDefine
+	// GlobalDelete is a property deletion.
+	GlobalDelete
+	// GlobalPreventExtensions is Object.preventExtensions(globalThis) or an equivalent, including the first step of
+	// Object.freeze() and Object.seal(), which then report a GlobalDefine for each property they reconfigure.
+	GlobalPreventExtensions
+	// GlobalSetPrototype is a change of the prototype of the global object, e.g. Object.setPrototypeOf(globalThis, null).
+	GlobalSetPrototype
+)
+
+func (k GlobalMutationKind) String() string {
+	switch k {
+	case GlobalSet:
+		return "set"
+	case GlobalDefine:
+		return "define"
+	case GlobalDelete:
+		return "delete"
+	case GlobalPreventExtensions:
+		return "preventExtensions"
+	case GlobalSetPrototype:
+		return "setPrototype"
+	}
+	return "unknown"
+}
+
+// GlobalMutation describes an attempt to modify a property of the global object.
+type GlobalMutation struct {
+	Kind GlobalMutationKind
+	// Key is the property key, a string or a *Symbol. It's nil for GlobalPreventExtensions and GlobalSetPrototype.
+	Key Value
+	// Value is the new value (the new prototype, possibly null, for GlobalSetPrototype). It's nil for the deletions,
+	// the definitions which do not change the value (such as those of accessor properties) and
+	// GlobalPreventExtensions.
+	Value Value
+}
+
+// guardedGlobalObject wraps the global object to report the modifications made by scripts.
+type guardedGlobalObject struct {
+	objectImpl
+	r *Runtime
+}
+
+// SetGlobalMutationHook sets a function which is called before a script sets, defines or deletes a property of the
+// global object, makes it non-extensible or changes its prototype. If the function returns an error the modification
+// is vetoed: it fails the same way as if the property was read-only (or non-configurable in case of a deletion), i.e.
+// silently in non-strict code and with a TypeError containing the error message otherwise. The hook can also be used
+// just to log the modifications, in which case it should always return nil.
+//
+// The modifications made by the host while no script is running (e.g. using Runtime.Set()) are not reported.
+// Global lexical declarations (let, const and class) do not create properties of the global object and are not
+// reported either.
+//
+// Passing nil removes the hook. Installing a hook disables some of the optimisations for the global object,
+// so the global variable access becomes somewhat slower.
+func (r *Runtime) SetGlobalMutationHook(hook func(m GlobalMutation) error) {
+	g, guarded := r.globalObject.self.(*guardedGlobalObject)
+	if hook == nil {
+		if guarded {
+			r.globalObject.self = g.objectImpl
+		}
+		r.globalMutationHook = nil
+		return
+	}
+	if !guarded {
+		r.globalObject.self = &guardedGlobalObject{objectImpl: r.globalObject.self, r: r}
+	}
+	r.globalMutationHook = hook
+}
+
+func (g *guardedGlobalObject) allow(kind GlobalMutationKind, key, value Value, throw bool) bool {
+	r := g.r
+	if r.globalMutationHook == nil || len(r.vm.callStack) == 0 {
+		return true
+	}
+	if err := r.globalMutationHook(GlobalMutation{Kind: kind, Key: key, Value: value}); err != nil {
+		if key == nil {
+			r.typeErrorResult(throw, "Cannot %s the global object: %s", kind, err.Error())
+		} else {
+			r.typeErrorResult(throw, "Cannot %s global property '%s': %s", kind, key.String(), err.Error())
+		}
+		return false
+	}
+	return true
+}
+
+func (g *guardedGlobalObject) setOwnStr(p unistring.String, v Value, throw bool) bool {
+	if !g.allow(GlobalSet, stringValueFromRaw(p), v, throw) {
+		return false
+	}
+	return g.objectImpl.setOwnStr(p, v, throw)
+}
+
+func (g *guardedGlobalObject) setOwnIdx(p valueInt, v Value, throw bool) bool {
+	if !g.allow(GlobalSet, p.toString(), v, throw) {
+		return false
+	}
+	return g.objectImpl.setOwnIdx(p, v, throw)
+}
+
+func (g *guardedGlobalObject) setOwnSym(p *Symbol, v Value, throw bool) bool {
+	if !g.allow(GlobalSet, p, v, throw) {
+		return false
+	}
+	return g.objectImpl.setOwnSym(p, v, throw)
+}
+
+func (g *guardedGlobalObject) defineOwnPropertyStr(name unistring.String, desc PropertyDescriptor, throw bool) bool {
+	if !g.allow(GlobalDefine, stringValueFromRaw(name), desc.Value, throw) {
+		return false
+	}
+	return g.objectImpl.defineOwnPropertyStr(name, desc, throw)
+}
+
+func (g *guardedGlobalObject) defineOwnPropertyIdx(name valueInt, desc PropertyDescriptor, throw bool) bool {
+	if !g.allow(GlobalDefine, name.toString(), desc.Value, throw) {
+		return false
+	}
+	return g.objectImpl.defineOwnPropertyIdx(name, desc, throw)
+}
+
+func (g *guardedGlobalObject) defineOwnPropertySym(name *Symbol, desc PropertyDescriptor, throw bool) bool {
+	if !g.allow(GlobalDefine, name, desc.Value, throw) {
+		return false
+	}
+	return g.objectImpl.defineOwnPropertySym(name, desc, throw)
+}
+
+func (g *guardedGlobalObject) deleteStr(name unistring.String, throw bool) bool {
+	if g.objectImpl.hasOwnPropertyStr(name) && !g.allow(GlobalDelete, stringValueFromRaw(name), nil, throw) {
+		return false
+	}
+	return g.objectImpl.deleteStr(name, throw)
+}
+
+func (g *guardedGlobalObject) deleteIdx(idx valueInt, throw bool) bool {
+	if g.objectImpl.hasOwnPropertyIdx(idx) && !g.allow(GlobalDelete, idx.toString(), nil, throw) {
+		return false
+	}
+	return g.objectImpl.deleteIdx(idx, throw)
+}
+
+func (g *guardedGlobalObject) deleteSym(s *Symbol, throw bool) bool {
+	if g.objectImpl.hasOwnPropertySym(s) && !g.allow(GlobalDelete, s, nil, throw) {
+		return false
+	}
+	return g.objectImpl.deleteSym(s, throw)
+}
+
+func (g *guardedGlobalObject) preventExtensions(throw bool) bool {
+	if g.objectImpl.isExtensible() && !g.allow(GlobalPreventExtensions, nil, nil, throw) {
+		return false
+	}
+	return g.objectImpl.preventExtensions(throw)
+}
+
+func (g *guardedGlobalObject) setProto(proto *Object, throw bool) bool {
+	if proto != g.objectImpl.proto() {
+		var value Value = _null
+		if proto != nil {
+			value = proto
+		}
+		if !g.allow(GlobalSetPrototype, nil, value, throw) {
+			return false
+		}
+	}
+	return g.objectImpl.setProto(proto, throw)
+}
@@ -0,0 +1,133 @@
+package example
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+func testOrder() *Order {
+	return &Order{
+		ID:       9007199254740991,
+		Status:   StatusShipped,
+		Customer: &Customer{Name: "Alice", Email: "alice@example.com"},
+		Items: []Item{
+			{SKU: "A1", Quantity: 2},
+			{SKU: "B2", Quantity: 1, Gift: true},
+		},
+		Labels:    map[string]string{"priority": "high"},
+		Total:     12.5,
+		Created:   time.Unix(1600000000, 0),
+		Internal:  "secret",
+		Extra:     "extra",
+		Matrix:    [][]int{{1, 2}, {3}},
+		Companion: map[string]*Customer{"bob": {Name: "Bob"}, "nobody": nil},
+		Grades:    map[Grade]float64{"a": 1.5},
+	}
+}
+
+func TestBindings(t *testing.T) {
+	vm := goja.New()
+	RegisterGojaBindings(vm)
+	order := testOrder()
+	vm.Set("order", order)
+	res, err := vm.RunString(`
+	if (order.customer.name !== "Alice" || order.items[1].gift !== true || order.labels.priority !== "high") {
+		throw new Error("unexpected values: " + JSON.stringify(order));
+	}
+	if ("internal" in order || order.matrix[1][0] !== 3 || order.companion.nobody !== null || order.grades.a !== 1.5) {
+		throw new Error("unexpected values: " + JSON.stringify(order));
+	}
+	if (order.created.Unix() !== 1600000000) { // converted using reflection
+		throw new Error("unexpected created: " + order.created);
+	}
+	order.items.push({sku: "C3", quantity: 5});
+	order.status = 0;
+	order.customer = null;
+	order;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported Order
+	if err := vm.ExportTo(res, &exported); err != nil {
+		t.Fatal(err)
+	}
+	expected := testOrder()
+	expected.Items = append(expected.Items, Item{SKU: "C3", Quantity: 5})
+	expected.Status = StatusPending
+	expected.Customer = nil
+	expected.Internal = ""
+	if !reflect.DeepEqual(&exported, expected) {
+		t.Fatalf("unexpected result: %#v", exported)
+	}
+
+	var p *Order
+	if err := vm.ExportTo(res, &p); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p, expected) {
+		t.Fatalf("unexpected result: %#v", p)
+	}
+
+	// nested in a type exported using reflection
+	var items []Item
+	if err := vm.ExportTo(res.(*goja.Object).Get("items"), &items); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, expected.Items) {
+		t.Fatalf("unexpected items: %#v", items)
+	}
+
+	if err := vm.ExportTo(vm.ToValue(1), &exported); err == nil {
+		t.Fatal("expected an error")
+	}
+	res, err = vm.RunString(`({items: 1})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.ExportTo(res, &exported); err == nil || err.Error() != "Order.Items: expected an array, got 1" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func BenchmarkExportTo(b *testing.B) {
+	type plainItem struct {
+		SKU      string `json:"sku"`
+		Quantity uint32 `json:"quantity"`
+		Gift     bool   `json:"gift,omitempty"`
+	}
+	const script = `({id: 1, status: 1, customer: {Name: "Alice"}, items: [{sku: "A1", quantity: 2}, {sku: "B2", quantity: 1}], labels: {a: "b"}})`
+	b.Run("binding", func(b *testing.B) {
+		vm := goja.New()
+		RegisterGojaBindings(vm)
+		v, _ := vm.RunString(script)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var order Order
+			if err := vm.ExportTo(v, &order); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		vm := goja.New()
+		vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", false))
+		v, _ := vm.RunString(script)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var order struct {
+				ID       int64 `json:"id"`
+				Status   int   `json:"status"`
+				Customer *Customer
+				Items    []plainItem       `json:"items"`
+				Labels   map[string]string `json:"labels"`
+			}
+			if err := vm.ExportTo(v, &order); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,464 @@
+// Code generated by gojabind; DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/dop251/goja"
+)
+
+// RegisterGojaBindings registers the conversion functions for Order, Item, Customer with the Runtime.
+func RegisterGojaBindings(r *goja.Runtime) {
+	r.RegisterBinding(reflect.TypeOf(Order{}), goja.TypeBinding{
+		ToValue: func(r *goja.Runtime, v interface{}) goja.Value {
+			x := v.(Order)
+			return orderToValue(r, &x)
+		},
+		ExportTo: func(r *goja.Runtime, v goja.Value, dst interface{}) error {
+			return orderExportTo(r, v, dst.(*Order))
+		},
+	})
+	r.RegisterBinding(reflect.TypeOf((*Order)(nil)), goja.TypeBinding{
+		ToValue: func(r *goja.Runtime, v interface{}) goja.Value {
+			if x := v.(*Order); x != nil {
+				return orderToValue(r, x)
+			}
+			return goja.Null()
+		},
+		ExportTo: func(r *goja.Runtime, v goja.Value, dst interface{}) error {
+			p := dst.(**Order)
+			if goja.IsNull(v) || goja.IsUndefined(v) {
+				*p = nil
+				return nil
+			}
+			if *p == nil {
+				*p = new(Order)
+			}
+			return orderExportTo(r, v, *p)
+		},
+	})
+	r.RegisterBinding(reflect.TypeOf(Item{}), goja.TypeBinding{
+		ToValue: func(r *goja.Runtime, v interface{}) goja.Value {
+			x := v.(Item)
+			return itemToValue(r, &x)
+		},
+		ExportTo: func(r *goja.Runtime, v goja.Value, dst interface{}) error {
+			return itemExportTo(r, v, dst.(*Item))
+		},
+	})
+	r.RegisterBinding(reflect.TypeOf((*Item)(nil)), goja.TypeBinding{
+		ToValue: func(r *goja.Runtime, v interface{}) goja.Value {
+			if x := v.(*Item); x != nil {
+				return itemToValue(r, x)
+			}
+			return goja.Null()
+		},
+		ExportTo: func(r *goja.Runtime, v goja.Value, dst interface{}) error {
+			p := dst.(**Item)
+			if goja.IsNull(v) || goja.IsUndefined(v) {
+				*p = nil
+				return nil
+			}
+			if *p == nil {
+				*p = new(Item)
+			}
+			return itemExportTo(r, v, *p)
+		},
+	})
+	r.RegisterBinding(reflect.TypeOf(Customer{}), goja.TypeBinding{
+		ToValue: func(r *goja.Runtime, v interface{}) goja.Value {
+			x := v.(Customer)
+			return customerToValue(r, &x)
+		},
+		ExportTo: func(r *goja.Runtime, v goja.Value, dst interface{}) error {
+			return customerExportTo(r, v, dst.(*Customer))
+		},
+	})
+	r.RegisterBinding(reflect.TypeOf((*Customer)(nil)), goja.TypeBinding{
+		ToValue: func(r *goja.Runtime, v interface{}) goja.Value {
+			if x := v.(*Customer); x != nil {
+				return customerToValue(r, x)
+			}
+			return goja.Null()
+		},
+		ExportTo: func(r *goja.Runtime, v goja.Value, dst interface{}) error {
+			p := dst.(**Customer)
+			if goja.IsNull(v) || goja.IsUndefined(v) {
+				*p = nil
+				return nil
+			}
+			if *p == nil {
+				*p = new(Customer)
+			}
+			return customerExportTo(r, v, *p)
+		},
+	})
+}
+
+func orderToValue(r *goja.Runtime, v *Order) goja.Value {
+	o := r.NewObject()
+	{
+		x := r.ToValue(v.ID)
+		_ = o.Set("id", x)
+	}
+	{
+		x := r.ToValue(int(v.Status))
+		_ = o.Set("status", x)
+	}
+	{
+		x := goja.Null()
+		if v.Customer != nil {
+			x = customerToValue(r, v.Customer)
+		}
+		_ = o.Set("customer", x)
+	}
+	{
+		x := goja.Null()
+		if v.Items != nil {
+			items1 := make([]interface{}, len(v.Items))
+			for i2 := range v.Items {
+				e3 := itemToValue(r, &v.Items[i2])
+				items1[i2] = e3
+			}
+			x = r.NewArray(items1...)
+		}
+		_ = o.Set("items", x)
+	}
+	{
+		x := goja.Null()
+		if v.Labels != nil {
+			keys4 := make([]string, 0, len(v.Labels))
+			for k5 := range v.Labels {
+				keys4 = append(keys4, k5)
+			}
+			sort.Strings(keys4)
+			o6 := r.NewObject()
+			for _, k5 := range keys4 {
+				elem7 := v.Labels[k5]
+				e8 := r.ToValue(elem7)
+				_ = o6.Set(k5, e8)
+			}
+			x = o6
+		}
+		_ = o.Set("labels", x)
+	}
+	{
+		x := r.ToValue(v.Total)
+		_ = o.Set("total", x)
+	}
+	{
+		x := r.ToValue(v.Created)
+		_ = o.Set("created", x)
+	}
+	{
+		x := r.ToValue(v.Extra)
+		_ = o.Set("extra", x)
+	}
+	{
+		x := goja.Null()
+		if v.Matrix != nil {
+			items9 := make([]interface{}, len(v.Matrix))
+			for i10 := range v.Matrix {
+				e11 := goja.Null()
+				if v.Matrix[i10] != nil {
+					items12 := make([]interface{}, len(v.Matrix[i10]))
+					for i13 := range v.Matrix[i10] {
+						e14 := r.ToValue(v.Matrix[i10][i13])
+						items12[i13] = e14
+					}
+					e11 = r.NewArray(items12...)
+				}
+				items9[i10] = e11
+			}
+			x = r.NewArray(items9...)
+		}
+		_ = o.Set("matrix", x)
+	}
+	{
+		x := goja.Null()
+		if v.Companion != nil {
+			keys15 := make([]string, 0, len(v.Companion))
+			for k16 := range v.Companion {
+				keys15 = append(keys15, k16)
+			}
+			sort.Strings(keys15)
+			o17 := r.NewObject()
+			for _, k16 := range keys15 {
+				elem18 := v.Companion[k16]
+				e19 := goja.Null()
+				if elem18 != nil {
+					e19 = customerToValue(r, elem18)
+				}
+				_ = o17.Set(k16, e19)
+			}
+			x = o17
+		}
+		_ = o.Set("companion", x)
+	}
+	{
+		x := goja.Null()
+		if v.Grades != nil {
+			keys20 := make([]string, 0, len(v.Grades))
+			for k21 := range v.Grades {
+				keys20 = append(keys20, string(k21))
+			}
+			sort.Strings(keys20)
+			o22 := r.NewObject()
+			for _, k21 := range keys20 {
+				elem23 := v.Grades[Grade(k21)]
+				e24 := r.ToValue(elem23)
+				_ = o22.Set(k21, e24)
+			}
+			x = o22
+		}
+		_ = o.Set("grades", x)
+	}
+	return o
+}
+
+func orderExportTo(r *goja.Runtime, v goja.Value, dst *Order) error {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		*dst = Order{}
+		return nil
+	}
+	o, ok := v.(*goja.Object)
+	if !ok {
+		return fmt.Errorf("cannot export %s into Order", v)
+	}
+	if x := o.Get("id"); x != nil && !goja.IsUndefined(x) {
+		dst.ID = x.ToInteger()
+	}
+	if x := o.Get("status"); x != nil && !goja.IsUndefined(x) {
+		dst.Status = Status(x.ToInteger())
+	}
+	if x := o.Get("customer"); x != nil && !goja.IsUndefined(x) {
+		if goja.IsNull(x) {
+			dst.Customer = nil
+		} else {
+			if dst.Customer == nil {
+				dst.Customer = new(Customer)
+			}
+			if err := customerExportTo(r, x, dst.Customer); err != nil {
+				return err
+			}
+		}
+	}
+	if x := o.Get("items"); x != nil && !goja.IsUndefined(x) {
+		if goja.IsNull(x) {
+			dst.Items = nil
+		} else {
+			o1, ok := x.(*goja.Object)
+			if !ok {
+				return fmt.Errorf("Order.Items: expected an array, got %s", x)
+			}
+			l2 := o1.Get("length")
+			if l2 == nil {
+				return fmt.Errorf("Order.Items: expected an array, got %s", x)
+			}
+			s3 := make([]Item, l2.ToInteger())
+			for i4 := range s3 {
+				e5 := o1.Get(strconv.Itoa(i4))
+				if e5 == nil {
+					e5 = goja.Undefined()
+				}
+				if err := itemExportTo(r, e5, &s3[i4]); err != nil {
+					return err
+				}
+			}
+			dst.Items = s3
+		}
+	}
+	if x := o.Get("labels"); x != nil && !goja.IsUndefined(x) {
+		if goja.IsNull(x) {
+			dst.Labels = nil
+		} else {
+			o6, ok := x.(*goja.Object)
+			if !ok {
+				return fmt.Errorf("Order.Labels: expected an object, got %s", x)
+			}
+			m7 := make(map[string]string)
+			for _, k8 := range o6.Keys() {
+				e9 := o6.Get(k8)
+				var elem10 string
+				elem10 = e9.String()
+				m7[k8] = elem10
+			}
+			dst.Labels = m7
+		}
+	}
+	if x := o.Get("total"); x != nil && !goja.IsUndefined(x) {
+		dst.Total = x.ToFloat()
+	}
+	if x := o.Get("created"); x != nil && !goja.IsUndefined(x) {
+		if err := r.ExportTo(x, &dst.Created); err != nil {
+			return fmt.Errorf("Order.Created: %w", err)
+		}
+	}
+	if x := o.Get("extra"); x != nil && !goja.IsUndefined(x) {
+		if err := r.ExportTo(x, &dst.Extra); err != nil {
+			return fmt.Errorf("Order.Extra: %w", err)
+		}
+	}
+	if x := o.Get("matrix"); x != nil && !goja.IsUndefined(x) {
+		if goja.IsNull(x) {
+			dst.Matrix = nil
+		} else {
+			o11, ok := x.(*goja.Object)
+			if !ok {
+				return fmt.Errorf("Order.Matrix: expected an array, got %s", x)
+			}
+			l12 := o11.Get("length")
+			if l12 == nil {
+				return fmt.Errorf("Order.Matrix: expected an array, got %s", x)
+			}
+			s13 := make([][]int, l12.ToInteger())
+			for i14 := range s13 {
+				e15 := o11.Get(strconv.Itoa(i14))
+				if e15 == nil {
+					e15 = goja.Undefined()
+				}
+				if goja.IsNull(e15) {
+					s13[i14] = nil
+				} else {
+					o16, ok := e15.(*goja.Object)
+					if !ok {
+						return fmt.Errorf("Order.Matrix[]: expected an array, got %s", e15)
+					}
+					l17 := o16.Get("length")
+					if l17 == nil {
+						return fmt.Errorf("Order.Matrix[]: expected an array, got %s", e15)
+					}
+					s18 := make([]int, l17.ToInteger())
+					for i19 := range s18 {
+						e20 := o16.Get(strconv.Itoa(i19))
+						if e20 == nil {
+							e20 = goja.Undefined()
+						}
+						s18[i19] = int(e20.ToInteger())
+					}
+					s13[i14] = s18
+				}
+			}
+			dst.Matrix = s13
+		}
+	}
+	if x := o.Get("companion"); x != nil && !goja.IsUndefined(x) {
+		if goja.IsNull(x) {
+			dst.Companion = nil
+		} else {
+			o21, ok := x.(*goja.Object)
+			if !ok {
+				return fmt.Errorf("Order.Companion: expected an object, got %s", x)
+			}
+			m22 := make(map[string]*Customer)
+			for _, k23 := range o21.Keys() {
+				e24 := o21.Get(k23)
+				var elem25 *Customer
+				if goja.IsNull(e24) {
+					elem25 = nil
+				} else {
+					if elem25 == nil {
+						elem25 = new(Customer)
+					}
+					if err := customerExportTo(r, e24, elem25); err != nil {
+						return err
+					}
+				}
+				m22[k23] = elem25
+			}
+			dst.Companion = m22
+		}
+	}
+	if x := o.Get("grades"); x != nil && !goja.IsUndefined(x) {
+		if goja.IsNull(x) {
+			dst.Grades = nil
+		} else {
+			o26, ok := x.(*goja.Object)
+			if !ok {
+				return fmt.Errorf("Order.Grades: expected an object, got %s", x)
+			}
+			m27 := make(map[Grade]float64)
+			for _, k28 := range o26.Keys() {
+				e29 := o26.Get(k28)
+				var elem30 float64
+				elem30 = e29.ToFloat()
+				m27[Grade(k28)] = elem30
+			}
+			dst.Grades = m27
+		}
+	}
+	return nil
+}
+
+func itemToValue(r *goja.Runtime, v *Item) goja.Value {
+	o := r.NewObject()
+	{
+		x := r.ToValue(v.SKU)
+		_ = o.Set("sku", x)
+	}
+	{
+		x := r.ToValue(v.Quantity)
+		_ = o.Set("quantity", x)
+	}
+	{
+		x := r.ToValue(v.Gift)
+		_ = o.Set("gift", x)
+	}
+	return o
+}
+
+func itemExportTo(r *goja.Runtime, v goja.Value, dst *Item) error {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		*dst = Item{}
+		return nil
+	}
+	o, ok := v.(*goja.Object)
+	if !ok {
+		return fmt.Errorf("cannot export %s into Item", v)
+	}
+	if x := o.Get("sku"); x != nil && !goja.IsUndefined(x) {
+		dst.SKU = x.String()
+	}
+	if x := o.Get("quantity"); x != nil && !goja.IsUndefined(x) {
+		dst.Quantity = uint32(x.ToInteger())
+	}
+	if x := o.Get("gift"); x != nil && !goja.IsUndefined(x) {
+		dst.Gift = x.ToBoolean()
+	}
+	return nil
+}
+
+func customerToValue(r *goja.Runtime, v *Customer) goja.Value {
+	o := r.NewObject()
+	{
+		x := r.ToValue(v.Name)
+		_ = o.Set("name", x)
+	}
+	{
+		x := r.ToValue(v.Email)
+		_ = o.Set("email", x)
+	}
+	return o
+}
+
+func customerExportTo(r *goja.Runtime, v goja.Value, dst *Customer) error {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		*dst = Customer{}
+		return nil
+	}
+	o, ok := v.(*goja.Object)
+	if !ok {
+		return fmt.Errorf("cannot export %s into Customer", v)
+	}
+	if x := o.Get("name"); x != nil && !goja.IsUndefined(x) {
+		dst.Name = x.String()
+	}
+	if x := o.Get("email"); x != nil && !goja.IsUndefined(x) {
+		dst.Email = x.String()
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// Package example contains types with bindings generated by gojabind, it's used for testing the generator.
+package example
+
+import "time"
+
+//go:generate go run github.com/dop251/goja/gojabind -type Order,Item,Customer -tag json -case camel
+
+type Status int
+
+const (
+	StatusPending Status = iota
+	StatusShipped
+)
+
+type Grade string
+
+type Order struct {
+	ID         int64             `json:"id"`
+	Status     Status            `json:"status"`
+	Customer   *Customer         `json:"customer"`
+	Items      []Item            `json:"items"`
+	Labels     map[string]string `json:"labels"`
+	Total      float64
+	Created    time.Time
+	Internal   string `json:"-"`
+	Extra      interface{}
+	Matrix     [][]int
+	Companion  map[string]*Customer
+	Grades     map[Grade]float64
+	unexported int
+}
+
+type Item struct {
+	SKU      string `json:"sku"`
+	Quantity uint32 `json:"quantity"`
+	Gift     bool   `json:"gift,omitempty"`
+}
+
+type Customer struct {
+	Name  string
+	Email string
+}
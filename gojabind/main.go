@@ -0,0 +1,497 @@
+// Command gojabind generates static conversion functions between Go struct types and JavaScript values, which are
+// registered with a goja Runtime using Runtime.RegisterBinding(), so that Runtime.ToValue() and Runtime.ExportTo()
+// don't have to use reflection for these types.
+//
+// It is meant to be used with go:generate:
+//
+//	//go:generate go run github.com/dop251/goja/gojabind -type Order,Item -tag json
+//
+// This creates a file (by default gojabind_gen.go) with the RegisterGojaBindings(*goja.Runtime) function. The
+// structs are converted into plain objects (rather than live wrappers) with a property for each exported field;
+// ExportTo() assigns the fields for which the object has a property that is not undefined.
+//
+// Fields of the following types are converted by the generated code: booleans, numbers and strings (including
+// named types defined in the same package), other structs listed in -type and pointers to them, as well as slices
+// and maps with string keys of these types. Fields of other types are converted using Runtime.ToValue() and
+// Runtime.ExportTo(). Embedded fields are not supported and are ignored.
+//
+// The property names are the names of the fields, optionally taken from a struct tag (-tag, with "-" hiding the
+// field) or converted using one of the goja field name mappers (-case camel, snake, kebab or uncap).
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of struct type names; required")
+	output    = flag.String("output", "gojabind_gen.go", "output file name")
+	tagName   = flag.String("tag", "", "struct tag to take the property names from, e.g. json")
+	nameCase  = flag.String("case", "", "convert the field names: camel, snake, kebab or uncap")
+	funcName  = flag.String("func", "RegisterGojaBindings", "name of the generated registration function")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gojabind: ")
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if args := flag.Args(); len(args) > 0 {
+		dir = args[0]
+	}
+	cfg := &config{
+		types:    strings.Split(*typeNames, ","),
+		tag:      *tagName,
+		funcName: *funcName,
+	}
+	var err error
+	if cfg.mapper, err = caseMapper(*nameCase); err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(dir, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type config struct {
+	types    []string
+	tag      string
+	mapper   goja.FieldNameMapper
+	funcName string
+}
+
+func caseMapper(name string) (goja.FieldNameMapper, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "camel":
+		return goja.CamelCaseFieldNameMapper(), nil
+	case "snake":
+		return goja.SnakeCaseFieldNameMapper(), nil
+	case "kebab":
+		return goja.KebabCaseFieldNameMapper(), nil
+	case "uncap":
+		return goja.UncapFieldNameMapper(), nil
+	}
+	return nil, fmt.Errorf("unknown case %q", name)
+}
+
+type generator struct {
+	cfg     *config
+	pkg     string
+	decls   map[string]*ast.TypeSpec
+	structs map[string]bool
+	buf     bytes.Buffer
+	imports map[string]bool
+	tmp     int
+}
+
+// generate parses the package in dir and returns the formatted source of the bindings.
+func generate(dir string, cfg *config) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+	g := &generator{
+		cfg:     cfg,
+		decls:   make(map[string]*ast.TypeSpec),
+		structs: make(map[string]bool),
+		imports: map[string]bool{"reflect": true},
+	}
+	for name, pkg := range pkgs {
+		g.pkg = name
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+					for _, spec := range gd.Specs {
+						ts := spec.(*ast.TypeSpec)
+						g.decls[ts.Name.Name] = ts
+					}
+				}
+			}
+		}
+	}
+	for _, name := range cfg.types {
+		ts := g.decls[name]
+		if ts == nil {
+			return nil, fmt.Errorf("type %s not found", name)
+		}
+		if _, ok := ts.Type.(*ast.StructType); !ok {
+			return nil, fmt.Errorf("type %s is not a struct", name)
+		}
+		g.structs[name] = true
+	}
+	g.registration()
+	for _, name := range cfg.types {
+		if err := g.structFuncs(name); err != nil {
+			return nil, err
+		}
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by gojabind; DO NOT EDIT.\n\npackage %s\n\nimport (\n", g.pkg)
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	fmt.Fprintf(&out, "\n\t\"github.com/dop251/goja\"\n)\n\n")
+	out.Write(g.buf.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting the generated code: %v\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func toValueFunc(name string) string {
+	return lowerFirst(name) + "ToValue"
+}
+
+func exportFunc(name string) string {
+	return lowerFirst(name) + "ExportTo"
+}
+
+func (g *generator) registration() {
+	g.printf("// %s registers the conversion functions for %s with the Runtime.\n", g.cfg.funcName, strings.Join(g.cfg.types, ", "))
+	g.printf("func %s(r *goja.Runtime) {\n", g.cfg.funcName)
+	for _, name := range g.cfg.types {
+		g.printf(`r.RegisterBinding(reflect.TypeOf(%[1]s{}), goja.TypeBinding{
+			ToValue: func(r *goja.Runtime, v interface{}) goja.Value {
+				x := v.(%[1]s)
+				return %[2]s(r, &x)
+			},
+			ExportTo: func(r *goja.Runtime, v goja.Value, dst interface{}) error {
+				return %[3]s(r, v, dst.(*%[1]s))
+			},
+		})
+		r.RegisterBinding(reflect.TypeOf((*%[1]s)(nil)), goja.TypeBinding{
+			ToValue: func(r *goja.Runtime, v interface{}) goja.Value {
+				if x := v.(*%[1]s); x != nil {
+					return %[2]s(r, x)
+				}
+				return goja.Null()
+			},
+			ExportTo: func(r *goja.Runtime, v goja.Value, dst interface{}) error {
+				p := dst.(**%[1]s)
+				if goja.IsNull(v) || goja.IsUndefined(v) {
+					*p = nil
+					return nil
+				}
+				if *p == nil {
+					*p = new(%[1]s)
+				}
+				return %[3]s(r, v, *p)
+			},
+		})
+`, name, toValueFunc(name), exportFunc(name))
+	}
+	g.printf("}\n\n")
+}
+
+type field struct {
+	goName string
+	jsName string
+	typ    ast.Expr
+}
+
+func (g *generator) fields(name string) ([]field, error) {
+	st := g.decls[name].Type.(*ast.StructType)
+	var res []field
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			continue
+		}
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(s)
+		}
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			jsName := n.Name
+			if g.cfg.mapper != nil {
+				jsName = g.cfg.mapper.FieldName(nil, reflect.StructField{Name: n.Name})
+			}
+			if g.cfg.tag != "" {
+				t := tag.Get(g.cfg.tag)
+				if idx := strings.IndexByte(t, ','); idx != -1 {
+					t = t[:idx]
+				}
+				if t == "-" {
+					continue
+				}
+				if t != "" {
+					jsName = t
+				}
+			}
+			res = append(res, field{goName: n.Name, jsName: jsName, typ: f.Type})
+		}
+	}
+	return res, nil
+}
+
+func (g *generator) structFuncs(name string) error {
+	fields, err := g.fields(name)
+	if err != nil {
+		return err
+	}
+	g.tmp = 0
+	g.printf("func %s(r *goja.Runtime, v *%s) goja.Value {\n", toValueFunc(name), name)
+	g.printf("o := r.NewObject()\n")
+	for _, f := range fields {
+		g.printf("{\n")
+		g.toJS(f.typ, "v."+f.goName, "x")
+		g.printf("_ = o.Set(%q, x)\n}\n", f.jsName)
+	}
+	g.printf("return o\n}\n\n")
+
+	g.tmp = 0
+	g.imports["fmt"] = true
+	g.printf("func %s(r *goja.Runtime, v goja.Value, dst *%s) error {\n", exportFunc(name), name)
+	g.printf(`if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		*dst = %[1]s{}
+		return nil
+	}
+	o, ok := v.(*goja.Object)
+	if !ok {
+		return fmt.Errorf("cannot export %%s into %[1]s", v)
+	}
+`, name)
+	for _, f := range fields {
+		g.printf("if x := o.Get(%q); x != nil && !goja.IsUndefined(x) {\n", f.jsName)
+		g.fromJS(f.typ, "x", "dst."+f.goName, name+"."+f.goName)
+		g.printf("}\n")
+	}
+	g.printf("return nil\n}\n\n")
+	return nil
+}
+
+var basicKinds = map[string]string{
+	"bool": "bool", "string": "string",
+	"int": "int", "int8": "int", "int16": "int", "int32": "int", "int64": "int", "rune": "int",
+	"uint": "int", "uint8": "int", "uint16": "int", "uint32": "int", "uint64": "int", "byte": "int", "uintptr": "int",
+	"float32": "float", "float64": "float",
+}
+
+// basic returns the kind ("bool", "string", "int" or "float") and the underlying basic type of a basic type or a
+// named type defined in the package.
+func (g *generator) basic(t ast.Expr) (kind, underlying string) {
+	for i := 0; i < 10; i++ {
+		id, ok := t.(*ast.Ident)
+		if !ok {
+			return "", ""
+		}
+		if ts := g.decls[id.Name]; ts != nil {
+			t = ts.Type
+			continue
+		}
+		if k, ok := basicKinds[id.Name]; ok {
+			return k, id.Name
+		}
+		return "", ""
+	}
+	return "", ""
+}
+
+func (g *generator) structName(t ast.Expr) string {
+	if id, ok := t.(*ast.Ident); ok && g.structs[id.Name] {
+		return id.Name
+	}
+	return ""
+}
+
+func (g *generator) temp(prefix string) string {
+	g.tmp++
+	return prefix + strconv.Itoa(g.tmp)
+}
+
+// toJS emits the statements declaring the variable dst with the JavaScript value of the addressable Go
+// expression src.
+func (g *generator) toJS(t ast.Expr, src, dst string) {
+	if kind, underlying := g.basic(t); kind != "" {
+		if id := t.(*ast.Ident); id.Name != underlying {
+			src = underlying + "(" + src + ")"
+		}
+		g.printf("%s := r.ToValue(%s)\n", dst, src)
+		return
+	}
+	if name := g.structName(t); name != "" {
+		g.printf("%s := %s(r, &%s)\n", dst, toValueFunc(name), src)
+		return
+	}
+	switch t := t.(type) {
+	case *ast.StarExpr:
+		if name := g.structName(t.X); name != "" {
+			g.printf("%s := goja.Null()\nif %s != nil {\n%s = %s(r, %s)\n}\n", dst, src, dst, toValueFunc(name), src)
+			return
+		}
+	case *ast.ArrayType:
+		if t.Len == nil && g.supported(t.Elt) {
+			items, i := g.temp("items"), g.temp("i")
+			g.printf("%s := goja.Null()\nif %s != nil {\n", dst, src)
+			g.printf("%s := make([]interface{}, len(%s))\nfor %s := range %s {\n", items, src, i, src)
+			e := g.temp("e")
+			g.toJS(t.Elt, src+"["+i+"]", e)
+			g.printf("%s[%s] = %s\n}\n%s = r.NewArray(%s...)\n}\n", items, i, e, dst, items)
+			return
+		}
+	case *ast.MapType:
+		if kind, _ := g.basic(t.Key); kind == "string" && g.supported(t.Value) {
+			g.imports["sort"] = true
+			keys, k, obj, elem := g.temp("keys"), g.temp("k"), g.temp("o"), g.temp("elem")
+			g.printf("%s := goja.Null()\nif %s != nil {\n", dst, src)
+			g.printf("%s := make([]string, 0, len(%s))\nfor %s := range %s {\n%s = append(%s, %s)\n}\nsort.Strings(%s)\n",
+				keys, src, k, src, keys, keys, toString(types.ExprString(t.Key), k), keys)
+			g.printf("%s := r.NewObject()\nfor _, %s := range %s {\n%s := %s[%s]\n", obj, k, keys, elem, src, convert(types.ExprString(t.Key), k))
+			e := g.temp("e")
+			g.toJS(t.Value, elem, e)
+			g.printf("_ = %s.Set(%s, %s)\n}\n%s = %s\n}\n", obj, k, e, dst, obj)
+			return
+		}
+	}
+	g.printf("%s := r.ToValue(%s)\n", dst, src)
+}
+
+// toString returns the expression converting the expression s of type typ (with the underlying type string) into
+// a string.
+func toString(typ, s string) string {
+	if typ == "string" {
+		return s
+	}
+	return "string(" + s + ")"
+}
+
+// convert returns the expression converting the string expression s into typ.
+func convert(typ, s string) string {
+	if typ == "string" {
+		return s
+	}
+	return typ + "(" + s + ")"
+}
+
+// supported returns true if the conversion of the type doesn't fall back to reflection.
+func (g *generator) supported(t ast.Expr) bool {
+	if kind, _ := g.basic(t); kind != "" {
+		return true
+	}
+	if g.structName(t) != "" {
+		return true
+	}
+	switch t := t.(type) {
+	case *ast.StarExpr:
+		return g.structName(t.X) != ""
+	case *ast.ArrayType:
+		return t.Len == nil && g.supported(t.Elt)
+	case *ast.MapType:
+		kind, _ := g.basic(t.Key)
+		return kind == "string" && g.supported(t.Value)
+	}
+	return false
+}
+
+// fromJS emits the statements assigning the JavaScript value src to the addressable Go expression dst, path is
+// used in error messages.
+func (g *generator) fromJS(t ast.Expr, src, dst, path string) {
+	typ := types.ExprString(t)
+	if kind, _ := g.basic(t); kind != "" {
+		var conv, convType string
+		switch kind {
+		case "bool":
+			conv, convType = src+".ToBoolean()", "bool"
+		case "string":
+			conv, convType = src+".String()", "string"
+		case "int":
+			conv, convType = src+".ToInteger()", "int64"
+		case "float":
+			conv, convType = src+".ToFloat()", "float64"
+		}
+		if typ != convType {
+			conv = typ + "(" + conv + ")"
+		}
+		g.printf("%s = %s\n", dst, conv)
+		return
+	}
+	if name := g.structName(t); name != "" {
+		g.printf("if err := %s(r, %s, &%s); err != nil {\nreturn err\n}\n", exportFunc(name), src, dst)
+		return
+	}
+	switch t := t.(type) {
+	case *ast.StarExpr:
+		if name := g.structName(t.X); name != "" {
+			g.printf("if goja.IsNull(%s) {\n%s = nil\n} else {\nif %s == nil {\n%s = new(%s)\n}\n", src, dst, dst, dst, name)
+			g.printf("if err := %s(r, %s, %s); err != nil {\nreturn err\n}\n}\n", exportFunc(name), src, dst)
+			return
+		}
+	case *ast.ArrayType:
+		if t.Len == nil && g.supported(t.Elt) {
+			g.imports["strconv"] = true
+			obj, l, s, i, e := g.temp("o"), g.temp("l"), g.temp("s"), g.temp("i"), g.temp("e")
+			g.printf("if goja.IsNull(%s) {\n%s = nil\n} else {\n", src, dst)
+			g.printf("%s, ok := %s.(*goja.Object)\nif !ok {\nreturn fmt.Errorf(\"%s: expected an array, got %%s\", %s)\n}\n", obj, src, path, src)
+			g.printf("%s := %s.Get(\"length\")\nif %s == nil {\nreturn fmt.Errorf(\"%s: expected an array, got %%s\", %s)\n}\n", l, obj, l, path, src)
+			g.printf("%s := make(%s, %s.ToInteger())\nfor %s := range %s {\n", s, typ, l, i, s)
+			g.printf("%s := %s.Get(strconv.Itoa(%s))\nif %s == nil {\n%s = goja.Undefined()\n}\n", e, obj, i, e, e)
+			g.fromJS(t.Elt, e, s+"["+i+"]", path+"[]")
+			g.printf("}\n%s = %s\n}\n", dst, s)
+			return
+		}
+	case *ast.MapType:
+		if kind, _ := g.basic(t.Key); kind == "string" && g.supported(t.Value) {
+			obj, m, k, e, elem := g.temp("o"), g.temp("m"), g.temp("k"), g.temp("e"), g.temp("elem")
+			g.printf("if goja.IsNull(%s) {\n%s = nil\n} else {\n", src, dst)
+			g.printf("%s, ok := %s.(*goja.Object)\nif !ok {\nreturn fmt.Errorf(\"%s: expected an object, got %%s\", %s)\n}\n", obj, src, path, src)
+			g.printf("%s := make(%s)\nfor _, %s := range %s.Keys() {\n", m, typ, k, obj)
+			g.printf("%s := %s.Get(%s)\nvar %s %s\n", e, obj, k, elem, types.ExprString(t.Value))
+			g.fromJS(t.Value, e, elem, path+"[]")
+			g.printf("%s[%s] = %s\n}\n%s = %s\n}\n", m, convert(types.ExprString(t.Key), k), elem, dst, m)
+			return
+		}
+	}
+	g.printf("if err := r.ExportTo(%s, &%s); err != nil {\nreturn fmt.Errorf(\"%s: %%w\", err)\n}\n", src, dst, path)
+}
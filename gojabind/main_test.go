@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateGolden(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+	mapper, err := caseMapper("camel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(dir, &config{
+		types:    []string{"Order", "Item", "Customer"},
+		tag:      "json",
+		mapper:   mapper,
+		funcName: "RegisterGojaBindings",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(filepath.Join(dir, "gojabind_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, expected) {
+		t.Fatal("the generated code differs from internal/example/gojabind_gen.go, run go generate")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+	for _, typ := range []string{"Missing", "Status"} {
+		if _, err := generate(dir, &config{types: []string{typ}, funcName: "F"}); err == nil {
+			t.Fatalf("%s: expected an error", typ)
+		}
+	}
+	if _, err := caseMapper("pascal"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
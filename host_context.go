@@ -0,0 +1,83 @@
+package goja
+
+import (
+	gocontext "context"
+)
+
+type scriptValueKey string
+
+// WithScriptValue returns a copy of parent which carries a value that scripts can read using the object returned
+// by NewContextNamespace(), e.g. a trace or a tenant ID. The values are stored in the context, so they are scoped
+// to a request (or whatever the context represents) rather than to a Runtime:
+//
+//	ctx = goja.WithScriptValue(ctx, "traceId", traceID)
+//	err := vm.RunWithContext(ctx, func() error {
+//	    _, err := handler(goja.Undefined(), req)
+//	    return err
+//	})
+//
+// The names do not clash with the keys of other packages using the same context.
+func WithScriptValue(parent gocontext.Context, name string, value interface{}) gocontext.Context {
+	return gocontext.WithValue(parent, scriptValueKey(name), value)
+}
+
+// RunWithContext makes ctx the current context of the Runtime (see Context()) while f is running and returns
+// the result of f. f would normally run a script or call a JavaScript function. The calls can be nested, the
+// previous context is restored when f returns (or panics).
+//
+// Note that the context is only used to provide the values, its cancellation does not interrupt the execution.
+func (r *Runtime) RunWithContext(ctx gocontext.Context, f func() error) error {
+	prev := r.ctx
+	r.ctx = ctx
+	defer func() {
+		r.ctx = prev
+	}()
+	return f()
+}
+
+// Context returns the current context set by RunWithContext() or context.Background() if there is none.
+// It can be used by the host functions called from scripts.
+func (r *Runtime) Context() gocontext.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	return gocontext.Background()
+}
+
+// NewContextNamespace creates an object which gives scripts access to the values set with WithScriptValue()
+// in the current context of the Runtime (see RunWithContext()). It has two methods: get(name), which returns
+// the value converted with ToValue() or undefined if it's not set, and has(name). It's up to the host to make it
+// available, e.g.:
+//
+//	host := vm.NewObject()
+//	host.Set("context", vm.NewContextNamespace())
+//	vm.Set("host", host)
+//
+//	console.log(host.context.get("traceId"), "request received");
+//
+// As the values are looked up at the time of the call, a callback run in a different context (e.g. a promise
+// reaction processed by a later RunWithContext() call) sees the values of that context.
+func (r *Runtime) NewContextNamespace() *Object {
+	o := r.NewObject()
+	o.self._putProp("get", r.newNativeFunc(r.contextNamespace_get, nil, "get", nil, 1), true, false, true)
+	o.self._putProp("has", r.newNativeFunc(r.contextNamespace_has, nil, "has", nil, 1), true, false, true)
+	return o
+}
+
+func (r *Runtime) scriptValue(name Value) interface{} {
+	if r.ctx == nil {
+		return nil
+	}
+	return r.ctx.Value(scriptValueKey(name.String()))
+}
+
+func (r *Runtime) contextNamespace_get(call FunctionCall) Value {
+	if v := r.scriptValue(call.Argument(0)); v != nil {
+		return r.ToValue(v)
+	}
+	return _undefined
+}
+
+func (r *Runtime) contextNamespace_has(call FunctionCall) Value {
+	return r.toBoolean(r.scriptValue(call.Argument(0)) != nil)
+}
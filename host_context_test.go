@@ -0,0 +1,69 @@
+package goja
+
+import (
+	gocontext "context"
+	"testing"
+)
+
+func TestContextNamespace(t *testing.T) {
+	vm := New()
+	host := vm.NewObject()
+	_ = host.Set("context", vm.NewContextNamespace())
+	_ = vm.Set("host", host)
+	_, err := vm.RunString(`
+	function handler(suffix) {
+		return host.context.has("traceId") ? host.context.get("traceId") + suffix : "none";
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, _ := AssertFunction(vm.Get("handler"))
+
+	call := func(ctx gocontext.Context) string {
+		var res Value
+		err := vm.RunWithContext(ctx, func() error {
+			var err error
+			res, err = handler(_undefined, asciiString("!"))
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.String()
+	}
+
+	ctx := WithScriptValue(gocontext.Background(), "traceId", "abc")
+	if res := call(ctx); res != "abc!" {
+		t.Fatalf("Unexpected result: %q", res)
+	}
+	if res := call(WithScriptValue(ctx, "traceId", 42)); res != "42!" {
+		t.Fatalf("Unexpected result: %q", res)
+	}
+	if res := call(gocontext.WithValue(gocontext.Background(), "traceId", "abc")); res != "none" {
+		t.Fatalf("Values with other key types must not be visible: %q", res)
+	}
+	if res, _ := handler(_undefined); res.String() != "none" {
+		t.Fatalf("Unexpected result outside of RunWithContext: %q", res)
+	}
+
+	// nested calls restore the outer context
+	err = vm.RunWithContext(ctx, func() error {
+		if vm.Context() != ctx {
+			t.Fatal("Unexpected context")
+		}
+		_ = vm.RunWithContext(WithScriptValue(ctx, "traceId", "inner"), func() error {
+			return nil
+		})
+		if res, _ := handler(_undefined, asciiString("")); res.String() != "abc" {
+			t.Fatalf("Unexpected result after a nested call: %q", res)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vm.Context() != gocontext.Background() {
+		t.Fatal("The context is not reset")
+	}
+}
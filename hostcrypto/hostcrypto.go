@@ -0,0 +1,430 @@
+// Package hostcrypto exposes native implementations of hash functions, HMAC, PBKDF2 and symmetric ciphers to
+// JavaScript code running in goja, so that crypto polyfills do not have to implement them in JavaScript.
+//
+// The primitives are registered in a Registry which is then installed into a Runtime:
+//
+//	reg := hostcrypto.Default()
+//	reg.RegisterHash("blake2b-256", func() hash.Hash { h, _ := blake2b.New256(nil); return h })
+//	reg.Enable(vm)
+//
+// Enable defines the global hostCrypto object, which scripts can detect and use instead of their own
+// implementations:
+//
+//	if (typeof hostCrypto === "object" && hostCrypto.digests.includes("sha256")) {
+//	    const mac = hostCrypto.hmac("sha256", key, data);               // ArrayBuffer
+//	    const dk = hostCrypto.pbkdf2("sha256", password, salt, 100000, 32);
+//	    const h = hostCrypto.createHash("sha256").update(chunk1).update(chunk2).digest();
+//	}
+//
+// The object has the following properties:
+//
+//   - digests and ciphers: frozen arrays with the names of the registered algorithms.
+//   - digest(name, data): the hash of the data.
+//   - hmac(name, key, data): the HMAC of the data.
+//   - createHash(name) and createHmac(name, key): incremental versions of the above, the returned object has the
+//     update(data) method (which returns the object itself) and the digest() method.
+//   - pbkdf2(name, password, salt, iterations, length): the PBKDF2 key derived using HMAC with the hash. The
+//     number of iterations and the length are limited by Registry.MaxIterations and Registry.MaxKeyLength.
+//   - encrypt(name, key, iv, data) and decrypt(name, key, iv, data): encryption and decryption with the cipher.
+//   - timingSafeEqual(a, b): compares the data in constant time.
+//
+// Data arguments can be strings (which are encoded as UTF-8), ArrayBuffers, typed arrays or DataViews. The results
+// are ArrayBuffers. Unknown algorithms and invalid arguments cause a TypeError, errors returned by ciphers
+// (e.g. an authentication failure) are thrown as GoErrors.
+package hostcrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"sort"
+
+	"github.com/dop251/goja"
+)
+
+// Cipher is a symmetric cipher. The iv is the initialisation vector or the nonce, depending on the mode.
+// The methods must not modify or retain the arguments.
+type Cipher interface {
+	Encrypt(key, iv, data []byte) ([]byte, error)
+	Decrypt(key, iv, data []byte) ([]byte, error)
+}
+
+// The default limits of pbkdf2, see Registry.
+const (
+	DefaultMaxIterations = 1000000
+	DefaultMaxKeyLength  = 256
+)
+
+// Registry is a set of named hash functions and ciphers. It must not be modified while it is used by a Runtime.
+type Registry struct {
+	// MaxIterations and MaxKeyLength limit the work done by pbkdf2 (which is proportional to the number of
+	// iterations multiplied by the number of hash blocks in the key), as it cannot be interrupted. Larger values
+	// cause a TypeError, zero means no limit. New sets them to DefaultMaxIterations and DefaultMaxKeyLength.
+	MaxIterations int
+	MaxKeyLength  int
+
+	hashes  map[string]func() hash.Hash
+	ciphers map[string]Cipher
+}
+
+// New returns an empty Registry.
+func New() *Registry {
+	return &Registry{
+		MaxIterations: DefaultMaxIterations,
+		MaxKeyLength:  DefaultMaxKeyLength,
+		hashes:        make(map[string]func() hash.Hash),
+		ciphers:       make(map[string]Cipher),
+	}
+}
+
+// Default returns a Registry with the hash functions and ciphers from the standard library: "md5", "sha1",
+// "sha224", "sha256", "sha384", "sha512", and "aes-cbc" (with PKCS#7 padding), "aes-ctr" and "aes-gcm" (with the
+// authentication tag appended to the ciphertext). The AES key size is determined by the length of the key.
+func Default() *Registry {
+	reg := New()
+	reg.RegisterHash("md5", md5.New)
+	reg.RegisterHash("sha1", sha1.New)
+	reg.RegisterHash("sha224", sha256.New224)
+	reg.RegisterHash("sha256", sha256.New)
+	reg.RegisterHash("sha384", sha512.New384)
+	reg.RegisterHash("sha512", sha512.New)
+	reg.RegisterCipher("aes-cbc", aesCBC{})
+	reg.RegisterCipher("aes-ctr", aesCTR{})
+	reg.RegisterCipher("aes-gcm", aesGCM{})
+	return reg
+}
+
+// RegisterHash adds a hash function, replacing the one with the same name if there is one.
+func (reg *Registry) RegisterHash(name string, newHash func() hash.Hash) {
+	reg.hashes[name] = newHash
+}
+
+// RegisterCipher adds a cipher, replacing the one with the same name if there is one.
+func (reg *Registry) RegisterCipher(name string, c Cipher) {
+	reg.ciphers[name] = c
+}
+
+type binding struct {
+	r   *goja.Runtime
+	reg *Registry
+}
+
+// Enable defines the hostCrypto global object in the Runtime.
+func (reg *Registry) Enable(r *goja.Runtime) error {
+	return r.Set("hostCrypto", reg.NewObject(r))
+}
+
+// NewObject creates the object which is installed as hostCrypto by Enable, so that it can be exposed under
+// a different name.
+func (reg *Registry) NewObject(r *goja.Runtime) *goja.Object {
+	b := &binding{r: r, reg: reg}
+	o := r.NewObject()
+	set := func(name string, value interface{}) {
+		if err := o.DefineDataProperty(name, r.ToValue(value), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE); err != nil {
+			panic(err)
+		}
+	}
+	var digests, ciphers []string
+	for name := range reg.hashes {
+		digests = append(digests, name)
+	}
+	for name := range reg.ciphers {
+		ciphers = append(ciphers, name)
+	}
+	set("digests", b.names(digests))
+	set("ciphers", b.names(ciphers))
+	set("digest", b.digest)
+	set("hmac", b.hmac)
+	set("createHash", b.createHash)
+	set("createHmac", b.createHmac)
+	set("pbkdf2", b.pbkdf2)
+	set("encrypt", b.encrypt)
+	set("decrypt", b.decrypt)
+	set("timingSafeEqual", b.timingSafeEqual)
+	return o
+}
+
+func (b *binding) names(names []string) *goja.Object {
+	sort.Strings(names)
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		values[i] = name
+	}
+	arr := b.r.NewArray(values...)
+	if freeze, ok := goja.AssertFunction(b.r.Get("Object").ToObject(b.r).Get("freeze")); ok {
+		if _, err := freeze(nil, arr); err != nil {
+			panic(err)
+		}
+	}
+	return arr
+}
+
+func (b *binding) hash(name goja.Value) func() hash.Hash {
+	if h := b.reg.hashes[name.String()]; h != nil {
+		return h
+	}
+	panic(b.r.NewTypeError("Unknown digest algorithm: %s", name.String()))
+}
+
+func (b *binding) cipher(name goja.Value) Cipher {
+	if c := b.reg.ciphers[name.String()]; c != nil {
+		return c
+	}
+	panic(b.r.NewTypeError("Unknown cipher: %s", name.String()))
+}
+
+// bytes returns the data of a string, an ArrayBuffer or a view. The returned slice is shared with the buffer.
+func (b *binding) bytes(v goja.Value, what string) []byte {
+	switch v := v.(type) {
+	case *goja.Object:
+		if buf, ok := v.Export().(goja.ArrayBuffer); ok {
+			return buf.Bytes()
+		}
+		if bv, ok := v.Get("buffer").(*goja.Object); ok {
+			if buf, ok := bv.Export().(goja.ArrayBuffer); ok {
+				data := buf.Bytes()
+				offset, length := v.Get("byteOffset").ToInteger(), v.Get("byteLength").ToInteger()
+				if offset >= 0 && length >= 0 && offset+length <= int64(len(data)) {
+					return data[offset : offset+length]
+				}
+			}
+		}
+	default:
+		if s, ok := v.Export().(string); ok {
+			return []byte(s)
+		}
+	}
+	panic(b.r.NewTypeError("The %s must be a string, an ArrayBuffer or a view", what))
+}
+
+func (b *binding) result(data []byte) goja.Value {
+	return b.r.ToValue(b.r.NewArrayBuffer(data))
+}
+
+func (b *binding) digest(call goja.FunctionCall) goja.Value {
+	h := b.hash(call.Argument(0))()
+	h.Write(b.bytes(call.Argument(1), "data"))
+	return b.result(h.Sum(nil))
+}
+
+func (b *binding) hmac(call goja.FunctionCall) goja.Value {
+	h := hmac.New(b.hash(call.Argument(0)), b.bytes(call.Argument(1), "key"))
+	h.Write(b.bytes(call.Argument(2), "data"))
+	return b.result(h.Sum(nil))
+}
+
+func (b *binding) hashObject(h hash.Hash) *goja.Object {
+	o := b.r.NewObject()
+	done := false
+	_ = o.Set("update", func(call goja.FunctionCall) goja.Value {
+		if done {
+			panic(b.r.NewTypeError("Digest already called"))
+		}
+		h.Write(b.bytes(call.Argument(0), "data"))
+		return o
+	})
+	_ = o.Set("digest", func(goja.FunctionCall) goja.Value {
+		if done {
+			panic(b.r.NewTypeError("Digest already called"))
+		}
+		done = true
+		return b.result(h.Sum(nil))
+	})
+	return o
+}
+
+func (b *binding) createHash(call goja.FunctionCall) goja.Value {
+	return b.hashObject(b.hash(call.Argument(0))())
+}
+
+func (b *binding) createHmac(call goja.FunctionCall) goja.Value {
+	return b.hashObject(hmac.New(b.hash(call.Argument(0)), b.bytes(call.Argument(1), "key")))
+}
+
+func (b *binding) pbkdf2(call goja.FunctionCall) goja.Value {
+	h := b.hash(call.Argument(0))
+	password := b.bytes(call.Argument(1), "password")
+	salt := b.bytes(call.Argument(2), "salt")
+	iter := call.Argument(3).ToInteger()
+	keyLen := call.Argument(4).ToInteger()
+	if iter < 1 || iter > 1<<31-1 {
+		panic(b.r.NewTypeError("Invalid number of iterations: %s", call.Argument(3).String()))
+	}
+	if keyLen < 1 || keyLen > 1<<30 {
+		panic(b.r.NewTypeError("Invalid key length: %s", call.Argument(4).String()))
+	}
+	if max := b.reg.MaxIterations; max > 0 && iter > int64(max) {
+		panic(b.r.NewTypeError("The number of iterations exceeds the limit of %d", max))
+	}
+	if max := b.reg.MaxKeyLength; max > 0 && keyLen > int64(max) {
+		panic(b.r.NewTypeError("The key length exceeds the limit of %d", max))
+	}
+	return b.result(pbkdf2Key(password, salt, int(iter), int(keyLen), h))
+}
+
+func (b *binding) crypt(call goja.FunctionCall, encrypt bool) goja.Value {
+	c := b.cipher(call.Argument(0))
+	key := b.bytes(call.Argument(1), "key")
+	iv := b.bytes(call.Argument(2), "iv")
+	data := b.bytes(call.Argument(3), "data")
+	var res []byte
+	var err error
+	if encrypt {
+		res, err = c.Encrypt(key, iv, data)
+	} else {
+		res, err = c.Decrypt(key, iv, data)
+	}
+	if err != nil {
+		panic(b.r.NewGoError(err))
+	}
+	return b.result(res)
+}
+
+func (b *binding) encrypt(call goja.FunctionCall) goja.Value {
+	return b.crypt(call, true)
+}
+
+func (b *binding) decrypt(call goja.FunctionCall) goja.Value {
+	return b.crypt(call, false)
+}
+
+func (b *binding) timingSafeEqual(call goja.FunctionCall) goja.Value {
+	x, y := b.bytes(call.Argument(0), "data"), b.bytes(call.Argument(1), "data")
+	return b.r.ToValue(subtle.ConstantTimeCompare(x, y) == 1)
+}
+
+// pbkdf2Key implements PBKDF2 as defined in RFC 8018.
+func pbkdf2Key(password, salt []byte, iter, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:4])
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = u[:0]
+			u = prf.Sum(u)
+			for i, x := range u {
+				t[i] ^= x
+			}
+		}
+	}
+	return dk[:keyLen]
+}
+
+var errInvalidPadding = errors.New("invalid padding")
+
+type aesCBC struct{}
+
+func (aesCBC) Encrypt(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("invalid iv length")
+	}
+	pad := block.BlockSize() - len(data)%block.BlockSize()
+	res := make([]byte, len(data)+pad)
+	copy(res, data)
+	for i := len(data); i < len(res); i++ {
+		res[i] = byte(pad)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(res, res)
+	return res, nil
+}
+
+func (aesCBC) Decrypt(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("invalid iv length")
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid ciphertext length")
+	}
+	res := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(res, data)
+	// the padding is checked in constant time, so that the timing does not reveal where it is invalid
+	n, bs := len(res), block.BlockSize()
+	pad := int(res[n-1])
+	good := subtle.ConstantTimeLessOrEq(1, pad) & subtle.ConstantTimeLessOrEq(pad, bs)
+	for i := 1; i <= bs; i++ {
+		inPad := subtle.ConstantTimeLessOrEq(i, pad)
+		good &= subtle.ConstantTimeByteEq(res[n-i], byte(pad)) | (inPad ^ 1)
+	}
+	if good != 1 {
+		return nil, errInvalidPadding
+	}
+	return res[:n-pad], nil
+}
+
+type aesCTR struct{}
+
+func (aesCTR) Encrypt(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("invalid iv length")
+	}
+	res := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(res, data)
+	return res, nil
+}
+
+func (c aesCTR) Decrypt(key, iv, data []byte) ([]byte, error) {
+	return c.Encrypt(key, iv, data)
+}
+
+type aesGCM struct{}
+
+func newGCM(key, iv []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) == 0 {
+		return nil, errors.New("invalid iv length")
+	}
+	return cipher.NewGCMWithNonceSize(block, len(iv))
+}
+
+func (aesGCM) Encrypt(key, iv, data []byte) ([]byte, error) {
+	aead, err := newGCM(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, iv, data, nil), nil
+}
+
+func (aesGCM) Decrypt(key, iv, data []byte) ([]byte, error) {
+	aead, err := newGCM(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, iv, data, nil)
+}
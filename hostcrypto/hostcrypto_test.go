@@ -0,0 +1,158 @@
+package hostcrypto
+
+import (
+	"hash"
+	"hash/crc32"
+	"testing"
+
+	"github.com/dop251/goja"
+)
+
+const testLib = `
+function hex(buf) {
+	return Array.prototype.map.call(new Uint8Array(buf), function(b) {
+		return (b < 16 ? "0" : "") + b.toString(16);
+	}).join("");
+}
+
+function assertEq(actual, expected, msg) {
+	if (actual !== expected) {
+		throw new Error((msg ? msg + ": " : "") + "expected " + expected + ", got " + actual);
+	}
+}
+
+function assertThrows(ctor, f, msg) {
+	try {
+		f();
+	} catch (e) {
+		if (!(e instanceof ctor)) {
+			throw new Error((msg ? msg + ": " : "") + "unexpected exception " + e);
+		}
+		return;
+	}
+	throw new Error((msg ? msg + ": " : "") + "expected an exception");
+}
+`
+
+func run(t *testing.T, reg *Registry, script string) {
+	t.Helper()
+	vm := goja.New()
+	if err := reg.Enable(vm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vm.RunString(testLib + script); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	const SCRIPT = `
+	assertEq(hex(hostCrypto.digest("sha256", "abc")), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad");
+	assertEq(hex(hostCrypto.digest("md5", new Uint8Array([0x61, 0x62, 0x63]))), "900150983cd24fb0d6963f7d28e17f72");
+	assertEq(hex(hostCrypto.digest("sha1", new Uint8Array([0, 0x61, 0x62, 0x63, 0]).subarray(1, 4))), "a9993e364706816aba3e25717850c26c9cd0d89d", "view");
+	assertEq(hex(hostCrypto.digest("sha256", asciiBuffer("abc"))), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", "ArrayBuffer");
+
+	var h = hostCrypto.createHash("sha256");
+	assertEq(h.update("a").update(new Uint8Array([0x62, 0x63])), h);
+	assertEq(hex(h.digest()), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", "incremental");
+	assertThrows(TypeError, function() { h.update("a"); });
+
+	// RFC 4231, test case 2
+	assertEq(hex(hostCrypto.hmac("sha256", "Jefe", "what do ya want for nothing?")), "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843", "hmac");
+	assertEq(hex(hostCrypto.createHmac("sha256", "Jefe").update("what do ya ").update("want for nothing?").digest()), "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843", "createHmac");
+
+	assertEq(hostCrypto.digests.join(), "md5,sha1,sha224,sha256,sha384,sha512");
+	assertEq(Object.isFrozen(hostCrypto.digests), true);
+	assertThrows(TypeError, function() { hostCrypto.digest("sha3", "abc"); });
+	assertThrows(TypeError, function() { hostCrypto.digest("sha256", 42); });
+
+	assertEq(hostCrypto.timingSafeEqual("abc", new Uint8Array([0x61, 0x62, 0x63])), true);
+	assertEq(hostCrypto.timingSafeEqual("abc", "abd"), false);
+	assertEq(hostCrypto.timingSafeEqual("abc", "ab"), false);
+	`
+	run(t, Default(), `
+	function asciiBuffer(s) {
+		var buf = new ArrayBuffer(s.length), view = new Uint8Array(buf);
+		for (var i = 0; i < s.length; i++) {
+			view[i] = s.charCodeAt(i);
+		}
+		return buf;
+	}
+	`+SCRIPT)
+}
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 6070
+	const SCRIPT = `
+	assertEq(hex(hostCrypto.pbkdf2("sha1", "password", "salt", 1, 20)), "0c60c80f961f0e71f3a9b524af6012062fe037a6");
+	assertEq(hex(hostCrypto.pbkdf2("sha1", "password", "salt", 2, 20)), "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957");
+	assertEq(hex(hostCrypto.pbkdf2("sha1", "password", "salt", 4096, 20)), "4b007901b765489abead49d926f721d065a429c1");
+	assertEq(hex(hostCrypto.pbkdf2("sha1", "passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 25)), "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038");
+	assertThrows(TypeError, function() { hostCrypto.pbkdf2("sha1", "password", "salt", 0, 20); });
+	assertThrows(TypeError, function() { hostCrypto.pbkdf2("sha1", "password", "salt", 1, 0); });
+	`
+	run(t, Default(), SCRIPT)
+}
+
+func TestPBKDF2Limits(t *testing.T) {
+	reg := Default()
+	run(t, reg, `
+	assertEq(hostCrypto.pbkdf2("sha1", "password", "salt", 1, 256).byteLength, 256);
+	assertThrows(TypeError, function() { hostCrypto.pbkdf2("sha1", "password", "salt", 1, 257); });
+	assertThrows(TypeError, function() { hostCrypto.pbkdf2("sha1", "password", "salt", 1000001, 20); });
+	`)
+	reg.MaxIterations = 10
+	reg.MaxKeyLength = 0
+	run(t, reg, `
+	assertEq(hostCrypto.pbkdf2("sha1", "password", "salt", 10, 1024).byteLength, 1024);
+	assertThrows(TypeError, function() { hostCrypto.pbkdf2("sha1", "password", "salt", 11, 20); });
+	`)
+}
+
+func TestCipher(t *testing.T) {
+	const SCRIPT = `
+	var key = new Uint8Array(16), iv = new Uint8Array(16), nonce = new Uint8Array(12);
+	for (var i = 0; i < 16; i++) {
+		key[i] = i;
+		iv[i] = 255 - i;
+	}
+	for (var name of ["aes-cbc", "aes-ctr", "aes-gcm"]) {
+		var n = name === "aes-gcm" ? nonce : iv;
+		var ct = hostCrypto.encrypt(name, key, n, "hello, world");
+		assertEq(String.fromCharCode.apply(null, new Uint8Array(hostCrypto.decrypt(name, key, n, ct))), "hello, world", name);
+	}
+	// FIPS 197, appendix C.1 (the second block is the padding)
+	var ct = hostCrypto.encrypt("aes-cbc", key, new Uint8Array(16), new Uint8Array([0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff]));
+	assertEq(hex(ct).substring(0, 32), "69c4e0d86a7b0430d8cdb78070b4c55a");
+	assertEq(ct.byteLength, 32);
+
+	var sealed = new Uint8Array(hostCrypto.encrypt("aes-gcm", key, nonce, "secret"));
+	sealed[0] ^= 1;
+	assertThrows(GoError, function() { hostCrypto.decrypt("aes-gcm", key, nonce, sealed); }, "tampered");
+	assertThrows(GoError, function() { hostCrypto.encrypt("aes-cbc", new Uint8Array(5), iv, "x"); }, "key size");
+	// flipping a bit of the iv flips the same bit of the plaintext, the last 4 bytes are the padding
+	var cbc = hostCrypto.encrypt("aes-cbc", key, iv, "hello, world");
+	for (var i = 0; i < 16; i++) {
+		var tampered = iv.slice();
+		tampered[i] ^= 1;
+		if (i < 12) {
+			assertEq(hostCrypto.decrypt("aes-cbc", key, tampered, cbc).byteLength, 12);
+		} else {
+			assertThrows(GoError, function() { hostCrypto.decrypt("aes-cbc", key, tampered, cbc); }, "padding " + i);
+		}
+	}
+	assertThrows(TypeError, function() { hostCrypto.encrypt("des", key, iv, "x"); });
+	assertEq(hostCrypto.ciphers.join(), "aes-cbc,aes-ctr,aes-gcm");
+	`
+	run(t, Default(), SCRIPT)
+}
+
+func TestRegisterHash(t *testing.T) {
+	reg := New()
+	reg.RegisterHash("crc32", func() hash.Hash { return crc32.NewIEEE() })
+	run(t, reg, `
+	assertEq(hex(hostCrypto.digest("crc32", "123456789")), "cbf43926");
+	assertEq(hostCrypto.digests.join(), "crc32");
+	assertEq(hostCrypto.ciphers.length, 0);
+	`)
+}
@@ -0,0 +1,311 @@
+// Package hostkv exposes a key-value storage implemented by the host to JavaScript code running in goja, so that
+// embedded scripts can persist small amounts of state using a common API instead of one invented by each embedder.
+//
+// The storage is backed by a Store. MemoryStore is an in-memory implementation with quotas and expiration, a
+// deployment running scripts on behalf of several tenants would typically give each tenant a Store of its own:
+//
+//	store := hostkv.NewMemoryStore(hostkv.Quota{MaxKeys: 1000, MaxBytes: 1 << 20})
+//	hostkv.Enable(vm, store)
+//
+// Enable defines the global storage object with the following methods, all of which return Promises:
+//
+//   - get(key, type): the value of the key, or null if there is no such key or it has expired. type is "text" (the
+//     default), "json" or "arrayBuffer"; it can also be passed as an object: get(key, {type: "json"}).
+//   - set(key, value, options): stores the value, which is a string, an ArrayBuffer or a view. options.ttl is the
+//     time to live in seconds, by default the value does not expire.
+//   - delete(key): deletes the key.
+//   - list(options): the keys in lexicographical order, an array of strings. options.prefix restricts the keys to
+//     the ones starting with the prefix, options.limit is the maximum number of keys returned (1000 by default).
+//
+// For example:
+//
+//	await storage.set("visits", String(visits + 1), {ttl: 3600});
+//	const settings = await storage.get("settings", "json");
+//
+// Invalid arguments cause the Promise to be rejected with a TypeError. Errors returned by the Store are reported
+// as GoErrors, except for the ones caused by an exceeded quota (see ErrQuotaExceeded), which are reported as errors
+// named QuotaExceededError.
+//
+// By default the Store is called synchronously, so the Promises are already settled when the methods return. With
+// WithAsync the Store is called on a separate goroutine and the Promises are settled by a job posted to the event
+// loop of the Runtime.
+package hostkv
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// ErrQuotaExceeded is returned (possibly wrapped) by Store.Set if storing the value would exceed a quota.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// Store is the host implementation of the storage. The methods must not modify or retain the arguments. If
+// WithAsync is used, they are called concurrently and from different goroutines.
+type Store interface {
+	// Get returns the value of the key. ok is false if there is no such key or it has expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores the value of the key. ttl is the time after which the value expires, zero means never.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete deletes the key. Deleting a key which does not exist is not an error.
+	Delete(ctx context.Context, key string) error
+	// List returns at most limit keys starting with the prefix, in lexicographical order.
+	List(ctx context.Context, prefix string, limit int) ([]string, error)
+}
+
+const defaultListLimit = 1000
+
+// Option configures the storage object.
+type Option func(*binding)
+
+// WithContext sets the context passed to the Store. The default is context.Background().
+func WithContext(ctx context.Context) Option {
+	return func(b *binding) {
+		b.ctx = ctx
+	}
+}
+
+// WithAsync makes the methods call the Store on a separate goroutine. post is called on that goroutine once the
+// Store returns, it must arrange for job to be called on the goroutine of the Runtime, e.g. using
+// eventloop.Loop.RunOnLoop. If the job is never called, the Promise remains pending.
+func WithAsync(post func(job func())) Option {
+	return func(b *binding) {
+		b.post = post
+	}
+}
+
+type binding struct {
+	r     *goja.Runtime
+	store Store
+	ctx   context.Context
+	post  func(job func())
+}
+
+// Enable defines the storage global object in the Runtime.
+func Enable(r *goja.Runtime, store Store, opts ...Option) error {
+	return r.Set("storage", NewObject(r, store, opts...))
+}
+
+// NewObject creates the object which is installed as storage by Enable, so that it can be exposed under a different
+// name.
+func NewObject(r *goja.Runtime, store Store, opts ...Option) *goja.Object {
+	b := &binding{r: r, store: store, ctx: context.Background()}
+	for _, opt := range opts {
+		opt(b)
+	}
+	o := r.NewObject()
+	set := func(name string, fn func(goja.FunctionCall) goja.Value) {
+		if err := o.DefineDataProperty(name, r.ToValue(b.rejectOnThrow(fn)), goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE); err != nil {
+			panic(err)
+		}
+	}
+	set("get", b.get)
+	set("set", b.set)
+	set("delete", b.delete)
+	set("list", b.list)
+	return o
+}
+
+// rejectOnThrow turns the exceptions thrown by fn into rejected Promises.
+func (b *binding) rejectOnThrow(fn func(goja.FunctionCall) goja.Value) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) (ret goja.Value) {
+		defer func() {
+			if x := recover(); x != nil {
+				o, ok := x.(*goja.Object)
+				if !ok {
+					panic(x)
+				}
+				p, _, reject := b.r.NewPromise()
+				reject(o)
+				ret = b.r.ToValue(p)
+			}
+		}()
+		return fn(call)
+	}
+}
+
+// run returns a Promise which is settled with the result of op. result converts the value returned by op and is
+// called on the goroutine of the Runtime, a JavaScript exception returned by it rejects the Promise.
+func (b *binding) run(op func(ctx context.Context) (interface{}, error), result func(v interface{}) (goja.Value, error)) goja.Value {
+	p, resolve, reject := b.r.NewPromise()
+	settle := func(v interface{}, err error) {
+		var res goja.Value
+		if err == nil {
+			res, err = result(v)
+		}
+		if ex, ok := err.(*goja.Exception); ok {
+			reject(ex.Value())
+		} else if err != nil {
+			reject(b.error(err))
+		} else {
+			resolve(res)
+		}
+	}
+	if b.post == nil {
+		settle(op(b.ctx))
+	} else {
+		go func() {
+			v, err := op(b.ctx)
+			b.post(func() {
+				settle(v, err)
+			})
+		}()
+	}
+	return b.r.ToValue(p)
+}
+
+func (b *binding) error(err error) *goja.Object {
+	e := b.r.NewGoError(err)
+	if errors.Is(err, ErrQuotaExceeded) {
+		_ = e.Set("name", "QuotaExceededError")
+	}
+	return e
+}
+
+func (b *binding) key(v goja.Value) string {
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		panic(b.r.NewTypeError("The key must be a non-empty string"))
+	}
+	key := v.String()
+	if key == "" {
+		panic(b.r.NewTypeError("The key must be a non-empty string"))
+	}
+	return key
+}
+
+// option returns the property of the options object, or nil if it is not present.
+func (b *binding) option(options goja.Value, name string) goja.Value {
+	if goja.IsUndefined(options) || goja.IsNull(options) {
+		return nil
+	}
+	o, ok := options.(*goja.Object)
+	if !ok {
+		panic(b.r.NewTypeError("The options must be an object"))
+	}
+	if v := o.Get(name); v != nil && !goja.IsUndefined(v) {
+		return v
+	}
+	return nil
+}
+
+// bytes returns a copy of the data of a string, an ArrayBuffer or a view.
+func (b *binding) bytes(v goja.Value) []byte {
+	switch v := v.(type) {
+	case *goja.Object:
+		if buf, ok := v.Export().(goja.ArrayBuffer); ok {
+			return append([]byte(nil), buf.Bytes()...)
+		}
+		if bv, ok := v.Get("buffer").(*goja.Object); ok {
+			if buf, ok := bv.Export().(goja.ArrayBuffer); ok {
+				data := buf.Bytes()
+				offset, length := v.Get("byteOffset").ToInteger(), v.Get("byteLength").ToInteger()
+				if offset >= 0 && length >= 0 && offset+length <= int64(len(data)) {
+					return append([]byte(nil), data[offset:offset+length]...)
+				}
+			}
+		}
+	default:
+		if s, ok := v.Export().(string); ok {
+			return []byte(s)
+		}
+	}
+	panic(b.r.NewTypeError("The value must be a string, an ArrayBuffer or a view"))
+}
+
+func (b *binding) get(call goja.FunctionCall) goja.Value {
+	key := b.key(call.Argument(0))
+	typ := "text"
+	if t := call.Argument(1); !goja.IsUndefined(t) {
+		if _, ok := t.(*goja.Object); ok {
+			t = b.option(t, "type")
+		}
+		if t != nil {
+			typ = t.String()
+		}
+	}
+	var convert func(data []byte) (goja.Value, error)
+	switch typ {
+	case "text":
+		convert = func(data []byte) (goja.Value, error) {
+			return b.r.ToValue(string(data)), nil
+		}
+	case "json":
+		parse, _ := goja.AssertFunction(b.r.Get("JSON").ToObject(b.r).Get("parse"))
+		convert = func(data []byte) (goja.Value, error) {
+			return parse(nil, b.r.ToValue(string(data)))
+		}
+	case "arrayBuffer":
+		convert = func(data []byte) (goja.Value, error) {
+			return b.r.ToValue(b.r.NewArrayBuffer(data)), nil
+		}
+	default:
+		panic(b.r.NewTypeError("Unknown value type: %s", typ))
+	}
+	return b.run(func(ctx context.Context) (interface{}, error) {
+		data, ok, err := b.store.Get(ctx, key)
+		if !ok || err != nil {
+			return nil, err
+		}
+		return data, nil
+	}, func(v interface{}) (goja.Value, error) {
+		if v == nil {
+			return goja.Null(), nil
+		}
+		return convert(v.([]byte))
+	})
+}
+
+func (b *binding) set(call goja.FunctionCall) goja.Value {
+	key := b.key(call.Argument(0))
+	value := b.bytes(call.Argument(1))
+	var ttl time.Duration
+	if v := b.option(call.Argument(2), "ttl"); v != nil {
+		s := v.ToFloat()
+		if math.IsNaN(s) || s <= 0 || s > float64(math.MaxInt64/time.Second) {
+			panic(b.r.NewTypeError("Invalid ttl: %s", v.String()))
+		}
+		ttl = time.Duration(s * float64(time.Second))
+	}
+	return b.run(func(ctx context.Context) (interface{}, error) {
+		return nil, b.store.Set(ctx, key, value, ttl)
+	}, func(interface{}) (goja.Value, error) {
+		return goja.Undefined(), nil
+	})
+}
+
+func (b *binding) delete(call goja.FunctionCall) goja.Value {
+	key := b.key(call.Argument(0))
+	return b.run(func(ctx context.Context) (interface{}, error) {
+		return nil, b.store.Delete(ctx, key)
+	}, func(interface{}) (goja.Value, error) {
+		return goja.Undefined(), nil
+	})
+}
+
+func (b *binding) list(call goja.FunctionCall) goja.Value {
+	var prefix string
+	if v := b.option(call.Argument(0), "prefix"); v != nil {
+		prefix = v.String()
+	}
+	limit := defaultListLimit
+	if v := b.option(call.Argument(0), "limit"); v != nil {
+		l := v.ToInteger()
+		if l <= 0 || l > math.MaxInt32 {
+			panic(b.r.NewTypeError("Invalid limit: %s", v.String()))
+		}
+		limit = int(l)
+	}
+	return b.run(func(ctx context.Context) (interface{}, error) {
+		return b.store.List(ctx, prefix, limit)
+	}, func(v interface{}) (goja.Value, error) {
+		keys := v.([]string)
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = key
+		}
+		return b.r.NewArray(values...), nil
+	})
+}
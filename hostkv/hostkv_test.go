@@ -0,0 +1,196 @@
+package hostkv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/eventloop"
+)
+
+const testLib = `
+function assertEq(actual, expected, msg) {
+	if (actual !== expected) {
+		throw new Error((msg ? msg + ": " : "") + "expected " + expected + ", got " + actual);
+	}
+}
+
+async function assertRejects(name, p, msg) {
+	try {
+		await p;
+	} catch (e) {
+		assertEq(e.name, name, msg);
+		return;
+	}
+	throw new Error((msg ? msg + ": " : "") + "expected a rejection");
+}
+`
+
+// runAsync runs the body of an async function and checks that the returned Promise has been fulfilled.
+func runAsync(t *testing.T, vm *goja.Runtime, body string) {
+	t.Helper()
+	v, err := vm.RunString(testLib + "(async function() {" + body + "})()")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := v.Export().(*goja.Promise)
+	if p.State() != goja.PromiseStateFulfilled {
+		t.Fatalf("%v: %v", p.State(), p.Result())
+	}
+}
+
+func TestStorage(t *testing.T) {
+	const SCRIPT = `
+	assertEq(await storage.get("missing"), null, "missing");
+	assertEq(await storage.set("a", "text"), undefined, "set");
+	assertEq(await storage.get("a"), "text", "get");
+	assertEq(await storage.get("a", {type: "text"}), "text", "get text");
+
+	await storage.set("json", JSON.stringify({x: [1, 2]}));
+	assertEq((await storage.get("json", "json")).x[1], 2, "get json");
+	await assertRejects("SyntaxError", storage.get("a", "json"), "invalid json");
+
+	await storage.set("bin", new Uint8Array([1, 2, 3, 4]).subarray(1, 3));
+	const buf = await storage.get("bin", "arrayBuffer");
+	assertEq(buf instanceof ArrayBuffer, true, "arrayBuffer");
+	assertEq(new Uint8Array(buf).join(), "2,3", "arrayBuffer contents");
+
+	await storage.set("list/b", "");
+	await storage.set("list/a", "");
+	await storage.set("list/c", "");
+	assertEq((await storage.list({prefix: "list/"})).join(), "list/a,list/b,list/c", "list");
+	assertEq((await storage.list({prefix: "list/", limit: 2})).join(), "list/a,list/b", "list limit");
+	assertEq((await storage.list()).length, 6, "list all");
+
+	await storage.delete("list/b");
+	await storage.delete("list/b");
+	assertEq(await storage.get("list/b"), null, "deleted");
+
+	await assertRejects("TypeError", storage.get(""), "empty key");
+	await assertRejects("TypeError", storage.get("a", "blob"), "unknown type");
+	await assertRejects("TypeError", storage.set("a", {}), "invalid value");
+	await assertRejects("TypeError", storage.set("a", "", {ttl: -1}), "invalid ttl");
+	await assertRejects("TypeError", storage.list({limit: 0}), "invalid limit");
+	await assertRejects("QuotaExceededError", storage.set("a", "x".repeat(21)), "quota");
+	assertEq(await storage.get("a"), "text", "after quota");
+	`
+	vm := goja.New()
+	if err := Enable(vm, NewMemoryStore(Quota{MaxValueSize: 20})); err != nil {
+		t.Fatal(err)
+	}
+	runAsync(t, vm, SCRIPT)
+}
+
+func TestStorageTTL(t *testing.T) {
+	now := time.Unix(1e9, 0)
+	store := NewMemoryStore(Quota{})
+	store.now = func() time.Time {
+		return now
+	}
+	vm := goja.New()
+	if err := Enable(vm, store); err != nil {
+		t.Fatal(err)
+	}
+	runAsync(t, vm, `
+	await storage.set("short", "1", {ttl: 1.5});
+	await storage.set("long", "2", {ttl: 60});
+	await storage.set("forever", "3");
+	`)
+	now = now.Add(1500 * time.Millisecond)
+	runAsync(t, vm, `
+	assertEq(await storage.get("short"), null, "short");
+	assertEq(await storage.get("long"), "2", "long");
+	assertEq((await storage.list()).join(), "forever,long", "list");
+	`)
+	now = now.Add(time.Hour)
+	runAsync(t, vm, `
+	assertEq((await storage.list()).join(), "forever", "list");
+	`)
+}
+
+func TestMemoryStoreQuota(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1e9, 0)
+	s := NewMemoryStore(Quota{MaxKeys: 2, MaxBytes: 10, MaxKeySize: 3})
+	s.now = func() time.Time {
+		return now
+	}
+	check := func(err error, quota bool) {
+		t.Helper()
+		if quota && !errors.Is(err, ErrQuotaExceeded) || !quota && err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	check(s.Set(ctx, "long", nil, 0), true)
+	check(s.Set(ctx, "a", []byte("1234"), 0), false)
+	check(s.Set(ctx, "b", []byte("1234"), time.Second), false)
+	check(s.Set(ctx, "c", nil, 0), true)
+	// replacing a key does not count it twice
+	check(s.Set(ctx, "b", []byte("12"), time.Second), false)
+	check(s.Set(ctx, "a", []byte("12345678"), 0), true)
+	if keys, size := s.Size(); keys != 2 || size != 8 {
+		t.Fatalf("Unexpected size: %d, %d", keys, size)
+	}
+	// the expired keys do not count
+	now = now.Add(time.Second)
+	check(s.Set(ctx, "c", []byte("123"), 0), false)
+	check(s.Set(ctx, "a", []byte("123456"), 0), true)
+	if keys, size := s.Size(); keys != 2 || size != 9 {
+		t.Fatalf("Unexpected size: %d, %d", keys, size)
+	}
+}
+
+type slowStore struct {
+	Store
+	delay time.Duration
+}
+
+func (s slowStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+	return s.Store.Get(ctx, key)
+}
+
+func TestStorageAsync(t *testing.T) {
+	s := eventloop.NewScheduler()
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	vm := goja.New()
+	loop := s.NewLoop(vm)
+	store := slowStore{Store: NewMemoryStore(Quota{}), delay: 10 * time.Millisecond}
+	if err := Enable(vm, store, WithContext(ctx), WithAsync(func(job func()) {
+		_ = loop.RunOnLoop(func(*goja.Runtime) {
+			job()
+		})
+	})); err != nil {
+		t.Fatal(err)
+	}
+	results := make(chan string)
+	_ = vm.Set("done", func(s string) {
+		results <- s
+	})
+	run := func(script string) string {
+		t.Helper()
+		if err := loop.RunOnLoop(func(vm *goja.Runtime) {
+			if _, err := vm.RunString(script); err != nil {
+				t.Error(err)
+			}
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return <-results
+	}
+	if res := run(`storage.set("a", "value").then(() => storage.get("a")).then(v => done(v), e => done(String(e)))`); res != "value" {
+		t.Fatalf("Unexpected result: %s", res)
+	}
+	cancel()
+	if res := run(`storage.get("a").then(v => done(String(v)), e => done(String(e)))`); res != "GoError: context canceled" {
+		t.Fatalf("Unexpected result: %s", res)
+	}
+}
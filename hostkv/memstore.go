@@ -0,0 +1,152 @@
+package hostkv
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quota limits the amount of data in a MemoryStore. Zero values mean no limit.
+type Quota struct {
+	// MaxKeys is the maximum number of keys.
+	MaxKeys int
+	// MaxBytes is the maximum total size of the keys and the values.
+	MaxBytes int64
+	// MaxKeySize and MaxValueSize are the maximum sizes of a single key and a single value.
+	MaxKeySize   int
+	MaxValueSize int
+}
+
+// MemoryStore is a Store which keeps the data in memory. It is safe for concurrent use.
+type MemoryStore struct {
+	quota Quota
+	now   func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]memEntry
+	size    int64
+}
+
+type memEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore with the quota.
+func NewMemoryStore(quota Quota) *MemoryStore {
+	return &MemoryStore{
+		quota:   quota,
+		now:     time.Now,
+		entries: make(map[string]memEntry),
+	}
+}
+
+func (e memEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// Size returns the number of keys and their total size, including the values. The expired keys which have not been
+// removed yet are included.
+func (s *MemoryStore) Size() (keys int, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries), s.size
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, exists := s.entries[key]
+	if !exists {
+		return nil, false, nil
+	}
+	if e.expired(s.now()) {
+		s.remove(key, e)
+		return nil, false, nil
+	}
+	return append([]byte(nil), e.value...), true, nil
+}
+
+// Set implements Store. It returns an error wrapping ErrQuotaExceeded if the key or the value is too big, or if
+// storing the value would exceed the maximum number of keys or the maximum total size after the expired keys have
+// been removed.
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	q := &s.quota
+	if q.MaxKeySize > 0 && len(key) > q.MaxKeySize {
+		return fmt.Errorf("%w: the key is longer than %d bytes", ErrQuotaExceeded, q.MaxKeySize)
+	}
+	if q.MaxValueSize > 0 && len(value) > q.MaxValueSize {
+		return fmt.Errorf("%w: the value is longer than %d bytes", ErrQuotaExceeded, q.MaxValueSize)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	keys, size := len(s.entries), s.size+int64(len(key)+len(value))
+	if old, exists := s.entries[key]; exists {
+		keys--
+		size -= int64(len(key) + len(old.value))
+	}
+	if q.MaxKeys > 0 && keys >= q.MaxKeys || q.MaxBytes > 0 && size > q.MaxBytes {
+		// only purge when it can make a difference
+		for k, e := range s.entries {
+			if k != key && e.expired(now) {
+				s.remove(k, e)
+				keys--
+				size -= int64(len(k) + len(e.value))
+			}
+		}
+		if q.MaxKeys > 0 && keys >= q.MaxKeys {
+			return fmt.Errorf("%w: the number of keys is limited to %d", ErrQuotaExceeded, q.MaxKeys)
+		}
+		if q.MaxBytes > 0 && size > q.MaxBytes {
+			return fmt.Errorf("%w: the total size is limited to %d bytes", ErrQuotaExceeded, q.MaxBytes)
+		}
+	}
+	if old, exists := s.entries[key]; exists {
+		s.remove(key, old)
+	}
+	e := memEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	s.entries[key] = e
+	s.size += int64(len(key) + len(value))
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, exists := s.entries[key]; exists {
+		s.remove(key, e)
+	}
+	return nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context, prefix string, limit int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	keys := []string{}
+	for k, e := range s.entries {
+		if strings.HasPrefix(k, prefix) && !e.expired(now) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys, nil
+}
+
+func (s *MemoryStore) remove(key string, e memEntry) {
+	delete(s.entries, key)
+	s.size -= int64(len(key) + len(e.value))
+}
@@ -0,0 +1,262 @@
+package httphandler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/file"
+)
+
+// CacheEntry is a response stored in a Cache. The entries passed to and returned by a Cache must not be modified.
+type CacheEntry struct {
+	Status     int
+	StatusText string
+	Header     http.Header
+	Body       []byte
+
+	// Stored is the time the response has been put into the cache.
+	Stored time.Time
+	// TTL is the time the response remains fresh for, zero means forever.
+	TTL time.Duration
+	// StaleWhileRevalidate is the time after the response has become stale during which it is still served while
+	// it is being revalidated in the background.
+	StaleWhileRevalidate time.Duration
+}
+
+// Expires returns the time after which the entry is no longer used, or the zero time if it never expires.
+func (e *CacheEntry) Expires() time.Time {
+	if e.TTL == 0 {
+		return time.Time{}
+	}
+	return e.Stored.Add(e.TTL + e.StaleWhileRevalidate)
+}
+
+// Cache is the host implementation of the cache exposed to scripts as the caches global object, see WithCache.
+// The entries are identified by the name of the cache (which is "default" for caches.default) and the URL of the
+// request. The methods are called from multiple goroutines.
+//
+// The scripts use the cache as in edge function platforms:
+//
+//	let res = await caches.default.match(request);
+//	if (!res) {
+//	    res = await fetch(request);
+//	    ctx.waitUntil(caches.default.put(request, res.clone()));
+//	}
+//
+// caches.open(name) returns a Promise of a cache with the given name. The caches have the following methods, all of
+// which return Promises:
+//
+//   - match(request): the cached response for the request (or URL), or undefined. The Age header of the response is
+//     set to the number of seconds since the response has been stored. If the response is stale but still within
+//     its stale-while-revalidate period, it is returned and the request is fetched again in the background (once
+//     per URL at a time), the new response replaces the cached one if its status is 2xx.
+//   - put(request, response, options): stores the response, consuming its body. The TTL and the stale-while-
+//     revalidate period are taken from the s-maxage (or max-age) and stale-while-revalidate directives of the
+//     Cache-Control header, or from options.ttl and options.staleWhileRevalidate (in seconds) which take precedence.
+//     Responses with no-store, no-cache, private or a zero TTL are not stored. Without a TTL the response never
+//     expires.
+//   - delete(request): deletes the response, the result is true if there was one.
+//
+// Only GET requests are supported: match resolves to undefined and put rejects with a TypeError for other methods.
+type Cache interface {
+	// Get returns the entry, or nil if there is none.
+	Get(ctx context.Context, cache, url string) (*CacheEntry, error)
+	Put(ctx context.Context, cache, url string, entry *CacheEntry) error
+	// Delete deletes the entry and reports whether it has existed.
+	Delete(ctx context.Context, cache, url string) (bool, error)
+}
+
+type memCacheKey struct {
+	cache, url string
+}
+
+// MemoryCache is a Cache which keeps the entries in memory, up to the given total size of the response bodies.
+// When the limit is reached, the expired entries are evicted first, then the ones which have been stored the
+// earliest.
+type MemoryCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[memCacheKey]*CacheEntry
+	size    int64
+}
+
+// NewMemoryCache creates a MemoryCache. Zero or negative maxBytes means no limit.
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	return &MemoryCache{
+		maxBytes: maxBytes,
+		entries:  make(map[memCacheKey]*CacheEntry),
+	}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(_ context.Context, cache, url string) (*CacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[memCacheKey{cache, url}], nil
+}
+
+// Put implements Cache. Entries bigger than the limit are not stored.
+func (c *MemoryCache) Put(_ context.Context, cache, url string, entry *CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := memCacheKey{cache, url}
+	c.remove(key)
+	size := int64(len(entry.Body))
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return nil
+	}
+	if c.maxBytes > 0 && c.size+size > c.maxBytes {
+		now := time.Now()
+		var keys []memCacheKey
+		for k, e := range c.entries {
+			if exp := e.Expires(); !exp.IsZero() && exp.Before(now) {
+				c.remove(k)
+			} else {
+				keys = append(keys, k)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return c.entries[keys[i]].Stored.Before(c.entries[keys[j]].Stored)
+		})
+		for _, k := range keys {
+			if c.size+size <= c.maxBytes {
+				break
+			}
+			c.remove(k)
+		}
+	}
+	c.entries[key] = entry
+	c.size += size
+	return nil
+}
+
+// Delete implements Cache.
+func (c *MemoryCache) Delete(_ context.Context, cache, url string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.remove(memCacheKey{cache, url}), nil
+}
+
+func (c *MemoryCache) remove(key memCacheKey) bool {
+	e, exists := c.entries[key]
+	if exists {
+		delete(c.entries, key)
+		c.size -= int64(len(e.Body))
+	}
+	return exists
+}
+
+// installCache adds the host functions used by the caches object of the prelude.
+func (i *instance) installCache(host *goja.Object) {
+	rt := i.rt
+	_ = host.Set("cacheMatch", func(name, url string) goja.Value {
+		return i.cacheCall(func(ctx context.Context) (interface{}, error) {
+			return i.h.cache.Get(ctx, name, url)
+		}, func(v interface{}) goja.Value {
+			e := v.(*CacheEntry)
+			if e == nil {
+				return goja.Null()
+			}
+			age := i.h.now().Sub(e.Stored)
+			stale := e.TTL > 0 && age >= e.TTL
+			if stale && age >= e.TTL+e.StaleWhileRevalidate {
+				return goja.Null()
+			}
+			res := rt.NewObject()
+			_ = res.Set("status", e.Status)
+			_ = res.Set("statusText", e.StatusText)
+			_ = res.Set("headers", headerPairs(rt, e.Header))
+			_ = res.Set("body", rt.NewArrayBuffer(append([]byte(nil), e.Body...)))
+			_ = res.Set("age", int64(age/time.Second))
+			_ = res.Set("ttl", e.TTL.Seconds())
+			_ = res.Set("staleWhileRevalidate", e.StaleWhileRevalidate.Seconds())
+			_ = res.Set("stale", stale)
+			return res
+		})
+	})
+	_ = host.Set("cachePut", func(call goja.FunctionCall) goja.Value {
+		var headers [][]string
+		if err := rt.ExportTo(call.Argument(4), &headers); err != nil {
+			panic(rt.NewTypeError(err.Error()))
+		}
+		e := &CacheEntry{
+			Status:               int(call.Argument(2).ToInteger()),
+			StatusText:           call.Argument(3).String(),
+			Header:               make(http.Header),
+			Stored:               i.h.now(),
+			TTL:                  seconds(call.Argument(6)),
+			StaleWhileRevalidate: seconds(call.Argument(7)),
+		}
+		for _, h := range headers {
+			e.Header.Add(h[0], h[1])
+		}
+		switch b := call.Argument(5).Export().(type) {
+		case string:
+			e.Body = []byte(b)
+		case goja.ArrayBuffer:
+			e.Body = append([]byte(nil), b.Bytes()...)
+		}
+		name, url := call.Argument(0).String(), call.Argument(1).String()
+		return i.cacheCall(func(ctx context.Context) (interface{}, error) {
+			return nil, i.h.cache.Put(ctx, name, url, e)
+		}, func(interface{}) goja.Value {
+			return goja.Undefined()
+		})
+	})
+	_ = host.Set("cacheDelete", func(name, url string) goja.Value {
+		return i.cacheCall(func(ctx context.Context) (interface{}, error) {
+			return i.h.cache.Delete(ctx, name, url)
+		}, func(v interface{}) goja.Value {
+			return rt.ToValue(v)
+		})
+	})
+	_ = host.Set("cacheRevalidate", func(name, url string) bool {
+		_, running := i.h.revalidating.LoadOrStore(memCacheKey{name, url}, struct{}{})
+		return !running
+	})
+	_ = host.Set("cacheRevalidated", func(call goja.FunctionCall) goja.Value {
+		url := call.Argument(1).String()
+		i.h.revalidating.Delete(memCacheKey{call.Argument(0).String(), url})
+		if err := call.Argument(2); !goja.IsUndefined(err) {
+			i.h.console("error", "cache revalidation of "+url+" failed: "+i.formatValue(err))
+		}
+		return goja.Undefined()
+	})
+	_ = host.Set("waitUntil", i.addWaitUntil)
+}
+
+// seconds converts the non-negative number of seconds into a Duration, clamping it to the maximum.
+func seconds(v goja.Value) time.Duration {
+	return time.Duration(math.Min(v.ToFloat()*float64(time.Second), math.MaxInt64))
+}
+
+// cacheCall runs op on a separate goroutine and returns a Promise which is settled with the value returned by result.
+func (i *instance) cacheCall(op func(ctx context.Context) (interface{}, error), result func(v interface{}) goja.Value) goja.Value {
+	rt := i.rt
+	l := i.currentLoop()
+	var pos file.Position
+	if l.longTask != nil {
+		pos = i.callerPos()
+	}
+	p, resolve, reject := rt.NewPromise()
+	done := l.startAsync("cache", pos, func() {
+		reject(rt.NewGoError(ErrLoopStopped))
+	})
+	go func() {
+		v, err := op(l.ctx)
+		done(func() {
+			if err != nil {
+				reject(rt.NewGoError(err))
+				return
+			}
+			resolve(result(v))
+		})
+	}()
+	return rt.ToValue(p)
+}
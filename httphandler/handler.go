@@ -0,0 +1,452 @@
+// Package httphandler serves HTTP requests with JavaScript handlers executed by goja.
+//
+// The handler script uses one of the two conventions common in edge function platforms:
+//
+//	export default async function(request, ctx) {
+//	    return new Response("Hello from " + request.url);
+//	}
+//
+// (the default export may also be an object with a fetch(request, ctx) method), or
+//
+//	addEventListener("fetch", event => {
+//	    event.respondWith(new Response("Hello"));
+//	});
+//
+// Scripts have access to fetch, Headers, Request, Response, console, setTimeout/setInterval and their clear
+// counterparts, as well as to caches if a Cache is configured (see WithCache) and to WebSocket if a dialer is
+// configured (see WithWebSocket). Request and Response bodies are exchanged as strings or ArrayBuffers. The
+// connections made by fetch and WebSocket can be restricted and accounted for by a NetworkPolicy, and the resources
+// consumed by each request can be reported with WithUsageHandler.
+//
+// As goja does not support ES modules, "export default" is only recognised at the beginning of a line and is
+// rewritten into a plain assignment; no other module syntax is supported.
+//
+// Each Runtime is initialised once by running the script and is then reused for subsequent requests, so global
+// state persists between the requests served by the same Runtime. A Runtime serves one request at a time; when a
+// request is cancelled or times out while the script is running the Runtime is discarded.
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// Handler is an http.Handler that runs a JavaScript handler.
+type Handler struct {
+	prg *goja.Program
+
+	pool         chan *instance
+	timeout      time.Duration
+	maxBodySize  int64
+	client       *http.Client
+	console      func(level, msg string)
+	setup        func(*goja.Runtime) error
+	errorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+	longTaskThreshold time.Duration
+	longTask          func(r *http.Request, task LongTask)
+
+	stopMode     StopMode
+	drainTimeout time.Duration
+
+	cache        Cache
+	revalidating sync.Map // the cache entries being revalidated
+	now          func() time.Time
+
+	wsDialer WebSocketDialer
+	policy   NetworkPolicy
+
+	usage func(r *http.Request, u goja.ResourceUsage)
+}
+
+// WithPoolSize sets the maximum number of idle Runtimes kept for reuse. The default is 16.
+func WithPoolSize(n int) Option {
+	return func(h *Handler) {
+		h.pool = make(chan *instance, n)
+	}
+}
+
+// WithTimeout limits the time a single request may take, including all asynchronous work. The request's own
+// context is always honoured. The default is no limit.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.timeout = d
+	}
+}
+
+// WithMaxBodySize limits the size of incoming request bodies and of responses retrieved by fetch. The default is
+// 10MB, zero or negative means no limit.
+func WithMaxBodySize(n int64) Option {
+	return func(h *Handler) {
+		h.maxBodySize = n
+	}
+}
+
+// WithHTTPClient sets the client used by fetch. The default is http.DefaultClient. If c is nil, fetch is disabled
+// and throws a TypeError.
+func WithHTTPClient(c *http.Client) Option {
+	return func(h *Handler) {
+		h.client = c
+	}
+}
+
+// WithConsole sets the function receiving the output of the console built-in of goja (see
+// goja.Runtime.SetConsolePrinter), as well as the errors reported by the Handler. The level is one of the levels of
+// the console package (log, info, warn, error or debug). By default the output goes to the standard logger.
+func WithConsole(f func(level, msg string)) Option {
+	return func(h *Handler) {
+		h.console = f
+	}
+}
+
+// WithRuntimeSetup sets a function which is called for every new Runtime before the script is run. It can be used
+// to install additional globals.
+func WithRuntimeSetup(f func(*goja.Runtime) error) Option {
+	return func(h *Handler) {
+		h.setup = f
+	}
+}
+
+// WithErrorHandler sets the function which is called when the script fails to produce a response. The default
+// handler logs the error and replies with 504 Gateway Timeout if the timeout expired or with 500 Internal Server
+// Error otherwise.
+func WithErrorHandler(f func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(h *Handler) {
+		h.errorHandler = f
+	}
+}
+
+// WithLongTaskHandler sets a function which is called when a single task blocks the event loop for longer than
+// the threshold. A task is the invocation of the request handler, a timer callback or the completion of a fetch,
+// including the Promise jobs it has triggered. The function is called on the goroutine serving the request after
+// the task has finished; it must not use the Runtime.
+func WithLongTaskHandler(threshold time.Duration, f func(r *http.Request, task LongTask)) Option {
+	return func(h *Handler) {
+		h.longTaskThreshold = threshold
+		h.longTask = f
+	}
+}
+
+// WithStopMode sets what happens to the asynchronous work which is still pending when a request is done, see
+// StopMode. drainTimeout limits the time spent running the pending work in StopDrain mode and is ignored by the
+// other modes. Note that the request is not complete (i.e. ServeHTTP does not return) until the loop is drained.
+func WithStopMode(mode StopMode, drainTimeout time.Duration) Option {
+	return func(h *Handler) {
+		h.stopMode = mode
+		h.drainTimeout = drainTimeout
+	}
+}
+
+// WithCache defines the caches global object backed by the Cache, see Cache for the details. By default it is not
+// defined.
+func WithCache(c Cache) Option {
+	return func(h *Handler) {
+		h.cache = c
+	}
+}
+
+// WithWebSocket defines the WebSocket class, whose connections are created by the dialer. By default the class is
+// not defined.
+//
+// The class follows the WHATWG WebSocket API with the exception of Blob messages (binaryType is always
+// "arraybuffer"). The messages can also be consumed through the async iterator of the object, which yields the
+// data of the messages received after it has been created and finishes when the connection is closed (or throws if
+// it has failed). The connections are closed when the request is done.
+func WithWebSocket(d WebSocketDialer) Option {
+	return func(h *Handler) {
+		h.wsDialer = d
+	}
+}
+
+// WithNetworkPolicy sets the policy consulted by fetch and WebSocket before connecting, see NetworkPolicy. By
+// default all the connections are allowed.
+func WithNetworkPolicy(p NetworkPolicy) Option {
+	return func(h *Handler) {
+		h.policy = p
+	}
+}
+
+// WithUsageHandler enables the resource accounting of the Runtimes (see goja.Runtime.SetResourceAccounting) and
+// sets a function which is called with the resources consumed by each request once it is done, e.g. for billing.
+// The usage includes the asynchronous work run before the request is done (see WithStopMode) and the data
+// transferred by fetch and WebSocket, as reported to NetworkPolicy.Transferred. The function is called on the
+// goroutine serving the request, even if the request has failed; it must not use the Runtime.
+func WithUsageHandler(f func(r *http.Request, u goja.ResourceUsage)) Option {
+	return func(h *Handler) {
+		h.usage = f
+	}
+}
+
+var exportDefaultRegexp = regexp.MustCompile(`(?m)^([ \t]*)export[ \t]+default\b`)
+
+// New compiles the script and creates a Handler. The script is run once to make sure it defines a handler, any
+// error is returned.
+func New(name, src string, options ...Option) (*Handler, error) {
+	h := &Handler{
+		maxBodySize:  10 << 20,
+		client:       http.DefaultClient,
+		console:      defaultConsole,
+		errorHandler: defaultErrorHandler,
+		now:          time.Now,
+	}
+	for _, opt := range options {
+		opt(h)
+	}
+	if h.pool == nil {
+		h.pool = make(chan *instance, 16)
+	}
+	if h.policy != nil && h.client != nil {
+		h.client = policyClient(h.client, h.policy)
+	}
+
+	src = exportDefaultRegexp.ReplaceAllString(src, "${1}var "+strings.ReplaceAll(defaultExportName, "$", "$$")+" =")
+	prg, err := goja.Compile(name, src, false)
+	if err != nil {
+		return nil, err
+	}
+	h.prg = prg
+
+	inst, err := h.newInstance()
+	if err != nil {
+		return nil, err
+	}
+	h.put(inst)
+	return h, nil
+}
+
+func defaultConsole(level, msg string) {
+	log.Printf("console.%s: %s", level, msg)
+}
+
+func defaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("httphandler: %s %s: %v", r.Method, r.URL, err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		http.Error(w, http.StatusText(http.StatusGatewayTimeout), http.StatusGatewayTimeout)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (h *Handler) get() (*instance, error) {
+	select {
+	case inst := <-h.pool:
+		return inst, nil
+	default:
+		return h.newInstance()
+	}
+}
+
+func (h *Handler) put(inst *instance) {
+	select {
+	case h.pool <- inst:
+	default:
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := readLimited(r.Body, h.maxBodySize)
+	if err != nil {
+		if err == errBodyTooLarge {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		}
+		return
+	}
+
+	inst, err := h.get()
+	if err != nil {
+		h.errorHandler(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := make(chan struct{})
+	interrupted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			inst.rt.Interrupt(ctx.Err())
+			interrupted <- true
+		case <-stop:
+			interrupted <- false
+		}
+	}()
+
+	err = inst.serve(ctx, w, r, body)
+
+	close(stop)
+	if !<-interrupted {
+		h.put(inst)
+	}
+
+	if err != nil {
+		var ie *goja.InterruptedError
+		if errors.As(err, &ie) {
+			if e, ok := ie.Value().(error); ok {
+				err = e
+			}
+		}
+		h.errorHandler(w, r, err)
+	}
+}
+
+func (i *instance) serve(ctx context.Context, w http.ResponseWriter, r *http.Request, body []byte) error {
+	l := newEventLoop(ctx)
+	if i.h.longTask != nil {
+		l.longTaskThreshold = i.h.longTaskThreshold
+		l.longTask = func(task LongTask) {
+			i.h.longTask(r, task)
+		}
+	}
+	l.resume(i.frozenTimers)
+	i.frozenTimers = nil
+	i.loop = l
+	i.request = r
+	if i.h.usage != nil {
+		// the usage of the previous requests has already been reported
+		i.rt.ResetResourceUsage()
+	}
+	defer func() {
+		i.frozenTimers = l.close(i.h.stopMode == StopFreeze)
+		i.loop = nil
+		i.request = nil
+		i.response = nil
+		i.waitUntil = nil
+		if i.h.usage != nil {
+			i.h.usage(r, i.rt.ResetResourceUsage())
+		}
+	}()
+
+	rt := i.rt
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	var reqBody goja.Value = goja.Null()
+	if len(body) > 0 {
+		reqBody = rt.ToValue(rt.NewArrayBuffer(body))
+	}
+	req, err := i.newRequest(nil, rt.ToValue(r.Method), rt.ToValue(scheme+"://"+r.Host+r.URL.RequestURI()),
+		headerPairs(rt, r.Header), reqBody)
+	if err != nil {
+		return err
+	}
+
+	err = l.runTask("handler", i.handlerPos, func() (err error) {
+		if i.handler != nil {
+			i.response, err = i.handler(i.handlerThis, req, i.newContext())
+		} else {
+			_, err = i.listener(nil, i.newFetchEvent(req))
+		}
+		return
+	})
+	if err != nil {
+		return err
+	}
+	if i.response == nil {
+		return errNoResponse
+	}
+
+	if p, ok := i.response.Export().(*goja.Promise); ok {
+		err = l.run(ctx, func() bool {
+			return p.State() != goja.PromiseStatePending
+		})
+		if err != nil {
+			return err
+		}
+		if p.State() == goja.PromiseStateRejected {
+			return rejectionError(p.Result())
+		}
+		i.response = p.Result()
+	}
+
+	res, err := i.unwrapResponse(nil, i.response)
+	if err != nil {
+		return err
+	}
+	resObj := res.ToObject(rt)
+	var headers [][]string
+	if err := rt.ExportTo(resObj.Get("headers"), &headers); err != nil {
+		return err
+	}
+	var data []byte
+	switch b := resObj.Get("body").Export().(type) {
+	case string:
+		data = []byte(b)
+	case goja.ArrayBuffer:
+		data = b.Bytes()
+	}
+
+	header := w.Header()
+	for _, pair := range headers {
+		header.Add(pair[0], pair[1])
+	}
+	w.WriteHeader(int(resObj.Get("status").ToInteger()))
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+
+	if len(i.waitUntil) > 0 {
+		flush(w)
+		err = l.run(ctx, func() bool {
+			for _, p := range i.waitUntil {
+				if p.State() == goja.PromiseStatePending {
+					return false
+				}
+			}
+			return true
+		})
+		if err != nil {
+			i.h.console("error", "waitUntil: "+err.Error())
+		}
+	}
+	if i.h.stopMode == StopDrain && l.pending > 0 {
+		flush(w)
+		drainCtx, cancel := context.WithTimeout(ctx, i.h.drainTimeout)
+		err = l.run(drainCtx, func() bool {
+			return l.pending == 0
+		})
+		cancel()
+		if err != nil {
+			i.h.console("error", "drain: "+err.Error())
+		}
+	}
+	return nil
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// rejectionError converts the reason of a rejected handler promise into an error.
+func rejectionError(reason goja.Value) error {
+	if obj, ok := reason.(*goja.Object); ok {
+		if stack := obj.Get("stack"); stack != nil && !goja.IsUndefined(stack) {
+			return errors.New("Uncaught (in promise) " + stack.String())
+		}
+	}
+	return errors.New("Uncaught (in promise) " + reason.String())
+}
@@ -0,0 +1,839 @@
+package httphandler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+func serve(t *testing.T, h http.Handler, method, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, url, r))
+	return w
+}
+
+func TestExportDefault(t *testing.T) {
+	const SCRIPT = `
+	let count = 0;
+	export default async function(req, ctx) {
+		count++;
+		const data = await req.json();
+		return Response.json({method: req.method, url: req.url, sum: data.a + data.b, count: count,
+			agent: req.headers.get("User-Agent")}, {status: 201, headers: {"X-Test": "yes"}});
+	}
+	`
+	h, err := New("test.js", SCRIPT, WithPoolSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 2; i++ {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "http://example.com/path?q=1", strings.NewReader(`{"a": 1, "b": 2}`))
+		req.Header.Set("User-Agent", "tester")
+		h.ServeHTTP(w, req)
+		if w.Code != 201 {
+			t.Fatalf("Unexpected status: %d (%s)", w.Code, w.Body.String())
+		}
+		if v := w.Header().Get("X-Test"); v != "yes" {
+			t.Fatalf("Unexpected header: %q", v)
+		}
+		if v := w.Header().Get("Content-Type"); v != "application/json" {
+			t.Fatalf("Unexpected content type: %q", v)
+		}
+		expected := `{"method":"POST","url":"http://example.com/path?q=1","sum":3,"count":` + string(rune('0'+i)) + `,"agent":"tester"}`
+		if body := w.Body.String(); body != expected {
+			t.Fatalf("Unexpected body: %s", body)
+		}
+	}
+}
+
+func TestExportDefaultObject(t *testing.T) {
+	const SCRIPT = `
+	export default {
+		prefix: "Hello, ",
+		fetch(req) {
+			return new Response(this.prefix + req.method);
+		}
+	}
+	`
+	h, err := New("test.js", SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(t, h, "GET", "/", "")
+	if w.Code != 200 || w.Body.String() != "Hello, GET" {
+		t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+	}
+	if v := w.Header().Get("Content-Type"); v != "text/plain;charset=UTF-8" {
+		t.Fatalf("Unexpected content type: %q", v)
+	}
+}
+
+func TestFetchEventListener(t *testing.T) {
+	const SCRIPT = `
+	addEventListener("fetch", event => {
+		event.respondWith(new Promise(resolve => {
+			let n = 0;
+			const id = setInterval(() => {
+				if (++n === 3) {
+					clearInterval(id);
+					setTimeout((a, b) => resolve(new Response(a + b + n, {status: 202})), 1, "x", "y");
+				}
+			}, 1);
+		}));
+	});
+	`
+	h, err := New("test.js", SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(t, h, "GET", "/", "")
+	if w.Code != 202 || w.Body.String() != "xy3" {
+		t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestFetch(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream", r.Header.Get("X-Req"))
+		w.WriteHeader(418)
+		_, _ = w.Write([]byte(r.Method + ":" + string(body)))
+	}))
+	defer upstream.Close()
+
+	const SCRIPT = `
+	export default async function(req) {
+		const res = await fetch(await req.text(), {method: "PUT", headers: {"X-Req": "abc"}, body: "data"});
+		return new Response(res.status + " " + res.ok + " " + res.headers.get("x-upstream") + " " + await res.text());
+	}
+	`
+	h, err := New("test.js", SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(t, h, "POST", "/", upstream.URL)
+	if w.Code != 200 || w.Body.String() != "418 false abc PUT:data" {
+		t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+	}
+
+	h, err = New("test.js", SCRIPT, WithHTTPClient(nil), WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		if !strings.Contains(err.Error(), "fetch is disabled") {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		w.WriteHeader(599)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(t, h, "POST", "/", upstream.URL); w.Code != 599 {
+		t.Fatalf("Unexpected status: %d", w.Code)
+	}
+}
+
+func TestLongTasks(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	var tasks []string
+	longTask := WithLongTaskHandler(20*time.Millisecond, func(r *http.Request, task LongTask) {
+		if task.Duration < 20*time.Millisecond {
+			t.Errorf("Unexpected duration: %v", task.Duration)
+		}
+		tasks = append(tasks, r.URL.Path+" "+task.Kind+" "+task.Location.String())
+	})
+
+	const SCRIPT = `
+	function busy(ms) {
+		const end = Date.now() + ms;
+		while (Date.now() < end) {}
+	}
+	export default async function(req) {
+		busy(30);
+		await new Promise(resolve => setTimeout(function tick() {
+			busy(30);
+			resolve();
+		}, 1));
+		await new Promise(resolve => setTimeout(resolve, 1));
+		const res = await fetch(await req.text());
+		busy(30);
+		return new Response(await res.text());
+	}
+	`
+	h, err := New("test.js", SCRIPT, longTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(t, h, "POST", "/path", upstream.URL); w.Code != 200 || w.Body.String() != "ok" {
+		t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+	}
+	expected := []string{
+		"/path handler test.js:6:17",
+		"/path timer test.js:8:43",
+		"/path fetch test.js:13:26",
+	}
+	if !reflect.DeepEqual(tasks, expected) {
+		t.Fatalf("Unexpected tasks: %q", tasks)
+	}
+}
+
+func TestStopModes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	const SCRIPT = `
+	const fired = [];
+	export default async function(req) {
+		switch (req.url.slice(req.url.lastIndexOf("/") + 1)) {
+		case "fetch":
+			fetch(await req.text()).catch(e => console.log("rejected: " + e.message));
+			break;
+		case "timer":
+			setTimeout(() => { fired.push("timer " + req.method); console.log("fired") }, 20);
+			break;
+		case "interval":
+			setInterval(() => {}, 1);
+			break;
+		case "wait":
+			await new Promise(resolve => setTimeout(resolve, 50));
+			return new Response(fired.join());
+		}
+		return new Response("ok");
+	}
+	`
+	var out []string
+	console := WithConsole(func(level, msg string) {
+		out = append(out, level+": "+msg)
+	})
+	newHandler := func(mode StopMode) *Handler {
+		out = nil
+		h, err := New("test.js", SCRIPT, console, WithPoolSize(1), WithStopMode(mode, 50*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	t.Run("immediate", func(t *testing.T) {
+		h := newHandler(StopImmediate)
+		serve(t, h, "POST", "/fetch", upstream.URL)
+		serve(t, h, "GET", "/timer", "")
+		time.Sleep(30 * time.Millisecond)
+		if w := serve(t, h, "GET", "/wait", ""); w.Body.String() != "" {
+			t.Fatalf("Unexpected body: %q", w.Body.String())
+		}
+		if !reflect.DeepEqual(out, []string{"log: rejected: " + ErrLoopStopped.Error()}) {
+			t.Fatalf("Unexpected output: %q", out)
+		}
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		h := newHandler(StopDrain)
+		serve(t, h, "GET", "/timer", "")
+		if !reflect.DeepEqual(out, []string{"log: fired"}) {
+			t.Fatalf("Unexpected output: %q", out)
+		}
+		out = nil
+		start := time.Now()
+		serve(t, h, "GET", "/interval", "")
+		if d := time.Since(start); d < 50*time.Millisecond {
+			t.Fatalf("Drain timeout has not been honoured: %v", d)
+		}
+		serve(t, h, "POST", "/fetch", upstream.URL)
+		expected := []string{
+			"error: drain: context deadline exceeded", // interval
+			"error: drain: context deadline exceeded", // fetch
+			"log: rejected: " + ErrLoopStopped.Error(),
+		}
+		if !reflect.DeepEqual(out, expected) {
+			t.Fatalf("Unexpected output: %q", out)
+		}
+	})
+
+	t.Run("freeze", func(t *testing.T) {
+		h := newHandler(StopFreeze)
+		serve(t, h, "PUT", "/timer", "")
+		time.Sleep(30 * time.Millisecond)
+		if w := serve(t, h, "GET", "/wait", ""); w.Body.String() != "timer PUT" {
+			t.Fatalf("Unexpected body: %q", w.Body.String())
+		}
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	const SCRIPT = `
+	export default function(req) {
+		if (req.method === "POST") {
+			for (;;) {}
+		}
+		if (req.method === "PUT") {
+			return new Promise(() => { setTimeout(() => {}, 100000) });
+		}
+		return new Response("ok");
+	}
+	`
+	h, err := New("test.js", SCRIPT, WithTimeout(50*time.Millisecond), WithPoolSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(t, h, "POST", "/", "x"); w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("Unexpected status: %d", w.Code)
+	}
+	if w := serve(t, h, "PUT", "/", "x"); w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("Unexpected status: %d", w.Code)
+	}
+	if w := serve(t, h, "GET", "/", ""); w.Code != 200 || w.Body.String() != "ok" {
+		t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var mu sync.Mutex
+	var errs []string
+	errorHandler := WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		mu.Lock()
+		errs = append(errs, err.Error())
+		mu.Unlock()
+		w.WriteHeader(500)
+	})
+
+	const SCRIPT = `
+	export default async function(req) {
+		switch (req.url.slice(req.url.lastIndexOf("/") + 1)) {
+		case "throw":
+			throw new Error("boom");
+		case "string":
+			return "not a response";
+		case "pending":
+			return new Promise(() => {});
+		}
+	}
+	`
+	h, err := New("test.js", SCRIPT, errorHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"throw", "string", "pending"} {
+		if w := serve(t, h, "GET", "/"+path, ""); w.Code != 500 {
+			t.Fatalf("%s: unexpected status: %d", path, w.Code)
+		}
+	}
+	if len(errs) != 3 || !strings.Contains(errs[0], "boom") || !strings.Contains(errs[1], "Response") || errs[2] != errNoResponse.Error() {
+		t.Fatalf("Unexpected errors: %q", errs)
+	}
+
+	if _, err := New("test.js", `var x = 1;`); err == nil {
+		t.Fatal("Expected an error for a script without a handler")
+	}
+	if _, err := New("test.js", `setTimeout(() => {}, 1); export default function() {}`); err == nil {
+		t.Fatal("Expected an error for a timer in the global scope")
+	}
+}
+
+func TestConsoleAndWaitUntil(t *testing.T) {
+	var mu sync.Mutex
+	var out []string
+	console := WithConsole(func(level, msg string) {
+		mu.Lock()
+		out = append(out, level+": "+msg)
+		mu.Unlock()
+	})
+
+	const SCRIPT = `
+	export default function(req, ctx) {
+		console.log("request", {method: req.method}, [1, 2]);
+		ctx.waitUntil(new Promise(resolve => setTimeout(() => {
+			console.warn("done");
+			resolve();
+		}, 1)));
+		return new Response(null, {status: 204});
+	}
+	`
+	h, err := New("test.js", SCRIPT, console)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(t, h, "GET", "/", ""); w.Code != 204 {
+		t.Fatalf("Unexpected status: %d", w.Code)
+	}
+	if len(out) != 2 || out[0] != "log: request { method: 'GET' } [ 1, 2 ]" || out[1] != "warn: done" {
+		t.Fatalf("Unexpected output: %q", out)
+	}
+}
+
+func TestCache(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "public, max-age=10, stale-while-revalidate=60")
+		_, _ = w.Write([]byte(fmt.Sprintf("v%d", n)))
+	}))
+	defer upstream.Close()
+
+	const SCRIPT = `
+	export default async function(req, ctx) {
+		const cache = caches.default;
+		const key = ORIGIN + "/item";
+		switch (req.method) {
+		case "DELETE":
+			return new Response(String(await cache.delete(key)));
+		case "PUT":
+			const other = await caches.open("other");
+			const results = [
+				await cache.put(new Request(key, {method: "POST"}), new Response("x")).catch(e => e.name),
+				await other.put("/no-store", new Response("x", {headers: {"Cache-Control": "no-store"}})),
+				await other.match("/no-store"),
+				await other.put("/ttl", new Response("y", {headers: {"Cache-Control": "no-store"}}), {ttl: 1}),
+				await (await other.match("/ttl")).text(),
+				await other.match(key),
+			];
+			return new Response(results.join(","));
+		}
+		let res = await cache.match(key);
+		let status = "HIT";
+		if (!res) {
+			status = "MISS";
+			res = await fetch(key);
+			ctx.waitUntil(cache.put(key, res.clone()));
+		}
+		return new Response(status + " " + res.headers.get("age") + " " + await res.text());
+	}
+	`
+	h, err := New("test.js", SCRIPT, WithCache(NewMemoryCache(0)), WithRuntimeSetup(func(rt *goja.Runtime) error {
+		return rt.Set("ORIGIN", upstream.URL)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1e9, 0)
+	h.now = func() time.Time {
+		return now
+	}
+	for _, step := range []struct {
+		advance  time.Duration
+		method   string
+		expected string
+	}{
+		{0, "GET", "MISS null v1"},
+		{5 * time.Second, "GET", "HIT 5 v1"},
+		// stale, the response is revalidated after it has been sent
+		{10 * time.Second, "GET", "HIT 15 v1"},
+		{0, "GET", "HIT 0 v2"},
+		{100 * time.Second, "GET", "MISS null v3"},
+		{0, "DELETE", "true"},
+		{0, "DELETE", "false"},
+		{0, "PUT", "TypeError,,,,y,"},
+	} {
+		now = now.Add(step.advance)
+		if w := serve(t, h, step.method, "/", ""); w.Code != 200 || w.Body.String() != step.expected {
+			t.Fatalf("Unexpected response: %d %q, expected %q", w.Code, w.Body.String(), step.expected)
+		}
+	}
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Fatalf("Unexpected number of upstream requests: %d", n)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+	now := time.Now()
+	put := func(url string, size int, stored time.Time, ttl time.Duration) {
+		t.Helper()
+		if err := c.Put(ctx, "default", url, &CacheEntry{Body: make([]byte, size), Stored: stored, TTL: ttl}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	has := func(url string) bool {
+		e, err := c.Get(ctx, "default", url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e != nil
+	}
+	put("/a", 4, now.Add(-time.Minute), 0)
+	put("/b", 4, now.Add(-time.Hour), time.Second)
+	put("/c", 4, now, 0)
+	// the expired entry is evicted first
+	if !has("/a") || has("/b") || !has("/c") {
+		t.Fatal("Unexpected eviction")
+	}
+	put("/d", 4, now, 0)
+	if has("/a") || !has("/c") || !has("/d") {
+		t.Fatal("Unexpected eviction")
+	}
+	put("/big", 11, now, 0)
+	if has("/big") || !has("/c") {
+		t.Fatal("Unexpected eviction")
+	}
+	if ok, _ := c.Delete(ctx, "default", "/c"); !ok {
+		t.Fatal("Delete failed")
+	}
+	if ok, _ := c.Delete(ctx, "default", "/c"); ok {
+		t.Fatal("Repeated delete succeeded")
+	}
+}
+
+type echoMessage struct {
+	data []byte
+	text bool
+}
+
+// echoConn is a WebSocketConn sending the messages back. The "quit" message makes it close the connection.
+type echoConn struct {
+	in        chan echoMessage
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeCode int
+}
+
+func (c *echoConn) ReadMessage() ([]byte, bool, error) {
+	select {
+	case m := <-c.in:
+		if m.text && string(m.data) == "quit" {
+			return nil, false, &WebSocketCloseError{Code: 4000, Reason: "bye"}
+		}
+		return m.data, m.text, nil
+	case <-c.closed:
+		return nil, false, io.EOF
+	}
+}
+
+func (c *echoConn) WriteMessage(data []byte, text bool) error {
+	c.in <- echoMessage{data, text}
+	return nil
+}
+
+func (c *echoConn) Close(code int, reason string) error {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		close(c.closed)
+	})
+	return nil
+}
+
+type echoDialer struct {
+	mu    sync.Mutex
+	conns []*echoConn
+}
+
+func (d *echoDialer) Dial(_ context.Context, u *url.URL, protocols []string, _ *tls.Config) (WebSocketConn, string, error) {
+	if u.Host != "echo.example" {
+		return nil, "", fmt.Errorf("cannot connect to %s", u.Host)
+	}
+	c := &echoConn{in: make(chan echoMessage, 16), closed: make(chan struct{})}
+	d.mu.Lock()
+	d.conns = append(d.conns, c)
+	d.mu.Unlock()
+	var protocol string
+	if len(protocols) > 0 {
+		protocol = protocols[len(protocols)-1]
+	}
+	return c, protocol, nil
+}
+
+func TestWebSocket(t *testing.T) {
+	const SCRIPT = `
+	function opened(ws) {
+		return new Promise((resolve, reject) => {
+			ws.addEventListener("open", resolve);
+			ws.addEventListener("error", e => reject(e.error));
+		});
+	}
+
+	function closed(ws) {
+		return new Promise(resolve => ws.onclose = e => resolve([e.code, e.reason, e.wasClean, ws.readyState].join(" ")));
+	}
+
+	export default async function(req) {
+		if (req.url.endsWith("/leak")) {
+			await opened(new WebSocket("wss://echo.example/"));
+			return new Response("leaked");
+		}
+		const log = [];
+		for (const u of ["wss://denied.example/", "https://echo.example/", "wss://echo.example/#hash"]) {
+			try {
+				new WebSocket(u);
+			} catch (e) {
+				log.push(e.name);
+			}
+		}
+
+		const ws = new WebSocket("wss://echo.example/chat", ["v1", "v2"]);
+		try {
+			ws.send("early");
+		} catch (e) {
+			log.push(e.name);
+		}
+		await opened(ws);
+		log.push(ws.protocol, ws.readyState);
+		const messages = [];
+		ws.onmessage = e => messages.push(typeof e.data === "string" ? e.data : new Uint8Array(e.data).join(":"));
+		ws.addEventListener("message", () => {
+			throw new Error("boom");
+		});
+		const it = ws[Symbol.asyncIterator]();
+		ws.send("hello");
+		ws.send(new Uint8Array([1, 2, 3]).subarray(1));
+		log.push((await it.next()).value);
+		log.push(new Uint8Array((await it.next()).value).join(":"));
+		ws.send("quit");
+		log.push(await closed(ws), (await it.next()).done, messages.join("|"));
+
+		const ws2 = new WebSocket("wss://echo.example/");
+		await opened(ws2);
+		const it2 = ws2[Symbol.asyncIterator]();
+		ws2.close(4001, "done");
+		ws2.close();
+		log.push(ws2.readyState, await closed(ws2), (await it2.next()).done);
+
+		const failed = new WebSocket("wss://unknown.example/");
+		const it3 = failed[Symbol.asyncIterator]();
+		log.push(await opened(failed).catch(e => e.message), await it3.next().catch(e => e.name));
+		return new Response(log.join(","));
+	}
+	`
+	var logged []string
+	dialer := &echoDialer{}
+	policy := &BasicNetworkPolicy{DeniedHosts: []string{"denied.example"}}
+	h, err := New("test.js", SCRIPT, WithWebSocket(dialer), WithNetworkPolicy(policy), WithConsole(func(level, msg string) {
+		logged = append(logged, level+": "+msg)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(t, h, "GET", "/", "")
+	const expected = "SecurityError,SyntaxError,SyntaxError,InvalidStateError,v2,1,hello,2:3," +
+		"4000 bye true 3,true,hello|2:3,2,4001 done true 3,true," +
+		"WebSocket connection failed: cannot connect to unknown.example,NetworkError"
+	if w.Code != 200 || w.Body.String() != expected {
+		t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+	}
+	if len(logged) != 2 || !strings.Contains(logged[0], "error: Uncaught Error: boom") {
+		t.Fatalf("Unexpected console output: %q", logged)
+	}
+	// "hello", the 2 bytes and "quit" have been sent, the first two have been echoed
+	if b := policy.Usage(""); b.Sent != 11 || b.Received != 7 {
+		t.Fatalf("Unexpected usage: %+v", b)
+	}
+
+	// the connections left open are closed when the request is done
+	if w := serve(t, h, "GET", "/leak", ""); w.Code != 200 || w.Body.String() != "leaked" {
+		t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+	}
+	dialer.mu.Lock()
+	c := dialer.conns[len(dialer.conns)-1]
+	dialer.mu.Unlock()
+	select {
+	case <-c.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The connection has not been closed")
+	}
+	if c.closeCode != 1001 {
+		t.Fatalf("Unexpected close code: %d", c.closeCode)
+	}
+}
+
+func TestNetworkPolicy(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "https://denied.example/", http.StatusFound)
+			return
+		}
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer upstream.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(upstream.Certificate())
+
+	const SCRIPT = `
+	export default async function(req) {
+		const results = [];
+		for (const [url, init] of [
+			[ORIGIN + "/echo", {method: "POST", body: "abcde"}],
+			[ORIGIN + "/redirect"],
+			["https://denied.example/"],
+		]) {
+			results.push(await fetch(url, init).then(res => res.text(), e => e.name + ": " + e.message));
+		}
+		return new Response(results.join(","));
+	}
+	`
+	policy := &BasicNetworkPolicy{
+		AllowedHosts: []string{"127.0.0.1", "*.example"},
+		DeniedHosts:  []string{"denied.example"},
+		Tenant: func(r *http.Request) string {
+			return r.URL.Query().Get("tenant")
+		},
+		MaxBytes: 15,
+		TLS:      &tls.Config{RootCAs: roots},
+	}
+	h, err := New("test.js", SCRIPT, WithNetworkPolicy(policy), WithRuntimeSetup(func(rt *goja.Runtime) error {
+		return rt.Set("ORIGIN", upstream.URL)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const denied = "SecurityError: connections to denied.example are not allowed"
+	for _, step := range []struct {
+		tenant   string
+		expected string
+	}{
+		{"a", "abcde," + denied + "," + denied},
+		{"b", "abcde," + denied + "," + denied},
+		// the limit is reached by the first fetch
+		{"a", "abcde,SecurityError: network bandwidth exceeded," + denied},
+		{"a", "SecurityError: network bandwidth exceeded,SecurityError: network bandwidth exceeded," + denied},
+	} {
+		if w := serve(t, h, "GET", "/?tenant="+step.tenant, ""); w.Code != 200 || w.Body.String() != step.expected {
+			t.Fatalf("Unexpected response: %d %q, expected %q", w.Code, w.Body.String(), step.expected)
+		}
+	}
+	if b := policy.Usage("a"); b.Sent != 10 || b.Received != 10 {
+		t.Fatalf("Unexpected usage: %+v", b)
+	}
+	policy.ResetUsage("a")
+	if b := policy.Usage("a"); b != (Bandwidth{}) {
+		t.Fatalf("Unexpected usage after reset: %+v", b)
+	}
+}
+
+type tcpDialer struct{}
+
+func (tcpDialer) Dial(ctx context.Context, u *url.URL, _ []string, _ *tls.Config) (WebSocketConn, string, error) {
+	conn, err := DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, "", err
+	}
+	conn.Close()
+	return nil, "", fmt.Errorf("connected to %s", conn.RemoteAddr())
+}
+
+func TestNetworkPolicyAddresses(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+	port := upstream.Listener.Addr().(*net.TCPAddr).Port
+
+	const SCRIPT = `
+	export default async function(req) {
+		const results = [];
+		for (const host of ["127.0.0.1", "localhost"]) {
+			results.push(await fetch("http://" + host + ":" + PORT + "/").then(res => res.text(), e => e.name + ": " + e.message));
+		}
+		results.push(await new Promise(resolve => {
+			const ws = new WebSocket("ws://127.0.0.1:" + PORT + "/");
+			ws.onerror = e => resolve(e.error.message);
+		}));
+		return new Response(results.join("\n"));
+	}
+	`
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	_, loopback6, _ := net.ParseCIDR("::1/128")
+	policy := &BasicNetworkPolicy{
+		AllowedHosts:   []string{"127.0.0.1", "localhost"},
+		DeniedNetworks: []*net.IPNet{loopback, loopback6},
+	}
+	h, err := New("test.js", SCRIPT, WithNetworkPolicy(policy), WithWebSocket(tcpDialer{}), WithRuntimeSetup(func(rt *goja.Runtime) error {
+		return rt.Set("PORT", port)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(t, h, "GET", "/", "")
+	results := strings.Split(w.Body.String(), "\n")
+	if w.Code != 200 || len(results) != 3 {
+		t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+	}
+	if results[0] != "SecurityError: connections to 127.0.0.1 (127.0.0.1) are not allowed" {
+		t.Fatalf("Unexpected result: %q", results[0])
+	}
+	if !strings.HasPrefix(results[1], "SecurityError: connections to localhost (") {
+		t.Fatalf("Unexpected result: %q", results[1])
+	}
+	if results[2] != "WebSocket connection failed: connections to 127.0.0.1 (127.0.0.1) are not allowed" {
+		t.Fatalf("Unexpected result: %q", results[2])
+	}
+
+	// without the denied networks the connections are made
+	policy.DeniedNetworks = nil
+	h, err = New("test.js", SCRIPT, WithNetworkPolicy(policy), WithWebSocket(tcpDialer{}), WithRuntimeSetup(func(rt *goja.Runtime) error {
+		return rt.Set("PORT", port)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = serve(t, h, "GET", "/", "")
+	if expected := fmt.Sprintf("ok\nok\nWebSocket connection failed: connected to 127.0.0.1:%d", port); w.Body.String() != expected {
+		t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestUsageHandler(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer upstream.Close()
+
+	const SCRIPT = `
+	export default async function(req) {
+		const data = [];
+		for (let i = 0; i < 100; i++) {
+			data.push({i});
+		}
+		const res = await fetch(ORIGIN, {method: "POST", body: "abcde"});
+		return new Response(await res.text());
+	}
+	`
+	var usage []goja.ResourceUsage
+	h, err := New("test.js", SCRIPT, WithUsageHandler(func(r *http.Request, u goja.ResourceUsage) {
+		usage = append(usage, u)
+	}), WithRuntimeSetup(func(rt *goja.Runtime) error {
+		return rt.Set("ORIGIN", upstream.URL)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if w := serve(t, h, "GET", "/", ""); w.Code != 200 || w.Body.String() != "abcde" {
+			t.Fatalf("Unexpected response: %d %q", w.Code, w.Body.String())
+		}
+	}
+	if len(usage) != 2 {
+		t.Fatalf("Unexpected number of reports: %d", len(usage))
+	}
+	for _, u := range usage {
+		if u.NetworkBytesSent != 5 || u.NetworkBytesReceived != 5 || u.HostCalls == 0 || u.Instructions == 0 ||
+			u.BytesAllocated < 100*64 {
+			t.Fatalf("Unexpected usage: %+v", u)
+		}
+	}
+	// the usage is reported per request rather than cumulatively
+	if usage[1].Instructions > usage[0].Instructions || usage[1].HostCalls != usage[0].HostCalls {
+		t.Fatalf("Unexpected usage: %+v", usage)
+	}
+}
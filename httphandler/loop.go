@@ -0,0 +1,291 @@
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/file"
+)
+
+var errNoResponse = errors.New("handler did not produce a response")
+
+// ErrLoopStopped is the error the promises of the fetches which are still pending when the event loop of a request
+// is stopped are rejected with (wrapped into a GoError). See StopMode.
+var ErrLoopStopped = errors.New("the event loop has been stopped")
+
+// StopMode defines what happens to the asynchronous work (timers and fetches) which is still pending when the event
+// loop of a request is stopped, i.e. after the response has been sent and the waitUntil promises have settled, or
+// when the handler has failed. If the request has been cancelled or has timed out the Runtime is discarded along
+// with any pending work regardless of the mode.
+type StopMode int
+
+const (
+	// StopImmediate cancels the pending timers and rejects the promises of the pending fetches with ErrLoopStopped.
+	// This is the default.
+	StopImmediate StopMode = iota
+	// StopDrain keeps running the loop after the response has been sent until there is no pending work or the drain
+	// timeout expires. The work which is still pending at that point is stopped as with StopImmediate.
+	StopDrain
+	// StopFreeze rejects the pending fetches as StopImmediate, but saves the pending timers along with the remaining
+	// delays. They are resumed by the loop of the next request served by the same Runtime and keep their IDs.
+	StopFreeze
+)
+
+// eventLoop runs the asynchronous part of a single request. Jobs may be posted from any goroutine, but they are
+// only executed on the goroutine that serves the request. Once the request is done the loop is closed and any
+// jobs posted afterwards (e.g. by an outstanding fetch) are silently dropped.
+type eventLoop struct {
+	ctx context.Context
+
+	mu     sync.Mutex
+	jobs   []func() error
+	closed bool
+	wakeup chan struct{}
+
+	// the fields below are only accessed on the loop goroutine
+	pending int
+	timers  map[int64]*timer
+	timerID int64
+	async   map[int64]func()
+	asyncID int64
+
+	// longTask is called for the tasks running longer than longTaskThreshold, see WithLongTaskHandler
+	longTaskThreshold time.Duration
+	longTask          func(task LongTask)
+}
+
+// LongTask describes a task which has blocked the event loop, see WithLongTaskHandler.
+type LongTask struct {
+	// Kind is "handler", "timer", "fetch", "cache" or "websocket".
+	Kind string
+	// Location is the position of the function responsible for the task: the request handler (or the fetch event
+	// listener), the timer callback, the call to fetch() (or to a cache method) whose completion has run the task, or
+	// the construction of the WebSocket whose event has run the task.
+	// It is empty if the function is not defined in JavaScript.
+	Location file.Position
+	Duration time.Duration
+}
+
+type timer struct {
+	id       int64
+	fn       goja.Callable
+	pos      file.Position
+	args     []goja.Value
+	delay    time.Duration
+	interval bool
+	canceled bool
+	due      time.Time
+	t        *time.Timer
+}
+
+// frozenTimer is a timer saved by close in StopFreeze mode.
+type frozenTimer struct {
+	*timer
+	remaining time.Duration
+}
+
+func newEventLoop(ctx context.Context) *eventLoop {
+	return &eventLoop{
+		ctx:    ctx,
+		wakeup: make(chan struct{}, 1),
+		timers: make(map[int64]*timer),
+		async:  make(map[int64]func()),
+	}
+}
+
+// enqueue posts a job to the loop. It is goroutine-safe.
+func (l *eventLoop) enqueue(job func() error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.jobs = append(l.jobs, job)
+	l.mu.Unlock()
+	select {
+	case l.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// startAsync registers an outstanding asynchronous operation. The returned function must be called exactly once
+// (from any goroutine) with the job completing the operation. kind and pos describe the job in LongTask. cancel is
+// called instead of the job if the loop is stopped before the job has run.
+func (l *eventLoop) startAsync(kind string, pos file.Position, cancel func()) func(job func()) {
+	l.pending++
+	l.asyncID++
+	id := l.asyncID
+	l.async[id] = cancel
+	return func(job func()) {
+		l.enqueue(func() error {
+			l.pending--
+			delete(l.async, id)
+			return l.runTask(kind, pos, func() error {
+				job()
+				return nil
+			})
+		})
+	}
+}
+
+// runTask runs the task and reports it if it took longer than the long task threshold.
+func (l *eventLoop) runTask(kind string, pos file.Position, task func() error) error {
+	if l.longTask == nil {
+		return task()
+	}
+	start := time.Now()
+	err := task()
+	if d := time.Since(start); d > l.longTaskThreshold {
+		l.longTask(LongTask{
+			Kind:     kind,
+			Location: pos,
+			Duration: d,
+		})
+	}
+	return err
+}
+
+// run executes jobs until done() returns true, ctx (which must be derived from the loop's context) is done, a job
+// fails, or there is nothing left that could make done() return true.
+func (l *eventLoop) run(ctx context.Context, done func() bool) error {
+	for {
+		l.mu.Lock()
+		jobs := l.jobs
+		l.jobs = nil
+		l.mu.Unlock()
+		for _, job := range jobs {
+			if err := job(); err != nil {
+				return err
+			}
+		}
+		if done() {
+			return nil
+		}
+		if len(jobs) == 0 {
+			if l.pending == 0 {
+				return errNoResponse
+			}
+			select {
+			case <-l.wakeup:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// close stops the loop. The pending asynchronous operations are cancelled unless the context is done (in which case
+// the Runtime is about to be discarded, so no JavaScript code must run). The pending timers are cancelled as well,
+// if freeze is true they are returned so that they can be resumed by another loop.
+func (l *eventLoop) close(freeze bool) (frozen []frozenTimer) {
+	l.mu.Lock()
+	l.closed = true
+	l.jobs = nil
+	l.mu.Unlock()
+	// cancelling may run JavaScript code which can start new operations
+	for len(l.async) > 0 && l.ctx.Err() == nil {
+		async := l.async
+		l.async = make(map[int64]func())
+		for _, cancel := range async {
+			cancel()
+		}
+	}
+	l.async = nil
+	now := time.Now()
+	for _, t := range l.timers {
+		t.canceled = true
+		t.t.Stop()
+		if freeze {
+			remaining := t.due.Sub(now)
+			if remaining < 0 {
+				remaining = 0
+			}
+			frozen = append(frozen, frozenTimer{
+				timer: &timer{
+					id:       t.id,
+					fn:       t.fn,
+					pos:      t.pos,
+					args:     t.args,
+					delay:    t.delay,
+					interval: t.interval,
+				},
+				remaining: remaining,
+			})
+		}
+	}
+	l.timers = nil
+	sort.Slice(frozen, func(i, j int) bool {
+		return frozen[i].id < frozen[j].id
+	})
+	return
+}
+
+// resume re-arms the timers returned by close.
+func (l *eventLoop) resume(timers []frozenTimer) {
+	for _, t := range timers {
+		if t.id > l.timerID {
+			l.timerID = t.id
+		}
+		l.armTimer(t.timer, t.remaining)
+	}
+}
+
+func (l *eventLoop) addTimer(fn goja.Callable, pos file.Position, delay goja.Value, args []goja.Value, interval bool) int64 {
+	d := delay.ToFloat()
+	if math.IsNaN(d) || d < 0 {
+		d = 0
+	}
+	l.timerID++
+	t := &timer{
+		id:       l.timerID,
+		fn:       fn,
+		pos:      pos,
+		args:     args,
+		delay:    time.Duration(math.Min(d, float64(math.MaxInt64/time.Millisecond))) * time.Millisecond,
+		interval: interval,
+	}
+	l.armTimer(t, t.delay)
+	return t.id
+}
+
+func (l *eventLoop) armTimer(t *timer, d time.Duration) {
+	l.timers[t.id] = t
+	l.pending++
+	t.due = time.Now().Add(d)
+	t.t = time.AfterFunc(d, func() {
+		l.enqueue(func() error {
+			return l.fire(t)
+		})
+	})
+}
+
+func (l *eventLoop) fire(t *timer) error {
+	if t.canceled {
+		return nil
+	}
+	if t.interval {
+		t.due = time.Now().Add(t.delay)
+		t.t.Reset(t.delay)
+	} else {
+		delete(l.timers, t.id)
+		l.pending--
+	}
+	return l.runTask("timer", t.pos, func() error {
+		_, err := t.fn(nil, t.args...)
+		return err
+	})
+}
+
+func (l *eventLoop) clearTimer(id int64) {
+	if t, exists := l.timers[id]; exists {
+		t.canceled = true
+		t.t.Stop()
+		delete(l.timers, id)
+		l.pending--
+	}
+}
@@ -0,0 +1,315 @@
+package httphandler
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+)
+
+// ErrBandwidthExceeded is returned by BasicNetworkPolicy.Allow once a tenant has transferred MaxBytes.
+var ErrBandwidthExceeded = errors.New("network bandwidth exceeded")
+
+// NetworkPolicy is consulted by every network built-in (fetch, including the requests made to revalidate cached
+// responses, and WebSocket), see WithNetworkPolicy. The methods are called from multiple goroutines.
+type NetworkPolicy interface {
+	// Allow is called before a connection to u is made on behalf of the script serving r. kind is "fetch" or
+	// "websocket". It is also called for each redirect followed by fetch. If it returns an error, the connection is
+	// not made: WebSocket throws the error and fetch rejects with it, as a SecurityError.
+	Allow(r *http.Request, kind string, u *url.URL) error
+	// AllowIP is called with each address the host of a connection resolves to, right before connecting to it. The
+	// connection is made to the checked address, so the host cannot be resolved again to a different one. If it
+	// denies all the addresses, the connection fails the same way as when Allow denies it. WebSocket connections
+	// are only checked if the WebSocketDialer connects with DialContext.
+	AllowIP(r *http.Request, kind string, host string, ip net.IP) error
+	// TLSConfig returns the TLS configuration for the connections to u, nil means the default one.
+	TLSConfig(u *url.URL) *tls.Config
+	// Transferred is called once data has been exchanged with u: sent and received are the sizes of the request and
+	// the response bodies (for fetch) or of the messages (for WebSocket).
+	Transferred(r *http.Request, kind string, u *url.URL, sent, received int64)
+}
+
+// Bandwidth is the amount of data transferred by a tenant, see BasicNetworkPolicy.
+type Bandwidth struct {
+	Sent     int64
+	Received int64
+}
+
+// BasicNetworkPolicy is a NetworkPolicy which allows the connections by host name and counts the data transferred
+// by each tenant. The zero value allows all the connections and accounts them to a single tenant. The exported fields
+// must not be modified once the policy is in use.
+type BasicNetworkPolicy struct {
+	// AllowedHosts are the hosts the scripts may connect to, an empty list allows all of them. A name starting with
+	// "*." matches the subdomains of the rest of the name.
+	AllowedHosts []string
+	// DeniedHosts are the hosts the scripts may not connect to, they take precedence over AllowedHosts.
+	DeniedHosts []string
+	// DeniedNetworks are the IP networks the scripts may not connect to, whatever the name of the host, e.g. the
+	// loopback and the link-local (cloud metadata) ones.
+	DeniedNetworks []*net.IPNet
+	// Tenant returns the tenant the traffic of the request is accounted to. By default the tenant is "".
+	Tenant func(r *http.Request) string
+	// MaxBytes is the amount of data (sent and received) after which the connections of a tenant are denied with
+	// ErrBandwidthExceeded. Zero means no limit.
+	MaxBytes int64
+	// TLS is returned by TLSConfig.
+	TLS *tls.Config
+
+	mu    sync.Mutex
+	usage map[string]Bandwidth
+}
+
+func (p *BasicNetworkPolicy) tenant(r *http.Request) string {
+	if p.Tenant == nil || r == nil {
+		return ""
+	}
+	return p.Tenant(r)
+}
+
+// Allow implements NetworkPolicy.
+func (p *BasicNetworkPolicy) Allow(r *http.Request, _ string, u *url.URL) error {
+	host := strings.ToLower(u.Hostname())
+	if matchHost(p.DeniedHosts, host) || len(p.AllowedHosts) > 0 && !matchHost(p.AllowedHosts, host) {
+		return fmt.Errorf("connections to %s are not allowed", host)
+	}
+	if p.MaxBytes > 0 {
+		if b := p.Usage(p.tenant(r)); b.Sent+b.Received >= p.MaxBytes {
+			return ErrBandwidthExceeded
+		}
+	}
+	return nil
+}
+
+// AllowIP implements NetworkPolicy.
+func (p *BasicNetworkPolicy) AllowIP(_ *http.Request, _ string, host string, ip net.IP) error {
+	for _, n := range p.DeniedNetworks {
+		if n.Contains(ip) {
+			return fmt.Errorf("connections to %s (%s) are not allowed", host, ip)
+		}
+	}
+	return nil
+}
+
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if strings.HasPrefix(pattern, "*.") {
+			if strings.HasSuffix(host, pattern[1:]) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// TLSConfig implements NetworkPolicy.
+func (p *BasicNetworkPolicy) TLSConfig(*url.URL) *tls.Config {
+	return p.TLS
+}
+
+// Transferred implements NetworkPolicy.
+func (p *BasicNetworkPolicy) Transferred(r *http.Request, _ string, _ *url.URL, sent, received int64) {
+	tenant := p.tenant(r)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.usage == nil {
+		p.usage = make(map[string]Bandwidth)
+	}
+	b := p.usage[tenant]
+	b.Sent += sent
+	b.Received += received
+	p.usage[tenant] = b
+}
+
+// Usage returns the amount of data transferred by the tenant.
+func (p *BasicNetworkPolicy) Usage(tenant string) Bandwidth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.usage[tenant]
+}
+
+// ResetUsage resets the amount of data transferred by the tenant, e.g. at the start of a new billing period.
+func (p *BasicNetworkPolicy) ResetUsage(tenant string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.usage, tenant)
+}
+
+type policyRequestKey struct{}
+
+// policyClient returns a copy of the client which applies the policy to the redirects and, if the transport of the
+// client is an *http.Transport, to the resolved addresses and uses its TLS configuration. The request being served
+// must be stored in the context of the requests under policyRequestKey.
+func policyClient(c *http.Client, p NetworkPolicy) *http.Client {
+	client := *c
+	checkRedirect := c.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		r, _ := req.Context().Value(policyRequestKey{}).(*http.Request)
+		if err := p.Allow(r, "fetch", req.URL); err != nil {
+			return &deniedError{err}
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if t, ok := transport.(*http.Transport); ok {
+		base := t.Clone()
+		dial := base.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		base.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			r, _ := ctx.Value(policyRequestKey{}).(*http.Request)
+			return dialAllowed(ctx, p, r, "fetch", dial, network, addr)
+		}
+		client.Transport = &policyTransport{
+			base:    base,
+			policy:  p,
+			configs: make(map[*tls.Config]*http.Transport),
+		}
+	}
+	return &client
+}
+
+// policyTransport uses a separate clone of the base transport for each TLS configuration returned by the policy.
+type policyTransport struct {
+	base   *http.Transport
+	policy NetworkPolicy
+
+	mu      sync.Mutex
+	configs map[*tls.Config]*http.Transport
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	config := t.policy.TLSConfig(req.URL)
+	if config == nil {
+		return t.base.RoundTrip(req)
+	}
+	t.mu.Lock()
+	transport := t.configs[config]
+	if transport == nil {
+		transport = t.base.Clone()
+		transport.TLSClientConfig = config
+		t.configs[config] = transport
+	}
+	t.mu.Unlock()
+	return transport.RoundTrip(req)
+}
+
+// dialAllowed resolves the host of addr and connects to the first of its addresses allowed by the policy.
+func dialAllowed(ctx context.Context, p NetworkPolicy, r *http.Request, kind string,
+	dial func(ctx context.Context, network, addr string) (net.Conn, error), network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var denied, dialErr error
+	for _, ip := range ips {
+		if err := p.AllowIP(r, kind, host, ip.IP); err != nil {
+			if denied == nil {
+				denied = err
+			}
+			continue
+		}
+		conn, err := dial(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		dialErr = err
+	}
+	if dialErr != nil {
+		return nil, dialErr
+	}
+	if denied == nil {
+		denied = fmt.Errorf("no addresses found for %s", host)
+	}
+	return nil, &deniedError{denied}
+}
+
+type policyDialKey struct{}
+
+// policyDial is stored in the context passed to WebSocketDialer.Dial under policyDialKey.
+type policyDial struct {
+	policy  NetworkPolicy
+	request *http.Request
+}
+
+// DialContext connects to the address like net.Dialer.DialContext, consulting the NetworkPolicy of the Handler (see
+// NetworkPolicy.AllowIP) if ctx is the one passed to WebSocketDialer.Dial. WebSocketDialer implementations should use
+// it to make their connections, e.g. as the NetDialContext of a gorilla/websocket Dialer.
+func DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	var d net.Dialer
+	if pd, ok := ctx.Value(policyDialKey{}).(*policyDial); ok {
+		return dialAllowed(ctx, pd.policy, pd.request, "websocket", d.DialContext, network, addr)
+	}
+	return d.DialContext(ctx, network, addr)
+}
+
+// deniedError is returned by the CheckRedirect function and the dialer of policyClient when the policy denies a
+// redirect or all the addresses of a host.
+type deniedError struct {
+	err error
+}
+
+func (e *deniedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *deniedError) Unwrap() error {
+	return e.err
+}
+
+// allow consults the policy (if any) before a connection to u is made and throws a SecurityError if it is denied.
+func (i *instance) allow(kind string, u *url.URL) {
+	if p := i.h.policy; p != nil {
+		if err := p.Allow(i.request, kind, u); err != nil {
+			panic(i.securityError(err))
+		}
+	}
+}
+
+func (i *instance) securityError(err error) *goja.Object {
+	e := i.rt.NewGoError(err)
+	_ = e.Set("name", "SecurityError")
+	return e
+}
+
+// transferred reports the data exchanged with u to the policy (if any) and to the resource accounting of the
+// Runtime (see WithUsageHandler).
+func (i *instance) transferred(r *http.Request, kind string, u *url.URL, sent, received int64) {
+	if sent == 0 && received == 0 {
+		return
+	}
+	if p := i.h.policy; p != nil {
+		p.Transferred(r, kind, u, sent, received)
+	}
+	if i.h.usage != nil {
+		i.rt.AddNetworkUsage(sent, received)
+	}
+}
+
+// withPolicyRequest stores the request being served in ctx for policyClient.
+func withPolicyRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, policyRequestKey{}, r)
+}
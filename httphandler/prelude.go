@@ -0,0 +1,715 @@
+package httphandler
+
+import "github.com/dop251/goja"
+
+// preludeSrc implements the Fetch API classes in JavaScript. It evaluates to a function which receives the native
+// host bindings and returns the values to be installed into the global object along with the internal helpers used
+// by the Go side to convert requests and responses.
+const preludeSrc = `(function(host) {
+"use strict";
+
+const kMap = Symbol("map");
+const kBody = Symbol("body");
+const kUsed = Symbol("bodyUsed");
+const kURL = Symbol("url");
+const kMethod = Symbol("method");
+const kHeaders = Symbol("headers");
+const kStatus = Symbol("status");
+const kStatusText = Symbol("statusText");
+
+function normName(name) {
+	name = String(name);
+	if (!/^[!#$%&'*+\-.^_\x60|~0-9A-Za-z]+$/.test(name)) {
+		throw new TypeError("Invalid header name: " + name);
+	}
+	return name.toLowerCase();
+}
+
+function normValue(value) {
+	return String(value).replace(/^[\t\n\r ]+|[\t\n\r ]+$/g, "");
+}
+
+class Headers {
+	constructor(init) {
+		this[kMap] = new Map();
+		if (init === undefined || init === null) {
+			return;
+		}
+		if (typeof init !== "object") {
+			throw new TypeError("Invalid headers init");
+		}
+		if (typeof init[Symbol.iterator] === "function") {
+			for (const pair of init) {
+				const arr = Array.from(pair);
+				if (arr.length !== 2) {
+					throw new TypeError("Header pairs must contain exactly two items");
+				}
+				this.append(arr[0], arr[1]);
+			}
+		} else {
+			for (const name of Object.keys(init)) {
+				this.append(name, init[name]);
+			}
+		}
+	}
+
+	append(name, value) {
+		name = normName(name);
+		value = normValue(value);
+		const cur = this[kMap].get(name);
+		this[kMap].set(name, cur === undefined ? value : cur + ", " + value);
+	}
+
+	set(name, value) {
+		this[kMap].set(normName(name), normValue(value));
+	}
+
+	get(name) {
+		const value = this[kMap].get(normName(name));
+		return value === undefined ? null : value;
+	}
+
+	has(name) {
+		return this[kMap].has(normName(name));
+	}
+
+	delete(name) {
+		this[kMap].delete(normName(name));
+	}
+
+	forEach(callback, thisArg) {
+		for (const [name, value] of this) {
+			callback.call(thisArg, value, name, this);
+		}
+	}
+
+	entries() {
+		return Array.from(this[kMap].entries()).sort((a, b) => a[0] < b[0] ? -1 : a[0] > b[0] ? 1 : 0)[Symbol.iterator]();
+	}
+
+	keys() {
+		return Array.from(this.entries(), e => e[0])[Symbol.iterator]();
+	}
+
+	values() {
+		return Array.from(this.entries(), e => e[1])[Symbol.iterator]();
+	}
+
+	[Symbol.iterator]() {
+		return this.entries();
+	}
+
+	get [Symbol.toStringTag]() {
+		return "Headers";
+	}
+}
+
+function toBody(body) {
+	if (body === undefined || body === null) {
+		return null;
+	}
+	if (typeof body === "string" || body instanceof ArrayBuffer) {
+		return body;
+	}
+	if (ArrayBuffer.isView(body)) {
+		return body.buffer.slice(body.byteOffset, body.byteOffset + body.byteLength);
+	}
+	return String(body);
+}
+
+function consume(obj) {
+	if (obj[kUsed]) {
+		throw new TypeError("Body has already been consumed");
+	}
+	obj[kUsed] = true;
+	return obj[kBody];
+}
+
+class Body {
+	get bodyUsed() {
+		return this[kUsed];
+	}
+
+	text() {
+		return Promise.resolve(this).then(obj => {
+			const body = consume(obj);
+			if (body === null) {
+				return "";
+			}
+			return typeof body === "string" ? body : host.decode(body);
+		});
+	}
+
+	arrayBuffer() {
+		return Promise.resolve(this).then(obj => {
+			const body = consume(obj);
+			if (body === null) {
+				return new ArrayBuffer(0);
+			}
+			return typeof body === "string" ? host.encode(body) : body.slice(0);
+		});
+	}
+
+	json() {
+		return this.text().then(JSON.parse);
+	}
+}
+
+class Request extends Body {
+	constructor(input, init) {
+		super();
+		if (init === undefined || init === null) {
+			init = {};
+		}
+		let url, method = "GET", headers, body = null;
+		if (input instanceof Request) {
+			url = input.url;
+			method = input.method;
+			headers = input.headers;
+			body = consume(input);
+		} else {
+			url = String(input);
+		}
+		if (init.method !== undefined) {
+			method = String(init.method).toUpperCase();
+		}
+		if (init.headers !== undefined) {
+			headers = init.headers;
+		}
+		if (init.body !== undefined) {
+			body = toBody(init.body);
+		}
+		if (body !== null && (method === "GET" || method === "HEAD")) {
+			throw new TypeError("Request with GET/HEAD method cannot have body");
+		}
+		this[kURL] = url;
+		this[kMethod] = method;
+		this[kHeaders] = new Headers(headers);
+		this[kBody] = body;
+		this[kUsed] = false;
+	}
+
+	get url() {
+		return this[kURL];
+	}
+
+	get method() {
+		return this[kMethod];
+	}
+
+	get headers() {
+		return this[kHeaders];
+	}
+
+	clone() {
+		if (this[kUsed]) {
+			throw new TypeError("Body has already been consumed");
+		}
+		return new Request(this[kURL], {method: this[kMethod], headers: this[kHeaders], body: this[kBody]});
+	}
+
+	get [Symbol.toStringTag]() {
+		return "Request";
+	}
+}
+
+function makeResponse(body, status, statusText, headers) {
+	const res = Object.create(Response.prototype);
+	res[kStatus] = status;
+	res[kStatusText] = statusText;
+	res[kHeaders] = new Headers(headers);
+	res[kBody] = toBody(body);
+	res[kUsed] = false;
+	return res;
+}
+
+class Response extends Body {
+	constructor(body, init) {
+		super();
+		if (init === undefined || init === null) {
+			init = {};
+		}
+		const status = init.status === undefined ? 200 : Number(init.status);
+		if (!Number.isInteger(status) || status < 200 || status > 599) {
+			throw new RangeError("Invalid response status: " + init.status);
+		}
+		this[kStatus] = status;
+		this[kStatusText] = init.statusText === undefined ? "" : String(init.statusText);
+		this[kHeaders] = new Headers(init.headers);
+		this[kBody] = toBody(body);
+		this[kUsed] = false;
+		if (typeof this[kBody] === "string" && !this[kHeaders].has("content-type")) {
+			this[kHeaders].set("content-type", "text/plain;charset=UTF-8");
+		}
+	}
+
+	get status() {
+		return this[kStatus];
+	}
+
+	get ok() {
+		return this[kStatus] >= 200 && this[kStatus] < 300;
+	}
+
+	get statusText() {
+		return this[kStatusText];
+	}
+
+	get headers() {
+		return this[kHeaders];
+	}
+
+	clone() {
+		if (this[kUsed]) {
+			throw new TypeError("Body has already been consumed");
+		}
+		return makeResponse(this[kBody], this[kStatus], this[kStatusText], this[kHeaders]);
+	}
+
+	static json(data, init) {
+		const res = new Response(JSON.stringify(data), init);
+		res.headers.set("content-type", "application/json");
+		return res;
+	}
+
+	static redirect(url, status) {
+		if (status === undefined) {
+			status = 302;
+		}
+		if ([301, 302, 303, 307, 308].indexOf(status) === -1) {
+			throw new RangeError("Invalid redirect status: " + status);
+		}
+		return makeResponse(null, status, "", {location: String(url)});
+	}
+
+	get [Symbol.toStringTag]() {
+		return "Response";
+	}
+}
+
+function fetch(input, init) {
+	try {
+		const req = new Request(input, init);
+		return host.fetch(req.method, req.url, Array.from(req.headers), consume(req))
+			.then(res => makeResponse(res.body, res.status, res.statusText, res.headers));
+	} catch (e) {
+		return Promise.reject(e);
+	}
+}
+
+const kName = Symbol("name");
+
+function parseCacheControl(value) {
+	const directives = {};
+	if (value !== null) {
+		for (const part of value.split(",")) {
+			const m = /^\s*([A-Za-z-]+)\s*(?:=\s*"?(\d+)"?)?\s*$/.exec(part);
+			if (m) {
+				directives[m[1].toLowerCase()] = m[2] === undefined ? true : Number(m[2]);
+			}
+		}
+	}
+	return directives;
+}
+
+function toRequest(request) {
+	return request instanceof Request ? request : new Request(request);
+}
+
+function cacheSeconds(value, name) {
+	const n = Number(value);
+	if (!(n >= 0) || n === Infinity) {
+		throw new TypeError("Invalid " + name + ": " + value);
+	}
+	return n;
+}
+
+function revalidate(cache, req, entry) {
+	const name = cache[kName], url = req.url;
+	if (!host.cacheRevalidate(name, url)) {
+		return;
+	}
+	host.waitUntil(fetch(url, {headers: req.headers})
+		.then(res => {
+			if (res.ok) {
+				return cache.put(url, res, {ttl: entry.ttl, staleWhileRevalidate: entry.staleWhileRevalidate});
+			}
+		})
+		.then(() => host.cacheRevalidated(name, url), e => host.cacheRevalidated(name, url, e)));
+}
+
+class Cache {
+	constructor() {
+		throw new TypeError("Illegal constructor");
+	}
+
+	match(request) {
+		try {
+			const req = toRequest(request);
+			if (req.method !== "GET") {
+				return Promise.resolve(undefined);
+			}
+			return host.cacheMatch(this[kName], req.url).then(entry => {
+				if (entry === null) {
+					return undefined;
+				}
+				if (entry.stale) {
+					revalidate(this, req, entry);
+				}
+				const res = makeResponse(entry.body, entry.status, entry.statusText, entry.headers);
+				res[kHeaders].set("age", String(entry.age));
+				return res;
+			});
+		} catch (e) {
+			return Promise.reject(e);
+		}
+	}
+
+	put(request, response, options) {
+		try {
+			const req = toRequest(request);
+			if (req.method !== "GET") {
+				throw new TypeError("Only GET requests can be cached");
+			}
+			if (!(response instanceof Response)) {
+				throw new TypeError("The response must be a Response");
+			}
+			if (response.status === 206) {
+				throw new TypeError("Partial responses cannot be cached");
+			}
+			const cc = parseCacheControl(response.headers.get("cache-control"));
+			let ttl = typeof cc["s-maxage"] === "number" ? cc["s-maxage"] : cc["max-age"];
+			let swr = cc["stale-while-revalidate"];
+			if (typeof ttl !== "number") {
+				ttl = undefined;
+			}
+			if (typeof swr !== "number") {
+				swr = 0;
+			}
+			let store = !cc["no-store"] && !cc["no-cache"] && !cc["private"];
+			if (options !== undefined && options !== null) {
+				if (options.ttl !== undefined) {
+					ttl = cacheSeconds(options.ttl, "ttl");
+					store = true;
+				}
+				if (options.staleWhileRevalidate !== undefined) {
+					swr = cacheSeconds(options.staleWhileRevalidate, "staleWhileRevalidate");
+				}
+			}
+			const body = consume(response);
+			if (!store || ttl === 0) {
+				return Promise.resolve(undefined);
+			}
+			return host.cachePut(this[kName], req.url, response.status, response.statusText,
+				Array.from(response.headers), body, ttl === undefined ? 0 : ttl, swr);
+		} catch (e) {
+			return Promise.reject(e);
+		}
+	}
+
+	delete(request) {
+		try {
+			const req = toRequest(request);
+			if (req.method !== "GET") {
+				return Promise.resolve(false);
+			}
+			return host.cacheDelete(this[kName], req.url);
+		} catch (e) {
+			return Promise.reject(e);
+		}
+	}
+
+	get [Symbol.toStringTag]() {
+		return "Cache";
+	}
+}
+
+function makeCache(name) {
+	const cache = Object.create(Cache.prototype);
+	cache[kName] = name;
+	return cache;
+}
+
+const caches = {
+	default: makeCache("default"),
+	open(name) {
+		return Promise.resolve(makeCache(String(name)));
+	},
+};
+
+const kSocket = Symbol("socket");
+const kReadyState = Symbol("readyState");
+const kProtocol = Symbol("protocol");
+const kListeners = Symbol("listeners");
+const kIterators = Symbol("iterators");
+
+const CONNECTING = 0, OPEN = 1, CLOSING = 2, CLOSED = 3;
+
+function domError(name, message) {
+	const e = new Error(message);
+	e.name = name;
+	return e;
+}
+
+function fireEvent(ws, event) {
+	event.target = ws;
+	const listeners = [];
+	const handler = ws["on" + event.type];
+	if (typeof handler === "function") {
+		listeners.push(handler);
+	}
+	const added = ws[kListeners].get(event.type);
+	if (added !== undefined) {
+		listeners.push(...added);
+	}
+	for (const listener of listeners) {
+		try {
+			if (typeof listener === "function") {
+				listener.call(ws, event);
+			} else {
+				listener.handleEvent(event);
+			}
+		} catch (e) {
+			host.reportError(e);
+		}
+	}
+}
+
+function wsDispatch(ws, type, a, b, c, d) {
+	switch (type) {
+	case "open":
+		ws[kReadyState] = OPEN;
+		ws[kProtocol] = a;
+		fireEvent(ws, {type: "open"});
+		break;
+	case "message":
+		if (ws[kReadyState] !== OPEN) {
+			return;
+		}
+		for (const it of ws[kIterators]) {
+			iterPush(it, a);
+		}
+		fireEvent(ws, {type: "message", data: a});
+		break;
+	case "close": {
+		ws[kReadyState] = CLOSED;
+		const error = d === undefined ? undefined : domError("NetworkError", "WebSocket connection failed: " + d);
+		for (const it of ws[kIterators]) {
+			iterEnd(it, error);
+		}
+		ws[kIterators] = [];
+		if (error !== undefined) {
+			fireEvent(ws, {type: "error", message: error.message, error: error});
+		}
+		fireEvent(ws, {type: "close", code: a, reason: b, wasClean: c});
+		break;
+	}
+	}
+}
+
+function iterPush(it, value) {
+	if (it.waiting.length > 0) {
+		it.waiting.shift().resolve({value: value, done: false});
+	} else {
+		it.queue.push(value);
+	}
+}
+
+function iterEnd(it, error) {
+	it.done = true;
+	it.error = error;
+	for (const w of it.waiting.splice(0)) {
+		if (error !== undefined) {
+			it.error = undefined;
+			w.reject(error);
+		} else {
+			w.resolve({value: undefined, done: true});
+		}
+	}
+}
+
+class WebSocket {
+	constructor(url, protocols) {
+		url = String(url);
+		if (!/^wss?:\/\/[^\/?#]/i.test(url) || url.indexOf("#") !== -1) {
+			throw domError("SyntaxError", "Invalid WebSocket URL: " + url);
+		}
+		if (protocols === undefined) {
+			protocols = [];
+		} else if (typeof protocols === "string") {
+			protocols = [protocols];
+		} else {
+			protocols = Array.from(protocols, String);
+		}
+		if (new Set(protocols).size !== protocols.length) {
+			throw domError("SyntaxError", "Duplicate WebSocket protocols");
+		}
+		this[kURL] = url;
+		this[kReadyState] = CONNECTING;
+		this[kProtocol] = "";
+		this[kListeners] = new Map();
+		this[kIterators] = [];
+		this.onopen = null;
+		this.onmessage = null;
+		this.onerror = null;
+		this.onclose = null;
+		this[kSocket] = host.wsConnect(url, protocols, (type, a, b, c, d) => wsDispatch(this, type, a, b, c, d));
+	}
+
+	get url() {
+		return this[kURL];
+	}
+
+	get readyState() {
+		return this[kReadyState];
+	}
+
+	get protocol() {
+		return this[kProtocol];
+	}
+
+	get extensions() {
+		return "";
+	}
+
+	get binaryType() {
+		return "arraybuffer";
+	}
+
+	set binaryType(value) {
+		if (value !== "arraybuffer") {
+			throw domError("NotSupportedError", "Unsupported binaryType: " + value);
+		}
+	}
+
+	send(data) {
+		if (this[kReadyState] === CONNECTING) {
+			throw domError("InvalidStateError", "The WebSocket is not open yet");
+		}
+		if (this[kReadyState] !== OPEN) {
+			return;
+		}
+		data = toBody(data);
+		this[kSocket].send(data === null ? "null" : data);
+	}
+
+	close(code, reason) {
+		if (code !== undefined) {
+			code = Number(code);
+			if (code !== 1000 && !(Number.isInteger(code) && code >= 3000 && code <= 4999)) {
+				throw domError("InvalidAccessError", "Invalid close code: " + code);
+			}
+		}
+		reason = reason === undefined ? "" : String(reason);
+		if (host.encode(reason).byteLength > 123) {
+			throw domError("SyntaxError", "The close reason is too long");
+		}
+		if (this[kReadyState] === CLOSING || this[kReadyState] === CLOSED) {
+			return;
+		}
+		this[kReadyState] = CLOSING;
+		this[kSocket].close(code === undefined ? 1005 : code, reason);
+	}
+
+	addEventListener(type, listener) {
+		if (listener === null || listener === undefined) {
+			return;
+		}
+		type = String(type);
+		let listeners = this[kListeners].get(type);
+		if (listeners === undefined) {
+			listeners = [];
+			this[kListeners].set(type, listeners);
+		}
+		if (listeners.indexOf(listener) === -1) {
+			listeners.push(listener);
+		}
+	}
+
+	removeEventListener(type, listener) {
+		const listeners = this[kListeners].get(String(type));
+		if (listeners !== undefined) {
+			const idx = listeners.indexOf(listener);
+			if (idx !== -1) {
+				listeners.splice(idx, 1);
+			}
+		}
+	}
+
+	[Symbol.asyncIterator]() {
+		const it = {queue: [], waiting: [], done: this[kReadyState] === CLOSED, error: undefined};
+		if (!it.done) {
+			this[kIterators].push(it);
+		}
+		const ws = this;
+		return {
+			next() {
+				if (it.queue.length > 0) {
+					return Promise.resolve({value: it.queue.shift(), done: false});
+				}
+				if (it.done) {
+					const error = it.error;
+					it.error = undefined;
+					return error === undefined ? Promise.resolve({value: undefined, done: true}) : Promise.reject(error);
+				}
+				return new Promise((resolve, reject) => {
+					it.waiting.push({resolve: resolve, reject: reject});
+				});
+			},
+			return() {
+				const idx = ws[kIterators].indexOf(it);
+				if (idx !== -1) {
+					ws[kIterators].splice(idx, 1);
+				}
+				it.queue = [];
+				iterEnd(it, undefined);
+				return Promise.resolve({value: undefined, done: true});
+			},
+			[Symbol.asyncIterator]() {
+				return this;
+			},
+		};
+	}
+
+	get [Symbol.toStringTag]() {
+		return "WebSocket";
+	}
+}
+
+for (const [name, value] of [["CONNECTING", CONNECTING], ["OPEN", OPEN], ["CLOSING", CLOSING], ["CLOSED", CLOSED]]) {
+	Object.defineProperty(WebSocket, name, {value: value, enumerable: true});
+	Object.defineProperty(WebSocket.prototype, name, {value: value, enumerable: true});
+}
+
+function newRequest(method, url, headers, body) {
+	const req = new Request(url, {method: method, headers: headers});
+	req[kBody] = body;
+	return req;
+}
+
+function unwrapResponse(res) {
+	if (!(res instanceof Response)) {
+		throw new TypeError("Handler must return a Response, got " + (res === null ? "null" : typeof res));
+	}
+	return {
+		status: res[kStatus],
+		headers: Array.from(res[kHeaders]),
+		body: consume(res),
+	};
+}
+
+return {
+	Headers: Headers,
+	Request: Request,
+	Response: Response,
+	fetch: fetch,
+	caches: caches,
+	WebSocket: WebSocket,
+	newRequest: newRequest,
+	unwrapResponse: unwrapResponse,
+};
+})`
+
+const preludeName = "prelude.js"
+
+var preludePrg = goja.MustCompile(preludeName, preludeSrc, true)
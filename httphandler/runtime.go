@@ -0,0 +1,355 @@
+package httphandler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/console"
+	"github.com/dop251/goja/file"
+)
+
+// defaultExportName is the global binding the "export default" form is rewritten to.
+const defaultExportName = "$default"
+
+// instance is a Runtime with the handler script loaded. It serves one request at a time.
+type instance struct {
+	h  *Handler
+	rt *goja.Runtime
+
+	newRequest     goja.Callable
+	unwrapResponse goja.Callable
+	jsonStringify  goja.Callable
+
+	handler     goja.Callable
+	handlerThis goja.Value
+	listener    goja.Callable
+	handlerPos  file.Position
+
+	// timers saved by the last request in StopFreeze mode
+	frozenTimers []frozenTimer
+
+	// state of the request being served
+	loop      *eventLoop
+	request   *http.Request
+	response  goja.Value
+	waitUntil []*goja.Promise
+}
+
+func (h *Handler) newInstance() (*instance, error) {
+	rt := goja.New()
+	inst := &instance{
+		h:  h,
+		rt: rt,
+	}
+
+	stringify, _ := goja.AssertFunction(rt.Get("JSON").ToObject(rt).Get("stringify"))
+	inst.jsonStringify = stringify
+
+	inst.installConsole()
+	inst.installTimers()
+
+	if err := rt.Set("addEventListener", inst.addEventListener); err != nil {
+		return nil, err
+	}
+
+	v, err := rt.RunProgram(preludePrg)
+	if err != nil {
+		return nil, err
+	}
+	init, _ := goja.AssertFunction(v)
+	host := rt.NewObject()
+	_ = host.Set("fetch", inst.fetch)
+	_ = host.Set("decode", func(buf goja.ArrayBuffer) string {
+		return string(buf.Bytes())
+	})
+	_ = host.Set("encode", func(s string) goja.ArrayBuffer {
+		return rt.NewArrayBuffer([]byte(s))
+	})
+	if h.cache != nil {
+		inst.installCache(host)
+	}
+	if h.wsDialer != nil {
+		inst.installWebSocket(host)
+	}
+	v, err = init(nil, host)
+	if err != nil {
+		return nil, err
+	}
+	exports := v.ToObject(rt)
+	for _, name := range []string{"Headers", "Request", "Response", "fetch"} {
+		if err := rt.Set(name, exports.Get(name)); err != nil {
+			return nil, err
+		}
+	}
+	if h.cache != nil {
+		if err := rt.Set("caches", exports.Get("caches")); err != nil {
+			return nil, err
+		}
+	}
+	if h.wsDialer != nil {
+		if err := rt.Set("WebSocket", exports.Get("WebSocket")); err != nil {
+			return nil, err
+		}
+	}
+	inst.newRequest, _ = goja.AssertFunction(exports.Get("newRequest"))
+	inst.unwrapResponse, _ = goja.AssertFunction(exports.Get("unwrapResponse"))
+
+	if h.setup != nil {
+		if err := h.setup(rt); err != nil {
+			return nil, err
+		}
+	}
+	if h.usage != nil {
+		rt.SetResourceAccounting(true)
+	}
+
+	if _, err := rt.RunProgram(h.prg); err != nil {
+		return nil, err
+	}
+
+	if def := rt.Get(defaultExportName); def != nil && !goja.IsUndefined(def) {
+		if fn, ok := goja.AssertFunction(def); ok {
+			inst.handler = fn
+			inst.handlerThis = goja.Undefined()
+			inst.handlerPos, _ = goja.FunctionPosition(def)
+		} else if obj, ok := def.(*goja.Object); ok {
+			fetch := obj.Get("fetch")
+			if fn, ok := goja.AssertFunction(fetch); ok {
+				inst.handler = fn
+				inst.handlerThis = obj
+				inst.handlerPos, _ = goja.FunctionPosition(fetch)
+			}
+		}
+		if inst.handler == nil {
+			return nil, errors.New("default export must be a function or an object with a fetch method")
+		}
+	} else if inst.listener == nil {
+		return nil, errors.New("script does not define a request handler")
+	}
+
+	return inst, nil
+}
+
+// currentLoop returns the loop of the request being served or throws if called outside of a request (i.e.
+// while the script is being initialised).
+func (i *instance) currentLoop() *eventLoop {
+	if i.loop == nil {
+		panic(i.rt.NewTypeError("Asynchronous operations are not allowed outside of a request handler"))
+	}
+	return i.loop
+}
+
+func (i *instance) addEventListener(call goja.FunctionCall) goja.Value {
+	typ := call.Argument(0).String()
+	if typ != "fetch" {
+		panic(i.rt.NewTypeError("Unsupported event type: %s", typ))
+	}
+	fn, ok := goja.AssertFunction(call.Argument(1))
+	if !ok {
+		panic(i.rt.NewTypeError("The listener is not a function"))
+	}
+	if i.loop != nil {
+		panic(i.rt.NewTypeError("Event listeners must be added while the script is being initialised"))
+	}
+	i.listener = fn
+	i.handlerPos, _ = goja.FunctionPosition(call.Argument(1))
+	return goja.Undefined()
+}
+
+func (i *instance) newFetchEvent(req goja.Value) *goja.Object {
+	event := i.rt.NewObject()
+	_ = event.Set("type", "fetch")
+	_ = event.Set("request", req)
+	_ = event.Set("respondWith", func(v goja.Value) {
+		if i.response != nil {
+			panic(i.rt.NewTypeError("respondWith() has already been called"))
+		}
+		i.response = v
+	})
+	_ = event.Set("waitUntil", i.addWaitUntil)
+	return event
+}
+
+func (i *instance) newContext() *goja.Object {
+	ctx := i.rt.NewObject()
+	_ = ctx.Set("waitUntil", i.addWaitUntil)
+	return ctx
+}
+
+func (i *instance) addWaitUntil(v goja.Value) {
+	if p, ok := v.Export().(*goja.Promise); ok {
+		i.waitUntil = append(i.waitUntil, p)
+	}
+}
+
+func (i *instance) installTimers() {
+	rt := i.rt
+	set := func(interval bool) func(goja.FunctionCall) goja.Value {
+		return func(call goja.FunctionCall) goja.Value {
+			l := i.currentLoop()
+			fn, ok := goja.AssertFunction(call.Argument(0))
+			if !ok {
+				panic(rt.NewTypeError("The callback is not a function"))
+			}
+			var args []goja.Value
+			if len(call.Arguments) > 2 {
+				args = append(args, call.Arguments[2:]...)
+			}
+			var pos file.Position
+			if l.longTask != nil {
+				pos, _ = goja.FunctionPosition(call.Argument(0))
+			}
+			return rt.ToValue(l.addTimer(fn, pos, call.Argument(1), args, interval))
+		}
+	}
+	clear := func(call goja.FunctionCall) goja.Value {
+		if i.loop != nil {
+			i.loop.clearTimer(call.Argument(0).ToInteger())
+		}
+		return goja.Undefined()
+	}
+	_ = rt.Set("setTimeout", set(false))
+	_ = rt.Set("setInterval", set(true))
+	_ = rt.Set("clearTimeout", clear)
+	_ = rt.Set("clearInterval", clear)
+}
+
+// installConsole routes the output of the console built-in to the console function of the Handler.
+func (i *instance) installConsole() {
+	i.rt.SetConsolePrinter(console.PrinterFunc(func(level console.Level, msg string) {
+		i.h.console(string(level), msg)
+	}))
+}
+
+func (i *instance) formatValue(v goja.Value) string {
+	obj, ok := v.(*goja.Object)
+	if !ok {
+		return v.String()
+	}
+	if _, isFunc := goja.AssertFunction(obj); isFunc {
+		return v.String()
+	}
+	if obj.ClassName() == "Error" {
+		if stack := obj.Get("stack"); stack != nil && !goja.IsUndefined(stack) {
+			return stack.String()
+		}
+		return v.String()
+	}
+	if s, err := i.jsonStringify(nil, obj); err == nil && !goja.IsUndefined(s) {
+		return s.String()
+	}
+	return v.String()
+}
+
+func (i *instance) fetch(call goja.FunctionCall) goja.Value {
+	rt := i.rt
+	l := i.currentLoop()
+	if i.h.client == nil {
+		panic(rt.NewTypeError("fetch is disabled"))
+	}
+	method := call.Argument(0).String()
+	url := call.Argument(1).String()
+	var headers [][]string
+	if err := rt.ExportTo(call.Argument(2), &headers); err != nil {
+		panic(rt.NewTypeError(err.Error()))
+	}
+	var body io.Reader
+	var sent int64
+	switch b := call.Argument(3).Export().(type) {
+	case string:
+		body = strings.NewReader(b)
+		sent = int64(len(b))
+	case goja.ArrayBuffer:
+		body = bytes.NewReader(b.Bytes())
+		sent = int64(len(b.Bytes()))
+	}
+	ctx, r := l.ctx, i.request
+	if i.h.policy != nil {
+		ctx = withPolicyRequest(ctx, r)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		panic(rt.NewTypeError("fetch failed: %v", err))
+	}
+	for _, h := range headers {
+		req.Header.Add(h[0], h[1])
+	}
+	i.allow("fetch", req.URL)
+
+	var pos file.Position
+	if l.longTask != nil {
+		pos = i.callerPos()
+	}
+	p, resolve, reject := rt.NewPromise()
+	done := l.startAsync("fetch", pos, func() {
+		reject(rt.NewGoError(ErrLoopStopped))
+	})
+	go func() {
+		resp, err := i.h.client.Do(req)
+		var data []byte
+		if err == nil {
+			data, err = readLimited(resp.Body, i.h.maxBodySize)
+			resp.Body.Close()
+			i.transferred(r, "fetch", req.URL, sent, int64(len(data)))
+		}
+		done(func() {
+			var denied *deniedError
+			if errors.As(err, &denied) {
+				reject(i.securityError(denied.err))
+				return
+			}
+			if err != nil {
+				reject(rt.NewTypeError("fetch failed: %v", err))
+				return
+			}
+			res := rt.NewObject()
+			_ = res.Set("status", resp.StatusCode)
+			_ = res.Set("statusText", strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))))
+			_ = res.Set("headers", headerPairs(rt, resp.Header))
+			_ = res.Set("body", rt.NewArrayBuffer(data))
+			resolve(res)
+		})
+	}()
+	return rt.ToValue(p)
+}
+
+// callerPos returns the position of the innermost call made by the handler script, skipping the native functions
+// and the prelude.
+func (i *instance) callerPos() file.Position {
+	for _, frame := range i.rt.CaptureCallStack(0, nil) {
+		if frame.SrcName() != preludeName {
+			if pos := frame.Position(); pos.Line > 0 {
+				return pos
+			}
+		}
+	}
+	return file.Position{}
+}
+
+func headerPairs(rt *goja.Runtime, header http.Header) goja.Value {
+	var pairs []interface{}
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, rt.NewArray(name, value))
+		}
+	}
+	return rt.NewArray(pairs...)
+}
+
+var errBodyTooLarge = errors.New("body is too large")
+
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err == nil && int64(len(data)) > limit {
+		err = errBodyTooLarge
+	}
+	return data, err
+}
@@ -0,0 +1,275 @@
+package httphandler
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/file"
+)
+
+// WebSocketConn is a WebSocket connection created by a WebSocketDialer. ReadMessage is called from one goroutine
+// and WriteMessage and Close from another one.
+type WebSocketConn interface {
+	// ReadMessage blocks until a message is received. text reports whether it is a text message. When the connection
+	// is closed by the peer, the error is a *WebSocketCloseError with the status code and the reason.
+	ReadMessage() (data []byte, text bool, err error)
+	WriteMessage(data []byte, text bool) error
+	// Close performs the closing handshake with the status code and the reason, and closes the connection. It must
+	// make a ReadMessage call in progress return.
+	Close(code int, reason string) error
+}
+
+// WebSocketCloseError is returned by WebSocketConn.ReadMessage when the connection has been closed by the peer.
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Reason)
+}
+
+// WebSocketDialer creates the connections of the WebSocket class, see WithWebSocket.
+type WebSocketDialer interface {
+	// Dial connects to the URL (whose scheme is ws or wss) requesting one of the subprotocols (if any). It returns
+	// the connection and the subprotocol selected by the server. ctx is the context of the request being served.
+	// config is the TLS configuration returned by the NetworkPolicy, nil means the default one. The connection should
+	// be made with DialContext, so that the NetworkPolicy is consulted about the resolved addresses.
+	Dial(ctx context.Context, u *url.URL, protocols []string, config *tls.Config) (conn WebSocketConn, protocol string, err error)
+}
+
+const (
+	wsCloseNormal    = 1000
+	wsCloseGoingAway = 1001
+	wsCloseNoStatus  = 1005
+	wsCloseAbnormal  = 1006
+
+	wsCloseInternalError = 1011
+)
+
+// wsConn is the Go side of a WebSocket object. The messages are sent by a separate goroutine, so that send() does not
+// block the event loop.
+type wsConn struct {
+	mu      sync.Mutex
+	conn    WebSocketConn
+	queue   []wsMessage
+	wakeup  chan struct{}
+	closing *WebSocketCloseError // the close initiated by the script or by the loop
+	sent    func(n int)
+}
+
+type wsMessage struct {
+	data []byte
+	text bool
+}
+
+// installWebSocket adds the host functions used by the WebSocket class of the prelude.
+func (i *instance) installWebSocket(host *goja.Object) {
+	_ = host.Set("wsConnect", i.wsConnect)
+	_ = host.Set("reportError", func(v goja.Value) {
+		i.h.console("error", "Uncaught "+i.formatValue(v))
+	})
+}
+
+// wsConnect starts connecting to the URL. dispatch is called on the loop with the events: ("open", protocol),
+// ("message", data) and ("close", code, reason, wasClean, error), the latter being the last one. The returned object
+// has the send(data) and close(code, reason) methods.
+func (i *instance) wsConnect(call goja.FunctionCall) goja.Value {
+	rt := i.rt
+	l := i.currentLoop()
+	u, err := url.Parse(call.Argument(0).String())
+	if err != nil {
+		panic(rt.NewTypeError("Invalid WebSocket URL: %v", err))
+	}
+	var protocols []string
+	if err := rt.ExportTo(call.Argument(1), &protocols); err != nil {
+		panic(rt.NewTypeError(err.Error()))
+	}
+	dispatch, ok := goja.AssertFunction(call.Argument(2))
+	if !ok {
+		panic(rt.NewTypeError("dispatch is not a function"))
+	}
+	i.allow("websocket", u)
+	var config *tls.Config
+	if i.h.policy != nil {
+		config = i.h.policy.TLSConfig(u)
+	}
+
+	var pos file.Position
+	if l.longTask != nil {
+		pos = i.callerPos()
+	}
+	emit := func(args ...interface{}) error {
+		values := make([]goja.Value, len(args))
+		for n, arg := range args {
+			if data, ok := arg.([]byte); ok {
+				arg = rt.NewArrayBuffer(data)
+			}
+			values[n] = rt.ToValue(arg)
+		}
+		// the exceptions thrown by the listeners are reported by dispatch
+		_, err := dispatch(nil, values...)
+		return err
+	}
+	post := func(args ...interface{}) {
+		l.enqueue(func() error {
+			return l.runTask("websocket", pos, func() error {
+				return emit(args...)
+			})
+		})
+	}
+	r := i.request
+	ws := &wsConn{
+		wakeup: make(chan struct{}, 1),
+		sent: func(n int) {
+			i.transferred(r, "websocket", u, int64(n), 0)
+		},
+	}
+	done := l.startAsync("websocket", pos, func() {
+		ws.close(wsCloseGoingAway, "")
+		_ = emit("close", wsCloseAbnormal, "", false, ErrLoopStopped.Error())
+	})
+	closed := func(code int, reason string, wasClean bool, err error) {
+		done(func() {
+			if err != nil {
+				_ = emit("close", code, reason, wasClean, err.Error())
+			} else {
+				_ = emit("close", code, reason, wasClean)
+			}
+		})
+	}
+	ctx := l.ctx
+	if i.h.policy != nil {
+		ctx = context.WithValue(ctx, policyDialKey{}, &policyDial{policy: i.h.policy, request: r})
+	}
+	go func() {
+		conn, protocol, err := i.h.wsDialer.Dial(ctx, u, protocols, config)
+		if err != nil {
+			closed(wsCloseAbnormal, "", false, err)
+			return
+		}
+		if !ws.connected(conn) {
+			_ = conn.Close(wsCloseGoingAway, "")
+			closed(wsCloseAbnormal, "", false, errors.New("the connection has been closed before it was established"))
+			return
+		}
+		post("open", protocol)
+		go ws.writeLoop(l.ctx)
+		for {
+			data, text, err := conn.ReadMessage()
+			if err != nil {
+				var ce *WebSocketCloseError
+				if errors.As(err, &ce) {
+					closed(ce.Code, ce.Reason, true, nil)
+				} else if c := ws.closed(); c != nil {
+					closed(c.Code, c.Reason, true, nil)
+				} else {
+					closed(wsCloseAbnormal, "", false, err)
+				}
+				ws.close(wsCloseNormal, "")
+				return
+			}
+			i.transferred(r, "websocket", u, 0, int64(len(data)))
+			if text {
+				post("message", string(data))
+			} else {
+				post("message", data)
+			}
+		}
+	}()
+
+	handle := rt.NewObject()
+	_ = handle.Set("send", func(data goja.Value) {
+		switch b := data.Export().(type) {
+		case string:
+			ws.send(wsMessage{data: []byte(b), text: true})
+		case goja.ArrayBuffer:
+			ws.send(wsMessage{data: append([]byte(nil), b.Bytes()...)})
+		default:
+			panic(rt.NewTypeError("Invalid message"))
+		}
+	})
+	_ = handle.Set("close", func(code int, reason string) {
+		ws.close(code, reason)
+	})
+	return handle
+}
+
+// connected sets the connection, it returns false if the connection has been closed in the meantime.
+func (ws *wsConn) connected(conn WebSocketConn) bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.closing != nil {
+		return false
+	}
+	ws.conn = conn
+	return true
+}
+
+func (ws *wsConn) closed() *WebSocketCloseError {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.closing
+}
+
+func (ws *wsConn) send(msg wsMessage) {
+	ws.mu.Lock()
+	if ws.closing == nil {
+		ws.queue = append(ws.queue, msg)
+	}
+	ws.mu.Unlock()
+	ws.wake()
+}
+
+// close makes the write loop close the connection once the queued messages have been sent.
+func (ws *wsConn) close(code int, reason string) {
+	ws.mu.Lock()
+	if ws.closing == nil {
+		ws.closing = &WebSocketCloseError{Code: code, Reason: reason}
+	}
+	ws.mu.Unlock()
+	ws.wake()
+}
+
+func (ws *wsConn) wake() {
+	select {
+	case ws.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// writeLoop sends the queued messages until the connection is closed by close or ctx is done.
+func (ws *wsConn) writeLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ws.wakeup:
+		case <-ctx.Done():
+			ws.close(wsCloseGoingAway, "")
+		}
+		ws.mu.Lock()
+		queue, closing := ws.queue, ws.closing
+		ws.queue = nil
+		ws.mu.Unlock()
+		for _, msg := range queue {
+			if err := ws.conn.WriteMessage(msg.data, msg.text); err != nil {
+				// the reader will fail as well and report the error
+				_ = ws.conn.Close(wsCloseInternalError, "")
+				return
+			}
+			ws.sent(len(msg.data))
+		}
+		if closing != nil {
+			code := closing.Code
+			if code == wsCloseNoStatus {
+				code = wsCloseNormal
+			}
+			_ = ws.conn.Close(code, closing.Reason)
+			return
+		}
+	}
+}
@@ -0,0 +1,885 @@
+package goja
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+
+	"github.com/dop251/goja/unistring"
+)
+
+var pluralCategoryNames = [...]string{
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+	plural.Other: "other",
+}
+
+// pluralCategory returns the CLDR plural category of n as it's displayed with at most 3 fraction digits.
+func pluralCategory(tag language.Tag, n float64, ordinal bool) string {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return "other"
+	}
+	// the plural operands, see https://unicode.org/reports/tr35/tr35-numbers.html#Operands
+	s := strconv.FormatFloat(math.Abs(n), 'f', -1, 64)
+	intPart, fracPart := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		intPart, fracPart = s[:dot], s[dot+1:]
+		if len(fracPart) > 3 {
+			fracPart = strings.TrimRight(fracPart[:3], "0")
+		}
+	}
+	if len(intPart) > 9 {
+		// the rules only look at the last few digits of large numbers
+		intPart = "1" + intPart[len(intPart)-8:]
+	}
+	i, _ := strconv.Atoi(intPart)
+	f, _ := strconv.Atoi("0" + fracPart)
+	trimmed := strings.TrimRight(fracPart, "0")
+	t, _ := strconv.Atoi("0" + trimmed)
+	rules := plural.Cardinal
+	if ordinal {
+		rules = plural.Ordinal
+	}
+	return pluralCategoryNames[rules.MatchPlural(tag, i, len(fracPart), len(trimmed), f, t)]
+}
+
+// pluralCategories returns the plural categories used by the locale, in the order required by
+// Intl.PluralRules.prototype.resolvedOptions().
+func pluralCategories(tag language.Tag, ordinal bool) []string {
+	found := make(map[string]bool)
+	for i := 0; i <= 1000; i++ {
+		found[pluralCategory(tag, float64(i), ordinal)] = true
+	}
+	if !ordinal {
+		for i := 1; i < 100; i++ {
+			found[pluralCategory(tag, float64(i)/10, ordinal)] = true
+		}
+		found[pluralCategory(tag, 1e6, ordinal)] = true
+	}
+	var res []string
+	for _, name := range [...]string{"zero", "one", "two", "few", "many", "other"} {
+		if found[name] {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// localeLanguage returns the language subtag of the tag, or "" if it's undetermined (which is also the case when
+// the requested language was unknown to the parser).
+func localeLanguage(tag language.Tag) string {
+	base, conf := tag.Base()
+	if conf != language.Exact {
+		return ""
+	}
+	return base.String()
+}
+
+// cldrLanguage reports whether there is CLDR data for the language, which is what PluralRules and Segmenter
+// support.
+func cldrLanguage(lang string) bool {
+	if lang == "" {
+		return false
+	}
+	_, exact := language.CompactIndex(language.Make(lang))
+	return exact
+}
+
+// resolveLocale returns the first language tag of the locales argument (which can be a string, an iterable of
+// strings or undefined) whose language is supported, or def if there is none.
+func (r *Runtime) resolveLocale(locales Value, def language.Tag, supported func(lang string) bool) language.Tag {
+	for _, tag := range r.localeList(locales) {
+		if supported(localeLanguage(tag)) {
+			return tag
+		}
+	}
+	return def
+}
+
+// localeList parses the locales argument which can be a string, an iterable of strings or undefined. The locales
+// must be well-formed, but they may contain unknown subtags which are dropped (an unknown language becomes "und").
+func (r *Runtime) localeList(locales Value) []language.Tag {
+	if locales == nil || locales == _undefined {
+		return nil
+	}
+	var list []string
+	if _, ok := locales.(valueString); ok {
+		list = []string{locales.String()}
+	} else {
+		r.getIterator(locales, nil).iterate(func(item Value) {
+			list = append(list, item.String())
+		})
+	}
+	tags := make([]language.Tag, len(list))
+	for i, s := range list {
+		if !isWellFormedLocale(s) {
+			panic(r.newError(r.global.RangeError, "Incorrect locale information provided: %s", s))
+		}
+		// the tag is well-formed, so the only errors left are about the unknown subtags
+		tags[i], _ = language.Parse(s)
+	}
+	return tags
+}
+
+func isLocaleAlpha(c byte) bool {
+	c |= 0x20
+	return c >= 'a' && c <= 'z'
+}
+
+func isLocaleDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLocaleAlnum(c byte) bool {
+	return isLocaleAlpha(c) || isLocaleDigit(c)
+}
+
+// isLocaleSubtag reports whether s has min to max characters of the class.
+func isLocaleSubtag(s string, min, max int, class func(c byte) bool) bool {
+	if len(s) < min || len(s) > max {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !class(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLanguageSubtag(s string) bool {
+	return isLocaleSubtag(s, 2, 3, isLocaleAlpha) || isLocaleSubtag(s, 5, 8, isLocaleAlpha)
+}
+
+// wellFormedLanguageID checks the unicode_language_id which starts with the language subtag and returns the number
+// of the subtags it consists of.
+func wellFormedLanguageID(subtags []string) (int, bool) {
+	if len(subtags) == 0 || !isLanguageSubtag(subtags[0]) {
+		return 0, false
+	}
+	i := 1
+	if i < len(subtags) && isLocaleSubtag(subtags[i], 4, 4, isLocaleAlpha) {
+		i++
+	}
+	if i < len(subtags) && (isLocaleSubtag(subtags[i], 2, 2, isLocaleAlpha) || isLocaleSubtag(subtags[i], 3, 3, isLocaleDigit)) {
+		i++
+	}
+	var variants map[string]bool
+	for ; i < len(subtags); i++ {
+		s := subtags[i]
+		if !isLocaleSubtag(s, 5, 8, isLocaleAlnum) && !(len(s) == 4 && isLocaleDigit(s[0]) && isLocaleSubtag(s[1:], 3, 3, isLocaleAlnum)) {
+			break
+		}
+		s = strings.ToLower(s)
+		if variants[s] {
+			return 0, false
+		}
+		if variants == nil {
+			variants = make(map[string]bool)
+		}
+		variants[s] = true
+	}
+	return i, true
+}
+
+// isWellFormedLocale reports whether s is a structurally valid language tag as defined by ECMA-402, i.e. a Unicode
+// BCP 47 locale identifier without the backwards compatibility syntax, duplicate variants or duplicate singletons.
+// See https://402.ecma-international.org/#sec-isstructurallyvalidlanguagetag
+func isWellFormedLocale(s string) bool {
+	subtags := strings.Split(s, "-")
+	i, ok := wellFormedLanguageID(subtags)
+	if !ok {
+		return false
+	}
+	var singletons [256]bool
+	for i < len(subtags) {
+		if len(subtags[i]) != 1 || !isLocaleAlnum(subtags[i][0]) {
+			return false
+		}
+		singleton := subtags[i][0]
+		if isLocaleAlpha(singleton) {
+			singleton |= 0x20
+		}
+		i++
+		start := i
+		if singleton == 'x' {
+			for ; i < len(subtags); i++ {
+				if !isLocaleSubtag(subtags[i], 1, 8, isLocaleAlnum) {
+					return false
+				}
+			}
+			return i > start
+		}
+		if singletons[singleton] {
+			return false
+		}
+		singletons[singleton] = true
+		switch singleton {
+		case 'u':
+			// attributes followed by keywords
+			for i < len(subtags) && isLocaleSubtag(subtags[i], 3, 8, isLocaleAlnum) {
+				i++
+			}
+			for i < len(subtags) && len(subtags[i]) == 2 && isLocaleAlnum(subtags[i][0]) && isLocaleAlpha(subtags[i][1]) {
+				i++
+				for i < len(subtags) && isLocaleSubtag(subtags[i], 3, 8, isLocaleAlnum) {
+					i++
+				}
+			}
+		case 't':
+			// the optional tlang followed by fields
+			if i < len(subtags) && isLanguageSubtag(subtags[i]) {
+				n, ok := wellFormedLanguageID(subtags[i:])
+				if !ok {
+					return false
+				}
+				i += n
+			}
+			for i < len(subtags) && len(subtags[i]) == 2 && isLocaleAlpha(subtags[i][0]) && isLocaleDigit(subtags[i][1]) {
+				i++
+				values := i
+				for i < len(subtags) && isLocaleSubtag(subtags[i], 3, 8, isLocaleAlnum) {
+					i++
+				}
+				if i == values {
+					return false
+				}
+			}
+		default:
+			for i < len(subtags) && isLocaleSubtag(subtags[i], 2, 8, isLocaleAlnum) {
+				i++
+			}
+		}
+		if i == start {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveSupportedLocale is like resolveLocale, but also falls back to English if there is no data for def.
+func (r *Runtime) resolveSupportedLocale(locales Value, def language.Tag, supported func(lang string) bool) (language.Tag, string) {
+	tag := r.resolveLocale(locales, def, supported)
+	if lang := localeLanguage(tag); supported(lang) {
+		return tag, lang
+	}
+	return language.English, "en"
+}
+
+func (r *Runtime) intlOptions(v Value) *Object {
+	if v == nil || v == _undefined {
+		return nil
+	}
+	return r.toObject(v)
+}
+
+// getIntlOption returns the value of a string option, which must be one of the allowed values.
+func (r *Runtime) getIntlOption(options *Object, ctorName, name string, allowed []string, def string) string {
+	if options == nil {
+		return def
+	}
+	v := options.self.getStr(unistring.NewFromString(name), nil)
+	if v == nil || v == _undefined {
+		return def
+	}
+	s := v.toString().String()
+	for _, a := range allowed {
+		if s == a {
+			return s
+		}
+	}
+	panic(r.newError(r.global.RangeError, "Value %s out of range for Intl.%s options property %s", s, ctorName, name))
+}
+
+func argOrUndefined(args []Value, i int) Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return _undefined
+}
+
+// newIntlConstructor creates a constructor (which requires new) with its own prototype. The create function
+// receives the prototype for the new object (which may come from new.target). The tag becomes the
+// Symbol.toStringTag property of the prototype. The static supportedLocalesOf() method reports the locales whose
+// language passes the supported check (the same one as used with resolveLocale()).
+func (r *Runtime) newIntlConstructor(name, tag string, length int64, supported func(lang string) bool, create func(args []Value, proto *Object) *Object, methods func(proto *Object)) *Object {
+	ctor := &Object{runtime: r}
+	proto := r.NewObject()
+	proto.self._putProp("constructor", ctor, true, false, true)
+	methods(proto)
+	proto.self._putSym(SymToStringTag, valueProp(asciiString(tag), false, false, true))
+
+	r.newNativeConstructOnly(ctor, func(args []Value, newTarget *Object) *Object {
+		if newTarget == nil {
+			panic(r.needNew(name))
+		}
+		return create(args, r.getPrototypeFromCtor(newTarget, ctor, proto))
+	}, proto, unistring.NewFromString(name), length)
+	r.putMethod(ctor, "supportedLocalesOf", func(call FunctionCall) Value {
+		return r.intlSupportedLocalesOf(call, name, supported)
+	}, 1)
+	return ctor
+}
+
+// intlSupportedLocalesOf returns the canonicalized locales of the list, without duplicates, whose language is
+// supported.
+func (r *Runtime) intlSupportedLocalesOf(call FunctionCall, ctorName string, supported func(lang string) bool) Value {
+	tags := r.localeList(call.Argument(0))
+	r.getIntlOption(r.intlOptions(call.Argument(1)), ctorName, "localeMatcher", []string{"lookup", "best fit"}, "best fit")
+	var res []Value
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		s := tag.String()
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		if supported(localeLanguage(tag)) {
+			res = append(res, newStringValue(s))
+		}
+	}
+	return r.newArrayValues(res)
+}
+
+func (r *Runtime) putMethod(o *Object, name string, f func(FunctionCall) Value, length int) {
+	n := unistring.NewFromString(name)
+	o.self._putProp(n, r.newNativeFunc(f, nil, n, nil, length), true, false, true)
+}
+
+func (r *Runtime) newResolvedOptions(props ...string) *Object {
+	res := r.NewObject()
+	for i := 0; i < len(props); i += 2 {
+		res.self._putProp(unistring.NewFromString(props[i]), newStringValue(props[i+1]), true, true, true)
+	}
+	return res
+}
+
+func initHostObject(o *Object, b *baseObject, self objectImpl, proto *Object) {
+	b.class = classObject
+	b.val = o
+	b.extensible = true
+	b.prototype = proto
+	o.self = self
+	b.init()
+}
+
+type pluralRulesObject struct {
+	baseObject
+	tag     language.Tag
+	ordinal bool
+}
+
+func (r *Runtime) toPluralRules(v Value, method string) *pluralRulesObject {
+	if obj, ok := v.(*Object); ok {
+		if p, ok := obj.self.(*pluralRulesObject); ok {
+			return p
+		}
+	}
+	panic(r.NewTypeError("Method Intl.PluralRules.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) newPluralRulesConstructor(def language.Tag) *Object {
+	return r.newIntlConstructor("PluralRules", "Intl.PluralRules", 0, cldrLanguage, func(args []Value, proto *Object) *Object {
+		o := &Object{runtime: r}
+		p := &pluralRulesObject{
+			tag: r.resolveLocale(argOrUndefined(args, 0), def, cldrLanguage),
+		}
+		opts := r.intlOptions(argOrUndefined(args, 1))
+		p.ordinal = r.getIntlOption(opts, "PluralRules", "type", []string{"cardinal", "ordinal"}, "cardinal") == "ordinal"
+		initHostObject(o, &p.baseObject, p, proto)
+		return o
+	}, func(proto *Object) {
+		r.putMethod(proto, "select", func(call FunctionCall) Value {
+			p := r.toPluralRules(call.This, "select")
+			return asciiString(pluralCategory(p.tag, call.Argument(0).ToFloat(), p.ordinal))
+		}, 1)
+		r.putMethod(proto, "resolvedOptions", func(call FunctionCall) Value {
+			p := r.toPluralRules(call.This, "resolvedOptions")
+			typ := "cardinal"
+			if p.ordinal {
+				typ = "ordinal"
+			}
+			res := r.newResolvedOptions("locale", p.tag.String(), "type", typ)
+			categories := pluralCategories(p.tag, p.ordinal)
+			values := make([]Value, len(categories))
+			for i, c := range categories {
+				values[i] = asciiString(c)
+			}
+			res.self._putProp("pluralCategories", r.newArrayValues(values), true, true, true)
+			return res
+		}, 0)
+	})
+}
+
+// List patterns: the separator between two elements, the separators between the elements of longer lists
+// and the separator before the last element.
+type listPatterns struct {
+	pair, middle, end string
+}
+
+var listFormatData = map[string]map[string]listPatterns{
+	"en": {
+		"conjunction-long":   {" and ", ", ", ", and "},
+		"conjunction-short":  {" & ", ", ", ", & "},
+		"conjunction-narrow": {", ", ", ", ", "},
+		"disjunction-long":   {" or ", ", ", ", or "},
+		"disjunction-short":  {" or ", ", ", ", or "},
+		"disjunction-narrow": {" or ", ", ", ", or "},
+		"unit-long":          {", ", ", ", ", "},
+		"unit-short":         {", ", ", ", ", "},
+		"unit-narrow":        {" ", " ", " "},
+	},
+	"de": {
+		"conjunction": {" und ", ", ", " und "},
+		"disjunction": {" oder ", ", ", " oder "},
+		"unit":        {", ", ", ", " und "},
+	},
+	"fr": {
+		"conjunction": {" et ", ", ", " et "},
+		"disjunction": {" ou ", ", ", " ou "},
+		"unit":        {" et ", ", ", " et "},
+	},
+	"it": {
+		"conjunction": {" e ", ", ", " e "},
+		"disjunction": {" o ", ", ", " o "},
+		"unit":        {" e ", ", ", " e "},
+	},
+	"nl": {
+		"conjunction": {" en ", ", ", " en "},
+		"disjunction": {" of ", ", ", " of "},
+		"unit":        {" en ", ", ", " en "},
+	},
+	"pt": {
+		"conjunction": {" e ", ", ", " e "},
+		"disjunction": {" ou ", ", ", " ou "},
+		"unit":        {" e ", ", ", " e "},
+	},
+}
+
+type listFormatObject struct {
+	baseObject
+	tag        language.Tag
+	typ, style string
+	patterns   listPatterns
+}
+
+func (r *Runtime) toListFormat(v Value, method string) *listFormatObject {
+	if obj, ok := v.(*Object); ok {
+		if l, ok := obj.self.(*listFormatObject); ok {
+			return l
+		}
+	}
+	panic(r.NewTypeError("Method Intl.ListFormat.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) stringListFromIterable(v Value) []Value {
+	if v == _undefined {
+		return nil
+	}
+	var list []Value
+	r.getIterator(v, nil).iterate(func(item Value) {
+		s, ok := item.(valueString)
+		if !ok {
+			panic(r.NewTypeError("Iterable yielded %s which is not a string", describeValue(item)))
+		}
+		list = append(list, s)
+	})
+	return list
+}
+
+// parts returns the elements interleaved with the separators.
+func (l *listFormatObject) parts(list []Value) []Value {
+	var res []Value
+	for i, item := range list {
+		if i > 0 {
+			sep := l.patterns.middle
+			if len(list) == 2 {
+				sep = l.patterns.pair
+			} else if i == len(list)-1 {
+				sep = l.patterns.end
+			}
+			res = append(res, newStringValue(sep))
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+func (r *Runtime) newListFormatConstructor(def language.Tag) *Object {
+	supported := func(lang string) bool {
+		return listFormatData[lang] != nil
+	}
+	return r.newIntlConstructor("ListFormat", "Intl.ListFormat", 0, supported, func(args []Value, proto *Object) *Object {
+		o := &Object{runtime: r}
+		l := &listFormatObject{}
+		var lang string
+		l.tag, lang = r.resolveSupportedLocale(argOrUndefined(args, 0), def, supported)
+		opts := r.intlOptions(argOrUndefined(args, 1))
+		l.typ = r.getIntlOption(opts, "ListFormat", "type", []string{"conjunction", "disjunction", "unit"}, "conjunction")
+		l.style = r.getIntlOption(opts, "ListFormat", "style", []string{"long", "short", "narrow"}, "long")
+		data := listFormatData[lang]
+		if p, ok := data[l.typ+"-"+l.style]; ok {
+			l.patterns = p
+		} else {
+			l.patterns = data[l.typ]
+		}
+		initHostObject(o, &l.baseObject, l, proto)
+		return o
+	}, func(proto *Object) {
+		r.putMethod(proto, "format", func(call FunctionCall) Value {
+			l := r.toListFormat(call.This, "format")
+			var b valueStringBuilder
+			for _, part := range l.parts(r.stringListFromIterable(call.Argument(0))) {
+				b.WriteString(part.toString())
+			}
+			return b.String()
+		}, 1)
+		r.putMethod(proto, "formatToParts", func(call FunctionCall) Value {
+			l := r.toListFormat(call.This, "formatToParts")
+			parts := l.parts(r.stringListFromIterable(call.Argument(0)))
+			res := make([]Value, len(parts))
+			for i, part := range parts {
+				typ := "element"
+				if i%2 == 1 {
+					typ = "literal"
+				}
+				obj := r.NewObject()
+				obj.self._putProp("type", asciiString(typ), true, true, true)
+				obj.self._putProp("value", part, true, true, true)
+				res[i] = obj
+			}
+			return r.newArrayValues(res)
+		}, 1)
+		r.putMethod(proto, "resolvedOptions", func(call FunctionCall) Value {
+			l := r.toListFormat(call.This, "resolvedOptions")
+			return r.newResolvedOptions("locale", l.tag.String(), "type", l.typ, "style", l.style)
+		}, 0)
+	})
+}
+
+// Relative time patterns of a unit, indexed by the plural category. {0} is replaced with the number.
+type relativeTimeUnit struct {
+	future, past map[string]string
+	// the phrases used with numeric: "auto" for the values -1, 0 and 1
+	previous, current, next string
+	// the phrases for -2 and 2 which some languages have for days (e.g. "vorgestern")
+	beforePrevious, afterNext string
+}
+
+func newRelativeTimeUnit(futureOne, futureOther, pastOne, pastOther, previous, current, next string) *relativeTimeUnit {
+	return &relativeTimeUnit{
+		future:   map[string]string{"one": futureOne, "other": futureOther},
+		past:     map[string]string{"one": pastOne, "other": pastOther},
+		previous: previous,
+		current:  current,
+		next:     next,
+	}
+}
+
+func (u *relativeTimeUnit) withTwo(beforePrevious, afterNext string) *relativeTimeUnit {
+	u.beforePrevious = beforePrevious
+	u.afterNext = afterNext
+	return u
+}
+
+func (u *relativeTimeUnit) phrase(v float64) string {
+	switch v {
+	case -2:
+		return u.beforePrevious
+	case -1:
+		return u.previous
+	case 0:
+		return u.current
+	case 1:
+		return u.next
+	case 2:
+		return u.afterNext
+	}
+	return ""
+}
+
+// relativeTimeStyles is used for the languages whose narrow style is the same as the short one.
+func relativeTimeStyles(long, short map[string]*relativeTimeUnit) map[string]map[string]*relativeTimeUnit {
+	return map[string]map[string]*relativeTimeUnit{"long": long, "short": short, "narrow": short}
+}
+
+var relativeTimeData = map[string]map[string]map[string]*relativeTimeUnit{
+	"en": {
+		"long": {
+			"year":    newRelativeTimeUnit("in {0} year", "in {0} years", "{0} year ago", "{0} years ago", "last year", "this year", "next year"),
+			"quarter": newRelativeTimeUnit("in {0} quarter", "in {0} quarters", "{0} quarter ago", "{0} quarters ago", "last quarter", "this quarter", "next quarter"),
+			"month":   newRelativeTimeUnit("in {0} month", "in {0} months", "{0} month ago", "{0} months ago", "last month", "this month", "next month"),
+			"week":    newRelativeTimeUnit("in {0} week", "in {0} weeks", "{0} week ago", "{0} weeks ago", "last week", "this week", "next week"),
+			"day":     newRelativeTimeUnit("in {0} day", "in {0} days", "{0} day ago", "{0} days ago", "yesterday", "today", "tomorrow"),
+			"hour":    newRelativeTimeUnit("in {0} hour", "in {0} hours", "{0} hour ago", "{0} hours ago", "", "this hour", ""),
+			"minute":  newRelativeTimeUnit("in {0} minute", "in {0} minutes", "{0} minute ago", "{0} minutes ago", "", "this minute", ""),
+			"second":  newRelativeTimeUnit("in {0} second", "in {0} seconds", "{0} second ago", "{0} seconds ago", "", "now", ""),
+		},
+		"short": {
+			"year":    newRelativeTimeUnit("in {0} yr.", "in {0} yr.", "{0} yr. ago", "{0} yr. ago", "last yr.", "this yr.", "next yr."),
+			"quarter": newRelativeTimeUnit("in {0} qtr.", "in {0} qtrs.", "{0} qtr. ago", "{0} qtrs. ago", "last qtr.", "this qtr.", "next qtr."),
+			"month":   newRelativeTimeUnit("in {0} mo.", "in {0} mo.", "{0} mo. ago", "{0} mo. ago", "last mo.", "this mo.", "next mo."),
+			"week":    newRelativeTimeUnit("in {0} wk.", "in {0} wk.", "{0} wk. ago", "{0} wk. ago", "last wk.", "this wk.", "next wk."),
+			"day":     newRelativeTimeUnit("in {0} day", "in {0} days", "{0} day ago", "{0} days ago", "yesterday", "today", "tomorrow"),
+			"hour":    newRelativeTimeUnit("in {0} hr.", "in {0} hr.", "{0} hr. ago", "{0} hr. ago", "", "this hour", ""),
+			"minute":  newRelativeTimeUnit("in {0} min.", "in {0} min.", "{0} min. ago", "{0} min. ago", "", "this minute", ""),
+			"second":  newRelativeTimeUnit("in {0} sec.", "in {0} sec.", "{0} sec. ago", "{0} sec. ago", "", "now", ""),
+		},
+		"narrow": {
+			"year":    newRelativeTimeUnit("in {0}y", "in {0}y", "{0}y ago", "{0}y ago", "last yr.", "this yr.", "next yr."),
+			"quarter": newRelativeTimeUnit("in {0}q", "in {0}q", "{0}q ago", "{0}q ago", "last qtr.", "this qtr.", "next qtr."),
+			"month":   newRelativeTimeUnit("in {0}mo", "in {0}mo", "{0}mo ago", "{0}mo ago", "last mo.", "this mo.", "next mo."),
+			"week":    newRelativeTimeUnit("in {0}w", "in {0}w", "{0}w ago", "{0}w ago", "last wk.", "this wk.", "next wk."),
+			"day":     newRelativeTimeUnit("in {0}d", "in {0}d", "{0}d ago", "{0}d ago", "yesterday", "today", "tomorrow"),
+			"hour":    newRelativeTimeUnit("in {0}h", "in {0}h", "{0}h ago", "{0}h ago", "", "this hour", ""),
+			"minute":  newRelativeTimeUnit("in {0}m", "in {0}m", "{0}m ago", "{0}m ago", "", "this minute", ""),
+			"second":  newRelativeTimeUnit("in {0}s", "in {0}s", "{0}s ago", "{0}s ago", "", "now", ""),
+		},
+	},
+	"de": relativeTimeStyles(map[string]*relativeTimeUnit{
+		"year":    newRelativeTimeUnit("in {0} Jahr", "in {0} Jahren", "vor {0} Jahr", "vor {0} Jahren", "letztes Jahr", "dieses Jahr", "nächstes Jahr"),
+		"quarter": newRelativeTimeUnit("in {0} Quartal", "in {0} Quartalen", "vor {0} Quartal", "vor {0} Quartalen", "letztes Quartal", "dieses Quartal", "nächstes Quartal"),
+		"month":   newRelativeTimeUnit("in {0} Monat", "in {0} Monaten", "vor {0} Monat", "vor {0} Monaten", "letzten Monat", "diesen Monat", "nächsten Monat"),
+		"week":    newRelativeTimeUnit("in {0} Woche", "in {0} Wochen", "vor {0} Woche", "vor {0} Wochen", "letzte Woche", "diese Woche", "nächste Woche"),
+		"day":     newRelativeTimeUnit("in {0} Tag", "in {0} Tagen", "vor {0} Tag", "vor {0} Tagen", "gestern", "heute", "morgen").withTwo("vorgestern", "übermorgen"),
+		"hour":    newRelativeTimeUnit("in {0} Stunde", "in {0} Stunden", "vor {0} Stunde", "vor {0} Stunden", "", "in dieser Stunde", ""),
+		"minute":  newRelativeTimeUnit("in {0} Minute", "in {0} Minuten", "vor {0} Minute", "vor {0} Minuten", "", "in dieser Minute", ""),
+		"second":  newRelativeTimeUnit("in {0} Sekunde", "in {0} Sekunden", "vor {0} Sekunde", "vor {0} Sekunden", "", "jetzt", ""),
+	}, map[string]*relativeTimeUnit{
+		"year":    newRelativeTimeUnit("in {0} J.", "in {0} J.", "vor {0} J.", "vor {0} J.", "letztes Jahr", "dieses Jahr", "nächstes Jahr"),
+		"quarter": newRelativeTimeUnit("in {0} Quart.", "in {0} Quart.", "vor {0} Quart.", "vor {0} Quart.", "letztes Quartal", "dieses Quartal", "nächstes Quartal"),
+		"month":   newRelativeTimeUnit("in {0} Monat", "in {0} Monaten", "vor {0} Monat", "vor {0} Monaten", "letzten Monat", "diesen Monat", "nächsten Monat"),
+		"week":    newRelativeTimeUnit("in {0} Woche", "in {0} Wochen", "vor {0} Woche", "vor {0} Wochen", "letzte Woche", "diese Woche", "nächste Woche"),
+		"day":     newRelativeTimeUnit("in {0} Tag", "in {0} Tagen", "vor {0} Tag", "vor {0} Tagen", "gestern", "heute", "morgen").withTwo("vorgestern", "übermorgen"),
+		"hour":    newRelativeTimeUnit("in {0} Std.", "in {0} Std.", "vor {0} Std.", "vor {0} Std.", "", "in dieser Stunde", ""),
+		"minute":  newRelativeTimeUnit("in {0} Min.", "in {0} Min.", "vor {0} Min.", "vor {0} Min.", "", "in dieser Minute", ""),
+		"second":  newRelativeTimeUnit("in {0} Sek.", "in {0} Sek.", "vor {0} Sek.", "vor {0} Sek.", "", "jetzt", ""),
+	}),
+	"es": relativeTimeStyles(map[string]*relativeTimeUnit{
+		"year":    newRelativeTimeUnit("dentro de {0} año", "dentro de {0} años", "hace {0} año", "hace {0} años", "el año pasado", "este año", "el próximo año"),
+		"quarter": newRelativeTimeUnit("dentro de {0} trimestre", "dentro de {0} trimestres", "hace {0} trimestre", "hace {0} trimestres", "el trimestre pasado", "este trimestre", "el próximo trimestre"),
+		"month":   newRelativeTimeUnit("dentro de {0} mes", "dentro de {0} meses", "hace {0} mes", "hace {0} meses", "el mes pasado", "este mes", "el próximo mes"),
+		"week":    newRelativeTimeUnit("dentro de {0} semana", "dentro de {0} semanas", "hace {0} semana", "hace {0} semanas", "la semana pasada", "esta semana", "la próxima semana"),
+		"day":     newRelativeTimeUnit("dentro de {0} día", "dentro de {0} días", "hace {0} día", "hace {0} días", "ayer", "hoy", "mañana").withTwo("anteayer", "pasado mañana"),
+		"hour":    newRelativeTimeUnit("dentro de {0} hora", "dentro de {0} horas", "hace {0} hora", "hace {0} horas", "", "esta hora", ""),
+		"minute":  newRelativeTimeUnit("dentro de {0} minuto", "dentro de {0} minutos", "hace {0} minuto", "hace {0} minutos", "", "este minuto", ""),
+		"second":  newRelativeTimeUnit("dentro de {0} segundo", "dentro de {0} segundos", "hace {0} segundo", "hace {0} segundos", "", "ahora", ""),
+	}, map[string]*relativeTimeUnit{
+		"year":    newRelativeTimeUnit("dentro de {0} a", "dentro de {0} a", "hace {0} a", "hace {0} a", "el año pasado", "este año", "el próximo año"),
+		"quarter": newRelativeTimeUnit("dentro de {0} trim.", "dentro de {0} trim.", "hace {0} trim.", "hace {0} trim.", "el trimestre pasado", "este trimestre", "el próximo trimestre"),
+		"month":   newRelativeTimeUnit("dentro de {0} m", "dentro de {0} m", "hace {0} m", "hace {0} m", "el mes pasado", "este mes", "el próximo mes"),
+		"week":    newRelativeTimeUnit("dentro de {0} sem.", "dentro de {0} sem.", "hace {0} sem.", "hace {0} sem.", "la semana pasada", "esta semana", "la próxima semana"),
+		"day":     newRelativeTimeUnit("dentro de {0} d", "dentro de {0} d", "hace {0} d", "hace {0} d", "ayer", "hoy", "mañana").withTwo("anteayer", "pasado mañana"),
+		"hour":    newRelativeTimeUnit("dentro de {0} h", "dentro de {0} h", "hace {0} h", "hace {0} h", "", "esta hora", ""),
+		"minute":  newRelativeTimeUnit("dentro de {0} min", "dentro de {0} min", "hace {0} min", "hace {0} min", "", "este minuto", ""),
+		"second":  newRelativeTimeUnit("dentro de {0} s", "dentro de {0} s", "hace {0} s", "hace {0} s", "", "ahora", ""),
+	}),
+	"fr": relativeTimeStyles(map[string]*relativeTimeUnit{
+		"year":    newRelativeTimeUnit("dans {0} an", "dans {0} ans", "il y a {0} an", "il y a {0} ans", "l’année dernière", "cette année", "l’année prochaine"),
+		"quarter": newRelativeTimeUnit("dans {0} trimestre", "dans {0} trimestres", "il y a {0} trimestre", "il y a {0} trimestres", "le trimestre dernier", "ce trimestre", "le trimestre prochain"),
+		"month":   newRelativeTimeUnit("dans {0} mois", "dans {0} mois", "il y a {0} mois", "il y a {0} mois", "le mois dernier", "ce mois-ci", "le mois prochain"),
+		"week":    newRelativeTimeUnit("dans {0} semaine", "dans {0} semaines", "il y a {0} semaine", "il y a {0} semaines", "la semaine dernière", "cette semaine", "la semaine prochaine"),
+		"day":     newRelativeTimeUnit("dans {0} jour", "dans {0} jours", "il y a {0} jour", "il y a {0} jours", "hier", "aujourd’hui", "demain").withTwo("avant-hier", "après-demain"),
+		"hour":    newRelativeTimeUnit("dans {0} heure", "dans {0} heures", "il y a {0} heure", "il y a {0} heures", "", "cette heure-ci", ""),
+		"minute":  newRelativeTimeUnit("dans {0} minute", "dans {0} minutes", "il y a {0} minute", "il y a {0} minutes", "", "cette minute-ci", ""),
+		"second":  newRelativeTimeUnit("dans {0} seconde", "dans {0} secondes", "il y a {0} seconde", "il y a {0} secondes", "", "maintenant", ""),
+	}, map[string]*relativeTimeUnit{
+		"year":    newRelativeTimeUnit("dans {0} a", "dans {0} a", "il y a {0} a", "il y a {0} a", "l’année dernière", "cette année", "l’année prochaine"),
+		"quarter": newRelativeTimeUnit("dans {0} trim.", "dans {0} trim.", "il y a {0} trim.", "il y a {0} trim.", "le trimestre dernier", "ce trimestre", "le trimestre prochain"),
+		"month":   newRelativeTimeUnit("dans {0} m.", "dans {0} m.", "il y a {0} m.", "il y a {0} m.", "le mois dernier", "ce mois-ci", "le mois prochain"),
+		"week":    newRelativeTimeUnit("dans {0} sem.", "dans {0} sem.", "il y a {0} sem.", "il y a {0} sem.", "la semaine dernière", "cette semaine", "la semaine prochaine"),
+		"day":     newRelativeTimeUnit("dans {0} j", "dans {0} j", "il y a {0} j", "il y a {0} j", "hier", "aujourd’hui", "demain").withTwo("avant-hier", "après-demain"),
+		"hour":    newRelativeTimeUnit("dans {0} h", "dans {0} h", "il y a {0} h", "il y a {0} h", "", "cette heure-ci", ""),
+		"minute":  newRelativeTimeUnit("dans {0} min", "dans {0} min", "il y a {0} min", "il y a {0} min", "", "cette minute-ci", ""),
+		"second":  newRelativeTimeUnit("dans {0} s", "dans {0} s", "il y a {0} s", "il y a {0} s", "", "maintenant", ""),
+	}),
+}
+
+type relativeTimeFormatObject struct {
+	baseObject
+	tag         language.Tag
+	style       string
+	numericAuto bool
+	units       map[string]*relativeTimeUnit
+}
+
+func (r *Runtime) toRelativeTimeFormat(v Value, method string) *relativeTimeFormatObject {
+	if obj, ok := v.(*Object); ok {
+		if f, ok := obj.self.(*relativeTimeFormatObject); ok {
+			return f
+		}
+	}
+	panic(r.NewTypeError("Method Intl.RelativeTimeFormat.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+type relativeTimePart struct {
+	typ, value, unit string
+}
+
+// formatToParts returns the parts of the formatted value. The number is split into the integer, group, decimal and
+// fraction parts, each of them carrying the unit.
+func (f *relativeTimeFormatObject) formatToParts(r *Runtime, value Value, unit Value) []relativeTimePart {
+	v := value.ToFloat()
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		panic(r.newError(r.global.RangeError, "Invalid value %s", value.String()))
+	}
+	u := unit.toString().String()
+	singular := strings.TrimSuffix(u, "s")
+	data := f.units[singular]
+	if data == nil {
+		panic(r.newError(r.global.RangeError, "Invalid unit argument for format() '%s'", u))
+	}
+	if f.numericAuto {
+		if phrase := data.phrase(v); phrase != "" {
+			return []relativeTimePart{{typ: "literal", value: phrase}}
+		}
+	}
+	patterns := data.future
+	if v < 0 || v == 0 && math.Signbit(v) {
+		patterns = data.past
+	}
+	abs := math.Abs(v)
+	pattern, ok := patterns[pluralCategory(f.tag, abs, false)]
+	if !ok {
+		pattern = patterns["other"]
+	}
+	p := message.NewPrinter(f.tag)
+	decimalSep := []rune(p.Sprint(number.Decimal(0.5)))[1]
+	var parts []relativeTimePart
+	idx := strings.Index(pattern, "{0}")
+	if idx > 0 {
+		parts = append(parts, relativeTimePart{typ: "literal", value: pattern[:idx]})
+	}
+	typ := "integer"
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			parts = append(parts, relativeTimePart{typ: typ, value: cur.String(), unit: singular})
+			cur.Reset()
+		}
+	}
+	for _, c := range p.Sprint(number.Decimal(abs)) {
+		if c >= '0' && c <= '9' {
+			cur.WriteRune(c)
+			continue
+		}
+		flush()
+		if c == decimalSep {
+			parts = append(parts, relativeTimePart{typ: "decimal", value: string(c), unit: singular})
+			typ = "fraction"
+		} else {
+			parts = append(parts, relativeTimePart{typ: "group", value: string(c), unit: singular})
+		}
+	}
+	flush()
+	if rest := pattern[idx+len("{0}"):]; rest != "" {
+		parts = append(parts, relativeTimePart{typ: "literal", value: rest})
+	}
+	return parts
+}
+
+func (f *relativeTimeFormatObject) format(r *Runtime, value Value, unit Value) string {
+	var b strings.Builder
+	for _, part := range f.formatToParts(r, value, unit) {
+		b.WriteString(part.value)
+	}
+	return b.String()
+}
+
+func (r *Runtime) newRelativeTimeFormatConstructor(def language.Tag) *Object {
+	supported := func(lang string) bool {
+		return relativeTimeData[lang] != nil
+	}
+	return r.newIntlConstructor("RelativeTimeFormat", "Intl.RelativeTimeFormat", 0, supported, func(args []Value, proto *Object) *Object {
+		o := &Object{runtime: r}
+		f := &relativeTimeFormatObject{}
+		var lang string
+		f.tag, lang = r.resolveSupportedLocale(argOrUndefined(args, 0), def, supported)
+		opts := r.intlOptions(argOrUndefined(args, 1))
+		f.style = r.getIntlOption(opts, "RelativeTimeFormat", "style", []string{"long", "short", "narrow"}, "long")
+		f.numericAuto = r.getIntlOption(opts, "RelativeTimeFormat", "numeric", []string{"always", "auto"}, "always") == "auto"
+		f.units = relativeTimeData[lang][f.style]
+		initHostObject(o, &f.baseObject, f, proto)
+		return o
+	}, func(proto *Object) {
+		r.putMethod(proto, "format", func(call FunctionCall) Value {
+			f := r.toRelativeTimeFormat(call.This, "format")
+			return newStringValue(f.format(r, call.Argument(0), call.Argument(1)))
+		}, 2)
+		r.putMethod(proto, "formatToParts", func(call FunctionCall) Value {
+			f := r.toRelativeTimeFormat(call.This, "formatToParts")
+			parts := f.formatToParts(r, call.Argument(0), call.Argument(1))
+			res := make([]Value, len(parts))
+			for i, part := range parts {
+				obj := r.NewObject()
+				obj.self._putProp("type", asciiString(part.typ), true, true, true)
+				obj.self._putProp("value", newStringValue(part.value), true, true, true)
+				if part.unit != "" {
+					obj.self._putProp("unit", asciiString(part.unit), true, true, true)
+				}
+				res[i] = obj
+			}
+			return r.newArrayValues(res)
+		}, 2)
+		r.putMethod(proto, "resolvedOptions", func(call FunctionCall) Value {
+			f := r.toRelativeTimeFormat(call.This, "resolvedOptions")
+			numeric := "always"
+			if f.numericAuto {
+				numeric = "auto"
+			}
+			return r.newResolvedOptions("locale", f.tag.String(), "style", f.style, "numeric", numeric, "numberingSystem", "latn")
+		}, 0)
+	})
+}
+
+// NewIntlNamespace creates an object with a subset of the ECMA-402 internationalization API, backed by
+// golang.org/x/text. goja does not provide a global Intl object because scripts commonly assume that if it exists
+// the whole API is available, so hosts that know their scripts only need the supported part can install it:
+//
+//	vm.Set("Intl", vm.NewIntlNamespace("en"))
+//
+// defaultLocale is used when no locale is passed to a constructor (or the requested one is not supported).
+// It also becomes the default locale of Date.prototype.toLocaleString() and the related methods, which use
+// en-US otherwise, and of String.prototype.toLocaleUpperCase() and toLocaleLowerCase(). The namespace contains:
+//
+//   - DateTimeFormat with the date and time component options, dateStyle, timeStyle, hour12, hourCycle and
+//     timeZone (any IANA name known to the time package), format(), formatToParts() and resolvedOptions(). Only the
+//     Gregorian calendar and the Latin digits are supported. The locale data covers English, German, Spanish,
+//     French, Italian, Dutch and Portuguese.
+//   - PluralRules with the type option (cardinal or ordinal), select() and resolvedOptions(), supporting all
+//     locales known to golang.org/x/text/feature/plural.
+//   - ListFormat with the type (conjunction, disjunction or unit) and style options, format(), formatToParts()
+//     and resolvedOptions(). The locale data covers English, German, French, Italian, Dutch and Portuguese.
+//   - RelativeTimeFormat with the style and numeric options, format(), formatToParts() and resolvedOptions(). The
+//     locale data covers English, German, Spanish and French.
+//   - Segmenter with the grapheme, word and sentence granularities. The segmentation follows the default rules of
+//     Unicode Standard Annex #29 with some simplifications (e.g. Han and Hiragana text is split into single
+//     characters for the word granularity, as there is no dictionary).
+//   - MessageFormat, see NewMessageFormatConstructor().
+//
+// The constructors have the static supportedLocalesOf() method which reports the requested locales covered by
+// their locale data. Numbers are formatted according to the locale.
+func (r *Runtime) NewIntlNamespace(defaultLocale string) *Object {
+	def, err := language.Parse(defaultLocale)
+	if err != nil {
+		def = language.English
+	}
+	r.intlLocale = def
+	o := r.NewObject()
+	o.self._putProp("DateTimeFormat", r.newDateTimeFormatConstructor(def), true, false, true)
+	o.self._putProp("PluralRules", r.newPluralRulesConstructor(def), true, false, true)
+	o.self._putProp("ListFormat", r.newListFormatConstructor(def), true, false, true)
+	o.self._putProp("RelativeTimeFormat", r.newRelativeTimeFormatConstructor(def), true, false, true)
+	o.self._putProp("Segmenter", r.newSegmenterConstructor(def), true, false, true)
+	o.self._putProp("MessageFormat", r.NewMessageFormatConstructor(defaultLocale), true, false, true)
+	o.self._putSym(SymToStringTag, valueProp(asciiString("Intl"), false, false, true))
+	return o
+}
@@ -0,0 +1,834 @@
+package goja
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/dop251/goja/unistring"
+)
+
+// Locale data of Intl.DateTimeFormat. The patterns use the CLDR syntax, see
+// https://unicode.org/reports/tr35/tr35-dates.html#Date_Field_Symbol_Table. textDate and numericDate contain all
+// the date fields, the ones that are not requested are removed together with the literal text that follows them.
+// The widths of the fields are adjusted to the options, except for the numeric month and day, which keep the
+// locale's width unless 2-digit is requested.
+type dateTimeLocale struct {
+	months, shortMonths     [12]string
+	weekdays, shortWeekdays [7]string
+	dayPeriods              [2]string
+	hour12                  bool
+	textDate, numericDate   string
+	// the full, long, medium and short date styles
+	dateStyles [4]string
+	// the separator between the date and the time, and the one used with the full and long date styles
+	dateTimeSep, styleDateTimeSep string
+}
+
+var (
+	englishMonths        = [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	englishShortMonths   = [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	englishWeekdays      = [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	englishShortWeekdays = [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+)
+
+var dateTimeFormatData = map[string]*dateTimeLocale{
+	"en": {
+		months:           englishMonths,
+		shortMonths:      englishShortMonths,
+		weekdays:         englishWeekdays,
+		shortWeekdays:    englishShortWeekdays,
+		dayPeriods:       [2]string{"AM", "PM"},
+		hour12:           true,
+		textDate:         "EEEE, MMMM d, y",
+		numericDate:      "EEEE, M/d/y",
+		dateStyles:       [4]string{"EEEE, MMMM d, y", "MMMM d, y", "MMM d, y", "M/d/yy"},
+		dateTimeSep:      ", ",
+		styleDateTimeSep: " 'at' ",
+	},
+	"en-GB": {
+		months:           englishMonths,
+		shortMonths:      englishShortMonths,
+		weekdays:         englishWeekdays,
+		shortWeekdays:    englishShortWeekdays,
+		dayPeriods:       [2]string{"am", "pm"},
+		textDate:         "EEEE d MMMM y",
+		numericDate:      "EEEE, dd/MM/y",
+		dateStyles:       [4]string{"EEEE d MMMM y", "d MMMM y", "d MMM y", "dd/MM/y"},
+		dateTimeSep:      ", ",
+		styleDateTimeSep: " 'at' ",
+	},
+	"de": {
+		months:           [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths:      [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		weekdays:         [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortWeekdays:    [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		dayPeriods:       [2]string{"AM", "PM"},
+		textDate:         "EEEE, d. MMMM y",
+		numericDate:      "EEEE, d.M.y",
+		dateStyles:       [4]string{"EEEE, d. MMMM y", "d. MMMM y", "dd.MM.y", "dd.MM.yy"},
+		dateTimeSep:      ", ",
+		styleDateTimeSep: " 'um' ",
+	},
+	"es": {
+		months:           [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths:      [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		weekdays:         [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortWeekdays:    [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		dayPeriods:       [2]string{"a. m.", "p. m."},
+		textDate:         "EEEE, d 'de' MMMM 'de' y",
+		numericDate:      "EEEE, d/M/y",
+		dateStyles:       [4]string{"EEEE, d 'de' MMMM 'de' y", "d 'de' MMMM 'de' y", "d MMM y", "d/M/yy"},
+		dateTimeSep:      ", ",
+		styleDateTimeSep: ", ",
+	},
+	"fr": {
+		months:           [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths:      [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		weekdays:         [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortWeekdays:    [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		dayPeriods:       [2]string{"AM", "PM"},
+		textDate:         "EEEE d MMMM y",
+		numericDate:      "EEEE dd/MM/y",
+		dateStyles:       [4]string{"EEEE d MMMM y", "d MMMM y", "d MMM y", "dd/MM/y"},
+		dateTimeSep:      " ",
+		styleDateTimeSep: " 'à' ",
+	},
+	"it": {
+		months:           [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths:      [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		weekdays:         [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		shortWeekdays:    [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		dayPeriods:       [2]string{"AM", "PM"},
+		textDate:         "EEEE d MMMM y",
+		numericDate:      "EEEE d/M/y",
+		dateStyles:       [4]string{"EEEE d MMMM y", "d MMMM y", "d MMM y", "dd/MM/yy"},
+		dateTimeSep:      ", ",
+		styleDateTimeSep: " 'alle ore' ",
+	},
+	"nl": {
+		months:           [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		shortMonths:      [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		weekdays:         [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		shortWeekdays:    [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		dayPeriods:       [2]string{"a.m.", "p.m."},
+		textDate:         "EEEE d MMMM y",
+		numericDate:      "EEEE d-M-y",
+		dateStyles:       [4]string{"EEEE d MMMM y", "d MMMM y", "d MMM y", "dd-MM-y"},
+		dateTimeSep:      " ",
+		styleDateTimeSep: " 'om' ",
+	},
+	"pt": {
+		months:           [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths:      [12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		weekdays:         [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		shortWeekdays:    [7]string{"dom.", "seg.", "ter.", "qua.", "qui.", "sex.", "sáb."},
+		dayPeriods:       [2]string{"AM", "PM"},
+		textDate:         "EEEE, d 'de' MMMM 'de' y",
+		numericDate:      "EEEE, dd/MM/y",
+		dateStyles:       [4]string{"EEEE, d 'de' MMMM 'de' y", "d 'de' MMMM 'de' y", "d 'de' MMM 'de' y", "dd/MM/y"},
+		dateTimeSep:      ", ",
+		styleDateTimeSep: " 'às' ",
+	},
+}
+
+var dateTimeStyles = []string{"full", "long", "medium", "short"}
+
+func dateTimeStyleIndex(style string) int {
+	for i, s := range dateTimeStyles {
+		if s == style {
+			return i
+		}
+	}
+	return -1
+}
+
+func dateTimeFormatSupported(lang string) bool {
+	return dateTimeFormatData[lang] != nil
+}
+
+// dateTimeLocaleData returns the locale data for the tag. English outside of the US uses the British data.
+func dateTimeLocaleData(tag language.Tag) *dateTimeLocale {
+	lang := localeLanguage(tag)
+	if lang == "en" {
+		if region, conf := tag.Region(); conf == language.Exact && region.String() != "US" {
+			return dateTimeFormatData["en-GB"]
+		}
+	}
+	return dateTimeFormatData[lang]
+}
+
+// dateTimePatternItem is a field (if letter is not 0) or a literal text of a pattern.
+type dateTimePatternItem struct {
+	letter byte
+	width  int
+	text   string
+}
+
+func isPatternLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func parseDateTimePattern(pattern string) []dateTimePatternItem {
+	var items []dateTimePatternItem
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			items = append(items, dateTimePatternItem{text: literal.String()})
+			literal.Reset()
+		}
+	}
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		switch {
+		case isPatternLetter(c):
+			flush()
+			j := i + 1
+			for j < len(pattern) && pattern[j] == c {
+				j++
+			}
+			items = append(items, dateTimePatternItem{letter: c, width: j - i})
+			i = j
+		case c == '\'':
+			if i+1 < len(pattern) && pattern[i+1] == '\'' {
+				literal.WriteByte('\'')
+				i += 2
+				continue
+			}
+			j := i + 1
+			for j < len(pattern) {
+				if pattern[j] == '\'' {
+					if j+1 < len(pattern) && pattern[j+1] == '\'' {
+						literal.WriteByte('\'')
+						j += 2
+						continue
+					}
+					break
+				}
+				literal.WriteByte(pattern[j])
+				j++
+			}
+			i = j + 1
+		default:
+			literal.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return items
+}
+
+// filterDateTimePattern keeps the fields for which width returns a non-zero width, together with the literal text
+// that follows each of them (except after the last one).
+func filterDateTimePattern(items []dateTimePatternItem, width func(letter byte, width int) int) []dateTimePatternItem {
+	var res []dateTimePatternItem
+	keep := false
+	for _, item := range items {
+		if item.letter != 0 {
+			if w := width(item.letter, item.width); w > 0 {
+				item.width = w
+				res = append(res, item)
+				keep = true
+			} else {
+				keep = false
+			}
+		} else if keep {
+			res = append(res, item)
+		}
+	}
+	if l := len(res); l > 0 && res[l-1].letter == 0 {
+		res = res[:l-1]
+	}
+	return res
+}
+
+type dateTimeFormatObject struct {
+	baseObject
+	tag      language.Tag
+	data     *dateTimeLocale
+	loc      *time.Location
+	timeZone string
+
+	hourCycle                                                     string
+	weekday, year, month, day, hour, minute, second, timeZoneName string
+	fractionalSecondDigits                                        int
+	dateStyle, timeStyle                                          string
+
+	items       []dateTimePatternItem
+	boundFormat *Object
+}
+
+func (r *Runtime) toDateTimeFormat(v Value, method string) *dateTimeFormatObject {
+	if obj, ok := v.(*Object); ok {
+		if f, ok := obj.self.(*dateTimeFormatObject); ok {
+			return f
+		}
+	}
+	panic(r.NewTypeError("Method Intl.DateTimeFormat.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+var unicodeTypeRegexp = regexp.MustCompile(`^[a-z0-9]{3,8}(-[a-z0-9]{3,8})*$`)
+
+// localTimeZoneName returns the IANA name of the local time zone, if it can be determined.
+func localTimeZoneName() string {
+	if name := time.Local.String(); name != "Local" {
+		return name
+	}
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		tz = strings.TrimPrefix(tz, ":")
+		if tz == "" {
+			return "UTC"
+		}
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz
+		}
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if i := strings.Index(target, "zoneinfo/"); i >= 0 {
+			return target[i+len("zoneinfo/"):]
+		}
+	}
+	return "UTC"
+}
+
+// localTimeZone returns the local time zone of the Runtime (see SetLocation) and its name.
+func (r *Runtime) localTimeZone() (*time.Location, string) {
+	loc := r.location()
+	if loc == time.Local {
+		return loc, localTimeZoneName()
+	}
+	name := loc.String()
+	if name == "" {
+		// an unnamed fixed zone
+		_, offset := time.Now().In(loc).Zone()
+		sign := byte('+')
+		if offset < 0 {
+			sign, offset = '-', -offset
+		}
+		name = fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset/60%60)
+	}
+	return loc, name
+}
+
+var timeZoneOffsetRegexp = regexp.MustCompile(`^([+-])([01]\d|2[0-3]):?([0-5]\d)$`)
+
+func (r *Runtime) resolveTimeZone(v Value) (*time.Location, string) {
+	if v == nil || v == _undefined {
+		return r.localTimeZone()
+	}
+	name := v.toString().String()
+	switch strings.ToUpper(name) {
+	case "UTC", "ETC/UTC", "GMT", "ETC/GMT":
+		return time.UTC, "UTC"
+	}
+	if m := timeZoneOffsetRegexp.FindStringSubmatch(name); m != nil {
+		h, _ := strconv.Atoi(m[2])
+		min, _ := strconv.Atoi(m[3])
+		offset := h*3600 + min*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		name = m[1] + m[2] + ":" + m[3]
+		return time.FixedZone(name, offset), name
+	}
+	if strings.HasPrefix(name, ".") || strings.Contains(name, "..") {
+		panic(r.newError(r.global.RangeError, "Invalid time zone specified: %s", name))
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" || name == "Local" {
+		panic(r.newError(r.global.RangeError, "Invalid time zone specified: %s", name))
+	}
+	return loc, loc.String()
+}
+
+func (r *Runtime) getIntlValue(options *Object, name string) Value {
+	if options == nil {
+		return nil
+	}
+	if v := options.self.getStr(unistring.NewFromString(name), nil); v != nil && v != _undefined {
+		return v
+	}
+	return nil
+}
+
+// newDateTimeFormat creates an Intl.DateTimeFormat without the JavaScript object. required and defaults are the
+// parameters of ToDateTimeOptions (https://402.ecma-international.org/#sec-todatetimeoptions), so that it can be
+// used by the locale-sensitive methods of Date.
+func (r *Runtime) newDateTimeFormat(locales, options Value, required, defaults string, def language.Tag) *dateTimeFormatObject {
+	f := &dateTimeFormatObject{}
+	f.tag = r.resolveLocale(locales, def, dateTimeFormatSupported)
+	if f.data = dateTimeLocaleData(f.tag); f.data == nil {
+		f.tag = def
+		if f.data = dateTimeLocaleData(def); f.data == nil {
+			f.tag, f.data = language.English, dateTimeFormatData["en"]
+		}
+	}
+	opts := r.intlOptions(options)
+
+	for _, name := range [...]string{"calendar", "numberingSystem"} {
+		if v := r.getIntlValue(opts, name); v != nil {
+			if !unicodeTypeRegexp.MatchString(v.toString().String()) {
+				panic(r.newError(r.global.RangeError, "Invalid %s : %s", name, v.String()))
+			}
+		}
+	}
+	hour12 := r.getIntlValue(opts, "hour12")
+	hourCycle := r.getIntlOption(opts, "DateTimeFormat", "hourCycle", []string{"h11", "h12", "h23", "h24"}, "")
+	f.loc, f.timeZone = r.resolveTimeZone(r.getIntlValue(opts, "timeZone"))
+
+	textValues := []string{"narrow", "short", "long"}
+	numericValues := []string{"2-digit", "numeric"}
+	f.weekday = r.getIntlOption(opts, "DateTimeFormat", "weekday", textValues, "")
+	f.year = r.getIntlOption(opts, "DateTimeFormat", "year", numericValues, "")
+	f.month = r.getIntlOption(opts, "DateTimeFormat", "month", []string{"2-digit", "numeric", "narrow", "short", "long"}, "")
+	f.day = r.getIntlOption(opts, "DateTimeFormat", "day", numericValues, "")
+	f.hour = r.getIntlOption(opts, "DateTimeFormat", "hour", numericValues, "")
+	f.minute = r.getIntlOption(opts, "DateTimeFormat", "minute", numericValues, "")
+	f.second = r.getIntlOption(opts, "DateTimeFormat", "second", numericValues, "")
+	if v := r.getIntlValue(opts, "fractionalSecondDigits"); v != nil {
+		n := v.ToFloat()
+		if math.IsNaN(n) || n < 1 || n > 3 {
+			panic(r.newError(r.global.RangeError, "fractionalSecondDigits value is out of range."))
+		}
+		f.fractionalSecondDigits = int(n)
+	}
+	f.timeZoneName = r.getIntlOption(opts, "DateTimeFormat", "timeZoneName", []string{"short", "long"}, "")
+	f.dateStyle = r.getIntlOption(opts, "DateTimeFormat", "dateStyle", dateTimeStyles, "")
+	f.timeStyle = r.getIntlOption(opts, "DateTimeFormat", "timeStyle", dateTimeStyles, "")
+
+	hasDate := f.weekday != "" || f.year != "" || f.month != "" || f.day != ""
+	hasTime := f.hour != "" || f.minute != "" || f.second != "" || f.fractionalSecondDigits != 0
+	if f.dateStyle != "" || f.timeStyle != "" {
+		style := "dateStyle"
+		if f.dateStyle == "" {
+			style = "timeStyle"
+		}
+		for _, o := range [...]struct{ name, value string }{{"weekday", f.weekday}, {"year", f.year}, {"month", f.month},
+			{"day", f.day}, {"hour", f.hour}, {"minute", f.minute}, {"second", f.second}, {"timeZoneName", f.timeZoneName}} {
+			if o.value != "" {
+				panic(r.NewTypeError("Can't set option %s when %s is used", o.name, style))
+			}
+		}
+		if f.fractionalSecondDigits != 0 {
+			panic(r.NewTypeError("Can't set option fractionalSecondDigits when %s is used", style))
+		}
+		if required == "date" && f.timeStyle != "" {
+			panic(r.NewTypeError("Invalid option : timeStyle"))
+		}
+		if required == "time" && f.dateStyle != "" {
+			panic(r.NewTypeError("Invalid option : dateStyle"))
+		}
+	} else {
+		needDefaults := true
+		if (required == "date" || required == "any") && hasDate || (required == "time" || required == "any") && hasTime {
+			needDefaults = false
+		}
+		if needDefaults {
+			if defaults == "date" || defaults == "all" {
+				f.year, f.month, f.day = "numeric", "numeric", "numeric"
+			}
+			if defaults == "time" || defaults == "all" {
+				f.hour, f.minute, f.second = "numeric", "numeric", "numeric"
+			}
+		}
+	}
+
+	if f.hour != "" || f.timeStyle != "" {
+		switch {
+		case hour12 != nil && hour12.ToBoolean():
+			f.hourCycle = "h12"
+		case hour12 != nil:
+			f.hourCycle = "h23"
+		case hourCycle != "":
+			f.hourCycle = hourCycle
+		case f.data.hour12:
+			f.hourCycle = "h12"
+		default:
+			f.hourCycle = "h23"
+		}
+	}
+
+	f.items = parseDateTimePattern(f.pattern())
+	return f
+}
+
+func (f *dateTimeFormatObject) datePattern() []dateTimePatternItem {
+	if f.dateStyle != "" {
+		return parseDateTimePattern(f.data.dateStyles[dateTimeStyleIndex(f.dateStyle)])
+	}
+	if f.weekday == "" && f.year == "" && f.month == "" && f.day == "" {
+		return nil
+	}
+	textual := f.month == "long" || f.month == "short" || f.month == "narrow"
+	tmpl := f.data.numericDate
+	if textual {
+		tmpl = f.data.textDate
+	}
+	return filterDateTimePattern(parseDateTimePattern(tmpl), func(letter byte, width int) int {
+		switch letter {
+		case 'E':
+			switch f.weekday {
+			case "long":
+				return 4
+			case "short":
+				return 3
+			case "narrow":
+				return 5
+			}
+		case 'y':
+			switch f.year {
+			case "numeric":
+				return 1
+			case "2-digit":
+				return 2
+			}
+		case 'M':
+			switch f.month {
+			case "long":
+				return 4
+			case "short":
+				return 3
+			case "narrow":
+				return 5
+			case "2-digit":
+				return 2
+			case "numeric":
+				return width
+			}
+		case 'd':
+			switch f.day {
+			case "2-digit":
+				return 2
+			case "numeric":
+				return width
+			}
+		}
+		return 0
+	})
+}
+
+func (f *dateTimeFormatObject) timePattern() string {
+	hour, minute, second, tzName := f.hour, f.minute, f.second, f.timeZoneName
+	if f.timeStyle != "" {
+		hour, minute = "numeric", "2-digit"
+		idx := dateTimeStyleIndex(f.timeStyle)
+		if idx <= 2 {
+			second = "2-digit"
+		}
+		switch idx {
+		case 0:
+			tzName = "long"
+		case 1:
+			tzName = "short"
+		}
+	}
+	var b strings.Builder
+	if hour != "" {
+		var letter byte
+		width := 1
+		switch f.hourCycle {
+		case "h11":
+			letter = 'K'
+		case "h12":
+			letter = 'h'
+		case "h23":
+			letter, width = 'H', 2
+		case "h24":
+			letter, width = 'k', 2
+		}
+		if hour == "2-digit" {
+			width = 2
+		}
+		b.WriteString(strings.Repeat(string(letter), width))
+	}
+	if minute != "" {
+		if b.Len() > 0 {
+			b.WriteString(":mm")
+		} else if minute == "2-digit" {
+			b.WriteString("mm")
+		} else {
+			b.WriteString("m")
+		}
+	}
+	if second != "" {
+		if b.Len() > 0 {
+			b.WriteString(":ss")
+		} else if second == "2-digit" {
+			b.WriteString("ss")
+		} else {
+			b.WriteString("s")
+		}
+	}
+	if f.fractionalSecondDigits > 0 {
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(strings.Repeat("S", f.fractionalSecondDigits))
+	}
+	if hour != "" && (f.hourCycle == "h11" || f.hourCycle == "h12") {
+		b.WriteString(" a")
+	}
+	if tzName != "" {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		if tzName == "long" {
+			b.WriteString("zzzz")
+		} else {
+			b.WriteString("z")
+		}
+	}
+	return b.String()
+}
+
+func (f *dateTimeFormatObject) pattern() string {
+	var b strings.Builder
+	for _, item := range f.datePattern() {
+		if item.letter != 0 {
+			b.WriteString(strings.Repeat(string(item.letter), item.width))
+		} else {
+			b.WriteByte('\'')
+			b.WriteString(strings.Replace(item.text, "'", "''", -1))
+			b.WriteByte('\'')
+		}
+	}
+	if t := f.timePattern(); t != "" {
+		if b.Len() > 0 {
+			if f.timeStyle != "" && (f.dateStyle == "full" || f.dateStyle == "long") {
+				b.WriteString(f.data.styleDateTimeSep)
+			} else {
+				b.WriteString(f.data.dateTimeSep)
+			}
+		}
+		b.WriteString(t)
+	}
+	return b.String()
+}
+
+func padNumber(n, width int) string {
+	s := strconv.Itoa(n)
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
+
+func firstLetter(s string) string {
+	for _, c := range s {
+		return strings.ToUpper(string(c))
+	}
+	return ""
+}
+
+func isASCIIAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !(s[i] >= 'A' && s[i] <= 'Z' || s[i] >= 'a' && s[i] <= 'z') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// timeZoneDisplayName returns the name of the time zone at t. Go only knows the abbreviations, so the long names
+// (other than for UTC) and the abbreviations which are numeric use the localised GMT format.
+func timeZoneDisplayName(t time.Time, long bool) string {
+	name, offset := t.Zone()
+	if t.Location() == time.UTC || name == "UTC" {
+		if long {
+			return "Coordinated Universal Time"
+		}
+		return "UTC"
+	}
+	if !long && isASCIIAlpha(name) {
+		return name
+	}
+	if offset == 0 {
+		return "GMT"
+	}
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	h, m := offset/3600, offset%3600/60
+	if long {
+		return "GMT" + sign + padNumber(h, 2) + ":" + padNumber(m, 2)
+	}
+	if m != 0 {
+		return "GMT" + sign + strconv.Itoa(h) + ":" + padNumber(m, 2)
+	}
+	return "GMT" + sign + strconv.Itoa(h)
+}
+
+type dateTimePart struct {
+	typ, value string
+}
+
+func (f *dateTimeFormatObject) formatToParts(msec int64) []dateTimePart {
+	t := timeFromMsec(msec).In(f.loc)
+	var parts []dateTimePart
+	add := func(typ, value string) {
+		if typ == "literal" && len(parts) > 0 && parts[len(parts)-1].typ == "literal" {
+			parts[len(parts)-1].value += value
+			return
+		}
+		parts = append(parts, dateTimePart{typ: typ, value: value})
+	}
+	for _, item := range f.items {
+		switch item.letter {
+		case 0:
+			add("literal", item.text)
+		case 'E':
+			name := f.data.weekdays[t.Weekday()]
+			switch item.width {
+			case 5:
+				name = firstLetter(name)
+			case 1, 2, 3:
+				name = f.data.shortWeekdays[t.Weekday()]
+			}
+			add("weekday", name)
+		case 'y':
+			year := t.Year()
+			if item.width == 2 {
+				if year < 0 {
+					year = -year
+				}
+				add("year", padNumber(year%100, 2))
+			} else {
+				add("year", strconv.Itoa(year))
+			}
+		case 'M':
+			month := int(t.Month())
+			switch item.width {
+			case 1, 2:
+				add("month", padNumber(month, item.width))
+			case 3:
+				add("month", f.data.shortMonths[month-1])
+			case 4:
+				add("month", f.data.months[month-1])
+			default:
+				add("month", firstLetter(f.data.months[month-1]))
+			}
+		case 'd':
+			add("day", padNumber(t.Day(), item.width))
+		case 'h', 'H', 'K', 'k':
+			h := t.Hour()
+			switch item.letter {
+			case 'h':
+				if h = h % 12; h == 0 {
+					h = 12
+				}
+			case 'K':
+				h = h % 12
+			case 'k':
+				if h == 0 {
+					h = 24
+				}
+			}
+			add("hour", padNumber(h, item.width))
+		case 'm':
+			add("minute", padNumber(t.Minute(), item.width))
+		case 's':
+			add("second", padNumber(t.Second(), item.width))
+		case 'S':
+			add("fractionalSecond", padNumber(t.Nanosecond()/1e6, 3)[:item.width])
+		case 'a':
+			if t.Hour() < 12 {
+				add("dayPeriod", f.data.dayPeriods[0])
+			} else {
+				add("dayPeriod", f.data.dayPeriods[1])
+			}
+		case 'z':
+			add("timeZoneName", timeZoneDisplayName(t, item.width >= 4))
+		}
+	}
+	return parts
+}
+
+func (f *dateTimeFormatObject) format(msec int64) string {
+	var b strings.Builder
+	for _, part := range f.formatToParts(msec) {
+		b.WriteString(part.value)
+	}
+	return b.String()
+}
+
+// toDateTimeFormatTime converts the argument of format() and formatToParts() into a time value.
+func (r *Runtime) toDateTimeFormatTime(v Value) int64 {
+	if v == _undefined {
+		return timeToMsec(r.now())
+	}
+	n := v.ToFloat()
+	if math.IsNaN(n) || math.Abs(n) > maxTime {
+		panic(r.newError(r.global.RangeError, "Invalid time value"))
+	}
+	return int64(n)
+}
+
+func (r *Runtime) newDateTimeFormatConstructor(def language.Tag) *Object {
+	return r.newIntlConstructor("DateTimeFormat", "Intl.DateTimeFormat", 0, dateTimeFormatSupported, func(args []Value, proto *Object) *Object {
+		o := &Object{runtime: r}
+		f := r.newDateTimeFormat(argOrUndefined(args, 0), argOrUndefined(args, 1), "any", "date", def)
+		initHostObject(o, &f.baseObject, f, proto)
+		return o
+	}, func(proto *Object) {
+		proto.self.setOwnStr("format", &valueProperty{
+			getterFunc: r.newNativeFunc(func(call FunctionCall) Value {
+				f := r.toDateTimeFormat(call.This, "format")
+				if f.boundFormat == nil {
+					f.boundFormat = r.newNativeFunc(func(call FunctionCall) Value {
+						return newStringValue(f.format(r.toDateTimeFormatTime(call.Argument(0))))
+					}, nil, "", nil, 1)
+				}
+				return f.boundFormat
+			}, nil, "get format", nil, 0),
+			accessor:     true,
+			writable:     true,
+			configurable: true,
+		}, true)
+		r.putMethod(proto, "formatToParts", func(call FunctionCall) Value {
+			f := r.toDateTimeFormat(call.This, "formatToParts")
+			parts := f.formatToParts(r.toDateTimeFormatTime(call.Argument(0)))
+			res := make([]Value, len(parts))
+			for i, part := range parts {
+				obj := r.NewObject()
+				obj.self._putProp("type", asciiString(part.typ), true, true, true)
+				obj.self._putProp("value", newStringValue(part.value), true, true, true)
+				res[i] = obj
+			}
+			return r.newArrayValues(res)
+		}, 1)
+		r.putMethod(proto, "resolvedOptions", func(call FunctionCall) Value {
+			f := r.toDateTimeFormat(call.This, "resolvedOptions")
+			props := []string{"locale", f.tag.String(), "calendar", "gregory", "numberingSystem", "latn", "timeZone", f.timeZone}
+			if f.hourCycle != "" {
+				props = append(props, "hourCycle", f.hourCycle)
+			}
+			res := r.newResolvedOptions(props...)
+			if f.hourCycle != "" {
+				res.self._putProp("hour12", r.toBoolean(f.hourCycle == "h11" || f.hourCycle == "h12"), true, true, true)
+			}
+			for _, o := range [...]struct{ name, value string }{{"weekday", f.weekday}, {"year", f.year}, {"month", f.month},
+				{"day", f.day}, {"hour", f.hour}, {"minute", f.minute}, {"second", f.second}} {
+				if o.value != "" {
+					res.self._putProp(unistring.NewFromString(o.name), asciiString(o.value), true, true, true)
+				}
+			}
+			if f.fractionalSecondDigits != 0 {
+				res.self._putProp("fractionalSecondDigits", intToValue(int64(f.fractionalSecondDigits)), true, true, true)
+			}
+			for _, o := range [...]struct{ name, value string }{{"timeZoneName", f.timeZoneName}, {"dateStyle", f.dateStyle}, {"timeStyle", f.timeStyle}} {
+				if o.value != "" {
+					res.self._putProp(unistring.NewFromString(o.name), asciiString(o.value), true, true, true)
+				}
+			}
+			return res
+		}, 0)
+	})
+}
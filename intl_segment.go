@@ -0,0 +1,604 @@
+package goja
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// Grapheme cluster break property values, see https://unicode.org/reports/tr29/#Grapheme_Cluster_Break_Property_Values
+const (
+	gbOther = iota
+	gbCR
+	gbLF
+	gbControl
+	gbExtend
+	gbZWJ
+	gbRegionalIndicator
+	gbSpacingMark
+	gbL
+	gbV
+	gbT
+	gbLV
+	gbLVT
+)
+
+func isRegionalIndicator(c rune) bool {
+	return c >= 0x1F1E6 && c <= 0x1F1FF
+}
+
+func isEmojiModifier(c rune) bool {
+	return c >= 0x1F3FB && c <= 0x1F3FF
+}
+
+// isExtendedPictographic approximates the Extended_Pictographic property with the blocks where it's assigned.
+func isExtendedPictographic(c rune) bool {
+	switch {
+	case c < 0xA9:
+		return false
+	case c == 0xA9, c == 0xAE, c == 0x203C, c == 0x2049, c == 0x2122, c == 0x2139,
+		c >= 0x2194 && c <= 0x2199, c == 0x21A9, c == 0x21AA, c == 0x231A, c == 0x231B, c == 0x2328, c == 0x23CF,
+		c >= 0x23E9 && c <= 0x23F3, c >= 0x23F8 && c <= 0x23FA, c == 0x24C2, c == 0x25AA, c == 0x25AB,
+		c == 0x25B6, c == 0x25C0, c >= 0x25FB && c <= 0x25FE, c >= 0x2600 && c <= 0x27BF, c == 0x2934, c == 0x2935,
+		c >= 0x2B05 && c <= 0x2B07, c == 0x2B1B, c == 0x2B1C, c == 0x2B50, c == 0x2B55, c == 0x3030, c == 0x303D,
+		c == 0x3297, c == 0x3299:
+		return true
+	case c >= 0x1F000 && c <= 0x1FAFF:
+		return !isRegionalIndicator(c) && !isEmojiModifier(c)
+	case c >= 0x1FC00 && c <= 0x1FFFD:
+		return true
+	}
+	return false
+}
+
+func graphemeBreakProperty(c rune) int {
+	switch {
+	case c == '\r':
+		return gbCR
+	case c == '\n':
+		return gbLF
+	case c == 0x200D:
+		return gbZWJ
+	case c == 0x200C, isEmojiModifier(c), c >= 0xE0020 && c <= 0xE007F:
+		return gbExtend
+	case c < 0x20, c >= 0x7F && c < 0xA0, c == 0x2028, c == 0x2029:
+		return gbControl
+	case c < 0x300:
+		return gbOther
+	case isRegionalIndicator(c):
+		return gbRegionalIndicator
+	case c >= 0x1100 && c <= 0x115F, c >= 0xA960 && c <= 0xA97C:
+		return gbL
+	case c >= 0x1160 && c <= 0x11A7, c >= 0xD7B0 && c <= 0xD7C6:
+		return gbV
+	case c >= 0x11A8 && c <= 0x11FF, c >= 0xD7CB && c <= 0xD7FB:
+		return gbT
+	case c >= 0xAC00 && c <= 0xD7A3:
+		if (c-0xAC00)%28 == 0 {
+			return gbLV
+		}
+		return gbLVT
+	case unicode.In(c, unicode.Mn, unicode.Me):
+		return gbExtend
+	case unicode.Is(unicode.Mc, c):
+		return gbSpacingMark
+	case unicode.Is(unicode.Cf, c):
+		return gbControl
+	}
+	return gbOther
+}
+
+// graphemeBoundaries returns the rune indices of the extended grapheme cluster boundaries, including 0 and
+// len(runes).
+func graphemeBoundaries(runes []rune) []int {
+	bounds := []int{0}
+	if len(runes) == 0 {
+		return bounds
+	}
+	prev := graphemeBreakProperty(runes[0])
+	pict := isExtendedPictographic(runes[0]) // the text so far ends with ExtPict Extend*
+	zwjPict := false                         // the text so far ends with ExtPict Extend* ZWJ
+	ri := 0                                  // the number of trailing regional indicators
+	if prev == gbRegionalIndicator {
+		ri = 1
+	}
+	for i := 1; i < len(runes); i++ {
+		cur := graphemeBreakProperty(runes[i])
+		var brk bool
+		switch {
+		case prev == gbCR && cur == gbLF:
+		case prev == gbCR, prev == gbLF, prev == gbControl, cur == gbCR, cur == gbLF, cur == gbControl:
+			brk = true
+		case prev == gbL && (cur == gbL || cur == gbV || cur == gbLV || cur == gbLVT):
+		case (prev == gbLV || prev == gbV) && (cur == gbV || cur == gbT):
+		case (prev == gbLVT || prev == gbT) && cur == gbT:
+		case cur == gbExtend, cur == gbZWJ, cur == gbSpacingMark:
+		case zwjPict && isExtendedPictographic(runes[i]):
+		case prev == gbRegionalIndicator && cur == gbRegionalIndicator && ri%2 == 1:
+		default:
+			brk = true
+		}
+		if brk {
+			bounds = append(bounds, i)
+		}
+		zwjPict = cur == gbZWJ && pict
+		if isExtendedPictographic(runes[i]) {
+			pict = true
+		} else if cur != gbExtend {
+			pict = false
+		}
+		if cur == gbRegionalIndicator {
+			ri++
+		} else {
+			ri = 0
+		}
+		prev = cur
+	}
+	return append(bounds, len(runes))
+}
+
+// Word break property values (simplified), see https://unicode.org/reports/tr29/#Word_Break_Property_Values
+const (
+	wbOther = iota
+	wbCR
+	wbLF
+	wbNewline
+	wbExtend
+	wbZWJ
+	wbRegionalIndicator
+	wbKatakana
+	wbALetter
+	wbSingleQuote
+	wbMidNumLet
+	wbMidLetter
+	wbMidNum
+	wbNumeric
+	wbExtendNumLet
+	wbWSegSpace
+	// Han and Hiragana: not part of the default rules, each character is a separate word-like segment
+	wbIdeographic
+)
+
+func wordBreakProperty(c rune) int {
+	switch c {
+	case '\r':
+		return wbCR
+	case '\n':
+		return wbLF
+	case 0x0B, 0x0C, 0x85, 0x2028, 0x2029:
+		return wbNewline
+	case 0x200D:
+		return wbZWJ
+	case '\'':
+		return wbSingleQuote
+	case '.', 0x2018, 0x2019, 0x2024, 0xFE52, 0xFF07, 0xFF0E:
+		return wbMidNumLet
+	case ':', 0xB7, 0x387, 0x5F4, 0x2027, 0xFE13, 0xFE55, 0xFF1A:
+		return wbMidLetter
+	case ',', ';', 0x37E, 0x589, 0x60C, 0x60D, 0x66C, 0x7F8, 0x2044, 0xFE10, 0xFE14, 0xFE50, 0xFE54, 0xFF0C, 0xFF1B:
+		return wbMidNum
+	case ' ', 0x1680, 0x205F, 0x3000:
+		return wbWSegSpace
+	case 0x202F:
+		return wbExtendNumLet
+	case 0x30FC:
+		return wbKatakana
+	}
+	switch {
+	case c < utf8.RuneSelf:
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+			return wbALetter
+		case '0' <= c && c <= '9':
+			return wbNumeric
+		case c == '_':
+			return wbExtendNumLet
+		}
+		return wbOther
+	case c >= 0x2000 && c <= 0x200A && c != 0x2007:
+		return wbWSegSpace
+	case isRegionalIndicator(c):
+		return wbRegionalIndicator
+	case isEmojiModifier(c), c == 0x200C, unicode.In(c, unicode.Mn, unicode.Me, unicode.Mc):
+		return wbExtend
+	case unicode.Is(unicode.Cf, c) && c != 0x200B:
+		return wbExtend
+	case unicode.Is(unicode.Katakana, c):
+		return wbKatakana
+	case unicode.In(c, unicode.Han, unicode.Hiragana):
+		return wbIdeographic
+	case unicode.IsLetter(c):
+		return wbALetter
+	case unicode.Is(unicode.Nd, c):
+		return wbNumeric
+	case unicode.Is(unicode.Pc, c):
+		return wbExtendNumLet
+	}
+	return wbOther
+}
+
+func isAHLetter(p int) bool {
+	return p == wbALetter
+}
+
+func isMidNumLetQ(p int) bool {
+	return p == wbMidNumLet || p == wbSingleQuote
+}
+
+func isWordLikeProperty(p int) bool {
+	return p == wbALetter || p == wbNumeric || p == wbKatakana || p == wbIdeographic
+}
+
+// wordBoundaries returns the rune indices of the word boundaries, including 0 and len(runes), and for each
+// segment whether it contains letters, digits or ideographs.
+func wordBoundaries(runes []rune) ([]int, []bool) {
+	bounds := []int{0}
+	var wordLike []bool
+	if len(runes) == 0 {
+		return bounds, wordLike
+	}
+	props := make([]int, len(runes))
+	for i, c := range runes {
+		props[i] = wordBreakProperty(c)
+	}
+	// the runes which are not ignored by WB4
+	base := make([]int, 0, len(runes))
+	for i, p := range props {
+		if i > 0 && (p == wbExtend || p == wbZWJ) {
+			if last := props[base[len(base)-1]]; last != wbCR && last != wbLF && last != wbNewline {
+				continue
+			}
+		}
+		base = append(base, i)
+	}
+	prop := func(k int) int {
+		if k < 0 || k >= len(base) {
+			return -1
+		}
+		return props[base[k]]
+	}
+	isWordLike := isWordLikeProperty(props[0])
+	ri := 0
+	if props[0] == wbRegionalIndicator {
+		ri = 1
+	}
+	for k := 1; k < len(base); k++ {
+		i := base[k]
+		prev2, prev, cur, next := prop(k-2), prop(k-1), prop(k), prop(k+1)
+		var brk bool
+		switch {
+		case prev == wbCR && cur == wbLF:
+		case prev == wbCR, prev == wbLF, prev == wbNewline, cur == wbCR, cur == wbLF, cur == wbNewline:
+			brk = true
+		case props[i-1] == wbZWJ && isExtendedPictographic(runes[i]):
+		case prev == wbWSegSpace && cur == wbWSegSpace && base[k-1] == i-1:
+		case isAHLetter(prev) && isAHLetter(cur):
+		case isAHLetter(prev) && (cur == wbMidLetter || isMidNumLetQ(cur)) && isAHLetter(next):
+		case isAHLetter(prev2) && (prev == wbMidLetter || isMidNumLetQ(prev)) && isAHLetter(cur):
+		case (prev == wbNumeric || isAHLetter(prev)) && (cur == wbNumeric || isAHLetter(cur)):
+		case prev2 == wbNumeric && (prev == wbMidNum || isMidNumLetQ(prev)) && cur == wbNumeric:
+		case prev == wbNumeric && (cur == wbMidNum || isMidNumLetQ(cur)) && next == wbNumeric:
+		case prev == wbKatakana && cur == wbKatakana:
+		case (isAHLetter(prev) || prev == wbNumeric || prev == wbKatakana || prev == wbExtendNumLet) && cur == wbExtendNumLet:
+		case prev == wbExtendNumLet && (isAHLetter(cur) || cur == wbNumeric || cur == wbKatakana):
+		case prev == wbRegionalIndicator && cur == wbRegionalIndicator && ri%2 == 1:
+		default:
+			brk = true
+		}
+		if brk {
+			bounds = append(bounds, i)
+			wordLike = append(wordLike, isWordLike)
+			isWordLike = false
+		}
+		if isWordLikeProperty(cur) {
+			isWordLike = true
+		}
+		if cur == wbRegionalIndicator {
+			ri++
+		} else {
+			ri = 0
+		}
+	}
+	return append(bounds, len(runes)), append(wordLike, isWordLike)
+}
+
+// Sentence break property values (simplified), see https://unicode.org/reports/tr29/#Sentence_Break_Property_Values
+const (
+	sbOther = iota
+	sbCR
+	sbLF
+	sbSep
+	sbExtend
+	sbSp
+	sbLower
+	sbUpper
+	sbOLetter
+	sbNumeric
+	sbATerm
+	sbSTerm
+	sbClose
+	sbSContinue
+)
+
+func sentenceBreakProperty(c rune) int {
+	switch c {
+	case '\r':
+		return sbCR
+	case '\n':
+		return sbLF
+	case 0x85, 0x2028, 0x2029:
+		return sbSep
+	case '\t', 0x0B, 0x0C:
+		return sbSp
+	case '.', 0x2024, 0xFE52, 0xFF0E:
+		return sbATerm
+	case '!', '?', 0x589, 0x61F, 0x6D4, 0x700, 0x701, 0x702, 0x7F9, 0x964, 0x965, 0x203C, 0x203D, 0x2047, 0x2048,
+		0x2049, 0x3002, 0xFE56, 0xFE57, 0xFF01, 0xFF1F, 0xFF61:
+		return sbSTerm
+	case '"', '\'':
+		return sbClose
+	case ',', '-', ':', ';', 0x55D, 0x60C, 0x60D, 0x7F8, 0x1802, 0x1808, 0x2013, 0x2014, 0x3001, 0xFE10, 0xFE11,
+		0xFE13, 0xFE31, 0xFE32, 0xFE50, 0xFE51, 0xFE55, 0xFE58, 0xFE63, 0xFF0C, 0xFF0D, 0xFF1A, 0xFF1B, 0xFF64:
+		return sbSContinue
+	}
+	switch {
+	case unicode.IsLower(c):
+		return sbLower
+	case unicode.IsUpper(c), unicode.IsTitle(c):
+		return sbUpper
+	case unicode.IsLetter(c):
+		return sbOLetter
+	case unicode.Is(unicode.Nd, c):
+		return sbNumeric
+	case unicode.Is(unicode.Zs, c):
+		return sbSp
+	case unicode.In(c, unicode.Mn, unicode.Me, unicode.Mc, unicode.Cf):
+		return sbExtend
+	case unicode.In(c, unicode.Ps, unicode.Pe, unicode.Pi, unicode.Pf):
+		return sbClose
+	}
+	return sbOther
+}
+
+// sentenceBoundaries returns the rune indices of the sentence boundaries, including 0 and len(runes).
+func sentenceBoundaries(runes []rune) []int {
+	bounds := []int{0}
+	props := make([]int, len(runes))
+	for i, c := range runes {
+		props[i] = sentenceBreakProperty(c)
+	}
+	// skip returns the index of the first rune starting at i which is not ignored by SB5
+	skip := func(i int) int {
+		for i < len(props) && props[i] == sbExtend {
+			i++
+		}
+		return i
+	}
+	prop := func(i int) int {
+		if i >= len(props) {
+			return -1
+		}
+		return props[i]
+	}
+	// paraSep consumes a paragraph separator at i, if there is one
+	paraSep := func(i int) int {
+		switch prop(i) {
+		case sbCR:
+			if prop(i+1) == sbLF {
+				return i + 2
+			}
+			return i + 1
+		case sbLF, sbSep:
+			return i + 1
+		}
+		return i
+	}
+	lastBase := -1
+	for i := 0; i < len(props); {
+		if j := paraSep(i); j > i {
+			if j < len(props) {
+				bounds = append(bounds, j)
+			}
+			lastBase = -1
+			i = j
+			continue
+		}
+		p := props[i]
+		if p != sbATerm && p != sbSTerm {
+			if p != sbExtend {
+				lastBase = p
+			}
+			i++
+			continue
+		}
+		j := skip(i + 1)
+		if p == sbATerm {
+			if prop(j) == sbNumeric || prop(j) == sbUpper && (lastBase == sbUpper || lastBase == sbLower) {
+				lastBase = p
+				i = j
+				continue
+			}
+		}
+		for prop(j) == sbClose {
+			j = skip(j + 1)
+		}
+		for prop(j) == sbSp {
+			j = skip(j + 1)
+		}
+		if p == sbATerm {
+			k := j
+			for k < len(props) {
+				switch props[k] {
+				case sbOLetter, sbUpper, sbLower, sbSep, sbCR, sbLF, sbATerm, sbSTerm:
+				default:
+					k++
+					continue
+				}
+				break
+			}
+			if prop(k) == sbLower {
+				lastBase = p
+				i = j
+				continue
+			}
+		}
+		if q := prop(j); q == sbSContinue || q == sbATerm || q == sbSTerm {
+			lastBase = p
+			i = j
+			continue
+		}
+		j = paraSep(j)
+		if j < len(props) {
+			bounds = append(bounds, j)
+		}
+		lastBase = -1
+		i = j
+	}
+	if len(runes) > 0 {
+		bounds = append(bounds, len(runes))
+	}
+	return bounds
+}
+
+type segmenterObject struct {
+	baseObject
+	tag         language.Tag
+	granularity string
+}
+
+type segmentsObject struct {
+	baseObject
+	input    valueString
+	bounds   []int // UTF-16 offsets
+	wordLike []bool
+}
+
+type segmentIteratorObject struct {
+	baseObject
+	segments *segmentsObject
+	pos      int
+}
+
+func (r *Runtime) toSegmenter(v Value, method string) *segmenterObject {
+	if obj, ok := v.(*Object); ok {
+		if s, ok := obj.self.(*segmenterObject); ok {
+			return s
+		}
+	}
+	panic(r.NewTypeError("Method Intl.Segmenter.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) toSegments(v Value, method string) *segmentsObject {
+	if obj, ok := v.(*Object); ok {
+		if s, ok := obj.self.(*segmentsObject); ok {
+			return s
+		}
+	}
+	panic(r.NewTypeError("Method %%Segments.prototype%%.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) toSegmentIterator(v Value) *segmentIteratorObject {
+	if obj, ok := v.(*Object); ok {
+		if s, ok := obj.self.(*segmentIteratorObject); ok {
+			return s
+		}
+	}
+	panic(r.NewTypeError("Method %%SegmentIterator.prototype%%.next called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: v})))
+}
+
+// segment splits s and returns the boundaries as UTF-16 offsets.
+func (s *segmenterObject) segment(str valueString) (bounds []int, wordLike []bool) {
+	runes := []rune(str.String())
+	switch s.granularity {
+	case "word":
+		bounds, wordLike = wordBoundaries(runes)
+	case "sentence":
+		bounds = sentenceBoundaries(runes)
+	default:
+		bounds = graphemeBoundaries(runes)
+	}
+	// convert the rune indices into UTF-16 offsets (lone surrogates become U+FFFD, which keeps their length)
+	offset, ri := 0, 0
+	for i, b := range bounds {
+		for ; ri < b; ri++ {
+			if runes[ri] >= 0x10000 {
+				offset += 2
+			} else {
+				offset++
+			}
+		}
+		bounds[i] = offset
+	}
+	return
+}
+
+func (r *Runtime) newSegmentData(s *segmentsObject, i int) Value {
+	start, end := s.bounds[i], s.bounds[i+1]
+	o := r.NewObject()
+	o.self._putProp("segment", s.input.substring(start, end), true, true, true)
+	o.self._putProp("index", intToValue(int64(start)), true, true, true)
+	o.self._putProp("input", s.input, true, true, true)
+	if s.wordLike != nil {
+		o.self._putProp("isWordLike", valueBool(s.wordLike[i]), true, true, true)
+	}
+	return o
+}
+
+func (r *Runtime) newSegmenterConstructor(def language.Tag) *Object {
+	iterProto := r.newBaseObject(r.getIteratorPrototype(), classObject)
+	iterProto._putProp("next", r.newNativeFunc(func(call FunctionCall) Value {
+		it := r.toSegmentIterator(call.This)
+		if it.pos >= len(it.segments.bounds)-1 {
+			return r.createIterResultObject(_undefined, true)
+		}
+		res := r.newSegmentData(it.segments, it.pos)
+		it.pos++
+		return r.createIterResultObject(res, false)
+	}, nil, "next", nil, 0), true, false, true)
+	iterProto._putSym(SymToStringTag, valueProp(asciiString("Segmenter String Iterator"), false, false, true))
+
+	segmentsProto := r.NewObject()
+	r.putMethod(segmentsProto, "containing", func(call FunctionCall) Value {
+		s := r.toSegments(call.This, "containing")
+		n := call.Argument(0).ToInteger()
+		if n < 0 || n >= int64(s.input.length()) {
+			return _undefined
+		}
+		return r.newSegmentData(s, sort.SearchInts(s.bounds, int(n)+1)-1)
+	}, 1)
+	segmentsProto.self._putSym(SymIterator, valueProp(r.newNativeFunc(func(call FunctionCall) Value {
+		s := r.toSegments(call.This, "[Symbol.iterator]")
+		o := &Object{runtime: r}
+		it := &segmentIteratorObject{segments: s}
+		initHostObject(o, &it.baseObject, it, iterProto.val)
+		return o
+	}, nil, "[Symbol.iterator]", nil, 0), true, false, true))
+
+	return r.newIntlConstructor("Segmenter", "Intl.Segmenter", 0, cldrLanguage, func(args []Value, proto *Object) *Object {
+		o := &Object{runtime: r}
+		s := &segmenterObject{
+			tag: r.resolveLocale(argOrUndefined(args, 0), def, cldrLanguage),
+		}
+		opts := r.intlOptions(argOrUndefined(args, 1))
+		s.granularity = r.getIntlOption(opts, "Segmenter", "granularity", []string{"grapheme", "word", "sentence"}, "grapheme")
+		initHostObject(o, &s.baseObject, s, proto)
+		return o
+	}, func(proto *Object) {
+		r.putMethod(proto, "segment", func(call FunctionCall) Value {
+			seg := r.toSegmenter(call.This, "segment")
+			o := &Object{runtime: r}
+			s := &segmentsObject{
+				input: call.Argument(0).toString(),
+			}
+			s.bounds, s.wordLike = seg.segment(s.input)
+			initHostObject(o, &s.baseObject, s, segmentsProto)
+			return o
+		}, 1)
+		r.putMethod(proto, "resolvedOptions", func(call FunctionCall) Value {
+			s := r.toSegmenter(call.This, "resolvedOptions")
+			return r.newResolvedOptions("locale", s.tag.String(), "granularity", s.granularity)
+		}, 0)
+	})
+}
@@ -0,0 +1,263 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestIntlNamespace(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(Object.prototype.toString.call(Intl), "[object Intl]");
+
+	const pr = new Intl.PluralRules("en");
+	assert.sameValue([0, 1, 2, 1.5].map(n => pr.select(n)).join(), "other,one,other,other");
+	const ord = new Intl.PluralRules("en-US", {type: "ordinal"});
+	assert.sameValue([1, 2, 3, 4, 11, 12, 13, 21, 22, 23, 101].map(n => ord.select(n)).join(),
+		"one,two,few,other,other,other,other,one,two,few,one");
+	const ru = new Intl.PluralRules("ru");
+	assert.sameValue([1, 2, 5, 21, 1.5].map(n => ru.select(n)).join(), "one,few,many,one,other");
+	assert.sameValue(ru.resolvedOptions().pluralCategories.join(), "one,few,many,other");
+	assert.sameValue(ord.resolvedOptions().type, "ordinal");
+	assert.sameValue(ord.resolvedOptions().locale, "en-US");
+	assert.sameValue(new Intl.PluralRules().resolvedOptions().locale, "en");
+	assert.throws(RangeError, () => new Intl.PluralRules("en", {type: "bogus"}));
+	assert.throws(TypeError, () => Intl.PluralRules());
+	assert.throws(TypeError, () => Intl.PluralRules.prototype.select.call({}, 1));
+
+	const list = ["Motorcycle", "Bus", "Car"];
+	assert.sameValue(new Intl.ListFormat("en").format(list), "Motorcycle, Bus, and Car");
+	assert.sameValue(new Intl.ListFormat("en", {style: "short"}).format(list), "Motorcycle, Bus, & Car");
+	assert.sameValue(new Intl.ListFormat("en", {type: "disjunction"}).format(list), "Motorcycle, Bus, or Car");
+	assert.sameValue(new Intl.ListFormat("en", {type: "unit", style: "narrow"}).format(list), "Motorcycle Bus Car");
+	assert.sameValue(new Intl.ListFormat("en").format(["A", "B"]), "A and B");
+	assert.sameValue(new Intl.ListFormat("en").format(["A"]), "A");
+	assert.sameValue(new Intl.ListFormat("en").format([]), "");
+	assert.sameValue(new Intl.ListFormat("de").format(list), "Motorcycle, Bus und Car");
+	assert.sameValue(new Intl.ListFormat("en").format(new Set(["x", "y"])), "x and y");
+	assert.sameValue(JSON.stringify(new Intl.ListFormat("en").formatToParts(["A", "B"])),
+		'[{"type":"element","value":"A"},{"type":"literal","value":" and "},{"type":"element","value":"B"}]');
+	assert.sameValue(new Intl.ListFormat("ja").resolvedOptions().locale, "en", "unsupported locale");
+	assert.throws(TypeError, () => new Intl.ListFormat("en").format([1, 2]));
+
+	const rtf = new Intl.RelativeTimeFormat("en");
+	assert.sameValue(rtf.format(3, "day"), "in 3 days");
+	assert.sameValue(rtf.format(-1, "days"), "1 day ago");
+	assert.sameValue(rtf.format(-1, "day"), "1 day ago");
+	assert.sameValue(rtf.format(1234, "year"), "in 1,234 years");
+	assert.sameValue(rtf.format(-0, "second"), "0 seconds ago");
+	assert.sameValue(rtf.format(1.5, "hour"), "in 1.5 hours");
+	const auto = new Intl.RelativeTimeFormat("en", {numeric: "auto"});
+	assert.sameValue(auto.format(-1, "day"), "yesterday");
+	assert.sameValue(auto.format(0, "year"), "this year");
+	assert.sameValue(auto.format(1, "week"), "next week");
+	assert.sameValue(auto.format(0, "second"), "now");
+	assert.sameValue(auto.format(-1, "hour"), "1 hour ago");
+	assert.sameValue(new Intl.RelativeTimeFormat("en", {style: "short"}).format(-2, "month"), "2 mo. ago");
+	assert.sameValue(rtf.resolvedOptions().numeric, "always");
+	assert.throws(RangeError, () => rtf.format(1, "fortnight"));
+	assert.throws(RangeError, () => rtf.format(NaN, "day"));
+	assert.sameValue(JSON.stringify(rtf.formatToParts(1234.5, "days")),
+		'[{"type":"literal","value":"in "},{"type":"integer","value":"1","unit":"day"},' +
+		'{"type":"group","value":",","unit":"day"},{"type":"integer","value":"234","unit":"day"},' +
+		'{"type":"decimal","value":".","unit":"day"},{"type":"fraction","value":"5","unit":"day"},' +
+		'{"type":"literal","value":" days"}]');
+	assert.sameValue(JSON.stringify(auto.formatToParts(-1, "day")), '[{"type":"literal","value":"yesterday"}]');
+	assert.sameValue(new Intl.RelativeTimeFormat("de").format(-3, "hour"), "vor 3 Stunden");
+	assert.sameValue(new Intl.RelativeTimeFormat("de", {numeric: "auto"}).format(-2, "day"), "vorgestern");
+	assert.sameValue(new Intl.RelativeTimeFormat("de", {style: "short"}).format(1.5, "minute"), "in 1,5 Min.");
+	assert.sameValue(new Intl.RelativeTimeFormat("fr").format(1, "week"), "dans 1 semaine");
+	assert.sameValue(new Intl.RelativeTimeFormat("fr", {numeric: "auto"}).format(2, "day"), "après-demain");
+	assert.sameValue(new Intl.RelativeTimeFormat("es").format(-5, "years"), "hace 5 años");
+	assert.sameValue(new Intl.RelativeTimeFormat("es", {numeric: "auto"}).format(0, "day"), "hoy");
+	assert.sameValue(new Intl.RelativeTimeFormat("ja").resolvedOptions().locale, "en", "unsupported locale");
+
+	function segments(str, granularity) {
+		return Array.from(new Intl.Segmenter("en", {granularity}).segment(str), s => s.segment);
+	}
+	assert.sameValue(segments("é👍🏽🇺🇦🇩🇪👩‍👩‍👧\r\nx").length, 7, "graphemes");
+	assert.sameValue(segments("각각").length, 2, "hangul");
+	assert.sameValue(segments("The quick (\"brown\") fox can't jump 32.3 feet, right?", "word").join("|"),
+		'The| |quick| |(|"|brown|"|)| |fox| |can\'t| |jump| |32.3| |feet|,| |right|?');
+	const words = Array.from(new Intl.Segmenter("en", {granularity: "word"}).segment("Hello, world 42!"));
+	assert.sameValue(words.filter(s => s.isWordLike).map(s => s.segment).join(), "Hello,world,42");
+	assert.sameValue(words[3].index, 7);
+	assert.sameValue(words[3].input, "Hello, world 42!");
+	assert.sameValue(segments("日本語テキスト", "word").join("|"), "日|本|語|テキスト");
+	assert.sameValue(segments("Mr. Smith went to Washington D.C. yesterday. He said \"hi!\" Then left.\nNext line", "sentence").join("|"),
+		'Mr. |Smith went to Washington D.C. yesterday. |He said "hi!" |Then left.\n|Next line');
+	assert.sameValue(segments("The price is 3.5 dollars. OK?", "sentence").length, 2);
+
+	const segs = new Intl.Segmenter("en", {granularity: "word"}).segment("foo bar");
+	assert.sameValue(segs.containing(5).segment, "bar");
+	assert.sameValue(segs.containing(3).segment, " ");
+	assert.sameValue(segs.containing(7), undefined);
+	assert.sameValue(segs.containing(-1), undefined);
+	assert.sameValue(segs.containing().segment, "foo");
+	const it = segs[Symbol.iterator]();
+	assert.sameValue(Object.prototype.toString.call(it), "[object Segmenter String Iterator]");
+	assert.sameValue(it.next().value.segment, "foo");
+	assert.sameValue(Array.from(new Intl.Segmenter().segment("")).length, 0);
+	assert.sameValue(new Intl.Segmenter().resolvedOptions().granularity, "grapheme");
+	assert.throws(RangeError, () => new Intl.Segmenter("en", {granularity: "line"}));
+
+	assert.sameValue(Intl.ListFormat.supportedLocalesOf(["ja", "EN-us", "de", "en-US"]).join(), "en-US,de");
+	assert.sameValue(Intl.RelativeTimeFormat.supportedLocalesOf("fr-CA").join(), "fr-CA");
+	assert.sameValue(Intl.RelativeTimeFormat.supportedLocalesOf(["ja"]).length, 0);
+	assert.sameValue(Intl.PluralRules.supportedLocalesOf(["ru", "ja"]).join(), "ru,ja");
+	assert.sameValue(Intl.Segmenter.supportedLocalesOf().length, 0);
+	assert.sameValue(Intl.Segmenter.supportedLocalesOf.length, 1);
+	assert.throws(RangeError, () => Intl.ListFormat.supportedLocalesOf(["en", "!"]));
+	assert.throws(RangeError, () => Intl.PluralRules.supportedLocalesOf("en", {localeMatcher: "bogus"}));
+
+	const mf = new Intl.MessageFormat("{n, plural, one {# item} other {# items}}", "en");
+	assert.sameValue(mf.format({n: 2}), "2 items");
+	`
+	r := New()
+	r.Set("Intl", r.NewIntlNamespace("en"))
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestIntlLocales(t *testing.T) {
+	const SCRIPT = `
+	const ctors = ["DateTimeFormat", "PluralRules", "ListFormat", "RelativeTimeFormat", "Segmenter"];
+	for (const name of ctors) {
+		const C = Intl[name];
+		assert.sameValue(new C("xx").resolvedOptions().locale, "en-US", name + " unknown language");
+		assert.sameValue(new C("zz-ZZ").resolvedOptions().locale, "en-US", name + " unknown language and region");
+		assert.sameValue(new C("tlh").resolvedOptions().locale, "en-US", name + " no data");
+		assert.sameValue(new C(["xx", "qaa", "de"]).resolvedOptions().locale, "de", name + " first supported");
+		assert.sameValue(C.supportedLocalesOf(["zz-ZZ", "qaa", "tlh", "und", "EN-us", "en-US", "de-XX"]).join(),
+			"en-US,de-XX", name + " supportedLocalesOf");
+		for (const tag of ["en_US", "", "en-", "-en", "en--US", "x-private", "root", "abcd", "toolongtag",
+			"en-US-US", "de-1996-1996", "en-US-u", "en-a-x", "en-a-foo-a-bar", "en-x-foo-bar-baz123456", "i-klingon",
+			"en-t-m0", "en-t-de-1996-1996"]) {
+			assert.throws(RangeError, () => new C(tag), name + " " + JSON.stringify(tag));
+			assert.throws(RangeError, () => C.supportedLocalesOf(["en", tag]), name + " supportedLocalesOf " + JSON.stringify(tag));
+		}
+	}
+	for (const tag of ["en-12345", "en-1234", "en-Latn-US-u-ca-gregory-nu-latn", "en-u-foo-bar-nu-latn", "en-t-de-latn-m0-ungegn",
+		"en-a-bb-x-a", "EN-latn-us", "de-DE-u-co-phonebk", "sr-Latn-RS-x-private"]) {
+		assert.sameValue(Intl.PluralRules.supportedLocalesOf(tag).length, 1, JSON.stringify(tag));
+	}
+	assert.sameValue(Intl.PluralRules.supportedLocalesOf(["sw", "zh-Hant-TW"]).join(), "sw,zh-Hant-TW");
+	assert.sameValue("i".toLocaleUpperCase("xx"), "I");
+	assert.sameValue("i".toLocaleUpperCase(["xx", "tr"]), "I");
+	assert.sameValue("i".toLocaleUpperCase(["tr", "xx"]), "\u0130");
+	assert.throws(RangeError, () => "i".toLocaleUpperCase("en_US"));
+	let err;
+	try {
+		new Intl.ListFormat("en").format([Symbol("s")]);
+	} catch (e) {
+		err = e;
+	}
+	assert(err instanceof TypeError, "TypeError");
+	assert.sameValue(err.message, "Iterable yielded Symbol(s) which is not a string");
+	`
+	r := New()
+	r.Set("Intl", r.NewIntlNamespace("en-US"))
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestIntlDateTimeFormat(t *testing.T) {
+	const SCRIPT = `
+	const d = new Date(Date.UTC(2024, 0, 2, 15, 4, 5, 678));
+	const timeZone = "UTC";
+	function fmt(locale, options) {
+		return new Intl.DateTimeFormat(locale, Object.assign({timeZone}, options)).format(d);
+	}
+	assert.sameValue(fmt("en-US"), "1/2/2024");
+	assert.sameValue(fmt("en-US", {dateStyle: "full"}), "Tuesday, January 2, 2024");
+	assert.sameValue(fmt("en-US", {dateStyle: "long"}), "January 2, 2024");
+	assert.sameValue(fmt("en-US", {dateStyle: "medium"}), "Jan 2, 2024");
+	assert.sameValue(fmt("en-US", {dateStyle: "short"}), "1/2/24");
+	assert.sameValue(fmt("en-US", {timeStyle: "short"}), "3:04 PM");
+	assert.sameValue(fmt("en-US", {dateStyle: "long", timeStyle: "long"}), "January 2, 2024 at 3:04:05 PM UTC");
+	assert.sameValue(fmt("en-US", {dateStyle: "short", timeStyle: "medium"}), "1/2/24, 3:04:05 PM");
+	assert.sameValue(fmt("en-US", {month: "long", year: "numeric"}), "January 2024");
+	assert.sameValue(fmt("en-US", {month: "short", day: "numeric"}), "Jan 2");
+	assert.sameValue(fmt("en-US", {month: "2-digit", day: "2-digit", year: "2-digit"}), "01/02/24");
+	assert.sameValue(fmt("en-US", {minute: "2-digit", second: "2-digit"}), "04:05");
+	assert.sameValue(fmt("en-US", {hour: "numeric", minute: "numeric", hour12: false}), "15:04");
+	assert.sameValue(fmt("en-US", {hour: "numeric", hourCycle: "h11"}), "3 PM");
+	assert.sameValue(fmt("en-US", {second: "numeric", fractionalSecondDigits: 2}), "5.67");
+	assert.sameValue(fmt("de", {dateStyle: "full", timeStyle: "short"}), "Dienstag, 2. Januar 2024 um 15:04");
+	assert.sameValue(fmt("fr", {weekday: "long", day: "numeric", month: "long"}), "mardi 2 janvier");
+	assert.sameValue(fmt("es", {dateStyle: "long"}), "2 de enero de 2024");
+	assert.sameValue(fmt("en-GB", {hour: "numeric", minute: "numeric"}), "15:04");
+	assert.sameValue(fmt("en-US", {timeZone: "America/New_York", hour: "numeric", timeZoneName: "short"}), "10 AM EST");
+	assert.sameValue(fmt("en-US", {timeZone: "Asia/Kolkata", hour: "numeric", minute: "numeric", timeZoneName: "long"}),
+		"8:34 PM GMT+05:30");
+	assert.sameValue(fmt("ja"), "1/2/2024", "unsupported locale");
+
+	const dtf = new Intl.DateTimeFormat("en-US", {timeZone, hour: "numeric", minute: "2-digit"});
+	assert.sameValue(dtf.format, dtf.format);
+	assert.sameValue(["x"].map(() => dtf.format(0)).join(), "12:00 AM");
+	assert.sameValue(JSON.stringify(dtf.formatToParts(d)),
+		'[{"type":"hour","value":"3"},{"type":"literal","value":":"},{"type":"minute","value":"04"},' +
+		'{"type":"literal","value":" "},{"type":"dayPeriod","value":"PM"}]');
+	const ro = dtf.resolvedOptions();
+	assert.sameValue(Object.keys(ro).join(), "locale,calendar,numberingSystem,timeZone,hourCycle,hour12,hour,minute");
+	assert.sameValue(ro.hourCycle, "h12");
+	assert.sameValue(ro.hour12, true);
+	assert.sameValue(new Intl.DateTimeFormat("en", {timeZone: "Europe/Berlin"}).resolvedOptions().timeZone, "Europe/Berlin");
+
+	assert.throws(RangeError, () => new Intl.DateTimeFormat("en", {timeZone: "Mars/Olympus_Mons"}));
+	assert.throws(RangeError, () => new Intl.DateTimeFormat("en", {month: "longest"}));
+	assert.throws(RangeError, () => new Intl.DateTimeFormat("en", {fractionalSecondDigits: 4}));
+	assert.throws(TypeError, () => new Intl.DateTimeFormat("en", {dateStyle: "short", year: "numeric"}));
+	assert.throws(RangeError, () => dtf.format(NaN));
+	assert.throws(TypeError, () => Intl.DateTimeFormat.prototype.formatToParts.call({}, 0));
+
+	assert.sameValue(d.toLocaleString("en-US", {timeZone}), "1/2/2024, 3:04:05 PM");
+	assert.sameValue(d.toLocaleString("en-GB", {timeZone}), "02/01/2024, 15:04:05");
+	assert.sameValue(d.toLocaleDateString("de", {timeZone}), "2.1.2024");
+	assert.sameValue(d.toLocaleTimeString("en-US", {timeZone}), "3:04:05 PM");
+	assert.sameValue(d.toLocaleDateString("en-US", {timeZone, hour: "numeric"}), "1/2/2024, 3 PM");
+	assert.sameValue(d.toLocaleTimeString("en-US", {timeZone, timeStyle: "short"}), "3:04 PM");
+	assert.throws(TypeError, () => d.toLocaleTimeString("en-US", {dateStyle: "short"}));
+	assert.sameValue(new Date(NaN).toLocaleString("en", {timeZone: "bogus"}), "Invalid Date");
+	`
+	r := New()
+	r.Set("Intl", r.NewIntlNamespace("en"))
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestDateToLocaleStringDefaultLocale(t *testing.T) {
+	const SCRIPT = `
+	const d = new Date(Date.UTC(2024, 0, 2, 15, 4, 5));
+	assert.sameValue(d.toLocaleString(undefined, {timeZone: "UTC"}), "1/2/2024, 3:04:05 PM");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+	r := New()
+	r.Set("Intl", r.NewIntlNamespace("de"))
+	r.testScriptWithTestLib("assert.sameValue(new Date(0).toLocaleDateString(undefined, {timeZone: 'UTC'}), '1.1.1970');", _undefined, t)
+}
+
+func TestSegmentationBoundaries(t *testing.T) {
+	tests := []struct {
+		s           string
+		granularity string
+		expected    []int
+	}{
+		{"abc", "grapheme", []int{0, 1, 2, 3}},
+		{"a\U0001F600b", "grapheme", []int{0, 1, 3, 4}},
+		{"\U0001F1FA\U0001F1E6\U0001F1E9", "grapheme", []int{0, 4, 6}},
+		{"don't stop", "word", []int{0, 5, 6, 10}},
+		{"a_b 1,000.5", "word", []int{0, 3, 4, 11}},
+		{"One. Two", "sentence", []int{0, 5, 8}},
+		{"e.g. this", "sentence", []int{0, 9}},
+	}
+	for _, test := range tests {
+		s := &segmenterObject{granularity: test.granularity}
+		bounds, _ := s.segment(newStringValue(test.s))
+		if len(bounds) != len(test.expected) {
+			t.Errorf("%q (%s): %v, expected %v", test.s, test.granularity, bounds, test.expected)
+			continue
+		}
+		for i := range bounds {
+			if bounds[i] != test.expected[i] {
+				t.Errorf("%q (%s): %v, expected %v", test.s, test.granularity, bounds, test.expected)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,544 @@
+package goja
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+
+	"github.com/dop251/goja/unistring"
+)
+
+// A parsed ICU message pattern.
+type mfMessage []mfPart
+
+type mfPart interface {
+	format(f *mfFormatter, ctx *mfPluralCtx)
+}
+
+type mfText string
+
+// The '#' placeholder in a plural sub-message.
+type mfPound struct{}
+
+type mfArg struct {
+	name  unistring.String
+	typ   string // "", "number", "date" or "time"
+	style string
+}
+
+type mfSelect struct {
+	name  unistring.String
+	cases map[string]mfMessage
+}
+
+type mfPlural struct {
+	name    unistring.String
+	ordinal bool
+	offset  float64
+	exact   map[float64]mfMessage
+	cases   map[string]mfMessage
+}
+
+type mfPluralCtx struct {
+	value float64
+}
+
+const mfMaxDepth = 64
+
+type mfParser struct {
+	src []rune
+	pos int
+}
+
+type mfSyntaxError struct {
+	msg string
+	pos int
+}
+
+func (e *mfSyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.msg, e.pos)
+}
+
+func parseMessageFormat(pattern string) (msg mfMessage, err error) {
+	p := &mfParser{src: []rune(pattern)}
+	defer func() {
+		if x := recover(); x != nil {
+			if e, ok := x.(*mfSyntaxError); ok {
+				msg, err = nil, e
+				return
+			}
+			panic(x)
+		}
+	}()
+	msg = p.parseMessage(0, false)
+	if p.pos < len(p.src) {
+		p.errorf("Unexpected '}'")
+	}
+	return
+}
+
+func (p *mfParser) errorf(format string, args ...interface{}) {
+	panic(&mfSyntaxError{msg: fmt.Sprintf(format, args...), pos: p.pos})
+}
+
+func (p *mfParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *mfParser) expect(c rune) {
+	if p.pos >= len(p.src) {
+		p.errorf("Unexpected end of message, expected '%c'", c)
+	}
+	if p.src[p.pos] != c {
+		p.errorf("Unexpected '%c', expected '%c'", p.src[p.pos], c)
+	}
+	p.pos++
+}
+
+func isMessageFormatSyntax(c rune) bool {
+	return c == '{' || c == '}' || c == ',' || c == '#' || c == '\'' || c == '=' || unicode.IsSpace(c)
+}
+
+func (p *mfParser) parseWord() string {
+	start := p.pos
+	for p.pos < len(p.src) && !isMessageFormatSyntax(p.src[p.pos]) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// parseMessage parses literal text and arguments up to the closing '}' of a sub-message (which is not consumed)
+// or the end of the pattern. The apostrophe quoting follows the ICU DOUBLE_OPTIONAL mode: a doubled apostrophe is
+// a literal one and a single apostrophe only starts a quoted literal if it's followed by a syntax character.
+func (p *mfParser) parseMessage(depth int, inPlural bool) mfMessage {
+	if depth > mfMaxDepth {
+		p.errorf("Message is nested too deeply")
+	}
+	var msg mfMessage
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			msg = append(msg, mfText(text.String()))
+			text.Reset()
+		}
+	}
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\'':
+			p.pos++
+			if p.pos < len(p.src) {
+				next := p.src[p.pos]
+				if next == '\'' {
+					text.WriteRune('\'')
+					p.pos++
+					continue
+				}
+				if next == '{' || next == '}' || next == '|' || next == '#' && inPlural {
+					for p.pos < len(p.src) {
+						c := p.src[p.pos]
+						p.pos++
+						if c == '\'' {
+							if p.pos < len(p.src) && p.src[p.pos] == '\'' {
+								p.pos++
+							} else {
+								break
+							}
+						}
+						text.WriteRune(c)
+					}
+					continue
+				}
+			}
+			text.WriteRune('\'')
+		case c == '{':
+			flush()
+			p.pos++
+			msg = append(msg, p.parseArgument(depth, inPlural))
+		case c == '}':
+			flush()
+			return msg
+		case c == '#' && inPlural:
+			flush()
+			p.pos++
+			msg = append(msg, mfPound{})
+		default:
+			text.WriteRune(c)
+			p.pos++
+		}
+	}
+	flush()
+	return msg
+}
+
+func (p *mfParser) parseArgument(depth int, inPlural bool) mfPart {
+	p.skipSpace()
+	name := p.parseWord()
+	if name == "" {
+		p.errorf("Expected argument name")
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '}' {
+		p.pos++
+		return &mfArg{name: unistring.NewFromString(name)}
+	}
+	p.expect(',')
+	p.skipSpace()
+	typ := p.parseWord()
+	p.skipSpace()
+	switch typ {
+	case "plural", "selectordinal":
+		p.expect(',')
+		return p.parsePlural(name, typ == "selectordinal", depth)
+	case "select":
+		p.expect(',')
+		return p.parseSelect(name, depth, inPlural)
+	case "number", "date", "time":
+	default:
+		p.errorf("Unsupported argument type '%s'", typ)
+	}
+	arg := &mfArg{name: unistring.NewFromString(name), typ: typ}
+	if p.pos < len(p.src) && p.src[p.pos] == ',' {
+		p.pos++
+		p.skipSpace()
+		arg.style = p.parseWord()
+		p.skipSpace()
+		if !arg.isValidStyle() {
+			p.errorf("Unsupported %s style '%s'", typ, arg.style)
+		}
+	}
+	p.expect('}')
+	return arg
+}
+
+func (a *mfArg) isValidStyle() bool {
+	switch a.typ {
+	case "number":
+		return a.style == "integer" || a.style == "percent"
+	default:
+		_, ok := dateStyleLayouts[a.style]
+		return ok
+	}
+}
+
+func (p *mfParser) parseCases(depth int, inPlural bool, plural bool, add func(key string, exact float64, isExact bool, msg mfMessage)) {
+	hasOther := false
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			p.errorf("Unexpected end of message")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			break
+		}
+		var exact float64
+		var isExact bool
+		if plural && p.src[p.pos] == '=' {
+			p.pos++
+			w := p.parseWord()
+			v, err := strconv.ParseFloat(w, 64)
+			if err != nil {
+				p.errorf("Invalid plural selector '=%s'", w)
+			}
+			exact, isExact = v, true
+		}
+		key := ""
+		if !isExact {
+			key = p.parseWord()
+			if key == "" {
+				p.errorf("Expected selector")
+			}
+			if key == "other" {
+				hasOther = true
+			}
+		}
+		p.skipSpace()
+		p.expect('{')
+		msg := p.parseMessage(depth+1, inPlural)
+		p.expect('}')
+		add(key, exact, isExact, msg)
+	}
+	if !hasOther {
+		p.errorf("Missing 'other' case")
+	}
+}
+
+func (p *mfParser) parsePlural(name string, ordinal bool, depth int) mfPart {
+	pl := &mfPlural{
+		name:    unistring.NewFromString(name),
+		ordinal: ordinal,
+		exact:   make(map[float64]mfMessage),
+		cases:   make(map[string]mfMessage),
+	}
+	p.skipSpace()
+	if strings.HasPrefix(string(p.src[p.pos:]), "offset:") {
+		p.pos += len("offset:")
+		p.skipSpace()
+		w := p.parseWord()
+		v, err := strconv.ParseFloat(w, 64)
+		if err != nil {
+			p.errorf("Invalid plural offset '%s'", w)
+		}
+		pl.offset = v
+	}
+	p.parseCases(depth, true, true, func(key string, exact float64, isExact bool, msg mfMessage) {
+		if isExact {
+			pl.exact[exact] = msg
+			return
+		}
+		switch key {
+		case "zero", "one", "two", "few", "many", "other":
+		default:
+			p.errorf("Invalid plural category '%s'", key)
+		}
+		pl.cases[key] = msg
+	})
+	return pl
+}
+
+func (p *mfParser) parseSelect(name string, depth int, inPlural bool) mfPart {
+	s := &mfSelect{
+		name:  unistring.NewFromString(name),
+		cases: make(map[string]mfMessage),
+	}
+	p.parseCases(depth, inPlural, false, func(key string, _ float64, _ bool, msg mfMessage) {
+		s.cases[key] = msg
+	})
+	return s
+}
+
+var dateStyleLayouts = map[string][2]string{
+	"short":  {"1/2/06", "3:04 PM"},
+	"":       {"Jan 2, 2006", "3:04:05 PM"},
+	"medium": {"Jan 2, 2006", "3:04:05 PM"},
+	"long":   {"January 2, 2006", "3:04:05 PM MST"},
+	"full":   {"Monday, January 2, 2006", "3:04:05 PM MST"},
+}
+
+type mfFormatter struct {
+	r       *Runtime
+	tag     language.Tag
+	printer *message.Printer
+	values  *Object
+	b       strings.Builder
+}
+
+func (m mfMessage) format(f *mfFormatter, ctx *mfPluralCtx) {
+	for _, part := range m {
+		part.format(f, ctx)
+	}
+}
+
+func (t mfText) format(f *mfFormatter, _ *mfPluralCtx) {
+	f.b.WriteString(string(t))
+}
+
+func (mfPound) format(f *mfFormatter, ctx *mfPluralCtx) {
+	if ctx != nil {
+		f.b.WriteString(f.formatNumber(ctx.value, ""))
+	} else {
+		f.b.WriteByte('#')
+	}
+}
+
+func (f *mfFormatter) value(name unistring.String) Value {
+	var v Value
+	if f.values != nil {
+		v = f.values.self.getStr(name, nil)
+	}
+	if v == nil || v == _undefined {
+		panic(f.r.NewTypeError("No value provided for the message argument '%s'", name))
+	}
+	return v
+}
+
+func (f *mfFormatter) formatNumber(n float64, style string) string {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return floatToValue(n).String()
+	}
+	switch style {
+	case "integer":
+		return f.printer.Sprint(number.Decimal(n, number.MaxFractionDigits(0)))
+	case "percent":
+		return f.printer.Sprint(number.Percent(n))
+	}
+	return f.printer.Sprint(number.Decimal(n))
+}
+
+func (f *mfFormatter) toTime(v Value) (time.Time, bool) {
+	if obj, ok := v.(*Object); ok {
+		if d, ok := obj.self.(*dateObject); ok {
+			if !d.isSet() {
+				return time.Time{}, false
+			}
+			return d.time(), true
+		}
+	}
+	n := v.ToFloat()
+	if math.IsNaN(n) || math.IsInf(n, 0) || math.Abs(n) > maxTime {
+		return time.Time{}, false
+	}
+	return timeFromMsec(int64(n)).In(f.r.location()), true
+}
+
+func (a *mfArg) format(f *mfFormatter, _ *mfPluralCtx) {
+	v := f.value(a.name)
+	switch a.typ {
+	case "":
+		switch v := v.(type) {
+		case valueInt, valueFloat:
+			f.b.WriteString(f.formatNumber(v.ToFloat(), ""))
+			return
+		case *Object:
+			if _, ok := v.self.(*dateObject); ok {
+				if t, ok := f.toTime(v); ok {
+					layouts := dateStyleLayouts["short"]
+					f.b.WriteString(t.Format(layouts[0] + ", " + layouts[1]))
+				} else {
+					f.b.WriteString("Invalid Date")
+				}
+				return
+			}
+		}
+		f.b.WriteString(v.String())
+	case "number":
+		f.b.WriteString(f.formatNumber(v.ToFloat(), a.style))
+	default:
+		t, ok := f.toTime(v)
+		if !ok {
+			f.b.WriteString("Invalid Date")
+			return
+		}
+		layouts := dateStyleLayouts[a.style]
+		if a.typ == "date" {
+			f.b.WriteString(t.Format(layouts[0]))
+		} else {
+			f.b.WriteString(t.Format(layouts[1]))
+		}
+	}
+}
+
+func (s *mfSelect) format(f *mfFormatter, ctx *mfPluralCtx) {
+	key := f.value(s.name).String()
+	msg, ok := s.cases[key]
+	if !ok {
+		msg = s.cases["other"]
+	}
+	msg.format(f, ctx)
+}
+
+func (pl *mfPlural) format(f *mfFormatter, _ *mfPluralCtx) {
+	n := f.value(pl.name).ToFloat()
+	ctx := &mfPluralCtx{value: n - pl.offset}
+	if msg, ok := pl.exact[n]; ok {
+		msg.format(f, ctx)
+		return
+	}
+	msg, ok := pl.cases[pluralCategory(f.tag, ctx.value, pl.ordinal)]
+	if !ok {
+		msg = pl.cases["other"]
+	}
+	msg.format(f, ctx)
+}
+
+type messageFormatObject struct {
+	baseObject
+	msg mfMessage
+	tag language.Tag
+}
+
+func (r *Runtime) toMessageFormat(v Value, method string) *messageFormatObject {
+	if obj, ok := v.(*Object); ok {
+		if m, ok := obj.self.(*messageFormatObject); ok {
+			return m
+		}
+	}
+	panic(r.NewTypeError("Method MessageFormat.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+// NewMessageFormatConstructor creates a constructor for formatting messages in the ICU MessageFormat syntax, so
+// that localization scripts don't have to bundle a JavaScript implementation:
+//
+//	vm.Set("MessageFormat", vm.NewMessageFormatConstructor("en"))
+//
+//	const mf = new MessageFormat("{name} has {count, plural, =0 {no messages} one {# message} other {# messages}}", "en");
+//	mf.format({name: "Alice", count: 1234}); // "Alice has 1,234 messages"
+//
+// The constructor takes the message pattern and an optional locale (a language tag or an array of them, the first
+// one is used). If the locale is not specified defaultLocale is used. Syntax errors in the pattern throw a
+// SyntaxError.
+//
+// The supported argument types are:
+//
+//   - {name} inserts the value. Numbers are formatted according to the locale, Dates using the short date and
+//     time formats.
+//   - {name, number} and {name, number, integer|percent} format the value as a number.
+//   - {name, date} and {name, time} with an optional short, medium, long or full style format a Date or a
+//     timestamp in milliseconds. The date and time formats are always in English.
+//   - {name, plural, [offset:N] =0 {...} one {...} other {...}} selects a sub-message by an exact value or the
+//     CLDR plural category of the locale. A '#' in the sub-message is replaced with the formatted value minus
+//     the offset.
+//   - {name, selectordinal, one {#st} two {#nd} few {#rd} other {#th}} uses the ordinal plural rules instead.
+//   - {name, select, male {...} female {...} other {...}} selects a sub-message by the string value.
+//
+// Sub-messages can contain further arguments. Literal braces can be quoted with apostrophes ('{' or '{text}'),
+// and a doubled apostrophe produces a single one.
+//
+// The format() method of the created objects takes an object with the argument values and returns the formatted
+// string. A TypeError is thrown if a value is missing. The resolvedOptions() method returns an object with the
+// locale property.
+//
+// A new constructor (with a new prototype) is created on every call.
+func (r *Runtime) NewMessageFormatConstructor(defaultLocale string) *Object {
+	def, err := language.Parse(defaultLocale)
+	if err != nil {
+		def = language.English
+	}
+
+	return r.newIntlConstructor("MessageFormat", "MessageFormat", 1, cldrLanguage, func(args []Value, proto *Object) *Object {
+		msg, err := parseMessageFormat(argOrUndefined(args, 0).toString().String())
+		if err != nil {
+			var se *mfSyntaxError
+			if errors.As(err, &se) {
+				panic(r.newError(r.global.SyntaxError, "Invalid message pattern: %s", se.Error()))
+			}
+			panic(err)
+		}
+		o := &Object{runtime: r}
+		m := &messageFormatObject{
+			msg: msg,
+			tag: r.resolveLocale(argOrUndefined(args, 1), def, cldrLanguage),
+		}
+		initHostObject(o, &m.baseObject, m, proto)
+		return o
+	}, func(proto *Object) {
+		r.putMethod(proto, "format", func(call FunctionCall) Value {
+			m := r.toMessageFormat(call.This, "format")
+			f := &mfFormatter{
+				r:       r,
+				tag:     m.tag,
+				printer: message.NewPrinter(m.tag),
+			}
+			if v := call.Argument(0); v != _undefined && v != _null {
+				f.values = r.toObject(v)
+			}
+			m.msg.format(f, nil)
+			return newStringValue(f.b.String())
+		}, 1)
+		r.putMethod(proto, "resolvedOptions", func(call FunctionCall) Value {
+			m := r.toMessageFormat(call.This, "resolvedOptions")
+			return r.newResolvedOptions("locale", m.tag.String())
+		}, 0)
+	})
+}
@@ -0,0 +1,63 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestMessageFormat(t *testing.T) {
+	const SCRIPT = `
+	function fmt(pattern, values, locale) {
+		return new MessageFormat(pattern, locale).format(values);
+	}
+
+	assert.sameValue(fmt("Hello, {name}!", {name: "World"}), "Hello, World!");
+	assert.sameValue(fmt("{n} items", {n: 1234567.5}), "1,234,567.5 items");
+	assert.sameValue(fmt("{n} items", {n: 1234567.5}, "de"), "1.234.567,5 items");
+	assert.sameValue(fmt("{n, number, integer} / {p, number, percent}", {n: 12.4, p: 0.25}), "12 / 25%");
+
+	const inbox = "{name} has {count, plural, =0 {no messages} one {# message} other {# messages}}.";
+	assert.sameValue(fmt(inbox, {name: "Alice", count: 0}), "Alice has no messages.");
+	assert.sameValue(fmt(inbox, {name: "Alice", count: 1}), "Alice has 1 message.");
+	assert.sameValue(fmt(inbox, {name: "Alice", count: 1000}), "Alice has 1,000 messages.");
+	assert.sameValue(fmt(inbox, {name: "Alice", count: 1.5}), "Alice has 1.5 messages.");
+
+	const files = "{n, plural, one {# файл} few {# файла} many {# файлов} other {# файла}}";
+	assert.sameValue(fmt(files, {n: 1}, "ru"), "1 файл");
+	assert.sameValue(fmt(files, {n: 3}, "ru"), "3 файла");
+	assert.sameValue(fmt(files, {n: 5}, "ru"), "5 файлов");
+	assert.sameValue(fmt(files, {n: 21}, "ru"), "21 файл");
+
+	const place = "{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}";
+	assert.sameValue([1, 2, 3, 4, 11, 22, 103].map(n => fmt(place, {n})).join(), "1st,2nd,3rd,4th,11th,22nd,103rd");
+
+	const party = "{gender, select, female {{guests, plural, offset:1 =0 {{host} does not give a party.} =1 {{host} invites {guest} to her party.} one {{host} invites {guest} and one other person to her party.} other {{host} invites {guest} and # other people to her party.}}} other {{host} invites {guests, plural, =0 {nobody} other {# people}}.}}";
+	assert.sameValue(fmt(party, {gender: "female", host: "Ann", guest: "Bob", guests: 0}), "Ann does not give a party.");
+	assert.sameValue(fmt(party, {gender: "female", host: "Ann", guest: "Bob", guests: 1}), "Ann invites Bob to her party.");
+	assert.sameValue(fmt(party, {gender: "female", host: "Ann", guest: "Bob", guests: 2}), "Ann invites Bob and one other person to her party.");
+	assert.sameValue(fmt(party, {gender: "female", host: "Ann", guest: "Bob", guests: 5}), "Ann invites Bob and 4 other people to her party.");
+	assert.sameValue(fmt(party, {gender: "male", host: "Tom", guests: 3}, "en"), "Tom invites 3 people.");
+
+	assert.sameValue(fmt("It''s '{quoted}' and '{'braces'}' # '#", {}), "It's {quoted} and {braces} # '#");
+	assert.sameValue(fmt("{n, plural, other {'#' is #}}", {n: 7}), "# is 7");
+	assert.sameValue(fmt("{d, date, short}", {d: new Date(2024, 0, 31)}), "1/31/24");
+	assert.sameValue(fmt("{d, date, long}", {d: new Date(2024, 0, 31).getTime()}), "January 31, 2024");
+	assert.sameValue(fmt("{d, time, short}", {d: new Date(2024, 0, 31, 13, 5)}), "1:05 PM");
+
+	const mf = new MessageFormat("{a}", ["fr-CA", "en"]);
+	assert.sameValue(mf.resolvedOptions().locale, "fr-CA");
+	assert.sameValue(new MessageFormat("{a}").resolvedOptions().locale, "en");
+	assert.sameValue(Object.prototype.toString.call(mf), "[object MessageFormat]");
+	assert.sameValue(Object.getPrototypeOf(mf), MessageFormat.prototype);
+
+	assert.throws(TypeError, () => mf.format({}));
+	assert.throws(TypeError, () => MessageFormat("{a}"));
+	assert.throws(TypeError, () => MessageFormat.prototype.format.call({}));
+	assert.throws(RangeError, () => new MessageFormat("{a}", "not a locale!"));
+	for (const bad of ["{", "{a", "}", "{a, plural, one {x}}", "{a, select, x {y}", "{a, foo}", "{a, number, bar}", "{a, plural, odd {x} other {y}}"]) {
+		assert.throws(SyntaxError, () => new MessageFormat(bad), bad);
+	}
+	`
+	r := New()
+	r.Set("MessageFormat", r.NewMessageFormatConstructor("en"))
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
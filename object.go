@@ -5,7 +5,10 @@ import (
 	"math"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/dop251/goja/parser"
 	"github.com/dop251/goja/unistring"
 )
 
@@ -20,6 +23,7 @@ const (
 	classFunction      = "Function"
 	classAsyncFunction = "AsyncFunction"
 	classNumber        = "Number"
+	classBigInt        = "BigInt"
 	classString        = "String"
 	classBoolean       = "Boolean"
 	classError         = "Error"
@@ -145,6 +149,7 @@ type objectExportCacheItem map[reflect.Type]interface{}
 
 type objectExportCtx struct {
 	cache map[*Object]interface{}
+	path  []Value
 }
 
 type objectImpl interface {
@@ -272,6 +277,9 @@ func (f ConstructorCall) Argument(idx int) Value {
 
 func (o *baseObject) init() {
 	o.values = make(map[unistring.String]Value)
+	if v := o.val; v != nil && v.runtime != nil {
+		v.runtime.accountAlloc(usageObjectSize)
+	}
 }
 
 func (o *baseObject) className() string {
@@ -486,6 +494,11 @@ func (o *baseObject) setOwnStr(name unistring.String, val Value, throw bool) boo
 			o.val.runtime.typeErrorResult(throw, "Cannot add property %s, object is not extensible", name)
 			return false
 		} else {
+			r := o.val.runtime
+			if r.propertyLimits != nil {
+				r.checkNewProperty(o)
+			}
+			r.accountAlloc(usagePropertySize)
 			o.values[name] = val
 			names := copyNamesIfNeeded(o.propNames, 1)
 			o.propNames = append(names, name)
@@ -526,6 +539,9 @@ func (o *baseObject) setOwnSym(name *Symbol, val Value, throw bool) bool {
 			o.val.runtime.typeErrorResult(throw, "Cannot add property %s, object is not extensible", name)
 			return false
 		} else {
+			if r := o.val.runtime; r.propertyLimits != nil {
+				r.checkNewProperty(o)
+			}
 			if o.symValues == nil {
 				o.symValues = newOrderedMap(nil)
 			}
@@ -754,9 +770,15 @@ Reject:
 
 func (o *baseObject) defineOwnPropertyStr(name unistring.String, descr PropertyDescriptor, throw bool) bool {
 	existingVal := o.values[name]
+	if existingVal == nil && o.extensible {
+		if r := o.val.runtime; r.propertyLimits != nil {
+			r.checkNewProperty(o)
+		}
+	}
 	if v, ok := o._defineOwnProperty(name, existingVal, descr, throw); ok {
 		o.values[name] = v
 		if existingVal == nil {
+			o.val.runtime.accountAlloc(usagePropertySize)
 			names := copyNamesIfNeeded(o.propNames, 1)
 			o.propNames = append(names, name)
 		}
@@ -774,6 +796,11 @@ func (o *baseObject) defineOwnPropertySym(s *Symbol, descr PropertyDescriptor, t
 	if o.symValues != nil {
 		existingVal = o.symValues.get(s)
 	}
+	if existingVal == nil && o.extensible {
+		if r := o.val.runtime; r.propertyLimits != nil {
+			r.checkNewProperty(o)
+		}
+	}
 	if v, ok := o._defineOwnProperty(s.descriptiveString().string(), existingVal, descr, throw); ok {
 		if o.symValues == nil {
 			o.symValues = newOrderedMap(nil)
@@ -786,6 +813,9 @@ func (o *baseObject) defineOwnPropertySym(s *Symbol, descr PropertyDescriptor, t
 
 func (o *baseObject) _put(name unistring.String, v Value) {
 	if _, exists := o.values[name]; !exists {
+		if v := o.val; v != nil && v.runtime != nil {
+			v.runtime.accountAlloc(usagePropertySize)
+		}
 		names := copyNamesIfNeeded(o.propNames, 1)
 		o.propNames = append(names, name)
 	}
@@ -932,7 +962,7 @@ func (o *baseObject) assertCallable() (func(FunctionCall) Value, bool) {
 }
 
 func (o *baseObject) vmCall(vm *vm, n int) {
-	vm.r.typeErrorResult(true, "Not a function: %s", o.val.toString())
+	panic(vm.newNotCallableError(o.val))
 }
 
 func (o *baseObject) assertConstructor() func(args []Value, newTarget *Object) *Object {
@@ -1011,9 +1041,9 @@ func genericExportToMap(o *Object, dst reflect.Value, typ reflect.Type, ctx *obj
 		var err error
 		if needConvertKeys {
 			kv = reflect.New(keyTyp).Elem()
-			err = r.toReflectValue(item.name, kv, ctx)
+			err = r.toReflectValueAt(item.name, kv, ctx, item.name)
 			if err != nil {
-				return fmt.Errorf("could not convert map key %s to %v: %w", item.name.String(), typ, err)
+				return err
 			}
 		} else {
 			kv = reflect.ValueOf(item.name.String())
@@ -1022,9 +1052,9 @@ func genericExportToMap(o *Object, dst reflect.Value, typ reflect.Type, ctx *obj
 		ival := o.self.getStr(item.name.string(), nil)
 		if ival != nil {
 			vv := reflect.New(elemTyp).Elem()
-			err = r.toReflectValue(ival, vv, ctx)
+			err = r.toReflectValueAt(ival, vv, ctx, item.name)
 			if err != nil {
-				return fmt.Errorf("could not convert map value %v to %v at key %s: %w", ival, typ, item.name.String(), err)
+				return err
 			}
 			dst.SetMapIndex(kv, vv)
 		} else {
@@ -1062,7 +1092,7 @@ func genericExportToArrayOrSlice(o *Object, dst reflect.Value, typ reflect.Type,
 		}
 		ctx.putTyped(o, typ, dst.Interface())
 		for i, val := range values {
-			err = r.toReflectValue(val, dst.Index(i), ctx)
+			err = r.toReflectValueAt(val, dst.Index(i), ctx, intToValue(int64(i)))
 			if err != nil {
 				return
 			}
@@ -1087,7 +1117,7 @@ func genericExportToArrayOrSlice(o *Object, dst reflect.Value, typ reflect.Type,
 		ctx.putTyped(o, typ, dst.Interface())
 		for i := 0; i < l; i++ {
 			val := nilSafe(o.self.getIdx(valueInt(i), nil))
-			err = r.toReflectValue(val, dst.Index(i), ctx)
+			err = r.toReflectValueAt(val, dst.Index(i), ctx, valueInt(i))
 			if err != nil {
 				return
 			}
@@ -1418,7 +1448,7 @@ func (o *baseObject) keys(all bool, accum []Value) []Value {
 }
 
 func (o *baseObject) hasInstance(Value) bool {
-	panic(o.val.runtime.NewTypeError("Expecting a function in instanceof check, but got %s", o.val.toString()))
+	panic(o.val.runtime.NewTypeError("Right-hand side of 'instanceof' is not callable"))
 }
 
 func toMethod(v Value) func(FunctionCall) Value {
@@ -1736,6 +1766,31 @@ func (ctx *objectExportCtx) put(key *Object, value interface{}) {
 	}
 }
 
+// pathString returns the path of the value being exported, e.g. "orders[3].items[0].price".
+func (ctx *objectExportCtx) pathString() string {
+	var b strings.Builder
+	for _, key := range ctx.path {
+		if idx, ok := key.(valueInt); ok {
+			b.WriteByte('[')
+			b.WriteString(strconv.FormatInt(int64(idx), 10))
+			b.WriteByte(']')
+			continue
+		}
+		name := key.String()
+		if parser.IsIdentifier(name) {
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(name)
+		} else {
+			b.WriteByte('[')
+			b.WriteString(strconv.Quote(name))
+			b.WriteByte(']')
+		}
+	}
+	return b.String()
+}
+
 func (ctx *objectExportCtx) putTyped(key *Object, typ reflect.Type, value interface{}) {
 	if ctx.cache == nil {
 		ctx.cache = make(map[*Object]interface{})
@@ -448,7 +448,7 @@ func (o *baseDynamicObject) assertCallable() (call func(FunctionCall) Value, ok
 }
 
 func (o *baseDynamicObject) vmCall(vm *vm, n int) {
-	panic(vm.r.NewTypeError("Dynamic object is not callable"))
+	panic(vm.newNotCallableError(o.val))
 }
 
 func (*baseDynamicObject) assertConstructor() func(args []Value, newTarget *Object) *Object {
@@ -465,7 +465,7 @@ func (o *baseDynamicObject) setProto(proto *Object, throw bool) bool {
 }
 
 func (o *baseDynamicObject) hasInstance(v Value) bool {
-	panic(newTypeError("Expecting a function in instanceof check, but got a dynamic object"))
+	panic(newTypeError("Right-hand side of 'instanceof' is not callable"))
 }
 
 func (*baseDynamicObject) isExtensible() bool {
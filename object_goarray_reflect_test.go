@@ -302,3 +302,39 @@ func TestReflectArrayToString(t *testing.T) {
 		t.Fatal(exp)
 	}
 }
+
+func TestReflectArrayMutatingMethods(t *testing.T) {
+	const SCRIPT = `
+	a.fill(5, 1);
+	assert(compareArray(a, [1, 5, 5]), "fill()");
+	a.copyWithin(0, 2);
+	assert(compareArray(a, [5, 5, 5]), "copyWithin()");
+	assert(compareArray(a.splice(0, 2, 1, 2), [5, 5]), "splice() result");
+	assert(compareArray(a, [1, 2, 5]), "splice()");
+	assert.throws(TypeError, function() {
+		a.splice(0, 1);
+	}, "splice() changing the length");
+	assert(compareArray(a, [1, 2, 5]), "not modified by a failed splice()");
+	assert.throws(TypeError, function() {
+		m.fill(1);
+	}, "fill() with a non-convertible value");
+
+	s.fill(7, 1);
+	s.copyWithin(2, 0);
+	assert(compareArray(s.splice(1, 0, 8), []), "splice() result");
+	assert(compareArray(s, [1, 8, 7, 1, 7]), "slice");
+	`
+	r := New()
+	a := [3]int{1, 2, 3}
+	s := []int{1, 2, 3, 4}
+	r.Set("a", &a)
+	r.Set("s", &s)
+	r.Set("m", &[]map[string]int{nil})
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+	if a != [3]int{1, 2, 5} {
+		t.Fatal(a)
+	}
+	if len(s) != 5 || s[1] != 8 || s[4] != 7 {
+		t.Fatal(s)
+	}
+}
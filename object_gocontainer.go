@@ -0,0 +1,405 @@
+package goja
+
+import (
+	"reflect"
+)
+
+// MapLike is implemented by Go containers (such as caches and indexes) that should appear to scripts as a Map.
+// When a value implementing MapLike is passed to Runtime.ToValue() it's wrapped into an object that has the
+// methods and the properties of Map.prototype (size, get(), set(), has(), delete(), clear(), forEach(), keys(),
+// values(), entries() and [Symbol.iterator]) which operate directly on the container, without copying.
+// Export() returns the original value.
+//
+// The keys are compared as the implementation sees fit, however SameValueZero (as used by Map) is expected.
+// The iterators take a snapshot of the keys when created: the entries deleted before they are reached are
+// skipped, the ones added are not visited.
+type MapLike interface {
+	// Len returns the number of entries.
+	Len() int
+	// Has returns true if the key exists.
+	Has(key Value) bool
+	// Get returns the value for the key or nil if it does not exist.
+	Get(key Value) Value
+	// Set sets the value for the key. Returning false causes a TypeError to be thrown.
+	Set(key, value Value) bool
+	// Delete removes the key and returns true if it existed.
+	Delete(key Value) bool
+	// Range calls fn for each entry until it returns false.
+	Range(fn func(key, value Value) bool)
+}
+
+// SetLike is implemented by Go containers that should appear to scripts as a Set, see MapLike. The wrapper
+// object has the size property and the add(), has(), delete(), clear(), forEach(), keys(), values(), entries()
+// and [Symbol.iterator] methods.
+type SetLike interface {
+	// Len returns the number of values.
+	Len() int
+	// Has returns true if the value is in the set.
+	Has(value Value) bool
+	// Add adds the value to the set. Returning false causes a TypeError to be thrown.
+	Add(value Value) bool
+	// Delete removes the value and returns true if it was in the set.
+	Delete(value Value) bool
+	// Range calls fn for each value until it returns false.
+	Range(fn func(value Value) bool)
+}
+
+type goMapLikeObject struct {
+	baseObject
+	m MapLike
+}
+
+type goSetLikeObject struct {
+	baseObject
+	s SetLike
+}
+
+type goContainerIterObject struct {
+	baseObject
+	mapLike  MapLike
+	setLike  SetLike
+	snapshot []Value
+	idx      int
+	kind     iterationKind
+}
+
+func (r *Runtime) newGoMapLike(m MapLike) *Object {
+	v := &Object{runtime: r}
+	o := &goMapLikeObject{
+		m: m,
+	}
+	o.class = classObject
+	o.val = v
+	o.extensible = true
+	v.self = o
+	o.prototype = r.getGoMapLikePrototype()
+	o.init()
+	return v
+}
+
+func (o *goMapLikeObject) export(*objectExportCtx) interface{} {
+	return o.m
+}
+
+func (o *goMapLikeObject) exportType() reflect.Type {
+	return reflect.TypeOf(o.m)
+}
+
+func (r *Runtime) newGoSetLike(s SetLike) *Object {
+	v := &Object{runtime: r}
+	o := &goSetLikeObject{
+		s: s,
+	}
+	o.class = classObject
+	o.val = v
+	o.extensible = true
+	v.self = o
+	o.prototype = r.getGoSetLikePrototype()
+	o.init()
+	return v
+}
+
+func (o *goSetLikeObject) export(*objectExportCtx) interface{} {
+	return o.s
+}
+
+func (o *goSetLikeObject) exportType() reflect.Type {
+	return reflect.TypeOf(o.s)
+}
+
+// normalizeContainerKey converts -0 to +0, as Map.prototype.set() and Set.prototype.add() do.
+func normalizeContainerKey(key Value) Value {
+	if key == _negativeZero {
+		return intToValue(0)
+	}
+	return key
+}
+
+// containerKeys returns a snapshot of the keys of the MapLike or the values of the SetLike (whichever is not nil).
+func containerKeys(mapLike MapLike, setLike SetLike) []Value {
+	var keys []Value
+	if mapLike != nil {
+		keys = make([]Value, 0, mapLike.Len())
+		mapLike.Range(func(key, _ Value) bool {
+			keys = append(keys, key)
+			return true
+		})
+	} else {
+		keys = make([]Value, 0, setLike.Len())
+		setLike.Range(func(value Value) bool {
+			keys = append(keys, value)
+			return true
+		})
+	}
+	return keys
+}
+
+func (r *Runtime) createGoContainerIterator(mapLike MapLike, setLike SetLike, kind iterationKind) *Object {
+	v := &Object{runtime: r}
+	o := &goContainerIterObject{
+		mapLike:  mapLike,
+		setLike:  setLike,
+		snapshot: containerKeys(mapLike, setLike),
+		kind:     kind,
+	}
+	o.class = classObject
+	o.val = v
+	o.extensible = true
+	v.self = o
+	o.prototype = r.getGoContainerIteratorPrototype()
+	o.init()
+	return v
+}
+
+func (o *goContainerIterObject) next() Value {
+	r := o.val.runtime
+	for o.idx < len(o.snapshot) {
+		key := o.snapshot[o.idx]
+		o.idx++
+		var value Value
+		if o.mapLike != nil {
+			if !o.mapLike.Has(key) {
+				continue
+			}
+			value = nilSafe(o.mapLike.Get(key))
+		} else {
+			if !o.setLike.Has(key) {
+				continue
+			}
+			value = key
+		}
+		var result Value
+		switch o.kind {
+		case iterationKindKey:
+			result = key
+		case iterationKindValue:
+			result = value
+		default:
+			result = r.newArrayValues([]Value{key, value})
+		}
+		return r.createIterResultObject(result, false)
+	}
+	o.snapshot = nil
+	return r.createIterResultObject(_undefined, true)
+}
+
+func (r *Runtime) toGoMapLike(v Value, method string) (*Object, MapLike) {
+	if obj, ok := v.(*Object); ok {
+		if o, ok := obj.self.(*goMapLikeObject); ok {
+			return obj, o.m
+		}
+	}
+	panic(r.NewTypeError("Method Map.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) toGoSetLike(v Value, method string) (*Object, SetLike) {
+	if obj, ok := v.(*Object); ok {
+		if o, ok := obj.self.(*goSetLikeObject); ok {
+			return obj, o.s
+		}
+	}
+	panic(r.NewTypeError("Method Set.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) goMapLikeProto_get(call FunctionCall) Value {
+	_, m := r.toGoMapLike(call.This, "get")
+	return nilSafe(m.Get(call.Argument(0)))
+}
+
+func (r *Runtime) goMapLikeProto_set(call FunctionCall) Value {
+	obj, m := r.toGoMapLike(call.This, "set")
+	if !m.Set(normalizeContainerKey(call.Argument(0)), call.Argument(1)) {
+		panic(r.NewTypeError("Cannot set the value of %s", call.Argument(0)))
+	}
+	return obj
+}
+
+func (r *Runtime) goMapLikeProto_has(call FunctionCall) Value {
+	_, m := r.toGoMapLike(call.This, "has")
+	return r.toBoolean(m.Has(call.Argument(0)))
+}
+
+func (r *Runtime) goMapLikeProto_delete(call FunctionCall) Value {
+	_, m := r.toGoMapLike(call.This, "delete")
+	return r.toBoolean(m.Delete(call.Argument(0)))
+}
+
+func (r *Runtime) goMapLikeProto_clear(call FunctionCall) Value {
+	_, m := r.toGoMapLike(call.This, "clear")
+	for _, key := range containerKeys(m, nil) {
+		m.Delete(key)
+	}
+	return _undefined
+}
+
+func (r *Runtime) goMapLikeProto_forEach(call FunctionCall) Value {
+	obj, m := r.toGoMapLike(call.This, "forEach")
+	callbackFn := r.toCallable(call.Argument(0))
+	t := call.Argument(1)
+	for _, key := range containerKeys(m, nil) {
+		if m.Has(key) {
+			callbackFn(FunctionCall{This: t, Arguments: []Value{nilSafe(m.Get(key)), key, obj}})
+		}
+	}
+	return _undefined
+}
+
+func (r *Runtime) goMapLikeProto_getSize(call FunctionCall) Value {
+	_, m := r.toGoMapLike(call.This, "size")
+	return intToValue(int64(m.Len()))
+}
+
+func (r *Runtime) goMapLikeProto_iterator(kind iterationKind, method string) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		_, m := r.toGoMapLike(call.This, method)
+		return r.createGoContainerIterator(m, nil, kind)
+	}
+}
+
+func (r *Runtime) goSetLikeProto_add(call FunctionCall) Value {
+	obj, s := r.toGoSetLike(call.This, "add")
+	if !s.Add(normalizeContainerKey(call.Argument(0))) {
+		panic(r.NewTypeError("Cannot add %s", call.Argument(0)))
+	}
+	return obj
+}
+
+func (r *Runtime) goSetLikeProto_has(call FunctionCall) Value {
+	_, s := r.toGoSetLike(call.This, "has")
+	return r.toBoolean(s.Has(call.Argument(0)))
+}
+
+func (r *Runtime) goSetLikeProto_delete(call FunctionCall) Value {
+	_, s := r.toGoSetLike(call.This, "delete")
+	return r.toBoolean(s.Delete(call.Argument(0)))
+}
+
+func (r *Runtime) goSetLikeProto_clear(call FunctionCall) Value {
+	_, s := r.toGoSetLike(call.This, "clear")
+	for _, value := range containerKeys(nil, s) {
+		s.Delete(value)
+	}
+	return _undefined
+}
+
+func (r *Runtime) goSetLikeProto_forEach(call FunctionCall) Value {
+	obj, s := r.toGoSetLike(call.This, "forEach")
+	callbackFn := r.toCallable(call.Argument(0))
+	t := call.Argument(1)
+	for _, value := range containerKeys(nil, s) {
+		if s.Has(value) {
+			callbackFn(FunctionCall{This: t, Arguments: []Value{value, value, obj}})
+		}
+	}
+	return _undefined
+}
+
+func (r *Runtime) goSetLikeProto_getSize(call FunctionCall) Value {
+	_, s := r.toGoSetLike(call.This, "size")
+	return intToValue(int64(s.Len()))
+}
+
+func (r *Runtime) goSetLikeProto_iterator(kind iterationKind, method string) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		_, s := r.toGoSetLike(call.This, method)
+		return r.createGoContainerIterator(nil, s, kind)
+	}
+}
+
+func (r *Runtime) goContainerIterProto_next(call FunctionCall) Value {
+	if obj, ok := call.This.(*Object); ok {
+		if iter, ok := obj.self.(*goContainerIterObject); ok {
+			return iter.next()
+		}
+	}
+	panic(r.NewTypeError("Method Iterator.prototype.next called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: call.This})))
+}
+
+func (r *Runtime) createGoMapLikeProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	o._putProp("clear", r.newNativeFunc(r.goMapLikeProto_clear, nil, "clear", nil, 0), true, false, true)
+	o._putProp("delete", r.newNativeFunc(r.goMapLikeProto_delete, nil, "delete", nil, 1), true, false, true)
+	entries := r.newNativeFunc(r.goMapLikeProto_iterator(iterationKindKeyValue, "entries"), nil, "entries", nil, 0)
+	o._putProp("entries", entries, true, false, true)
+	o._putProp("forEach", r.newNativeFunc(r.goMapLikeProto_forEach, nil, "forEach", nil, 1), true, false, true)
+	o._putProp("get", r.newNativeFunc(r.goMapLikeProto_get, nil, "get", nil, 1), true, false, true)
+	o._putProp("has", r.newNativeFunc(r.goMapLikeProto_has, nil, "has", nil, 1), true, false, true)
+	o._putProp("keys", r.newNativeFunc(r.goMapLikeProto_iterator(iterationKindKey, "keys"), nil, "keys", nil, 0), true, false, true)
+	o._putProp("set", r.newNativeFunc(r.goMapLikeProto_set, nil, "set", nil, 2), true, false, true)
+	o._putProp("values", r.newNativeFunc(r.goMapLikeProto_iterator(iterationKindValue, "values"), nil, "values", nil, 0), true, false, true)
+	o.setOwnStr("size", &valueProperty{
+		getterFunc:   r.newNativeFunc(r.goMapLikeProto_getSize, nil, "get size", nil, 0),
+		accessor:     true,
+		writable:     true,
+		configurable: true,
+	}, true)
+
+	o._putSym(SymIterator, valueProp(entries, true, false, true))
+	o._putSym(SymToStringTag, valueProp(asciiString(classMap), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) getGoMapLikePrototype() *Object {
+	var o *Object
+	if o = r.global.GoMapLikePrototype; o == nil {
+		o = &Object{runtime: r}
+		r.global.GoMapLikePrototype = o
+		o.self = r.createGoMapLikeProto(o)
+	}
+	return o
+}
+
+func (r *Runtime) createGoSetLikeProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	o._putProp("add", r.newNativeFunc(r.goSetLikeProto_add, nil, "add", nil, 1), true, false, true)
+	o._putProp("clear", r.newNativeFunc(r.goSetLikeProto_clear, nil, "clear", nil, 0), true, false, true)
+	o._putProp("delete", r.newNativeFunc(r.goSetLikeProto_delete, nil, "delete", nil, 1), true, false, true)
+	o._putProp("entries", r.newNativeFunc(r.goSetLikeProto_iterator(iterationKindKeyValue, "entries"), nil, "entries", nil, 0), true, false, true)
+	o._putProp("forEach", r.newNativeFunc(r.goSetLikeProto_forEach, nil, "forEach", nil, 1), true, false, true)
+	o._putProp("has", r.newNativeFunc(r.goSetLikeProto_has, nil, "has", nil, 1), true, false, true)
+	values := r.newNativeFunc(r.goSetLikeProto_iterator(iterationKindValue, "values"), nil, "values", nil, 0)
+	o._putProp("keys", values, true, false, true)
+	o._putProp("values", values, true, false, true)
+	o.setOwnStr("size", &valueProperty{
+		getterFunc:   r.newNativeFunc(r.goSetLikeProto_getSize, nil, "get size", nil, 0),
+		accessor:     true,
+		writable:     true,
+		configurable: true,
+	}, true)
+
+	o._putSym(SymIterator, valueProp(values, true, false, true))
+	o._putSym(SymToStringTag, valueProp(asciiString(classSet), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) getGoSetLikePrototype() *Object {
+	var o *Object
+	if o = r.global.GoSetLikePrototype; o == nil {
+		o = &Object{runtime: r}
+		r.global.GoSetLikePrototype = o
+		o.self = r.createGoSetLikeProto(o)
+	}
+	return o
+}
+
+func (r *Runtime) createGoContainerIterProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.getIteratorPrototype(), classObject)
+
+	o._putProp("next", r.newNativeFunc(r.goContainerIterProto_next, nil, "next", nil, 0), true, false, true)
+
+	return o
+}
+
+func (r *Runtime) getGoContainerIteratorPrototype() *Object {
+	var o *Object
+	if o = r.global.GoContainerIteratorPrototype; o == nil {
+		o = &Object{runtime: r}
+		r.global.GoContainerIteratorPrototype = o
+		o.self = r.createGoContainerIterProto(o)
+	}
+	return o
+}
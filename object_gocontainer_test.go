@@ -0,0 +1,152 @@
+package goja
+
+import (
+	"sort"
+	"testing"
+)
+
+type testCache struct {
+	keys   []string
+	values map[string]Value
+}
+
+func (c *testCache) Len() int {
+	return len(c.values)
+}
+
+func (c *testCache) Has(key Value) bool {
+	_, exists := c.values[key.String()]
+	return exists
+}
+
+func (c *testCache) Get(key Value) Value {
+	return c.values[key.String()]
+}
+
+func (c *testCache) Set(key, value Value) bool {
+	k := key.String()
+	if k == "readonly" {
+		return false
+	}
+	if _, exists := c.values[k]; !exists {
+		c.keys = append(c.keys, k)
+	}
+	c.values[k] = value
+	return true
+}
+
+func (c *testCache) Delete(key Value) bool {
+	k := key.String()
+	if _, exists := c.values[k]; !exists {
+		return false
+	}
+	delete(c.values, k)
+	for i, k1 := range c.keys {
+		if k1 == k {
+			c.keys = append(c.keys[:i], c.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (c *testCache) Range(fn func(key, value Value) bool) {
+	for _, k := range c.keys {
+		if !fn(newStringValue(k), c.values[k]) {
+			return
+		}
+	}
+}
+
+type testIndex map[int64]struct{}
+
+func (s testIndex) Len() int {
+	return len(s)
+}
+
+func (s testIndex) Has(value Value) bool {
+	_, exists := s[value.ToInteger()]
+	return exists
+}
+
+func (s testIndex) Add(value Value) bool {
+	s[value.ToInteger()] = struct{}{}
+	return true
+}
+
+func (s testIndex) Delete(value Value) bool {
+	_, exists := s[value.ToInteger()]
+	delete(s, value.ToInteger())
+	return exists
+}
+
+func (s testIndex) Range(fn func(value Value) bool) {
+	values := make([]int64, 0, len(s))
+	for v := range s {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	for _, v := range values {
+		if !fn(valueInt(v)) {
+			return
+		}
+	}
+}
+
+func TestMapLike(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(cache.size, 2, "size");
+	assert.sameValue(cache.get("a"), 1, "get");
+	assert.sameValue(cache.get("x"), undefined, "get missing");
+	assert.sameValue(cache.set("c", 3), cache, "set returns this");
+	assert(cache.has("c"), "has");
+	assert(compareArray([...cache.keys()], ["a", "b", "c"]), "keys");
+	assert(compareArray([...cache.values()], [1, 2, 3]), "values");
+	assert(compareArray([...cache].map(e => e.join("=")), ["a=1", "b=2", "c=3"]), "iterator");
+	var seen = [];
+	cache.forEach(function(v, k, m) {
+		assert.sameValue(m, cache);
+		seen.push(k + v + this.suffix);
+	}, {suffix: "!"});
+	assert(compareArray(seen, ["a1!", "b2!", "c3!"]), "forEach");
+	assert.sameValue(new Map(cache).get("b"), 2, "new Map()");
+	assert.sameValue(Object.prototype.toString.call(cache), "[object Map]");
+	assert.throws(TypeError, () => cache.set("readonly", 1));
+
+	var it = cache.entries();
+	it.next();
+	cache.delete("b");
+	assert.sameValue(it.next().value[0], "c", "deleted entries are skipped");
+	assert(it.next().done);
+	assert.sameValue(cache.delete("b"), false);
+	assert.throws(TypeError, () => cache.get.call(new Map(), "a"));
+
+	assert.sameValue(index.size, 2);
+	assert(index.has(10));
+	assert.sameValue(index.add(5), index);
+	assert(compareArray([...index], [5, 10, 20]), "set iterator");
+	assert(compareArray([...index.entries()][0], [5, 5]), "set entries");
+	assert.sameValue(index.keys, index.values);
+	assert(index.delete(10));
+	index.clear();
+	assert.sameValue(index.size, 0, "clear");
+	`
+	r := New()
+	cache := &testCache{values: map[string]Value{}}
+	cache.Set(newStringValue("a"), valueInt(1))
+	cache.Set(newStringValue("b"), valueInt(2))
+	index := testIndex{10: {}, 20: {}}
+	r.Set("cache", cache)
+	r.Set("index", index)
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	if cache.Len() != 2 || !cache.Has(newStringValue("c")) {
+		t.Fatal(cache.keys)
+	}
+	if len(index) != 0 {
+		t.Fatal(index)
+	}
+	if r.Get("cache").Export() != cache {
+		t.Fatal("Unexpected export")
+	}
+}
@@ -0,0 +1,112 @@
+package goja
+
+import "reflect"
+
+// goIdentity identifies a Go map, slice or pointer wrapped into an Object. Different wrappers of the same Go value
+// have the same identity, which is used to detect cycles in Go structures and to alias their wrappers, see
+// Runtime.SetPreserveGoIdentity().
+type goIdentity struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+func reflectGoIdentity(v reflect.Value) (goIdentity, bool) {
+	switch v.Kind() {
+	case reflect.Map, reflect.Ptr:
+		if !v.IsNil() {
+			return goIdentity{ptr: v.Pointer(), typ: v.Type()}, true
+		}
+	case reflect.Slice:
+		if v.Len() > 0 {
+			return goIdentity{ptr: v.Pointer(), len: v.Len(), typ: v.Type()}, true
+		}
+	}
+	return goIdentity{}, false
+}
+
+// goIdentity returns the identity of the Go value wrapped by the object, if it's a map, a slice or a pointer.
+func (o *Object) goIdentity() (goIdentity, bool) {
+	switch obj := o.self.(type) {
+	case *objectGoMapSimple:
+		if obj.ordered != nil {
+			return reflectGoIdentity(reflect.ValueOf(obj.ordered))
+		}
+		return reflectGoIdentity(reflect.ValueOf(obj.data))
+	case *objectGoSlice:
+		return reflectGoIdentity(reflect.ValueOf(*obj.data))
+	case *objectGoMapReflect:
+		return reflectGoIdentity(obj.fieldsValue)
+	case *objectGoSliceReflect:
+		return reflectGoIdentity(obj.fieldsValue)
+	case *objectGoArrayReflect:
+		return reflectGoIdentity(obj.origValue)
+	case *objectGoReflect:
+		return reflectGoIdentity(obj.origValue)
+	}
+	return goIdentity{}, false
+}
+
+// objectStack is the stack of the objects being traversed by an algorithm which needs to detect cycles, such as
+// JSON.stringify(). Wrappers of the same Go value are treated as the same object, otherwise a cyclic Go structure
+// would appear infinitely deep.
+type objectStack struct {
+	objects []*Object
+	ids     []goIdentity
+}
+
+func (s *objectStack) contains(o *Object) bool {
+	id, isGo := o.goIdentity()
+	for i, obj := range s.objects {
+		if obj == o || isGo && s.ids[i] == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *objectStack) push(o *Object) {
+	id, _ := o.goIdentity()
+	s.objects = append(s.objects, o)
+	s.ids = append(s.ids, id)
+}
+
+func (s *objectStack) pop() {
+	l := len(s.objects) - 1
+	s.objects[l] = nil
+	s.objects = s.objects[:l]
+	s.ids = s.ids[:l]
+}
+
+func (s *objectStack) len() int {
+	return len(s.objects)
+}
+
+// SetPreserveGoIdentity controls whether ToValue() returns the same Object each time it's called with the same Go
+// map, slice or pointer (the map[string]interface{} and []interface{} wrappers included). This applies to the nested
+// values as well, so that a cyclic Go structure appears cyclic in JavaScript (e.g. node.next.next === node) and can
+// be traversed using the usual techniques, such as a Set of visited objects. The wrappers are retained for the
+// lifetime of the Runtime (or until the option is turned off), so this is not suitable for the Runtimes which
+// convert an unbounded number of Go values.
+//
+// By default a new wrapper is created each time, so a cyclic Go structure looks like an infinitely deep one.
+// The built-in algorithms which traverse values, such as JSON.stringify() and MarshalBinary(), detect the cycles
+// by comparing the wrapped Go values regardless of this option and throw a TypeError.
+func (r *Runtime) SetPreserveGoIdentity(preserve bool) {
+	if preserve {
+		if r.goWrappers == nil {
+			r.goWrappers = make(map[goIdentity]*Object)
+		}
+	} else {
+		r.goWrappers = nil
+	}
+}
+
+func (r *Runtime) getGoWrapper(v reflect.Value) (id goIdentity, obj *Object, cacheable bool) {
+	if r.goWrappers != nil {
+		if id, cacheable = reflectGoIdentity(v); cacheable {
+			obj = r.goWrappers[id]
+		}
+	}
+	return
+}
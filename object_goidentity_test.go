@@ -0,0 +1,115 @@
+package goja
+
+import (
+	"testing"
+)
+
+type testGoCycleNode struct {
+	Name     string
+	Next     *testGoCycleNode
+	Children []*testGoCycleNode
+	Attrs    map[string]interface{}
+}
+
+func newTestGoCycleGraph() (*testGoCycleNode, map[string]interface{}, []interface{}) {
+	a := &testGoCycleNode{Name: "a"}
+	b := &testGoCycleNode{Name: "b", Next: a}
+	a.Next = b
+	a.Children = []*testGoCycleNode{a, b}
+	m := map[string]interface{}{"node": a}
+	m["self"] = m
+	a.Attrs = m
+	b.Attrs = map[string]interface{}{"parent": m}
+	s := make([]interface{}, 2)
+	s[0] = s
+	s[1] = map[string]interface{}{"list": s, "node": b}
+	return a, m, s
+}
+
+func TestGoCyclesDetected(t *testing.T) {
+	vm := New()
+	a, m, s := newTestGoCycleGraph()
+	vm.Set("a", a)
+	vm.Set("m", m)
+	vm.Set("s", s)
+
+	_, err := vm.RunString(`
+	for (const v of [a, m, s, a.Children, m.self.node.Next.Attrs, s[1]]) {
+		let threw = false;
+		try {
+			JSON.stringify(v);
+		} catch (e) {
+			threw = e instanceof TypeError;
+		}
+		if (!threw) {
+			throw new Error("Cycle was not detected in " + Object.keys(v));
+		}
+	}
+	if (JSON.stringify(a.Children[1].Name) !== '"b"') {
+		throw new Error("unexpected result");
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []interface{}{a, m, s} {
+		_, err := vm.MarshalBinary(CBOR, vm.ToValue(v))
+		if _, ok := err.(*Exception); !ok {
+			t.Fatalf("Unexpected error for %T: %v", v, err)
+		}
+	}
+}
+
+func TestGoCyclesPreserveIdentity(t *testing.T) {
+	vm := New()
+	vm.SetPreserveGoIdentity(true)
+	a, m, s := newTestGoCycleGraph()
+	vm.Set("a", a)
+	vm.Set("m", m)
+	vm.Set("s", s)
+
+	_, err := vm.RunString(`
+	function assert(cond, msg) {
+		if (!cond) {
+			throw new Error(msg);
+		}
+	}
+	assert(a.Next.Next === a, "a.Next.Next");
+	assert(a.Children[0] === a && a.Children[1] === a.Next, "a.Children");
+	assert(a.Attrs === m && m.self === m && m.node === a, "m");
+	assert(a.Next.Attrs.parent === m, "b.Attrs.parent");
+	assert(s[0] === s && s[1].list === s && s[1].node === a.Next, "s");
+
+	function count(root) {
+		const seen = new Set();
+		const queue = [root];
+		while (queue.length > 0) {
+			const v = queue.pop();
+			if (v === null || typeof v !== "object" || seen.has(v)) {
+				continue;
+			}
+			seen.add(v);
+			for (const key of Object.keys(v)) {
+				queue.push(v[key]);
+			}
+		}
+		return seen.size;
+	}
+	// a, b, a.Children, b.Children (an empty array), m, b.Attrs
+	assert(count(a) === 6, "count(a): " + count(a));
+	// the same plus s and s[1]
+	assert(count(s) === 8, "count(s): " + count(s));
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vm.ToValue(m) != vm.ToValue(m) {
+		t.Fatal("ToValue() returned different objects")
+	}
+	vm.SetPreserveGoIdentity(false)
+	if vm.ToValue(m) == vm.ToValue(m) {
+		t.Fatal("ToValue() returned the same object")
+	}
+}
@@ -2,12 +2,15 @@ package goja
 
 import (
 	"reflect"
+	"sort"
 
 	"github.com/dop251/goja/unistring"
 )
 
 type objectGoMapSimple struct {
 	data map[string]interface{}
+	// ordered is set if the map is an *OrderedMap, data is then its values.
+	ordered *OrderedMap
 	baseObject
 }
 
@@ -33,6 +36,10 @@ func (o *objectGoMapSimple) getStr(name unistring.String, receiver Value) Value
 	return o.baseObject.getStr(name, receiver)
 }
 
+func (o *objectGoMapSimple) getSym(s *Symbol, receiver Value) Value {
+	return goMapGetSym(&o.baseObject, s, receiver)
+}
+
 func (o *objectGoMapSimple) getOwnPropStr(name unistring.String) Value {
 	if v := o._getStr(name.String()); v != nil {
 		return v
@@ -57,11 +64,19 @@ func (o *objectGoMapSimple) setOwnStr(name unistring.String, val Value, throw bo
 		o.val.runtime.typeErrorResult(throw, "Cannot add property %s, object is not extensible", name)
 		return false
 	} else {
-		o.data[n] = val.Export()
+		o.put(n, val.Export())
 	}
 	return true
 }
 
+func (o *objectGoMapSimple) put(name string, v interface{}) {
+	if o.ordered != nil {
+		o.ordered.Set(name, v)
+	} else {
+		o.data[name] = v
+	}
+}
+
 func trueValIfPresent(present bool) Value {
 	if present {
 		return valueTrue
@@ -89,7 +104,7 @@ func (o *objectGoMapSimple) defineOwnPropertyStr(name unistring.String, descr Pr
 
 	n := name.String()
 	if o.extensible || o._hasStr(n) {
-		o.data[n] = descr.Value.Export()
+		o.put(n, descr.Value.Export())
 		return true
 	}
 
@@ -116,7 +131,11 @@ func (o *objectGoMapSimple) assertCallable() (call func(FunctionCall) Value, ok
 */
 
 func (o *objectGoMapSimple) deleteStr(name unistring.String, _ bool) bool {
-	delete(o.data, name.String())
+	if o.ordered != nil {
+		o.ordered.Delete(name.String())
+	} else {
+		delete(o.data, name.String())
+	}
 	return true
 }
 
@@ -138,33 +157,46 @@ func (i *gomapPropIter) next() (propIterItem, iterNextFunc) {
 	return propIterItem{}, nil
 }
 
-func (o *objectGoMapSimple) iterateStringKeys() iterNextFunc {
-	propNames := make([]string, len(o.data))
-	i := 0
+func (o *objectGoMapSimple) sortedKeys() []string {
+	if o.ordered != nil {
+		return append([]string(nil), o.ordered.keys...)
+	}
+	keys := make([]string, 0, len(o.data))
 	for key := range o.data {
-		propNames[i] = key
-		i++
+		keys = append(keys, key)
 	}
+	if o.val.runtime.mapKeyOrder == MapKeyOrderSorted {
+		sortMapKeys(keys)
+	}
+	return keys
+}
 
+func (o *objectGoMapSimple) iterateStringKeys() iterNextFunc {
 	return (&gomapPropIter{
 		o:         o,
-		propNames: propNames,
+		propNames: o.sortedKeys(),
 	}).next
 }
 
 func (o *objectGoMapSimple) stringKeys(_ bool, accum []Value) []Value {
 	// all own keys are enumerable
-	for key := range o.data {
+	for _, key := range o.sortedKeys() {
 		accum = append(accum, newStringValue(key))
 	}
 	return accum
 }
 
 func (o *objectGoMapSimple) export(*objectExportCtx) interface{} {
+	if o.ordered != nil {
+		return o.ordered
+	}
 	return o.data
 }
 
 func (o *objectGoMapSimple) exportType() reflect.Type {
+	if o.ordered != nil {
+		return reflectTypeOrderedMap
+	}
 	return reflectTypeMap
 }
 
@@ -174,3 +206,21 @@ func (o *objectGoMapSimple) equal(other objectImpl) bool {
 	}
 	return false
 }
+
+// mapKeyLess defines the enumeration order of Go map keys. Go maps do not preserve the insertion order,
+// so in order to make the enumeration deterministic the keys are ordered as close to
+// https://262.ecma-international.org/#sec-ordinaryownpropertykeys as possible: array indexes first
+// in ascending numeric order, followed by the rest of the keys in lexicographic order.
+func mapKeyLess(a, b string) bool {
+	ai, bi := strToArrayIdx(unistring.String(a)), strToArrayIdx(unistring.String(b))
+	if ai != bi {
+		return ai < bi
+	}
+	return a < b
+}
+
+func sortMapKeys(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		return mapKeyLess(keys[i], keys[j])
+	})
+}
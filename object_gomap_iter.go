@@ -0,0 +1,97 @@
+package goja
+
+type goMapIterObject struct {
+	baseObject
+	obj   *Object
+	names []Value
+	idx   int
+}
+
+func (r *Runtime) createGoMapIterator(obj *Object) *Object {
+	o := &Object{runtime: r}
+	mi := &goMapIterObject{
+		obj:   obj,
+		names: obj.self.stringKeys(false, nil),
+	}
+	mi.class = classObject
+	mi.val = o
+	mi.extensible = true
+	o.self = mi
+	mi.prototype = r.getGoMapIteratorPrototype()
+	mi.init()
+	return o
+}
+
+func (mi *goMapIterObject) next() Value {
+	r := mi.val.runtime
+	for mi.idx < len(mi.names) {
+		key := mi.names[mi.idx]
+		mi.idx++
+		name := key.string()
+		// the keys deleted during the iteration are skipped
+		if mi.obj.self.hasOwnPropertyStr(name) {
+			value := nilSafe(mi.obj.self.getStr(name, nil))
+			return r.createIterResultObject(r.newArrayValues([]Value{key, value}), false)
+		}
+	}
+	mi.obj = nil
+	mi.names = nil
+	return r.createIterResultObject(_undefined, true)
+}
+
+func (r *Runtime) goMap_iterator(call FunctionCall) Value {
+	thisObj := r.toObject(call.This)
+	switch thisObj.self.(type) {
+	case *objectGoMapSimple, *objectGoMapReflect:
+	default:
+		panic(r.NewTypeError("Go map [Symbol.iterator] called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return r.createGoMapIterator(thisObj)
+}
+
+// getGoMapIterator returns the [Symbol.iterator] method of the wrapped Go maps. It's not a property of the
+// prototype (which is Object.prototype) but it's returned when the symbol is not found in the prototype chain,
+// see goMapGetSym().
+func (r *Runtime) getGoMapIterator() *Object {
+	if r.global.GoMapIterator == nil {
+		r.global.GoMapIterator = r.newNativeFunc(r.goMap_iterator, nil, "[Symbol.iterator]", nil, 0)
+	}
+	return r.global.GoMapIterator
+}
+
+// goMapGetSym is the getSym() implementation for the wrapped Go maps. It makes them iterable over the
+// [key, value] pairs, so they can be used with for-of, the spread syntax, new Map() and so on.
+func goMapGetSym(o *baseObject, s *Symbol, receiver Value) Value {
+	v := o.getSym(s, receiver)
+	if v == nil && s == SymIterator {
+		return o.val.runtime.getGoMapIterator()
+	}
+	return v
+}
+
+func (r *Runtime) goMapIterProto_next(call FunctionCall) Value {
+	thisObj := r.toObject(call.This)
+	if iter, ok := thisObj.self.(*goMapIterObject); ok {
+		return iter.next()
+	}
+	panic(r.NewTypeError("Method Go Map Iterator.prototype.next called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
+}
+
+func (r *Runtime) createGoMapIterProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.getIteratorPrototype(), classObject)
+
+	o._putProp("next", r.newNativeFunc(r.goMapIterProto_next, nil, "next", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString("Go Map Iterator"), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) getGoMapIteratorPrototype() *Object {
+	var o *Object
+	if o = r.global.GoMapIteratorPrototype; o == nil {
+		o = &Object{runtime: r}
+		r.global.GoMapIteratorPrototype = o
+		o.self = r.createGoMapIterProto(o)
+	}
+	return o
+}
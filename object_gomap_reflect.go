@@ -2,6 +2,7 @@ package goja
 
 import (
 	"reflect"
+	"sort"
 
 	"github.com/dop251/goja/unistring"
 )
@@ -72,6 +73,10 @@ func (o *objectGoMapReflect) getIdx(idx valueInt, receiver Value) Value {
 	return o.objectGoReflect.getIdx(idx, receiver)
 }
 
+func (o *objectGoMapReflect) getSym(s *Symbol, receiver Value) Value {
+	return goMapGetSym(&o.baseObject, s, receiver)
+}
+
 func (o *objectGoMapReflect) getOwnPropStr(name unistring.String) Value {
 	if v := o._getStr(name.String()); v != nil {
 		return &valueProperty{
@@ -230,36 +235,79 @@ func (o *objectGoMapReflect) deleteIdx(idx valueInt, throw bool) bool {
 }
 
 type gomapReflectPropIter struct {
-	o    *objectGoMapReflect
-	keys []reflect.Value
-	idx  int
+	o     *objectGoMapReflect
+	keys  []reflect.Value
+	names []string
+	idx   int
 }
 
 func (i *gomapReflectPropIter) next() (propIterItem, iterNextFunc) {
 	for i.idx < len(i.keys) {
 		key := i.keys[i.idx]
+		name := i.names[i.idx]
 		v := i.o.fieldsValue.MapIndex(key)
 		i.idx++
 		if v.IsValid() {
-			return propIterItem{name: newStringValue(key.String()), enumerable: _ENUM_TRUE}, i.next
+			return propIterItem{name: newStringValue(name), enumerable: _ENUM_TRUE}, i.next
 		}
 	}
 
 	return propIterItem{}, nil
 }
 
+func (o *objectGoMapReflect) keyToString(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	return o.val.runtime.ToValue(key.Interface()).String()
+}
+
+// sortedKeys returns the map keys along with their string representations, ordered by mapKeyLess.
+func (o *objectGoMapReflect) sortedKeys() ([]reflect.Value, []string) {
+	keys := o.fieldsValue.MapKeys()
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = o.keyToString(key)
+	}
+	if o.val.runtime.mapKeyOrder == MapKeyOrderSorted {
+		sort.Sort(&mapReflectKeySorter{keys: keys, names: names})
+	}
+	return keys, names
+}
+
 func (o *objectGoMapReflect) iterateStringKeys() iterNextFunc {
+	keys, names := o.sortedKeys()
 	return (&gomapReflectPropIter{
-		o:    o,
-		keys: o.fieldsValue.MapKeys(),
+		o:     o,
+		keys:  keys,
+		names: names,
 	}).next
 }
 
 func (o *objectGoMapReflect) stringKeys(_ bool, accum []Value) []Value {
 	// all own keys are enumerable
-	for _, key := range o.fieldsValue.MapKeys() {
-		accum = append(accum, newStringValue(key.String()))
+	_, names := o.sortedKeys()
+	for _, name := range names {
+		accum = append(accum, newStringValue(name))
 	}
 
 	return accum
 }
+
+type mapReflectKeySorter struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (s *mapReflectKeySorter) Len() int {
+	return len(s.keys)
+}
+
+func (s *mapReflectKeySorter) Less(i, j int) bool {
+	return mapKeyLess(s.names[i], s.names[j])
+}
+
+func (s *mapReflectKeySorter) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+}
@@ -307,3 +307,22 @@ func TestGoMapReflectElt(t *testing.T) {
 
 	r.testScript(SCRIPT, valueTrue, t)
 }
+
+func TestGoMapReflectKeyOrder(t *testing.T) {
+	const SCRIPT = `
+	assert(compareArray(Object.keys(s), ["1", "10", "01", "a", "b"]), "string keys");
+	assert(compareArray(Object.keys(i), ["2", "10", "-1"]), "int keys");
+	var forIn = [];
+	for (var key in i) {
+		forIn.push(key);
+	}
+	assert(compareArray(forIn, ["2", "10", "-1"]), "for-in");
+	var sym = Symbol("sym");
+	i[sym] = true;
+	assert(compareArray(Reflect.ownKeys(i), ["2", "10", "-1", sym]), "Reflect.ownKeys()");
+	`
+	r := New()
+	r.Set("s", map[string]int{"b": 0, "10": 0, "a": 0, "01": 0, "1": 0})
+	r.Set("i", map[int]string{10: "", -1: "", 2: ""})
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
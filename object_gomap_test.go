@@ -326,3 +326,131 @@ func TestGoMapUnicode(t *testing.T) {
 		t.Fatalf("Unexpected value: %v", res)
 	}
 }
+
+func TestGoMapKeyOrder(t *testing.T) {
+	const SCRIPT = `
+	var expected = ["1", "2", "10", "", "a", "b", "z"];
+	assert(compareArray(Object.keys(m), expected), "Object.keys()");
+	assert(compareArray(Object.getOwnPropertyNames(m), expected), "Object.getOwnPropertyNames()");
+	var s = Symbol("s");
+	m[s] = true;
+	assert(compareArray(Reflect.ownKeys(m), expected.concat(s)), "Reflect.ownKeys()");
+	assert(compareArray(Object.getOwnPropertySymbols(m), [s]), "Object.getOwnPropertySymbols()");
+	var forIn = [];
+	for (var key in m) {
+		forIn.push(key);
+	}
+	assert(compareArray(forIn, expected), "for-in");
+	assert.sameValue(JSON.stringify(m), '{"1":1,"2":2,"10":10,"":0,"a":"a","b":"b","z":"z"}', "JSON.stringify()");
+	`
+	r := New()
+	r.Set("m", map[string]interface{}{
+		"z":  "z",
+		"10": 10,
+		"b":  "b",
+		"2":  2,
+		"":   0,
+		"a":  "a",
+		"1":  1,
+	})
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestGoMapKeyOrderUnspecified(t *testing.T) {
+	r := New()
+	r.SetMapKeyOrder(MapKeyOrderUnspecified)
+	m := map[string]interface{}{}
+	for i := 0; i < 20; i++ {
+		m[string(rune('a'+i))] = i
+	}
+	r.Set("m", m)
+	v, err := r.RunString(`Object.keys(m).sort().join("")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "abcdefghijklmnopqrst" {
+		t.Fatal(s)
+	}
+}
+
+func TestGoOrderedMap(t *testing.T) {
+	const SCRIPT = `
+	assert(compareArray(Object.keys(m), ["z", "10", "a", "1"]), "Object.keys()");
+	var forIn = [];
+	for (var key in m) {
+		forIn.push(key);
+	}
+	assert(compareArray(forIn, ["z", "10", "a", "1"]), "for-in");
+	m.b = "b";
+	delete m["10"];
+	m.z = "zz";
+	Object.defineProperty(m, "c", {value: "c", writable: true, enumerable: true});
+	assert.sameValue(JSON.stringify(m), '{"z":"zz","a":"a","1":1,"b":"b","c":"c"}', "JSON.stringify()");
+	`
+	r := New()
+	m := NewOrderedMap(0)
+	m.Set("z", "z")
+	m.Set("10", 10)
+	m.Set("a", "a")
+	m.Set("1", 1)
+	r.Set("m", m)
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	if keys := m.Keys(); len(keys) != 5 || keys[4] != "c" || m.Len() != 5 {
+		t.Fatal(keys)
+	}
+	if v, _ := m.Get("z"); v != "zz" {
+		t.Fatal(v)
+	}
+	if r.Get("m").Export() != m {
+		t.Fatal("Unexpected export")
+	}
+
+	var empty OrderedMap
+	r.Set("e", &empty)
+	if _, err := r.RunString(`e.x = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := empty.Get("x"); !ok || v != int64(1) {
+		t.Fatal(v)
+	}
+}
+
+func TestGoMapIterator(t *testing.T) {
+	const SCRIPT = `
+	var entries = [];
+	for (var [k, v] of m) {
+		entries.push(k + "=" + v);
+	}
+	assert(compareArray(entries, ["1=one", "a=x", "b=y"]), "for-of");
+	assert(compareArray([...m].map(e => e[0]), ["1", "a", "b"]), "spread");
+	assert.sameValue(new Map(m).get("b"), "y", "new Map()");
+	assert(compareArray(Object.values(m), ["one", "x", "y"]), "Object.values()");
+	assert(compareArray(Object.entries(m)[1], ["a", "x"]), "Object.entries()");
+	assert.sameValue(Object.getPrototypeOf(m), Object.prototype, "prototype");
+	assert(!Reflect.ownKeys(m).includes(Symbol.iterator), "own keys");
+
+	var it = m[Symbol.iterator]();
+	assert.sameValue(Object.prototype.toString.call(it), "[object Go Map Iterator]", "toStringTag");
+	it.next();
+	delete m.a;
+	assert.sameValue(it.next().value[0], "b", "deleted keys are skipped");
+	assert(it.next().done, "done");
+
+	for (var [k, v] of mr) {
+		assert.sameValue(typeof k, "string", "reflect map key");
+		assert.sameValue(v, "two", "reflect map value");
+	}
+	assert.throws(TypeError, function() {
+		m[Symbol.iterator].call({});
+	});
+	`
+	r := New()
+	r.Set("m", map[string]interface{}{
+		"b": "y",
+		"a": "x",
+		"1": "one",
+	})
+	r.Set("mr", map[int]string{2: "two"})
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
@@ -5,6 +5,9 @@ import (
 	"go/ast"
 	"reflect"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/dop251/goja/parser"
 	"github.com/dop251/goja/unistring"
@@ -16,6 +19,16 @@ type JsonEncodable interface {
 	JsonEncodable() interface{}
 }
 
+// PrimitiveConverter can be implemented by Go types to control how their values are converted to primitive
+// values when they are used in JavaScript, for example in comparisons, arithmetic or string concatenation.
+// The hint is "number", "string" or "default" as per the ECMAScript ToPrimitive() abstract operation. The result
+// is converted using Runtime.ToValue(); if it is not a primitive value a TypeError is thrown.
+// This takes precedence over the default conversions (i.e. based on the underlying kind, fmt.Stringer or error),
+// however a Symbol.toPrimitive method defined on the wrapper object in JavaScript is still called first.
+type PrimitiveConverter interface {
+	ToPrimitive(hint string) interface{}
+}
+
 // FieldNameMapper provides custom mapping between Go and JavaScript property names.
 type FieldNameMapper interface {
 	// FieldName returns a JavaScript name for the given struct field in the given type.
@@ -107,6 +120,7 @@ type objectGoReflect struct {
 	valueCache   map[string]reflectValueWrapper
 	toString     func() Value
 	valueOf      func() Value
+	toPrim       PrimitiveConverter
 	toJson       func() interface{}
 	origValue    reflect.Value
 	fieldsValue  reflect.Value
@@ -171,14 +185,16 @@ func (o *objectGoReflect) init() {
 
 	o.extensible = true
 
-	switch o.origValue.Interface().(type) {
+	switch v := o.origValue.Interface().(type) {
+	case PrimitiveConverter:
+		o.toPrim = v
 	case fmt.Stringer:
 		o.toString = o._toStringStringer
 	case error:
 		o.toString = o._toStringError
 	}
 
-	if o.toString != nil || o.valueOf != nil {
+	if o.toString != nil || o.valueOf != nil || o.toPrim != nil {
 		o.baseObject._putProp("toString", o.val.runtime.newNativeFunc(o.toStringFunc, nil, "toString", nil, 0), true, false, true)
 		o.baseObject._putProp("valueOf", o.val.runtime.newNativeFunc(o.valueOfFunc, nil, "valueOf", nil, 0), true, false, true)
 	}
@@ -195,7 +211,7 @@ func (o *objectGoReflect) init() {
 }
 
 func (o *objectGoReflect) toStringFunc(FunctionCall) Value {
-	return o.toPrimitiveString()
+	return o.toPrimitiveString().toString()
 }
 
 func (o *objectGoReflect) valueOfFunc(FunctionCall) Value {
@@ -442,7 +458,18 @@ func (o *objectGoReflect) _toStringError() Value {
 	return newStringValue(o.origValue.Interface().(error).Error())
 }
 
+func (o *objectGoReflect) convertToPrimitive(hint string) Value {
+	v := o.val.runtime.ToValue(o.toPrim.ToPrimitive(hint))
+	if _, ok := v.(*Object); ok {
+		panic(o.val.runtime.NewTypeError("Cannot convert object to primitive value"))
+	}
+	return v
+}
+
 func (o *objectGoReflect) toPrimitiveNumber() Value {
+	if o.toPrim != nil {
+		return o.convertToPrimitive("number")
+	}
 	if o.valueOf != nil {
 		return o.valueOf()
 	}
@@ -453,6 +480,9 @@ func (o *objectGoReflect) toPrimitiveNumber() Value {
 }
 
 func (o *objectGoReflect) toPrimitiveString() Value {
+	if o.toPrim != nil {
+		return o.convertToPrimitive("string")
+	}
 	if o.toString != nil {
 		return o.toString()
 	}
@@ -463,6 +493,9 @@ func (o *objectGoReflect) toPrimitiveString() Value {
 }
 
 func (o *objectGoReflect) toPrimitive() Value {
+	if o.toPrim != nil {
+		return o.convertToPrimitive("default")
+	}
 	if o.valueOf != nil {
 		return o.valueOf()
 	}
@@ -710,3 +743,139 @@ func TagFieldNameMapper(tagName string, uncapMethods bool) FieldNameMapper {
 func UncapFieldNameMapper() FieldNameMapper {
 	return uncapFieldNameMapper{}
 }
+
+type caseFieldNameMapper struct {
+	join  func(words []string) string
+	cache sync.Map
+}
+
+func (c *caseFieldNameMapper) name(s string) string {
+	if name, ok := c.cache.Load(s); ok {
+		return name.(string)
+	}
+	name := c.join(splitGoName(s))
+	c.cache.Store(s, name)
+	return name
+}
+
+func (c *caseFieldNameMapper) FieldName(_ reflect.Type, f reflect.StructField) string {
+	return c.name(f.Name)
+}
+
+func (c *caseFieldNameMapper) MethodName(_ reflect.Type, m reflect.Method) string {
+	return c.name(m.Name)
+}
+
+// splitGoName splits a Go identifier into words. Words start at an upper case letter following a lower case
+// letter or a digit, or at the last letter of a run of upper case letters followed by a lower case letter
+// (so that "HTTPServer" becomes "HTTP" and "Server", unless the lower case letter is a plural "s" as in "IDs").
+// Underscores separate words and are dropped.
+func splitGoName(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+	for i, c := range runes {
+		switch {
+		case c == '_':
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+		case unicode.IsUpper(c) && i > start:
+			prev := runes[i-1]
+			if !unicode.IsUpper(prev) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !isPluralSuffix(runes, i+1) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// isPluralSuffix returns true if runes[i] is a lone "s", like in "IDs".
+func isPluralSuffix(runes []rune, i int) bool {
+	return runes[i] == 's' && (i+1 == len(runes) || !unicode.IsLower(runes[i+1]))
+}
+
+func capitalizeWord(w string) string {
+	r, size := utf8.DecodeRuneInString(w)
+	return string(unicode.ToUpper(r)) + strings.ToLower(w[size:])
+}
+
+// CamelCaseFieldNameMapper returns a FieldNameMapper that converts struct field and method names into camelCase,
+// e.g. "UserID" becomes "userId" and "HTTPServer" becomes "httpServer". The converted names are cached, the
+// mapper can be shared between Runtimes.
+func CamelCaseFieldNameMapper() FieldNameMapper {
+	return &caseFieldNameMapper{
+		join: func(words []string) string {
+			for i, w := range words {
+				if i == 0 {
+					words[i] = strings.ToLower(w)
+				} else {
+					words[i] = capitalizeWord(w)
+				}
+			}
+			return strings.Join(words, "")
+		},
+	}
+}
+
+// SnakeCaseFieldNameMapper returns a FieldNameMapper that converts struct field and method names into snake_case,
+// e.g. "UserID" becomes "user_id" and "HTTPServer" becomes "http_server". The converted names are cached, the
+// mapper can be shared between Runtimes.
+func SnakeCaseFieldNameMapper() FieldNameMapper {
+	return &caseFieldNameMapper{
+		join: func(words []string) string {
+			return strings.ToLower(strings.Join(words, "_"))
+		},
+	}
+}
+
+// KebabCaseFieldNameMapper returns a FieldNameMapper that converts struct field and method names into kebab-case,
+// e.g. "UserID" becomes "user-id". As such names are not valid identifiers the properties have to be accessed
+// using the bracket notation (o["user-id"]). The converted names are cached, the mapper can be shared between
+// Runtimes.
+func KebabCaseFieldNameMapper() FieldNameMapper {
+	return &caseFieldNameMapper{
+		join: func(words []string) string {
+			return strings.ToLower(strings.Join(words, "-"))
+		},
+	}
+}
+
+type composedFieldNameMapper []FieldNameMapper
+
+func (c composedFieldNameMapper) FieldName(t reflect.Type, f reflect.StructField) string {
+	for _, m := range c {
+		if name := m.FieldName(t, f); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func (c composedFieldNameMapper) MethodName(t reflect.Type, m reflect.Method) string {
+	for _, mapper := range c {
+		if name := mapper.MethodName(t, m); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// ComposeFieldNameMappers returns a FieldNameMapper that tries the mappers in order and uses the first non-empty
+// name, for example to use the json tags where present and snake_case names otherwise:
+//
+//	vm.SetFieldNameMapper(goja.ComposeFieldNameMappers(
+//		goja.TagFieldNameMapper("json", false),
+//		goja.SnakeCaseFieldNameMapper(),
+//	))
+//
+// Note that a field is only hidden if all the mappers return "" for it, so in the example above a field tagged
+// with json:"-" gets a snake_case name. To hide such fields wrap the mappers into one that checks the tag first.
+func ComposeFieldNameMappers(mappers ...FieldNameMapper) FieldNameMapper {
+	return composedFieldNameMapper(append([]FieldNameMapper(nil), mappers...))
+}
@@ -1088,6 +1088,84 @@ func ExampleUncapFieldNameMapper() {
 	// Output: passed and passed too
 }
 
+func TestCaseFieldNameMappers(t *testing.T) {
+	tests := []struct {
+		name, camel, snake, kebab string
+	}{
+		{"Name", "name", "name", "name"},
+		{"UserID", "userId", "user_id", "user-id"},
+		{"HTTPServer", "httpServer", "http_server", "http-server"},
+		{"ID", "id", "id", "id"},
+		{"UserIDs", "userIds", "user_ids", "user-ids"},
+		{"X509Cert", "x509Cert", "x509_cert", "x509-cert"},
+		{"V2API", "v2Api", "v2_api", "v2-api"},
+		{"Snake_Case", "snakeCase", "snake_case", "snake-case"},
+		{"ÜberName", "überName", "über_name", "über-name"},
+	}
+	camel, snake, kebab := CamelCaseFieldNameMapper(), SnakeCaseFieldNameMapper(), KebabCaseFieldNameMapper()
+	for _, tc := range tests {
+		f := reflect.StructField{Name: tc.name}
+		m := reflect.Method{Name: tc.name}
+		for i := 0; i < 2; i++ { // the second iteration uses the cache
+			if n := camel.FieldName(nil, f); n != tc.camel {
+				t.Fatalf("camelCase(%s): %s", tc.name, n)
+			}
+			if n := snake.MethodName(nil, m); n != tc.snake {
+				t.Fatalf("snake_case(%s): %s", tc.name, n)
+			}
+			if n := kebab.FieldName(nil, f); n != tc.kebab {
+				t.Fatalf("kebab-case(%s): %s", tc.name, n)
+			}
+		}
+	}
+}
+
+type testCaseMapperS struct {
+	UserID     int
+	HTTPServer string `json:"server"`
+	Hidden     bool   `json:"-"`
+}
+
+func (s testCaseMapperS) GetUserID() int {
+	return s.UserID
+}
+
+func TestCaseFieldNameMappersRuntime(t *testing.T) {
+	vm := New()
+	vm.SetFieldNameMapper(SnakeCaseFieldNameMapper())
+	vm.Set("s", testCaseMapperS{UserID: 42, HTTPServer: "srv"})
+	vm.testScriptWithTestLib(`
+	assert.sameValue(s.user_id, 42);
+	assert.sameValue(s.http_server, "srv");
+	assert.sameValue(s.get_user_id(), 42);
+	assert.sameValue(s.UserID, undefined);
+	`, _undefined, t)
+
+	vm = New()
+	vm.SetFieldNameMapper(ComposeFieldNameMappers(TagFieldNameMapper("json", true), CamelCaseFieldNameMapper()))
+	vm.Set("s", testCaseMapperS{UserID: 42, HTTPServer: "srv"})
+	vm.testScriptWithTestLib(`
+	assert.sameValue(s.userId, 42);
+	assert.sameValue(s.server, "srv");
+	assert.sameValue(s.httpServer, undefined);
+	assert.sameValue(s.hidden, false);
+	assert.sameValue(s.getUserID(), 42);
+	`, _undefined, t)
+}
+
+func ExampleSnakeCaseFieldNameMapper() {
+	vm := New()
+	vm.SetFieldNameMapper(SnakeCaseFieldNameMapper())
+	type S struct {
+		UserID     int
+		HTTPServer string
+	}
+	vm.Set("s", S{UserID: 42, HTTPServer: "example.com"})
+	res, _ := vm.RunString(`s.user_id + " " + s.http_server`)
+	fmt.Println(res.Export())
+	// Output: 42 example.com
+}
+
 func TestGoReflectWithProto(t *testing.T) {
 	type S struct {
 		Field int
@@ -1521,6 +1599,48 @@ func TestGoReflectToPrimitive(t *testing.T) {
 	})
 }
 
+type testMoney struct {
+	Amount   int
+	Currency string
+}
+
+func (m testMoney) ToPrimitive(hint string) interface{} {
+	if hint == "number" {
+		return m.Amount
+	}
+	return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+}
+
+type testBadPrimitive struct{}
+
+func (testBadPrimitive) ToPrimitive(string) interface{} {
+	return map[string]interface{}{}
+}
+
+func TestGoReflectPrimitiveConverter(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(+a, 100, "+a");
+	assert.sameValue(a * 2, 200, "a * 2");
+	assert.sameValue(a + "", "100 EUR", "a + ''");
+	assert.sameValue(` + "`${a}`" + `, "100 EUR", "template");
+	assert.sameValue(String(a), "100 EUR", "String(a)");
+	assert.sameValue(a.toString(), "100 EUR", "toString()");
+	assert.sameValue(a.valueOf(), 100, "valueOf()");
+	assert(a < b, "a < b");
+	assert(a == "100 EUR", "a == '100 EUR'");
+	assert.sameValue(a.Currency, "EUR", "field access");
+	assert.throws(TypeError, function() { bad + 1; });
+
+	a[Symbol.toPrimitive] = function(hint) { return hint; };
+	assert.sameValue(a + "", "default", "Symbol.toPrimitive takes precedence");
+	`
+	vm := New()
+	vm.Set("a", testMoney{Amount: 100, Currency: "EUR"})
+	vm.Set("b", &testMoney{Amount: 200, Currency: "EUR"})
+	vm.Set("bad", testBadPrimitive{})
+	vm.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 type testGoReflectFuncRt struct {
 }
 
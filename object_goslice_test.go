@@ -271,3 +271,32 @@ func TestGoSliceToString(t *testing.T) {
 		t.Fatal(exp)
 	}
 }
+
+func TestGoSliceMutatingMethods(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(s.fill(0, 1, 3), s, "fill() result");
+	assert(compareArray(s, [1, 0, 0, 4, 5]), "fill()");
+	assert.sameValue(s.copyWithin(0, 3), s, "copyWithin() result");
+	assert(compareArray(s, [4, 5, 0, 4, 5]), "copyWithin()");
+	assert(compareArray(s.splice(1, 2, "x", "y", "z"), [5, 0]), "splice() result (grow)");
+	assert(compareArray(s, [4, "x", "y", "z", 4, 5]), "splice() (grow)");
+	assert(compareArray(s.splice(0, 3, true), [4, "x", "y"]), "splice() result (shrink)");
+	assert(compareArray(s, [true, "z", 4, 5]), "splice() (shrink)");
+	assert(compareArray(s.splice(-1), [5]), "splice() result (delete)");
+	var o = {};
+	s.fill(o, 1);
+	assert.sameValue(s.length, 3, "length");
+	assert(Object.hasOwn(s, 2), "Object.hasOwn()");
+	assert(!Object.hasOwn(s, 3), "Object.hasOwn() beyond length");
+	`
+	r := New()
+	s := []interface{}{1, 2, 3, 4, 5}
+	r.Set("s", &s)
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+	if len(s) != 3 || s[0] != true || s[1] == nil {
+		t.Fatalf("Unexpected value: %v", s)
+	}
+	if _, ok := s[2].(map[string]interface{}); !ok {
+		t.Fatalf("Unexpected value type: %T", s[2])
+	}
+}
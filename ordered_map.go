@@ -0,0 +1,86 @@
+package goja
+
+import (
+	"reflect"
+)
+
+// MapKeyOrder defines the order in which the keys of Go maps are enumerated, see Runtime.SetMapKeyOrder().
+type MapKeyOrder int
+
+const (
+	// MapKeyOrderSorted enumerates the keys that are array indices first in ascending numeric order, followed by
+	// the rest of the keys in lexicographic order. This is the default.
+	MapKeyOrderSorted MapKeyOrder = iota
+	// MapKeyOrderUnspecified enumerates the keys in Go's map iteration order which is random and may change
+	// between enumerations. It avoids the cost of sorting the keys.
+	MapKeyOrderUnspecified
+)
+
+// SetMapKeyOrder sets the order in which the keys of Go maps (other than *OrderedMap) are enumerated by for-in,
+// Object.keys(), JSON.stringify() and similar. The setting applies to all enumerations that start after the call.
+func (r *Runtime) SetMapKeyOrder(order MapKeyOrder) {
+	r.mapKeyOrder = order
+}
+
+var reflectTypeOrderedMap = reflect.TypeOf((*OrderedMap)(nil))
+
+// OrderedMap is a string-keyed map that remembers the order in which the keys were added. When passed to
+// Runtime.ToValue() it is converted into a host object which behaves like a map[string]interface{} (see the Maps
+// section of the ToValue() documentation) but enumerates the keys in insertion order, like an ordinary JavaScript
+// Object (except that array indices are not moved to the front). New properties created by scripts are appended.
+// Export() returns the *OrderedMap.
+//
+// The zero value is an empty map ready to use. An OrderedMap is not safe for concurrent use.
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewOrderedMap creates a new OrderedMap with the given capacity.
+func NewOrderedMap(size int) *OrderedMap {
+	return &OrderedMap{
+		keys:   make([]string, 0, size),
+		values: make(map[string]interface{}, size),
+	}
+}
+
+// Set sets the value of the key. If the key is new it is added to the end.
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if m.values == nil {
+		m.values = make(map[string]interface{})
+	}
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get returns the value of the key and whether it exists.
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	v, exists := m.values[key]
+	return v, exists
+}
+
+// Delete removes the key.
+func (m *OrderedMap) Delete(key string) {
+	if _, exists := m.values[key]; !exists {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys in insertion order. The returned slice must not be modified.
+func (m *OrderedMap) Keys() []string {
+	return m.keys
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
@@ -505,6 +505,7 @@ func (self *_parser) parseMethodDefinition(keyStartIdx file.Idx, kind ast.Proper
 			self.error(idx1, "Setter must have exactly one formal parameter.")
 		}
 	}
+	self.addFunction()
 	node := &ast.FunctionLiteral{
 		Function:      keyStartIdx,
 		ParameterList: parameterList,
@@ -576,7 +577,7 @@ func (self *_parser) parseTemplateLiteral(tagged bool) *ast.TemplateLiteral {
 	}
 	for {
 		start := self.offset
-		literal, parsed, finished, parseErr, err := self.parseTemplateCharacters()
+		literal, parsed, finished, parseErr, err := self.parseTemplateCharacters(tagged)
 		if err != "" {
 			self.error(self.offset, err)
 		}
@@ -1186,6 +1187,7 @@ func (self *_parser) parseConditionalExpression() ast.Expression {
 
 func (self *_parser) parseArrowFunction(start file.Idx, paramList *ast.ParameterList, async bool) ast.Expression {
 	self.expect(token.ARROW)
+	self.addFunction()
 	node := &ast.ArrowFunctionLiteral{
 		Start:         start,
 		ParameterList: paramList,
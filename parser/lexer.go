@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 	"unicode"
@@ -195,6 +196,7 @@ type parserState struct {
 	chr                                rune
 	chrOffset, offset                  int
 	errorCount                         int
+	functionCount                      int
 }
 
 func (self *_parser) mark(state *parserState) *parserState {
@@ -205,6 +207,7 @@ func (self *_parser) mark(state *parserState) *parserState {
 		self.idx, self.token, self.literal, self.parsedLiteral, self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset
 
 	state.errorCount = len(self.errors)
+	state.functionCount = self.functionCount
 	return state
 }
 
@@ -212,6 +215,7 @@ func (self *_parser) restore(state *parserState) {
 	self.idx, self.token, self.literal, self.parsedLiteral, self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset =
 		state.idx, state.tok, state.literal, state.parsedLiteral, state.implicitSemicolon, state.insertSemicolon, state.chr, state.chrOffset, state.offset
 	self.errors = self.errors[:state.errorCount]
+	self.functionCount = state.functionCount
 }
 
 func (self *_parser) peek() token.Token {
@@ -224,9 +228,13 @@ func (self *_parser) peek() token.Token {
 func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unistring.String, idx file.Idx) {
 
 	self.implicitSemicolon = false
+	// whether a line terminator has been seen since the previous token, see SingleLineHTMLCloseComment below
+	newLine := false
 
 	for {
-		self.skipWhiteSpace()
+		if self.skipWhiteSpace() {
+			newLine = true
+		}
 
 		idx = self.idxOf(self.chrOffset)
 		insertSemicolon := false
@@ -307,6 +315,7 @@ func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unis
 			case '\r', '\n', '\u2028', '\u2029':
 				self.insertSemicolon = false
 				self.implicitSemicolon = true
+				newLine = true
 				continue
 			case ':':
 				tkn = token.COLON
@@ -352,6 +361,11 @@ func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unis
 					insertSemicolon = true
 				}
 			case '-':
+				if self.chr == '-' && self._peek() == '>' && (newLine || self.isStartOfInput(self.chrOffset-1)) {
+					// Annex B SingleLineHTMLCloseComment, only allowed at the beginning of a line
+					self.skipSingleLineComment()
+					continue
+				}
 				tkn = self.switch3(token.MINUS, token.SUBTRACT_ASSIGN, '-', token.DECREMENT)
 				if tkn == token.DECREMENT {
 					insertSemicolon = true
@@ -371,6 +385,7 @@ func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unis
 					if self.skipMultiLineComment() {
 						self.insertSemicolon = false
 						self.implicitSemicolon = true
+						newLine = true
 					}
 					continue
 				} else {
@@ -383,6 +398,11 @@ func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unis
 			case '^':
 				tkn = self.switch2(token.EXCLUSIVE_OR, token.EXCLUSIVE_OR_ASSIGN)
 			case '<':
+				if self.chr == '!' && strings.HasPrefix(self.str[self.offset:], "--") {
+					// Annex B SingleLineHTMLOpenComment
+					self.skipSingleLineComment()
+					continue
+				}
 				tkn = self.switch4(token.LESS, token.LESS_OR_EQUAL, '<', token.SHIFT_LEFT, token.SHIFT_LEFT_ASSIGN)
 			case '>':
 				tkn = self.switch6(token.GREATER, token.GREATER_OR_EQUAL, '>', token.SHIFT_RIGHT, token.SHIFT_RIGHT_ASSIGN, '>', token.UNSIGNED_SHIFT_RIGHT, token.UNSIGNED_SHIFT_RIGHT_ASSIGN)
@@ -543,6 +563,11 @@ func (self *_parser) read() {
 	}
 }
 
+// isStartOfInput returns true if there is nothing but whitespace in the source before the given offset.
+func (self *_parser) isStartOfInput(offset int) bool {
+	return strings.TrimLeftFunc(self.str[:offset], isLineWhiteSpace) == ""
+}
+
 func (self *_parser) skipSingleLineComment() {
 	for self.chr != -1 {
 		self.read()
@@ -604,7 +629,9 @@ func (self *_parser) skipWhiteSpaceCheckLineTerminator() bool {
 	return false
 }
 
-func (self *_parser) skipWhiteSpace() {
+// skipWhiteSpace skips the white space, as well as the line terminators unless a semicolon may need to be inserted.
+// It returns whether a line terminator has been skipped.
+func (self *_parser) skipWhiteSpace() (lineTerminator bool) {
 	for {
 		switch self.chr {
 		case ' ', '\t', '\f', '\v', '\u00a0', '\ufeff':
@@ -620,6 +647,7 @@ func (self *_parser) skipWhiteSpace() {
 				return
 			}
 			self.read()
+			lineTerminator = true
 			continue
 		}
 		if self.chr >= utf8.RuneSelf {
@@ -628,12 +656,21 @@ func (self *_parser) skipWhiteSpace() {
 				continue
 			}
 		}
-		break
+		return
 	}
 }
 
-func (self *_parser) scanMantissa(base int) {
-	for digitValue(self.chr) < base {
+func (self *_parser) scanMantissa(base int, allowSeparator bool) {
+	for digitValue(self.chr) < base || (allowSeparator && self.chr == '_') {
+		if self.chr == '_' {
+			offset := self.chrOffset
+			prev := rune(self.str[offset-1])
+			self.read()
+			if !isDigit(prev, base) || !isDigit(self.chr, base) {
+				self.error(self.idxOf(offset), "Invalid numeric separator")
+			}
+			continue
+		}
 		self.read()
 	}
 }
@@ -776,6 +813,113 @@ newline:
 	return "", "", errStr
 }
 
+// checkStringEscapes is used by WithStrictEncoding. It reports the first escape sequence in the body of a string
+// or template literal that is either malformed or produces an unpaired surrogate. The body starts at offset.
+func (self *_parser) checkStringEscapes(body string, offset int) {
+	high := -1 // the position of a high surrogate escape which has not been paired yet
+	for i := 0; i < len(body); {
+		if body[i] != '\\' {
+			if high >= 0 {
+				break
+			}
+			_, size := utf8.DecodeRuneInString(body[i:])
+			i += size
+			continue
+		}
+		start := i
+		i++
+		if i >= len(body) {
+			break
+		}
+		switch body[i] {
+		case 'x':
+			i++
+			if i+2 > len(body) || !isHexDigit(body[i]) || !isHexDigit(body[i+1]) {
+				self.error(self.idxOf(offset+start), "Invalid hexadecimal escape sequence")
+				return
+			}
+			i += 2
+		case 'u':
+			i++
+			value, size := decodeUnicodeEscape(body[i:])
+			if size == 0 {
+				self.error(self.idxOf(offset+start), "Invalid Unicode escape sequence")
+				return
+			}
+			i += size
+			switch {
+			case value >= 0xD800 && value <= 0xDBFF:
+				if high >= 0 {
+					break
+				}
+				high = start
+				continue
+			case value >= 0xDC00 && value <= 0xDFFF:
+				if high < 0 {
+					self.error(self.idxOf(offset+start), "Unpaired surrogate %s", body[start:i])
+					return
+				}
+				high = -1
+				continue
+			}
+		default:
+			chr, size := utf8.DecodeRuneInString(body[i:])
+			i += size
+			if chr == '\r' && i < len(body) && body[i] == '\n' {
+				i++
+			}
+			if isLineTerminator(chr) {
+				// line continuations do not produce any characters
+				continue
+			}
+		}
+		if high >= 0 {
+			break
+		}
+	}
+	if high >= 0 {
+		_, size := decodeUnicodeEscape(body[high+2:])
+		self.error(self.idxOf(offset+high), "Unpaired surrogate %s", body[high:high+2+size])
+	}
+}
+
+// decodeUnicodeEscape decodes the part of a \u escape sequence after the 'u', i.e. either XXXX or {X...}.
+// The returned size is 0 if the sequence is malformed.
+func decodeUnicodeEscape(s string) (value rune, size int) {
+	if len(s) > 0 && s[0] == '{' {
+		for size = 1; size < len(s) && s[size] != '}'; size++ {
+			d, ok := hex2decimal(s[size])
+			if !ok {
+				return 0, 0
+			}
+			value = value<<4 | d
+			if value > utf8.MaxRune {
+				return 0, 0
+			}
+		}
+		if size == 1 || size == len(s) {
+			return 0, 0
+		}
+		return value, size + 1
+	}
+	if len(s) < 4 {
+		return 0, 0
+	}
+	for _, c := range []byte(s[:4]) {
+		d, ok := hex2decimal(c)
+		if !ok {
+			return 0, 0
+		}
+		value = value<<4 | d
+	}
+	return value, 4
+}
+
+func isHexDigit(chr byte) bool {
+	_, ok := hex2decimal(chr)
+	return ok
+}
+
 func (self *_parser) scanNewline() {
 	if self.chr == '\u2028' || self.chr == '\u2029' {
 		self.read()
@@ -790,7 +934,7 @@ func (self *_parser) scanNewline() {
 	self.read()
 }
 
-func (self *_parser) parseTemplateCharacters() (literal string, parsed unistring.String, finished bool, parseErr, err string) {
+func (self *_parser) parseTemplateCharacters(tagged bool) (literal string, parsed unistring.String, finished bool, parseErr, err string) {
 	offset := self.chrOffset
 	var end int
 	length := 0
@@ -851,6 +995,9 @@ func (self *_parser) parseTemplateCharacters() (literal string, parsed unistring
 	}
 	if parseErr == "" {
 		parsed, parseErr = parseStringLiteral(literal, length, isUnicode, true)
+		if parseErr == "" && isUnicode && self.opts.strictEncoding && !tagged {
+			self.checkStringEscapes(self.str[offset:end], offset)
+		}
 	}
 	self.insertSemicolon = true
 	return
@@ -892,6 +1039,12 @@ func hex2decimal(chr byte) (value rune, ok bool) {
 }
 
 func parseNumberLiteral(literal string) (value interface{}, err error) {
+	if strings.IndexByte(literal, '_') >= 0 {
+		literal = strings.ReplaceAll(literal, "_", "")
+	}
+	if len(literal) > 1 && literal[len(literal)-1] == 'n' {
+		return parseBigIntLiteral(literal[:len(literal)-1])
+	}
 	// TODO Is Uint okay? What about -MAX_UINT
 	value, err = strconv.ParseInt(literal, 0, 64)
 	if err == nil {
@@ -930,6 +1083,27 @@ error:
 	return nil, errors.New("Illegal numeric literal")
 }
 
+func parseBigIntLiteral(literal string) (*big.Int, error) {
+	base := 10
+	if len(literal) > 2 && literal[0] == '0' {
+		switch literal[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			literal = literal[2:]
+		}
+	}
+	if b, ok := new(big.Int).SetString(literal, base); ok {
+		return b, nil
+	}
+	return nil, errors.New("Illegal numeric literal")
+}
+
 func parseStringLiteral(literal string, length int, unicode, strict bool) (unistring.String, string) {
 	var sb strings.Builder
 	var chars []uint16
@@ -1122,7 +1296,7 @@ func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string)
 
 	if decimalPoint {
 		offset--
-		self.scanMantissa(10)
+		self.scanMantissa(10, true)
 	} else {
 		if self.chr == '0' {
 			self.read()
@@ -1136,9 +1310,12 @@ func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string)
 				base = 2
 			case '.', 'e', 'E':
 				// no-op
+			case 'n':
+				self.read()
+				goto end
 			default:
 				// legacy octal
-				self.scanMantissa(8)
+				self.scanMantissa(8, false)
 				goto end
 			}
 			if base > 0 {
@@ -1146,15 +1323,22 @@ func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string)
 				if !isDigit(self.chr, base) {
 					return token.ILLEGAL, self.str[offset:self.chrOffset]
 				}
-				self.scanMantissa(base)
+				self.scanMantissa(base, true)
+				if self.chr == 'n' {
+					self.read()
+				}
 				goto end
 			}
 		} else {
-			self.scanMantissa(10)
+			self.scanMantissa(10, true)
+			if self.chr == 'n' {
+				self.read()
+				goto end
+			}
 		}
 		if self.chr == '.' {
 			self.read()
-			self.scanMantissa(10)
+			self.scanMantissa(10, true)
 		}
 	}
 
@@ -1165,7 +1349,7 @@ func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string)
 		}
 		if isDecimalDigit(self.chr) {
 			self.read()
-			self.scanMantissa(10)
+			self.scanMantissa(10, true)
 		} else {
 			return token.ILLEGAL, self.str[offset:self.chrOffset]
 		}
@@ -53,6 +53,62 @@ func TestLexer(t *testing.T) {
 			token.EOF, "", 2,
 		)
 
+		test("<!-- comment\n1",
+			token.NUMBER, "1", 14,
+			token.EOF, "", 15,
+		)
+
+		test("1 <!-- comment\n-->\n2",
+			token.NUMBER, "1", 1,
+			token.NUMBER, "2", 20,
+			token.EOF, "", 21,
+		)
+
+		test("  --> comment",
+			token.EOF, "", 14,
+		)
+
+		test("1 /*\n*/ --> comment",
+			token.NUMBER, "1", 1,
+			token.EOF, "", 20,
+		)
+
+		test("z = 5;\n--> note\n6",
+			token.IDENTIFIER, "z", 1,
+			token.ASSIGN, "", 3,
+			token.NUMBER, "5", 5,
+			token.SEMICOLON, "", 6,
+			token.NUMBER, "6", 17,
+			token.EOF, "", 18,
+		)
+
+		test("}\n  --> note",
+			token.RIGHT_BRACE, "", 1,
+			token.EOF, "", 13,
+		)
+
+		test("x-->0",
+			token.IDENTIFIER, "x", 1,
+			token.DECREMENT, "", 2,
+			token.GREATER, "", 4,
+			token.NUMBER, "0", 5,
+			token.EOF, "", 6,
+		)
+
+		test("x<!--y",
+			token.IDENTIFIER, "x", 1,
+			token.EOF, "", 7,
+		)
+
+		test("x <! --y",
+			token.IDENTIFIER, "x", 1,
+			token.LESS, "", 3,
+			token.NOT, "", 4,
+			token.DECREMENT, "", 6,
+			token.IDENTIFIER, "y", 8,
+			token.EOF, "", 9,
+		)
+
 		test(".0",
 			token.NUMBER, ".0", 1,
 			token.EOF, "", 3,
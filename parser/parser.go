@@ -34,6 +34,7 @@ package parser
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -54,10 +55,17 @@ const (
 type options struct {
 	disableSourceMaps bool
 	sourceMapLoader   func(path string) ([]byte, error)
+	ctx               context.Context
+	maxFunctions      int
+	strictEncoding    bool
 }
 
+// ErrTooManyFunctions is returned by the Parse methods when the source contains more functions than allowed by
+// WithMaxFunctions.
+var ErrTooManyFunctions = errors.New("too many functions")
+
 // Option represents one of the options for the parser to use in the Parse methods. Currently supported are:
-// WithDisableSourceMaps and WithSourceMapLoader.
+// WithDisableSourceMaps, WithSourceMapLoader, WithContext, WithMaxFunctions and WithStrictEncoding.
 type Option func(*options)
 
 // WithDisableSourceMaps is an option to disable source maps support. May save a bit of time when source maps
@@ -77,6 +85,38 @@ func WithSourceMapLoader(loader func(path string) ([]byte, error)) Option {
 	}
 }
 
+// WithContext is an option to abort the parsing as soon as the context is done. In this case the Parse methods
+// return ctx.Err() instead of an ErrorList.
+func WithContext(ctx context.Context) Option {
+	return func(opts *options) {
+		opts.ctx = ctx
+	}
+}
+
+// WithMaxFunctions is an option to abort the parsing with ErrTooManyFunctions if the source contains more than
+// max functions. Function declarations and expressions, arrow functions, methods, accessors and class static
+// blocks are counted. Zero means no limit.
+func WithMaxFunctions(max int) Option {
+	return func(opts *options) {
+		opts.maxFunctions = max
+	}
+}
+
+// WithStrictEncoding is an option to reject string and template literals containing escape sequences that
+// produce unpaired surrogates (e.g. "\uD800"), which are otherwise allowed by the specification but cannot be
+// represented in UTF-8. It also makes the parser report malformed escape sequences in string literals at their
+// exact position rather than as an unexpected token. Invalid UTF-8 in the source and escape sequences in
+// identifiers are always validated. Tagged templates are not checked, as the tag may only use the raw strings
+// (e.g. String.raw`\uD800`), and regular expression literals are not affected.
+func WithStrictEncoding(opts *options) {
+	opts.strictEncoding = true
+}
+
+// parseAbort is used to unwind the parser when the parsing is aborted, see _parser.abort().
+type parseAbort struct {
+	err error
+}
+
 type _parser struct {
 	str    string
 	length int
@@ -107,6 +147,9 @@ type _parser struct {
 	opts options
 
 	file *file.File
+
+	functionCount int
+	tokenCount    int
 }
 
 func _newParser(filename, src string, base int, opts ...Option) *_parser {
@@ -208,19 +251,53 @@ func (self *_parser) slice(idx0, idx1 file.Idx) string {
 	return ""
 }
 
-func (self *_parser) parse() (*ast.Program, error) {
+func (self *_parser) parse() (program *ast.Program, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			if a, ok := x.(*parseAbort); ok {
+				program, err = nil, a.err
+				return
+			}
+			panic(x)
+		}
+	}()
 	self.openScope()
 	defer self.closeScope()
 	self.next()
-	program := self.parseProgram()
+	program = self.parseProgram()
 	if false {
 		self.errors.Sort()
 	}
 	return program, self.errors.Err()
 }
 
+func (self *_parser) abort(err error) {
+	panic(&parseAbort{err: err})
+}
+
 func (self *_parser) next() {
 	self.token, self.literal, self.parsedLiteral, self.idx = self.scan()
+	if self.opts.strictEncoding && (self.token == token.STRING && self.parsedLiteral.AsUtf16() != nil || self.token == token.ILLEGAL) && len(self.literal) > 1 {
+		if quote := self.literal[0]; quote == '"' || quote == '\'' {
+			self.checkStringEscapes(self.literal[1:len(self.literal)-1], int(self.idx)-self.base+1)
+		}
+	}
+	if self.opts.ctx != nil {
+		self.tokenCount++
+		if self.tokenCount&1023 == 0 {
+			if err := self.opts.ctx.Err(); err != nil {
+				self.abort(err)
+			}
+		}
+	}
+}
+
+// addFunction is called for every function literal, so that WithMaxFunctions can be enforced.
+func (self *_parser) addFunction() {
+	self.functionCount++
+	if self.opts.maxFunctions > 0 && self.functionCount > self.opts.maxFunctions {
+		self.abort(ErrTooManyFunctions)
+	}
 }
 
 func (self *_parser) optionalSemicolon() {
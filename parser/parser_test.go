@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"strings"
@@ -141,6 +142,20 @@ func TestParserErr(t *testing.T) {
 
 		test("0x3in[]", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
 
+		test("1__0", "(anonymous): Line 1:2 Invalid numeric separator")
+
+		test("1_", "(anonymous): Line 1:2 Invalid numeric separator")
+
+		test("0x_1", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1._5", "(anonymous): Line 1:3 Invalid numeric separator")
+
+		test("1_.5", "(anonymous): Line 1:2 Invalid numeric separator")
+
+		test("1e_5", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("0_1", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
 		test("\"Hello\nWorld\"", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
 
 		test("\u203f = 10", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
@@ -511,6 +526,11 @@ func TestParserErr(t *testing.T) {
             st\u0061tic m() {}
 		}
 		`, "(anonymous): Line 3:25 Unexpected identifier")
+		test(`{ using x; }`, "(anonymous): Line 1:10 Missing initializer in using declaration")
+		test(`if (a) using x = null;`, "(anonymous): Line 1:8 Lexical declaration cannot appear in a single-statement context")
+		test(`for (using x in y);`, "(anonymous): Line 1:14 The left-hand side of a for-in loop may not be a using declaration")
+		test(`async function f() { await using x; }`, "(anonymous): Line 1:35 Missing initializer in using declaration")
+		test(`function f() { await using x = null; }`, "(anonymous): Line 1:22 Unexpected identifier")
 	})
 }
 
@@ -672,6 +692,8 @@ func TestParser(t *testing.T) {
 
 		test("0x41", nil)
 
+		test("1_000_000 + 0xFF_FF + 0b1010_0101 + 0o7_7 + 1_0.0_1e1_0", nil)
+
 		test(`"\d"`, nil)
 
 		test(`(function(){return this})`, nil)
@@ -928,6 +950,28 @@ func TestParser(t *testing.T) {
 		}
 		`, nil)
 		is(len(program.Body), 1)
+
+		program = test(`{ using x = a, y = b; }`, nil)
+		decl := program.Body[0].(*ast.BlockStatement).List[0].(*ast.LexicalDeclaration)
+		is(decl.Token, token.USING)
+		is(len(decl.List), 2)
+
+		program = test(`async function f() { await using x = a; for (await using y of b); }`, nil)
+		body := program.Body[0].(*ast.FunctionDeclaration).Function.Body.List
+		is(body[0].(*ast.LexicalDeclaration).Token, token.AWAIT_USING)
+		is(body[1].(*ast.ForOfStatement).Into.(*ast.ForDeclaration).Using, token.AWAIT_USING)
+
+		// "using" is not a keyword
+		program = test(`
+		using
+		x = 1;
+		using[0] = 1;
+		for (using of a);
+		for (using x of a);
+		`, nil)
+		is(len(program.Body), 5)
+		is(program.Body[3].(*ast.ForOfStatement).Into.(*ast.ForIntoExpression).Expression.(*ast.Identifier).Name, "using")
+		is(program.Body[4].(*ast.ForOfStatement).Into.(*ast.ForDeclaration).Using, token.USING)
 	})
 }
 
@@ -1027,6 +1071,12 @@ func Test_parseNumberLiteral(t *testing.T) {
 		test("0", 0)
 
 		test("0x8000000000000000", float64(9.223372036854776e+18))
+
+		test("1_000_000", int64(1000000))
+
+		test("0xFF_FF", int64(65535))
+
+		test("1_0.2_5", float64(10.25))
 	})
 }
 
@@ -1134,6 +1184,57 @@ var x = {};
 	})
 }
 
+func TestAbortOptions(t *testing.T) {
+	tt(t, func() {
+		src := `function f() {}; var g = (a, b = () => 1) => a; class C { get x() {} static {} }`
+		_, err := ParseFile(nil, "", src, 0, WithMaxFunctions(5))
+		is(err, nil)
+		_, err = ParseFile(nil, "", src, 0, WithMaxFunctions(4))
+		is(err, ErrTooManyFunctions)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = ParseFile(nil, "", strings.Repeat("x;", 1024), 0, WithContext(ctx))
+		is(err, context.Canceled)
+	})
+}
+
+func TestStrictEncoding(t *testing.T) {
+	tt(t, func() {
+		for _, src := range []string{
+			`var a = "\uD83D\uDE00", b = "\u{D83D}\u{DE00}", c = '\x41\u00e9\
+\u0041'`,
+			"var a = '\\\\uD800', b = `${a}\\uD83D\\uDE00`, c = String.raw`\\unicode`;",
+			"var a = '\\uD83D\\\n\\uDE00';",
+			"/\\uD800/",
+			"String.raw`\\uD800`; tag`a${1}\\uDFFF`;",
+		} {
+			_, err := ParseFile(nil, "", src, 0, WithStrictEncoding)
+			is(err, nil)
+		}
+
+		for _, test := range []struct {
+			src, err string
+		}{
+			{"var a = \"\\uD800\";", "Line 1:10 Unpaired surrogate \\uD800"},
+			{"var a = 'xy\\u{DC00}z';", "Line 1:12 Unpaired surrogate \\u{DC00}"},
+			{"var a = '\\uD800\\u0041';", "Line 1:10 Unpaired surrogate \\uD800"},
+			{"var a = '\\uD800\\uD800\\uDC00';", "Line 1:10 Unpaired surrogate \\uD800"},
+			{"var a = 'ok',\n  b = '\\x4';", "Line 2:8 Invalid hexadecimal escape sequence"},
+			{"var a = '\\u{110000}';", "Line 1:10 Invalid Unicode escape sequence"},
+			{"var a = `${1}\\r\n\\uD800`;", "Line 2:1 Unpaired surrogate \\uD800"},
+			{"tag`${`\\uDFFF`}`;", "Line 1:8 Unpaired surrogate \\uDFFF"},
+		} {
+			_, err := ParseFile(nil, "", test.src, 0, WithStrictEncoding)
+			is(err != nil, true)
+			is(strings.HasPrefix(err.Error(), "(anonymous): "+test.err), true)
+		}
+
+		_, err := ParseFile(nil, "", "var a = '\\uD800';", 0)
+		is(err, nil)
+	})
+}
+
 func TestParseTemplateCharacters(t *testing.T) {
 	parser := newParser("", "`test\\\r\\\n${a}`")
 	parser.next()
@@ -1141,7 +1242,7 @@ func TestParseTemplateCharacters(t *testing.T) {
 		t.Fatalf("Token: %s", parser.token)
 	}
 	checkParseTemplateChars := func(expectedLiteral string, expectedParsed unistring.String, expectedFinished, expectParseErr, expectErr bool) {
-		literal, parsed, finished, parseErr, err := parser.parseTemplateCharacters()
+		literal, parsed, finished, parseErr, err := parser.parseTemplateCharacters(false)
 		if err != "" != expectErr {
 			t.Fatal(err)
 		}
@@ -73,6 +73,10 @@ func (self *_parser) parseStatement() ast.Statement {
 		self.insertSemicolon = true
 	case token.CONST:
 		return self.parseLexicalDeclaration(self.token)
+	case token.IDENTIFIER, token.AWAIT:
+		if tok := self.usingDeclarationToken(false); tok != 0 {
+			return self.parseUsingDeclaration(tok)
+		}
 	case token.ASYNC:
 		if f := self.parseMaybeAsyncFunction(true); f != nil {
 			return &ast.FunctionDeclaration{
@@ -204,7 +208,7 @@ func (self *_parser) parseMaybeAsyncFunction(declaration bool) *ast.FunctionLite
 }
 
 func (self *_parser) parseFunction(declaration, async bool, start file.Idx) *ast.FunctionLiteral {
-
+	self.addFunction()
 	node := &ast.FunctionLiteral{
 		Function: start,
 		Async:    async,
@@ -339,6 +343,7 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 			default:
 				self.next()
 				if self.token == token.LEFT_BRACE {
+					self.addFunction()
 					b := &ast.ClassStaticBlock{
 						Static: start,
 					}
@@ -672,9 +677,15 @@ func (self *_parser) parseForOrForInStatement() ast.Statement {
 			default:
 				tok = token.IDENTIFIER
 			}
+		} else if using := self.usingDeclarationToken(true); using != 0 {
+			tok = using
 		}
-		if tok == token.VAR || tok == token.LET || tok == token.CONST {
+		isUsing := tok == token.USING || tok == token.AWAIT_USING
+		if tok == token.VAR || tok == token.LET || tok == token.CONST || isUsing {
 			idx := self.idx
+			if tok == token.AWAIT_USING {
+				self.next() // await
+			}
 			self.next()
 			var list []*ast.Binding
 			if tok == token.VAR {
@@ -684,6 +695,9 @@ func (self *_parser) parseForOrForInStatement() ast.Statement {
 			}
 			if len(list) == 1 {
 				if self.token == token.IN {
+					if isUsing {
+						self.error(self.idx, "The left-hand side of a for-in loop may not be a using declaration")
+					}
 					self.next() // in
 					forIn = true
 				} else if self.token == token.IDENTIFIER && self.literal == "of" {
@@ -700,11 +714,24 @@ func (self *_parser) parseForOrForInStatement() ast.Statement {
 						Binding: list[0],
 					}
 				} else {
-					into = &ast.ForDeclaration{
+					decl := &ast.ForDeclaration{
 						Idx:     idx,
-						IsConst: tok == token.CONST,
+						IsConst: tok == token.CONST || isUsing,
 						Target:  list[0].Target,
 					}
+					if isUsing {
+						decl.Using = tok
+					}
+					into = decl
+				}
+			} else if isUsing {
+				self.ensureUsingInit(list)
+				initializer = &ast.ForLoopInitializerLexicalDecl{
+					LexicalDeclaration: ast.LexicalDeclaration{
+						Idx:   idx,
+						Token: tok,
+						List:  list,
+					},
 				}
 			} else {
 				self.ensurePatternInit(list)
@@ -809,6 +836,67 @@ func (self *_parser) parseLexicalDeclaration(tok token.Token) *ast.LexicalDeclar
 	}
 }
 
+// usingDeclarationToken returns token.USING or token.AWAIT_USING if the current token starts a using or an
+// await using declaration respectively, or 0 otherwise. As "using" is not a keyword, it's only recognised if it's
+// followed by a binding identifier on the same line (and, in a for statement head, if that identifier is not "of").
+func (self *_parser) usingDeclarationToken(forHead bool) token.Token {
+	var tok token.Token
+	switch {
+	case self.token == token.IDENTIFIER && self.literal == "using":
+		tok = token.USING
+	case self.token == token.AWAIT && self.scope.allowAwait:
+		tok = token.AWAIT_USING
+	default:
+		return 0
+	}
+	state := self.mark(nil)
+	defer self.restore(state)
+	if tok == token.AWAIT_USING {
+		self.next()
+		if self.implicitSemicolon || self.token != token.IDENTIFIER || self.literal != "using" {
+			return 0
+		}
+	}
+	self.next()
+	if self.implicitSemicolon || self.token != token.IDENTIFIER && !token.IsUnreservedWord(self.token) {
+		return 0
+	}
+	if forHead && tok == token.USING && self.literal == "of" {
+		return 0
+	}
+	return tok
+}
+
+func (self *_parser) parseUsingDeclaration(tok token.Token) *ast.LexicalDeclaration {
+	idx := self.idx
+	if tok == token.AWAIT_USING {
+		self.next() // await
+	}
+	self.next() // using
+	if !self.scope.allowLet {
+		self.error(idx, "Lexical declaration cannot appear in a single-statement context")
+	}
+
+	list := self.parseVariableDeclarationList()
+	self.ensureUsingInit(list)
+	self.semicolon()
+
+	return &ast.LexicalDeclaration{
+		Idx:   idx,
+		Token: tok,
+		List:  list,
+	}
+}
+
+func (self *_parser) ensureUsingInit(list []*ast.Binding) {
+	for _, item := range list {
+		if item.Initializer == nil {
+			self.error(item.Idx1(), "Missing initializer in using declaration")
+			break
+		}
+	}
+}
+
 func (self *_parser) parseDoWhileStatement() ast.Statement {
 	inIteration := self.scope.inIteration
 	self.scope.inIteration = true
@@ -0,0 +1,148 @@
+package goja
+
+import (
+	"fmt"
+
+	js_ast "github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+)
+
+// Path is a compiled property access path such as "a.b[2].c", see CompilePath(). It is not linked to a runtime
+// and can be used with objects of different runtimes (possibly at the same time).
+type Path struct {
+	src  string
+	keys []Value
+}
+
+// CompilePath parses a chain of property accesses relative to an object, using the JavaScript syntax:
+// "user.address.city", "items[2].price", "headers['content-type']" or "[0].name". The resulting Path can be
+// used to read or write the property many times without parsing the path again, which makes it suitable for
+// host-side data binding.
+//
+// Only identifiers after a dot and string or number literals within brackets are allowed (i.e. there are no
+// computed keys, calls or optional chaining).
+func CompilePath(path string) (*Path, error) {
+	src := "$"
+	if len(path) > 0 && path[0] != '[' {
+		src += "."
+	}
+	src += path
+	prg, err := parser.ParseFile(nil, "", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	var expr js_ast.Expression
+	if len(prg.Body) == 1 {
+		if st, ok := prg.Body[0].(*js_ast.ExpressionStatement); ok {
+			expr = st.Expression
+		}
+	}
+	var keys []Value
+	for expr != nil {
+		switch e := expr.(type) {
+		case *js_ast.DotExpression:
+			keys = append(keys, stringValueFromRaw(e.Identifier.Name))
+			expr = e.Left
+			continue
+		case *js_ast.BracketExpression:
+			var key Value
+			switch m := e.Member.(type) {
+			case *js_ast.StringLiteral:
+				key = stringValueFromRaw(m.Value)
+			case *js_ast.NumberLiteral:
+				switch n := m.Value.(type) {
+				case int64:
+					key = intToValue(n)
+				case float64:
+					key = floatToValue(n).toString()
+				}
+			}
+			if key != nil {
+				keys = append(keys, key)
+				expr = e.Left
+				continue
+			}
+		case *js_ast.Identifier:
+			if e.Name == "$" && len(keys) > 0 {
+				for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+					keys[i], keys[j] = keys[j], keys[i]
+				}
+				return &Path{src: path, keys: keys}, nil
+			}
+		}
+		break
+	}
+	return nil, fmt.Errorf("invalid path %q: only property accesses with constant keys are allowed", path)
+}
+
+// MustCompilePath is like CompilePath but panics if the path cannot be compiled.
+func MustCompilePath(path string) *Path {
+	p, err := CompilePath(path)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *Path) String() string {
+	return p.src
+}
+
+// Get returns the value at the path. If one of the intermediate values is undefined or null the result is undefined,
+// as if the path used optional chaining. Primitive intermediate values are accessed as in JavaScript, e.g.
+// "name.length" works for a string.
+// This method will panic with an *Exception if a JavaScript exception is thrown in the process (e.g. by a getter).
+func (p *Path) Get(o *Object) Value {
+	r := o.runtime
+	var v Value = o
+	for _, key := range p.keys {
+		if v == _undefined || v == _null {
+			return _undefined
+		}
+		if obj, ok := v.(*Object); ok {
+			v = obj.get(key, nil)
+		} else {
+			v = r.getV(v, key)
+		}
+		if v == nil {
+			v = _undefined
+		}
+	}
+	return v
+}
+
+// Set assigns the value (converted using Runtime.ToValue()) to the property at the path. Unlike Get() it fails with
+// a TypeError if one of the intermediate values is undefined or null.
+// Returns an error if the assignment fails, e.g. because the property is not writable.
+func (p *Path) Set(o *Object, value interface{}) error {
+	r := o.runtime
+	return r.try(func() {
+		var v Value = o
+		last := len(p.keys) - 1
+		for i, key := range p.keys {
+			if v == _undefined || v == _null {
+				if i == last {
+					panic(r.NewTypeError("Cannot set properties of %s (setting '%s')", v, key))
+				}
+				panic(r.NewTypeError("Cannot read properties of %s (reading '%s')", v, key))
+			}
+			if i == last {
+				val := r.ToValue(value)
+				if obj, ok := v.(*Object); ok {
+					obj.set(key, val, obj, true)
+				} else {
+					v.ToObject(r).set(key, val, v, true)
+				}
+				return
+			}
+			if obj, ok := v.(*Object); ok {
+				v = obj.get(key, nil)
+			} else {
+				v = r.getV(v, key)
+			}
+			if v == nil {
+				v = _undefined
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestCompilePath(t *testing.T) {
+	r := New()
+	o, err := r.RunString(`({a: {b: [0, 1, {c: 42}], "content-type": "text/plain", name: "goja"}})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := o.(*Object)
+
+	for _, test := range []struct {
+		path     string
+		expected interface{}
+	}{
+		{"a.b[2].c", int64(42)},
+		{"a['content-type']", "text/plain"},
+		{`a["b"].length`, int64(3)},
+		{"a.name.length", int64(4)},
+		{"a.missing.c", nil},
+		{"a.b[5].c", nil},
+	} {
+		p, err := CompilePath(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := p.Get(obj).Export(); v != test.expected {
+			t.Fatalf("%s: %v", test.path, v)
+		}
+	}
+
+	p := MustCompilePath("a.b[2].c")
+	if err := p.Set(obj, 43); err != nil {
+		t.Fatal(err)
+	}
+	if v := p.Get(obj); v.ToInteger() != 43 {
+		t.Fatal(v)
+	}
+
+	arr := r.NewArray(r.NewObject())
+	if err := MustCompilePath("[0].x").Set(arr, "y"); err != nil {
+		t.Fatal(err)
+	}
+	if v := MustCompilePath("[0].x").Get(arr); v.String() != "y" {
+		t.Fatal(v)
+	}
+
+	err = MustCompilePath("a.missing.c").Set(obj, 1)
+	if ex, ok := err.(*Exception); !ok || ex.Value().String() != "TypeError: Cannot set properties of undefined (setting 'c')" {
+		t.Fatal(err)
+	}
+	err = MustCompilePath("a.missing.c.d").Set(obj, 1)
+	if ex, ok := err.(*Exception); !ok || ex.Value().String() != "TypeError: Cannot read properties of undefined (reading 'c')" {
+		t.Fatal(err)
+	}
+
+	// the same path used with another runtime
+	r1 := New()
+	o1, err := r1.RunString(`({a: {b: [, , {c: "other"}]}})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := p.Get(o1.(*Object)); v.String() != "other" {
+		t.Fatal(v)
+	}
+
+	for _, path := range []string{"", "a.b()", "a[b]", "a?.b", "a + b", "a; b", "a.#b", "a[-1]", "a.b[", "a.b = 1"} {
+		if _, err := CompilePath(path); err == nil {
+			t.Fatalf("%q: expected an error", path)
+		}
+	}
+}
+
+func BenchmarkPathGet(b *testing.B) {
+	r := New()
+	o, err := r.RunString(`({a: {b: [0, 1, {c: 42}]}})`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	obj := o.(*Object)
+	p := MustCompilePath("a.b[2].c")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Get(obj)
+	}
+}
@@ -0,0 +1,89 @@
+package goja
+
+type propertyLimits struct {
+	perObject  int
+	insertions int
+	inserted   int
+}
+
+// SetPropertyLimits limits the number of properties scripts can create, as a defence against scripts that
+// exhaust the memory by building objects with huge numbers of keys. maxPerObject is the maximum number of own
+// properties (string and symbol keyed) an ordinary object other than the global object can have; adding
+// a property to an object which already has that many throws a RangeError. maxInsertions is the maximum number
+// of properties that can be added to ordinary objects during a run, i.e. from the moment the control is passed to
+// the Runtime (e.g. by RunProgram() or by calling a JavaScript function from Go) until it returns; exceeding it
+// throws a RangeError too.
+// Zero or a negative value means no limit, passing zero for both removes the limits (the default).
+//
+// The limits apply to the properties added by assignments, Object.defineProperty() and similar functions, object
+// spreads and JSON.parse(), to the elements of sparse arrays (i.e. the ones with large gaps between the indexes)
+// and to the entries of Maps and Sets, which count as properties for both limits. The properties in object literals
+// with non-computed keys are not counted (their number is bounded by the size of the source) and neither are the
+// elements of dense arrays and typed arrays, whose memory is proportional to their length. The properties of the
+// built-in objects and the ones added by the host are not affected.
+func (r *Runtime) SetPropertyLimits(maxPerObject, maxInsertions int) {
+	if maxPerObject <= 0 && maxInsertions <= 0 {
+		r.propertyLimits = nil
+		return
+	}
+	r.propertyLimits = &propertyLimits{
+		perObject:  maxPerObject,
+		insertions: maxInsertions,
+	}
+}
+
+// checkNewProperty throws a RangeError if a new property cannot be added to the object because of the limits
+// set by SetPropertyLimits(). It must only be called when the limits are set.
+func (r *Runtime) checkNewProperty(o *baseObject) {
+	count := len(o.propNames)
+	if o.symValues != nil {
+		count += o.symValues.size
+	}
+	r.checkNewKey(o.val, count, "properties in an object")
+}
+
+// checkNewElement is checkNewProperty() for the sparse arrays, which keep the elements separately from the other
+// properties.
+func (r *Runtime) checkNewElement(a *sparseArrayObject) {
+	count := len(a.items) + len(a.propNames)
+	if a.symValues != nil {
+		count += a.symValues.size
+	}
+	r.checkNewKey(a.val, count, "properties in an object")
+}
+
+// setMapEntry sets the entry of a Map or a Set (m is its data), checking the limits set by SetPropertyLimits()
+// if the key is new.
+func (r *Runtime) setMapEntry(o *baseObject, m *orderedMap, key, value Value) {
+	if r.propertyLimits != nil && !m.has(key) {
+		kind := "entries in a Map"
+		if _, ok := o.val.self.(*setObject); ok {
+			kind = "entries in a Set"
+		}
+		r.checkNewKey(o.val, m.size, kind)
+	}
+	m.set(key, value)
+}
+
+func (r *Runtime) checkNewKey(o *Object, count int, kind string) {
+	l := r.propertyLimits
+	if len(r.vm.callStack) == 0 {
+		// the host is modifying the object
+		return
+	}
+	if l.perObject > 0 && o != r.globalObject && count >= l.perObject {
+		panic(r.newError(r.global.RangeError, "Too many %s (the limit is %d)", kind, l.perObject))
+	}
+	if l.insertions > 0 {
+		if l.inserted >= l.insertions {
+			panic(r.newError(r.global.RangeError, "Too many property insertions (the limit is %d)", l.insertions))
+		}
+		l.inserted++
+	}
+}
+
+func (r *Runtime) resetPropertyInsertions() {
+	if r.propertyLimits != nil {
+		r.propertyLimits.inserted = 0
+	}
+}
@@ -0,0 +1,228 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestPropertyLimits(t *testing.T) {
+	r := New()
+	r.SetPropertyLimits(10, 0)
+	_, err := r.RunString(`
+	function fill(o, n) {
+		for (var i = 0; i < n; i++) {
+			o["k" + i] = i;
+		}
+	}
+	var o = {};
+	fill(o, 10);
+	o.k0 = "existing properties can be modified";
+
+	var caught;
+	try {
+		fill(o, 11);
+	} catch (e) {
+		caught = e;
+	}
+	if (!(caught instanceof RangeError)) {
+		throw new Error("expected a RangeError, got " + caught);
+	}
+	if (Object.keys(o).length !== 10) {
+		throw new Error("unexpected number of keys: " + Object.keys(o).length);
+	}
+	delete o.k9;
+	o[Symbol.iterator] = null;
+	for (var f of [
+		function() { o[Symbol("x")] = 1; },
+		function() { Object.defineProperty(o, "x", {value: 1}); },
+		function() { Object.assign({}, o, {extra: 1}); },
+		function() { ({...o, ...{extra: 1}}); },
+		function() { JSON.parse(JSON.stringify(o).slice(0, -1) + ', "a": 1, "b": 2}'); },
+	]) {
+		caught = undefined;
+		try {
+			f();
+		} catch (e) {
+			caught = e;
+		}
+		if (!(caught instanceof RangeError)) {
+			throw new Error(f + ": expected a RangeError, got " + caught);
+		}
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the host is not limited
+	o := r.NewObject()
+	for i := 0; i < 20; i++ {
+		if err := o.Set(string(rune('a'+i)), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPropertyInsertionLimit(t *testing.T) {
+	r := New()
+	r.SetPropertyLimits(0, 100)
+	prg := MustCompile("", `
+	var objects = [];
+	for (var i = 0; i < 10; i++) {
+		var o = {};
+		for (var j = 0; j < 9; j++) {
+			o["p" + j] = j;
+		}
+		objects.push(o);
+	}
+	`, false)
+	for i := 0; i < 3; i++ {
+		if _, err := r.RunProgram(prg); err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+	}
+	_, err := r.RunString(`
+	var big = {};
+	for (var i = 0; i < 1000; i++) {
+		big[i] = i;
+	}
+	`)
+	if ex, ok := err.(*Exception); !ok || ex.Value().ToObject(r).Get("name").String() != "RangeError" {
+		t.Fatal(err)
+	}
+
+	r.SetPropertyLimits(0, 0)
+	if _, err := r.RunString(`for (var i = 0; i < 1000; i++) big[i] = i;`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPropertyLimitsSparseArrays(t *testing.T) {
+	r := New()
+	r.SetPropertyLimits(100, 0)
+	_, err := r.RunString(`
+	var a = [];
+	var caught;
+	try {
+		for (var i = 0; i < 200; i++) {
+			a[i * 1000] = i;
+		}
+	} catch (e) {
+		caught = e;
+	}
+	if (!(caught instanceof RangeError)) {
+		throw new Error("expected a RangeError, got " + caught);
+	}
+	if (Object.keys(a).length > 100) {
+		throw new Error("unexpected number of keys: " + Object.keys(a).length);
+	}
+	a[0] = "existing elements can be modified";
+	caught = undefined;
+	try {
+		Object.defineProperty(a, 12345678, {value: 1});
+	} catch (e) {
+		caught = e;
+	}
+	if (!(caught instanceof RangeError)) {
+		throw new Error("defineProperty: expected a RangeError, got " + caught);
+	}
+
+	var dense = [];
+	for (var i = 0; i < 200; i++) {
+		dense.push(i);
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.SetPropertyLimits(0, 100)
+	_, err = r.RunString(`
+	var arrays = [];
+	for (var i = 0; i < 20; i++) {
+		var a = [];
+		for (var j = 0; j < 10; j++) {
+			a[j * 100000] = j;
+		}
+		arrays.push(a);
+	}
+	`)
+	if ex, ok := err.(*Exception); !ok || ex.Value().ToObject(r).Get("name").String() != "RangeError" {
+		t.Fatal(err)
+	}
+}
+
+func TestPropertyLimitsMapsAndSets(t *testing.T) {
+	r := New()
+	r.SetPropertyLimits(10, 0)
+	_, err := r.RunString(`
+	function expectRangeError(f) {
+		try {
+			f();
+		} catch (e) {
+			if (e instanceof RangeError) {
+				return;
+			}
+			throw e;
+		}
+		throw new Error(f + ": expected a RangeError");
+	}
+	var m = new Map();
+	for (var i = 0; i < 10; i++) {
+		m.set(i, i);
+	}
+	m.set(0, "existing entries can be modified");
+	expectRangeError(function() { m.set(10, 10); });
+	m.delete(0);
+	m.set(10, 10);
+
+	var s = new Set();
+	for (var i = 0; i < 10; i++) {
+		s.add(i);
+	}
+	s.add(0);
+	expectRangeError(function() { s.add(10); });
+	expectRangeError(function() { s.union(new Set([10, 11])); });
+	expectRangeError(function() { new Map(Array.from({length: 11}, function(_, i) { return [i, i]; })); });
+	expectRangeError(function() { new Set(Array.from({length: 11}, function(_, i) { return i; })); });
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.SetPropertyLimits(0, 100)
+	_, err = r.RunString(`
+	var sets = [];
+	for (var i = 0; i < 20; i++) {
+		var s = new Set();
+		for (var j = 0; j < 10; j++) {
+			s.add(j);
+		}
+		sets.push(s);
+	}
+	`)
+	if ex, ok := err.(*Exception); !ok || ex.Value().ToObject(r).Get("name").String() != "RangeError" {
+		t.Fatal(err)
+	}
+	_, err = r.RunString(`
+	var m = new Map();
+	for (var i = 0; i < 101; i++) {
+		m.set(i, i);
+	}
+	`)
+	if ex, ok := err.(*Exception); !ok || ex.Value().ToObject(r).Get("name").String() != "RangeError" {
+		t.Fatal(err)
+	}
+
+	// the host is not limited
+	r.SetPropertyLimits(1, 1)
+	m, err := r.RunString(`new Map()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, _ := AssertFunction(m.ToObject(r).Get("set"))
+	for i := 0; i < 5; i++ {
+		if _, err := set(m, r.ToValue(i), r.ToValue(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
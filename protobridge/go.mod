@@ -0,0 +1,10 @@
+module github.com/dop251/goja/protobridge
+
+go 1.16
+
+require (
+	github.com/dop251/goja v0.0.0-20211022113120-dc8c55024d06
+	google.golang.org/protobuf v1.28.1
+)
+
+replace github.com/dop251/goja => ../
@@ -0,0 +1,596 @@
+// Package protobridge exposes protocol buffer messages to JavaScript code running in goja.
+//
+// A message is wrapped into an object backed by its protoreflect.Message, so reading and writing properties
+// accesses the fields of the message directly, without converting it to JSON and back:
+//
+//	msg := dynamicpb.NewMessage(desc)
+//	vm.Set("msg", protobridge.New(vm, msg))
+//
+//	msg.name = "order-" + msg.id;
+//	msg.items.push({sku: "A1", quantity: 2});
+//	msg.labels["priority"] = "high";
+//	msg.status = "SHIPPED";
+//
+// Fields are accessed by their names as declared in the .proto file (JSON names are accepted as well). The values
+// are converted as follows:
+//
+//   - bool, string, float and double fields and 32-bit integer fields are booleans, strings and numbers.
+//   - 64-bit integer fields are BigInts. Numbers, BigInts and numeric strings are accepted when setting.
+//   - Enum fields are the names of the values (or numbers if the value is not declared in the enum). Both names
+//     and numbers are accepted when setting.
+//   - bytes fields are ArrayBuffers (copies of the data). ArrayBuffers, typed arrays and DataViews are accepted
+//     when setting.
+//   - Message fields are wrapped messages, or null if the field is not set. Setting a field to a plain object
+//     assigns the fields of a new message from its properties.
+//   - Repeated fields are array-like objects backed by the list, they can be modified in place or replaced with an
+//     array.
+//   - Map fields are objects backed by the map, with the keys converted to strings.
+//
+// The name of a oneof returns the name of the field of the oneof that is set (or null). Setting a field to null or
+// undefined, as well as deleting it, clears the field. Setting an unknown field or a value of a wrong type throws
+// a TypeError.
+//
+// Only the populated fields are enumerable, so Object.keys() and for-in loops behave like the JSON mapping which
+// omits default values. JSON.stringify() uses the protobuf JSON mapping (with the original field names), so that
+// 64-bit integers and bytes can be serialised.
+//
+// The wrapped messages are not safe for concurrent use, the same way as the Runtime itself.
+//
+// The package is a separate module (github.com/dop251/goja/protobridge), so that goja itself does not depend on
+// the protobuf runtime.
+package protobridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/dop251/goja"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type message struct {
+	r *goja.Runtime
+	m protoreflect.Message
+}
+
+type list struct {
+	r  *goja.Runtime
+	fd protoreflect.FieldDescriptor
+	l  protoreflect.List
+}
+
+type mapObject struct {
+	r  *goja.Runtime
+	fd protoreflect.FieldDescriptor
+	m  protoreflect.Map
+}
+
+// New wraps the message into a JavaScript object. The message must be mutable for the properties to be writable.
+func New(r *goja.Runtime, m protoreflect.Message) *goja.Object {
+	return r.NewDynamicObject(&message{r: r, m: m})
+}
+
+// Message returns the message wrapped by an object created with New.
+func Message(v goja.Value) (protoreflect.Message, bool) {
+	if obj, ok := v.(*goja.Object); ok {
+		if m, ok := obj.Export().(*message); ok {
+			return m.m, true
+		}
+	}
+	return nil, false
+}
+
+// Assign sets the fields of m from the properties of a JavaScript object, which may be a plain object or a wrapped
+// message. Errors are returned as *goja.Exception.
+func Assign(r *goja.Runtime, m protoreflect.Message, v goja.Value) error {
+	// calling through a native function converts the thrown errors into *goja.Exception
+	assign, _ := goja.AssertFunction(r.ToValue(func(call goja.FunctionCall) goja.Value {
+		(&message{r: r, m: m}).assign(call.Argument(0))
+		return goja.Undefined()
+	}))
+	_, err := assign(goja.Undefined(), v)
+	return err
+}
+
+func (m *message) field(key string) protoreflect.FieldDescriptor {
+	fields := m.m.Descriptor().Fields()
+	if fd := fields.ByName(protoreflect.Name(key)); fd != nil {
+		return fd
+	}
+	return fields.ByJSONName(key)
+}
+
+func (m *message) Get(key string) goja.Value {
+	fd := m.field(key)
+	if fd == nil {
+		if od := m.m.Descriptor().Oneofs().ByName(protoreflect.Name(key)); od != nil {
+			if fd := m.m.WhichOneof(od); fd != nil {
+				return m.r.ToValue(string(fd.Name()))
+			}
+			return goja.Null()
+		}
+		if key == "toJSON" {
+			return m.r.ToValue(m.toJSON)
+		}
+		return nil
+	}
+	switch {
+	case fd.IsList():
+		return m.r.NewDynamicArray(&list{r: m.r, fd: fd, l: m.value(fd).List()})
+	case fd.IsMap():
+		return m.r.NewDynamicObject(&mapObject{r: m.r, fd: fd, m: m.value(fd).Map()})
+	case fd.Message() != nil:
+		if !m.m.Has(fd) {
+			return goja.Null()
+		}
+	}
+	return toJS(m.r, fd, m.m.Get(fd))
+}
+
+// value returns the value of a repeated or a map field, which is mutable unless the message is read-only.
+func (m *message) value(fd protoreflect.FieldDescriptor) protoreflect.Value {
+	if m.m.IsValid() {
+		return m.m.Mutable(fd)
+	}
+	return m.m.Get(fd)
+}
+
+func (m *message) Set(key string, val goja.Value) bool {
+	fd := m.field(key)
+	if fd == nil {
+		panic(m.r.NewTypeError("Unknown field '%s' of %s", key, m.m.Descriptor().FullName()))
+	}
+	if isNullish(val) {
+		m.m.Clear(fd)
+		return true
+	}
+	switch {
+	case fd.IsList():
+		values := arrayValues(m.r, fd, val)
+		l := m.m.Mutable(fd).List()
+		l.Truncate(0)
+		for _, v := range values {
+			l.Append(fromJS(m.r, fd, v, l.NewElement))
+		}
+	case fd.IsMap():
+		entries := mapEntries(m.r, fd, val)
+		mp := m.m.Mutable(fd).Map()
+		mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+			mp.Clear(k)
+			return true
+		})
+		for _, e := range entries {
+			mp.Set(e.key, fromJS(m.r, fd.MapValue(), e.value, func() protoreflect.Value {
+				return mp.NewValue()
+			}))
+		}
+	default:
+		m.m.Set(fd, fromJS(m.r, fd, val, func() protoreflect.Value {
+			return m.m.NewField(fd)
+		}))
+	}
+	return true
+}
+
+func (m *message) Has(key string) bool {
+	if m.field(key) != nil {
+		return true
+	}
+	return m.m.Descriptor().Oneofs().ByName(protoreflect.Name(key)) != nil
+}
+
+func (m *message) Delete(key string) bool {
+	if fd := m.field(key); fd != nil {
+		m.m.Clear(fd)
+	}
+	return true
+}
+
+func (m *message) Keys() []string {
+	fields := m.m.Descriptor().Fields()
+	var keys []string
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); m.m.Has(fd) {
+			keys = append(keys, string(fd.Name()))
+		}
+	}
+	return keys
+}
+
+func (m *message) assign(v goja.Value) {
+	obj := v.ToObject(m.r)
+	if src, ok := obj.Export().(*message); ok {
+		if src.m.Descriptor().FullName() != m.m.Descriptor().FullName() {
+			panic(m.r.NewTypeError("Cannot assign %s to %s", src.m.Descriptor().FullName(), m.m.Descriptor().FullName()))
+		}
+		if src.m != m.m {
+			proto.Merge(m.m.Interface(), src.m.Interface())
+		}
+		return
+	}
+	for _, key := range obj.Keys() {
+		m.Set(key, obj.Get(key))
+	}
+}
+
+func (m *message) toJSON(goja.FunctionCall) goja.Value {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m.m.Interface())
+	if err != nil {
+		panic(m.r.NewGoError(err))
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	res, err := decodeJSON(m.r, dec)
+	if err != nil {
+		panic(m.r.NewGoError(err))
+	}
+	return res
+}
+
+// decodeJSON converts the JSON output of protojson into JavaScript values preserving the order of the fields.
+func decodeJSON(r *goja.Runtime, dec *json.Decoder) (goja.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			var items []interface{}
+			for dec.More() {
+				item, err := decodeJSON(r, dec)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			_, err = dec.Token()
+			return r.NewArray(items...), err
+		}
+		obj := r.NewObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(r, dec)
+			if err != nil {
+				return nil, err
+			}
+			_ = obj.Set(key.(string), value)
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Number:
+		f, err := tok.Float64()
+		return r.ToValue(f), err
+	case nil:
+		return goja.Null(), nil
+	}
+	return r.ToValue(tok), nil
+}
+
+func (l *list) Len() int {
+	return l.l.Len()
+}
+
+func (l *list) Get(idx int) goja.Value {
+	if idx < 0 || idx >= l.l.Len() {
+		return nil
+	}
+	return toJS(l.r, l.fd, l.l.Get(idx))
+}
+
+func (l *list) Set(idx int, val goja.Value) bool {
+	if idx < 0 {
+		return false
+	}
+	v := fromJS(l.r, l.fd, val, l.l.NewElement)
+	if idx < l.l.Len() {
+		l.l.Set(idx, v)
+		return true
+	}
+	for l.l.Len() < idx {
+		l.l.Append(l.l.NewElement())
+	}
+	l.l.Append(v)
+	return true
+}
+
+func (l *list) SetLen(n int) bool {
+	if n < l.l.Len() {
+		l.l.Truncate(n)
+		return true
+	}
+	for l.l.Len() < n {
+		l.l.Append(l.l.NewElement())
+	}
+	return true
+}
+
+func (m *mapObject) key(s string) (protoreflect.MapKey, bool) {
+	var v protoreflect.Value
+	switch m.fd.MapKey().Kind() {
+	case protoreflect.StringKind:
+		v = protoreflect.ValueOfString(s)
+	case protoreflect.BoolKind:
+		switch s {
+		case "true":
+			v = protoreflect.ValueOfBool(true)
+		case "false":
+			v = protoreflect.ValueOfBool(false)
+		default:
+			return protoreflect.MapKey{}, false
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, false
+		}
+		v = protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, false
+		}
+		v = protoreflect.ValueOfInt64(n)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, false
+		}
+		v = protoreflect.ValueOfUint32(uint32(n))
+	default:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, false
+		}
+		v = protoreflect.ValueOfUint64(n)
+	}
+	return v.MapKey(), true
+}
+
+func (m *mapObject) Get(key string) goja.Value {
+	k, ok := m.key(key)
+	if !ok || !m.m.Has(k) {
+		return nil
+	}
+	return toJS(m.r, m.fd.MapValue(), m.m.Get(k))
+}
+
+func (m *mapObject) Set(key string, val goja.Value) bool {
+	k, ok := m.key(key)
+	if !ok {
+		panic(m.r.NewTypeError("Invalid key '%s' for the map field %s", key, m.fd.FullName()))
+	}
+	m.m.Set(k, fromJS(m.r, m.fd.MapValue(), val, m.m.NewValue))
+	return true
+}
+
+func (m *mapObject) Has(key string) bool {
+	k, ok := m.key(key)
+	return ok && m.m.Has(k)
+}
+
+func (m *mapObject) Delete(key string) bool {
+	if k, ok := m.key(key); ok {
+		m.m.Clear(k)
+	}
+	return true
+}
+
+func (m *mapObject) Keys() []string {
+	keys := make([]protoreflect.MapKey, 0, m.m.Len())
+	m.m.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sortMapKeys(m.fd.MapKey().Kind(), keys)
+	res := make([]string, len(keys))
+	for i, k := range keys {
+		res[i] = k.String()
+	}
+	return res
+}
+
+func sortMapKeys(kind protoreflect.Kind, keys []protoreflect.MapKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		switch kind {
+		case protoreflect.StringKind:
+			return keys[i].String() < keys[j].String()
+		case protoreflect.BoolKind:
+			return !keys[i].Bool() && keys[j].Bool()
+		case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+			protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+			return keys[i].Int() < keys[j].Int()
+		}
+		return keys[i].Uint() < keys[j].Uint()
+	})
+}
+
+func isNullish(v goja.Value) bool {
+	return v == nil || goja.IsUndefined(v) || goja.IsNull(v)
+}
+
+func arrayValues(r *goja.Runtime, fd protoreflect.FieldDescriptor, val goja.Value) []goja.Value {
+	obj, ok := val.(*goja.Object)
+	if !ok {
+		panic(r.NewTypeError("The value of the repeated field %s must be an array", fd.FullName()))
+	}
+	l := obj.Get("length")
+	if l == nil {
+		panic(r.NewTypeError("The value of the repeated field %s must be an array", fd.FullName()))
+	}
+	n := l.ToInteger()
+	values := make([]goja.Value, 0, n)
+	for i := int64(0); i < n; i++ {
+		values = append(values, obj.Get(strconv.FormatInt(i, 10)))
+	}
+	return values
+}
+
+type mapEntry struct {
+	key   protoreflect.MapKey
+	value goja.Value
+}
+
+func mapEntries(r *goja.Runtime, fd protoreflect.FieldDescriptor, val goja.Value) []mapEntry {
+	obj, ok := val.(*goja.Object)
+	if !ok {
+		panic(r.NewTypeError("The value of the map field %s must be an object", fd.FullName()))
+	}
+	keyConv := &mapObject{r: r, fd: fd}
+	keys := obj.Keys()
+	entries := make([]mapEntry, 0, len(keys))
+	for _, key := range keys {
+		k, ok := keyConv.key(key)
+		if !ok {
+			panic(r.NewTypeError("Invalid key '%s' for the map field %s", key, fd.FullName()))
+		}
+		entries = append(entries, mapEntry{key: k, value: obj.Get(key)})
+	}
+	return entries
+}
+
+// toJS converts a singular value (or a list element or a map value) of the field.
+func toJS(r *goja.Runtime, fd protoreflect.FieldDescriptor, v protoreflect.Value) goja.Value {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return r.ToValue(v.Bool())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return r.ToValue(string(ev.Name()))
+		}
+		return r.ToValue(int64(v.Enum()))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return r.ToValue(v.Int())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return r.ToValue(v.Uint())
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return r.ToValue(big.NewInt(v.Int()))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return r.ToValue(new(big.Int).SetUint64(v.Uint()))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return r.ToValue(v.Float())
+	case protoreflect.StringKind:
+		return r.ToValue(v.String())
+	case protoreflect.BytesKind:
+		return r.ToValue(r.NewArrayBuffer(append([]byte(nil), v.Bytes()...)))
+	}
+	return New(r, v.Message())
+}
+
+// fromJS converts a JavaScript value into a singular value (or a list element or a map value) of the field. The
+// newMessage function is used to create messages for plain objects.
+func fromJS(r *goja.Runtime, fd protoreflect.FieldDescriptor, val goja.Value, newMessage func() protoreflect.Value) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(val.ToBoolean())
+	case protoreflect.EnumKind:
+		if s, ok := val.Export().(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number())
+			}
+			panic(r.NewTypeError("Invalid value '%s' for the enum %s", s, fd.Enum().FullName()))
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(toInt(r, fd, val, math.MinInt32, math.MaxInt32).Int64()))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(toInt(r, fd, val, math.MinInt32, math.MaxInt32).Int64()))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(toInt(r, fd, val, 0, math.MaxUint32).Int64()))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(toInt(r, fd, val, math.MinInt64, math.MaxInt64).Int64())
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(toUint64(r, fd, val))
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(toNumber(r, fd, val)))
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(toNumber(r, fd, val))
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(val.String())
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes(toBytes(r, fd, val))
+	}
+	obj, ok := val.(*goja.Object)
+	if !ok {
+		panic(r.NewTypeError("The value of the field %s must be an object", fd.FullName()))
+	}
+	if src, ok := obj.Export().(*message); ok {
+		if src.m.Descriptor().FullName() != fd.Message().FullName() {
+			panic(r.NewTypeError("Cannot assign %s to the field %s", src.m.Descriptor().FullName(), fd.FullName()))
+		}
+		return protoreflect.ValueOfMessage(proto.Clone(src.m.Interface()).ProtoReflect())
+	}
+	v := newMessage()
+	(&message{r: r, m: v.Message()}).assign(obj)
+	return v
+}
+
+func toNumber(r *goja.Runtime, fd protoreflect.FieldDescriptor, val goja.Value) float64 {
+	switch val.Export().(type) {
+	case int64, float64:
+		return val.ToFloat()
+	case *big.Int:
+		return val.ToFloat()
+	}
+	panic(r.NewTypeError("The value of the field %s must be a number", fd.FullName()))
+}
+
+func toBigInt(r *goja.Runtime, fd protoreflect.FieldDescriptor, val goja.Value) *big.Int {
+	switch v := val.Export().(type) {
+	case int64:
+		return big.NewInt(v)
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			b, _ := big.NewFloat(v).Int(nil)
+			return b
+		}
+	case *big.Int:
+		return v
+	case string:
+		if b, ok := new(big.Int).SetString(v, 10); ok {
+			return b
+		}
+	}
+	panic(r.NewTypeError("The value of the field %s must be an integer", fd.FullName()))
+}
+
+func toInt(r *goja.Runtime, fd protoreflect.FieldDescriptor, val goja.Value, min, max int64) *big.Int {
+	b := toBigInt(r, fd, val)
+	if !b.IsInt64() || b.Int64() < min || b.Int64() > max {
+		panic(r.NewTypeError("The value %s is out of range for the field %s", b.String(), fd.FullName()))
+	}
+	return b
+}
+
+func toUint64(r *goja.Runtime, fd protoreflect.FieldDescriptor, val goja.Value) uint64 {
+	b := toBigInt(r, fd, val)
+	if !b.IsUint64() {
+		panic(r.NewTypeError("The value %s is out of range for the field %s", b.String(), fd.FullName()))
+	}
+	return b.Uint64()
+}
+
+func toBytes(r *goja.Runtime, fd protoreflect.FieldDescriptor, val goja.Value) []byte {
+	if obj, ok := val.(*goja.Object); ok {
+		if buf, ok := obj.Export().(goja.ArrayBuffer); ok {
+			return append([]byte(nil), buf.Bytes()...)
+		}
+		if b, ok := obj.Get("buffer").(*goja.Object); ok {
+			if buf, ok := b.Export().(goja.ArrayBuffer); ok {
+				data := buf.Bytes()
+				offset, length := obj.Get("byteOffset").ToInteger(), obj.Get("byteLength").ToInteger()
+				if offset >= 0 && length >= 0 && offset+length <= int64(len(data)) {
+					return append([]byte(nil), data[offset:offset+length]...)
+				}
+			}
+		}
+	}
+	panic(r.NewTypeError("The value of the field %s must be an ArrayBuffer or a view", fd.FullName()))
+}
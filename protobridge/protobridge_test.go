@@ -0,0 +1,255 @@
+package protobridge
+
+import (
+	"testing"
+
+	"github.com/dop251/goja"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func testDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   typ.Enum(),
+			Label:  label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	)
+	labelsEntry := &descriptorpb.DescriptorProto{
+		Name: proto.String("LabelsEntry"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+			field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+		},
+		Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+	}
+	countsEntry := &descriptorpb.DescriptorProto{
+		Name: proto.String("CountsEntry"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+			field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ".test.Item"),
+		},
+		Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+	}
+	email := field("email", 11, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, "")
+	email.OneofIndex = proto.Int32(0)
+	phone := field("phone", 12, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, "")
+	phone.OneofIndex = proto.Int32(0)
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("PENDING"), Number: proto.Int32(1)},
+				{Name: proto.String("SHIPPED"), Number: proto.Int32(2)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Item"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("sku", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("quantity", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+				},
+			},
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+					field("customer_name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("status", 3, descriptorpb.FieldDescriptorProto_TYPE_ENUM, optional, ".test.Status"),
+					field("items", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".test.Item"),
+					field("labels", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".test.Order.LabelsEntry"),
+					field("main_item", 6, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ".test.Item"),
+					field("payload", 7, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional, ""),
+					field("weight", 8, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, optional, ""),
+					field("tags", 9, descriptorpb.FieldDescriptorProto_TYPE_STRING, repeated, ""),
+					field("counts", 10, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".test.Order.CountsEntry"),
+					email,
+					phone,
+					field("serial", 13, descriptorpb.FieldDescriptorProto_TYPE_UINT64, optional, ""),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{labelsEntry, countsEntry},
+				OneofDecl:  []*descriptorpb.OneofDescriptorProto{{Name: proto.String("contact")}},
+			},
+		},
+	}
+	file, err := protodesc.NewFile(fd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file.Messages().ByName("Order")
+}
+
+func TestMessage(t *testing.T) {
+	desc := testDescriptor(t)
+	msg := dynamicpb.NewMessage(desc)
+	err := protojson.Unmarshal([]byte(`{
+		"id": "9007199254740993",
+		"customerName": "Alice",
+		"status": "PENDING",
+		"items": [{"sku": "A1", "quantity": 2}],
+		"labels": {"priority": "low"},
+		"payload": "AQID"
+	}`), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm := goja.New()
+	vm.Set("msg", New(vm, msg))
+	vm.Set("assertEq", func(actual, expected goja.Value, msg string) {
+		if !actual.StrictEquals(expected) {
+			panic(vm.NewTypeError("%s: expected %v, got %v", msg, expected, actual))
+		}
+	})
+	_, err = vm.RunString(`
+	assertEq(msg.id, 9007199254740993n, "id");
+	assertEq(msg.customer_name, "Alice", "customer_name");
+	assertEq(msg.customerName, "Alice", "JSON name");
+	assertEq(msg.status, "PENDING", "status");
+	assertEq(msg.items.length, 1, "items.length");
+	assertEq(msg.items[0].sku, "A1", "items[0].sku");
+	assertEq(msg.labels.priority, "low", "labels.priority");
+	assertEq(msg.main_item, null, "main_item");
+	assertEq(msg.weight, 0, "weight");
+	assertEq(msg.contact, null, "contact");
+	assertEq(Object.keys(msg).join(), "id,customer_name,status,items,labels,payload", "keys");
+	assertEq(new Uint8Array(msg.payload).join(), "1,2,3", "payload");
+
+	msg.id = 42;
+	msg.status = 2;
+	msg.items.push({sku: "B2", quantity: 5});
+	msg.items[0].quantity++;
+	msg.labels.priority = "high";
+	msg.labels["region"] = "eu";
+	delete msg.customer_name;
+	msg.main_item = {sku: "C3"};
+	msg.main_item.quantity = 7;
+	msg.payload = new Uint8Array([4, 5, 6, 7]).subarray(1, 3);
+	msg.weight = 1.5;
+	msg.tags = ["a", "b"];
+	msg.tags[3] = "d";
+	msg.counts[10] = {sku: "X", quantity: 10};
+	msg.email = "a@example.com";
+	assertEq(msg.contact, "email", "contact");
+	msg.phone = "123";
+	assertEq(msg.contact, "phone", "contact after phone");
+	assertEq(msg.email, "", "email cleared by the oneof");
+	msg.serial = "18446744073709551615";
+
+	let threw = false;
+	try {
+		msg.unknown = 1;
+	} catch (e) {
+		threw = e instanceof TypeError;
+	}
+	assertEq(threw, true, "unknown field");
+	for (const [field, value] of [["status", "BOGUS"], ["id", 1.5], ["items", 1], ["main_item", "x"], ["payload", "x"], ["weight", "1"]]) {
+		threw = false;
+		try {
+			msg[field] = value;
+		} catch (e) {
+			threw = e instanceof TypeError;
+		}
+		assertEq(threw, true, "invalid value for " + field);
+	}
+
+	JSON.stringify(msg);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := dynamicpb.NewMessage(desc)
+	err = protojson.Unmarshal([]byte(`{
+		"id": "42",
+		"status": "SHIPPED",
+		"items": [{"sku": "A1", "quantity": 3}, {"sku": "B2", "quantity": 5}],
+		"labels": {"priority": "high", "region": "eu"},
+		"mainItem": {"sku": "C3", "quantity": 7},
+		"payload": "BQY=",
+		"weight": 1.5,
+		"tags": ["a", "b", "", "d"],
+		"counts": {"10": {"sku": "X", "quantity": 10}},
+		"phone": "123",
+		"serial": "18446744073709551615"
+	}`), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(msg, expected) {
+		t.Fatalf("unexpected message: %v", msg)
+	}
+
+	res, err := vm.RunString(`JSON.stringify(msg.items[1])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != `{"sku":"B2","quantity":5}` {
+		t.Fatalf("unexpected JSON: %s", s)
+	}
+	res, err = vm.RunString(`JSON.stringify({id: msg.id})`)
+	if err == nil {
+		t.Fatalf("expected an error serialising a BigInt, got %v", res)
+	}
+	res, err = vm.RunString(`JSON.parse(JSON.stringify(msg)).id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "42" {
+		t.Fatalf("unexpected id: %s", s)
+	}
+}
+
+func TestAssign(t *testing.T) {
+	desc := testDescriptor(t)
+	vm := goja.New()
+	v, err := vm.RunString(`({id: 7n, status: "SHIPPED", items: [{sku: "A"}], labels: {k: "v"}})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := dynamicpb.NewMessage(desc)
+	if err := Assign(vm, msg, v); err != nil {
+		t.Fatal(err)
+	}
+	if id := msg.Get(desc.Fields().ByName("id")).Int(); id != 7 {
+		t.Fatalf("unexpected id: %d", id)
+	}
+	copied := dynamicpb.NewMessage(desc)
+	if err := Assign(vm, copied, New(vm, msg)); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(msg, copied) {
+		t.Fatal("messages are not equal")
+	}
+	if m, ok := Message(New(vm, msg)); !ok || m != msg.ProtoReflect() {
+		t.Fatal("Message() did not return the wrapped message")
+	}
+
+	v, err = vm.RunString(`({status: "BOGUS"})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Assign(vm, dynamicpb.NewMessage(desc), v)
+	if _, ok := err.(*goja.Exception); !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 	"unicode/utf16"
 
 	"github.com/dlclark/regexp2"
@@ -18,10 +19,19 @@ type regexp2MatchCache struct {
 	posMap []int
 }
 
+// regexp2MatchTimeout is the time after which a regexp2 match is restarted with a doubled timeout, checking for an
+// interrupt in between (regexp2 does not provide any other way to stop a match). Restarting the matches that take
+// longer makes them at most about twice as slow.
+const regexp2MatchTimeout = 100 * time.Millisecond
+
 // Not goroutine-safe. Use regexp2Wrapper.clone()
 type regexp2Wrapper struct {
 	rx    *regexp2.Regexp
+	opts  regexp2.RegexOptions
 	cache *regexp2MatchCache
+
+	// timed is a private copy of rx used to restart the matches which have timed out, see regexp2MatchTimeout.
+	timed *regexp2.Regexp
 }
 
 type regexpWrapper regexp.Regexp
@@ -68,6 +78,7 @@ type regexpPattern struct {
 	multiline      bool
 	sticky         bool
 	unicode        bool
+	unicodeSets    bool
 }
 
 func compileRegexp2(src string, multiline, ignoreCase bool) (*regexp2Wrapper, error) {
@@ -82,8 +93,9 @@ func compileRegexp2(src string, multiline, ignoreCase bool) (*regexp2Wrapper, er
 	if err1 != nil {
 		return nil, fmt.Errorf("Invalid regular expression (regexp2): %s (%v)", src, err1)
 	}
+	regexp2Pattern.MatchTimeout = regexp2MatchTimeout
 
-	return &regexp2Wrapper{rx: regexp2Pattern}, nil
+	return &regexp2Wrapper{rx: regexp2Pattern, opts: opts}, nil
 }
 
 func (p *regexpPattern) createRegexp2() {
@@ -120,23 +132,23 @@ func buildUTF8PosMap(s unicodeString) (positionMap, string) {
 	return pm, sb.String()
 }
 
-func (p *regexpPattern) findSubmatchIndex(s valueString, start int) []int {
+func (p *regexpPattern) findSubmatchIndex(s valueString, start int, vm *vm) []int {
 	if p.regexpWrapper == nil {
-		return p.regexp2Wrapper.findSubmatchIndex(s, start, p.unicode, p.global || p.sticky)
+		return p.regexp2Wrapper.findSubmatchIndex(s, start, p.unicode, p.global || p.sticky, vm)
 	}
 	if start != 0 {
 		// Unfortunately Go's regexp library does not allow starting from an arbitrary position.
 		// If we just drop the first _start_ characters of the string the assertions (^, $, \b and \B) will not
 		// work correctly.
 		p.createRegexp2()
-		return p.regexp2Wrapper.findSubmatchIndex(s, start, p.unicode, p.global || p.sticky)
+		return p.regexp2Wrapper.findSubmatchIndex(s, start, p.unicode, p.global || p.sticky, vm)
 	}
 	return p.regexpWrapper.findSubmatchIndex(s, p.unicode)
 }
 
-func (p *regexpPattern) findAllSubmatchIndex(s valueString, start int, limit int, sticky bool) [][]int {
+func (p *regexpPattern) findAllSubmatchIndex(s valueString, start int, limit int, sticky bool, vm *vm) [][]int {
 	if p.regexpWrapper == nil {
-		return p.regexp2Wrapper.findAllSubmatchIndex(s, start, limit, sticky, p.unicode)
+		return p.regexp2Wrapper.findAllSubmatchIndex(s, start, limit, sticky, p.unicode, vm)
 	}
 	if start == 0 {
 		a, u := devirtualizeString(s)
@@ -168,18 +180,19 @@ func (p *regexpPattern) findAllSubmatchIndex(s valueString, start int, limit int
 	}
 
 	p.createRegexp2()
-	return p.regexp2Wrapper.findAllSubmatchIndex(s, start, limit, sticky, p.unicode)
+	return p.regexp2Wrapper.findAllSubmatchIndex(s, start, limit, sticky, p.unicode, vm)
 }
 
 // clone creates a copy of the regexpPattern which can be used concurrently.
 func (p *regexpPattern) clone() *regexpPattern {
 	ret := &regexpPattern{
-		src:        p.src,
-		global:     p.global,
-		ignoreCase: p.ignoreCase,
-		multiline:  p.multiline,
-		sticky:     p.sticky,
-		unicode:    p.unicode,
+		src:         p.src,
+		global:      p.global,
+		ignoreCase:  p.ignoreCase,
+		multiline:   p.multiline,
+		sticky:      p.sticky,
+		unicode:     p.unicode,
+		unicodeSets: p.unicodeSets,
 	}
 	if p.regexpWrapper != nil {
 		ret.regexpWrapper = p.regexpWrapper.clone()
@@ -197,15 +210,52 @@ type regexpObject struct {
 	standard bool
 }
 
-func (r *regexp2Wrapper) findSubmatchIndex(s valueString, start int, fullUnicode, doCache bool) (result []int) {
+func (r *regexp2Wrapper) findSubmatchIndex(s valueString, start int, fullUnicode, doCache bool, vm *vm) (result []int) {
 	if fullUnicode {
-		return r.findSubmatchIndexUnicode(s, start, doCache)
+		return r.findSubmatchIndexUnicode(s, start, doCache, vm)
 	}
-	return r.findSubmatchIndexUTF16(s, start, doCache)
+	return r.findSubmatchIndexUTF16(s, start, doCache, vm)
 }
 
-func (r *regexp2Wrapper) findUTF16Cached(s valueString, start int, doCache bool) (match *regexp2.Match, runes []rune, err error) {
-	wrapped := r.rx
+// run calls f with rx. If the match times out, it is restarted with a doubled timeout until it completes or the vm is
+// interrupted, see regexp2MatchTimeout.
+func (r *regexp2Wrapper) run(vm *vm, f func(rx *regexp2.Regexp) (*regexp2.Match, error)) (*regexp2.Match, error) {
+	match, err := f(r.rx)
+	if err == nil {
+		return match, nil
+	}
+	if r.timed == nil {
+		timed, err := regexp2.Compile(r.rx.String(), r.opts)
+		if err != nil {
+			return nil, err
+		}
+		r.timed = timed
+	}
+	timeout := regexp2MatchTimeout
+	for {
+		vm.checkInterrupt()
+		timeout *= 2
+		r.timed.MatchTimeout = timeout
+		match, err = f(r.timed)
+		if err == nil {
+			return match, nil
+		}
+	}
+}
+
+func (r *regexp2Wrapper) findRunes(runes []rune, start int, vm *vm) (*regexp2.Match, error) {
+	return r.run(vm, func(rx *regexp2.Regexp) (*regexp2.Match, error) {
+		return rx.FindRunesMatchStartingAt(runes, start)
+	})
+}
+
+func (r *regexp2Wrapper) findNext(match *regexp2.Match, vm *vm) (*regexp2.Match, error) {
+	return r.run(vm, func(rx *regexp2.Regexp) (*regexp2.Match, error) {
+		return rx.FindNextMatch(match)
+	})
+}
+
+func (r *regexp2Wrapper) findUTF16Cached(s valueString, start int, doCache bool, vm *vm) (match *regexp2.Match, runes []rune, err error) {
 	cache := r.cache
 	if cache != nil && cache.posMap == nil && cache.target.SameAs(s) {
 		runes = cache.runes
@@ -213,7 +263,7 @@ func (r *regexp2Wrapper) findUTF16Cached(s valueString, start int, doCache bool)
 		runes = s.utf16Runes()
 		cache = nil
 	}
-	match, err = wrapped.FindRunesMatchStartingAt(runes, start)
+	match, err = r.findRunes(runes, start, vm)
 	if doCache && match != nil && err == nil {
 		if cache == nil {
 			if r.cache == nil {
@@ -230,8 +280,8 @@ func (r *regexp2Wrapper) findUTF16Cached(s valueString, start int, doCache bool)
 	return
 }
 
-func (r *regexp2Wrapper) findSubmatchIndexUTF16(s valueString, start int, doCache bool) (result []int) {
-	match, _, err := r.findUTF16Cached(s, start, doCache)
+func (r *regexp2Wrapper) findSubmatchIndexUTF16(s valueString, start int, doCache bool, vm *vm) (result []int) {
+	match, _, err := r.findUTF16Cached(s, start, doCache, vm)
 	if err != nil {
 		return
 	}
@@ -252,14 +302,13 @@ func (r *regexp2Wrapper) findSubmatchIndexUTF16(s valueString, start int, doCach
 	return
 }
 
-func (r *regexp2Wrapper) findUnicodeCached(s valueString, start int, doCache bool) (match *regexp2.Match, posMap []int, err error) {
+func (r *regexp2Wrapper) findUnicodeCached(s valueString, start int, doCache bool, vm *vm) (match *regexp2.Match, posMap []int, err error) {
 	var (
 		runes       []rune
 		mappedStart int
 		splitPair   bool
 		savedRune   rune
 	)
-	wrapped := r.rx
 	cache := r.cache
 	if cache != nil && cache.posMap != nil && cache.target.SameAs(s) {
 		runes, posMap = cache.runes, cache.posMap
@@ -269,11 +318,13 @@ func (r *regexp2Wrapper) findUnicodeCached(s valueString, start int, doCache boo
 		cache = nil
 	}
 	if splitPair {
-		// temporarily set the rune at mappedStart to the second code point of the pair
+		// temporarily set the rune at mappedStart to the second code point of the pair (the cache is dropped until
+		// it is restored in case the match is interrupted)
+		r.cache = nil
 		_, second := utf16.EncodeRune(runes[mappedStart])
 		savedRune, runes[mappedStart] = runes[mappedStart], second
 	}
-	match, err = wrapped.FindRunesMatchStartingAt(runes, mappedStart)
+	match, err = r.findRunes(runes, mappedStart, vm)
 	if doCache && match != nil && err == nil {
 		if splitPair {
 			runes[mappedStart] = savedRune
@@ -287,6 +338,8 @@ func (r *regexp2Wrapper) findUnicodeCached(s valueString, start int, doCache boo
 				runes:  runes,
 				posMap: posMap,
 			}
+		} else {
+			r.cache = cache
 		}
 	} else {
 		r.cache = nil
@@ -295,8 +348,8 @@ func (r *regexp2Wrapper) findUnicodeCached(s valueString, start int, doCache boo
 	return
 }
 
-func (r *regexp2Wrapper) findSubmatchIndexUnicode(s valueString, start int, doCache bool) (result []int) {
-	match, posMap, err := r.findUnicodeCached(s, start, doCache)
+func (r *regexp2Wrapper) findSubmatchIndexUnicode(s valueString, start int, doCache bool, vm *vm) (result []int) {
+	match, posMap, err := r.findUnicodeCached(s, start, doCache, vm)
 	if match == nil || err != nil {
 		return
 	}
@@ -314,9 +367,8 @@ func (r *regexp2Wrapper) findSubmatchIndexUnicode(s valueString, start int, doCa
 	return
 }
 
-func (r *regexp2Wrapper) findAllSubmatchIndexUTF16(s valueString, start, limit int, sticky bool) [][]int {
-	wrapped := r.rx
-	match, runes, err := r.findUTF16Cached(s, start, false)
+func (r *regexp2Wrapper) findAllSubmatchIndexUTF16(s valueString, start, limit int, sticky bool, vm *vm) [][]int {
+	match, runes, err := r.findUTF16Cached(s, start, false, vm)
 	if match == nil || err != nil {
 		return nil
 	}
@@ -351,7 +403,8 @@ func (r *regexp2Wrapper) findAllSubmatchIndexUTF16(s valueString, start, limit i
 		if limit <= 0 {
 			break
 		}
-		match, err = wrapped.FindNextMatch(match)
+		vm.checkInterrupt()
+		match, err = r.findNext(match, vm)
 		if err != nil {
 			return nil
 		}
@@ -397,13 +450,12 @@ func posMapReverseLookup(posMap []int, pos int) (int, bool) {
 	return mapped, false
 }
 
-func (r *regexp2Wrapper) findAllSubmatchIndexUnicode(s unicodeString, start, limit int, sticky bool) [][]int {
-	wrapped := r.rx
+func (r *regexp2Wrapper) findAllSubmatchIndexUnicode(s unicodeString, start, limit int, sticky bool, vm *vm) [][]int {
 	if limit < 0 {
 		limit = len(s) + 1
 	}
 	results := make([][]int, 0, limit)
-	match, posMap, err := r.findUnicodeCached(s, start, false)
+	match, posMap, err := r.findUnicodeCached(s, start, false, vm)
 	if err != nil {
 		return nil
 	}
@@ -430,7 +482,8 @@ func (r *regexp2Wrapper) findAllSubmatchIndexUnicode(s unicodeString, start, lim
 		}
 
 		results = append(results, result)
-		match, err = wrapped.FindNextMatch(match)
+		vm.checkInterrupt()
+		match, err = r.findNext(match, vm)
 		if err != nil {
 			return nil
 		}
@@ -438,20 +491,21 @@ func (r *regexp2Wrapper) findAllSubmatchIndexUnicode(s unicodeString, start, lim
 	return results
 }
 
-func (r *regexp2Wrapper) findAllSubmatchIndex(s valueString, start, limit int, sticky, fullUnicode bool) [][]int {
+func (r *regexp2Wrapper) findAllSubmatchIndex(s valueString, start, limit int, sticky, fullUnicode bool, vm *vm) [][]int {
 	a, u := devirtualizeString(s)
 	if u != nil {
 		if fullUnicode {
-			return r.findAllSubmatchIndexUnicode(u, start, limit, sticky)
+			return r.findAllSubmatchIndexUnicode(u, start, limit, sticky, vm)
 		}
-		return r.findAllSubmatchIndexUTF16(u, start, limit, sticky)
+		return r.findAllSubmatchIndexUTF16(u, start, limit, sticky, vm)
 	}
-	return r.findAllSubmatchIndexUTF16(a, start, limit, sticky)
+	return r.findAllSubmatchIndexUTF16(a, start, limit, sticky, vm)
 }
 
 func (r *regexp2Wrapper) clone() *regexp2Wrapper {
 	return &regexp2Wrapper{
-		rx: r.rx,
+		rx:   r.rx,
+		opts: r.opts,
 	}
 }
 
@@ -557,9 +611,12 @@ func (r *regexpObject) updateLastIndex(index int64, firstResult, lastResult []in
 func (r *regexpObject) execRegexp(target valueString) (match bool, result []int) {
 	index := r.getLastIndex()
 	if index >= 0 && index <= int64(target.length()) {
-		result = r.pattern.findSubmatchIndex(target, int(index))
+		result = r.pattern.findSubmatchIndex(target, int(index), r.val.runtime.vm)
 	}
 	match = r.updateLastIndex(index, result, result)
+	if match {
+		r.val.runtime.updateLegacyRegExpStatics(target, result)
+	}
 	return
 }
 
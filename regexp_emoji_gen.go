@@ -0,0 +1,184 @@
+//go:build ignore
+// +build ignore
+
+// This program generates regexp_emoji_tables.go, the tables of the emoji properties of strings used by the
+// RegExp 'v' flag, from emoji-sequences.txt and emoji-zwj-sequences.txt of the Unicode emoji data.
+//
+// Usage:
+//
+//	go run regexp_emoji_gen.go [-version 16.0] [-dir path]
+//
+// If -dir is not given the files are downloaded from unicode.org.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	version = flag.String("version", "16.0", "the version of the Unicode emoji data")
+	dir     = flag.String("dir", "", "the directory with the data files; they are downloaded if empty")
+	output  = flag.String("output", "regexp_emoji_tables.go", "the output file")
+)
+
+var properties = []string{
+	"Basic_Emoji",
+	"Emoji_Keycap_Sequence",
+	"RGI_Emoji_Flag_Sequence",
+	"RGI_Emoji_Modifier_Sequence",
+	"RGI_Emoji_Tag_Sequence",
+	"RGI_Emoji_ZWJ_Sequence",
+}
+
+type property struct {
+	ranges  [][2]rune
+	strings []string
+}
+
+func open(name string) io.ReadCloser {
+	if *dir != "" {
+		f, err := os.Open(filepath.Join(*dir, name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		return f
+	}
+	url := "https://www.unicode.org/Public/emoji/" + *version + "/" + name
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("%s: %s", url, resp.Status)
+	}
+	return resp.Body
+}
+
+func parseCodePoint(s string) rune {
+	c, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rune(c)
+}
+
+// parse reads the lines of the form
+//
+//	231A..231B    ; Basic_Emoji    ; watch..hourglass done    # E0.6   [2] (⌚..⌛)
+//	0023 FE0F 20E3; Emoji_Keycap_Sequence ; keycap: \x{23}    # E0.6   [1] (#️⃣)
+func parse(name string, props map[string]*property) {
+	r := open(name)
+	defer r.Close()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Split(line, ";")
+		if len(fields) < 2 {
+			continue
+		}
+		typ := strings.TrimSpace(fields[1])
+		p := props[typ]
+		if p == nil {
+			log.Fatalf("%s: unknown property %q", name, typ)
+		}
+		cps := strings.TrimSpace(fields[0])
+		if i := strings.Index(cps, ".."); i >= 0 {
+			p.ranges = append(p.ranges, [2]rune{parseCodePoint(cps[:i]), parseCodePoint(cps[i+2:])})
+			continue
+		}
+		var sb strings.Builder
+		n := 0
+		for _, cp := range strings.Fields(cps) {
+			sb.WriteRune(parseCodePoint(cp))
+			n++
+		}
+		if n == 1 {
+			c := parseCodePoint(cps)
+			p.ranges = append(p.ranges, [2]rune{c, c})
+		} else {
+			p.strings = append(p.strings, sb.String())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// normalize sorts the ranges and merges the adjacent ones.
+func (p *property) normalize() {
+	sort.Slice(p.ranges, func(i, j int) bool {
+		return p.ranges[i][0] < p.ranges[j][0]
+	})
+	var res [][2]rune
+	for _, r := range p.ranges {
+		if l := len(res); l > 0 && r[0] <= res[l-1][1]+1 {
+			if r[1] > res[l-1][1] {
+				res[l-1][1] = r[1]
+			}
+			continue
+		}
+		res = append(res, r)
+	}
+	p.ranges = res
+	sort.Strings(p.strings)
+}
+
+func main() {
+	flag.Parse()
+	props := make(map[string]*property, len(properties))
+	for _, name := range properties {
+		props[name] = &property{}
+	}
+	parse("emoji-sequences.txt", props)
+	parse("emoji-zwj-sequences.txt", props)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by regexp_emoji_gen.go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package goja\n\n")
+	fmt.Fprintf(&buf, "// emojiStringProperties contains the properties of strings from the Unicode emoji data version %s.\n", *version)
+	fmt.Fprintf(&buf, "var emojiStringProperties = map[string]*emojiStringProperty{\n")
+	for _, name := range properties {
+		p := props[name]
+		p.normalize()
+		fmt.Fprintf(&buf, "%q: {\n", name)
+		if len(p.ranges) > 0 {
+			fmt.Fprintf(&buf, "ranges: []runeRange{\n")
+			for _, r := range p.ranges {
+				fmt.Fprintf(&buf, "{0x%04x, 0x%04x},\n", r[0], r[1])
+			}
+			fmt.Fprintf(&buf, "},\n")
+		}
+		if len(p.strings) > 0 {
+			fmt.Fprintf(&buf, "strings: []string{\n")
+			for _, s := range p.strings {
+				fmt.Fprintf(&buf, "%s,\n", strconv.QuoteToASCII(s))
+			}
+			fmt.Fprintf(&buf, "},\n")
+		}
+		fmt.Fprintf(&buf, "},\n")
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
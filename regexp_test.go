@@ -698,6 +698,108 @@ func TestRegexpInvalidUTF8(t *testing.T) {
 	}
 }
 
+func TestRegexpUnicodeSets(t *testing.T) {
+	const SCRIPT = `
+	assert(/[\p{L}--[a-z]]/v.test("A"), "difference #1");
+	assert(!/[\p{L}--[a-z]]/v.test("a"), "difference #2");
+	assert(!/[\d--[5]]/v.test("5"), "difference #3");
+	assert(/[[a-z]&&[aeiou]]/v.test("e"), "intersection #1");
+	assert(!/[[a-z]&&[aeiou]]/v.test("b"), "intersection #2");
+	assert(!/[\w&&\d]/v.test("a"), "intersection #3");
+
+	assert.sameValue("xabcx".match(/[\q{abc|d}x]/vg).join(), "x,abc,x", "strings");
+	assert.sameValue(/[\q{ab|abc}]/v.exec("abc")[0], "abc", "longest string first");
+	assert.sameValue(/[\p{Emoji_Keycap_Sequence}]/v.exec("a1\uFE0F\u20E3")[0], "1\uFE0F\u20E3", "property of strings");
+	assert(/^\p{Basic_Emoji}$/v.test("\u231A"), "Basic_Emoji #1");
+	assert(/^\p{Basic_Emoji}$/v.test("\u00A9\uFE0F"), "Basic_Emoji #2");
+	assert(!/^\p{Basic_Emoji}$/v.test("\u00A9"), "Basic_Emoji #3");
+	assert(/^\p{RGI_Emoji_Flag_Sequence}$/v.test("\u{1F1FA}\u{1F1E6}"), "RGI_Emoji_Flag_Sequence");
+	assert(/^\p{RGI_Emoji_Modifier_Sequence}$/v.test("\u{1F44B}\u{1F3FD}"), "RGI_Emoji_Modifier_Sequence");
+	assert(/^\p{RGI_Emoji_Tag_Sequence}$/v.test("\u{1F3F4}\u{E0067}\u{E0062}\u{E0073}\u{E0063}\u{E0074}\u{E007F}"), "RGI_Emoji_Tag_Sequence");
+	assert(/^\p{RGI_Emoji_ZWJ_Sequence}$/v.test("\u{1F468}\u200D\u{1F469}\u200D\u{1F467}"), "RGI_Emoji_ZWJ_Sequence");
+	assert.sameValue("a\u{1F468}\u200D\u{1F469}\u200D\u{1F467}\u{1F1FA}\u{1F1E6}#\uFE0F\u20E3\u231Ab".match(/\p{RGI_Emoji}/gv).length, 4, "RGI_Emoji");
+	assert(/^[\p{RGI_Emoji}--\p{RGI_Emoji_Flag_Sequence}]$/v.test("\u231A"), "RGI_Emoji difference #1");
+	assert(!/^[\p{RGI_Emoji}--\p{RGI_Emoji_Flag_Sequence}]$/v.test("\u{1F1FA}\u{1F1E6}"), "RGI_Emoji difference #2");
+	assert.throws(SyntaxError, function() { new RegExp("\\P{RGI_Emoji}", "v"); }, "negated property of strings");
+	assert(/^\p{Script=Greek}+$/v.test("\u03b1\u03b2"), "script");
+	assert.sameValue("\u{1F600}".match(/[^a]/gv).length, 1, "full unicode");
+
+	var re = /[a]/gv;
+	assert.sameValue(re.flags, "gv", "flags");
+	assert.sameValue(re.unicode, false, "unicode");
+	assert.sameValue(re.unicodeSets, true, "unicodeSets");
+	assert.sameValue(String(re), "/[a]/gv", "toString");
+
+	assert.throws(SyntaxError, function() { new RegExp(".", "uv"); }, "u and v");
+	assert.throws(SyntaxError, function() { new RegExp("[a-]", "v"); }, "unescaped -");
+	assert.throws(SyntaxError, function() { new RegExp("[(]", "v"); }, "unescaped (");
+	assert.throws(SyntaxError, function() { new RegExp("[a!!b]", "v"); }, "double punctuator");
+	assert.throws(SyntaxError, function() { new RegExp("[a-z--b]", "v"); }, "range operand");
+	assert.throws(SyntaxError, function() { new RegExp("[a--b&&c]", "v"); }, "mixed operators");
+	assert.throws(SyntaxError, function() { new RegExp("[^\\q{ab}]", "v"); }, "negated strings");
+	assert.throws(SyntaxError, function() { new RegExp("[\\z]", "v"); }, "identity escape");
+	assert(new RegExp("[\\-\\!]", "v").test("!"), "reserved punctuator escape");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestRegexpLegacyStatics(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(RegExp.$1, "", "initial $1");
+	assert.sameValue(RegExp.lastMatch, "", "initial lastMatch");
+
+	assert(/(\d+)-(\d+)/.test("tel: 123-456 (home)"), "test");
+	assert.sameValue(RegExp.$1, "123", "$1");
+	assert.sameValue(RegExp.$2, "456", "$2");
+	assert.sameValue(RegExp.$3, "", "$3");
+	assert.sameValue(RegExp.lastMatch, "123-456", "lastMatch");
+	assert.sameValue(RegExp["$&"], "123-456", "$&");
+	assert.sameValue(RegExp.lastParen, "456", "lastParen");
+	assert.sameValue(RegExp.leftContext, "tel: ", "leftContext");
+	assert.sameValue(RegExp["$'"], " (home)", "rightContext");
+	assert.sameValue(RegExp.input, "tel: 123-456 (home)", "input");
+	assert.sameValue(RegExp.$_, "tel: 123-456 (home)", "$_");
+
+	assert.sameValue(/x/.exec("abc"), null, "failed match");
+	assert.sameValue(RegExp.$1, "123", "failed match does not reset");
+
+	"a1b2c3".replace(/([a-z])(\d)/g, "");
+	assert.sameValue(RegExp.lastMatch, "c3", "replace");
+	"x-y".match(/(-)/);
+	assert.sameValue(RegExp.$1, "-", "match");
+	"a,b;c".split(/([,;])/);
+	assert.sameValue(RegExp.$1, ";", "split");
+
+	RegExp.input = "changed";
+	assert.sameValue(RegExp.input, "changed", "input setter");
+
+	/(b)(c)/.exec("abcd");
+	RegExp.input = "";
+	assert.sameValue(RegExp.$1, "b", "group after setting an empty input");
+	RegExp.input = "XYZW";
+	assert.sameValue([RegExp.$1, RegExp.$2, RegExp.lastMatch, RegExp.leftContext, RegExp.rightContext].join("|"),
+		"b|c|bc|a|d", "groups after setting input");
+	assert.sameValue(RegExp.$_, "XYZW", "$_ after setting input");
+
+	var desc = Object.getOwnPropertyDescriptor(RegExp, "$1");
+	assert.sameValue(desc.enumerable, false, "enumerable");
+	assert.sameValue(desc.configurable, true, "configurable");
+	assert.throws(TypeError, function() { desc.get.call({}); }, "receiver");
+	`
+	r := New()
+	r.SetLegacyRegExpStatics(true)
+	r.testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	r.SetLegacyRegExpStatics(false)
+	if v, err := r.RunString(`"$1" in RegExp || "lastMatch" in RegExp`); err != nil || v != valueFalse {
+		t.Fatal(v, err)
+	}
+	if v, err := New().RunString(`RegExp.$1`); err != nil || v != _undefined {
+		t.Fatal(v, err)
+	}
+}
+
 // this should not cause data races when run with -race
 func TestRegexpConcurrentLiterals(t *testing.T) {
 	prg := MustCompile("test.js", `var r = /(?<!-)\d+/; r.test("");`, false)
@@ -0,0 +1,739 @@
+package goja
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/dop251/goja/parser"
+)
+
+// This file implements the 'v' (unicodeSets) RegExp flag. Character classes written with the extended
+// syntax (nested classes, set difference '--', set intersection '&&', \q{...} string literals and
+// \p{...} properties) are evaluated into plain sets of code points and strings, which are then written
+// back using the syntax understood by the regular 'u' mode pipeline.
+//
+// The properties of strings (e.g. RGI_Emoji) use the tables in regexp_emoji_tables.go, see regexp_emoji_gen.go.
+//
+// Limitations: properties that are not covered by the Go unicode tables are rejected with a SyntaxError.
+// Script names must be given in their long form (e.g. Script=Greek).
+
+//go:generate go run regexp_emoji_gen.go
+
+type runeRange struct {
+	lo, hi rune
+}
+
+type charSet struct {
+	ranges  []runeRange
+	strings map[string]struct{}
+}
+
+type classSetSyntaxError struct {
+	msg string
+}
+
+const (
+	classSetSyntaxChars          = "()[]{}/-|"
+	classSetReservedDoublePunct  = "&!#$%*+,.:;<=>?@^`~"
+	classSetIdentityEscapedChars = "^$\\.*+?()[]{}|/&-!#%,:;<=>@`~"
+)
+
+var (
+	classSetDigits = []runeRange{{'0', '9'}}
+	classSetWord   = []runeRange{{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}}
+	classSetSpaces []runeRange
+)
+
+func init() {
+	var rr []runeRange
+	for _, r := range parser.WhitespaceChars {
+		rr = append(rr, runeRange{r, r})
+	}
+	classSetSpaces = normalizeRanges(rr)
+}
+
+type classSetParser struct {
+	src string
+	pos int
+}
+
+// convertRegexpUnicodeSets rewrites a pattern in unicodeSets mode into an equivalent 'u' mode pattern.
+func convertRegexpUnicodeSets(src string) (res string, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			if e, ok := x.(*classSetSyntaxError); ok {
+				err = errors.New(e.msg)
+				return
+			}
+			panic(x)
+		}
+	}()
+	p := &classSetParser{src: src}
+	var sb strings.Builder
+	start := 0
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			if p.pos+1 < len(p.src) {
+				switch p.src[p.pos+1] {
+				case 'p', 'P':
+					sb.WriteString(p.src[start:p.pos])
+					p.pos++
+					writeClassSet(&sb, p.parseClassEscape())
+					start = p.pos
+					continue
+				case 'q':
+					p.error("Invalid escape")
+				}
+			}
+			p.pos += 2
+		case '[':
+			sb.WriteString(p.src[start:p.pos])
+			p.pos++
+			writeClassSet(&sb, p.parseClass())
+			start = p.pos
+		default:
+			p.pos++
+		}
+	}
+	if start == 0 {
+		return src, nil
+	}
+	if start < len(p.src) {
+		sb.WriteString(p.src[start:])
+	}
+	return sb.String(), nil
+}
+
+func (p *classSetParser) error(msg string) {
+	panic(&classSetSyntaxError{msg: msg})
+}
+
+func (p *classSetParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *classSetParser) eat(c byte) bool {
+	if p.peek() == c && p.pos < len(p.src) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *classSetParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+// parseClass parses a class after the opening '['.
+func (p *classSetParser) parseClass() *charSet {
+	negate := p.eat('^')
+	set := p.parseClassContents()
+	if negate {
+		if len(set.strings) > 0 {
+			p.error("Negated character class may contain strings")
+		}
+		set = &charSet{ranges: complementRanges(set.ranges)}
+	}
+	return set
+}
+
+func (p *classSetParser) parseClassContents() *charSet {
+	if p.eat(']') {
+		return &charSet{}
+	}
+	set, isRange := p.parseOperand(true)
+	switch {
+	case p.hasPrefix("--"):
+		if isRange {
+			p.error("Invalid set operation in character class")
+		}
+		for p.hasPrefix("--") {
+			p.pos += 2
+			operand, _ := p.parseOperand(false)
+			set = subtractClassSets(set, operand)
+		}
+	case p.hasPrefix("&&"):
+		if isRange {
+			p.error("Invalid set operation in character class")
+		}
+		for p.hasPrefix("&&") {
+			p.pos += 2
+			if p.peek() == '&' {
+				p.error("Invalid character in character class")
+			}
+			operand, _ := p.parseOperand(false)
+			set = intersectClassSets(set, operand)
+		}
+	default:
+		for !p.eat(']') {
+			if p.hasPrefix("--") || p.hasPrefix("&&") {
+				p.error("Invalid set operation in character class")
+			}
+			operand, _ := p.parseOperand(true)
+			set = unionClassSets(set, operand)
+		}
+		return set
+	}
+	if !p.eat(']') {
+		if p.pos >= len(p.src) {
+			p.error("Unterminated character class")
+		}
+		p.error("Invalid set operation in character class")
+	}
+	return set
+}
+
+func (p *classSetParser) parseOperand(allowRange bool) (*charSet, bool) {
+	if p.pos >= len(p.src) {
+		p.error("Unterminated character class")
+	}
+	switch p.src[p.pos] {
+	case '[':
+		p.pos++
+		return p.parseClass(), false
+	case '\\':
+		if p.pos+1 < len(p.src) && strings.IndexByte("dDsSwWpPq", p.src[p.pos+1]) >= 0 {
+			p.pos++
+			return p.parseClassEscape(), false
+		}
+	}
+	lo := p.parseClassSetCharacter()
+	if allowRange && p.peek() == '-' && !p.hasPrefix("--") {
+		p.pos++
+		if p.pos >= len(p.src) {
+			p.error("Unterminated character class")
+		}
+		if p.src[p.pos] == '\\' && p.pos+1 < len(p.src) && strings.IndexByte("dDsSwWpPq", p.src[p.pos+1]) >= 0 {
+			p.error("Invalid character class")
+		}
+		hi := p.parseClassSetCharacter()
+		if hi < lo {
+			p.error("Range out of order in character class")
+		}
+		return &charSet{ranges: []runeRange{{lo, hi}}}, true
+	}
+	return &charSet{ranges: []runeRange{{lo, lo}}}, false
+}
+
+func (p *classSetParser) parseClassSetCharacter() rune {
+	if p.pos >= len(p.src) {
+		p.error("Unterminated character class")
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	if r == '\\' {
+		p.pos++
+		return p.parseCharacterEscape()
+	}
+	if r < utf8.RuneSelf {
+		if p.pos+1 < len(p.src) && p.src[p.pos+1] == byte(r) && strings.IndexByte(classSetReservedDoublePunct, byte(r)) >= 0 {
+			p.error("Invalid set operation in character class")
+		}
+		if strings.IndexByte(classSetSyntaxChars, byte(r)) >= 0 {
+			p.error("Invalid character in character class")
+		}
+	}
+	p.pos += size
+	return r
+}
+
+// parseCharacterEscape parses a character escape after the backslash.
+func (p *classSetParser) parseCharacterEscape() rune {
+	if p.pos >= len(p.src) {
+		p.error("\\ at end of pattern")
+	}
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		return '\b'
+	case 'f':
+		return '\f'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'v':
+		return '\v'
+	case 'c':
+		if l := p.peek(); 'a' <= l && l <= 'z' || 'A' <= l && l <= 'Z' {
+			p.pos++
+			return rune(l % 32)
+		}
+	case '0':
+		if d := p.peek(); d < '0' || d > '9' {
+			return 0
+		}
+	case 'x':
+		if p.pos+2 <= len(p.src) {
+			if v, ok := decodeHex(p.src[p.pos : p.pos+2]); ok {
+				p.pos += 2
+				return rune(v)
+			}
+		}
+	case 'u':
+		if r, ok := p.parseUnicodeEscape(); ok {
+			if utf16.IsSurrogate(r) && p.hasPrefix("\\u") {
+				save := p.pos
+				p.pos += 2
+				if second, ok := p.parseUnicodeEscape(); ok {
+					if dec := utf16.DecodeRune(r, second); dec != utf8.RuneError {
+						return dec
+					}
+				}
+				p.pos = save
+			}
+			return r
+		}
+	default:
+		if strings.IndexByte(classSetIdentityEscapedChars, c) >= 0 {
+			return rune(c)
+		}
+	}
+	p.error("Invalid escape")
+	return 0
+}
+
+// parseUnicodeEscape parses the part of a \u escape following 'u'.
+func (p *classSetParser) parseUnicodeEscape() (rune, bool) {
+	if p.eat('{') {
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end <= 0 || end > 8 {
+			return 0, false
+		}
+		v, ok := decodeHex(p.src[p.pos : p.pos+end])
+		if !ok || v > unicode.MaxRune {
+			return 0, false
+		}
+		p.pos += end + 1
+		return rune(v), true
+	}
+	if p.pos+4 <= len(p.src) {
+		if v, ok := decodeHex(p.src[p.pos : p.pos+4]); ok {
+			p.pos += 4
+			return rune(v), true
+		}
+	}
+	return 0, false
+}
+
+// parseClassEscape parses one of \d \D \s \S \w \W \p{} \P{} \q{} starting after the backslash.
+func (p *classSetParser) parseClassEscape() *charSet {
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'd':
+		return &charSet{ranges: classSetDigits}
+	case 'D':
+		return &charSet{ranges: complementRanges(classSetDigits)}
+	case 's':
+		return &charSet{ranges: classSetSpaces}
+	case 'S':
+		return &charSet{ranges: complementRanges(classSetSpaces)}
+	case 'w':
+		return &charSet{ranges: classSetWord}
+	case 'W':
+		return &charSet{ranges: complementRanges(classSetWord)}
+	case 'p', 'P':
+		if !p.eat('{') {
+			p.error("Invalid property name")
+		}
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end <= 0 {
+			p.error("Invalid property name")
+		}
+		name := p.src[p.pos : p.pos+end]
+		p.pos += end + 1
+		set := p.resolveProperty(name)
+		if c == 'P' {
+			if len(set.strings) > 0 {
+				p.error("Invalid property name")
+			}
+			set = &charSet{ranges: complementRanges(set.ranges)}
+		}
+		return set
+	case 'q':
+		return p.parseStringDisjunction()
+	}
+	p.error("Invalid escape")
+	return nil
+}
+
+func (p *classSetParser) parseStringDisjunction() *charSet {
+	if !p.eat('{') {
+		p.error("Invalid escape")
+	}
+	set := &charSet{}
+	var sb strings.Builder
+	for {
+		switch p.peek() {
+		case '|', '}':
+			s := sb.String()
+			if utf8.RuneCountInString(s) == 1 {
+				r, _ := utf8.DecodeRuneInString(s)
+				set = unionClassSets(set, &charSet{ranges: []runeRange{{r, r}}})
+			} else {
+				if set.strings == nil {
+					set.strings = make(map[string]struct{})
+				}
+				set.strings[s] = struct{}{}
+			}
+			sb.Reset()
+			if p.src[p.pos] == '}' {
+				p.pos++
+				return set
+			}
+			p.pos++
+		default:
+			sb.WriteRune(p.parseClassSetCharacter())
+		}
+	}
+}
+
+var generalCategoryAliases = map[string]string{
+	"Letter":                "L",
+	"Cased_Letter":          "LC",
+	"Uppercase_Letter":      "Lu",
+	"Lowercase_Letter":      "Ll",
+	"Titlecase_Letter":      "Lt",
+	"Modifier_Letter":       "Lm",
+	"Other_Letter":          "Lo",
+	"Mark":                  "M",
+	"Combining_Mark":        "M",
+	"Nonspacing_Mark":       "Mn",
+	"Spacing_Mark":          "Mc",
+	"Enclosing_Mark":        "Me",
+	"Number":                "N",
+	"Decimal_Number":        "Nd",
+	"digit":                 "Nd",
+	"Letter_Number":         "Nl",
+	"Other_Number":          "No",
+	"Punctuation":           "P",
+	"punct":                 "P",
+	"Connector_Punctuation": "Pc",
+	"Dash_Punctuation":      "Pd",
+	"Open_Punctuation":      "Ps",
+	"Close_Punctuation":     "Pe",
+	"Initial_Punctuation":   "Pi",
+	"Final_Punctuation":     "Pf",
+	"Other_Punctuation":     "Po",
+	"Symbol":                "S",
+	"Math_Symbol":           "Sm",
+	"Currency_Symbol":       "Sc",
+	"Modifier_Symbol":       "Sk",
+	"Other_Symbol":          "So",
+	"Separator":             "Z",
+	"Space_Separator":       "Zs",
+	"Line_Separator":        "Zl",
+	"Paragraph_Separator":   "Zp",
+	"Other":                 "C",
+	"Control":               "Cc",
+	"cntrl":                 "Cc",
+	"Format":                "Cf",
+	"Surrogate":             "Cs",
+	"Private_Use":           "Co",
+	"Unassigned":            "Cn",
+}
+
+func assignedRanges() []runeRange {
+	var rr []runeRange
+	for _, t := range unicode.Categories {
+		rr = append(rr, tableRanges(t)...)
+	}
+	return normalizeRanges(rr)
+}
+
+func generalCategoryRanges(name string) ([]runeRange, bool) {
+	if alias, exists := generalCategoryAliases[name]; exists {
+		name = alias
+	}
+	switch name {
+	case "LC":
+		return normalizeRanges(append(append(tableRanges(unicode.Lu), tableRanges(unicode.Ll)...), tableRanges(unicode.Lt)...)), true
+	case "Cn":
+		return complementRanges(assignedRanges()), true
+	case "C":
+		return normalizeRanges(append(tableRanges(unicode.C), complementRanges(assignedRanges())...)), true
+	}
+	if t, exists := unicode.Categories[name]; exists {
+		return tableRanges(t), true
+	}
+	return nil, false
+}
+
+func (p *classSetParser) resolveProperty(name string) *charSet {
+	if i := strings.IndexByte(name, '='); i >= 0 {
+		key, value := name[:i], name[i+1:]
+		switch key {
+		case "General_Category", "gc":
+			if rr, ok := generalCategoryRanges(value); ok {
+				return &charSet{ranges: rr}
+			}
+		case "Script", "sc", "Script_Extensions", "scx":
+			if t, exists := unicode.Scripts[value]; exists {
+				return &charSet{ranges: tableRanges(t)}
+			}
+		}
+		p.error("Invalid property name")
+	}
+	if rr, ok := generalCategoryRanges(name); ok {
+		return &charSet{ranges: rr}
+	}
+	switch name {
+	case "Any":
+		return &charSet{ranges: []runeRange{{0, unicode.MaxRune}}}
+	case "ASCII":
+		return &charSet{ranges: []runeRange{{0, 0x7F}}}
+	case "Assigned":
+		return &charSet{ranges: assignedRanges()}
+	case "Alphabetic", "Alpha":
+		return &charSet{ranges: normalizeRanges(append(append(tableRanges(unicode.L), tableRanges(unicode.Nl)...), tableRanges(unicode.Other_Alphabetic)...))}
+	case "Lowercase", "Lower":
+		return &charSet{ranges: normalizeRanges(append(tableRanges(unicode.Ll), tableRanges(unicode.Other_Lowercase)...))}
+	case "Uppercase", "Upper":
+		return &charSet{ranges: normalizeRanges(append(tableRanges(unicode.Lu), tableRanges(unicode.Other_Uppercase)...))}
+	case "RGI_Emoji":
+		set := &charSet{}
+		for _, prop := range emojiStringProperties {
+			set = unionClassSets(set, prop.charSet())
+		}
+		return set
+	}
+	if prop := emojiStringProperties[name]; prop != nil {
+		return prop.charSet()
+	}
+	if t, exists := unicode.Properties[name]; exists {
+		return &charSet{ranges: tableRanges(t)}
+	}
+	p.error("Invalid property name")
+	return nil
+}
+
+type emojiStringProperty struct {
+	ranges  []runeRange
+	strings []string
+}
+
+func (e *emojiStringProperty) charSet() *charSet {
+	set := &charSet{
+		ranges: append([]runeRange(nil), e.ranges...),
+	}
+	if len(e.strings) > 0 {
+		set.strings = make(map[string]struct{}, len(e.strings))
+		for _, s := range e.strings {
+			set.strings[s] = struct{}{}
+		}
+	}
+	return set
+}
+
+func tableRanges(t *unicode.RangeTable) []runeRange {
+	var rr []runeRange
+	add := func(lo, hi, stride rune) {
+		if stride == 1 {
+			rr = append(rr, runeRange{lo, hi})
+			return
+		}
+		for r := lo; r <= hi; r += stride {
+			rr = append(rr, runeRange{r, r})
+		}
+	}
+	for _, r := range t.R16 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range t.R32 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return normalizeRanges(rr)
+}
+
+// normalizeRanges sorts the ranges and merges the overlapping and adjacent ones.
+func normalizeRanges(rr []runeRange) []runeRange {
+	if len(rr) == 0 {
+		return nil
+	}
+	sort.Slice(rr, func(i, j int) bool {
+		return rr[i].lo < rr[j].lo
+	})
+	res := rr[:1]
+	for _, r := range rr[1:] {
+		last := &res[len(res)-1]
+		if r.lo <= last.hi+1 {
+			if r.hi > last.hi {
+				last.hi = r.hi
+			}
+		} else {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+func complementRanges(rr []runeRange) []runeRange {
+	var res []runeRange
+	next := rune(0)
+	for _, r := range rr {
+		if r.lo > next {
+			res = append(res, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= unicode.MaxRune {
+		res = append(res, runeRange{next, unicode.MaxRune})
+	}
+	return res
+}
+
+func intersectRanges(a, b []runeRange) []runeRange {
+	var res []runeRange
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		lo, hi := a[i].lo, a[i].hi
+		if b[j].lo > lo {
+			lo = b[j].lo
+		}
+		if b[j].hi < hi {
+			hi = b[j].hi
+		}
+		if lo <= hi {
+			res = append(res, runeRange{lo, hi})
+		}
+		if a[i].hi < b[j].hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return res
+}
+
+func unionClassSets(a, b *charSet) *charSet {
+	res := &charSet{
+		ranges: normalizeRanges(append(append([]runeRange(nil), a.ranges...), b.ranges...)),
+	}
+	if len(a.strings) > 0 || len(b.strings) > 0 {
+		res.strings = make(map[string]struct{}, len(a.strings)+len(b.strings))
+		for s := range a.strings {
+			res.strings[s] = struct{}{}
+		}
+		for s := range b.strings {
+			res.strings[s] = struct{}{}
+		}
+	}
+	return res
+}
+
+func intersectClassSets(a, b *charSet) *charSet {
+	res := &charSet{
+		ranges: intersectRanges(a.ranges, b.ranges),
+	}
+	for s := range a.strings {
+		if _, exists := b.strings[s]; exists {
+			if res.strings == nil {
+				res.strings = make(map[string]struct{})
+			}
+			res.strings[s] = struct{}{}
+		}
+	}
+	return res
+}
+
+func subtractClassSets(a, b *charSet) *charSet {
+	res := &charSet{
+		ranges: intersectRanges(a.ranges, complementRanges(b.ranges)),
+	}
+	for s := range a.strings {
+		if _, exists := b.strings[s]; !exists {
+			if res.strings == nil {
+				res.strings = make(map[string]struct{})
+			}
+			res.strings[s] = struct{}{}
+		}
+	}
+	return res
+}
+
+func writeClassSetRune(sb *strings.Builder, r rune) {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r > 0xFFFF:
+		sb.WriteRune(r)
+	default:
+		sb.WriteString(`\u`)
+		writeHex4(sb, int(r))
+	}
+}
+
+func writeClassSetRanges(sb *strings.Builder, rr []runeRange) {
+	sb.WriteByte('[')
+	for _, r := range rr {
+		lo, hi := r.lo, r.hi
+		// Surrogates cannot be matched in unicode mode
+		if lo >= 0xD800 && hi <= 0xDFFF {
+			continue
+		}
+		if lo < 0xD800 && hi >= 0xD800 {
+			if hi > 0xDFFF {
+				writeClassSetRanges0(sb, lo, 0xD7FF)
+				lo = 0xE000
+			} else {
+				hi = 0xD7FF
+			}
+		} else if lo <= 0xDFFF && hi > 0xDFFF {
+			lo = 0xE000
+		}
+		writeClassSetRanges0(sb, lo, hi)
+	}
+	sb.WriteByte(']')
+}
+
+func writeClassSetRanges0(sb *strings.Builder, lo, hi rune) {
+	writeClassSetRune(sb, lo)
+	if hi > lo {
+		sb.WriteByte('-')
+		writeClassSetRune(sb, hi)
+	}
+}
+
+func writeClassSet(sb *strings.Builder, set *charSet) {
+	if len(set.strings) == 0 {
+		writeClassSetRanges(sb, set.ranges)
+		return
+	}
+	strs := make([]string, 0, len(set.strings))
+	hasEmpty := false
+	for s := range set.strings {
+		if s == "" {
+			hasEmpty = true
+			continue
+		}
+		strs = append(strs, s)
+	}
+	// Longest strings must be tried first
+	sort.Slice(strs, func(i, j int) bool {
+		li, lj := utf8.RuneCountInString(strs[i]), utf8.RuneCountInString(strs[j])
+		if li != lj {
+			return li > lj
+		}
+		return strs[i] < strs[j]
+	})
+	sb.WriteString("(?:")
+	for _, s := range strs {
+		for _, r := range s {
+			writeClassSetRune(sb, r)
+		}
+		sb.WriteByte('|')
+	}
+	writeClassSetRanges(sb, set.ranges)
+	if hasEmpty {
+		sb.WriteByte('|')
+	}
+	sb.WriteByte(')')
+}
@@ -0,0 +1,129 @@
+package goja
+
+import "sync/atomic"
+
+// The estimated sizes used for the accounting of the allocations, see ResourceUsage.BytesAllocated.
+const (
+	usageObjectSize   = 64
+	usagePropertySize = 32
+	usageValueSize    = 16
+)
+
+// ResourceUsage is the amount of resources consumed by the scripts, see SetResourceAccounting.
+type ResourceUsage struct {
+	// Instructions is the number of VM instructions executed.
+	Instructions int64
+	// BytesAllocated is an estimate of the memory allocated by the scripts for objects, properties, array elements,
+	// string concatenations and ArrayBuffers. The memory reclaimed by the garbage collector is not subtracted, so
+	// this is the allocation volume rather than the size of the heap. The built-in objects, which are created on
+	// first use, are not accounted.
+	BytesAllocated int64
+	// HostCalls is the number of calls made by the scripts to the Go functions exposed by the host (see ToValue).
+	// The calls made by the built-ins (e.g. when a host function is passed to Array.prototype.map()) are not counted.
+	HostCalls int64
+	// NetworkBytesSent and NetworkBytesReceived are the amounts of data reported by the host with AddNetworkUsage.
+	NetworkBytesSent     int64
+	NetworkBytesReceived int64
+}
+
+// Add returns the sum of the usages, e.g. to aggregate the usage of several Runtimes.
+func (u ResourceUsage) Add(other ResourceUsage) ResourceUsage {
+	return ResourceUsage{
+		Instructions:         u.Instructions + other.Instructions,
+		BytesAllocated:       u.BytesAllocated + other.BytesAllocated,
+		HostCalls:            u.HostCalls + other.HostCalls,
+		NetworkBytesSent:     u.NetworkBytesSent + other.NetworkBytesSent,
+		NetworkBytesReceived: u.NetworkBytesReceived + other.NetworkBytesReceived,
+	}
+}
+
+type resourceAccounting struct {
+	// the value of vm.steps when the accounting has been started or reset
+	steps     int64
+	allocated int64
+	hostCalls int64
+	// the allocations are not accounted while the built-in objects are being created, see newLazyObject()
+	suspended bool
+	// updated atomically, see AddNetworkUsage()
+	netSent     int64
+	netReceived int64
+}
+
+// SetResourceAccounting enables or disables the accounting of the resources consumed by the scripts. While it is
+// enabled, ResourceUsage returns the usage accumulated since the accounting has been enabled or since the last call
+// to ResetResourceUsage. For per-invocation billing, call ResetResourceUsage once each invocation is done (including
+// its asynchronous part) and bill the usage it returns. Enabling the accounting again resets the usage.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetResourceAccounting(enabled bool) {
+	if enabled {
+		r.usage = &resourceAccounting{steps: r.vm.steps}
+	} else {
+		r.usage = nil
+	}
+	r.vm.countSteps = enabled
+}
+
+// ResourceUsage returns the resources consumed since the accounting has been enabled (see SetResourceAccounting) or
+// since the last call to ResetResourceUsage. If the accounting is disabled, the result is zero.
+func (r *Runtime) ResourceUsage() ResourceUsage {
+	u := r.usage
+	if u == nil {
+		return ResourceUsage{}
+	}
+	return ResourceUsage{
+		Instructions:         r.vm.steps - u.steps,
+		BytesAllocated:       u.allocated,
+		HostCalls:            u.hostCalls,
+		NetworkBytesSent:     atomic.LoadInt64(&u.netSent),
+		NetworkBytesReceived: atomic.LoadInt64(&u.netReceived),
+	}
+}
+
+// ResetResourceUsage returns the resources consumed since the accounting has been enabled or since the previous
+// call, and starts accounting anew. It must be called from the vm goroutine or when the vm is not running.
+func (r *Runtime) ResetResourceUsage() ResourceUsage {
+	u := r.usage
+	if u == nil {
+		return ResourceUsage{}
+	}
+	usage := ResourceUsage{
+		Instructions:         r.vm.steps - u.steps,
+		BytesAllocated:       u.allocated,
+		HostCalls:            u.hostCalls,
+		NetworkBytesSent:     atomic.SwapInt64(&u.netSent, 0),
+		NetworkBytesReceived: atomic.SwapInt64(&u.netReceived, 0),
+	}
+	u.steps = r.vm.steps
+	u.allocated = 0
+	u.hostCalls = 0
+	return usage
+}
+
+// AddNetworkUsage adds the amounts of data exchanged over the network on behalf of the scripts (e.g. by a fetch
+// implementation) to the resource usage. It does nothing if the accounting is disabled. Unlike most Runtime
+// methods it may be called from any goroutine, as long as it does not race with SetResourceAccounting.
+func (r *Runtime) AddNetworkUsage(sent, received int64) {
+	if u := r.usage; u != nil {
+		atomic.AddInt64(&u.netSent, sent)
+		atomic.AddInt64(&u.netReceived, received)
+	}
+}
+
+// accountAlloc adds an allocation of n bytes to the resource usage, if the accounting is enabled.
+func (r *Runtime) accountAlloc(n int64) {
+	if u := r.usage; u != nil && !u.suspended {
+		u.allocated += n
+	}
+}
+
+// accountStringAlloc accounts for the allocation of the string.
+func (r *Runtime) accountStringAlloc(s valueString) {
+	if u := r.usage; u != nil && !u.suspended {
+		if _, ok := s.(asciiString); ok {
+			u.allocated += int64(s.length())
+		} else {
+			u.allocated += 2 * int64(s.length())
+		}
+	}
+}
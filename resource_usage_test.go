@@ -0,0 +1,93 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestResourceUsage(t *testing.T) {
+	r := New()
+	if u := r.ResourceUsage(); u != (ResourceUsage{}) {
+		t.Fatalf("Usage without accounting: %+v", u)
+	}
+	calls := 0
+	_ = r.Set("host", func() int {
+		calls++
+		return calls
+	})
+	r.SetResourceAccounting(true)
+
+	_, err := r.RunString(`
+	for (var i = 0; i < 10; i++) {
+		host();
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := r.ResourceUsage()
+	if u.HostCalls != 10 {
+		t.Fatalf("HostCalls: %d", u.HostCalls)
+	}
+	if u.Instructions == 0 {
+		t.Fatal("Instructions have not been counted")
+	}
+
+	r.AddNetworkUsage(100, 200)
+	u1 := r.ResetResourceUsage()
+	if u1.HostCalls != 10 || u1.Instructions < u.Instructions || u1.NetworkBytesSent != 100 || u1.NetworkBytesReceived != 200 {
+		t.Fatalf("Unexpected usage: %+v", u1)
+	}
+	if u := r.ResourceUsage(); u != (ResourceUsage{}) {
+		t.Fatalf("Usage after reset: %+v", u)
+	}
+
+	// the allocations grow with the amount of data created by the script
+	alloc := func(n int) int64 {
+		r.ResetResourceUsage()
+		_ = r.Set("n", n)
+		_, err := r.RunString(`
+		var objs = [], s = "";
+		for (var i = 0; i < n; i++) {
+			objs.push({a: i, b: [i, i]});
+			s += "x";
+		}
+		new ArrayBuffer(n * 100);
+		`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u := r.ResourceUsage()
+		if u.HostCalls != 0 {
+			t.Fatalf("HostCalls: %d", u.HostCalls)
+		}
+		return u.BytesAllocated
+	}
+	a10, a100 := alloc(10), alloc(100)
+	if a10 < 10*(usageObjectSize+2*usagePropertySize)+1000 {
+		t.Fatalf("Too few bytes allocated: %d", a10)
+	}
+	if a100 < 9*a10 {
+		t.Fatalf("Allocations do not grow: %d, %d", a10, a100)
+	}
+
+	r.SetResourceAccounting(false)
+	r.AddNetworkUsage(1, 1)
+	if _, err := r.RunString(`host()`); err != nil {
+		t.Fatal(err)
+	}
+	if u := r.ResourceUsage(); u != (ResourceUsage{}) {
+		t.Fatalf("Usage with accounting disabled: %+v", u)
+	}
+	// the instructions are not counted while the accounting is disabled
+	steps := r.vm.steps
+	if _, err := r.RunString(`for (var i = 0; i < 10; i++) {}`); err != nil {
+		t.Fatal(err)
+	}
+	if r.vm.steps != steps {
+		t.Fatalf("Instructions counted with accounting disabled: %d", r.vm.steps-steps)
+	}
+
+	if sum := u1.Add(u1); sum.HostCalls != 20 || sum.NetworkBytesReceived != 400 {
+		t.Fatalf("Add: %+v", sum)
+	}
+}
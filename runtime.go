@@ -2,11 +2,13 @@ package goja
 
 import (
 	"bytes"
+	gocontext "context"
 	"errors"
 	"fmt"
 	"go/ast"
 	"hash/maphash"
 	"math"
+	"math/big"
 	"math/bits"
 	"math/rand"
 	"reflect"
@@ -15,8 +17,10 @@ import (
 	"time"
 
 	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
 
 	js_ast "github.com/dop251/goja/ast"
+	"github.com/dop251/goja/console"
 	"github.com/dop251/goja/file"
 	"github.com/dop251/goja/parser"
 	"github.com/dop251/goja/unistring"
@@ -53,6 +57,7 @@ type global struct {
 	Function *Object
 	String   *Object
 	Number   *Object
+	BigInt   *Object
 	Boolean  *Object
 	RegExp   *Object
 	Date     *Object
@@ -72,6 +77,7 @@ type global struct {
 	Int16Array        *Object
 	Uint32Array       *Object
 	Int32Array        *Object
+	Float16Array      *Object
 	Float32Array      *Object
 	Float64Array      *Object
 
@@ -80,20 +86,28 @@ type global struct {
 	Map     *Object
 	Set     *Object
 
-	Error          *Object
-	AggregateError *Object
-	TypeError      *Object
-	ReferenceError *Object
-	SyntaxError    *Object
-	RangeError     *Object
-	EvalError      *Object
-	URIError       *Object
+	DisposableStack      *Object
+	Iterator             *Object
+	AsyncDisposableStack *Object
+
+	StringBuilder *Object
+
+	Error           *Object
+	AggregateError  *Object
+	SuppressedError *Object
+	TypeError       *Object
+	ReferenceError  *Object
+	SyntaxError     *Object
+	RangeError      *Object
+	EvalError       *Object
+	URIError        *Object
 
 	GoError *Object
 
 	ObjectPrototype   *Object
 	ArrayPrototype    *Object
 	NumberPrototype   *Object
+	BigIntPrototype   *Object
 	StringPrototype   *Object
 	BooleanPrototype  *Object
 	FunctionPrototype *Object
@@ -110,6 +124,11 @@ type global struct {
 	SetPrototype         *Object
 	PromisePrototype     *Object
 
+	DisposableStackPrototype      *Object
+	AsyncDisposableStackPrototype *Object
+
+	StringBuilderPrototype *Object
+
 	GeneratorFunctionPrototype *Object
 	GeneratorFunction          *Object
 	GeneratorPrototype         *Object
@@ -117,20 +136,29 @@ type global struct {
 	AsyncFunctionPrototype *Object
 
 	IteratorPrototype             *Object
+	IteratorHelperPrototype       *Object
+	WrapForValidIteratorPrototype *Object
 	ArrayIteratorPrototype        *Object
 	MapIteratorPrototype          *Object
 	SetIteratorPrototype          *Object
 	StringIteratorPrototype       *Object
 	RegExpStringIteratorPrototype *Object
-
-	ErrorPrototype          *Object
-	AggregateErrorPrototype *Object
-	TypeErrorPrototype      *Object
-	SyntaxErrorPrototype    *Object
-	RangeErrorPrototype     *Object
-	ReferenceErrorPrototype *Object
-	EvalErrorPrototype      *Object
-	URIErrorPrototype       *Object
+	CursorIteratorPrototype       *Object
+	GoMapIterator                 *Object
+	GoMapIteratorPrototype        *Object
+	GoMapLikePrototype            *Object
+	GoSetLikePrototype            *Object
+	GoContainerIteratorPrototype  *Object
+
+	ErrorPrototype           *Object
+	AggregateErrorPrototype  *Object
+	SuppressedErrorPrototype *Object
+	TypeErrorPrototype       *Object
+	SyntaxErrorPrototype     *Object
+	RangeErrorPrototype      *Object
+	ReferenceErrorPrototype  *Object
+	EvalErrorPrototype       *Object
+	URIErrorPrototype        *Object
 
 	GoErrorPrototype *Object
 
@@ -147,6 +175,10 @@ type global struct {
 	setAdder      *Object
 	arrayValues   *Object
 	arrayToString *Object
+
+	stringCharAt      *Object
+	stringCharCodeAt  *Object
+	stringCodePointAt *Object
 }
 
 type Flag int
@@ -179,7 +211,33 @@ type Runtime struct {
 	rand            RandSource
 	now             Now
 	_collator       *collate.Collator
-	parserOptions   []parser.Option
+	// see SetExtendedDateParsing()
+	extendedDateParsing bool
+	// see SetLocation()
+	loc *time.Location
+	// the default locale of the locale-sensitive methods, set by NewIntlNamespace()
+	intlLocale language.Tag
+
+	// the state of the console object, see SetConsolePrinter()
+	console consoleState
+
+	hostFrameLocations bool
+	parserOptions      []parser.Option
+	// see SetStrictBlockFunctions()
+	strictBlockFunctions bool
+	// see SetDebugScopes()
+	debugScopes bool
+
+	globalMutationHook func(m GlobalMutation) error
+	// see SetPropertyLimits()
+	propertyLimits *propertyLimits
+	// see SetResourceAccounting()
+	usage *resourceAccounting
+	// see AddBreakpoint()
+	debugger *debugger
+
+	// see RunWithContext()
+	ctx gocontext.Context
 
 	symbolRegistry map[unistring.String]*Symbol
 
@@ -187,12 +245,23 @@ type Runtime struct {
 	methodsInfoCache map[reflect.Type]*reflectMethodsInfo
 
 	fieldNameMapper FieldNameMapper
+	typeBindings    map[reflect.Type]*TypeBinding
+	goWrappers      map[goIdentity]*Object
+	// see SetMapKeyOrder()
+	mapKeyOrder MapKeyOrder
+
+	arrayBufferPool ArrayBufferPool
+
+	regexpLegacyStatics *regexpLegacyStatics
 
 	vm    *vm
 	hash  *maphash.Hash
 	idSeq uint64
 
-	jobQueue []func()
+	weakMaps *weakMapTracker
+
+	jobQueue     []func()
+	microtaskErr *Exception
 
 	promiseRejectionTracker PromiseRejectionTracker
 	asyncContextTracker     AsyncContextTracker
@@ -202,6 +271,10 @@ type StackFrame struct {
 	prg      *Program
 	funcName unistring.String
 	pc       int
+
+	host     bool
+	hostFile string
+	hostLine int
 }
 
 func (f *StackFrame) SrcName() string {
@@ -221,6 +294,18 @@ func (f *StackFrame) FuncName() string {
 	return f.funcName.String()
 }
 
+// IsHost returns true if the frame belongs to a Go function provided by the host (as opposed to a JavaScript
+// function or a built-in).
+func (f *StackFrame) IsHost() bool {
+	return f.host
+}
+
+// HostPosition returns the location of the host Go function if the frame belongs to one and the locations
+// were enabled with Runtime.SetHostFrameLocations() at the time the stack was captured.
+func (f *StackFrame) HostPosition() (file string, line int) {
+	return f.hostFile, f.hostLine
+}
+
 func (f *StackFrame) Position() file.Position {
 	if f.prg == nil || f.prg.src == nil {
 		return file.Position{}
@@ -250,6 +335,18 @@ func (f *StackFrame) WriteToValueBuilder(b *valueStringBuilder) {
 		if f.prg.funcName != "" {
 			b.WriteRune(')')
 		}
+	} else if f.hostFile != "" {
+		b.WriteASCII("native ")
+		if f.funcName != "" {
+			b.WriteString(stringValueFromRaw(f.funcName))
+		} else {
+			b.WriteASCII("<anonymous>")
+		}
+		b.WriteASCII(" (go, ")
+		b.WriteString(newStringValue(f.hostFile))
+		b.WriteRune(':')
+		b.WriteASCII(strconv.Itoa(f.hostLine))
+		b.WriteRune(')')
 	} else {
 		if f.funcName != "" {
 			b.WriteString(stringValueFromRaw(f.funcName))
@@ -284,6 +381,18 @@ func (f *StackFrame) Write(b *bytes.Buffer) {
 		if f.prg.funcName != "" {
 			b.WriteByte(')')
 		}
+	} else if f.hostFile != "" {
+		b.WriteString("native ")
+		if f.funcName != "" {
+			b.WriteString(f.funcName.String())
+		} else {
+			b.WriteString("<anonymous>")
+		}
+		b.WriteString(" (go, ")
+		b.WriteString(f.hostFile)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(f.hostLine))
+		b.WriteByte(')')
 	} else {
 		if f.funcName != "" {
 			b.WriteString(f.funcName.String())
@@ -403,23 +512,6 @@ func (r *Runtime) addToGlobal(name string, value Value) {
 	r.globalObject.self._putProp(unistring.String(name), value, true, false, true)
 }
 
-func (r *Runtime) createIterProto(val *Object) objectImpl {
-	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
-
-	o._putSym(SymIterator, valueProp(r.newNativeFunc(r.returnThis, nil, "[Symbol.iterator]", nil, 0), true, false, true))
-	return o
-}
-
-func (r *Runtime) getIteratorPrototype() *Object {
-	var o *Object
-	if o = r.global.IteratorPrototype; o == nil {
-		o = &Object{runtime: r}
-		r.global.IteratorPrototype = o
-		o.self = r.createIterProto(o)
-	}
-	return o
-}
-
 func (r *Runtime) init() {
 	r.rand = rand.Float64
 	r.now = time.Now
@@ -444,6 +536,7 @@ func (r *Runtime) init() {
 	r.initString()
 	r.initGlobalObject()
 	r.initNumber()
+	r.initBigInt()
 	r.initRegExp()
 	r.initDate()
 	r.initBoolean()
@@ -465,6 +558,9 @@ func (r *Runtime) init() {
 	r.initMap()
 	r.initSet()
 	r.initPromise()
+	r.initDisposableStack()
+	r.initIterator()
+	r.initConsole()
 
 	r.global.thrower = r.newNativeFunc(r.builtin_thrower, nil, "", nil, 0)
 	r.global.throwerProperty = &valueProperty{
@@ -616,7 +712,7 @@ func (r *Runtime) newGeneratorFunc(name unistring.String, length int, strict boo
 func (r *Runtime) newClassFunc(name unistring.String, length int, proto *Object, derived bool) (f *classFuncObject) {
 	v := &Object{runtime: r}
 
-	f = &classFuncObject{}
+	f = &classFuncObject{name: name}
 	f.class = classFunction
 	f.val = v
 	f.extensible = true
@@ -813,7 +909,7 @@ func (r *Runtime) newNativeFunc(call func(FunctionCall) Value, construct func(ar
 	return v
 }
 
-func (r *Runtime) newWrappedFunc(value reflect.Value) *Object {
+func (r *Runtime) newWrappedFunc(value reflect.Value, opts *funcOptions) *Object {
 
 	v := &Object{runtime: r}
 
@@ -827,7 +923,8 @@ func (r *Runtime) newWrappedFunc(value reflect.Value) *Object {
 					prototype:  r.global.FunctionPrototype,
 				},
 			},
-			f: r.wrapReflectFunc(value),
+			f:      r.wrapReflectFunc(value, opts),
+			hostPC: value.Pointer(),
 		},
 		wrapped: value,
 	}
@@ -895,9 +992,17 @@ func (r *Runtime) newPrimitiveObject(value Value, proto *Object, class string) *
 	return v
 }
 
+func toNumberFromNumeric(v Value) Value {
+	v = toNumeric(v)
+	if b, ok := v.(*valueBigInt); ok {
+		return bigIntToNumber((*big.Int)(b))
+	}
+	return v
+}
+
 func (r *Runtime) builtin_Number(call FunctionCall) Value {
 	if len(call.Arguments) > 0 {
-		return call.Arguments[0].ToNumber()
+		return toNumberFromNumeric(call.Arguments[0])
 	} else {
 		return valueInt(0)
 	}
@@ -906,7 +1011,7 @@ func (r *Runtime) builtin_Number(call FunctionCall) Value {
 func (r *Runtime) builtin_newNumber(args []Value, proto *Object) *Object {
 	var v Value
 	if len(args) > 0 {
-		v = args[0].ToNumber()
+		v = toNumberFromNumeric(args[0])
 	} else {
 		v = intToValue(0)
 	}
@@ -1033,11 +1138,56 @@ func (r *Runtime) wrapNativeConstruct(c func(args []Value, proto *Object) *Objec
 }
 
 func (r *Runtime) toCallable(v Value) func(FunctionCall) Value {
-	if call, ok := r.toObject(v).self.assertCallable(); ok {
-		return call
+	if obj, ok := v.(*Object); ok {
+		if call, ok := obj.self.assertCallable(); ok {
+			return call
+		}
 	}
-	r.typeErrorResult(true, "Value is not callable: %s", v.toString())
-	return nil
+	panic(r.NewTypeError("%s is not a function", describeValue(v)))
+}
+
+// describeValue returns the description of a value for the error messages, in the same format as V8. Unlike
+// toString() it never calls into JavaScript code.
+func describeValue(v Value) string {
+	switch v := v.(type) {
+	case *Symbol:
+		return v.descriptiveString().String()
+	case *Object:
+		// objects which use Object.prototype.toString() are described by their constructor name, e.g. #<Foo>
+		if _, holder := lookupDataProp(v, "toString"); holder != nil && holder == v.runtime.global.ObjectPrototype {
+			if ctor, _ := lookupDataProp(v, "constructor"); ctor != nil {
+				if ctor, ok := ctor.(*Object); ok {
+					if name, _ := lookupDataProp(ctor, "name"); name != nil {
+						if name, ok := name.(valueString); ok && name.length() > 0 {
+							return "#<" + name.String() + ">"
+						}
+					}
+				}
+			}
+		}
+		return "[object " + v.self.className() + "]"
+	}
+	return v.String()
+}
+
+// lookupDataProp finds the property in the prototype chain of o and returns its value and the object which has
+// it. It returns nil if the property is not found or is an accessor property, or if a proxy is encountered.
+func lookupDataProp(o *Object, name unistring.String) (Value, *Object) {
+	for ; o != nil; o = o.self.proto() {
+		if _, ok := o.self.(*proxyObject); ok {
+			break
+		}
+		if prop := o.self.getOwnPropStr(name); prop != nil {
+			if prop, ok := prop.(*valueProperty); ok {
+				if prop.accessor {
+					break
+				}
+				return prop.value, o
+			}
+			return prop, o
+		}
+	}
+	return nil, nil
 }
 
 func (r *Runtime) checkObjectCoercible(v Value) {
@@ -1343,22 +1493,24 @@ func New() *Runtime {
 
 // Compile creates an internal representation of the JavaScript code that can be later run using the Runtime.RunProgram()
 // method. This representation is not linked to a runtime in any way and can be run in multiple runtimes (possibly
-// at the same time).
-func Compile(name, src string, strict bool) (*Program, error) {
-	return compile(name, src, strict, true, nil)
+// at the same time). The behaviour of the compiler can be adjusted using options, e.g.:
+//
+//	prg, err := Compile("test.js", src, false, WithStrictBlockFunctions)
+func Compile(name, src string, strict bool, options ...CompilerOption) (*Program, error) {
+	return compile(name, src, strict, true, nil, nil, options...)
 }
 
 // CompileAST creates an internal representation of the JavaScript code that can be later run using the Runtime.RunProgram()
 // method. This representation is not linked to a runtime in any way and can be run in multiple runtimes (possibly
 // at the same time).
-func CompileAST(prg *js_ast.Program, strict bool) (*Program, error) {
-	return compileAST(prg, strict, true, nil)
+func CompileAST(prg *js_ast.Program, strict bool, options ...CompilerOption) (*Program, error) {
+	return compileAST(prg, strict, true, nil, options...)
 }
 
 // MustCompile is like Compile but panics if the code cannot be compiled.
 // It simplifies safe initialization of global variables holding compiled JavaScript code.
-func MustCompile(name, src string, strict bool) *Program {
-	prg, err := Compile(name, src, strict)
+func MustCompile(name, src string, strict bool, options ...CompilerOption) *Program {
+	prg, err := Compile(name, src, strict, options...)
 	if err != nil {
 		panic(err)
 	}
@@ -1388,17 +1540,17 @@ func Parse(name, src string, options ...parser.Option) (prg *js_ast.Program, err
 	return
 }
 
-func compile(name, src string, strict, inGlobal bool, evalVm *vm, parserOptions ...parser.Option) (p *Program, err error) {
+func compile(name, src string, strict, inGlobal bool, evalVm *vm, parserOptions []parser.Option, options ...CompilerOption) (p *Program, err error) {
 	prg, err := Parse(name, src, parserOptions...)
 	if err != nil {
 		return
 	}
 
-	return compileAST(prg, strict, inGlobal, evalVm)
+	return compileAST(prg, strict, inGlobal, evalVm, options...)
 }
 
-func compileAST(prg *js_ast.Program, strict, inGlobal bool, evalVm *vm) (p *Program, err error) {
-	c := newCompiler()
+func compileAST(prg *js_ast.Program, strict, inGlobal bool, evalVm *vm, options ...CompilerOption) (p *Program, err error) {
+	c := newCompiler(options...)
 
 	defer func() {
 		if x := recover(); x != nil {
@@ -1406,6 +1558,8 @@ func compileAST(prg *js_ast.Program, strict, inGlobal bool, evalVm *vm) (p *Prog
 			switch x1 := x.(type) {
 			case *CompilerSyntaxError:
 				err = x1
+			case *CompileLimitError:
+				err = x1
 			default:
 				panic(x)
 			}
@@ -1418,7 +1572,14 @@ func compileAST(prg *js_ast.Program, strict, inGlobal bool, evalVm *vm) (p *Prog
 }
 
 func (r *Runtime) compile(name, src string, strict, inGlobal bool, evalVm *vm) (p *Program, err error) {
-	p, err = compile(name, src, strict, inGlobal, evalVm, r.parserOptions...)
+	var opts []CompilerOption
+	if r.strictBlockFunctions {
+		opts = append(opts, WithStrictBlockFunctions)
+	}
+	if r.debugScopes {
+		opts = append(opts, WithDebugScopes)
+	}
+	p, err = compile(name, src, strict, inGlobal, evalVm, r.parserOptions, opts...)
 	if err != nil {
 		switch x1 := err.(type) {
 		case *CompilerSyntaxError:
@@ -1473,6 +1634,48 @@ func asUncatchableException(v interface{}) error {
 
 // RunProgram executes a pre-compiled (see Compile()) code in the global context.
 func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
+	return r.runProgram(p, &r.global.stash)
+}
+
+// RunProgramWithGlobals is like RunProgram() but makes the supplied bindings visible as global variables for this
+// execution only. The bindings form a separate environment layer on top of the global one, so they shadow the
+// global variables (including the ones declared with let, const or class) with the same names, and assignments to
+// them only change the layer. Nothing is added to or removed from the global object, which makes it safe to use
+// with pooled Runtimes where setting and deleting the globals could leak the values between the requests.
+//
+// The functions created by the program keep seeing the bindings after it has finished. The bindings are not
+// properties of the global object, so they are not accessible through globalThis and they are not visible to
+// code executed by an indirect eval() or by another program. Nil values are treated as undefined.
+func (r *Runtime) RunProgramWithGlobals(p *Program, globals map[string]Value) (Value, error) {
+	return r.runProgram(p, newGlobalsStash(&r.global.stash, globals, nil))
+}
+
+// newGlobalsStash creates the environment layer for RunProgramWithGlobals() and EvalBatch(). If names is not nil, it
+// caches the converted names between the calls.
+func newGlobalsStash(outer *stash, globals map[string]Value, names map[string]unistring.String) *stash {
+	s := &stash{
+		outer:  outer,
+		names:  make(map[unistring.String]uint32, len(globals)),
+		values: make([]Value, 0, len(globals)),
+	}
+	for name, v := range globals {
+		n, exists := names[name]
+		if !exists {
+			n = unistring.NewFromString(name)
+			if names != nil {
+				names[name] = n
+			}
+		}
+		if v == nil {
+			v = _undefined
+		}
+		s.names[n] = uint32(len(s.values)) | maskVar
+		s.values = append(s.values, v)
+	}
+	return s
+}
+
+func (r *Runtime) runProgram(p *Program, stash *stash) (result Value, err error) {
 	vm := r.vm
 	recursive := len(vm.callStack) > 0
 	defer func() {
@@ -1481,6 +1684,7 @@ func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 			vm.popCtx()
 		} else {
 			vm.callStack = vm.callStack[:len(vm.callStack)-1]
+			vm.stash = &r.global.stash
 		}
 		if x := recover(); x != nil {
 			if ex := asUncatchableException(x); ex != nil {
@@ -1495,7 +1699,7 @@ func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 	}()
 	if recursive {
 		vm.pushCtx()
-		vm.stash = &r.global.stash
+		vm.stash = stash
 		vm.privEnv = nil
 		vm.newTarget = nil
 		vm.args = 0
@@ -1507,6 +1711,7 @@ func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 		vm.sp = sp + 2
 	} else {
 		vm.callStack = append(vm.callStack, context{})
+		vm.stash = stash
 	}
 	vm.prg = p
 	vm.pc = 0
@@ -1522,11 +1727,54 @@ func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 	} else {
 		vm.prg = nil
 		vm.sb = -1
-		r.leave()
+		if ex := r.leave(); ex != nil && err == nil {
+			err = ex
+		}
 	}
 	return
 }
 
+// BatchError is returned by Runtime.EvalBatch() when one of the runs fails.
+type BatchError struct {
+	// Index is the index of the input the program failed with.
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("input %d: %s", e.Index, e.Err.Error())
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// EvalBatch runs the program once for each of the inputs and returns the completion values, in the same order, so
+// that a compiled expression such as "score > threshold && country === 'NL'" can be evaluated against a large number
+// of events. Each run gets the entries of its input as global bindings the same way as with RunProgramWithGlobals(),
+// i.e. in a separate environment layer, so a name which is missing from an input resolves to the global variable
+// (if there is one) rather than to the value from a previous input, and assignments to the bindings do not outlive
+// the run. The names are converted once for the whole batch and the VM stacks are reused between the runs.
+//
+// As with RunProgramWithGlobals(), the global variables declared by the program itself (and the properties it
+// creates on the global object) persist between the runs. The promise jobs are run after each run, as with
+// RunProgram().
+//
+// If a run fails, the evaluation stops and the results obtained so far are returned together with a *BatchError
+// which wraps the error (e.g. an *Exception or an *InterruptedError).
+func (r *Runtime) EvalBatch(p *Program, inputs []map[string]Value) ([]Value, error) {
+	results := make([]Value, 0, len(inputs))
+	names := make(map[string]unistring.String)
+	for i, input := range inputs {
+		res, err := r.runProgram(p, newGlobalsStash(&r.global.stash, input, names))
+		if err != nil {
+			return results, &BatchError{Index: i, Err: err}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
 // CaptureCallStack appends the current call stack frames to the stack slice (which may be nil) up to the specified depth.
 // The most recent frame will be the first one.
 // If depth <= 0 or more than the number of available frames, returns the entire stack.
@@ -1550,7 +1798,14 @@ func (r *Runtime) CaptureCallStack(depth int, stack []StackFrame) []StackFrame {
 // Interrupt a running JavaScript. The corresponding Go call will return an *InterruptedError containing v.
 // If the interrupt propagates until the stack is empty the currently queued promise resolve/reject jobs will be cleared
 // without being executed. This is the same time they would be executed otherwise.
-// Note, it only works while in JavaScript code, it does not interrupt native Go functions (which includes all built-ins).
+//
+// JavaScript code is checked for the interrupt before each VM instruction, so it stops before executing the next one.
+// The built-ins which can run for a long time without calling any JavaScript code check it on each iteration:
+// sorting (on each comparison), the Array.prototype methods operating on generic array-like objects (e.g.
+// indexOf(), join() and fill()) and the RegExp methods which find multiple matches (e.g. replace() and split()
+// with a global regexp, on each match). A single match of a regexp that requires the backtracking engine (e.g. one
+// with lookbehind or backreferences) is restarted after 100ms, 200ms, 400ms and so on, checking it in between.
+// Host-provided Go functions are not interrupted, they need to return on their own.
 // If the runtime is currently not running, it will be immediately interrupted on the next Run*() call.
 // To avoid that use ClearInterrupt()
 func (r *Runtime) Interrupt(v interface{}) {
@@ -1730,6 +1985,7 @@ Functions with multiple return values return an Array. If the last return value
 converted into a JS exception. If the error is *Exception, it is thrown as is, otherwise it's wrapped in a GoEerror.
 Note that if there are exactly two return values and the last is an `error`, the function returns the first value as is,
 not an Array.
+Use WrapFunc() to return the results as an Array that includes the error, or as an Object with named properties.
 
 # Structs
 
@@ -1790,6 +2046,11 @@ Note that Value.Export() for a `Date` value returns time.Time in local timezone.
 # Maps
 
 Maps with string or integer key type are converted into host objects that largely behave like a JavaScript Object.
+Because Go maps do not preserve the insertion order, the keys are enumerated in a deterministic order instead: keys
+that are array indices come first in ascending numeric order, followed by the rest of the keys in lexicographic order.
+The order can be changed with Runtime.SetMapKeyOrder(). To enumerate the keys in insertion order use *OrderedMap.
+The resulting objects are iterable over the [key, value] pairs (in the same order), so they can be used directly with
+for-of, the spread syntax or new Map(). The iterator is not an own property and Object.prototype remains the prototype.
 
 # Maps with methods
 
@@ -1798,6 +2059,11 @@ This is because in JavaScript there is no distinction between 'object.key` and `
 If access to the map values is required, it can be achieved by defining another method or, if it's not possible, by
 defining an external getter function.
 
+# MapLike and SetLike
+
+Values implementing MapLike or SetLike (regardless of their underlying type) are converted into objects that
+behave like a Map or a Set respectively and operate directly on the Go container. See MapLike for details.
+
 # Slices
 
 Slices are converted into host objects that behave largely like JavaScript Array. It has the appropriate
@@ -1818,6 +2084,11 @@ to a Number, String, Boolean or Object.
 Note that the underlying type is not lost, calling Export() returns the original Go value. This applies to all
 reflect based types.
 */
+func hostFunc(o *Object, pc uintptr) *Object {
+	o.self.(*nativeFuncObject).hostPC = pc
+	return o
+}
+
 func (r *Runtime) ToValue(i interface{}) Value {
 	return r.toValue(i, reflect.Value{})
 }
@@ -1838,6 +2109,10 @@ func (r *Runtime) toValue(i interface{}, origValue reflect.Value) Value {
 		return i.toValue(r)
 	case Value:
 		return i
+	case MapLike:
+		return r.newGoMapLike(i)
+	case SetLike:
+		return r.newGoSetLike(i)
 	case string:
 		if len(i) <= 16 {
 			if u := unistring.Scan(i); u != nil {
@@ -1853,21 +2128,25 @@ func (r *Runtime) toValue(i interface{}, origValue reflect.Value) Value {
 			return valueFalse
 		}
 	case func(FunctionCall) Value:
-		name := unistring.NewFromString(runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name())
-		return r.newNativeFunc(i, nil, name, nil, 0)
+		pc := reflect.ValueOf(i).Pointer()
+		name := unistring.NewFromString(runtime.FuncForPC(pc).Name())
+		return hostFunc(r.newNativeFunc(i, nil, name, nil, 0), pc)
 	case func(FunctionCall, *Runtime) Value:
-		name := unistring.NewFromString(runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name())
-		return r.newNativeFunc(func(call FunctionCall) Value {
+		pc := reflect.ValueOf(i).Pointer()
+		name := unistring.NewFromString(runtime.FuncForPC(pc).Name())
+		return hostFunc(r.newNativeFunc(func(call FunctionCall) Value {
 			return i(call, r)
-		}, nil, name, nil, 0)
+		}, nil, name, nil, 0), pc)
 	case func(ConstructorCall) *Object:
-		name := unistring.NewFromString(runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name())
-		return r.newNativeConstructor(i, name, 0)
+		pc := reflect.ValueOf(i).Pointer()
+		name := unistring.NewFromString(runtime.FuncForPC(pc).Name())
+		return hostFunc(r.newNativeConstructor(i, name, 0), pc)
 	case func(ConstructorCall, *Runtime) *Object:
-		name := unistring.NewFromString(runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name())
-		return r.newNativeConstructor(func(call ConstructorCall) *Object {
+		pc := reflect.ValueOf(i).Pointer()
+		name := unistring.NewFromString(runtime.FuncForPC(pc).Name())
+		return hostFunc(r.newNativeConstructor(func(call ConstructorCall) *Object {
 			return i(call, r)
-		}, name, 0)
+		}, name, 0), pc)
 	case int:
 		return intToValue(int64(i))
 	case int8:
@@ -1899,11 +2178,20 @@ func (r *Runtime) toValue(i interface{}, origValue reflect.Value) Value {
 		return floatToValue(float64(i))
 	case float64:
 		return floatToValue(i)
+	case *big.Int:
+		if i == nil {
+			return _null
+		}
+		return (*valueBigInt)(new(big.Int).Set(i))
 	case map[string]interface{}:
 		if i == nil {
 			return _null
 		}
-		obj := &Object{runtime: r}
+		id, obj, cacheable := r.getGoWrapper(reflect.ValueOf(i))
+		if obj != nil {
+			return obj
+		}
+		obj = &Object{runtime: r}
 		m := &objectGoMapSimple{
 			baseObject: baseObject{
 				val:        obj,
@@ -1913,14 +2201,65 @@ func (r *Runtime) toValue(i interface{}, origValue reflect.Value) Value {
 		}
 		obj.self = m
 		m.init()
+		if cacheable {
+			r.goWrappers[id] = obj
+		}
+		return obj
+	case *OrderedMap:
+		if i == nil {
+			return _null
+		}
+		id, obj, cacheable := r.getGoWrapper(reflect.ValueOf(i))
+		if obj != nil {
+			return obj
+		}
+		if i.values == nil {
+			i.values = make(map[string]interface{})
+		}
+		obj = &Object{runtime: r}
+		m := &objectGoMapSimple{
+			baseObject: baseObject{
+				val:        obj,
+				extensible: true,
+			},
+			data:    i.values,
+			ordered: i,
+		}
+		obj.self = m
+		m.init()
+		if cacheable {
+			r.goWrappers[id] = obj
+		}
 		return obj
 	case []interface{}:
-		return r.newObjectGoSlice(&i).val
+		id, obj, cacheable := r.getGoWrapper(reflect.ValueOf(i))
+		if obj != nil {
+			return obj
+		}
+		obj = r.newObjectGoSlice(&i).val
+		if cacheable {
+			r.goWrappers[id] = obj
+		}
+		return obj
 	case *[]interface{}:
 		if i == nil {
 			return _null
 		}
-		return r.newObjectGoSlice(i).val
+		id, obj, cacheable := r.getGoWrapper(reflect.ValueOf(i))
+		if obj != nil {
+			return obj
+		}
+		obj = r.newObjectGoSlice(i).val
+		if cacheable {
+			r.goWrappers[id] = obj
+		}
+		return obj
+	}
+
+	if r.typeBindings != nil {
+		if b := r.typeBindings[reflect.TypeOf(i)]; b != nil && b.ToValue != nil {
+			return b.ToValue(r, i)
+		}
 	}
 
 	if !origValue.IsValid() {
@@ -1936,6 +2275,18 @@ func (r *Runtime) toValue(i interface{}, origValue reflect.Value) Value {
 		return _null
 	}
 
+	id, obj, cacheable := r.getGoWrapper(origValue)
+	if obj != nil {
+		return obj
+	}
+	obj = r.newReflectValueObject(origValue, value)
+	if cacheable {
+		r.goWrappers[id] = obj
+	}
+	return obj
+}
+
+func (r *Runtime) newReflectValueObject(origValue, value reflect.Value) *Object {
 	switch value.Kind() {
 	case reflect.Map:
 		if value.Type().NumMethod() == 0 {
@@ -1991,7 +2342,7 @@ func (r *Runtime) toValue(i interface{}, origValue reflect.Value) Value {
 		obj.self = a
 		return obj
 	case reflect.Func:
-		return r.newWrappedFunc(value)
+		return r.newWrappedFunc(value, nil)
 	}
 
 	obj := &Object{runtime: r}
@@ -2007,7 +2358,7 @@ func (r *Runtime) toValue(i interface{}, origValue reflect.Value) Value {
 	return obj
 }
 
-func (r *Runtime) wrapReflectFunc(value reflect.Value) func(FunctionCall) Value {
+func (r *Runtime) wrapReflectFunc(value reflect.Value, opts *funcOptions) func(FunctionCall) Value {
 	return func(call FunctionCall) Value {
 		typ := value.Type()
 		nargs := typ.NumIn()
@@ -2055,20 +2406,16 @@ func (r *Runtime) wrapReflectFunc(value reflect.Value) func(FunctionCall) Value
 		}
 
 		out := value.Call(in)
+		if opts != nil && opts.results != resultsDefault {
+			return r.wrapReflectFuncResults(out, opts)
+		}
 		if len(out) == 0 {
 			return _undefined
 		}
 
 		if last := out[len(out)-1]; last.Type() == reflectTypeError {
 			if !last.IsNil() {
-				err := last.Interface().(error)
-				if _, ok := err.(*Exception); ok {
-					panic(err)
-				}
-				if isUncatchableException(err) {
-					panic(err)
-				}
-				panic(r.NewGoError(err))
+				r.throwGoFuncError(last.Interface().(error))
 			}
 			out = out[:len(out)-1]
 		}
@@ -2089,9 +2436,147 @@ func (r *Runtime) wrapReflectFunc(value reflect.Value) func(FunctionCall) Value
 	}
 }
 
+func (r *Runtime) throwGoFuncError(err error) {
+	if _, ok := err.(*Exception); ok {
+		panic(err)
+	}
+	if isUncatchableException(err) {
+		panic(err)
+	}
+	panic(r.NewGoError(err))
+}
+
+func (r *Runtime) goFuncErrorToValue(err error) Value {
+	if err == nil {
+		return _null
+	}
+	if ex, ok := err.(*Exception); ok {
+		return ex.val
+	}
+	if isUncatchableException(err) {
+		panic(err)
+	}
+	return r.NewGoError(err)
+}
+
+func (r *Runtime) wrapReflectFuncResults(out []reflect.Value, opts *funcOptions) Value {
+	values := make([]Value, len(out))
+	for i, v := range out {
+		if v.Type() == reflectTypeError {
+			if i == len(out)-1 && len(out) > len(opts.names) && opts.results == resultsNamed {
+				if !v.IsNil() {
+					r.throwGoFuncError(v.Interface().(error))
+				}
+				values = values[:i]
+				break
+			}
+			err, _ := v.Interface().(error)
+			values[i] = r.goFuncErrorToValue(err)
+		} else {
+			values[i] = r.ToValue(v.Interface())
+		}
+	}
+	if opts.results == resultsArray {
+		return r.newArrayValues(values)
+	}
+	o := r.NewObject()
+	for i, name := range opts.names {
+		o.self._putProp(unistring.NewFromString(name), values[i], true, true, true)
+	}
+	return o
+}
+
+// FuncOption is an option that can be passed to Runtime.WrapFunc().
+type FuncOption func(*funcOptions)
+
+type funcResultsMode int
+
+const (
+	resultsDefault funcResultsMode = iota
+	resultsArray
+	resultsNamed
+)
+
+type funcOptions struct {
+	results funcResultsMode
+	names   []string
+}
+
+// WithArrayResults makes the wrapped function return all of its results as an Array, so that they can be
+// destructured in JavaScript:
+//
+//	const [rows, err] = db.query("...");
+//
+// Unlike the default behaviour, a trailing 'error' result is not thrown but is included in the Array: it is
+// null if the error is nil, the thrown value if it is an *Exception, and a GoError otherwise.
+func WithArrayResults(opts *funcOptions) {
+	opts.results = resultsArray
+	opts.names = nil
+}
+
+// WithNamedResults makes the wrapped function return an Object which has a property for each result, named
+// in order of the results:
+//
+//	r.Set("query", r.WrapFunc(db.Query, goja.WithNamedResults("rows", "err")))
+//
+//	const {rows, err} = query("...");
+//
+// The number of names must either match the number of results, in which case a trailing 'error' result is
+// returned in the same way as with WithArrayResults, or be one less if the last result is an 'error',
+// in which case a non-nil error is thrown as usual.
+func WithNamedResults(names ...string) FuncOption {
+	return func(opts *funcOptions) {
+		opts.results = resultsNamed
+		opts.names = names
+	}
+}
+
+// WrapFunc is like ToValue() applied to a Go function (see ToValue() for details on how the arguments are
+// converted), but it allows changing the way the results are returned to JavaScript using the specified
+// options. It panics if fn is not a function or if the options do not match its signature.
+func (r *Runtime) WrapFunc(fn interface{}, options ...FuncOption) *Object {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func {
+		panic(fmt.Errorf("WrapFunc: %T is not a function", fn))
+	}
+	var opts funcOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.results == resultsNamed {
+		typ := value.Type()
+		n := typ.NumOut()
+		if len(opts.names) != n && (n == 0 || len(opts.names) != n-1 || typ.Out(n-1) != reflectTypeError) {
+			panic(fmt.Errorf("WrapFunc: %d result names given for %s", len(opts.names), typ))
+		}
+	}
+	return r.newWrappedFunc(value, &opts)
+}
+
+// ExportError is returned by Runtime.ExportTo() when a value nested within the exported one cannot be converted.
+type ExportError struct {
+	// Path is the location of the value within the exported one, e.g. "orders[3].items[0].price".
+	Path string
+	Err  error
+}
+
+func (e *ExportError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *ExportError) Unwrap() error {
+	return e.Err
+}
+
 func (r *Runtime) toReflectValue(v Value, dst reflect.Value, ctx *objectExportCtx) error {
 	typ := dst.Type()
 
+	if r.typeBindings != nil && dst.CanAddr() {
+		if b := r.typeBindings[typ]; b != nil && b.ExportTo != nil {
+			return b.ExportTo(r, v, dst.Addr().Interface())
+		}
+	}
+
 	if typ == typeValue {
 		dst.Set(reflect.ValueOf(v))
 		return nil
@@ -2151,7 +2636,7 @@ func (r *Runtime) toReflectValue(v Value, dst reflect.Value, ctx *objectExportCt
 			}
 		}
 		if et.Kind() == reflect.String {
-			tme, ok := dateParse(v.String())
+			tme, ok := r.dateParse(v.String())
 			if !ok {
 				return fmt.Errorf("could not convert string %v to %v", v, typ)
 			}
@@ -2243,9 +2728,14 @@ func (r *Runtime) toReflectValue(v Value, dst reflect.Value, ctx *objectExportCt
 					}
 
 					if v != nil {
-						err := r.toReflectValue(v, s.Field(i), ctx)
+						var err error
+						if field.Anonymous {
+							err = r.toReflectValue(v, s.Field(i), ctx)
+						} else {
+							err = r.toReflectValueAt(v, s.Field(i), ctx, newStringValue(name))
+						}
 						if err != nil {
-							return fmt.Errorf("could not convert struct value %v to %v for field %s: %w", v, field.Type, field.Name, err)
+							return err
 						}
 					}
 				}
@@ -2273,6 +2763,20 @@ func (r *Runtime) toReflectValue(v Value, dst reflect.Value, ctx *objectExportCt
 	return fmt.Errorf("could not convert %v to %v", v, typ)
 }
 
+// toReflectValueAt is toReflectValue for a value nested within the one being exported, key is the property name
+// or the index of the value. Errors are returned as *ExportError containing the full path of the value.
+func (r *Runtime) toReflectValueAt(v Value, dst reflect.Value, ctx *objectExportCtx, key Value) error {
+	ctx.path = append(ctx.path, key)
+	err := r.toReflectValue(v, dst, ctx)
+	if err != nil {
+		if _, ok := err.(*ExportError); !ok {
+			err = &ExportError{Path: ctx.pathString(), Err: err}
+		}
+	}
+	ctx.path = ctx.path[:len(ctx.path)-1]
+	return err
+}
+
 func (r *Runtime) wrapJSFunc(fn Callable, typ reflect.Type) func(args []reflect.Value) (results []reflect.Value) {
 	return func(args []reflect.Value) (results []reflect.Value) {
 		jsArgs := make([]Value, len(args))
@@ -2321,7 +2825,8 @@ func (r *Runtime) wrapJSFunc(fn Callable, typ reflect.Type) func(args []reflect.
 }
 
 // ExportTo converts a JavaScript value into the specified Go value. The second parameter must be a non-nil pointer.
-// Returns error if conversion is not possible.
+// Returns error if conversion is not possible. If the value that could not be converted is nested within v,
+// the error is *ExportError which contains its path, e.g. "orders[3].items[0].price".
 //
 // Notes on specific cases:
 //
@@ -2441,11 +2946,127 @@ func (r *Runtime) SetTimeSource(now Now) {
 	r.now = now
 }
 
+// SetExtendedDateParsing makes Date.parse() and the Date constructor accept, in addition to the formats required
+// by the specification, the non-standard formats commonly accepted by the browsers, such as "Jan 1 2020",
+// "1/2/2020 10:30 PM", RFC 2822 dates with time zone abbreviations ("Wed, 1 Jan 2020 10:00:00 EST") or
+// "2020/01/02 10:00 GMT+0100". As in the browsers, the dates without an explicit offset or time zone are in local
+// time. The strings which are accepted in the default mode are parsed the same way.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetExtendedDateParsing(enabled bool) {
+	r.extendedDateParsing = enabled
+}
+
+// SetLocation sets the local time zone of the Runtime. It is used by the Date methods which operate in local time
+// (including the Date constructor, getTimezoneOffset() and toString()), when parsing dates without an explicit
+// offset and as the default time zone of Intl.DateTimeFormat. If loc is nil or the method is not called, the
+// time zone of the process (time.Local) is used.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetLocation(loc *time.Location) {
+	r.loc = loc
+}
+
+// SetTimeZone is a shortcut for SetLocation with the location loaded by time.LoadLocation, i.e. name is an IANA
+// time zone name such as "Europe/Berlin", "UTC" or "Local". It returns the error if the location cannot be loaded.
+func (r *Runtime) SetTimeZone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	r.SetLocation(loc)
+	return nil
+}
+
+// location returns the local time zone, see SetLocation.
+func (r *Runtime) location() *time.Location {
+	if r.loc != nil {
+		return r.loc
+	}
+	return time.Local
+}
+
+// SetConsolePrinter sets the Printer receiving the output of the console built-in object, see the console package.
+// If p is nil or the method is not called, the output is written to os.Stdout and os.Stderr.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetConsolePrinter(p console.Printer) {
+	r.console.printer = p
+}
+
 // SetParserOptions sets parser options to be used by RunString, RunScript and eval() within the code.
 func (r *Runtime) SetParserOptions(opts ...parser.Option) {
 	r.parserOptions = opts
 }
 
+// SetStrictBlockFunctions selects the semantics of function declarations inside blocks in non-strict code which
+// is compiled by RunString, RunScript, eval() and the Function constructor. By default the web compatibility
+// semantics of ECMAScript Annex B.3.3 apply, which legacy scripts depend on. If strict is true the functions are
+// only visible inside their blocks, the same as in strict mode code. See WithStrictBlockFunctions for details.
+// Programs compiled with Compile() use the semantics selected at compile time.
+func (r *Runtime) SetStrictBlockFunctions(strict bool) {
+	r.strictBlockFunctions = strict
+}
+
+// SetDebugScopes makes RunString, RunScript, eval() and the Function constructor compile the code as if
+// WithDebugScopes was used, so that Frame.Eval() can access all variables. Programs compiled with Compile() use
+// the options supplied at compile time.
+func (r *Runtime) SetDebugScopes(enabled bool) {
+	r.debugScopes = enabled
+}
+
+// SetArrayBufferPool sets the pool used to allocate the storage of ArrayBuffers created by scripts (including
+// the ones created implicitly by TypedArray constructors). The storage is returned to the pool when the buffer
+// is transferred to a larger one using ArrayBuffer.prototype.transfer() (e.g. buf.transfer(0) releases it
+// completely) or when ArrayBuffer.Release() is called. This reduces the pressure on the garbage collector in
+// scripts that allocate many short-lived buffers. The pool may be shared between Runtimes if it is safe for
+// concurrent use, as is the one returned by NewArrayBufferPool(). Set to nil (the default) to disable pooling.
+func (r *Runtime) SetArrayBufferPool(pool ArrayBufferPool) {
+	r.arrayBufferPool = pool
+}
+
+// SetLegacyRegExpStatics enables or disables the legacy static properties of the RegExp constructor: RegExp.$1-$9,
+// RegExp.input ($_), RegExp.lastMatch ($&), RegExp.lastParen ($+), RegExp.leftContext ($`) and
+// RegExp.rightContext ($'). When enabled, they reflect the last successful match performed by any RegExp in this
+// Runtime. They are not part of the ECMAScript standard and are disabled by default, however some older libraries
+// still depend on them.
+func (r *Runtime) SetLegacyRegExpStatics(enabled bool) {
+	if enabled {
+		if r.regexpLegacyStatics == nil {
+			r.regexpLegacyStatics = &regexpLegacyStatics{}
+			r.defineLegacyRegExpStatics()
+		}
+	} else if r.regexpLegacyStatics != nil {
+		r.regexpLegacyStatics = nil
+		r.deleteLegacyRegExpStatics()
+	}
+}
+
+// SetStringBuilder defines (or, if enabled is false, removes) the StringBuilder global constructor. It is not
+// part of ECMAScript and is meant for scripts that generate very large strings piece by piece, where
+// repeated concatenation with += copies the string built so far over and over:
+//
+//	const sb = new StringBuilder();
+//	for (const row of rows) {
+//	    sb.append(row.name, ",", row.value, "\n");
+//	}
+//	const csv = sb.toString();
+//
+// The constructor accepts an optional initial string. The prototype has the append(...values) method,
+// which converts its arguments to strings, appends them and returns the builder, clear(), toString() and the
+// length getter, which returns the length of the string built so far.
+func (r *Runtime) SetStringBuilder(enabled bool) {
+	if enabled {
+		if r.global.StringBuilder == nil {
+			r.global.StringBuilderPrototype = r.newLazyObject(r.createStringBuilderProto)
+			r.global.StringBuilder = r.newLazyObject(r.createStringBuilder)
+		}
+		r.addToGlobal(classStringBuilder, r.global.StringBuilder)
+	} else if r.global.StringBuilder != nil {
+		r.globalObject.self.deleteStr(classStringBuilder, false)
+	}
+}
+
 // SetMaxCallStackSize sets the maximum function call depth. When exceeded, a *StackOverflowError is thrown and
 // returned by RunProgram or by a Callable call. This is useful to prevent memory exhaustion caused by an
 // infinite recursion. The default value is math.MaxInt32.
@@ -2455,6 +3076,52 @@ func (r *Runtime) SetMaxCallStackSize(size int) {
 	r.vm.maxCallStackSize = size
 }
 
+// SetStackTraceLimit sets the maximum number of frames captured in the stack traces of the thrown exceptions
+// (see Exception.Stack()) and of the newly created Error objects. Scripts can override the latter by setting
+// Error.stackTraceLimit (for example, 'Error.stackTraceLimit = 0' disables the capture of the Error stacks
+// entirely), and deleting the property restores the Runtime default. The default value is math.MaxInt32.
+// Note that the frames are only formatted on the first access to the 'stack' property, so the cost of
+// an Error that is created and caught without ever inspecting its stack is mostly proportional to the limit.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetStackTraceLimit(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	r.vm.stackTraceLimit = limit
+}
+
+// SetHostFrameLocations enables or disables the Go source locations of the host functions in the captured
+// stack traces. By default the calls to the Go functions provided by the host (see ToValue()) appear in the stack
+// traces as "name (native)", the same as the built-ins (StackFrame.IsHost() tells them apart), with this option
+// enabled the frames become "native name (go, /path/to/file.go:42)".
+// This is useful for debugging the failures which involve both JavaScript and Go code, but it makes capturing
+// the stack traces somewhat slower and discloses the Go file paths to the scripts (through Error.prototype.stack).
+// The option is disabled by default.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetHostFrameLocations(enabled bool) {
+	r.hostFrameLocations = enabled
+}
+
+// SetAsyncStackTraces enables the async stack traces. Once an async function has been suspended by await, or
+// when a promise reaction (a then() or catch() callback) runs, the synchronous part of the stack that led to it
+// is gone. Normally the captured stack traces only include the async functions awaiting each other; with this
+// option every async function call and every then() call records the stack at the time it was made, and
+// the stack traces captured while the continuation runs are extended with it. This way an error thrown after
+// several awaits still shows where the chain originated.
+// The limit is the maximum number of frames recorded for each continuation, 0 (the default) disables the
+// feature. Recording the stacks makes the async calls and the then() calls slower and keeps the recorded
+// frames (and, through them, the Programs) in memory for as long as the continuations are pending.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetAsyncStackTraces(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	r.vm.asyncStackLimit = limit
+}
+
 // New is an equivalent of the 'new' operator allowing to call it directly from Go.
 func (r *Runtime) New(construct Value, args ...Value) (o *Object, err error) {
 	err = r.try(func() {
@@ -2463,6 +3130,24 @@ func (r *Runtime) New(construct Value, args ...Value) (o *Object, err error) {
 	return
 }
 
+// BindFunction is an equivalent of fn.bind(this, args...) that does not depend on Function.prototype.bind
+// being present or unmodified, so it can be used at bootstrap time. The resulting function has the same
+// "name" (prefixed with "bound ") and "length" as the result of bind(), can be used with 'new' if fn is
+// a constructor, and works with instanceof via the target function.
+// If fn is not a function, a TypeError is returned.
+func (r *Runtime) BindFunction(fn Value, this Value, args ...Value) (bound *Object, err error) {
+	if this == nil {
+		this = _undefined
+	}
+	boundArgs := make([]Value, 0, len(args)+1)
+	boundArgs = append(boundArgs, this)
+	boundArgs = append(boundArgs, args...)
+	err = r.try(func() {
+		bound = r.bindFunction(r.toObject(fn), boundArgs)
+	})
+	return
+}
+
 // Callable represents a JavaScript function that can be called from Go.
 type Callable func(this Value, args ...Value) (Value, error)
 
@@ -2486,6 +3171,19 @@ func AssertFunction(v Value) (Callable, bool) {
 	return nil, false
 }
 
+// FunctionPosition returns the position in the source code where the function was defined. The second return
+// value is false if v is not a function defined in JavaScript (e.g. a native or a bound function).
+func FunctionPosition(v Value) (file.Position, bool) {
+	if obj, ok := v.(*Object); ok {
+		if f, ok := obj.self.(jsFuncObjectImpl); ok {
+			if prg := f.program(); prg != nil && prg.src != nil {
+				return prg.src.Position(prg.sourceOffset(0)), true
+			}
+		}
+	}
+	return file.Position{}, false
+}
+
 // Constructor is a type that can be used to call constructors. The first argument (newTarget) can be nil
 // which sets it to the constructor function itself.
 type Constructor func(newTarget *Object, args ...Value) (*Object, error)
@@ -2523,7 +3221,9 @@ func (r *Runtime) runWrapped(f func()) (err error) {
 		err = ex
 	}
 	if len(r.vm.callStack) == 0 {
-		r.leave()
+		if ex := r.leave(); ex != nil && err == nil {
+			err = ex
+		}
 	} else {
 		r.vm.clearStack()
 	}
@@ -2697,9 +3397,16 @@ type iteratorRecord struct {
 
 func (r *Runtime) getIterator(obj Value, method func(FunctionCall) Value) *iteratorRecord {
 	if method == nil {
-		method = toMethod(r.getV(obj, SymIterator))
+		switch obj.(type) {
+		case valueUndefined, valueNull:
+		default:
+			method = toMethod(r.getV(obj, SymIterator))
+		}
 		if method == nil {
-			panic(r.NewTypeError("object is not iterable"))
+			if _, ok := obj.(*Object); ok {
+				panic(r.NewTypeError("object is not iterable"))
+			}
+			panic(r.NewTypeError("%s is not iterable", describeValue(obj)))
 		}
 	}
 
@@ -2793,8 +3500,24 @@ func (r *Runtime) createIterResultObject(value Value, done bool) Value {
 func (r *Runtime) newLazyObject(create func(*Object) objectImpl) *Object {
 	val := &Object{runtime: r}
 	o := &lazyObject{
-		val:    val,
-		create: create,
+		val: val,
+		create: func(val *Object) objectImpl {
+			if l := r.propertyLimits; l != nil {
+				// the properties of the built-in objects are not limited, see SetPropertyLimits()
+				r.propertyLimits = nil
+				defer func() {
+					r.propertyLimits = l
+				}()
+			}
+			if u := r.usage; u != nil && !u.suspended {
+				// neither are the allocations accounted, see ResourceUsage.BytesAllocated
+				u.suspended = true
+				defer func() {
+					u.suspended = false
+				}()
+			}
+			return create(val)
+		},
 	}
 	val.self = o
 	return val
@@ -2808,7 +3531,8 @@ func (r *Runtime) getHash() *maphash.Hash {
 }
 
 // called when the top level function returns normally (i.e. control is passed outside the Runtime).
-func (r *Runtime) leave() {
+// Returns the first exception thrown by a queueMicrotask() callback, if any.
+func (r *Runtime) leave() error {
 	var jobs []func()
 	for len(r.jobQueue) > 0 {
 		jobs, r.jobQueue = r.jobQueue, jobs[:0]
@@ -2818,12 +3542,20 @@ func (r *Runtime) leave() {
 	}
 	r.jobQueue = r.jobQueue[:0]
 	r.vm.stack = r.vm.stack[:0]
+	r.resetPropertyInsertions()
+	if ex := r.microtaskErr; ex != nil {
+		r.microtaskErr = nil
+		return ex
+	}
+	return nil
 }
 
 // called when the top level function returns (i.e. control is passed outside the Runtime) but it was due to an interrupt
 func (r *Runtime) leaveAbrupt() {
 	r.jobQueue = nil
+	r.microtaskErr = nil
 	r.ClearInterrupt()
+	r.resetPropertyInsertions()
 }
 
 func nilSafe(v Value) Value {
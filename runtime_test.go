@@ -1,6 +1,7 @@
 package goja
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math"
@@ -160,6 +161,9 @@ func TestNumberFormatRounding(t *testing.T) {
 	assert.sameValue((-25.5).toFixed(0), "-26");
 	assert.sameValue((99.9).toFixed(0), "100");
 	assert.sameValue((99.99).toFixed(1), "100.0");
+	assert.sameValue((-958.1371).toExponential(0), "-1e+3");
+	assert.sameValue((-999.118).toPrecision(1), "-1e+3");
+	assert.sameValue((-0.0000001).toFixed(2), "-0.00");
 	`
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
@@ -431,6 +435,77 @@ func TestInterrupt(t *testing.T) {
 	}
 }
 
+func TestInterruptBuiltins(t *testing.T) {
+	vm := New()
+	for _, test := range []struct {
+		name, fn, this string
+		args           []string
+	}{
+		{"indexOf", "Array.prototype.indexOf", "({length: 2**10})", []string{"1"}},
+		{"lastIndexOf", "Array.prototype.lastIndexOf", "({length: 2**10})", []string{"1"}},
+		{"includes", "Array.prototype.includes", "({length: 2**10})", []string{"1"}},
+		{"join", "Array.prototype.join", "({length: 2**10})", nil},
+		{"fill", "Array.prototype.fill", "({length: 2**10})", []string{"0"}},
+		{"sort", "Array.prototype.sort", "[3, 2, 1]", nil},
+		{"sortGeneric", "Array.prototype.sort", "({length: 2**10})", nil},
+		{"typedArraySort", "Float64Array.prototype.sort", "new Float64Array([3, 2, 1])", []string{"Math.max"}},
+		{"replace", "String.prototype.replace", "'aa'.repeat(1000)", []string{"/(a)\\1/g", "'b'"}},
+		{"match", "String.prototype.match", "'aa'.repeat(1000)", []string{"/(?<=a)a/g"}},
+		{"split", "String.prototype.split", "'aa'.repeat(1000)", []string{"/(a)\\1/"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := vm.RunString(test.fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fn, ok := AssertFunction(v)
+			if !ok {
+				t.Fatal("not a function")
+			}
+			this, err := vm.RunString(test.this)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := make([]Value, len(test.args))
+			for i, arg := range test.args {
+				if args[i], err = vm.RunString(arg); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Calling a built-in from Go does not execute any VM instructions, so it's stopped by its own checks.
+			vm.Interrupt("halt")
+			defer vm.ClearInterrupt()
+			_, err = fn(this, args...)
+			if _, ok := err.(*InterruptedError); !ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInterruptRegexpBacktracking(t *testing.T) {
+	for _, script := range []string{
+		// a non-zero lastIndex requires the backtracking engine
+		`var re = /(a+)+$/y; re.lastIndex = 1; re.test("a".repeat(40) + "b")`,
+		`/(a+)+$(?<!b)/.test("a".repeat(40) + "b")`,
+		`"a".repeat(40).replace(/(a+)+(?=b)/g, "")`,
+	} {
+		vm := New()
+		timer := time.AfterFunc(50*time.Millisecond, func() {
+			vm.Interrupt("halt")
+		})
+		start := time.Now()
+		_, err := vm.RunString(script)
+		timer.Stop()
+		if _, ok := err.(*InterruptedError); !ok {
+			t.Fatalf("%s: unexpected error: %v", script, err)
+		}
+		if d := time.Since(start); d > 5*time.Second {
+			t.Fatalf("%s: took too long to interrupt: %v", script, d)
+		}
+	}
+}
+
 func TestRuntime_ExportToNumbers(t *testing.T) {
 	vm := New()
 	t.Run("int8/no overflow", func(t *testing.T) {
@@ -812,6 +887,47 @@ func TestRuntime_ExportToStructWithPtrValues(t *testing.T) {
 
 }
 
+func TestRuntime_ExportToErrorPath(t *testing.T) {
+	type Item struct {
+		Created time.Time `json:"created"`
+	}
+	type Order struct {
+		Items []Item `json:"items"`
+	}
+	type Orders struct {
+		Orders []Order `json:"orders"`
+	}
+
+	vm := New()
+	vm.SetFieldNameMapper(TagFieldNameMapper("json", true))
+	v, err := vm.RunString(`({orders: [{items: []}, {items: [{created: new Date(0)}, {created: "invalid"}]}]})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o Orders
+	err = vm.ExportTo(v, &o)
+	var exportErr *ExportError
+	if !errors.As(err, &exportErr) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if exportErr.Path != "orders[1].items[1].created" {
+		t.Fatalf("Unexpected path: %q", exportErr.Path)
+	}
+	if msg := err.Error(); msg != "orders[1].items[1].created: could not convert string invalid to time.Time" {
+		t.Fatalf("Unexpected message: %q", msg)
+	}
+
+	v, err = vm.RunString(`({"a b": [[1, 2], [3]]})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string][][2]int
+	err = vm.ExportTo(v, &m)
+	if !errors.As(err, &exportErr) || exportErr.Path != `["a b"][1]` {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
 func TestRuntime_ExportToTime(t *testing.T) {
 	const SCRIPT = `
 	var dateStr = "2018-08-13T15:02:13+02:00";
@@ -1060,6 +1176,65 @@ func TestGoFuncError(t *testing.T) {
 	}
 }
 
+func TestWrapFuncResults(t *testing.T) {
+	const SCRIPT = `
+	var [rows, err] = query(false);
+	assert(compareArray(rows, [1, 2]), "rows");
+	assert.sameValue(err, null, "err");
+
+	[rows, err] = query(true);
+	assert.sameValue(rows.length, 0, "rows on error");
+	assert(err instanceof GoError, "err instanceof GoError");
+	assert.sameValue(err.value.Error(), "failed");
+	assert(Array.isArray(query(false)), "Array.isArray");
+
+	var {count, name} = stat(false);
+	assert.sameValue(count, 3, "count");
+	assert.sameValue(name, "x", "name");
+	assert.throws(GoError, function() { stat(true); });
+
+	var res = stat2();
+	assert(compareArray(Object.keys(res), ["a", "b", "err"]), "keys");
+	assert.sameValue(res.err, null, "stat2 err");
+	`
+
+	vm := New()
+	vm.Set("query", vm.WrapFunc(func(fail bool) ([]int, error) {
+		if fail {
+			return nil, errors.New("failed")
+		}
+		return []int{1, 2}, nil
+	}, WithArrayResults))
+	vm.Set("stat", vm.WrapFunc(func(fail bool) (int, string, error) {
+		if fail {
+			return 0, "", errors.New("failed")
+		}
+		return 3, "x", nil
+	}, WithNamedResults("count", "name")))
+	vm.Set("stat2", vm.WrapFunc(func() (int, int, error) {
+		return 1, 2, nil
+	}, WithNamedResults("a", "b", "err")))
+	vm.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestWrapFuncInvalid(t *testing.T) {
+	vm := New()
+	for _, f := range []func(){
+		func() { vm.WrapFunc(42) },
+		func() { vm.WrapFunc(func() (int, int) { return 0, 0 }, WithNamedResults("a")) },
+		func() { vm.WrapFunc(func() {}, WithNamedResults("a")) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("Expected a panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
 func TestToValueNil(t *testing.T) {
 	type T struct{}
 	var a *T
@@ -2156,6 +2331,29 @@ func TestRuntime_SetParserOptions_Eval(t *testing.T) {
 	}
 }
 
+func TestRuntime_SetParserOptions_StrictEncoding(t *testing.T) {
+	vm := New()
+	vm.SetParserOptions(parser.WithStrictEncoding)
+
+	_, err := vm.RunString(`var s = "\uD83D\uDE00";`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = vm.RunString(`
+	try {
+		eval("'\\uDE00'");
+		throw new Error("no error");
+	} catch (e) {
+		if (!(e instanceof SyntaxError) || !e.message.includes("Line 1:2 Unpaired surrogate")) {
+			throw e;
+		}
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestNativeCallWithRuntimeParameter(t *testing.T) {
 	vm := New()
 	vm.Set("f", func(_ FunctionCall, r *Runtime) Value {
@@ -2400,6 +2598,60 @@ func TestStacktraceLocationThrowNativeInTheMiddle(t *testing.T) {
 	}
 }
 
+func TestStacktraceHostFrames(t *testing.T) {
+	vm := New()
+	vm.Set("call", func(call FunctionCall) Value {
+		f, _ := AssertFunction(call.Argument(0))
+		res, err := f(nil)
+		if err != nil {
+			panic(err)
+		}
+		return res
+	})
+	const SCRIPT = `
+	function f() {
+		throw new Error("test");
+	}
+	call(f);
+	`
+	_, err := vm.RunString(SCRIPT)
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	stack := err.(*Exception).stack
+	if len(stack) != 3 {
+		t.Fatalf("Unexpected stack len: %v", stack)
+	}
+	frame := stack[1]
+	if !frame.IsHost() || !strings.HasSuffix(frame.FuncName(), "TestStacktraceHostFrames.func1") {
+		t.Fatalf("Unexpected stack frame 1: %#v", frame)
+	}
+	if file, _ := frame.HostPosition(); file != "" {
+		t.Fatal(file)
+	}
+	var b bytes.Buffer
+	frame.Write(&b)
+	if s := b.String(); strings.HasPrefix(s, "native ") || !strings.HasSuffix(s, ".func1 (native)") {
+		t.Fatal(s)
+	}
+
+	vm.SetHostFrameLocations(true)
+	_, err = vm.RunString(SCRIPT)
+	frame = err.(*Exception).stack[1]
+	if file, line := frame.HostPosition(); !strings.HasSuffix(file, "runtime_test.go") || line == 0 {
+		t.Fatal(file, line)
+	}
+	if s := err.(*Exception).String(); !strings.Contains(s, ".func1 (go, ") || !strings.Contains(s, "runtime_test.go:") {
+		t.Fatal(s)
+	}
+
+	// built-ins are not host functions
+	_, err = vm.RunString(`[1].forEach(f)`)
+	if frame := err.(*Exception).stack[1]; frame.IsHost() || frame.FuncName() != "forEach" {
+		t.Fatalf("Unexpected stack frame 1: %#v", frame)
+	}
+}
+
 func TestStrToInt64(t *testing.T) {
 	if _, ok := strToInt64(""); ok {
 		t.Fatal("<empty>")
@@ -2611,6 +2863,125 @@ assert.sameValue(callCount, 3, '"then"" invoked once for every iterated value');
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestPromiseTry(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	var sync = true;
+	Promise.try(function(a, b) {
+		"use strict";
+		assert.sameValue(this, undefined, "this");
+		assert(sync, "called synchronously");
+		return a + b;
+	}, 40, 2).then(function(v) {
+		log.push(v);
+	});
+	Promise.try(function() {
+		throw new Error("sync");
+	}).catch(function(e) {
+		log.push(e.message);
+	});
+	Promise.try(42).catch(function(e) {
+		log.push(e instanceof TypeError);
+	});
+	Promise.try(function() {
+		return Promise.resolve("nested");
+	}).then(function(v) {
+		log.push(v);
+	});
+	sync = false;
+	assert.throws(TypeError, function() {
+		Promise.try.call(1, function() {});
+	});
+	assert.sameValue(Promise.try.length, 1, "length");
+	`
+	vm := New()
+	vm.RunProgram(testLib())
+	if _, err := vm.RunString(SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	res, err := vm.RunString(`log.join()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "42,sync,true,nested" {
+		t.Fatalf("Unexpected result: %q", s)
+	}
+}
+
+func TestQueueMicrotask(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	var log = [];
+	queueMicrotask(function() {
+		"use strict";
+		log.push("first:" + this + ":" + arguments.length);
+		queueMicrotask(function() {
+			log.push("nested");
+		});
+	});
+	Promise.resolve().then(function() {
+		log.push("promise");
+	});
+	queueMicrotask(function() {
+		throw new Error("failed");
+	});
+	queueMicrotask(function() {
+		log.push("after error");
+	});
+	log.push("sync");
+	`)
+	if ex, ok := err.(*Exception); !ok || ex.Value().String() != "Error: failed" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res, err := vm.RunString(`log.join()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "sync,first:undefined:0,promise,after error,nested" {
+		t.Fatalf("Unexpected log: %q", s)
+	}
+
+	_, err = vm.RunString(`queueMicrotask(1)`)
+	if ex, ok := err.(*Exception); !ok || !strings.Contains(ex.Error(), "TypeError") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestRunMicrotasks(t *testing.T) {
+	vm := New()
+	p, resolve, _ := vm.NewPromise()
+	vm.Set("p", p)
+	_, err := vm.RunString(`
+	var result;
+	p.then(function(v) {
+		result = v;
+	});
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolve("done")
+	var ran []string
+	vm.QueueMicrotask(func() {
+		ran = append(ran, "go")
+		vm.QueueMicrotask(func() {
+			ran = append(ran, "nested")
+		})
+	})
+	if res := vm.Get("result"); res.String() != "done" {
+		t.Fatalf("Unexpected result: %v", res)
+	}
+	if ran != nil {
+		t.Fatalf("Jobs ran before draining: %v", ran)
+	}
+	if err := vm.RunMicrotasks(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ran, []string{"go", "nested"}) {
+		t.Fatalf("Unexpected jobs: %v", ran)
+	}
+}
+
 func TestPromiseExport(t *testing.T) {
 	vm := New()
 	p, _, _ := vm.NewPromise()
@@ -2645,6 +3016,52 @@ func TestErrorStack(t *testing.T) {
 	testScript(SCRIPT, _undefined, t)
 }
 
+func TestErrorStackTraceLimit(t *testing.T) {
+	const SCRIPT = `
+	function rec(n) {
+		if (n === 0) {
+			return new Error("deep");
+		}
+		return rec(n - 1);
+	}
+	function frames(err) {
+		return err.stack.split("\n").length - 2;
+	}
+	assert.sameValue(frames(rec(5)), 3, "runtime default");
+	Error.stackTraceLimit = 5;
+	assert.sameValue(frames(rec(10)), 5, "Error.stackTraceLimit");
+	Error.stackTraceLimit = 0;
+	assert.sameValue(rec(10).stack, "Error: deep\n", "zero");
+	Error.stackTraceLimit = "10";
+	assert.sameValue(rec(10).stack, "Error: deep\n", "non-number");
+	Error.stackTraceLimit = Infinity;
+	assert.sameValue(frames(rec(10)), 12, "Infinity");
+	delete Error.stackTraceLimit;
+	assert.sameValue(frames(rec(5)), 3, "restored");
+	`
+	vm := New()
+	vm.SetStackTraceLimit(3)
+	vm.RunProgram(testLib())
+	if _, err := vm.RunString(SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := vm.RunString(`
+	function thrower(n) {
+		if (n === 0) {
+			throw "str";
+		}
+		thrower(n - 1);
+	}
+	thrower(10);
+	`)
+	if ex, ok := err.(*Exception); !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if l := len(ex.stack); l != 3 {
+		t.Fatalf("Unexpected stack length: %d", l)
+	}
+}
+
 func TestErrorFormatSymbols(t *testing.T) {
 	vm := New()
 	vm.Set("a", func() (Value, error) { return nil, errors.New("something %s %f") })
@@ -2806,6 +3223,59 @@ func TestAsyncStacktrace(t *testing.T) {
 	testAsyncFuncWithTestLibX(SCRIPT, _undefined, t)
 }
 
+func TestAsyncStackTraceCreationStack(t *testing.T) {
+	// Do not reformat, assertions depend on the line and column numbers
+	const SCRIPT = `
+	async function handler(x) {
+	  await x;
+	  return inner(x);
+	}
+
+	async function inner(x) {
+	  await x;
+	  throw new Error("inner");
+	}
+
+	function origin() {
+	  return handler(1);
+	}
+
+	function thenOrigin() {
+	  return Promise.resolve().then(function cb() {
+	    throw new Error("then");
+	  });
+	}
+
+	function stack(e) {
+	  return e.stack.replace(/\(\d+\)/g, "");
+	}
+
+	var errs = [];
+	origin().catch(e => errs.push(stack(e)));
+	thenOrigin().catch(e => errs.push(stack(e)));
+	`
+	r := New()
+	r.SetAsyncStackTraces(10)
+	if _, err := r.RunScript("test.js", SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"Error: then\n\tat cb (test.js:18:12)\n\tat then (native)\n\tat thenOrigin (test.js:17:33)\n\tat test.js:28:12\n",
+		"Error: inner\n\tat inner (test.js:9:10)\n\tat handler (test.js:4:16)\n\tat origin (test.js:13:18)\n\tat test.js:27:8\n",
+	}
+	if errs := r.Get("errs").Export(); !reflect.DeepEqual(errs, []interface{}{expected[0], expected[1]}) {
+		t.Fatalf("%q", errs)
+	}
+
+	r.SetAsyncStackTraces(0)
+	if _, err := r.RunString("errs = []; origin().catch(e => errs.push(stack(e)));"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := r.Get("errs").Export(); !reflect.DeepEqual(errs, []interface{}{"Error: inner\n\tat inner (test.js:9:10)\n"}) {
+		t.Fatalf("%q", errs)
+	}
+}
+
 func TestPanicPropagation(t *testing.T) {
 	r := New()
 	r.Set("doPanic", func() {
@@ -2844,6 +3314,48 @@ func TestAwaitInParameters(t *testing.T) {
 	}
 }
 
+func TestBindFunction(t *testing.T) {
+	r := New()
+	v, err := r.RunString(`
+	function Point(x, y) {
+		this.x = x;
+		this.y = y;
+	}
+	Point.prototype.getThis = function() {
+		return this;
+	}
+	delete Function.prototype.bind;
+	Point;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	this := r.NewObject()
+	bound, err := r.BindFunction(v, this, r.ToValue(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Set("bound", bound)
+	r.Set("boundThis", this)
+	r.testScriptWithTestLib(`
+	assert.sameValue(bound.name, "bound Point");
+	assert.sameValue(bound.length, 1);
+	bound(2);
+	assert.sameValue(boundThis.x, 1);
+	assert.sameValue(boundThis.y, 2);
+	var p = new bound(3);
+	assert(p instanceof Point, "instanceof Point");
+	assert(p instanceof bound, "instanceof bound");
+	assert.sameValue(p.x, 1);
+	assert.sameValue(p.y, 3);
+	`, _undefined, t)
+
+	_, err = r.BindFunction(r.NewObject(), nil)
+	if ex, ok := err.(*Exception); !ok || !ex.Value().(*Object).Get("constructor").SameAs(r.Get("TypeError")) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
 /*
 func TestArrayConcatSparse(t *testing.T) {
 function foo(a,b,c)
@@ -2944,6 +3456,135 @@ func BenchmarkStringMapGet(b *testing.B) {
 	}
 }
 
+func TestEvalBatch(t *testing.T) {
+	r := New()
+	if _, err := r.RunString("let threshold = 10; var country;"); err != nil {
+		t.Fatal(err)
+	}
+	prg := MustCompile("", "score > threshold && country === 'NL'", false)
+	res, err := r.EvalBatch(prg, []map[string]Value{
+		{"score": r.ToValue(12), "country": r.ToValue("NL")},
+		{"score": r.ToValue(12)},
+		{"score": r.ToValue(15), "threshold": r.ToValue(20), "country": r.ToValue("NL")},
+		{"score": r.ToValue(15), "country": r.ToValue("NL")},
+		{"country": r.ToValue("NL"), "score": nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 5 || res[0] != valueTrue || res[1] != valueFalse || res[2] != valueFalse || res[3] != valueTrue || res[4] != valueFalse {
+		t.Fatal(res)
+	}
+	if v := r.Get("threshold"); v.ToInteger() != 10 {
+		t.Fatal("global binding was modified", v)
+	}
+	if v := r.Get("country"); v != _undefined {
+		t.Fatal("global variable was modified", v)
+	}
+
+	prg = MustCompile("", "typeof seen === 'undefined' ? (seen = x) : seen + '/' + x", false)
+	res, err = r.EvalBatch(prg, []map[string]Value{
+		{"x": r.ToValue("a"), "seen": r.ToValue("s")},
+		{"x": r.ToValue("b")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].String() != "s/a" || res[1].String() != "b" {
+		t.Fatal(res)
+	}
+
+	prg = MustCompile("", "x.y", false)
+	res, err = r.EvalBatch(prg, []map[string]Value{
+		{"x": r.ToValue(map[string]interface{}{"y": 1})},
+		{"x": nil},
+	})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Fatal(err)
+	}
+	var ex *Exception
+	if !errors.As(err, &ex) {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].ToInteger() != 1 {
+		t.Fatal(res)
+	}
+}
+
+func TestRunProgramWithGlobals(t *testing.T) {
+	r := New()
+	if _, err := r.RunString("let tenant = 'default'; var counter = 0; function getTenant() { return tenant; }"); err != nil {
+		t.Fatal(err)
+	}
+	prg := MustCompile("", `
+	counter++;
+	tenant = tenant + "!";
+	var f = function() { return tenant + "/" + requestId; };
+	[tenant, requestId, getTenant(), typeof globalThis.requestId, f()].join()
+	`, false)
+	res, err := r.RunProgramWithGlobals(prg, map[string]Value{
+		"tenant":    r.ToValue("acme"),
+		"requestId": r.ToValue(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "acme!,1,default,undefined,acme!/1" {
+		t.Fatal(s)
+	}
+	f, _ := AssertFunction(r.Get("f"))
+	if v, err := f(_undefined); err != nil || v.String() != "acme!/1" {
+		t.Fatal("closure does not see the bindings", v, err)
+	}
+	if v := r.Get("tenant"); v.String() != "default" {
+		t.Fatal("global was modified", v)
+	}
+	if v := r.Get("counter"); v.ToInteger() != 1 {
+		t.Fatal("global was not modified", v)
+	}
+
+	if _, err := r.RunString("requestId"); err == nil {
+		t.Fatal("binding leaked into a subsequent run")
+	}
+	res, err = r.RunProgramWithGlobals(MustCompile("", "tenant", false), nil)
+	if err != nil || res.String() != "default" {
+		t.Fatal(res, err)
+	}
+
+	// nested run from a host function
+	_ = r.Set("nested", func() Value {
+		v, err := r.RunProgramWithGlobals(MustCompile("", "requestId + tenant", false), map[string]Value{
+			"requestId": r.ToValue(2),
+		})
+		if err != nil {
+			panic(err)
+		}
+		return v
+	})
+	res, err = r.RunProgramWithGlobals(MustCompile("", "nested() + requestId", false), map[string]Value{
+		"requestId": r.ToValue(3),
+	})
+	if err != nil || res.String() != "2default3" {
+		t.Fatal(res, err)
+	}
+}
+
+func BenchmarkEvalBatch(b *testing.B) {
+	r := New()
+	prg := MustCompile("", "score * weight > 100", false)
+	inputs := make([]map[string]Value, 1000)
+	for i := range inputs {
+		inputs[i] = map[string]Value{"score": intToValue(int64(i)), "weight": floatToValue(0.5)}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.EvalBatch(prg, inputs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkValueStringMapGet(b *testing.B) {
 	m := make(map[valueString]Value)
 	for i := 0; i < 100; i++ {
@@ -2971,3 +3612,170 @@ func BenchmarkAsciiStringMapGet(b *testing.B) {
 		}
 	}
 }
+
+func TestGlobalMutationHook(t *testing.T) {
+	r := New()
+	var log []string
+	r.SetGlobalMutationHook(func(m GlobalMutation) error {
+		log = append(log, m.Kind.String()+" "+m.Key.String())
+		if m.Key.String() == "Array" || m.Key.String() == "JSON" {
+			return errors.New("built-ins are shared")
+		}
+		return nil
+	})
+	if err := r.Set("hostValue", 1); err != nil {
+		t.Fatal(err)
+	}
+	_, err := r.RunString(`
+	var v = 1;
+	function f() {}
+	let lexical = 1;
+	globalThis.x = 2;
+	y = 3;
+	Array = null;
+	if (typeof Array !== "function") {
+		throw new Error("Array was replaced");
+	}
+	delete JSON;
+	if (typeof JSON !== "object") {
+		throw new Error("JSON was deleted");
+	}
+	delete globalThis.missing;
+	Object.defineProperty(globalThis, "z", {value: 4});
+	try {
+		(function() {
+			"use strict";
+			Array = null;
+		})();
+		throw new Error("should have thrown");
+	} catch (e) {
+		if (!(e instanceof TypeError) || e.message !== "Cannot set global property 'Array': built-ins are shared") {
+			throw e;
+		}
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"define f", "set f", "define v", "set v", "set v", "set x", "set y", "set Array", "delete JSON", "define z", "set Array"}
+	if strings.Join(log, ",") != strings.Join(expected, ",") {
+		t.Fatal(log)
+	}
+
+	r.SetGlobalMutationHook(nil)
+	if _, err := r.RunString("Array = null"); err != nil {
+		t.Fatal(err)
+	}
+	if r.Get("Array") != _null {
+		t.Fatal("hook was not removed")
+	}
+}
+
+func TestGlobalMutationHookIntegrity(t *testing.T) {
+	r := New()
+	var log []string
+	veto := true
+	r.SetGlobalMutationHook(func(m GlobalMutation) error {
+		entry := m.Kind.String()
+		if m.Key != nil {
+			entry += " " + m.Key.String()
+		}
+		if m.Kind == GlobalSetPrototype {
+			entry += " " + m.Value.String()
+		}
+		log = append(log, entry)
+		if veto && m.Key == nil {
+			return errors.New("vetoed")
+		}
+		return nil
+	})
+	_, err := r.RunString(`
+	var x = 1;
+	for (const f of [Object.freeze, Object.seal, Object.preventExtensions, o => Object.setPrototypeOf(o, null)]) {
+		try {
+			f(globalThis);
+			throw new Error("should have thrown");
+		} catch (e) {
+			if (!(e instanceof TypeError) || !e.message.endsWith("the global object: vetoed")) {
+				throw e;
+			}
+		}
+	}
+	if (!Object.isExtensible(globalThis) || Object.isFrozen(globalThis) || Object.getPrototypeOf(globalThis) === null) {
+		throw new Error("the global object has been modified");
+	}
+	if (Reflect.preventExtensions(globalThis) || Reflect.setPrototypeOf(globalThis, null)) {
+		throw new Error("Reflect did not fail");
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"preventExtensions", "preventExtensions", "preventExtensions", "setPrototype null", "preventExtensions", "setPrototype null"}
+	if strings.Join(log[3:], ",") != strings.Join(expected, ",") {
+		t.Fatal(log)
+	}
+
+	veto = false
+	log = nil
+	_, err = r.RunString(`
+	Object.freeze(globalThis);
+	if (!Object.isFrozen(globalThis) || Object.getOwnPropertyDescriptor(globalThis, "x").writable) {
+		throw new Error("not frozen");
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) < 3 || log[0] != "preventExtensions" {
+		t.Fatal(log)
+	}
+	found := false
+	for _, entry := range log[1:] {
+		if entry == "define x" {
+			found = true
+		} else if !strings.HasPrefix(entry, "define ") {
+			t.Fatal(log)
+		}
+	}
+	if !found {
+		t.Fatal(log)
+	}
+}
+
+func TestSetStrictBlockFunctions(t *testing.T) {
+	const SCRIPT = `
+	{
+		function f() {}
+	}
+	var inFunc = (function() {
+		if (true) {
+			function g() {}
+		}
+		return typeof g;
+	})();
+	var inFuncEval = (function() {
+		eval("{ function e() {} }");
+		return typeof e;
+	})();
+	[typeof f, inFunc, eval("{ function h() {} } typeof h"), new Function("{ function k() {} } return typeof k")(), inFuncEval].join();
+	`
+	r := New()
+	v, err := r.RunString(SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "function,function,function,function,function" {
+		t.Fatal(s)
+	}
+
+	r = New()
+	r.SetStrictBlockFunctions(true)
+	v, err = r.RunString(SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "undefined,undefined,undefined,undefined,undefined" {
+		t.Fatal(s)
+	}
+}
@@ -0,0 +1,305 @@
+package goja
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/dop251/goja/parser"
+	"github.com/dop251/goja/unistring"
+)
+
+// StateChangeKind is the kind of a modification reported by StateSnapshot.Changes().
+type StateChangeKind int
+
+const (
+	// PropertyAdded is a new own property of an object.
+	PropertyAdded StateChangeKind = iota
+	// PropertyChanged is a modification of the value or the attributes of an own property.
+	PropertyChanged
+	// PropertyDeleted is a deletion of an own property.
+	PropertyDeleted
+	// PrototypeChanged is a change of the prototype of an object.
+	PrototypeChanged
+	// ExtensibilityChanged means that an object was made non-extensible, e.g. by Object.freeze().
+	ExtensibilityChanged
+	// EntriesChanged is a change of the number of entries of a Map or a Set.
+	EntriesChanged
+	// BindingAdded is a new global lexical (let, const or class) declaration.
+	BindingAdded
+	// BindingChanged is an assignment to a global lexical binding.
+	BindingChanged
+)
+
+func (k StateChangeKind) String() string {
+	switch k {
+	case PropertyAdded:
+		return "property added"
+	case PropertyChanged:
+		return "property changed"
+	case PropertyDeleted:
+		return "property deleted"
+	case PrototypeChanged:
+		return "prototype changed"
+	case ExtensibilityChanged:
+		return "extensibility changed"
+	case EntriesChanged:
+		return "entries changed"
+	case BindingAdded:
+		return "binding added"
+	case BindingChanged:
+		return "binding changed"
+	}
+	return "unknown"
+}
+
+// StateChange is a modification of the Runtime state detected by StateSnapshot.Changes().
+type StateChange struct {
+	Kind StateChangeKind
+	// Path is the path to the object or the property from the global object, e.g. "Array.prototype.flatten",
+	// "config.retries" or "globalThis.leaked". If the object is reachable in several ways the shortest path
+	// is used. For the bindings it is the name of the binding.
+	Path string
+}
+
+func (c StateChange) String() string {
+	return c.Kind.String() + ": " + c.Path
+}
+
+type objectSnapshot struct {
+	obj        *Object
+	path       string
+	proto      *Object
+	extensible bool
+	keys       []Value
+	props      map[interface{}]valueProperty
+	entries    int
+}
+
+// StateSnapshot is the state of a Runtime recorded by Runtime.SnapshotState().
+type StateSnapshot struct {
+	r       *Runtime
+	objects []*objectSnapshot
+	lexical map[unistring.String]Value
+}
+
+// SnapshotState records the state of the Runtime which can be shared between the executions: the global
+// variables and all objects reachable from them through own data properties and prototypes, including the
+// built-in constructors and prototypes. Calling Changes() on the snapshot later reports everything that has been
+// modified since, so a script can be checked for side effects before a Runtime is reused for different tenants
+// or requests:
+//
+//	snap := vm.SnapshotState()
+//	_, err := vm.RunProgram(prg)
+//	for _, change := range snap.Changes() {
+//	    log.Printf("%s leaks state: %s", name, change)
+//	}
+//
+// This is a diagnostic tool: taking a snapshot and comparing it is proportional to the size of the reachable
+// state, which includes the elements of the arrays and typed arrays. Proxies are not traversed (so that no traps
+// are called), the accessor properties are compared but their functions are not traversed, the state only
+// reachable through closures is not visible and the contents of Maps and Sets are only compared by size.
+// Pending timers and other asynchronous work are managed by the host and are not covered either.
+func (r *Runtime) SnapshotState() *StateSnapshot {
+	s := &StateSnapshot{
+		r:       r,
+		lexical: make(map[unistring.String]Value, len(r.global.stash.names)),
+	}
+	visited := map[*Object]bool{r.globalObject: true}
+	queue := []*objectSnapshot{{obj: r.globalObject, path: "globalThis"}}
+	enqueue := func(obj *Object, path string) {
+		if obj != nil && !visited[obj] {
+			visited[obj] = true
+			if _, isProxy := obj.self.(*proxyObject); !isProxy {
+				queue = append(queue, &objectSnapshot{obj: obj, path: path})
+			}
+		}
+	}
+	var names []string
+	for name, idx := range r.global.stash.names {
+		s.lexical[name] = r.global.stash.values[idx&^maskTyp]
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if obj, ok := s.lexical[unistring.String(name)].(*Object); ok {
+			enqueue(obj, name)
+		}
+	}
+	// the prototypes are only followed when the objects are not reachable through the properties, so that e.g.
+	// Array.prototype is not referred to as someArray.__proto__
+	var protos []*objectSnapshot
+	for len(queue) > 0 || len(protos) > 0 {
+		if len(queue) == 0 {
+			for _, o := range protos {
+				enqueue(o.proto, o.path+".__proto__")
+			}
+			protos = protos[:0]
+			continue
+		}
+		o := queue[0]
+		queue = queue[1:]
+		o.record()
+		s.objects = append(s.objects, o)
+		protos = append(protos, o)
+		for _, key := range o.keys {
+			if p := o.props[propKey(key)]; !p.accessor {
+				if obj, ok := p.value.(*Object); ok {
+					enqueue(obj, propertyPath(o.path, key))
+				}
+			}
+		}
+	}
+	return s
+}
+
+// propKey returns a comparable representation of a property key.
+func propKey(key Value) interface{} {
+	if sym, ok := key.(*Symbol); ok {
+		return sym
+	}
+	return key.string()
+}
+
+func (o *objectSnapshot) ownProp(key Value) (valueProperty, bool) {
+	var v Value
+	if sym, ok := key.(*Symbol); ok {
+		v = o.obj.self.getOwnPropSym(sym)
+	} else {
+		v = o.obj.self.getOwnPropStr(key.string())
+	}
+	if v == nil {
+		return valueProperty{}, false
+	}
+	if prop, ok := v.(*valueProperty); ok {
+		return *prop, true
+	}
+	return valueProperty{value: v, writable: true, configurable: true, enumerable: true}, true
+}
+
+func (o *objectSnapshot) ownKeys() []Value {
+	return o.obj.self.symbols(true, o.obj.self.stringKeys(true, nil))
+}
+
+func (o *objectSnapshot) entryCount() int {
+	switch self := o.obj.self.(type) {
+	case *mapObject:
+		return self.m.size
+	case *setObject:
+		return self.m.size
+	}
+	return 0
+}
+
+func (o *objectSnapshot) record() {
+	o.proto = o.obj.self.proto()
+	o.extensible = o.obj.self.isExtensible()
+	o.entries = o.entryCount()
+	o.keys = o.ownKeys()
+	o.props = make(map[interface{}]valueProperty, len(o.keys))
+	for _, key := range o.keys {
+		if prop, ok := o.ownProp(key); ok {
+			o.props[propKey(key)] = prop
+		}
+	}
+}
+
+func sameProp(p1, p2 *valueProperty) bool {
+	if p1.accessor != p2.accessor || p1.configurable != p2.configurable || p1.enumerable != p2.enumerable {
+		return false
+	}
+	if p1.accessor {
+		return p1.getterFunc == p2.getterFunc && p1.setterFunc == p2.setterFunc
+	}
+	return p1.writable == p2.writable && sameBinding(p1.value, p2.value)
+}
+
+func (o *objectSnapshot) compare(changes []StateChange) []StateChange {
+	if o.obj.self.proto() != o.proto {
+		changes = append(changes, StateChange{Kind: PrototypeChanged, Path: o.path})
+	}
+	if o.obj.self.isExtensible() != o.extensible {
+		changes = append(changes, StateChange{Kind: ExtensibilityChanged, Path: o.path})
+	}
+	if o.entryCount() != o.entries {
+		changes = append(changes, StateChange{Kind: EntriesChanged, Path: o.path})
+	}
+	seen := make(map[interface{}]bool, len(o.keys))
+	for _, key := range o.ownKeys() {
+		k := propKey(key)
+		seen[k] = true
+		prop, _ := o.ownProp(key)
+		if old, exists := o.props[k]; !exists {
+			changes = append(changes, StateChange{Kind: PropertyAdded, Path: o.propertyPath(key)})
+		} else if !sameProp(&old, &prop) {
+			changes = append(changes, StateChange{Kind: PropertyChanged, Path: o.propertyPath(key)})
+		}
+	}
+	for _, key := range o.keys {
+		if !seen[propKey(key)] {
+			changes = append(changes, StateChange{Kind: PropertyDeleted, Path: o.propertyPath(key)})
+		}
+	}
+	return changes
+}
+
+func (o *objectSnapshot) propertyPath(key Value) string {
+	if o.path == "globalThis" {
+		if _, isSym := key.(*Symbol); !isSym && parser.IsIdentifier(key.String()) {
+			return "globalThis." + key.String()
+		}
+	}
+	return propertyPath(o.path, key)
+}
+
+// propertyPath returns the path to a property of the object at the path. The properties of the global
+// object are referred to by their names.
+func propertyPath(path string, key Value) string {
+	if sym, ok := key.(*Symbol); ok {
+		return path + "[" + sym.descriptiveString().String() + "]"
+	}
+	name := key.String()
+	if parser.IsIdentifier(name) {
+		if path == "globalThis" {
+			return name
+		}
+		return path + "." + name
+	}
+	return path + "[" + strconv.Quote(name) + "]"
+}
+
+// Changes compares the current state of the Runtime with the snapshot and returns the modifications. The
+// property changes are reported in the order in which the objects were traversed, followed by the changes of
+// the global bindings sorted by name. An empty result means that no modifications were detected.
+func (s *StateSnapshot) Changes() []StateChange {
+	var changes []StateChange
+	for _, o := range s.objects {
+		changes = o.compare(changes)
+	}
+
+	r := s.r
+	var names []string
+	for name, idx := range r.global.stash.names {
+		v := r.global.stash.values[idx&^maskTyp]
+		if old, exists := s.lexical[name]; !exists {
+			names = append(names, string(name))
+		} else if !sameBinding(old, v) {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		kind := BindingChanged
+		if _, exists := s.lexical[unistring.String(name)]; !exists {
+			kind = BindingAdded
+		}
+		changes = append(changes, StateChange{Kind: kind, Path: name})
+	}
+	return changes
+}
+
+func sameBinding(v1, v2 Value) bool {
+	if v1 == nil || v2 == nil {
+		return v1 == v2
+	}
+	return v1.SameAs(v2)
+}
@@ -0,0 +1,67 @@
+package goja
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStateSnapshot(t *testing.T) {
+	r := New()
+	_, err := r.RunString(`
+	var config = {retries: 3, nested: {flag: true}};
+	let cache = new Map();
+	const limits = [1, 2];
+	function handler(x) { return x * 2; }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snap := r.SnapshotState()
+	if _, err := r.RunString("handler(21); var local = config.retries + limits[1];"); err != nil {
+		t.Fatal(err)
+	}
+	if changes := snap.Changes(); !reflect.DeepEqual(changes, []StateChange{{Kind: PropertyAdded, Path: "globalThis.local"}}) {
+		t.Fatal(changes)
+	}
+
+	snap = r.SnapshotState()
+	if changes := snap.Changes(); len(changes) != 0 {
+		t.Fatal("Unexpected changes", changes)
+	}
+	_, err = r.RunString(`
+	Array.prototype.sum = function() {};
+	config.nested.flag = false;
+	delete config.retries;
+	Object.preventExtensions(config);
+	cache.set("k", 1);
+	limits.push(3);
+	Object.setPrototypeOf(limits, null);
+	String.prototype[Symbol.for("x")] = 1;
+	globalThis["not an identifier"] = 1;
+	let newBinding;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"property added: globalThis[\"not an identifier\"]",
+		"entries changed: cache",
+		"prototype changed: limits",
+		"property added: limits[\"2\"]",
+		"property changed: limits.length",
+		"extensibility changed: config",
+		"property deleted: config.retries",
+		"property added: Array.prototype.sum",
+		"property added: String.prototype[Symbol(x)]",
+		"property changed: config.nested.flag",
+		"binding added: newBinding",
+	}
+	var actual []string
+	for _, change := range snap.Changes() {
+		actual = append(actual, change.String())
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Unexpected changes:\n%q\nexpected:\n%q", actual, expected)
+	}
+}
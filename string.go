@@ -24,21 +24,23 @@ var (
 	stringString      valueString = asciiString("string")
 	stringSymbol      valueString = asciiString("symbol")
 	stringNumber      valueString = asciiString("number")
+	stringBigInt      valueString = asciiString("bigint")
 	stringNaN         valueString = asciiString("NaN")
 	stringInfinity                = asciiString("Infinity")
 	stringNegInfinity             = asciiString("-Infinity")
 	stringBound_      valueString = asciiString("bound ")
 	stringEmpty       valueString = asciiString("")
 
-	stringError          valueString = asciiString("Error")
-	stringAggregateError valueString = asciiString("AggregateError")
-	stringTypeError      valueString = asciiString("TypeError")
-	stringReferenceError valueString = asciiString("ReferenceError")
-	stringSyntaxError    valueString = asciiString("SyntaxError")
-	stringRangeError     valueString = asciiString("RangeError")
-	stringEvalError      valueString = asciiString("EvalError")
-	stringURIError       valueString = asciiString("URIError")
-	stringGoError        valueString = asciiString("GoError")
+	stringError           valueString = asciiString("Error")
+	stringAggregateError  valueString = asciiString("AggregateError")
+	stringSuppressedError valueString = asciiString("SuppressedError")
+	stringTypeError       valueString = asciiString("TypeError")
+	stringReferenceError  valueString = asciiString("ReferenceError")
+	stringSyntaxError     valueString = asciiString("SyntaxError")
+	stringRangeError      valueString = asciiString("RangeError")
+	stringEvalError       valueString = asciiString("EvalError")
+	stringURIError        valueString = asciiString("URIError")
+	stringGoError         valueString = asciiString("GoError")
 
 	stringObjectNull      valueString = asciiString("[object Null]")
 	stringObjectUndefined valueString = asciiString("[object Undefined]")
@@ -324,6 +326,86 @@ func devirtualizeString(s valueString) (asciiString, unicodeString) {
 	}
 }
 
+// joinStrings concatenates the values separated by sep using a single allocation for the result. It returns nil
+// if any of the values is not a string primitive.
+func joinStrings(values []Value, sep valueString) valueString {
+	sepA, sepU := devirtualizeString(sep)
+	total := sep.length() * (len(values) - 1)
+	unicode := sepU != nil && len(values) > 1
+	for _, v := range values {
+		s, ok := v.(valueString)
+		if !ok {
+			return nil
+		}
+		if _, u := devirtualizeString(s); u != nil {
+			unicode = true
+		}
+		total += s.length()
+	}
+
+	if !unicode {
+		var b strings.Builder
+		b.Grow(total)
+		for i, v := range values {
+			if i > 0 {
+				b.WriteString(string(sepA))
+			}
+			a, _ := devirtualizeString(v.(valueString))
+			b.WriteString(string(a))
+		}
+		return asciiString(b.String())
+	}
+
+	buf := make([]uint16, 1, total+1)
+	buf[0] = unistring.BOM
+	for i, v := range values {
+		if i > 0 {
+			buf = appendUTF16(buf, sepA, sepU)
+		}
+		a, u := devirtualizeString(v.(valueString))
+		buf = appendUTF16(buf, a, u)
+	}
+	return unicodeString(buf)
+}
+
+func appendUTF16(buf []uint16, a asciiString, u unicodeString) []uint16 {
+	if u != nil {
+		return append(buf, u[1:]...)
+	}
+	for i := 0; i < len(a); i++ {
+		buf = append(buf, uint16(a[i]))
+	}
+	return buf
+}
+
 func unknownStringTypeErr(v Value) interface{} {
 	return newTypeError("Internal bug: unknown string type: %T", v)
 }
+
+// StringBytesUnsafe returns the contents of a primitive string value consisting only of ASCII characters as a
+// []byte which shares the memory with the string, i.e. without copying it. For any other value (including
+// non-ASCII strings, which are stored as UTF-16, and String objects) it returns false and the value has to be
+// converted as usual, e.g. using String().
+//
+// This is an opt-in optimisation for hosts that pass large strings produced by scripts directly to an io.Writer.
+// The returned slice is read-only: modifying it, even temporarily, breaks the immutability of strings, which
+// may affect any other value sharing the same storage, including values in other Runtimes and string constants
+// in compiled Programs. The slice stays valid for as long as it is referenced, regardless of what happens to
+// the Runtime, as the memory is kept alive by the garbage collector. Passing it to io.Writer.Write() is safe,
+// because Write is not allowed to modify or retain the slice.
+func StringBytesUnsafe(v Value) ([]byte, bool) {
+	var str string
+	switch v := v.(type) {
+	case asciiString:
+		str = string(v)
+	case *importedString:
+		v.ensureScanned()
+		if v.u != nil {
+			return nil, false
+		}
+		str = v.s
+	default:
+		return nil, false
+	}
+	return unsafeStringBytes(str), true
+}
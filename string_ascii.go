@@ -79,14 +79,35 @@ func isRangeErr(err error) bool {
 	return false
 }
 
+// isDecimalFloatString reports whether ss only contains characters allowed in a StrDecimalLiteral.
+// strconv.ParseFloat accepts Go syntax which is broader (underscores, hex floats, "inf").
+func isDecimalFloatString(ss string) bool {
+	for i := 0; i < len(ss); i++ {
+		switch c := ss[i]; {
+		case c >= '0' && c <= '9', c == '.', c == 'e', c == 'E', c == '+', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func (s asciiString) _toFloat() (float64, error) {
 	ss := strings.TrimSpace(string(s))
 	if ss == "" {
 		return 0, nil
 	}
-	if ss == "-0" {
+	switch ss {
+	case "-0":
 		var f float64
 		return -f, nil
+	case "Infinity", "+Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	}
+	if !isDecimalFloatString(ss) {
+		return 0, strconv.ErrSyntax
 	}
 	f, err := strconv.ParseFloat(ss, 64)
 	if isRangeErr(err) {
@@ -109,6 +130,12 @@ func (s asciiString) ToInteger() int64 {
 	if err != nil {
 		f, err := s._toFloat()
 		if err == nil {
+			switch {
+			case math.IsInf(f, 1):
+				return math.MaxInt64
+			case math.IsInf(f, -1):
+				return math.MinInt64
+			}
 			return int64(f)
 		}
 	}
@@ -205,6 +232,10 @@ func (s asciiString) Equals(other Value) bool {
 		return false
 	}
 
+	if o, ok := other.(*valueBigInt); ok {
+		return o.Equals(s)
+	}
+
 	if o, ok := other.(*Object); ok {
 		return s.Equals(o.toPrimitive())
 	}
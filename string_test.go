@@ -149,6 +149,37 @@ func TestImportedString(t *testing.T) {
 	}
 }
 
+func TestStringBytesUnsafe(t *testing.T) {
+	vm := New()
+	for _, tc := range []struct {
+		v        Value
+		expected string
+		ok       bool
+	}{
+		{asciiString("abc"), "abc", true},
+		{asciiString(""), "", true},
+		{vm.ToValue("imported"), "imported", true},
+		{vm.ToValue("é"), "", false},
+		{newStringValue("é"), "", false},
+		{vm.ToValue(42), "", false},
+		{vm.ToValue(asciiString("x")).ToObject(vm), "", false},
+	} {
+		b, ok := StringBytesUnsafe(tc.v)
+		if ok != tc.ok || string(b) != tc.expected {
+			t.Fatalf("%v: %q, %v", tc.v, b, ok)
+		}
+	}
+
+	v, err := vm.RunString(`"x".repeat(1000)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ok := StringBytesUnsafe(v)
+	if !ok || len(b) != 1000 || b[999] != 'x' {
+		t.Fatal(len(b), ok)
+	}
+}
+
 func BenchmarkASCIIConcat(b *testing.B) {
 	vm := New()
 
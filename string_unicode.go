@@ -243,6 +243,14 @@ func (b *valueStringBuilder) String() valueString {
 	return b.unicodeBuilder.String()
 }
 
+// Len returns the length of the string built so far in UTF-16 code units.
+func (b *valueStringBuilder) Len() int {
+	if b.ascii() {
+		return b.asciiBuilder.Len()
+	}
+	return len(b.unicodeBuilder.buf) - 1
+}
+
 func (b *valueStringBuilder) Grow(n int) {
 	if b.ascii() {
 		b.asciiBuilder.Grow(n)
@@ -367,6 +375,10 @@ func (s unicodeString) Equals(other Value) bool {
 		return true
 	}
 
+	if o, ok := other.(*valueBigInt); ok {
+		return o.Equals(s)
+	}
+
 	if o, ok := other.(*Object); ok {
 		return s.Equals(o.toPrimitive())
 	}
@@ -436,6 +448,41 @@ func (s unicodeString) substring(start, end int) valueString {
 	return asciiString(as)
 }
 
+// loneSurrogate returns the position of the first surrogate code unit which is not a part of
+// a surrogate pair, or -1 if there is none.
+func (s unicodeString) loneSurrogate(start int) int {
+	s1 := s[1:]
+	for i := start; i < len(s1); i++ {
+		c := rune(s1[i])
+		if isUTF16FirstSurrogate(c) {
+			if i+1 < len(s1) && isUTF16SecondSurrogate(rune(s1[i+1])) {
+				i++
+				continue
+			}
+			return i
+		}
+		if isUTF16SecondSurrogate(c) {
+			return i
+		}
+	}
+	return -1
+}
+
+// toWellFormed returns a copy of the string with all lone surrogates replaced with U+FFFD.
+func (s unicodeString) toWellFormed() unicodeString {
+	p := s.loneSurrogate(0)
+	if p == -1 {
+		return s
+	}
+	b := make(unicodeString, len(s))
+	copy(b, s)
+	for p != -1 {
+		b[p+1] = utf8.RuneError
+		p = s.loneSurrogate(p + 1)
+	}
+	return b
+}
+
 func (s unicodeString) String() string {
 	return string(utf16.Decode(s[1:]))
 }
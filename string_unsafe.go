@@ -0,0 +1,13 @@
+//go:build go1.20
+
+package goja
+
+import "unsafe"
+
+// unsafeStringBytes returns the bytes of the string without copying them. The slice must not be modified.
+func unsafeStringBytes(s string) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
@@ -0,0 +1,23 @@
+//go:build !go1.20
+// +build !go1.20
+
+package goja
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// unsafeStringBytes returns the bytes of the string without copying them. The slice must not be modified.
+// unsafe.StringData is not available before Go 1.20, so the headers are used instead.
+func unsafeStringBytes(s string) []byte {
+	var b []byte
+	if len(s) > 0 {
+		sh := (*reflect.StringHeader)(unsafe.Pointer(&s))
+		bh := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+		bh.Data = sh.Data
+		bh.Len = sh.Len
+		bh.Cap = sh.Len
+	}
+	return b
+}
@@ -0,0 +1,237 @@
+package goja
+
+import (
+	"github.com/dop251/goja/unistring"
+)
+
+const maxCloneDepth = 1000
+
+type cloneCtx struct {
+	r      *Runtime
+	memory map[*Object]*Object
+	depth  int
+}
+
+// StructuredClone creates a deep copy of v using the HTML structured clone algorithm, which is also available to
+// scripts as structuredClone(). Primitive values are returned as is. Plain objects, arrays, Maps, Sets, Dates,
+// RegExps, ArrayBuffers, typed arrays, DataViews, the Boolean, Number, BigInt and String wrapper objects and the
+// errors are copied, preserving circular and shared references. Only own enumerable string-keyed properties of
+// plain objects and arrays are copied (i.e. not symbols, accessors are copied as data properties), the prototypes
+// are not preserved. Wrapped Go maps are copied as plain objects.
+//
+// Functions, symbols, Proxies and other objects that cannot be copied (such as Promises or WeakMaps) cause an
+// error with the name "DataCloneError" to be thrown. It is returned as an *Exception.
+func (r *Runtime) StructuredClone(v Value) (ret Value, err error) {
+	err = r.runWrapped(func() {
+		ret = r.structuredClone(v, nil)
+	})
+	return
+}
+
+func (r *Runtime) newDataCloneError(format string, args ...interface{}) *Object {
+	e := r.newError(r.global.Error, format, args...).(*Object)
+	e.self._putProp("name", asciiString("DataCloneError"), true, false, true)
+	return e
+}
+
+func (r *Runtime) structuredClone(v Value, transfer []*arrayBufferObject) Value {
+	ctx := &cloneCtx{
+		r: r,
+	}
+	if len(transfer) > 0 {
+		ctx.memory = make(map[*Object]*Object, len(transfer))
+		for _, buf := range transfer {
+			if _, exists := ctx.memory[buf.val]; exists {
+				panic(r.newDataCloneError("ArrayBuffer is listed more than once in the transfer list"))
+			}
+			if buf.detached {
+				panic(r.newDataCloneError("An ArrayBuffer is detached and could not be cloned."))
+			}
+			ctx.memory[buf.val] = nil
+		}
+		for _, buf := range transfer {
+			// the data is shared while cloning so that views can be created over it, the source
+			// buffers are detached only once the cloning has succeeded
+			dst := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
+			dst.data = buf.data
+			ctx.memory[buf.val] = dst.val
+		}
+	}
+	res := ctx.clone(v)
+	for _, buf := range transfer {
+		dst := ctx.memory[buf.val].self.(*arrayBufferObject)
+		dst.pooled, dst.exposed = buf.pooled, buf.exposed
+		buf.detach()
+	}
+	return res
+}
+
+func (ctx *cloneCtx) clone(v Value) Value {
+	if obj, ok := v.(*Object); ok {
+		if res, exists := ctx.memory[obj]; exists {
+			return res
+		}
+		if ctx.depth >= maxCloneDepth {
+			panic(ctx.r.newError(ctx.r.global.RangeError, "Maximum nesting depth exceeded"))
+		}
+		ctx.depth++
+		res := ctx.cloneObject(obj)
+		ctx.depth--
+		return res
+	}
+	if _, ok := v.(*Symbol); ok {
+		panic(ctx.r.newDataCloneError("%s could not be cloned.", describeValue(v)))
+	}
+	return v
+}
+
+func (ctx *cloneCtx) remember(src, dst *Object) *Object {
+	if ctx.memory == nil {
+		ctx.memory = make(map[*Object]*Object)
+	}
+	ctx.memory[src] = dst
+	return dst
+}
+
+func (ctx *cloneCtx) cloneObject(o *Object) *Object {
+	r := ctx.r
+	switch obj := o.self.(type) {
+	case *primitiveValueObject:
+		if _, ok := obj.pValue.(*Symbol); !ok {
+			return ctx.remember(o, obj.pValue.ToObject(r))
+		}
+	case *stringObject:
+		return ctx.remember(o, o.toString().ToObject(r))
+	case *dateObject:
+		return ctx.remember(o, r.newDateObject(obj.time(), obj.isSet(), r.global.DatePrototype))
+	case *regexpObject:
+		res := r.newRegexpObject(r.global.RegExpPrototype)
+		res.source = obj.source
+		res.pattern = obj.pattern
+		return ctx.remember(o, res.val)
+	case *arrayBufferObject:
+		if obj.detached {
+			panic(r.newDataCloneError("An ArrayBuffer is detached and could not be cloned."))
+		}
+		res := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
+		res.data = append([]byte(nil), obj.data...)
+		return ctx.remember(o, res.val)
+	case *typedArrayObject:
+		obj.viewedArrayBuf.ensureNotDetached(true)
+		buf := ctx.clone(obj.viewedArrayBuf.val)
+		args := []Value{buf, intToValue(int64(obj.offset * obj.elemSize)), intToValue(int64(obj.length))}
+		return ctx.remember(o, r.toConstructor(obj.defaultCtor)(args, obj.defaultCtor))
+	case *dataViewObject:
+		obj.viewedArrayBuf.ensureNotDetached(true)
+		buf := ctx.clone(obj.viewedArrayBuf.val)
+		args := []Value{buf, intToValue(int64(obj.byteOffset)), intToValue(int64(obj.byteLen))}
+		return ctx.remember(o, r.toConstructor(r.global.DataView)(args, r.global.DataView))
+	case *mapObject:
+		res := r.toConstructor(r.global.Map)(nil, r.global.Map)
+		ctx.remember(o, res)
+		var entries []Value
+		iter := obj.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			entries = append(entries, entry.key, entry.value)
+		}
+		m := res.self.(*mapObject).m
+		for i := 0; i < len(entries); i += 2 {
+			m.set(ctx.clone(entries[i]), ctx.clone(entries[i+1]))
+		}
+		return res
+	case *setObject:
+		res := r.toConstructor(r.global.Set)(nil, r.global.Set)
+		ctx.remember(o, res)
+		var values []Value
+		iter := obj.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			values = append(values, entry.key)
+		}
+		m := res.self.(*setObject).m
+		for _, value := range values {
+			m.set(ctx.clone(value), nil)
+		}
+		return res
+	case *errorObject:
+		return ctx.cloneError(o)
+	case *arrayObject, *sparseArrayObject, *baseObject, *objectGoMapSimple, *objectGoMapReflect:
+		if _, ok := o.self.assertCallable(); ok {
+			break
+		}
+		var res *Object
+		if isArray(o) {
+			res = r.newArrayLength(toLength(o.self.getStr("length", nil)))
+		} else {
+			res = r.NewObject()
+		}
+		ctx.remember(o, res)
+		for _, key := range o.self.stringKeys(false, nil) {
+			name := key.string()
+			if !o.self.hasOwnPropertyStr(name) {
+				continue
+			}
+			createDataPropertyOrThrow(res, key, ctx.clone(nilSafe(o.self.getStr(name, nil))))
+		}
+		return res
+	}
+	panic(r.newDataCloneError("%s could not be cloned.", describeValue(o)))
+}
+
+func (ctx *cloneCtx) cloneError(o *Object) *Object {
+	r := ctx.r
+	var ctor *Object
+	switch nilSafe(o.self.getStr("name", nil)).String() {
+	case "EvalError":
+		ctor = r.global.EvalError
+	case "RangeError":
+		ctor = r.global.RangeError
+	case "ReferenceError":
+		ctor = r.global.ReferenceError
+	case "SyntaxError":
+		ctor = r.global.SyntaxError
+	case "TypeError":
+		ctor = r.global.TypeError
+	case "URIError":
+		ctor = r.global.URIError
+	default:
+		ctor = r.global.Error
+	}
+	var args []Value
+	if prop, ok := o.self.getOwnPropStr("message").(*valueProperty); ok && prop.accessor {
+		// only data properties are copied
+	} else if prop != nil {
+		args = []Value{nilSafe(o.self.getStr("message", nil)).toString()}
+	} else if msg := o.self.getOwnPropStr("message"); msg != nil {
+		args = []Value{msg.toString()}
+	}
+	res := r.toConstructor(ctor)(args, ctor)
+	ctx.remember(o, res)
+	if o.self.hasOwnPropertyStr("cause") {
+		res.self._putProp("cause", ctx.clone(nilSafe(o.self.getStr("cause", nil))), true, false, true)
+	}
+	if stack := o.self.getOwnPropStr(unistring.String(propNameStack)); stack != nil {
+		if _, ok := stack.(*valueProperty); !ok {
+			res.self._putProp(propNameStack, stack.toString(), true, false, true)
+		}
+	}
+	return res
+}
+
+func (r *Runtime) builtin_structuredClone(call FunctionCall) Value {
+	var transfer []*arrayBufferObject
+	if options, ok := call.Argument(1).(*Object); ok {
+		if list := options.self.getStr("transfer", nil); list != nil && list != _undefined {
+			for _, item := range r.iterableToList(list, nil) {
+				var buf *arrayBufferObject
+				if obj, ok := item.(*Object); ok {
+					buf, _ = obj.self.(*arrayBufferObject)
+				}
+				if buf == nil {
+					panic(r.newDataCloneError("Value not transferable"))
+				}
+				transfer = append(transfer, buf)
+			}
+		}
+	}
+	return r.structuredClone(call.Argument(0), transfer)
+}
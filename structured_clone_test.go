@@ -0,0 +1,116 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestStructuredClone(t *testing.T) {
+	const SCRIPT = `
+	var src = {
+		num: 1,
+		str: "s",
+		arr: [1, , 3],
+		date: new Date(1000),
+		re: /a+b/gi,
+		map: new Map([[1, {x: 1}]]),
+		set: new Set(["a", "b"]),
+		buf: new Uint8Array([1, 2, 3]),
+		boxed: new Number(42),
+		err: new RangeError("boom"),
+	};
+	src.err.cause = "why";
+	src.self = src;
+	src.shared = src.map.get(1);
+	var c = structuredClone(src);
+
+	assert(c !== src, "copy");
+	assert.sameValue(c.self, c, "circular reference");
+	assert.sameValue(c.shared, c.map.get(1), "shared reference");
+	assert(c.shared !== src.shared, "deep copy");
+	assert.sameValue(c.num, 1);
+	assert.sameValue(c.str, "s");
+	assert(Array.isArray(c.arr), "array");
+	assert.sameValue(c.arr.length, 3);
+	assert.sameValue(1 in c.arr, false, "hole");
+	assert(c.date instanceof Date, "date");
+	assert.sameValue(c.date.getTime(), 1000);
+	assert(c.re instanceof RegExp, "regexp");
+	assert.sameValue(c.re.source, "a+b");
+	assert.sameValue(c.re.flags, "gi");
+	assert(c.map instanceof Map, "map");
+	assert.sameValue(c.map.get(1).x, 1);
+	assert(c.set instanceof Set, "set");
+	assert.sameValue([...c.set].join(), "a,b");
+	assert(c.buf instanceof Uint8Array, "typed array");
+	assert(c.buf.buffer !== src.buf.buffer, "buffer");
+	assert.sameValue(c.buf.join(), "1,2,3");
+	assert.sameValue(typeof c.boxed, "object");
+	assert.sameValue(c.boxed.valueOf(), 42);
+	assert(c.err instanceof RangeError, "error");
+	assert.sameValue(c.err.message, "boom");
+	assert.sameValue(c.err.cause, "why");
+
+	function checkDataCloneError(v, message) {
+		try {
+			structuredClone(v);
+		} catch (e) {
+			assert.sameValue(e.name, "DataCloneError");
+			if (message !== undefined) {
+				assert.sameValue(e.message, message);
+			}
+			return;
+		}
+		throw new Test262Error("Expected DataCloneError for " + String(v));
+	}
+	checkDataCloneError(function() {});
+	checkDataCloneError({f: function() {}});
+	checkDataCloneError(Symbol("s"), "Symbol(s) could not be cloned.");
+	checkDataCloneError(Symbol(), "Symbol() could not be cloned.");
+	checkDataCloneError(new WeakMap(), "#<WeakMap> could not be cloned.");
+	checkDataCloneError(new Proxy({}, {}));
+
+	var ab = new ArrayBuffer(4);
+	new Uint8Array(ab)[0] = 7;
+	var moved = structuredClone(ab, {transfer: [ab]});
+	assert.sameValue(ab.byteLength, 0, "detached");
+	assert.sameValue(new Uint8Array(moved)[0], 7, "transferred");
+
+	var view = new Uint8Array([1, 2, 3, 4]).subarray(1);
+	var res = structuredClone({view: view}, {transfer: [view.buffer]});
+	assert.sameValue(view.buffer.byteLength, 0, "view buffer detached");
+	assert.sameValue(res.view.byteOffset, 1, "byteOffset");
+	assert.sameValue(res.view.join(), "2,3,4", "view over transferred buffer");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestStructuredCloneGo(t *testing.T) {
+	vm := New()
+	v, err := vm.RunString(`({a: [1, {b: 2}]})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := vm.StructuredClone(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == v {
+		t.Fatal("Expected a copy")
+	}
+	vm.Set("orig", v)
+	vm.Set("copy", c)
+	res, err := vm.RunString(`copy.a[1].b === 2 && copy.a[1] !== orig.a[1]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.ToBoolean() {
+		t.Fatal("Unexpected copy")
+	}
+
+	_, err = vm.StructuredClone(vm.ToValue(func() {}))
+	if ex, ok := err.(*Exception); !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if name := ex.Value().ToObject(vm).Get("name").String(); name != "DataCloneError" {
+		t.Fatalf("Unexpected error name: %s", name)
+	}
+}
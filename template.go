@@ -0,0 +1,166 @@
+package goja
+
+import (
+	"io"
+)
+
+// TaggedTemplate holds the parts of a tagged template literal evaluation, as received by a tag function created
+// with Runtime.NewTaggedTemplateCollector() or Runtime.NewTaggedTemplateTag().
+//
+// The literal parts of the template come from the source code and cannot be influenced by the values of the
+// substitutions, which makes it possible to build injection-safe APIs, e.g. to turn
+//
+//	sql`SELECT * FROM users WHERE name = ${name}`
+//
+// into a parametrised query "SELECT * FROM users WHERE name = ?" with name as the argument.
+type TaggedTemplate struct {
+	// Strings contains the cooked literal parts, there is always one more of them than Values. If a part
+	// contains an invalid escape sequence (which is allowed in tagged templates) its cooked value is empty.
+	Strings []string
+	// Raw contains the literal parts as they appear in the source code.
+	Raw []string
+	// Values contains the substitution values.
+	Values []Value
+	// Site identifies the template literal in the source code. It's the same for all evaluations of the
+	// same literal, including the ones in different Runtimes running the same Program, so it can be used
+	// as a cache key, e.g. for prepared statements.
+	Site TemplateSite
+}
+
+// TemplateSite is a comparable identifier of a template literal in the source code. See TaggedTemplate.
+type TemplateSite struct {
+	id *[]Value
+}
+
+// NewTaggedTemplateCollector creates a tag function which returns a *TaggedTemplate wrapped with ToValue().
+// Host functions can accept it as an argument to receive the literal parts and the substitution values
+// separately:
+//
+//	vm.Set("sql", vm.NewTaggedTemplateCollector())
+//	vm.Set("query", func(t *goja.TaggedTemplate) ([]Row, error) {
+//		q := strings.Join(t.Strings, "?")
+//		args := make([]interface{}, len(t.Values))
+//		for i, v := range t.Values {
+//			args[i] = v.Export()
+//		}
+//		return runQuery(q, args...)
+//	})
+//
+//	const rows = query(sql`SELECT * FROM users WHERE name = ${name}`);
+//
+// The returned function throws a TypeError if it's not called as a template tag, so the literal parts can only
+// come from a template literal in the source code.
+func (r *Runtime) NewTaggedTemplateCollector() *Object {
+	return r.NewTaggedTemplateTag(func(t *TaggedTemplate) Value {
+		return r.ToValue(t)
+	})
+}
+
+// NewTaggedTemplateTag creates a tag function which calls fn with the parts of the template and returns its
+// result. It can be used to implement tags that produce a value directly, such as an "html" tag that escapes
+// the substitutions. As with NewTaggedTemplateCollector(), a TypeError is thrown if the function is not called
+// as a template tag.
+func (r *Runtime) NewTaggedTemplateTag(fn func(t *TaggedTemplate) Value) *Object {
+	return r.newNativeFunc(func(call FunctionCall) Value {
+		return fn(r.toTaggedTemplate(call.Arguments))
+	}, nil, "", nil, 1)
+}
+
+func (r *Runtime) toTaggedTemplate(args []Value) *TaggedTemplate {
+	var cooked *taggedTemplateArray
+	if len(args) > 0 {
+		if obj, ok := args[0].(*Object); ok {
+			cooked, _ = obj.self.(*taggedTemplateArray)
+		}
+	}
+	if cooked == nil {
+		panic(r.NewTypeError("The function must be used as a template literal tag"))
+	}
+	raw, _ := cooked.getStr("raw", nil).(*Object).self.(*taggedTemplateArray)
+	n := int(cooked.length)
+	t := &TaggedTemplate{
+		Strings: make([]string, n),
+		Raw:     make([]string, n),
+		Values:  make([]Value, 0, n-1),
+		Site:    TemplateSite{id: cooked.idPtr},
+	}
+	for i := 0; i < n; i++ {
+		idx := valueInt(i)
+		if s, ok := cooked.getIdx(idx, nil).(valueString); ok {
+			t.Strings[i] = s.String()
+		}
+		t.Raw[i] = raw.getIdx(idx, nil).String()
+	}
+	for i := 1; i < n; i++ {
+		if i < len(args) {
+			t.Values = append(t.Values, args[i])
+		} else {
+			t.Values = append(t.Values, _undefined)
+		}
+	}
+	return t
+}
+
+// NewTemplateWriter creates a function which writes its output to w piece by piece instead of returning a string.
+// It's meant for rendering large documents from templates without building the whole result in memory. Used as
+// a template tag it writes the literal parts and the substitutions in order:
+//
+//	vm.Set("emit", vm.NewTemplateWriter(w))
+//
+//	emit`<h1>${title}</h1><ul>`;
+//	for (const item of items) {
+//		emit`<li>${item.name}</li>`;
+//	}
+//	emit`</ul>`;
+//
+// Called as a regular function it writes each of its arguments. The substitutions and the arguments are converted
+// to strings as in template literals, except for functions which are called without arguments and their result
+// is written unless it's undefined. This allows nested sections to stream their output in place:
+//
+//	emit`<ul>${() => items.forEach(item => emit`<li>${item.name}</li>`)}</ul>`;
+//
+// The function returns undefined. Errors returned by w are thrown as GoError. Note that each piece results in
+// a separate Write() call, so w should normally be buffered.
+func (r *Runtime) NewTemplateWriter(w io.Writer) *Object {
+	write := func(v Value) {
+		if obj, ok := v.(*Object); ok {
+			if fn, ok := obj.self.assertCallable(); ok {
+				v = fn(FunctionCall{This: _undefined})
+				if v == _undefined {
+					return
+				}
+			}
+		}
+		s := v.toString().String()
+		if s == "" {
+			return
+		}
+		if _, err := io.WriteString(w, s); err != nil {
+			r.throwGoFuncError(err)
+		}
+	}
+	return r.newNativeFunc(func(call FunctionCall) Value {
+		var cooked *taggedTemplateArray
+		if len(call.Arguments) > 0 {
+			if obj, ok := call.Arguments[0].(*Object); ok {
+				cooked, _ = obj.self.(*taggedTemplateArray)
+			}
+		}
+		if cooked == nil {
+			for _, arg := range call.Arguments {
+				write(arg)
+			}
+			return _undefined
+		}
+		n := int(cooked.length)
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				write(call.Argument(i))
+			}
+			if part, ok := cooked.getIdx(valueInt(i), nil).(valueString); ok {
+				write(part)
+			}
+		}
+		return _undefined
+	}, nil, "", nil, 1)
+}
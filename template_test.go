@@ -0,0 +1,122 @@
+package goja
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTaggedTemplateCollector(t *testing.T) {
+	vm := New()
+	var sites []TemplateSite
+	var queries []string
+	vm.Set("sql", vm.NewTaggedTemplateCollector())
+	vm.Set("query", func(t *TaggedTemplate) string {
+		sites = append(sites, t.Site)
+		q := strings.Join(t.Strings, "?")
+		for _, v := range t.Values {
+			q += "|" + v.String()
+		}
+		queries = append(queries, q)
+		return q
+	})
+	_, err := vm.RunString(`
+	function find(name) {
+		return query(sql` + "`SELECT * FROM users WHERE name = ${name} AND x = ${1}`" + `);
+	}
+	find("a");
+	find("'; DROP TABLE users; --");
+	query(sql` + "`SELECT 1`" + `);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"SELECT * FROM users WHERE name = ? AND x = ?|a|1",
+		"SELECT * FROM users WHERE name = ? AND x = ?|'; DROP TABLE users; --|1",
+		"SELECT 1",
+	}
+	if len(queries) != len(expected) {
+		t.Fatal(queries)
+	}
+	for i, q := range queries {
+		if q != expected[i] {
+			t.Fatalf("%d: %q", i, q)
+		}
+	}
+	if sites[0] != sites[1] || sites[0] == sites[2] {
+		t.Fatal("site identity")
+	}
+
+	_, err = vm.RunString("sql(['SELECT ', ''], 1)")
+	if ex, ok := err.(*Exception); !ok || !strings.Contains(ex.Error(), "TypeError") {
+		t.Fatal(err)
+	}
+}
+
+func TestTaggedTemplateTag(t *testing.T) {
+	vm := New()
+	vm.Set("upper", vm.NewTaggedTemplateTag(func(t *TaggedTemplate) Value {
+		var sb strings.Builder
+		for i, s := range t.Strings {
+			sb.WriteString(s + "[" + t.Raw[i] + "]")
+			if i < len(t.Values) {
+				sb.WriteString(strings.ToUpper(t.Values[i].String()))
+			}
+		}
+		return vm.ToValue(sb.String())
+	}))
+	v, err := vm.RunString("upper`a\\n${'b'}\\u{g}`")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "a\n[a\\n]B[\\u{g}]" {
+		t.Fatalf("%q", s)
+	}
+}
+
+type chunkWriter struct {
+	chunks []string
+	fail   bool
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("disk full")
+	}
+	w.chunks = append(w.chunks, string(p))
+	return len(p), nil
+}
+
+func TestTemplateWriter(t *testing.T) {
+	vm := New()
+	w := &chunkWriter{}
+	vm.Set("emit", vm.NewTemplateWriter(w))
+	_, err := vm.RunString(`
+	const items = [{name: "a"}, {name: "б"}];
+	emit` + "`<h1>${'Title'}</h1><ul>${() => items.forEach(item => emit`<li>${item.name}</li>`)}</ul>`" + `;
+	emit(1, () => 2, () => {}, "");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"<h1>", "Title", "</h1><ul>", "<li>", "a", "</li>", "<li>", "б", "</li>", "</ul>", "1", "2"}
+	if !reflect.DeepEqual(w.chunks, expected) {
+		t.Fatalf("Unexpected chunks: %q", w.chunks)
+	}
+
+	w.fail = true
+	_, err = vm.RunString(`
+	try {
+		emit("x");
+	} catch (e) {
+		if (!(e instanceof GoError) || e.message !== "disk full") {
+			throw e;
+		}
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
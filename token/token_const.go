@@ -75,6 +75,10 @@ const (
 	ELLIPSIS          // ...
 	BACKTICK          // `
 
+	// Declaration kinds which are only used in the AST (see ast.LexicalDeclaration), "using" is not a keyword
+	USING       // using
+	AWAIT_USING // await using
+
 	PRIVATE_IDENTIFIER
 
 	// tokens below (and only them) are syntactically valid identifiers
@@ -199,6 +203,8 @@ var token2string = [...]string{
 	ARROW:                       "=>",
 	ELLIPSIS:                    "...",
 	BACKTICK:                    "`",
+	USING:                       "using",
+	AWAIT_USING:                 "await using",
 	IF:                          "if",
 	IN:                          "in",
 	OF:                          "of",
@@ -0,0 +1,36 @@
+package goja
+
+import (
+	"reflect"
+)
+
+// TypeBinding contains static conversion functions for a Go type, which Runtime.ToValue() and Runtime.ExportTo()
+// use instead of reflection. Bindings are normally generated by the gojabind tool (see
+// github.com/dop251/goja/gojabind), but can also be written by hand.
+//
+// Unlike the reflection-based wrappers, which are live views of the Go values, the objects created by a binding
+// are usually plain JavaScript objects containing copies of the values.
+type TypeBinding struct {
+	// ToValue converts a value of the type (passed as interface{}) into a JavaScript value. If nil, the default
+	// conversion is used.
+	ToValue func(r *Runtime, v interface{}) Value
+
+	// ExportTo converts a JavaScript value into the type, dst is a non-nil pointer to a value of the type. If nil,
+	// the default conversion is used.
+	ExportTo func(r *Runtime, v Value, dst interface{}) error
+}
+
+// RegisterBinding registers static conversion functions for the Go type typ. The binding is used by ToValue() for
+// values of exactly that type (i.e. a binding for a struct type is not used for pointers to it) and by ExportTo()
+// when exporting into that type, including when it's nested in a type which is exported using reflection (such as
+// a slice of the type). Passing a zero TypeBinding removes the binding.
+func (r *Runtime) RegisterBinding(typ reflect.Type, b TypeBinding) {
+	if b.ToValue == nil && b.ExportTo == nil {
+		delete(r.typeBindings, typ)
+		return
+	}
+	if r.typeBindings == nil {
+		r.typeBindings = make(map[reflect.Type]*TypeBinding)
+	}
+	r.typeBindings[typ] = &b
+}
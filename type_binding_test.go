@@ -0,0 +1,65 @@
+package goja
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testBindingPoint struct {
+	X, Y int
+}
+
+func TestRegisterBinding(t *testing.T) {
+	vm := New()
+	vm.RegisterBinding(reflect.TypeOf(testBindingPoint{}), TypeBinding{
+		ToValue: func(r *Runtime, v interface{}) Value {
+			p := v.(testBindingPoint)
+			return r.NewArray(p.X, p.Y)
+		},
+		ExportTo: func(r *Runtime, v Value, dst interface{}) error {
+			o, ok := v.(*Object)
+			if !ok {
+				return errors.New("not an object")
+			}
+			p := dst.(*testBindingPoint)
+			p.X, p.Y = int(o.Get("0").ToInteger()), int(o.Get("1").ToInteger())
+			return nil
+		},
+	})
+	vm.Set("p", testBindingPoint{X: 1, Y: 2})
+	res, err := vm.RunString(`Array.isArray(p) && p[0] === 1 && p[1] === 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != valueTrue {
+		t.Fatal("the binding has not been used by ToValue()")
+	}
+
+	v, err := vm.RunString(`[[3, 4], [5, 6]]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var points []testBindingPoint
+	if err := vm.ExportTo(v, &points); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(points, []testBindingPoint{{3, 4}, {5, 6}}) {
+		t.Fatalf("unexpected result: %v", points)
+	}
+	var p testBindingPoint
+	if err := vm.ExportTo(valueInt(1), &p); err == nil || err.Error() != "not an object" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	vm.RegisterBinding(reflect.TypeOf(testBindingPoint{}), TypeBinding{})
+	if err := vm.ExportTo(v.(*Object).Get("0"), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.X != 0 {
+		t.Fatal("the binding has not been removed")
+	}
+	if _, ok := vm.ToValue(p).(*Object).self.(*objectGoReflect); !ok {
+		t.Fatal("the binding has not been removed")
+	}
+}
@@ -2,8 +2,10 @@ package goja
 
 import (
 	"math"
+	"math/bits"
 	"reflect"
 	"strconv"
+	"sync"
 	"unsafe"
 
 	"github.com/dop251/goja/unistring"
@@ -28,6 +30,8 @@ type arrayBufferObject struct {
 	data []byte
 	baseObject
 	detached bool
+	pooled   bool // data was obtained from the Runtime's ArrayBufferPool
+	exposed  bool // data has been returned by ArrayBuffer.Bytes(), so it is not returned to the pool by the built-ins
 }
 
 // ArrayBuffer is a Go wrapper around ECMAScript ArrayBuffer. Calling Runtime.ToValue() on it
@@ -62,6 +66,7 @@ type uint16Array []uint16
 type int16Array []int16
 type uint32Array []uint32
 type int32Array []int32
+type float16Array []uint16
 type float32Array []float32
 type float64Array []float64
 
@@ -88,7 +93,10 @@ func (a ArrayBuffer) toValue(r *Runtime) Value {
 
 // Bytes returns the underlying []byte for this ArrayBuffer.
 // For detached ArrayBuffers returns nil.
+// If the storage was allocated from the ArrayBufferPool of the Runtime, it is no longer returned to the pool when
+// the ArrayBuffer is detached by the script (e.g. by transfer()), only when Release() is called.
 func (a ArrayBuffer) Bytes() []byte {
+	a.buf.exposed = true
 	return a.buf.data
 }
 
@@ -110,6 +118,70 @@ func (a ArrayBuffer) Detached() bool {
 	return a.buf.detached
 }
 
+// Release detaches the ArrayBuffer and, if its storage was allocated from the ArrayBufferPool of the Runtime,
+// returns it to the pool. Unlike with Detach(), any slice previously returned by Bytes() must not be used
+// after this call.
+// Returns false if it was already detached, true otherwise.
+// Note, this method may only be called from the goroutine that 'owns' the Runtime, it may not
+// be called concurrently.
+func (a ArrayBuffer) Release() bool {
+	if a.buf.detached {
+		return false
+	}
+	a.buf.exposed = false
+	a.buf.release()
+	return true
+}
+
+// ArrayBufferPool provides storage for ArrayBuffers, see Runtime.SetArrayBufferPool().
+type ArrayBufferPool interface {
+	// Get returns a slice of the specified length. Its contents does not have to be zeroed.
+	Get(size int) []byte
+	// Put returns a slice obtained from Get() which is no longer referenced by the Runtime.
+	// Note that the length of the slice may be different from the one requested originally.
+	Put(b []byte)
+}
+
+const maxPooledArrayBufferClass = 24 // 16MiB
+
+type arrayBufferPool struct {
+	classes [maxPooledArrayBufferClass + 1]sync.Pool
+}
+
+// NewArrayBufferPool returns an ArrayBufferPool backed by a set of sync.Pool, one for each power-of-two size
+// class up to 16MiB. Larger buffers are not pooled. The returned pool is safe for concurrent use.
+func NewArrayBufferPool() ArrayBufferPool {
+	return &arrayBufferPool{}
+}
+
+func (p *arrayBufferPool) Get(size int) []byte {
+	if size <= 0 {
+		return []byte{}
+	}
+	class := bits.Len(uint(size - 1))
+	if class > maxPooledArrayBufferClass {
+		return allocByteSlice(size)
+	}
+	if b, ok := p.classes[class].Get().(*[]byte); ok {
+		return (*b)[:size]
+	}
+	return make([]byte, size, 1<<class)
+}
+
+func (p *arrayBufferPool) Put(b []byte) {
+	c := cap(b)
+	if c == 0 {
+		return
+	}
+	// the largest class which is guaranteed to fit
+	class := bits.Len(uint(c)) - 1
+	if class > maxPooledArrayBufferClass {
+		return
+	}
+	b = b[:0]
+	p.classes[class].Put(&b)
+}
+
 func (r *Runtime) NewArrayBuffer(data []byte) ArrayBuffer {
 	buf := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
 	buf.data = data
@@ -363,6 +435,44 @@ func (a *int32Array) typeMatch(v Value) bool {
 	return false
 }
 
+func (a *float16Array) get(idx int) Value {
+	return floatToValue(float16ToFloat64((*a)[idx]))
+}
+
+func (a *float16Array) getRaw(idx int) uint64 {
+	return uint64((*a)[idx])
+}
+
+func (a *float16Array) set(idx int, value Value) {
+	(*a)[idx] = toFloat16(value)
+}
+
+func (a *float16Array) toRaw(v Value) uint64 {
+	return uint64(toFloat16(v))
+}
+
+func (a *float16Array) setRaw(idx int, v uint64) {
+	(*a)[idx] = uint16(v)
+}
+
+func (a *float16Array) less(i, j int) bool {
+	return typedFloatLess(float16ToFloat64((*a)[i]), float16ToFloat64((*a)[j]))
+}
+
+func (a *float16Array) swap(i, j int) {
+	(*a)[i], (*a)[j] = (*a)[j], (*a)[i]
+}
+
+func (a *float16Array) typeMatch(v Value) bool {
+	switch v.(type) {
+	case valueInt, valueFloat:
+		// only the values that are exactly representable can be found
+		f := v.ToFloat()
+		return math.IsNaN(f) || float16ToFloat64(float64ToFloat16(f)) == f
+	}
+	return false
+}
+
 func (a *float32Array) get(idx int) Value {
 	return floatToValue(float64((*a)[idx]))
 }
@@ -723,6 +833,10 @@ func (r *Runtime) newInt32ArrayObject(buf *arrayBufferObject, offset, length int
 	return r._newTypedArrayObject(buf, offset, length, 4, r.global.Int32Array, (*int32Array)(unsafe.Pointer(&buf.data)), proto)
 }
 
+func (r *Runtime) newFloat16ArrayObject(buf *arrayBufferObject, offset, length int, proto *Object) *typedArrayObject {
+	return r._newTypedArrayObject(buf, offset, length, 2, r.global.Float16Array, (*float16Array)(unsafe.Pointer(&buf.data)), proto)
+}
+
 func (r *Runtime) newFloat32ArrayObject(buf *arrayBufferObject, offset, length int, proto *Object) *typedArrayObject {
 	return r._newTypedArrayObject(buf, offset, length, 4, r.global.Float32Array, (*float32Array)(unsafe.Pointer(&buf.data)), proto)
 }
@@ -758,6 +872,14 @@ func (o *arrayBufferObject) ensureNotDetached(throw bool) bool {
 	return true
 }
 
+func (o *arrayBufferObject) getFloat16(idx int, byteOrder byteOrder) float64 {
+	return float16ToFloat64(o.getUint16(idx, byteOrder))
+}
+
+func (o *arrayBufferObject) setFloat16(idx int, val uint16, byteOrder byteOrder) {
+	o.setUint16(idx, val, byteOrder)
+}
+
 func (o *arrayBufferObject) getFloat32(idx int, byteOrder byteOrder) float32 {
 	return math.Float32frombits(o.getUint32(idx, byteOrder))
 }
@@ -875,9 +997,22 @@ func (o *arrayBufferObject) setInt8(idx int, val int8) {
 
 func (o *arrayBufferObject) detach() {
 	o.data = nil
+	o.pooled = false
+	o.exposed = false
 	o.detached = true
 }
 
+// release detaches the ArrayBuffer and returns its storage to the pool, unless the host may still be holding it
+// (see ArrayBuffer.Bytes()).
+func (o *arrayBufferObject) release() {
+	if o.pooled && !o.exposed {
+		if pool := o.val.runtime.arrayBufferPool; pool != nil {
+			pool.Put(o.data)
+		}
+	}
+	o.detach()
+}
+
 func (o *arrayBufferObject) exportType() reflect.Type {
 	return arrayBufferType
 }
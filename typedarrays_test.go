@@ -1,6 +1,9 @@
 package goja
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestUint16ArrayObject(t *testing.T) {
 	vm := New()
@@ -65,6 +68,146 @@ func TestArrayBufferGoWrapper(t *testing.T) {
 	}
 }
 
+func TestArrayBufferTransfer(t *testing.T) {
+	const SCRIPT = `
+	var buf = new Uint8Array([1, 2, 3, 4]).buffer;
+	var buf1 = buf.transfer();
+	assert(buf.detached, "buf.detached");
+	assert(!buf1.detached, "buf1.detached");
+	assert.sameValue(buf.byteLength, 0);
+	assert(compareArray(new Uint8Array(buf1), [1, 2, 3, 4]), "same length");
+	assert.throws(TypeError, function() { buf.transfer(); });
+
+	var buf2 = buf1.transfer(2);
+	assert(compareArray(new Uint8Array(buf2), [1, 2]), "shrink");
+	var buf3 = buf2.transferToFixedLength(6);
+	assert(compareArray(new Uint8Array(buf3), [1, 2, 0, 0, 0, 0]), "grow");
+	assert.sameValue(buf3.transfer(0).byteLength, 0);
+	assert.throws(RangeError, function() { new ArrayBuffer(1).transfer(-1); });
+	assert.throws(TypeError, function() { ArrayBuffer.prototype.transfer.call({}); });
+	assert.throws(TypeError, function() { ArrayBuffer.prototype.transferToFixedLength.call({}); });
+	assert.sameValue(ArrayBuffer.prototype.transfer.length, 0);
+	assert.sameValue(ArrayBuffer.prototype.transferToFixedLength.length, 0);
+
+	var desc = Object.getOwnPropertyDescriptor(ArrayBuffer.prototype, "detached");
+	assert.sameValue(desc.set, undefined, "detached setter");
+	assert.sameValue(desc.get.name, "get detached");
+	assert.throws(TypeError, function() { desc.get.call({}); });
+
+	var src = new ArrayBuffer(8);
+	var view = new Uint8Array(src, 2, 4);
+	var dv = new DataView(src);
+	src.transfer();
+	assert.sameValue(view.length, 0, "view.length");
+	assert.sameValue(view.byteOffset, 0, "view.byteOffset");
+	assert.throws(TypeError, function() { dv.byteLength; });
+	assert.throws(TypeError, function() { dv.getInt8(0); });
+	assert.throws(TypeError, function() { src.slice(0, 0); });
+	assert.throws(TypeError, function() { src.transferToFixedLength(); });
+	assert.throws(TypeError, function() { new DataView(src); });
+	assert.throws(TypeError, function() { new Uint8Array(src); });
+
+	var detaching = new ArrayBuffer(1);
+	assert.throws(TypeError, function() {
+		detaching.transfer({valueOf: function() { detaching.transfer(); return 1; }});
+	});
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestArrayBufferTransferGoSlice(t *testing.T) {
+	vm := New()
+	data := make([]byte, 2, 4)
+	data[0] = 1
+	backing := data[:4]
+	backing[2] = 0xFF
+	vm.Set("buf", vm.NewArrayBuffer(data))
+	v, err := vm.RunString(`new Uint8Array(buf.transfer(3)).join()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "1,0,0" {
+		t.Fatal(s)
+	}
+	if backing[2] != 0xFF {
+		t.Fatal("spare capacity of the host slice was modified")
+	}
+}
+
+type countingArrayBufferPool struct {
+	ArrayBufferPool
+	gets, puts int
+}
+
+func (p *countingArrayBufferPool) Get(size int) []byte {
+	p.gets++
+	b := p.ArrayBufferPool.Get(size)
+	for i := range b {
+		b[i] = 0xFF
+	}
+	return b
+}
+
+func (p *countingArrayBufferPool) Put(b []byte) {
+	p.puts++
+	p.ArrayBufferPool.Put(b)
+}
+
+func TestArrayBufferPool(t *testing.T) {
+	pool := &countingArrayBufferPool{ArrayBufferPool: NewArrayBufferPool()}
+	vm := New()
+	vm.SetArrayBufferPool(pool)
+	v, err := vm.RunString(`
+	var res = [];
+	for (var i = 0; i < 3; i++) {
+		var a = new Uint8Array(100);
+		res.push(a[99]);
+		a.buffer.transfer(200).transfer(0);
+	}
+	var b = new ArrayBuffer(10);
+	res.join();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "0,0,0" {
+		t.Fatal(s)
+	}
+	if pool.gets != 7 || pool.puts != 6 {
+		t.Fatalf("gets: %d, puts: %d", pool.gets, pool.puts)
+	}
+	buf := vm.Get("b").Export().(ArrayBuffer)
+	if !buf.Release() || !buf.Detached() || buf.Release() {
+		t.Fatal("Release()")
+	}
+	if pool.puts != 7 {
+		t.Fatal(pool.puts)
+	}
+
+	// the storage exposed through Bytes() is not returned to the pool when the buffer is transferred by the script
+	if _, err := vm.RunString("var c = new ArrayBuffer(100)"); err != nil {
+		t.Fatal(err)
+	}
+	vm.Get("c").Export().(ArrayBuffer).Bytes()
+	puts := pool.puts
+	if _, err := vm.RunString("c.transfer(50).transfer(1000); new ArrayBuffer(100).transfer(0)"); err != nil {
+		t.Fatal(err)
+	}
+	if pool.puts != puts+1 {
+		t.Fatalf("puts: %d, expected %d", pool.puts, puts+1)
+	}
+
+	p := NewArrayBufferPool()
+	b := p.Get(100)
+	if len(b) != 100 || cap(b) != 128 {
+		t.Fatal(len(b), cap(b))
+	}
+	p.Put(b[:10])
+	if b := p.Get(1 << 25); len(b) != 1<<25 {
+		t.Fatal(len(b))
+	}
+}
+
 func TestTypedArrayIdx(t *testing.T) {
 	const SCRIPT = `
 	var a = new Uint8Array(1);
@@ -340,3 +483,30 @@ func TestTypedArrayGetInvalidIndex(t *testing.T) {
 	`
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
+
+func TestFloat16Conversion(t *testing.T) {
+	for i := 0; i <= 0xffff; i++ {
+		h := uint16(i)
+		f := float16ToFloat64(h)
+		if math.IsNaN(f) {
+			if r := float64ToFloat16(f); r&0x7fff != 0x7e00 {
+				t.Fatalf("%#04x: unexpected NaN conversion %#04x", h, r)
+			}
+			continue
+		}
+		if r := float64ToFloat16(f); r != h {
+			t.Fatalf("%#04x (%v): round trip produced %#04x", h, f, r)
+		}
+		if h&0x7fff < 0x7c00 {
+			// the midpoint between this value and the next one must round to the even one
+			next := float16ToFloat64(h + 1)
+			expected := h
+			if h&1 != 0 {
+				expected = h + 1
+			}
+			if r := float64ToFloat16((f + next) / 2); r != expected {
+				t.Fatalf("%#04x: midpoint rounded to %#04x", h, r)
+			}
+		}
+	}
+}
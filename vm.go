@@ -3,11 +3,14 @@ package goja
 import (
 	"fmt"
 	"math"
+	"math/big"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf16"
 
 	"github.com/dop251/goja/unistring"
 )
@@ -146,7 +149,7 @@ type thisRef struct {
 func (r *thisRef) get() Value {
 	v := (*r.v)[r.idx]
 	if v == nil {
-		panic(referenceError("Must call super constructor in derived class before accessing 'this'"))
+		panic(referenceError("Must call super constructor in derived class before accessing 'this' or returning from derived constructor"))
 	}
 
 	return v
@@ -175,7 +178,7 @@ type stashRefLex struct {
 func (r *stashRefLex) get() Value {
 	v := (*r.v)[r.idx]
 	if v == nil {
-		panic(errAccessBeforeInit)
+		panic(errAccessBeforeInit(r.n.String()))
 	}
 	return v
 }
@@ -183,7 +186,7 @@ func (r *stashRefLex) get() Value {
 func (r *stashRefLex) set(v Value) {
 	p := &(*r.v)[r.idx]
 	if *p == nil {
-		panic(errAccessBeforeInit)
+		panic(errAccessBeforeInit(r.n.String()))
 	}
 	*p = v
 }
@@ -310,6 +313,7 @@ type vm struct {
 	prg              *Program
 	profTracker      *profTracker
 	curAsyncRunner   *asyncRunner
+	asyncParentStack []StackFrame
 	r                *Runtime
 	stash            *stash
 	privEnv          *privateEnv
@@ -320,7 +324,11 @@ type vm struct {
 	callStack        []context
 	sb               int
 	stashAllocs      int
+	steps            int64 // the number of instructions executed while countSteps is set, see ResourceUsage.Instructions
+	countSteps       bool  // the resource accounting is enabled, see Runtime.SetResourceAccounting()
 	maxCallStackSize int
+	stackTraceLimit  int
+	asyncStackLimit  int
 	args             int
 	sp               int
 	pc               int
@@ -331,6 +339,65 @@ type instruction interface {
 	exec(*vm)
 }
 
+// CustomInstruction is an extension point for embedders that need to perform very frequent host operations
+// (such as vector math) without the overhead of a function call. A CustomInstruction is not reachable from
+// the JavaScript syntax, instead it is emitted by the compiler for an ast.CustomInstructionExpression which an
+// embedder inserts into the AST returned by Parse() (for example by replacing calls of a reserved function name)
+// before compiling it with CompileAST():
+//
+//	type vecAdd struct{}
+//
+//	func (vecAdd) Exec(r *Runtime, operands []Value) Value {
+//		return r.ToValue(operands[0].ToFloat() + operands[1].ToFloat())
+//	}
+//
+//	// ...
+//	prg.Body[0].(*ast.ExpressionStatement).Expression = &ast.CustomInstructionExpression{
+//		Instruction:  vecAdd{},
+//		ArgumentList: []ast.Expression{x, y},
+//		From:         call.Idx0(),
+//		To:           call.Idx1(),
+//	}
+//
+// The arguments of the expression are evaluated from left to right and their results are left on the VM
+// operand stack. Exec receives these values as a slice referencing the stack directly (i.e. without copying)
+// which means it must not retain or modify the slice after it returns. The returned value (nil is treated
+// as undefined) replaces the operands on the stack and becomes the result of the expression.
+//
+// Exec may panic with a value returned by Runtime.NewTypeError(), Runtime.NewGoError() or any other *Object
+// to throw a JavaScript exception, the same way native functions do. It must not call back into the same
+// Runtime in a way that runs JavaScript code unless it is prepared to handle re-entrancy exactly as a native
+// function would.
+type CustomInstruction interface {
+	Exec(r *Runtime, operands []Value) Value
+}
+
+// Intrinsic is a Go function which calls can be compiled into a CustomInstruction, bypassing the generic
+// function call machinery: there is no FunctionCall, no 'this' value and no call stack frame. See WithIntrinsic.
+// The same rules as for CustomInstruction.Exec apply to args.
+type Intrinsic func(r *Runtime, args []Value) Value
+
+// Exec implements CustomInstruction.
+func (f Intrinsic) Exec(r *Runtime, args []Value) Value {
+	return f(r, args)
+}
+
+type customInstr struct {
+	instr CustomInstruction
+	nargs int
+}
+
+func (c *customInstr) exec(vm *vm) {
+	sp := vm.sp - c.nargs
+	res := c.instr.Exec(vm.r, vm.stack[sp:vm.sp])
+	if res == nil {
+		res = _undefined
+	}
+	vm.sp = sp
+	vm.push(res)
+	vm.pc++
+}
+
 func intToValue(i int64) Value {
 	if idx := 256 + i; idx >= 0 && idx < 256 {
 		return intCache[idx]
@@ -444,7 +511,7 @@ func (s *stash) getByName(name unistring.String) (v Value, exists bool) {
 		v := s.values[idx&^maskTyp]
 		if v == nil {
 			if idx&maskVar == 0 {
-				panic(errAccessBeforeInit)
+				panic(errAccessBeforeInit(name.String()))
 			} else {
 				v = _undefined
 			}
@@ -542,6 +609,7 @@ func (vm *vm) init() {
 	vm.sb = -1
 	vm.stash = &vm.r.global.stash
 	vm.maxCallStackSize = math.MaxInt32
+	vm.stackTraceLimit = math.MaxInt32
 }
 
 func (vm *vm) halted() bool {
@@ -550,6 +618,9 @@ func (vm *vm) halted() bool {
 }
 
 func (vm *vm) run() {
+	if d := vm.r.debugger; d != nil && d.enabled() && !vm.runWithDebugger() {
+		return
+	}
 	if vm.profTracker != nil && !vm.runWithProfiler() {
 		return
 	}
@@ -571,17 +642,33 @@ func (vm *vm) run() {
 		if pc < 0 || pc >= len(vm.prg.code) {
 			break
 		}
+		if vm.countSteps {
+			vm.steps++
+		}
 		vm.prg.code[pc].exec(vm)
 	}
 
 	if interrupted {
-		vm.interruptLock.Lock()
-		v := &InterruptedError{
-			iface: vm.interruptVal,
-		}
-		v.stack = vm.captureStack(nil, 0)
-		vm.interruptLock.Unlock()
-		panic(v)
+		panic(vm.newInterruptedError())
+	}
+}
+
+func (vm *vm) newInterruptedError() *InterruptedError {
+	vm.interruptLock.Lock()
+	v := &InterruptedError{
+		iface: vm.interruptVal,
+	}
+	v.stack = vm.captureStackLimit(nil, 0, vm.stackTraceLimit)
+	vm.interruptLock.Unlock()
+	return v
+}
+
+// checkInterrupt stops the execution if the VM has been interrupted. It must be called on each iteration by the
+// built-ins which can loop for a long time without calling any JavaScript code (which checks for the interrupt
+// before each instruction).
+func (vm *vm) checkInterrupt() {
+	if atomic.LoadUint32(&vm.interrupted) != 0 {
+		panic(vm.newInterruptedError())
 	}
 }
 
@@ -604,6 +691,9 @@ func (vm *vm) runWithProfiler() bool {
 		if pc < 0 || pc >= len(vm.prg.code) {
 			break
 		}
+		if vm.countSteps {
+			vm.steps++
+		}
 		vm.prg.code[pc].exec(vm)
 		req := atomic.LoadInt32(&pt.req)
 		if req == profReqStop {
@@ -621,6 +711,34 @@ func (vm *vm) runWithProfiler() bool {
 	return false
 }
 
+// runWithDebugger runs the code checking for breakpoints before each instruction. It returns false if the execution
+// has finished or true if it should continue in the regular loop (i.e. the breakpoints have been removed or the vm
+// has been interrupted).
+func (vm *vm) runWithDebugger() bool {
+	for {
+		d := vm.r.debugger
+		if d == nil || len(d.breakpoints) == 0 || atomic.LoadUint32(&vm.interrupted) != 0 {
+			return true
+		}
+		pc := vm.pc
+		if pc < 0 || pc >= len(vm.prg.code) {
+			return false
+		}
+		if !d.handling {
+			if bps := d.breakpointsAt(vm.prg, pc); bps != nil {
+				vm.r.hitBreakpoints(d, bps)
+				if atomic.LoadUint32(&vm.interrupted) != 0 {
+					return true
+				}
+			}
+		}
+		if vm.countSteps {
+			vm.steps++
+		}
+		vm.prg.code[pc].exec(vm)
+	}
+}
+
 func (vm *vm) Interrupt(v interface{}) {
 	vm.interruptLock.Lock()
 	vm.interruptVal = v
@@ -644,35 +762,88 @@ func getFuncName(stack []Value, sb int) unistring.String {
 	return ""
 }
 
+// nativeStackFrame returns the stack frame of a native function call.
+func (vm *vm) nativeStackFrame(sb int) StackFrame {
+	frame := StackFrame{funcName: getFuncName(vm.stack, sb)}
+	if sb > 0 {
+		if f, ok := vm.stack[sb-1].(*Object); ok {
+			var pc uintptr
+			switch f := f.self.(type) {
+			case *nativeFuncObject:
+				pc = f.hostPC
+			case *wrappedFuncObject:
+				pc = f.hostPC
+			}
+			if pc != 0 {
+				frame.host = true
+				if vm.r.hostFrameLocations {
+					if fn := runtime.FuncForPC(pc); fn != nil {
+						frame.hostFile, frame.hostLine = fn.FileLine(fn.Entry())
+					}
+				}
+			}
+		}
+	}
+	return frame
+}
+
 func (vm *vm) captureStack(stack []StackFrame, ctxOffset int) []StackFrame {
+	return vm.captureStackLimit(stack, ctxOffset, math.MaxInt32)
+}
+
+// captureStackLimit is like captureStack but appends no more than limit frames.
+func (vm *vm) captureStackLimit(stack []StackFrame, ctxOffset, limit int) []StackFrame {
+	end := math.MaxInt32
+	if limit < end-len(stack) {
+		end = len(stack) + limit
+	}
 	// Unroll the context stack
-	if vm.prg != nil || vm.sb > 0 {
-		var funcName unistring.String
+	if len(stack) < end {
 		if vm.prg != nil {
-			funcName = vm.prg.funcName
-		} else {
-			funcName = getFuncName(vm.stack, vm.sb)
+			stack = append(stack, StackFrame{prg: vm.prg, pc: vm.pc, funcName: vm.prg.funcName})
+		} else if vm.sb > 0 {
+			stack = append(stack, vm.nativeStackFrame(vm.sb))
 		}
-		stack = append(stack, StackFrame{prg: vm.prg, pc: vm.pc, funcName: funcName})
 	}
-	for i := len(vm.callStack) - 1; i > ctxOffset-1; i-- {
+	for i := len(vm.callStack) - 1; i > ctxOffset-1 && len(stack) < end; i-- {
 		frame := &vm.callStack[i]
-		if frame.prg != nil || frame.sb > 0 {
-			var funcName unistring.String
-			if prg := frame.prg; prg != nil {
-				funcName = prg.funcName
-			} else {
-				funcName = getFuncName(vm.stack, frame.sb)
-			}
-			stack = append(stack, StackFrame{prg: vm.callStack[i].prg, pc: frame.pc, funcName: funcName})
+		if prg := frame.prg; prg != nil {
+			stack = append(stack, StackFrame{prg: prg, pc: frame.pc, funcName: prg.funcName})
+		} else if frame.sb > 0 {
+			stack = append(stack, vm.nativeStackFrame(frame.sb))
 		}
 	}
-	if ctxOffset == 0 && vm.curAsyncRunner != nil {
-		stack = vm.captureAsyncStack(stack, vm.curAsyncRunner)
+	if ctxOffset == 0 && len(stack) < end {
+		if vm.curAsyncRunner != nil {
+			stack = vm.captureAsyncStack(stack, vm.curAsyncRunner)
+		} else {
+			stack = append(stack, vm.asyncParentStack...)
+		}
+		if len(stack) > end {
+			stack = stack[:end]
+		}
 	}
 	return stack
 }
 
+// captureCreationStack returns the stack to be stored for a continuation (an async function call or a promise
+// reaction) if async stack traces are enabled, or nil otherwise.
+func (vm *vm) captureCreationStack() []StackFrame {
+	if vm.asyncStackLimit == 0 {
+		return nil
+	}
+	return vm.captureStackLimit(nil, 0, vm.asyncStackLimit)
+}
+
+// captureStackTrace captures up to limit frames of the current stack into a newly allocated slice.
+func (vm *vm) captureStackTrace(limit int) []StackFrame {
+	n := len(vm.callStack) + 1
+	if limit < n {
+		n = limit
+	}
+	return vm.captureStackLimit(make([]StackFrame, 0, n), 0, limit)
+}
+
 func (vm *vm) captureAsyncStack(stack []StackFrame, runner *asyncRunner) []StackFrame {
 	if promise, _ := runner.promiseCap.promise.self.(*Promise); promise != nil {
 		if len(promise.fulfillReactions) == 1 {
@@ -687,12 +858,12 @@ func (vm *vm) captureAsyncStack(stack []StackFrame, runner *asyncRunner) []Stack
 					}
 					stack = append(stack, StackFrame{prg: ctx.prg, pc: ctx.pc, funcName: funcName})
 				}
-				stack = vm.captureAsyncStack(stack, r)
+				return vm.captureAsyncStack(stack, r)
 			}
 		}
 	}
 
-	return stack
+	return append(stack, runner.creationStack...)
 }
 
 func (vm *vm) pushTryFrame(catchPos, finallyPos int32) {
@@ -850,7 +1021,7 @@ func (vm *vm) saveCtx(ctx *context) {
 func (vm *vm) pushCtx() {
 	if len(vm.callStack) > vm.maxCallStackSize {
 		ex := &StackOverflowError{}
-		ex.stack = vm.captureStack(nil, 0)
+		ex.stack = vm.captureStackLimit(nil, 0, vm.stackTraceLimit)
 		panic(ex)
 	}
 	vm.callStack = append(vm.callStack, context{})
@@ -884,9 +1055,65 @@ func (vm *vm) toCallee(v Value) *Object {
 		unresolved.throw()
 		panic("Unreachable")
 	case memberUnresolved:
-		panic(vm.r.NewTypeError("Object has no member '%s'", unresolved.ref))
+		panic(vm.newNotCallableError(v))
+	}
+	panic(vm.newNotCallableError(v))
+}
+
+// operandName returns the description of the operand of the current instruction recorded by the compiler,
+// see Program.setOperandName().
+func (vm *vm) operandName() string {
+	if vm.prg != nil {
+		return vm.prg.operandName(vm.pc)
+	}
+	return ""
+}
+
+// calleeDescription returns the description of the callee of the current call or new instruction recorded by
+// the compiler, or, if there is none, of the value itself.
+func (vm *vm) calleeDescription(v Value) string {
+	if name := vm.operandName(); name != "" {
+		return name
+	}
+	if v, ok := v.(memberUnresolved); ok {
+		return v.ref.String()
+	}
+	return describeValue(v)
+}
+
+func (vm *vm) newAccessBeforeInitError() referenceError {
+	return errAccessBeforeInit(vm.operandName())
+}
+
+func (vm *vm) newNotCallableError(v Value) *Object {
+	return vm.r.NewTypeError("%s is not a function", vm.calleeDescription(v))
+}
+
+func (vm *vm) newPropReadError(base Value, propName interface{}) *Object {
+	return vm.r.NewTypeError("Cannot read properties of %s (reading '%s')", base.String(), describePropKey(propName))
+}
+
+// toSetBase converts the base of a property assignment into an object.
+func (vm *vm) toSetBase(v Value, propName interface{}) *Object {
+	switch v.(type) {
+	case valueUndefined, valueNull:
+		panic(vm.r.NewTypeError("Cannot set properties of %s (setting '%s')", v.String(), describePropKey(propName)))
+	}
+	return v.ToObject(vm.r)
+}
+
+// describePropKey returns the description of a property name (a unistring.String) or of a property key value
+// (before or after ToPropertyKey()) for the error messages. Unlike toString() it never calls into JavaScript code.
+func describePropKey(key interface{}) string {
+	switch key := key.(type) {
+	case unistring.String:
+		return key.String()
+	case valueString:
+		return key.String()
+	case Value:
+		return describeValue(key)
 	}
-	panic(vm.r.NewTypeError("Value is not an object: %s", v.toString()))
+	return ""
 }
 
 type loadVal uint32
@@ -991,7 +1218,7 @@ func (l loadStackLex) exec(vm *vm) {
 		p = &vm.stack[vm.sb+vm.args+int(l)]
 	}
 	if *p == nil {
-		vm.throw(errAccessBeforeInit)
+		vm.throw(vm.newAccessBeforeInitError())
 		return
 	}
 	vm.push(*p)
@@ -1003,7 +1230,7 @@ type loadStack1Lex int
 func (l loadStack1Lex) exec(vm *vm) {
 	p := &vm.stack[vm.sb+int(l)]
 	if *p == nil {
-		vm.throw(errAccessBeforeInit)
+		vm.throw(vm.newAccessBeforeInitError())
 		return
 	}
 	vm.push(*p)
@@ -1055,7 +1282,7 @@ func (vm *vm) storeStackLex(s int) {
 	if *p != nil {
 		*p = vm.stack[vm.sp-1]
 	} else {
-		panic(errAccessBeforeInit)
+		panic(vm.newAccessBeforeInitError())
 	}
 	vm.pc++
 }
@@ -1070,7 +1297,7 @@ func (vm *vm) storeStack1Lex(s int) {
 	if *p != nil {
 		*p = vm.stack[vm.sp-1]
 	} else {
-		panic(errAccessBeforeInit)
+		panic(vm.newAccessBeforeInitError())
 	}
 	vm.pc++
 }
@@ -1175,7 +1402,7 @@ type _toNumber struct{}
 var toNumber _toNumber
 
 func (_toNumber) exec(vm *vm) {
-	vm.stack[vm.sp-1] = vm.stack[vm.sp-1].ToNumber()
+	vm.stack[vm.sp-1] = toNumeric(vm.stack[vm.sp-1])
 	vm.pc++
 }
 
@@ -1207,19 +1434,26 @@ func (_add) exec(vm *vm) {
 		if !isRightString {
 			rightString = right.toString()
 		}
-		ret = leftString.concat(rightString)
+		s := leftString.concat(rightString)
+		vm.r.accountStringAlloc(s)
+		ret = s
 	} else {
 		if leftInt, ok := left.(valueInt); ok {
 			if rightInt, ok := right.(valueInt); ok {
 				ret = intToValue(int64(leftInt) + int64(rightInt))
-			} else {
-				ret = floatToValue(float64(leftInt) + right.ToFloat())
+				goto end
 			}
+		}
+		left, right = toNumeric(left), toNumeric(right)
+		if l, r := bigIntOperands(left, right); l != nil {
+			ret = (*valueBigInt)(new(big.Int).Add(l, r))
 		} else {
 			ret = floatToValue(left.ToFloat() + right.ToFloat())
 		}
 	}
 
+end:
+
 	vm.stack[vm.sp-2] = ret
 	vm.sp--
 	vm.pc++
@@ -1242,6 +1476,12 @@ func (_sub) exec(vm *vm) {
 		}
 	}
 
+	left, right = toNumeric(left), toNumeric(right)
+	if l, r := bigIntOperands(left, right); l != nil {
+		result = (*valueBigInt)(new(big.Int).Sub(l, r))
+		goto end
+	}
+
 	result = floatToValue(left.ToFloat() - right.ToFloat())
 end:
 	vm.sp--
@@ -1254,11 +1494,16 @@ type _mul struct{}
 var mul _mul
 
 func (_mul) exec(vm *vm) {
-	left := vm.stack[vm.sp-2]
-	right := vm.stack[vm.sp-1]
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
 
+	if l, r := bigIntOperands(left, right); l != nil {
+		result = (*valueBigInt)(new(big.Int).Mul(l, r))
+		goto end
+	}
+
 	if left, ok := assertInt64(left); ok {
 		if right, ok := assertInt64(right); ok {
 			if left == 0 && right == -1 || left == -1 && right == 0 {
@@ -1289,7 +1534,12 @@ var exp _exp
 
 func (_exp) exec(vm *vm) {
 	vm.sp--
-	vm.stack[vm.sp-1] = pow(vm.stack[vm.sp-1], vm.stack[vm.sp])
+	left, right := toNumeric(vm.stack[vm.sp-1]), toNumeric(vm.stack[vm.sp])
+	if l, r := bigIntOperands(left, right); l != nil {
+		vm.stack[vm.sp-1] = bigIntExp(l, r)
+	} else {
+		vm.stack[vm.sp-1] = pow(left, right)
+	}
 	vm.pc++
 }
 
@@ -1298,8 +1548,19 @@ type _div struct{}
 var div _div
 
 func (_div) exec(vm *vm) {
-	left := vm.stack[vm.sp-2].ToFloat()
-	right := vm.stack[vm.sp-1].ToFloat()
+	leftNum, rightNum := toNumeric(vm.stack[vm.sp-2]), toNumeric(vm.stack[vm.sp-1])
+	if l, r := bigIntOperands(leftNum, rightNum); l != nil {
+		if r.Sign() == 0 {
+			panic(rangeError("Division by zero"))
+		}
+		vm.sp--
+		vm.stack[vm.sp-1] = (*valueBigInt)(new(big.Int).Quo(l, r))
+		vm.pc++
+		return
+	}
+
+	left := leftNum.ToFloat()
+	right := rightNum.ToFloat()
 
 	var result Value
 
@@ -1357,11 +1618,19 @@ type _mod struct{}
 var mod _mod
 
 func (_mod) exec(vm *vm) {
-	left := vm.stack[vm.sp-2]
-	right := vm.stack[vm.sp-1]
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
 
+	if l, r := bigIntOperands(left, right); l != nil {
+		if r.Sign() == 0 {
+			panic(rangeError("Division by zero"))
+		}
+		result = (*valueBigInt)(new(big.Int).Rem(l, r))
+		goto end
+	}
+
 	if leftInt, ok := assertInt64(left); ok {
 		if rightInt, ok := assertInt64(right); ok {
 			if rightInt == 0 {
@@ -1390,11 +1659,13 @@ type _neg struct{}
 var neg _neg
 
 func (_neg) exec(vm *vm) {
-	operand := vm.stack[vm.sp-1]
+	operand := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
 
-	if i, ok := assertInt64(operand); ok {
+	if b, ok := operand.(*valueBigInt); ok {
+		result = (*valueBigInt)(new(big.Int).Neg((*big.Int)(b)))
+	} else if i, ok := assertInt64(operand); ok {
 		if i == 0 {
 			result = _negativeZero
 		} else {
@@ -1426,7 +1697,12 @@ type _inc struct{}
 var inc _inc
 
 func (_inc) exec(vm *vm) {
-	v := vm.stack[vm.sp-1]
+	v := toNumeric(vm.stack[vm.sp-1])
+
+	if b, ok := v.(*valueBigInt); ok {
+		v = (*valueBigInt)(new(big.Int).Add((*big.Int)(b), big.NewInt(1)))
+		goto end
+	}
 
 	if i, ok := assertInt64(v); ok {
 		v = intToValue(i + 1)
@@ -1445,7 +1721,12 @@ type _dec struct{}
 var dec _dec
 
 func (_dec) exec(vm *vm) {
-	v := vm.stack[vm.sp-1]
+	v := toNumeric(vm.stack[vm.sp-1])
+
+	if b, ok := v.(*valueBigInt); ok {
+		v = (*valueBigInt)(new(big.Int).Sub((*big.Int)(b), big.NewInt(1)))
+		goto end
+	}
 
 	if i, ok := assertInt64(v); ok {
 		v = intToValue(i - 1)
@@ -1464,9 +1745,14 @@ type _and struct{}
 var and _and
 
 func (_and) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left & right))
+	leftNum, rightNum := toNumeric(vm.stack[vm.sp-2]), toNumeric(vm.stack[vm.sp-1])
+	if l, r := bigIntOperands(leftNum, rightNum); l != nil {
+		vm.stack[vm.sp-2] = (*valueBigInt)(new(big.Int).And(l, r))
+	} else {
+		left := toInt32(leftNum)
+		right := toInt32(rightNum)
+		vm.stack[vm.sp-2] = intToValue(int64(left & right))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1476,9 +1762,14 @@ type _or struct{}
 var or _or
 
 func (_or) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left | right))
+	leftNum, rightNum := toNumeric(vm.stack[vm.sp-2]), toNumeric(vm.stack[vm.sp-1])
+	if l, r := bigIntOperands(leftNum, rightNum); l != nil {
+		vm.stack[vm.sp-2] = (*valueBigInt)(new(big.Int).Or(l, r))
+	} else {
+		left := toInt32(leftNum)
+		right := toInt32(rightNum)
+		vm.stack[vm.sp-2] = intToValue(int64(left | right))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1488,9 +1779,14 @@ type _xor struct{}
 var xor _xor
 
 func (_xor) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left ^ right))
+	leftNum, rightNum := toNumeric(vm.stack[vm.sp-2]), toNumeric(vm.stack[vm.sp-1])
+	if l, r := bigIntOperands(leftNum, rightNum); l != nil {
+		vm.stack[vm.sp-2] = (*valueBigInt)(new(big.Int).Xor(l, r))
+	} else {
+		left := toInt32(leftNum)
+		right := toInt32(rightNum)
+		vm.stack[vm.sp-2] = intToValue(int64(left ^ right))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1500,8 +1796,13 @@ type _bnot struct{}
 var bnot _bnot
 
 func (_bnot) exec(vm *vm) {
-	op := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-1] = intToValue(int64(^op))
+	operand := toNumeric(vm.stack[vm.sp-1])
+	if b, ok := operand.(*valueBigInt); ok {
+		vm.stack[vm.sp-1] = (*valueBigInt)(new(big.Int).Not((*big.Int)(b)))
+	} else {
+		op := toInt32(operand)
+		vm.stack[vm.sp-1] = intToValue(int64(^op))
+	}
 	vm.pc++
 }
 
@@ -1510,9 +1811,14 @@ type _sal struct{}
 var sal _sal
 
 func (_sal) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toUint32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left << (right & 0x1F)))
+	leftNum, rightNum := toNumeric(vm.stack[vm.sp-2]), toNumeric(vm.stack[vm.sp-1])
+	if l, r := bigIntOperands(leftNum, rightNum); l != nil {
+		vm.stack[vm.sp-2] = bigIntShiftLeft(l, r)
+	} else {
+		left := toInt32(leftNum)
+		right := toUint32(rightNum)
+		vm.stack[vm.sp-2] = intToValue(int64(left << (right & 0x1F)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1522,9 +1828,14 @@ type _sar struct{}
 var sar _sar
 
 func (_sar) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toUint32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left >> (right & 0x1F)))
+	leftNum, rightNum := toNumeric(vm.stack[vm.sp-2]), toNumeric(vm.stack[vm.sp-1])
+	if l, r := bigIntOperands(leftNum, rightNum); l != nil {
+		vm.stack[vm.sp-2] = bigIntShiftLeft(l, new(big.Int).Neg(r))
+	} else {
+		left := toInt32(leftNum)
+		right := toUint32(rightNum)
+		vm.stack[vm.sp-2] = intToValue(int64(left >> (right & 0x1F)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1534,8 +1845,12 @@ type _shr struct{}
 var shr _shr
 
 func (_shr) exec(vm *vm) {
-	left := toUint32(vm.stack[vm.sp-2])
-	right := toUint32(vm.stack[vm.sp-1])
+	leftNum, rightNum := toNumeric(vm.stack[vm.sp-2]), toNumeric(vm.stack[vm.sp-1])
+	if l, _ := bigIntOperands(leftNum, rightNum); l != nil {
+		panic(typeError("BigInts have no unsigned right shift, use >> instead"))
+	}
+	left := toUint32(leftNum)
+	right := toUint32(rightNum)
 	vm.stack[vm.sp-2] = intToValue(int64(left >> (right & 0x1F)))
 	vm.sp--
 	vm.pc++
@@ -1632,7 +1947,7 @@ type _setElem struct{}
 var setElem _setElem
 
 func (_setElem) exec(vm *vm) {
-	obj := vm.stack[vm.sp-3].ToObject(vm.r)
+	obj := vm.toSetBase(vm.stack[vm.sp-3], vm.stack[vm.sp-2])
 	propName := toPropertyKey(vm.stack[vm.sp-2])
 	val := vm.stack[vm.sp-1]
 
@@ -1705,7 +2020,7 @@ type _setElemP struct{}
 var setElemP _setElemP
 
 func (_setElemP) exec(vm *vm) {
-	obj := vm.stack[vm.sp-3].ToObject(vm.r)
+	obj := vm.toSetBase(vm.stack[vm.sp-3], vm.stack[vm.sp-2])
 	propName := toPropertyKey(vm.stack[vm.sp-2])
 	val := vm.stack[vm.sp-1]
 
@@ -1726,7 +2041,7 @@ func (_setElemStrict) exec(vm *vm) {
 	if receiverObj, ok := receiver.(*Object); ok {
 		receiverObj.setOwn(propName, val, true)
 	} else {
-		base := receiver.ToObject(vm.r)
+		base := vm.toSetBase(receiver, vm.stack[vm.sp-2])
 		base.set(propName, val, receiver, true)
 	}
 
@@ -1788,7 +2103,7 @@ func (_setElemStrictP) exec(vm *vm) {
 	if receiverObj, ok := receiver.(*Object); ok {
 		receiverObj.setOwn(propName, val, true)
 	} else {
-		base := receiver.ToObject(vm.r)
+		base := vm.toSetBase(receiver, vm.stack[vm.sp-2])
 		base.set(propName, val, receiver, true)
 	}
 
@@ -1938,7 +2253,7 @@ type setProp unistring.String
 
 func (p setProp) exec(vm *vm) {
 	val := vm.stack[vm.sp-1]
-	vm.stack[vm.sp-2].ToObject(vm.r).self.setOwnStr(unistring.String(p), val, false)
+	vm.toSetBase(vm.stack[vm.sp-2], unistring.String(p)).self.setOwnStr(unistring.String(p), val, false)
 	vm.stack[vm.sp-2] = val
 	vm.sp--
 	vm.pc++
@@ -1948,7 +2263,7 @@ type setPropP unistring.String
 
 func (p setPropP) exec(vm *vm) {
 	val := vm.stack[vm.sp-1]
-	vm.stack[vm.sp-2].ToObject(vm.r).self.setOwnStr(unistring.String(p), val, false)
+	vm.toSetBase(vm.stack[vm.sp-2], unistring.String(p)).self.setOwnStr(unistring.String(p), val, false)
 	vm.sp -= 2
 	vm.pc++
 }
@@ -1962,7 +2277,7 @@ func (p setPropStrict) exec(vm *vm) {
 	if receiverObj, ok := receiver.(*Object); ok {
 		receiverObj.self.setOwnStr(propName, val, true)
 	} else {
-		base := receiver.ToObject(vm.r)
+		base := vm.toSetBase(receiver, propName)
 		base.setStr(propName, val, receiver, true)
 	}
 
@@ -2054,7 +2369,7 @@ func (p setPropStrictP) exec(vm *vm) {
 	if receiverObj, ok := receiver.(*Object); ok {
 		receiverObj.self.setOwnStr(propName, val, true)
 	} else {
-		base := receiver.ToObject(vm.r)
+		base := vm.toSetBase(receiver, propName)
 		base.setStr(propName, val, receiver, true)
 	}
 
@@ -2237,11 +2552,34 @@ func (s *defineSetter) exec(vm *vm) {
 
 type getProp unistring.String
 
+// getStringProp reads a named property of a primitive string directly from the string and
+// String.prototype, avoiding the String wrapper (and its length property update) on every access.
+// Returns false if v is not a string or the name is an index, in which case the caller must fall back
+// to the generic lookup.
+func (vm *vm) getStringProp(v Value, name unistring.String) (Value, bool) {
+	s, ok := v.(valueString)
+	if !ok {
+		return nil, false
+	}
+	if name == "length" {
+		return intToValue(int64(s.length())), true
+	}
+	if strToGoIdx(name) >= 0 {
+		return nil, false
+	}
+	return vm.r.global.StringPrototype.self.getStr(name, s), true
+}
+
 func (g getProp) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
+	if prop, ok := vm.getStringProp(v, unistring.String(g)); ok {
+		vm.stack[vm.sp-1] = nilSafe(prop)
+		vm.pc++
+		return
+	}
 	obj := v.baseObject(vm.r)
 	if obj == nil {
-		vm.throw(vm.r.NewTypeError("Cannot read property '%s' of undefined", g))
+		vm.throw(vm.newPropReadError(v, unistring.String(g)))
 		return
 	}
 	vm.stack[vm.sp-1] = nilSafe(obj.self.getStr(unistring.String(g), v))
@@ -2256,7 +2594,7 @@ func (g getPropRecv) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
 	obj := v.baseObject(vm.r)
 	if obj == nil {
-		vm.throw(vm.r.NewTypeError("Cannot read property '%s' of undefined", g))
+		vm.throw(vm.newPropReadError(v, unistring.String(g)))
 		return
 	}
 	vm.stack[vm.sp-2] = nilSafe(obj.self.getStr(unistring.String(g), recv))
@@ -2271,7 +2609,7 @@ func (g getPropRecvCallee) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
 	obj := v.baseObject(vm.r)
 	if obj == nil {
-		vm.throw(vm.r.NewTypeError("Cannot read property '%s' of undefined", g))
+		vm.throw(vm.newPropReadError(v, unistring.String(g)))
 		return
 	}
 
@@ -2289,13 +2627,16 @@ type getPropCallee unistring.String
 
 func (g getPropCallee) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
-	obj := v.baseObject(vm.r)
 	n := unistring.String(g)
-	if obj == nil {
-		vm.throw(vm.r.NewTypeError("Cannot read property '%s' of undefined or null", n))
-		return
+	prop, ok := vm.getStringProp(v, n)
+	if !ok {
+		obj := v.baseObject(vm.r)
+		if obj == nil {
+			vm.throw(vm.newPropReadError(v, n))
+			return
+		}
+		prop = obj.self.getStr(n, v)
 	}
-	prop := obj.self.getStr(n, v)
 	if prop == nil {
 		prop = memberUnresolved{valueUnresolved{r: vm.r, ref: n}}
 	}
@@ -2313,7 +2654,7 @@ func (_getElem) exec(vm *vm) {
 	obj := v.baseObject(vm.r)
 	propName := toPropertyKey(vm.stack[vm.sp-1])
 	if obj == nil {
-		vm.throw(vm.r.NewTypeError("Cannot read property '%s' of undefined", propName.String()))
+		vm.throw(vm.newPropReadError(v, vm.stack[vm.sp-1]))
 		return
 	}
 
@@ -2333,7 +2674,7 @@ func (_getElemRecv) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
 	obj := v.baseObject(vm.r)
 	if obj == nil {
-		vm.throw(vm.r.NewTypeError("Cannot read property '%s' of undefined", propName.String()))
+		vm.throw(vm.newPropReadError(v, vm.stack[vm.sp-2]))
 		return
 	}
 
@@ -2352,7 +2693,7 @@ func (_getKey) exec(vm *vm) {
 	obj := v.baseObject(vm.r)
 	propName := vm.stack[vm.sp-1]
 	if obj == nil {
-		vm.throw(vm.r.NewTypeError("Cannot read property '%s' of undefined", propName.String()))
+		vm.throw(vm.newPropReadError(v, propName))
 		return
 	}
 
@@ -2371,7 +2712,7 @@ func (_getElemCallee) exec(vm *vm) {
 	obj := v.baseObject(vm.r)
 	propName := toPropertyKey(vm.stack[vm.sp-1])
 	if obj == nil {
-		vm.throw(vm.r.NewTypeError("Cannot read property '%s' of undefined", propName.String()))
+		vm.throw(vm.newPropReadError(v, vm.stack[vm.sp-1]))
 		return
 	}
 
@@ -2394,7 +2735,7 @@ func (_getElemRecvCallee) exec(vm *vm) {
 	obj := v.baseObject(vm.r)
 	propName := toPropertyKey(vm.stack[vm.sp-1])
 	if obj == nil {
-		vm.throw(vm.r.NewTypeError("Cannot read property '%s' of undefined", propName.String()))
+		vm.throw(vm.newPropReadError(v, vm.stack[vm.sp-1]))
 		return
 	}
 
@@ -2551,7 +2892,7 @@ func (vm *vm) setLocalLex(s int) {
 	}
 	p := &stash.values[idx]
 	if *p == nil {
-		panic(errAccessBeforeInit)
+		panic(vm.newAccessBeforeInitError())
 	}
 	*p = v
 	vm.pc++
@@ -2777,7 +3118,7 @@ func (g loadStashLex) exec(vm *vm) {
 
 	v := stash.getByIdx(idx)
 	if v == nil {
-		vm.throw(errAccessBeforeInit)
+		vm.throw(vm.newAccessBeforeInitError())
 		return
 	}
 	vm.push(v)
@@ -2850,7 +3191,7 @@ func (g *loadMixedLex) exec(vm *vm) {
 	if stash != nil {
 		v := stash.getByIdx(idx)
 		if v == nil {
-			vm.throw(errAccessBeforeInit)
+			vm.throw(errAccessBeforeInit(g.name.String()))
 			return
 		}
 		vm.push(v)
@@ -3355,9 +3696,63 @@ func (numargs call) exec(vm *vm) {
 	n := int(numargs)
 	v := vm.stack[vm.sp-n-1] // callee
 	obj := vm.toCallee(v)
+	if n == 1 && vm.callStringScan(obj) {
+		return
+	}
 	obj.self.vmCall(vm, n)
 }
 
+// callStringScan handles calls to the built-in String.prototype.charAt, charCodeAt and codePointAt
+// with a primitive string 'this' and an integer index without going through the generic native call
+// path. These calls dominate index-based scanning loops (tokenizers, parsers) and none of them can
+// have side effects in this case. Returns false if the call is not eligible, leaving the stack untouched.
+func (vm *vm) callStringScan(callee *Object) bool {
+	g := &vm.r.global
+	if callee != g.stringCharCodeAt && callee != g.stringCharAt && callee != g.stringCodePointAt {
+		return false
+	}
+	s, ok := vm.stack[vm.sp-3].(valueString)
+	if !ok {
+		return false
+	}
+	idx, ok := vm.stack[vm.sp-1].(valueInt)
+	if !ok {
+		return false
+	}
+	var ret Value
+	pos, size := int(idx), s.length()
+	if idx < 0 || int64(idx) >= int64(size) {
+		switch callee {
+		case g.stringCharCodeAt:
+			ret = _NaN
+		case g.stringCharAt:
+			ret = stringEmpty
+		default:
+			ret = _undefined
+		}
+	} else {
+		c := s.charAt(pos)
+		switch callee {
+		case g.stringCharCodeAt:
+			ret = intToValue(int64(c & 0xFFFF))
+		case g.stringCharAt:
+			ret = s.substring(pos, pos+1)
+		default:
+			if isUTF16FirstSurrogate(c) && pos+1 < size {
+				if c2 := s.charAt(pos + 1); isUTF16SecondSurrogate(c2) {
+					ret = intToValue(int64(utf16.DecodeRune(c, c2)))
+					break
+				}
+			}
+			ret = intToValue(int64(c & 0xFFFF))
+		}
+	}
+	vm.stack[vm.sp-3] = ret
+	vm.sp -= 2
+	vm.pc++
+	return true
+}
+
 func (vm *vm) clearStack() {
 	sp := vm.sp
 	stackTail := vm.stack[sp:]
@@ -3975,31 +4370,61 @@ func (vm *vm) checkBindLexGlobal(names []unistring.String) {
 }
 
 type bindVars struct {
-	names     []unistring.String
+	names []unistring.String
+	// the names of the functions declared in blocks (see Annex B.3.3.3), which are skipped rather
+	// than causing an error if they conflict with a lexical declaration
+	annexB    []unistring.String
 	deletable bool
 }
 
+// varTarget returns the stash of the variable environment for the var binding, or nil if it conflicts with
+// a lexical declaration.
+func (vm *vm) varTarget(name unistring.String) *stash {
+	for s := vm.stash; s != nil; s = s.outer {
+		if idx, exists := s.names[name]; exists && idx&maskVar == 0 {
+			return nil
+		}
+		if s.isVariable() {
+			return s
+		}
+	}
+	return vm.stash
+}
+
 func (d *bindVars) exec(vm *vm) {
-	var target *stash
 	for _, name := range d.names {
-		for s := vm.stash; s != nil; s = s.outer {
-			if idx, exists := s.names[name]; exists && idx&maskVar == 0 {
-				vm.throw(vm.alreadyDeclared(name))
-				return
-			}
-			if s.isVariable() {
-				target = s
-				break
-			}
+		if vm.varTarget(name) == nil {
+			vm.throw(vm.alreadyDeclared(name))
+			return
 		}
 	}
-	if target == nil {
-		target = vm.stash
-	}
 	deletable := d.deletable
 	for _, name := range d.names {
-		target.createBinding(name, deletable)
+		vm.varTarget(name).createBinding(name, deletable)
+	}
+	for _, name := range d.annexB {
+		if target := vm.varTarget(name); target != nil {
+			target.createBinding(name, deletable)
+		}
+	}
+	vm.pc++
+}
+
+// setFuncVarP assigns the value on top of the stack to the var binding in the variable environment of the
+// function (if bindVars has created one, see bindVars.annexB) and pops it.
+type setFuncVarP unistring.String
+
+func (n setFuncVarP) exec(vm *vm) {
+	name := unistring.String(n)
+	for s := vm.stash; s != nil; s = s.outer {
+		if s.isVariable() {
+			if idx, exists := s.names[name]; exists && idx&maskVar != 0 {
+				s.values[idx&^maskTyp] = vm.peek()
+			}
+			break
+		}
 	}
+	vm.sp--
 	vm.pc++
 }
 
@@ -4177,6 +4602,14 @@ func cmp(px, py Value) Value {
 		}
 	}
 
+	if xb, ok := px.(*valueBigInt); ok {
+		return cmpBigInt((*big.Int)(xb), py, false)
+	}
+
+	if yb, ok := py.(*valueBigInt); ok {
+		return cmpBigInt((*big.Int)(yb), px, true)
+	}
+
 	nx = px.ToFloat()
 	ny = py.ToFloat()
 
@@ -4194,6 +4627,35 @@ end:
 
 }
 
+// cmpBigInt compares a BigInt with another primitive value. If swap is true the result is y < b,
+// otherwise it's b < y.
+func cmpBigInt(b *big.Int, y Value, swap bool) Value {
+	var c int
+	switch y := y.(type) {
+	case *valueBigInt:
+		c = b.Cmp((*big.Int)(y))
+	case valueString:
+		yb, ok := stringToBigInt(y)
+		if !ok {
+			return _undefined
+		}
+		c = b.Cmp(yb)
+	default:
+		f := y.ToFloat()
+		if math.IsNaN(f) {
+			return _undefined
+		}
+		c = compareBigIntFloat(b, f)
+	}
+	if swap {
+		c = -c
+	}
+	if c < 0 {
+		return valueTrue
+	}
+	return valueFalse
+}
+
 type _op_lt struct{}
 
 var op_lt _op_lt
@@ -4330,7 +4792,7 @@ var op_instanceof _op_instanceof
 
 func (_op_instanceof) exec(vm *vm) {
 	left := vm.stack[vm.sp-2]
-	right := vm.r.toObject(vm.stack[vm.sp-1])
+	right := vm.r.toObject(vm.stack[vm.sp-1], "Right-hand side of 'instanceof' is not an object")
 
 	if instanceOfOperator(left, right) {
 		vm.stack[vm.sp-2] = valueTrue
@@ -4348,7 +4810,10 @@ var op_in _op_in
 
 func (_op_in) exec(vm *vm) {
 	left := vm.stack[vm.sp-2]
-	right := vm.r.toObject(vm.stack[vm.sp-1])
+	right, ok := vm.stack[vm.sp-1].(*Object)
+	if !ok {
+		panic(vm.r.NewTypeError("Cannot use 'in' operator to search for '%s' in %s", describePropKey(left), describeValue(vm.stack[vm.sp-1])))
+	}
 
 	if right.hasProperty(left) {
 		vm.stack[vm.sp-2] = valueTrue
@@ -4396,6 +4861,15 @@ func (leaveTry) exec(vm *vm) {
 	}
 }
 
+// leaveTryJump ends a try block that has a catch block and no finally block. It pops the try frame and jumps over
+// the catch block (and the leaveTry that follows it), saving an instruction when no exception is thrown.
+type leaveTryJump int32
+
+func (j leaveTryJump) exec(vm *vm) {
+	vm.popTryFrame()
+	vm.pc += int(j)
+}
+
 type enterFinally struct{}
 
 func (enterFinally) exec(vm *vm) {
@@ -4449,7 +4923,7 @@ func (_throw) exec(vm *vm) {
 	if ex == nil {
 		ex = &Exception{
 			val:   v,
-			stack: vm.captureStack(make([]StackFrame, 0, len(vm.callStack)+1), 0),
+			stack: vm.captureStackTrace(vm.stackTraceLimit),
 		}
 	}
 
@@ -4471,7 +4945,13 @@ type _new uint32
 func (n _new) exec(vm *vm) {
 	sp := vm.sp - int(n)
 	obj := vm.stack[sp-1]
-	ctor := vm.r.toConstructor(obj)
+	var ctor func(args []Value, newTarget *Object) *Object
+	if o, ok := obj.(*Object); ok {
+		ctor = o.self.assertConstructor()
+	}
+	if ctor == nil {
+		panic(vm.r.NewTypeError("%s is not a constructor", vm.calleeDescription(obj)))
+	}
 	vm.stack[sp-1] = ctor(vm.stack[sp:vm.sp], nil)
 	vm.sp = sp
 	vm.pc++
@@ -4547,6 +5027,8 @@ func (_typeof) exec(vm *vm) {
 		r = stringString
 	case valueInt, valueFloat:
 		r = stringNumber
+	case *valueBigInt:
+		r = stringBigInt
 	case *Symbol:
 		r = stringSymbol
 	default:
@@ -4556,6 +5038,85 @@ func (_typeof) exec(vm *vm) {
 	vm.pc++
 }
 
+// typeofTag identifies a possible result of the typeof operator.
+type typeofTag uint8
+
+const (
+	typeofNone typeofTag = iota // not a possible result, never matches
+	typeofUndefined
+	typeofObject
+	typeofBoolean
+	typeofNumber
+	typeofBigInt
+	typeofString
+	typeofSymbol
+	typeofFunction
+)
+
+func typeofTagFromString(s unistring.String) typeofTag {
+	switch s {
+	case "undefined":
+		return typeofUndefined
+	case "object":
+		return typeofObject
+	case "boolean":
+		return typeofBoolean
+	case "number":
+		return typeofNumber
+	case "bigint":
+		return typeofBigInt
+	case "string":
+		return typeofString
+	case "symbol":
+		return typeofSymbol
+	case "function":
+		return typeofFunction
+	}
+	return typeofNone
+}
+
+func typeofTagOf(v Value) typeofTag {
+	switch v := v.(type) {
+	case valueUndefined, valueUnresolved:
+		return typeofUndefined
+	case valueNull:
+		return typeofObject
+	case *Object:
+		if v.self.typeOf() == stringFunction {
+			return typeofFunction
+		}
+		return typeofObject
+	case valueBool:
+		return typeofBoolean
+	case valueString:
+		return typeofString
+	case valueInt, valueFloat:
+		return typeofNumber
+	case *valueBigInt:
+		return typeofBigInt
+	case *Symbol:
+		return typeofSymbol
+	default:
+		panic(newTypeError("Compiler bug: unknown type: %T", v))
+	}
+}
+
+// typeofIs replaces the value on top of the stack with the result of 'typeof value === "<tag>"'
+// (or '!==' if not is set).
+type typeofIs struct {
+	tag typeofTag
+	not bool
+}
+
+func (t typeofIs) exec(vm *vm) {
+	if (typeofTagOf(vm.stack[vm.sp-1]) == t.tag) != t.not {
+		vm.stack[vm.sp-1] = valueTrue
+	} else {
+		vm.stack[vm.sp-1] = valueFalse
+	}
+	vm.pc++
+}
+
 type createArgsMapped uint32
 
 func (formalArgs createArgsMapped) exec(vm *vm) {
@@ -4721,12 +5282,24 @@ func (_iterateP) exec(vm *vm) {
 	vm.pc++
 }
 
-type _iterate struct{}
-
-var iterate _iterate
+// iterate starts the iteration of the source of an array destructuring assignment. The value is the description
+// of the source expression used in the error message, if known.
+type iterate string
 
-func (_iterate) exec(vm *vm) {
-	iter := vm.r.getIterator(vm.stack[vm.sp-1], nil)
+func (i iterate) exec(vm *vm) {
+	v := vm.stack[vm.sp-1]
+	var method func(FunctionCall) Value
+	if i != "" {
+		switch v.(type) {
+		case valueUndefined, valueNull:
+		default:
+			method = toMethod(vm.r.getV(v, SymIterator))
+		}
+		if method == nil {
+			panic(vm.r.NewTypeError("%s is not iterable", string(i)))
+		}
+	}
+	iter := vm.r.getIterator(v, method)
 	vm.iterStack = append(vm.iterStack, iterStackItem{iter: iter})
 	vm.pc++
 }
@@ -4827,23 +5400,44 @@ func (_copyRest) exec(vm *vm) {
 	vm.pc++
 }
 
-type _createDestructSrc struct{}
+// destructSrcDesc describes the source of an object destructuring assignment for the error message thrown if
+// it's undefined or null.
+type destructSrcDesc struct {
+	name string // the description of the source expression, if known
+	prop string // the name of the first property, if known
+}
 
-var createDestructSrc _createDestructSrc
+func (d *destructSrcDesc) check(vm *vm, v Value) {
+	switch v.(type) {
+	case valueUndefined, valueNull:
+		name := d.name
+		if name == "" {
+			name = v.String()
+		}
+		if d.prop != "" {
+			panic(vm.r.NewTypeError("Cannot destructure property '%s' of '%s' as it is %s.", d.prop, name, v.String()))
+		}
+		panic(vm.r.NewTypeError("Cannot destructure '%s' as it is %s.", name, v.String()))
+	}
+}
 
-func (_createDestructSrc) exec(vm *vm) {
+type createDestructSrc struct {
+	destructSrcDesc
+}
+
+func (c *createDestructSrc) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
-	vm.r.checkObjectCoercible(v)
+	c.check(vm, v)
 	vm.push(vm.r.newDestructKeyedSource(v))
 	vm.pc++
 }
 
-type _checkObjectCoercible struct{}
-
-var checkObjectCoercible _checkObjectCoercible
+type checkObjectCoercible struct {
+	destructSrcDesc
+}
 
-func (_checkObjectCoercible) exec(vm *vm) {
-	vm.r.checkObjectCoercible(vm.stack[vm.sp-1])
+func (c *checkObjectCoercible) exec(vm *vm) {
+	c.check(vm, vm.stack[vm.sp-1])
 	vm.pc++
 }
 
@@ -5109,7 +5703,7 @@ func (vm *vm) loadThis(v Value) {
 	if v != nil {
 		vm.push(v)
 	} else {
-		vm.throw(vm.r.newError(vm.r.global.ReferenceError, "Must call super constructor in derived class before accessing 'this'"))
+		vm.throw(vm.r.newError(vm.r.global.ReferenceError, "Must call super constructor in derived class before accessing 'this' or returning from derived constructor"))
 		return
 	}
 	vm.pc++
@@ -5477,7 +6071,7 @@ func (vm *vm) exceptionFromValue(x interface{}) *Exception {
 		return nil
 	}
 	if ex.stack == nil {
-		ex.stack = vm.captureStack(make([]StackFrame, 0, len(vm.callStack)+1), 0)
+		ex.stack = vm.captureStackTrace(vm.stackTraceLimit)
 	}
 	return ex
 }
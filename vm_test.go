@@ -1,6 +1,7 @@
 package goja
 
 import (
+	"github.com/dop251/goja/ast"
 	"github.com/dop251/goja/file"
 	"github.com/dop251/goja/parser"
 	"github.com/dop251/goja/unistring"
@@ -91,6 +92,215 @@ func TestNewArrayFromIterClosed(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+type testVecAddInstr struct{}
+
+func (testVecAddInstr) Exec(r *Runtime, operands []Value) Value {
+	if len(operands) != 2 {
+		panic(r.NewTypeError("vecadd: expected 2 operands, got %d", len(operands)))
+	}
+	return r.ToValue(operands[0].ToFloat() + operands[1].ToFloat())
+}
+
+func replaceVecAddCall(expr ast.Expression) ast.Expression {
+	if call, ok := expr.(*ast.CallExpression); ok {
+		if id, ok := call.Callee.(*ast.Identifier); ok && id.Name == "vecadd" {
+			return &ast.CustomInstructionExpression{
+				Instruction:  testVecAddInstr{},
+				ArgumentList: call.ArgumentList,
+				From:         call.Idx0(),
+				To:           call.Idx1(),
+			}
+		}
+	}
+	return expr
+}
+
+func TestCustomInstruction(t *testing.T) {
+	const SCRIPT = `
+	var x = 2;
+	var a = vecadd(1, x) * 10;
+	vecadd(a, vecadd(3, 4));
+	`
+	prg, err := Parse("test.js", SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decl := prg.Body[1].(*ast.VariableStatement).List[0].Initializer.(*ast.BinaryExpression)
+	decl.Left = replaceVecAddCall(decl.Left)
+	stmt := prg.Body[2].(*ast.ExpressionStatement)
+	stmt.Expression = replaceVecAddCall(stmt.Expression)
+	call := stmt.Expression.(*ast.CustomInstructionExpression)
+	call.ArgumentList[1] = replaceVecAddCall(call.ArgumentList[1])
+
+	p, err := CompileAST(prg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm := New()
+	vm.Set("vecadd", func(FunctionCall) Value {
+		panic("should not be called")
+	})
+	res, err := vm.RunProgram(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.SameAs(intToValue(37)) {
+		t.Fatalf("Unexpected result: %v", res)
+	}
+	if a := vm.Get("a"); !a.SameAs(intToValue(30)) {
+		t.Fatalf("Unexpected a: %v", a)
+	}
+}
+
+func TestCustomInstructionThrow(t *testing.T) {
+	prg, err := Parse("test.js", "var x;\nx = vecadd(1);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assign := prg.Body[1].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression)
+	assign.Right = replaceVecAddCall(assign.Right)
+	p, err := CompileAST(prg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = New().RunProgram(p)
+	if ex, ok := err.(*Exception); ok {
+		if msg := ex.Error(); msg != "TypeError: vecadd: expected 2 operands, got 1 at test.js:2:5(3)" {
+			t.Fatal(msg)
+		}
+	} else {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestCustomInstructionInvalid(t *testing.T) {
+	prg, err := Parse("test.js", "vecadd(...[1, 2])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := prg.Body[0].(*ast.ExpressionStatement)
+	stmt.Expression = replaceVecAddCall(stmt.Expression)
+	if _, err := CompileAST(prg, false); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	stmt.Expression = &ast.CustomInstructionExpression{Instruction: 42}
+	if _, err := CompileAST(prg, false); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func TestIntrinsic(t *testing.T) {
+	const SCRIPT = `
+	var res = [add(1, 2)];
+	(function() {
+		res.push(add(3, 4));
+		res.push((function(add) { return add(5, 6); })(function(a, b) { return a * b; }));
+		with ({add: function() { return "with"; }}) {
+			res.push(add(1, 1));
+		}
+		res.push(add(...[1, 1]));
+		res.push(typeof add);
+	})();
+	res.join();
+	`
+	var calls int
+	add := func(r *Runtime, args []Value) Value {
+		calls++
+		return r.ToValue(args[0].ToInteger() + args[1].ToInteger())
+	}
+	p, err := Compile("test.js", SCRIPT, false, WithIntrinsic("add", add))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm := New()
+	vm.Set("add", func(call FunctionCall) Value {
+		return vm.ToValue("global")
+	})
+	res, err := vm.RunProgram(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "3,7,30,with,global,function" {
+		t.Fatal(s)
+	}
+	if calls != 2 {
+		t.Fatal(calls)
+	}
+}
+
+// The expected messages are the ones produced by V8 for the same code.
+func TestTypeErrorMessages(t *testing.T) {
+	tests := []struct {
+		src, msg string
+	}{
+		{"var u; u.y", "TypeError: Cannot read properties of undefined (reading 'y')"},
+		{"var n = null; n[0]", "TypeError: Cannot read properties of null (reading '0')"},
+		{"var o = {}; o.a.b()", "TypeError: Cannot read properties of undefined (reading 'b')"},
+		{"var n = null; n.y = 1", "TypeError: Cannot set properties of null (setting 'y')"},
+		{"'use strict'; var u; u['y'] = 1", "TypeError: Cannot set properties of undefined (setting 'y')"},
+		{"var x = 1; x()", "TypeError: x is not a function"},
+		{"var o = {}; o.f()", "TypeError: o.f is not a function"},
+		{"var o = {a: {}}; o.a[1]()", "TypeError: o.a[1] is not a function"},
+		{"var o = {}, k = 'f'; o?.[k]()", "TypeError: o?.[k] is not a function"},
+		{"(function() {})()()", "TypeError: (intermediate value)(...) is not a function"},
+		{"var s = 'abc'; s.length()", "TypeError: s.length is not a function"},
+		{"new 1", "TypeError: 1 is not a constructor"},
+		{"var o = {}; new o.x()", "TypeError: o.x is not a constructor"},
+		{"[1].forEach(42)", "TypeError: 42 is not a function"},
+		{"[1].map(Object.create(null))", "TypeError: [object Object] is not a function"},
+		{"[1].map({})", "TypeError: #<Object> is not a function"},
+		{"class Foo {}; [1].map(new Foo)", "TypeError: #<Foo> is not a function"},
+		{"[1].map(new Map)", "TypeError: #<Map> is not a function"},
+		{"[1].map([1, 2])", "TypeError: [object Array] is not a function"},
+		{"[1].map('abc')", "TypeError: abc is not a function"},
+		{"for (var x of 1) {}", "TypeError: 1 is not iterable"},
+		{"'a' in 1", "TypeError: Cannot use 'in' operator to search for 'a' in 1"},
+		{"({}) in 1", "TypeError: Cannot use 'in' operator to search for '#<Object>' in 1"},
+		{"Symbol('s') in 'str'", "TypeError: Cannot use 'in' operator to search for 'Symbol(s)' in str"},
+		{"({}) instanceof 1", "TypeError: Right-hand side of 'instanceof' is not an object"},
+		{"({}) instanceof {}", "TypeError: Right-hand side of 'instanceof' is not callable"},
+		{"null[{}] = 1", "TypeError: Cannot set properties of null (setting '#<Object>')"},
+		{"var n = null; n[Symbol('s')] = 1", "TypeError: Cannot set properties of null (setting 'Symbol(s)')"},
+		{"var n = null; n[Symbol('s')]", "TypeError: Cannot read properties of null (reading 'Symbol(s)')"},
+		{"[].reduce(Symbol())", "TypeError: Symbol() is not a function"},
+		{"[].reduce(undefined)", "TypeError: undefined is not a function"},
+		{"[].reduce((a, b) => a)", "TypeError: Reduce of empty array with no initial value"},
+		{"[].reduceRight((a, b) => a)", "TypeError: Reduce of empty array with no initial value"},
+		{"class A {}; A()", "TypeError: Class constructor A cannot be invoked without 'new'"},
+		{"var o = {C: class {}}; o.C()", "TypeError: Class constructor C cannot be invoked without 'new'"},
+		{"(class {})()", "TypeError: Class constructors cannot be invoked without 'new'"},
+		{"z; let z = 1", "ReferenceError: Cannot access 'z' before initialization"},
+		{"(function() { y = 1; let y; })()", "ReferenceError: Cannot access 'y' before initialization"},
+		{"(function() { const f = () => y; f(); const y = 1; })()", "ReferenceError: Cannot access 'y' before initialization"},
+		{"(function(a = b, b) {})()", "ReferenceError: Cannot access 'b' before initialization"},
+		{"new (class extends Object { constructor() { this.a = 1; super(); } })",
+			"ReferenceError: Must call super constructor in derived class before accessing 'this' or returning from derived constructor"},
+		{"const {a} = undefined", "TypeError: Cannot destructure property 'a' of 'undefined' as it is undefined."},
+		{"const {} = null", "TypeError: Cannot destructure 'null' as it is null."},
+		{"var o = null; const {...r} = o", "TypeError: Cannot destructure 'o' as it is null."},
+		{"var u; const {[1+1]: q} = u", "TypeError: Cannot destructure 'u' as it is undefined."},
+		{"var u; const {'s': q} = u", "TypeError: Cannot destructure property 's' of 'u' as it is undefined."},
+		{"var o = {}; var {0: x} = o.x", "TypeError: Cannot destructure property '0' of 'o.x' as it is undefined."},
+		{"function g() {} const {a} = g()", "TypeError: Cannot destructure property 'a' of 'g(...)' as it is undefined."},
+		{"var u, a; ({a} = u)", "TypeError: Cannot destructure property 'a' of 'u' as it is undefined."},
+		{"(function({a}) {})()", "TypeError: Cannot destructure property 'a' of 'undefined' as it is undefined."},
+		{"var u; const [a] = u", "TypeError: u is not iterable"},
+		{"var x = 1; var [a] = x", "TypeError: x is not iterable"},
+		{"var [a] = null", "TypeError: null is not iterable"},
+		{"for (var x of undefined);", "TypeError: undefined is not iterable"},
+	}
+	for _, test := range tests {
+		_, err := New().RunString(test.src)
+		ex, ok := err.(*Exception)
+		if !ok {
+			t.Fatalf("%s: unexpected error: %v", test.src, err)
+		}
+		if msg := ex.Value().String(); msg != test.msg {
+			t.Fatalf("%s: unexpected message: %q", test.src, msg)
+		}
+	}
+}
 func BenchmarkVmNOP2(b *testing.B) {
 	prg := []func(*vm){
 		//loadVal(0).exec,
@@ -232,6 +442,29 @@ func BenchmarkEmptyLoop(b *testing.B) {
 	}
 }
 
+func BenchmarkTryCatchLoop(b *testing.B) {
+	const SCRIPT = `
+	function f() {
+		var s = 0;
+		for (var i = 0; i < 100; i++) {
+			try {
+				s++;
+			} catch (e) {
+				s--;
+			}
+		}
+	}
+	f()
+	`
+	b.StopTimer()
+	vm := New()
+	prg := MustCompile("test.js", SCRIPT, false)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		vm.RunProgram(prg)
+	}
+}
+
 func BenchmarkVMAdd(b *testing.B) {
 	vm := &vm{}
 	vm.stack = append(vm.stack, nil, nil)
@@ -270,6 +503,41 @@ func BenchmarkFuncCall(b *testing.B) {
 	}
 }
 
+func BenchmarkIntrinsicCall(b *testing.B) {
+	const SCRIPT = `
+	(function() {
+		var x = 0;
+		for (var i = 0; i < 1000; i++) {
+			x = add(x, i);
+		}
+	})();
+	`
+	add := func(r *Runtime, args []Value) Value {
+		return valueInt(args[0].ToInteger() + args[1].ToInteger())
+	}
+	for _, intrinsic := range []bool{false, true} {
+		var opts []CompilerOption
+		name := "native"
+		if intrinsic {
+			opts = append(opts, WithIntrinsic("add", add))
+			name = "intrinsic"
+		}
+		b.Run(name, func(b *testing.B) {
+			vm := New()
+			vm.Set("add", func(call FunctionCall) Value {
+				return add(vm, call.Arguments)
+			})
+			prg := MustCompile("test.js", SCRIPT, false, opts...)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := vm.RunProgram(prg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkAssertInt(b *testing.B) {
 	v := intToValue(42)
 	for i := 0; i < b.N; i++ {
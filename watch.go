@@ -0,0 +1,118 @@
+package goja
+
+import (
+	"errors"
+
+	"github.com/dop251/goja/unistring"
+)
+
+// PropertyWrite describes a modification of a watched property, see Runtime.WatchProperty().
+type PropertyWrite struct {
+	Object *Object
+	Name   string
+	// Value is the new value. It's nil for the deletions and the definitions of accessor properties.
+	Value   Value
+	Deleted bool
+	// Stack is the call stack of the script making the modification, the most recent frame first.
+	Stack []StackFrame
+}
+
+type propertyWatch struct {
+	fn func(w *PropertyWrite)
+}
+
+// watchedObject wraps an ordinary object to report the modifications of the watched properties.
+type watchedObject struct {
+	objectImpl
+	r       *Runtime
+	watches map[unistring.String][]*propertyWatch
+}
+
+// WatchProperty sets a watchpoint on a property of an ordinary object (i.e. one created by an object literal,
+// Object.create() or Runtime.NewObject()): fn is called every time a script assigns, defines or deletes the
+// property, before the modification takes place. It is meant for debugging, e.g. to find out which part of a large
+// script overwrites config.timeout:
+//
+//	unwatch, err := vm.WatchProperty(config, "timeout", func(w *goja.PropertyWrite) {
+//	    log.Printf("timeout set to %v at %v", w.Value, w.Stack)
+//	})
+//
+// The call is synchronous, so execution is effectively paused while fn is running; fn may inspect the state of
+// the Runtime (but must not run any JavaScript code) or stop the execution with Runtime.Interrupt(). The
+// modifications made by the host while no script is running are not reported. A property can be watched by
+// several functions, the returned function removes the watchpoint.
+//
+// Watching a property disables some of the optimisations for the object.
+func (r *Runtime) WatchProperty(o *Object, name string, fn func(w *PropertyWrite)) (unwatch func(), err error) {
+	if o.runtime != r {
+		return nil, errors.New("the object belongs to a different runtime")
+	}
+	wo, ok := o.self.(*watchedObject)
+	if !ok {
+		if _, ok := o.self.(*baseObject); !ok || o == r.globalObject {
+			return nil, errors.New("only the properties of ordinary objects can be watched")
+		}
+		wo = &watchedObject{objectImpl: o.self, r: r, watches: make(map[unistring.String][]*propertyWatch)}
+		o.self = wo
+	}
+	n := unistring.NewFromString(name)
+	w := &propertyWatch{fn: fn}
+	wo.watches[n] = append(wo.watches[n], w)
+	return func() {
+		wo.unwatch(n, w)
+	}, nil
+}
+
+func (wo *watchedObject) unwatch(name unistring.String, w *propertyWatch) {
+	list := wo.watches[name]
+	for i, w1 := range list {
+		if w1 == w {
+			list = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) > 0 {
+		wo.watches[name] = list
+		return
+	}
+	delete(wo.watches, name)
+	if len(wo.watches) == 0 {
+		if obj := wo.objectImpl.(*baseObject).val; obj.self == wo {
+			obj.self = wo.objectImpl
+		}
+	}
+}
+
+func (wo *watchedObject) notify(name unistring.String, value Value, deleted bool) {
+	list := wo.watches[name]
+	if len(list) == 0 || len(wo.r.vm.callStack) == 0 {
+		return
+	}
+	w := &PropertyWrite{
+		Object:  wo.objectImpl.(*baseObject).val,
+		Name:    name.String(),
+		Value:   value,
+		Deleted: deleted,
+		Stack:   wo.r.CaptureCallStack(0, nil),
+	}
+	for _, watch := range list {
+		watch.fn(w)
+	}
+}
+
+func (wo *watchedObject) setOwnStr(p unistring.String, v Value, throw bool) bool {
+	wo.notify(p, v, false)
+	return wo.objectImpl.setOwnStr(p, v, throw)
+}
+
+func (wo *watchedObject) defineOwnPropertyStr(name unistring.String, desc PropertyDescriptor, throw bool) bool {
+	wo.notify(name, desc.Value, false)
+	return wo.objectImpl.defineOwnPropertyStr(name, desc, throw)
+}
+
+func (wo *watchedObject) deleteStr(name unistring.String, throw bool) bool {
+	if wo.objectImpl.hasOwnPropertyStr(name) {
+		wo.notify(name, nil, true)
+	}
+	return wo.objectImpl.deleteStr(name, throw)
+}
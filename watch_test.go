@@ -0,0 +1,86 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestWatchProperty(t *testing.T) {
+	r := New()
+	v, err := r.RunString(`var config = {timeout: 10, retries: 1}; config`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := v.(*Object)
+
+	var writes []*PropertyWrite
+	unwatch, err := r.WatchProperty(config, "timeout", func(w *PropertyWrite) {
+		if w.Object != config {
+			t.Error("Unexpected object")
+		}
+		writes = append(writes, w)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var indexWrites int
+	if _, err := r.WatchProperty(config, "0", func(w *PropertyWrite) { indexWrites++ }); err != nil {
+		t.Fatal(err)
+	}
+	_ = config.Set("timeout", 20) // not reported, no script is running
+
+	_, err = r.RunString(`
+	function tune(c) {
+		c.retries = 5;
+		c.timeout = 30;
+	}
+	tune(config);
+	config["timeout"] += 1;
+	config[0] = "x";
+	Object.defineProperty(config, "timeout", {get() { return 1; }});
+	delete config.timeout;
+	delete config.timeout;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(writes) != 4 {
+		t.Fatalf("Unexpected number of writes: %d", len(writes))
+	}
+	if w := writes[0]; w.Value.ToInteger() != 30 || w.Deleted || len(w.Stack) < 2 || w.Stack[0].FuncName() != "tune" {
+		t.Fatalf("Unexpected write: %+v", w)
+	}
+	if w := writes[1]; w.Value.ToInteger() != 31 || w.Stack[0].Position().Line != 7 {
+		t.Fatalf("Unexpected write: %+v", w)
+	}
+	if w := writes[2]; w.Value != nil || w.Deleted {
+		t.Fatalf("Unexpected write: %+v", w)
+	}
+	if w := writes[3]; !w.Deleted {
+		t.Fatalf("Unexpected write: %+v", w)
+	}
+	if indexWrites != 1 {
+		t.Fatalf("Unexpected number of index writes: %d", indexWrites)
+	}
+
+	unwatch()
+	if _, err := r.RunString(`config.timeout = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if len(writes) != 4 {
+		t.Fatal("The watchpoint was not removed")
+	}
+
+	interrupting, _ := r.WatchProperty(config, "retries", func(w *PropertyWrite) {
+		r.Interrupt("halt")
+	})
+	_, err = r.RunString(`config.retries = 0; for (;;) {}`)
+	if _, ok := err.(*InterruptedError); !ok {
+		t.Fatal(err)
+	}
+	interrupting()
+
+	arr, _ := r.RunString(`[]`)
+	if _, err := r.WatchProperty(arr.(*Object), "length", func(*PropertyWrite) {}); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
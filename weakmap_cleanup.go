@@ -0,0 +1,112 @@
+//go:build go1.24
+
+package goja
+
+import (
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// The entries of WeakMaps and WeakSets are stored in the keys (see weakMap), so they are collected together with
+// the keys. To release the entries of an unreachable WeakMap whose keys are still alive, every map keeps track of
+// its keys using weak pointers and has a token with a finalizer, which reports the map as dead. The entries of
+// dead maps are removed from the keys by the Runtime goroutine the next time a weak collection is modified.
+
+const weakMapCleanupSupported = true
+
+type weakMapKeys struct {
+	keys map[weak.Pointer[Object]]struct{}
+	// collected keys are pruned when the set grows to this size
+	pruneAt int
+}
+
+type weakMapTracker struct {
+	mu   sync.Mutex
+	dead []weakMap
+
+	maps map[weakMap]*weakMapKeys
+}
+
+type weakMapToken struct {
+	id      weakMap
+	tracker *weakMapTracker
+}
+
+func (t *weakMapToken) finalize() {
+	t.tracker.mu.Lock()
+	t.tracker.dead = append(t.tracker.dead, t.id)
+	t.tracker.mu.Unlock()
+}
+
+func (r *Runtime) getWeakMapTracker() *weakMapTracker {
+	t := r.weakMaps
+	if t == nil {
+		t = &weakMapTracker{
+			maps: make(map[weakMap]*weakMapKeys),
+		}
+		r.weakMaps = t
+	}
+	return t
+}
+
+func (r *Runtime) newWeakMap() (weakMap, *weakMapToken) {
+	t := r.getWeakMapTracker()
+	t.cleanup()
+	tok := &weakMapToken{
+		id:      weakMap(r.genId()),
+		tracker: t,
+	}
+	runtime.SetFinalizer(tok, (*weakMapToken).finalize)
+	return tok.id, tok
+}
+
+// cleanup removes the entries of the maps that have been reported as dead from the keys that are still alive.
+func (t *weakMapTracker) cleanup() {
+	t.mu.Lock()
+	dead := t.dead
+	t.dead = nil
+	t.mu.Unlock()
+	for _, wm := range dead {
+		if k := t.maps[wm]; k != nil {
+			for p := range k.keys {
+				if key := p.Value(); key != nil {
+					delete(key.weakRefs, wm)
+				}
+			}
+			delete(t.maps, wm)
+		}
+	}
+}
+
+func (wm weakMap) track(key *Object) {
+	t := key.runtime.getWeakMapTracker()
+	t.cleanup()
+	k := t.maps[wm]
+	if k == nil {
+		k = &weakMapKeys{
+			keys:    make(map[weak.Pointer[Object]]struct{}),
+			pruneAt: 16,
+		}
+		t.maps[wm] = k
+	}
+	k.keys[weak.Make(key)] = struct{}{}
+	if len(k.keys) >= k.pruneAt {
+		for p := range k.keys {
+			if p.Value() == nil {
+				delete(k.keys, p)
+			}
+		}
+		if k.pruneAt < 2*len(k.keys) {
+			k.pruneAt = 2 * len(k.keys)
+		}
+	}
+}
+
+func (wm weakMap) untrack(key *Object) {
+	if t := key.runtime.weakMaps; t != nil {
+		if k := t.maps[wm]; k != nil {
+			delete(k.keys, weak.Make(key))
+		}
+	}
+}
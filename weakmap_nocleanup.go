@@ -0,0 +1,20 @@
+//go:build !go1.24
+
+package goja
+
+// Without weak pointers the keys of a WeakMap cannot be tracked, so the entries of an unreachable WeakMap are
+// only released together with the keys.
+
+const weakMapCleanupSupported = false
+
+type weakMapTracker struct{}
+
+type weakMapToken struct{}
+
+func (r *Runtime) newWeakMap() (weakMap, *weakMapToken) {
+	return weakMap(r.genId()), nil
+}
+
+func (wm weakMap) track(*Object) {}
+
+func (wm weakMap) untrack(*Object) {}